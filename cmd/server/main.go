@@ -45,6 +45,35 @@ func main() {
 		}
 	}()
 
+	// Background jobs stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Drop idempotency keys once they can no longer be replayed
+	go runPeriodically(jobsCtx, time.Hour, "expired idempotency keys purged", func(ctx context.Context) (int64, error) {
+		return appContainer.GetIdempotencyUseCase().PurgeExpired(ctx)
+	})
+
+	// Give the stock of expired reservations back
+	sweepInterval := time.Duration(config.Config.Business.ReservationSweepSeconds) * time.Second
+	if sweepInterval <= 0 {
+		sweepInterval = time.Minute
+	}
+	go runPeriodically(jobsCtx, sweepInterval, "expired reservations released", func(ctx context.Context) (int64, error) {
+		expired, err := appContainer.GetReservationUseCase().ExpireStale(ctx)
+		return int64(expired), err
+	})
+
+	// Apply scheduled price changes once they take effect
+	priceInterval := time.Duration(config.Config.Business.PriceScheduleSeconds) * time.Second
+	if priceInterval <= 0 {
+		priceInterval = time.Minute
+	}
+	go runPeriodically(jobsCtx, priceInterval, "scheduled prices activated", func(ctx context.Context) (int64, error) {
+		activated, err := appContainer.GetPricingUseCase().ActivateScheduledPrices(ctx)
+		return int64(activated), err
+	})
+
 	// Initialize HTTP router with dependency injection
 	httpRouter := httpInterface.NewRouter(appContainer)
 	router := httpRouter.SetupRoutes()
@@ -87,6 +116,27 @@ func main() {
 	log.Println("Server exited gracefully")
 }
 
+// runPeriodically runs job every interval until ctx is cancelled, logging
+// how many records each run handled
+func runPeriodically(ctx context.Context, interval time.Duration, what string, job func(ctx context.Context) (int64, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := job(ctx)
+			if err != nil {
+				log.Printf("Background job failed (%s): %v", what, err)
+			} else if count > 0 {
+				log.Printf("%d %s", count, what)
+			}
+		}
+	}
+}
+
 // Health check for the application
 func init() {
 	// Set log format
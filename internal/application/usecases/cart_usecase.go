@@ -0,0 +1,219 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// CartUseCase encapsulates business logic for shopping carts
+type CartUseCase struct {
+	cartRepo        repositories.CartRepository
+	customerUseCase *CustomerUseCase
+	productUseCase  *ProductUseCase
+	orderUseCase    *OrderUseCase
+	pricingUseCase  *PricingUseCase
+	unitOfWork      repositories.UnitOfWork
+}
+
+// NewCartUseCase creates a new cart use case
+func NewCartUseCase(
+	cartRepo repositories.CartRepository,
+	customerUseCase *CustomerUseCase,
+	productUseCase *ProductUseCase,
+	orderUseCase *OrderUseCase,
+	pricingUseCase *PricingUseCase,
+	unitOfWork repositories.UnitOfWork,
+) *CartUseCase {
+	return &CartUseCase{
+		cartRepo:        cartRepo,
+		customerUseCase: customerUseCase,
+		productUseCase:  productUseCase,
+		orderUseCase:    orderUseCase,
+		pricingUseCase:  pricingUseCase,
+		unitOfWork:      unitOfWork,
+	}
+}
+
+// AddCartItemRequest represents the request to put a product in the cart
+type AddCartItemRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// UpdateCartItemRequest represents the request to change the quantity of a cart item
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+// CheckoutRequest represents the request to turn the cart into an order
+type CheckoutRequest struct {
+	StoreCredit entities.Money `json:"store_credit"`
+	Currency    string         `json:"currency,omitempty"`
+
+	// ReservationIDs are stock holds covering some of the cart
+	ReservationIDs []string `json:"reservation_ids,omitempty"`
+
+	// LocationID and ShipTo choose where the order ships from, as for PlaceOrderRequest
+	LocationID string             `json:"location_id,omitempty"`
+	ShipTo     *entities.GeoPoint `json:"ship_to,omitempty"`
+
+	// CouponCode redeems a coupon, as for PlaceOrderRequest
+	CouponCode string `json:"coupon_code,omitempty"`
+}
+
+// GetCart returns the customer's cart priced as an order placed now would be
+func (uc *CartUseCase) GetCart(ctx context.Context, customerID string) (*entities.Cart, error) {
+	customer, err := uc.customerUseCase.GetCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := uc.cartRepo.GetItems(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[*entities.Product]int, len(items))
+	for _, item := range items {
+		if item.Product != nil {
+			quantities[item.Product] = item.Quantity
+		}
+	}
+	quotes, err := uc.pricingUseCase.quote(ctx, customer.PriceList, quantities, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.NewCart(customerID, items, quotes)
+}
+
+// AddItem puts a product in the cart; adding a product already in the cart increases its quantity
+// Stock is not reserved here, only at checkout
+func (uc *CartUseCase) AddItem(ctx context.Context, customerID string, req *AddCartItemRequest) (*entities.Cart, error) {
+	if _, err := uc.customerUseCase.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.productUseCase.GetSellableProduct(ctx, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		item, err := uc.cartRepo.GetItem(ctx, customerID, req.ProductID)
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			item = &entities.CartItem{
+				CustomerID: customerID,
+				ProductID:  req.ProductID,
+				CreatedAt:  time.Now().UTC(),
+			}
+		case err != nil:
+			return err
+		}
+		item.Quantity += req.Quantity
+
+		return uc.saveItem(ctx, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(ctx, customerID)
+}
+
+// UpdateItem sets the quantity of a product already in the cart
+func (uc *CartUseCase) UpdateItem(ctx context.Context, customerID, productID string, req *UpdateCartItemRequest) (*entities.Cart, error) {
+	item, err := uc.cartRepo.GetItem(ctx, customerID, productID)
+	if err != nil {
+		return nil, err
+	}
+	item.Quantity = req.Quantity
+
+	if err := uc.saveItem(ctx, item); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(ctx, customerID)
+}
+
+// RemoveItem takes a product out of the cart
+func (uc *CartUseCase) RemoveItem(ctx context.Context, customerID, productID string) (*entities.Cart, error) {
+	if err := uc.cartRepo.RemoveItem(ctx, customerID, productID); err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(ctx, customerID)
+}
+
+// ClearCart empties the customer's cart
+func (uc *CartUseCase) ClearCart(ctx context.Context, customerID string) error {
+	if _, err := uc.customerUseCase.GetCustomer(ctx, customerID); err != nil {
+		return err
+	}
+
+	return uc.cartRepo.Clear(ctx, customerID)
+}
+
+// Checkout places one order for everything in the cart and empties it
+// Stock for every line is validated and reserved together, the cooldown is
+// applied once, and a failure leaves both stock and the cart untouched
+func (uc *CartUseCase) Checkout(ctx context.Context, customerID string, req *CheckoutRequest) (*OrderResponse, error) {
+	if req == nil {
+		req = &CheckoutRequest{}
+	}
+
+	if _, err := uc.customerUseCase.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	var response *OrderResponse
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		items, err := uc.cartRepo.GetItems(ctx, customerID)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("%w: customer %s has nothing to check out", entities.ErrCartEmpty, customerID)
+		}
+
+		orderReq := &PlaceOrderRequest{
+			CustomerID:     customerID,
+			Items:          make([]OrderItemRequest, len(items)),
+			StoreCredit:    req.StoreCredit,
+			Currency:       req.Currency,
+			ReservationIDs: req.ReservationIDs,
+			LocationID:     req.LocationID,
+			ShipTo:         req.ShipTo,
+			CouponCode:     req.CouponCode,
+		}
+		for i, item := range items {
+			orderReq.Items[i] = OrderItemRequest{ProductID: item.ProductID, Quantity: item.Quantity}
+		}
+
+		response, err = uc.orderUseCase.PlaceOrder(ctx, orderReq)
+		if err != nil {
+			return err
+		}
+
+		return uc.cartRepo.Clear(ctx, customerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// saveItem validates and stores a cart item
+func (uc *CartUseCase) saveItem(ctx context.Context, item *entities.CartItem) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	return uc.cartRepo.SaveItem(ctx, item)
+}
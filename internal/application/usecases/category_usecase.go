@@ -0,0 +1,173 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// CategoryUseCase encapsulates business logic for the product category tree
+type CategoryUseCase struct {
+	categoryRepo repositories.CategoryRepository
+	unitOfWork   repositories.UnitOfWork
+	idGenerator  repositories.IDGenerator
+}
+
+// NewCategoryUseCase creates a new category use case
+func NewCategoryUseCase(
+	categoryRepo repositories.CategoryRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+) *CategoryUseCase {
+	return &CategoryUseCase{
+		categoryRepo: categoryRepo,
+		unitOfWork:   unitOfWork,
+		idGenerator:  idGenerator,
+	}
+}
+
+// CreateCategoryRequest represents the request to add a category
+type CreateCategoryRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID string `json:"parent_id,omitempty"` // empty for a top-level category
+}
+
+// UpdateCategoryRequest represents the request to rename or move a category
+// An empty ParentID moves the category to the top level
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name,omitempty"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+// CreateCategory adds a category, under ParentID when one is given
+func (uc *CategoryUseCase) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*entities.Category, error) {
+	parent, err := uc.parentCategory(ctx, req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uc.idGenerator.NewID(ctx, entities.CategoryIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate category ID: %w", err)
+	}
+
+	category := &entities.Category{
+		ID:        id,
+		Name:      strings.TrimSpace(req.Name),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := category.SetParent(parent); err != nil {
+		return nil, err
+	}
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.categoryRepo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetCategory retrieves a category by ID
+func (uc *CategoryUseCase) GetCategory(ctx context.Context, id string) (*entities.Category, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return category, nil
+}
+
+// GetCategories lists the whole category tree, parents before their children
+func (uc *CategoryUseCase) GetCategories(ctx context.Context) ([]*entities.Category, error) {
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// UpdateCategory renames a category and/or moves it, with its subcategories, under another parent
+// Products stay in their categories, so a move carries them along too
+func (uc *CategoryUseCase) UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest) (*entities.Category, error) {
+	var category *entities.Category
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if category, err = uc.GetCategory(ctx, id); err != nil {
+			return err
+		}
+
+		oldPath := category.Path
+		if req.Name != nil {
+			category.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.ParentID != nil {
+			parent, err := uc.parentCategory(ctx, *req.ParentID)
+			if err != nil {
+				return err
+			}
+			if err := category.SetParent(parent); err != nil {
+				return err
+			}
+			if err := uc.checkDepth(ctx, category, oldPath); err != nil {
+				return err
+			}
+		}
+		if err := category.Validate(); err != nil {
+			return err
+		}
+
+		return uc.categoryRepo.Update(ctx, category, oldPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// parentCategory resolves the parent a category is placed under; an empty ID means none
+func (uc *CategoryUseCase) parentCategory(ctx context.Context, id string) (*entities.Category, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	parent, err := uc.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent category: %w", err)
+	}
+	return parent, nil
+}
+
+// checkDepth rejects a move that would push the deepest subcategory below MaxCategoryDepth
+func (uc *CategoryUseCase) checkDepth(ctx context.Context, category *entities.Category, oldPath string) error {
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	shift := category.Depth() - strings.Count(oldPath, "/") + 1
+	for _, other := range categories {
+		if strings.HasPrefix(other.Path, oldPath) && other.Depth()+shift > entities.MaxCategoryDepth {
+			return fmt.Errorf("%w: categories can be at most %d levels deep", entities.ErrInvalidCategory, entities.MaxCategoryDepth)
+		}
+	}
+	return nil
+}
+
+// categoryTree indexes categories by ID for walking up the tree
+func categoryTree(categories []*entities.Category) map[string]*entities.Category {
+	tree := make(map[string]*entities.Category, len(categories))
+	for _, category := range categories {
+		tree[category.ID] = category
+	}
+	return tree
+}
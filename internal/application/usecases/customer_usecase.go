@@ -2,9 +2,7 @@ package usecases
 
 import (
 	"context"
-	"crypto/rand"
 	"fmt"
-	"math/big"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -15,6 +13,7 @@ import (
 type CustomerUseCase struct {
 	customerRepo   repositories.CustomerRepository
 	cooldownRepo   repositories.CustomerCooldownRepository
+	idGenerator    repositories.IDGenerator
 	cooldownPeriod time.Duration
 }
 
@@ -22,11 +21,13 @@ type CustomerUseCase struct {
 func NewCustomerUseCase(
 	customerRepo repositories.CustomerRepository,
 	cooldownRepo repositories.CustomerCooldownRepository,
+	idGenerator repositories.IDGenerator,
 	cooldownPeriodMinutes int,
 ) *CustomerUseCase {
 	return &CustomerUseCase{
 		customerRepo:   customerRepo,
 		cooldownRepo:   cooldownRepo,
+		idGenerator:    idGenerator,
 		cooldownPeriod: time.Duration(cooldownPeriodMinutes) * time.Minute,
 	}
 }
@@ -36,6 +37,23 @@ type CreateCustomerRequest struct {
 	Name  string `json:"name" binding:"required"`
 	Email string `json:"email" binding:"required,email"`
 	Phone string `json:"phone" binding:"required"`
+
+	// PriceList is the set of prices the customer buys at; it defaults to retail
+	PriceList entities.PriceList `json:"price_list,omitempty" binding:"omitempty,oneof=retail wholesale"`
+
+	// TaxJurisdiction is where the customer's orders are taxed, such as IN-KA;
+	// it defaults to the business's own jurisdiction
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
+}
+
+// UpdateCustomerRequest represents the request to update a customer
+type UpdateCustomerRequest struct {
+	Name      string             `json:"name,omitempty"`
+	Email     string             `json:"email,omitempty" binding:"omitempty,email"`
+	Phone     string             `json:"phone,omitempty"`
+	PriceList entities.PriceList `json:"price_list,omitempty" binding:"omitempty,oneof=retail wholesale"`
+
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
 }
 
 // CreateCustomer creates a new customer
@@ -47,7 +65,7 @@ func (uc *CustomerUseCase) CreateCustomer(ctx context.Context, req *CreateCustom
 	}
 
 	// Generate unique customer ID
-	id, err := generateCustomerID()
+	id, err := uc.idGenerator.NewID(ctx, entities.CustomerIDPrefix)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate customer ID: %w", err)
 	}
@@ -58,9 +76,14 @@ func (uc *CustomerUseCase) CreateCustomer(ctx context.Context, req *CreateCustom
 		Name:      req.Name,
 		Email:     req.Email,
 		Phone:     req.Phone,
+		PriceList: entities.PriceListRetail,
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
 	}
+	if req.PriceList != "" {
+		customer.PriceList = req.PriceList
+	}
+	customer.TaxJurisdiction = entities.NormalizeJurisdiction(req.TaxJurisdiction)
 
 	// Validate business rules
 	if err := customer.Validate(); err != nil {
@@ -89,6 +112,52 @@ func (uc *CustomerUseCase) GetCustomer(ctx context.Context, id string) (*entitie
 	return customer, nil
 }
 
+// UpdateCustomer updates a customer's contact details
+// expectedVersion is the version the caller last read; a stale version is rejected
+func (uc *CustomerUseCase) UpdateCustomer(ctx context.Context, id string, expectedVersion int, req *UpdateCustomerRequest) (*entities.Customer, error) {
+	if id == "" {
+		return nil, fmt.Errorf("customer ID is required")
+	}
+
+	customer, err := uc.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if customer.Version != expectedVersion {
+		return nil, fmt.Errorf("customer with ID %s is at version %d, not %d: %w",
+			id, customer.Version, expectedVersion, repositories.ErrVersionConflict)
+	}
+
+	// Check the new email is not taken by someone else
+	if req.Email != "" && req.Email != customer.Email {
+		existingCustomer, err := uc.customerRepo.GetByEmail(ctx, req.Email)
+		if err == nil && existingCustomer != nil {
+			return nil, fmt.Errorf("customer with email %s already exists", req.Email)
+		}
+	}
+
+	if err := customer.UpdateInfo(req.Name, req.Email, req.Phone); err != nil {
+		return nil, fmt.Errorf("failed to update customer: %w", err)
+	}
+	if req.PriceList != "" {
+		customer.PriceList = req.PriceList
+	}
+	if req.TaxJurisdiction != "" {
+		customer.TaxJurisdiction = entities.NormalizeJurisdiction(req.TaxJurisdiction)
+	}
+
+	if err := customer.Validate(); err != nil {
+		return nil, fmt.Errorf("customer validation failed: %w", err)
+	}
+
+	if err := uc.customerRepo.Update(ctx, customer); err != nil {
+		return nil, fmt.Errorf("failed to update customer: %w", err)
+	}
+
+	return customer, nil
+}
+
 // GetAllCustomers retrieves all customers with pagination
 func (uc *CustomerUseCase) GetAllCustomers(ctx context.Context, limit, offset int) ([]*entities.Customer, error) {
 	if limit <= 0 {
@@ -171,6 +240,19 @@ func (uc *CustomerUseCase) UpdateCustomerCooldown(ctx context.Context, customerI
 	return nil
 }
 
+// ClearCustomerCooldown removes the cooldown so the customer can order again immediately
+func (uc *CustomerUseCase) ClearCustomerCooldown(ctx context.Context, customerID string) error {
+	if customerID == "" {
+		return fmt.Errorf("customer ID is required")
+	}
+
+	if err := uc.cooldownRepo.Delete(ctx, customerID); err != nil {
+		return fmt.Errorf("failed to clear cooldown: %w", err)
+	}
+
+	return nil
+}
+
 // SearchCustomers searches customers by name
 func (uc *CustomerUseCase) SearchCustomers(ctx context.Context, name string) ([]*entities.Customer, error) {
 	if name == "" {
@@ -198,19 +280,3 @@ func (uc *CustomerUseCase) GetRecentCustomers(ctx context.Context, days int) ([]
 
 	return customers, nil
 }
-
-// generateCustomerID generates a unique customer ID in format CUST12345
-func generateCustomerID() (string, error) {
-	max := big.NewInt(99999)
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		return "", err
-	}
-
-	number := n.Int64() + 10000
-	if number > 99999 {
-		number = number%90000 + 10000
-	}
-
-	return fmt.Sprintf("CUST%05d", number), nil
-}
@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// ExchangeRateUseCase encapsulates business logic for exchange rates
+// Prices, orders and the ledger are kept in the base currency (the configured
+// default currency); rates convert from it to the currencies customers pay in
+type ExchangeRateUseCase struct {
+	exchangeRateRepo repositories.ExchangeRateRepository
+}
+
+// NewExchangeRateUseCase creates a new exchange rate use case
+func NewExchangeRateUseCase(exchangeRateRepo repositories.ExchangeRateRepository) *ExchangeRateUseCase {
+	return &ExchangeRateUseCase{
+		exchangeRateRepo: exchangeRateRepo,
+	}
+}
+
+// CreateExchangeRateRequest represents the request to publish a new exchange rate
+type CreateExchangeRateRequest struct {
+	Currency string        `json:"currency" binding:"required"`
+	Rate     entities.Rate `json:"rate"`
+
+	// EffectiveFrom defaults to now; a future date schedules the rate
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+}
+
+// BaseCurrency returns the currency prices and the ledger are kept in
+func (uc *ExchangeRateUseCase) BaseCurrency() string {
+	return entities.DefaultCurrency()
+}
+
+// CreateRate publishes a rate from the base currency that applies from its
+// effective date until the next rate for the same currency
+func (uc *ExchangeRateUseCase) CreateRate(ctx context.Context, req *CreateExchangeRateRequest) (*entities.ExchangeRate, error) {
+	rate := &entities.ExchangeRate{
+		BaseCurrency:  uc.BaseCurrency(),
+		Currency:      strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Rate:          req.Rate,
+		EffectiveFrom: time.Now().UTC(),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if req.EffectiveFrom != nil {
+		rate.EffectiveFrom = req.EffectiveFrom.UTC()
+	}
+
+	if err := rate.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Two rates taking effect at the same instant would make conversions ambiguous
+	current, err := uc.exchangeRateRepo.GetEffective(ctx, rate.Currency, rate.EffectiveFrom)
+	switch {
+	case err == nil && current.EffectiveFrom.Equal(rate.EffectiveFrom):
+		return nil, fmt.Errorf("%w: a rate for %s already takes effect at %s",
+			entities.ErrInvalidExchangeRate, rate.Currency, rate.EffectiveFrom.Format(time.RFC3339))
+	case err != nil && !errors.Is(err, repositories.ErrNotFound):
+		return nil, err
+	}
+
+	if err := uc.exchangeRateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// GetRates lists the rates for a currency, or for every currency when it is empty
+func (uc *ExchangeRateUseCase) GetRates(ctx context.Context, currency string) ([]*entities.ExchangeRate, error) {
+	if currency == "" {
+		return uc.exchangeRateRepo.GetAll(ctx)
+	}
+
+	return uc.exchangeRateRepo.GetByCurrency(ctx, strings.ToUpper(strings.TrimSpace(currency)))
+}
+
+// GetEffectiveRate returns the rate from the base currency to currency in effect at the given time
+// The base currency converts to itself at the identity rate
+func (uc *ExchangeRateUseCase) GetEffectiveRate(ctx context.Context, currency string, at time.Time) (*entities.ExchangeRate, error) {
+	base := uc.BaseCurrency()
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == base {
+		return &entities.ExchangeRate{BaseCurrency: base, Currency: base, Rate: entities.IdentityRate}, nil
+	}
+
+	rate, err := uc.exchangeRateRepo.GetEffective(ctx, currency, at.UTC())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s at %s", entities.ErrNoExchangeRate, currency, at.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// revenueConverter converts revenue in the base currency into a reporting
+// currency, each part at the rate that was in effect when it was booked
+type revenueConverter struct {
+	currency string
+	rates    map[uint]*entities.ExchangeRate
+}
+
+// newRevenueConverter loads every rate for currency
+func (uc *ExchangeRateUseCase) newRevenueConverter(ctx context.Context, currency string) (*revenueConverter, error) {
+	rates, err := uc.exchangeRateRepo.GetByCurrency(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	converter := &revenueConverter{currency: currency, rates: make(map[uint]*entities.ExchangeRate, len(rates))}
+	for _, rate := range rates {
+		converter.rates[rate.ID] = rate
+	}
+	return converter, nil
+}
+
+// convert returns the gross and refunded amounts of revenue in the reporting currency
+func (c *revenueConverter) convert(revenue *entities.RevenueAtRate) (gross, refunded entities.Money, err error) {
+	rate, ok := c.rates[revenue.RateID]
+	if !ok {
+		return entities.Money{}, entities.Money{}, fmt.Errorf("%w: %s for revenue booked before the first %s rate",
+			entities.ErrNoExchangeRate, c.currency, c.currency)
+	}
+
+	if gross, err = rate.Convert(revenue.Gross); err != nil {
+		return entities.Money{}, entities.Money{}, err
+	}
+	if refunded, err = rate.Convert(revenue.Refunded); err != nil {
+		return entities.Money{}, entities.Money{}, err
+	}
+	return gross, refunded, nil
+}
+
+// convertCost returns the cost of goods of revenue in the reporting currency
+func (c *revenueConverter) convertCost(revenue *entities.RevenueAtRate) (entities.Money, error) {
+	rate, ok := c.rates[revenue.RateID]
+	if !ok {
+		return entities.Money{}, fmt.Errorf("%w: %s for revenue booked before the first %s rate",
+			entities.ErrNoExchangeRate, c.currency, c.currency)
+	}
+
+	return rate.Convert(revenue.Cost)
+}
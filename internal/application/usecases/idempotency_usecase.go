@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// IdempotencyUseCase encapsulates business logic for Idempotency-Key handling
+// The first request with a key claims it; its response is stored and replayed
+// for retries of the same request until the key expires
+type IdempotencyUseCase struct {
+	idempotencyRepo repositories.IdempotencyRepository
+	ttl             time.Duration
+}
+
+// NewIdempotencyUseCase creates a new idempotency use case
+// A ttlHours of 0 or less uses entities.DefaultIdempotencyKeyTTL
+func NewIdempotencyUseCase(idempotencyRepo repositories.IdempotencyRepository, ttlHours int) *IdempotencyUseCase {
+	ttl := time.Duration(ttlHours) * time.Hour
+	if ttl <= 0 {
+		ttl = entities.DefaultIdempotencyKeyTTL
+	}
+
+	return &IdempotencyUseCase{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+	}
+}
+
+// Fingerprint identifies a request by its method, path and body
+func (uc *IdempotencyUseCase) Fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Begin claims key for the request identified by fingerprint
+// It returns nil when the caller should handle the request and then call
+// Complete or Release, or the completed record when the response should be
+// replayed instead. A key sent with a different request returns
+// ErrIdempotencyKeyReused; a key whose first request is still being handled
+// returns ErrIdempotencyKeyInProgress.
+func (uc *IdempotencyUseCase) Begin(ctx context.Context, key, fingerprint string) (*entities.IdempotencyRecord, error) {
+	record, err := entities.NewIdempotencyRecord(key, fingerprint, uc.ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expired record may be taken over, so a second attempt is enough
+	for attempt := 0; attempt < 2; attempt++ {
+		err = uc.idempotencyRepo.Create(ctx, record)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, repositories.ErrAlreadyExists) {
+			return nil, err
+		}
+
+		existing, err := uc.idempotencyRepo.GetByKey(ctx, key)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue // released between the insert and the read
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Another request may take the expired key over first; then nothing is
+		// deleted and the next attempt finds its claim
+		if now := time.Now().UTC(); existing.IsExpired(now) {
+			if err := uc.idempotencyRepo.DeleteIfExpired(ctx, key, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case existing.Fingerprint != fingerprint:
+			return nil, entities.ErrIdempotencyKeyReused
+		case !existing.IsCompleted():
+			return nil, entities.ErrIdempotencyKeyInProgress
+		default:
+			return existing, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to claim idempotency key %s: %w", key, entities.ErrIdempotencyKeyInProgress)
+}
+
+// Complete stores the response to the request that claimed key
+func (uc *IdempotencyUseCase) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	record := &entities.IdempotencyRecord{Key: key}
+	record.Complete(statusCode, contentType, body)
+	return uc.idempotencyRepo.Update(ctx, record)
+}
+
+// Release gives up a claimed key without storing a response, so that a
+// request that failed unexpectedly can be retried with the same key
+func (uc *IdempotencyUseCase) Release(ctx context.Context, key string) error {
+	return uc.idempotencyRepo.Delete(ctx, key)
+}
+
+// PurgeExpired removes keys that can no longer be replayed and returns how many were removed
+func (uc *IdempotencyUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	return uc.idempotencyRepo.DeleteExpired(ctx, time.Now().UTC())
+}
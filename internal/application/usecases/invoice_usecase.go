@@ -0,0 +1,71 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// InvoiceUseCase encapsulates business logic for invoices
+// Every order is invoiced when it is placed, in the same unit of work, so
+// invoice numbers run without gaps and the invoice records the order as placed
+type InvoiceUseCase struct {
+	invoiceRepo repositories.InvoiceRepository
+	orderRepo   repositories.OrderRepository
+
+	seller entities.InvoiceParty
+	prefix string
+}
+
+// NewInvoiceUseCase creates a new invoice use case
+// seller is the business named on invoices and prefix goes in front of
+// invoice numbers; an empty prefix uses entities.DefaultInvoicePrefix
+func NewInvoiceUseCase(
+	invoiceRepo repositories.InvoiceRepository,
+	orderRepo repositories.OrderRepository,
+	seller entities.InvoiceParty,
+	prefix string,
+) *InvoiceUseCase {
+	if prefix == "" {
+		prefix = entities.DefaultInvoicePrefix
+	}
+
+	return &InvoiceUseCase{
+		invoiceRepo: invoiceRepo,
+		orderRepo:   orderRepo,
+		seller:      seller,
+		prefix:      prefix,
+	}
+}
+
+// GetInvoice gets the invoice issued for an order
+func (uc *InvoiceUseCase) GetInvoice(ctx context.Context, orderID string) (*entities.Invoice, error) {
+	if _, err := uc.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	invoice, err := uc.invoiceRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// draftInvoice builds the invoice for an order that is about to be placed
+func (uc *InvoiceUseCase) draftInvoice(order *entities.Order, products map[string]*entities.Product) (*entities.Invoice, error) {
+	return entities.NewInvoice(order, uc.seller, products)
+}
+
+// issueInvoice numbers and stores a drafted invoice; it must run inside the
+// order's unit of work, so a failed order does not use up a number
+func (uc *InvoiceUseCase) issueInvoice(ctx context.Context, invoice *entities.Invoice) error {
+	sequence, err := uc.invoiceRepo.NextSequence(ctx)
+	if err != nil {
+		return err
+	}
+	invoice.Issue(uc.prefix, sequence)
+
+	return uc.invoiceRepo.Create(ctx, invoice)
+}
@@ -0,0 +1,307 @@
+package usecases
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// FulfilmentStrategy picks the location an order ships from when the order does not name one
+type FulfilmentStrategy string
+
+const (
+	// FulfilmentMostStock ships from the location holding the most units of the ordered products
+	FulfilmentMostStock FulfilmentStrategy = "most_stock"
+
+	// FulfilmentNearest ships from the location closest to the delivery address,
+	// falling back to most stock when the order has no address
+	FulfilmentNearest FulfilmentStrategy = "nearest"
+)
+
+// LocationUseCase encapsulates business logic for stores, warehouses and the stock they hold
+// Only locations that can ship a whole order are considered; orders are not split
+type LocationUseCase struct {
+	locationRepo repositories.LocationRepository
+	unitOfWork   repositories.UnitOfWork
+	idGenerator  repositories.IDGenerator
+
+	strategy FulfilmentStrategy
+}
+
+// NewLocationUseCase creates a new location use case
+// An empty strategy uses FulfilmentMostStock
+func NewLocationUseCase(
+	locationRepo repositories.LocationRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+	strategy FulfilmentStrategy,
+) *LocationUseCase {
+	if strategy == "" {
+		strategy = FulfilmentMostStock
+	}
+
+	return &LocationUseCase{
+		locationRepo: locationRepo,
+		unitOfWork:   unitOfWork,
+		idGenerator:  idGenerator,
+		strategy:     strategy,
+	}
+}
+
+// CreateLocationRequest represents the request to add a store or warehouse
+type CreateLocationRequest struct {
+	Code     string                `json:"code" binding:"required"`
+	Name     string                `json:"name" binding:"required"`
+	Type     entities.LocationType `json:"type" binding:"required"`
+	Position *entities.GeoPoint    `json:"position,omitempty"`
+}
+
+// CreateLocation adds a store or warehouse; it starts without stock
+func (uc *LocationUseCase) CreateLocation(ctx context.Context, req *CreateLocationRequest) (*entities.Location, error) {
+	id, err := uc.idGenerator.NewID(ctx, entities.LocationIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate location ID: %w", err)
+	}
+
+	location := &entities.Location{
+		ID:        id,
+		Code:      strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:      strings.TrimSpace(req.Name),
+		Type:      req.Type,
+		Position:  req.Position,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := location.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.locationRepo.Create(ctx, location); err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// GetLocation retrieves a location by ID
+func (uc *LocationUseCase) GetLocation(ctx context.Context, id string) (*entities.Location, error) {
+	location, err := uc.locationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	return location, nil
+}
+
+// GetLocations lists all locations, the default first
+func (uc *LocationUseCase) GetLocations(ctx context.Context) ([]*entities.Location, error) {
+	locations, err := uc.locationRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+
+	return locations, nil
+}
+
+// GetLocationStock lists the stock of every product held at a location
+func (uc *LocationUseCase) GetLocationStock(ctx context.Context, id string) ([]*entities.StockLevel, error) {
+	if _, err := uc.GetLocation(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return uc.locationRepo.GetLocationStock(ctx, id)
+}
+
+// EnsureDefaultLocation creates the default location if there is none and
+// puts any stock not yet held at a location there
+// It runs at startup, so databases from before stock was tracked per location keep working
+func (uc *LocationUseCase) EnsureDefaultLocation(ctx context.Context) error {
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		location, err := uc.locationRepo.GetDefault(ctx)
+		if errors.Is(err, repositories.ErrNotFound) {
+			location, err = uc.createDefaultLocation(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		unlocated, err := uc.locationRepo.UnlocatedStock(ctx)
+		if err != nil {
+			return err
+		}
+		for _, level := range unlocated {
+			if err := uc.locationRepo.AdjustStock(ctx, location.ID, level.ProductID, level.Quantity); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// createDefaultLocation adds the location that takes stock naming no location
+func (uc *LocationUseCase) createDefaultLocation(ctx context.Context) (*entities.Location, error) {
+	id, err := uc.idGenerator.NewID(ctx, entities.LocationIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate location ID: %w", err)
+	}
+
+	location := &entities.Location{
+		ID:        id,
+		Code:      entities.DefaultLocationCode,
+		Name:      "Main warehouse",
+		Type:      entities.LocationTypeWarehouse,
+		IsDefault: true,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := uc.locationRepo.Create(ctx, location); err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// fulfilmentLocation picks the location an order's items ship from
+// An order confirming holds ships from the location they were placed at. A
+// requested location must have every item unheld in full, less what the
+// order's holds set aside there; otherwise the configured strategy chooses
+// among the locations that do
+func (uc *LocationUseCase) fulfilmentLocation(ctx context.Context, items []*saleItem, reservations []*entities.StockReservation, locationID string, shipTo *entities.GeoPoint) (*entities.Location, error) {
+	if shipTo != nil {
+		if err := shipTo.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	heldAt := ""
+	for _, reservation := range reservations {
+		switch {
+		case reservation.LocationID == "":
+			// Held before locations were recorded; taken wherever the order ships from
+		case heldAt != "" && reservation.LocationID != heldAt:
+			return nil, fmt.Errorf("%w: holds at locations %s and %s cannot ship as one order",
+				entities.ErrInvalidReservation, heldAt, reservation.LocationID)
+		default:
+			heldAt = reservation.LocationID
+		}
+	}
+	if heldAt != "" {
+		if locationID != "" && locationID != heldAt {
+			return nil, fmt.Errorf("%w: the order's holds are at location %s, not %s",
+				entities.ErrInvalidReservation, heldAt, locationID)
+		}
+		locationID = heldAt
+	}
+
+	required := make(map[string]int, len(items))
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if _, seen := required[item.productID]; !seen {
+			productIDs = append(productIDs, item.productID)
+		}
+		required[item.productID] += item.quantity
+	}
+	for _, reservation := range reservations {
+		if reservation.LocationID != "" {
+			required[reservation.ProductID] -= reservation.Quantity
+		}
+	}
+
+	levels, err := uc.locationRepo.GetProductStock(ctx, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	stock := make(map[string]map[string]int)
+	for _, level := range levels {
+		if stock[level.LocationID] == nil {
+			stock[level.LocationID] = make(map[string]int)
+		}
+		stock[level.LocationID][level.ProductID] = level.Available()
+	}
+
+	if locationID != "" {
+		location, err := uc.GetLocation(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := shortfall(productIDs, required, stock[location.ID]); err != nil {
+			return nil, err
+		}
+		return location, nil
+	}
+
+	locations, err := uc.locationRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("no stock location %w", repositories.ErrNotFound)
+	}
+
+	// Rank every location, then ship from the best one that holds the whole order
+	units := func(location *entities.Location) int {
+		total := 0
+		for _, productID := range productIDs {
+			total += stock[location.ID][productID]
+		}
+		return total
+	}
+	byStock := func(a, b *entities.Location) int {
+		return cmp.Compare(units(b), units(a))
+	}
+	ranking := byStock
+	if uc.strategy == FulfilmentNearest && shipTo != nil {
+		ranking = func(a, b *entities.Location) int {
+			if c := compareDistance(a, b, *shipTo); c != 0 {
+				return c
+			}
+			return byStock(a, b)
+		}
+	}
+	slices.SortStableFunc(locations, ranking)
+
+	for _, location := range locations {
+		if shortfall(productIDs, required, stock[location.ID]) == nil {
+			return location, nil
+		}
+	}
+
+	// Nowhere holds it all; report what the best-stocked location is short of
+	slices.SortStableFunc(locations, byStock)
+	return nil, shortfall(productIDs, required, stock[locations[0].ID])
+}
+
+// shortfall reports the first product a location does not have enough unheld units of
+func shortfall(productIDs []string, required, stock map[string]int) error {
+	for _, productID := range productIDs {
+		if stock[productID] < required[productID] {
+			return &InsufficientStockError{
+				ProductID: productID,
+				Available: stock[productID],
+				Requested: required[productID],
+			}
+		}
+	}
+	return nil
+}
+
+// compareDistance orders locations by distance from a point; those without a position come last
+func compareDistance(a, b *entities.Location, to entities.GeoPoint) int {
+	switch {
+	case a.Position == nil && b.Position == nil:
+		return 0
+	case a.Position == nil:
+		return 1
+	case b.Position == nil:
+		return -1
+	}
+	return cmp.Compare(a.Position.DistanceKm(to), b.Position.DistanceKm(to))
+}
@@ -0,0 +1,21 @@
+package usecases
+
+import (
+	"fmt"
+
+	"day5/internal/domain/entities"
+)
+
+// validateRequestedAmount checks an amount supplied by a client: it must be in
+// the default currency and greater than zero, or zero too when allowZero is set
+func validateRequestedAmount(field string, amount entities.Money, allowZero bool) error {
+	if amount.IsNegative() || (amount.IsZero() && !allowZero) {
+		return fmt.Errorf("%w: %s must be greater than zero, got %s", entities.ErrInvalidAmount, field, amount)
+	}
+
+	if currency := entities.DefaultCurrency(); amount.Currency() != currency {
+		return fmt.Errorf("%w: %s must be in %s, got %s", entities.ErrInvalidAmount, field, currency, amount.Currency())
+	}
+
+	return nil
+}
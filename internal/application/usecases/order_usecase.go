@@ -2,9 +2,10 @@ package usecases
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"math/big"
+	"slices"
+	"strings"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -13,10 +14,23 @@ import (
 
 // OrderUseCase encapsulates business logic for order operations
 type OrderUseCase struct {
-	orderRepo       repositories.OrderRepository
-	customerUseCase *CustomerUseCase
-	productUseCase  *ProductUseCase
-	transactionRepo repositories.TransactionRepository
+	orderRepo          repositories.OrderRepository
+	customerUseCase    *CustomerUseCase
+	productUseCase     *ProductUseCase
+	walletUseCase      *WalletUseCase
+	exchangeUseCase    *ExchangeRateUseCase
+	reservationUseCase *ReservationUseCase
+	locationUseCase    *LocationUseCase
+	pricingUseCase     *PricingUseCase
+	promotionUseCase   *PromotionUseCase
+	taxUseCase         *TaxUseCase
+	invoiceUseCase     *InvoiceUseCase
+	transactionRepo    repositories.TransactionRepository
+	returnRepo         repositories.ReturnRepository
+	unitOfWork         repositories.UnitOfWork
+	idGenerator        repositories.IDGenerator
+
+	cancellationWindow time.Duration
 }
 
 // NewOrderUseCase creates a new order use case
@@ -24,39 +38,201 @@ func NewOrderUseCase(
 	orderRepo repositories.OrderRepository,
 	customerUseCase *CustomerUseCase,
 	productUseCase *ProductUseCase,
+	walletUseCase *WalletUseCase,
+	exchangeUseCase *ExchangeRateUseCase,
+	reservationUseCase *ReservationUseCase,
+	locationUseCase *LocationUseCase,
+	pricingUseCase *PricingUseCase,
+	promotionUseCase *PromotionUseCase,
+	taxUseCase *TaxUseCase,
+	invoiceUseCase *InvoiceUseCase,
 	transactionRepo repositories.TransactionRepository,
+	returnRepo repositories.ReturnRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+	cancellationWindowMinutes int,
 ) *OrderUseCase {
+	cancellationWindow := time.Duration(cancellationWindowMinutes) * time.Minute
+	if cancellationWindow <= 0 {
+		cancellationWindow = entities.DefaultCancellationWindow
+	}
+
 	return &OrderUseCase{
-		orderRepo:       orderRepo,
-		customerUseCase: customerUseCase,
-		productUseCase:  productUseCase,
-		transactionRepo: transactionRepo,
+		orderRepo:          orderRepo,
+		customerUseCase:    customerUseCase,
+		productUseCase:     productUseCase,
+		walletUseCase:      walletUseCase,
+		exchangeUseCase:    exchangeUseCase,
+		reservationUseCase: reservationUseCase,
+		locationUseCase:    locationUseCase,
+		pricingUseCase:     pricingUseCase,
+		promotionUseCase:   promotionUseCase,
+		taxUseCase:         taxUseCase,
+		invoiceUseCase:     invoiceUseCase,
+		transactionRepo:    transactionRepo,
+		returnRepo:         returnRepo,
+		unitOfWork:         unitOfWork,
+		idGenerator:        idGenerator,
+		cancellationWindow: cancellationWindow,
 	}
 }
 
+// ErrInvalidOrderItems is returned when an order request does not name its products properly
+var ErrInvalidOrderItems = errors.New("invalid order items")
+
 // PlaceOrderRequest represents the request to place an order
+// ProductID and Quantity order a single product; Items orders several
 type PlaceOrderRequest struct {
-	CustomerID string `json:"customer_id" binding:"required"`
-	ProductID  string `json:"product_id" binding:"required"`
-	Quantity   int    `json:"quantity" binding:"required,gt=0"`
+	CustomerID string             `json:"customer_id" binding:"required"`
+	ProductID  string             `json:"product_id,omitempty"`
+	Quantity   int                `json:"quantity,omitempty" binding:"omitempty,gt=0"`
+	Items      []OrderItemRequest `json:"items,omitempty" binding:"omitempty,dive"`
+
+	// StoreCredit is the most store credit to spend on this order; it is capped at the order total
+	StoreCredit entities.Money `json:"store_credit"`
+
+	// Currency is what the customer pays in; it defaults to the base currency
+	Currency string `json:"currency,omitempty"`
+
+	// ReservationIDs are stock holds to confirm into the order; held units
+	// count towards the ordered quantity of their product. Without other
+	// items the order is for exactly the held stock
+	ReservationIDs []string `json:"reservation_ids,omitempty"`
+
+	// LocationID is the store or warehouse to ship from; without it the
+	// configured fulfilment strategy picks one that holds the whole order
+	LocationID string `json:"location_id,omitempty"`
+
+	// ShipTo is where the order is delivered, used by the nearest strategy
+	ShipTo *entities.GeoPoint `json:"ship_to,omitempty"`
+
+	// CouponCode redeems a coupon for its promotion, on top of any promotion
+	// that applies automatically
+	CouponCode string `json:"coupon_code,omitempty"`
+}
+
+// OrderItemRequest is one product in a multi-line order request
+type OrderItemRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// orderItems returns the requested items, treating ProductID and Quantity as one more item
+// When only reservations are given, the held stock makes up the items
+func (r *PlaceOrderRequest) orderItems(reservations []*entities.StockReservation) ([]OrderItemRequest, error) {
+	items := slices.Clone(r.Items)
+	if r.ProductID != "" || r.Quantity != 0 {
+		items = append([]OrderItemRequest{{ProductID: r.ProductID, Quantity: r.Quantity}}, items...)
+	}
+	if len(items) == 0 {
+		for _, reservation := range reservations {
+			items = append(items, OrderItemRequest{ProductID: reservation.ProductID, Quantity: reservation.Quantity})
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: at least one product is required", ErrInvalidOrderItems)
+	}
+	for _, item := range items {
+		if strings.TrimSpace(item.ProductID) == "" {
+			return nil, fmt.Errorf("%w: product ID is required", ErrInvalidOrderItems)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity for product %s must be greater than zero", ErrInvalidOrderItems, item.ProductID)
+		}
+	}
+
+	// Held stock must be part of the order
+	ordered := make(map[string]int, len(items))
+	for _, item := range items {
+		ordered[item.ProductID] += item.Quantity
+	}
+	for productID, held := range heldQuantities(reservations) {
+		if held > ordered[productID] {
+			return nil, fmt.Errorf("%w: %d units of product %s are reserved but %d are ordered",
+				ErrInvalidOrderItems, held, productID, ordered[productID])
+		}
+	}
+
+	return items, nil
+}
+
+// heldQuantities sums the reserved quantity per product
+func heldQuantities(reservations []*entities.StockReservation) map[string]int {
+	held := make(map[string]int, len(reservations))
+	for _, reservation := range reservations {
+		held[reservation.ProductID] += reservation.Quantity
+	}
+	return held
 }
 
 // OrderResponse represents the response after placing an order
 type OrderResponse struct {
-	ID           string    `json:"id"`
-	CustomerID   string    `json:"customer_id"`
-	CustomerName string    `json:"customer_name"`
-	ProductID    string    `json:"product_id"`
-	ProductName  string    `json:"product_name"`
-	Quantity     int       `json:"quantity"`
-	UnitPrice    float64   `json:"unit_price"`
-	TotalAmount  float64   `json:"total_amount"`
-	OrderDate    time.Time `json:"order_date"`
-	Message      string    `json:"message"`
+	ID           string               `json:"id"`
+	CustomerID   string               `json:"customer_id"`
+	CustomerName string               `json:"customer_name"`
+	ProductID    string               `json:"product_id"`
+	ProductName  string               `json:"product_name"`
+	Quantity     int                  `json:"quantity"`
+	UnitPrice    entities.Money       `json:"unit_price"`
+	TotalAmount  entities.Money       `json:"total_amount"`
+	Status       entities.OrderStatus `json:"status"`
+	OrderDate    time.Time            `json:"order_date"`
+	Message      string               `json:"message"`
+
+	Lines []*entities.OrderLine `json:"lines"`
+
+	Discount   entities.Money `json:"discount"`
+	CouponCode string         `json:"coupon_code,omitempty"`
+
+	// Tax is included in TotalAmount; the lines break it down
+	Tax             entities.Money `json:"tax"`
+	TaxJurisdiction string         `json:"tax_jurisdiction,omitempty"`
+
+	// InvoiceNumber is the number of the invoice issued for the order
+	InvoiceNumber string `json:"invoice_number"`
+
+	StoreCreditApplied entities.Money `json:"store_credit_applied"`
+	AmountDue          entities.Money `json:"amount_due"`
+
+	ChargeCurrency string         `json:"charge_currency"`
+	ExchangeRate   entities.Rate  `json:"exchange_rate"`
+	ChargedAmount  entities.Money `json:"charged_amount"`
+
+	LocationID string `json:"location_id"`
+}
+
+// OrderStatusRequest represents the request to move an order through its lifecycle
+type OrderStatusRequest struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// CancelOrderRequest represents the request to cancel an order
+type CancelOrderRequest struct {
+	OrderStatusRequest
+
+	// ClearCooldown lets the customer place a new order straight away
+	ClearCooldown bool `json:"clear_cooldown"`
 }
 
 // PlaceOrder places a new order with complete business logic validation
 func (uc *OrderUseCase) PlaceOrder(ctx context.Context, req *PlaceOrderRequest) (*OrderResponse, error) {
+	reservations, err := uc.reservationUseCase.heldForOrder(ctx, req.CustomerID, req.ReservationIDs)
+	if err != nil {
+		return nil, err
+	}
+	held := heldQuantities(reservations)
+
+	items, err := req.orderItems(reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRequestedAmount("store credit", req.StoreCredit, true); err != nil {
+		return nil, err
+	}
+
 	// Step 1: Validate customer cooldown
 	canOrder, cooldown, err := uc.customerUseCase.CanCustomerPlaceOrder(ctx, req.CustomerID)
 	if err != nil {
@@ -72,46 +248,105 @@ func (uc *OrderUseCase) PlaceOrder(ctx context.Context, req *PlaceOrderRequest)
 		}
 	}
 
-	// Step 2: Check product availability
-	product, err := uc.productUseCase.CheckProductAvailability(ctx, req.ProductID, req.Quantity)
+	// Step 2: Check product availability for every line
+	orderID, err := uc.idGenerator.NewID(ctx, entities.OrderIDPrefix)
 	if err != nil {
-		return nil, fmt.Errorf("product availability check failed: %w", err)
+		return nil, fmt.Errorf("failed to generate order ID: %w", err)
 	}
 
-	// Step 3: Get customer details
+	order := &entities.Order{
+		ID:         orderID,
+		CustomerID: req.CustomerID,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	products := make(map[string]*entities.Product, len(items))
+	for _, item := range items {
+		// A product listed twice is checked against its combined quantity
+		quantity := item.Quantity
+		if line := order.FindLine(item.ProductID); line != nil {
+			quantity += line.Quantity
+		}
+
+		// Held units are already set aside for this order
+		var product *entities.Product
+		if unheld := quantity - held[item.ProductID]; unheld > 0 {
+			product, err = uc.productUseCase.CheckProductAvailability(ctx, item.ProductID, unheld)
+		} else {
+			product, err = uc.productUseCase.GetSellableProduct(ctx, item.ProductID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("product availability check failed: %w", err)
+		}
+
+		if _, err := order.AddLine(product, item.Quantity); err != nil {
+			return nil, err
+		}
+		order.Product = product
+		products[product.ID] = product
+	}
+	if len(order.Lines) > 1 {
+		order.Product = nil
+	}
+
+	// Step 3: Get customer details and price every line for them
 	customer, err := uc.customerUseCase.GetCustomer(ctx, req.CustomerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
+	order.Customer = customer
 
-	// Step 4: Create order entity
-	orderID, err := generateOrderID()
+	if err := uc.pricingUseCase.priceOrder(ctx, order, products); err != nil {
+		return nil, fmt.Errorf("failed to price order: %w", err)
+	}
+	redemptions, err := uc.promotionUseCase.applyPromotions(ctx, order, products, req.CouponCode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate order ID: %w", err)
+		return nil, fmt.Errorf("failed to apply promotions: %w", err)
+	}
+	if err := uc.taxUseCase.applyTax(ctx, order, products); err != nil {
+		return nil, fmt.Errorf("failed to calculate tax: %w", err)
 	}
 
-	order := &entities.Order{
-		ID:         orderID,
-		CustomerID: req.CustomerID,
-		ProductID:  req.ProductID,
-		Quantity:   req.Quantity,
-		UnitPrice:  product.Price,
-		CreatedAt:  time.Now().UTC(),
-		UpdatedAt:  time.Now().UTC(),
-		Customer:   customer,
-		Product:    product,
+	// Pick the location that ships the whole order, bundles as their components
+	saleItems, err := uc.orderSaleItems(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	location, err := uc.locationUseCase.fulfilmentLocation(ctx, saleItems, reservations, req.LocationID, req.ShipTo)
+	if err != nil {
+		return nil, fmt.Errorf("product availability check failed: %w", err)
 	}
+	order.LocationID = location.ID
 
-	order.CalculateTotal()
+	// Step 4: Complete the order entity
+	if err := order.CalculateTotal(); err != nil {
+		return nil, fmt.Errorf("order validation failed: %w", err)
+	}
+	if _, err := order.ApplyStoreCredit(req.StoreCredit); err != nil {
+		return nil, fmt.Errorf("order validation failed: %w", err)
+	}
 	order.SetOrderDate()
 
+	// Prices are in the base currency; the customer is charged at today's rate
+	rate, err := uc.exchangeUseCase.GetEffectiveRate(ctx, req.Currency, order.OrderDate)
+	if err != nil {
+		return nil, err
+	}
+	order.ChargeIn(rate.Currency, rate.Rate)
+	order.MarkPlaced(req.CustomerID)
+
 	// Validate order business rules
 	if err := order.Validate(); err != nil {
 		return nil, fmt.Errorf("order validation failed: %w", err)
 	}
+	invoice, err := uc.invoiceUseCase.draftInvoice(order, products)
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft invoice: %w", err)
+	}
 
 	// Step 5: Execute transaction (all or nothing)
-	if err := uc.executeOrderTransaction(ctx, order, product, req.Quantity); err != nil {
+	if err := uc.executeOrderTransaction(ctx, order, saleItems, reservations, redemptions, invoice); err != nil {
 		return nil, fmt.Errorf("failed to execute order transaction: %w", err)
 	}
 
@@ -121,63 +356,353 @@ func (uc *OrderUseCase) PlaceOrder(ctx context.Context, req *PlaceOrderRequest)
 		CustomerID:   order.CustomerID,
 		CustomerName: customer.Name,
 		ProductID:    order.ProductID,
-		ProductName:  product.ProductName,
 		Quantity:     order.Quantity,
 		UnitPrice:    order.UnitPrice,
 		TotalAmount:  order.TotalAmount,
+		Status:       order.Status,
 		OrderDate:    order.OrderDate,
 		Message:      "Order successfully placed",
+		Lines:        order.Lines,
+		Discount:     order.Discount,
+		CouponCode:   order.CouponCode,
+
+		Tax:             order.Tax,
+		TaxJurisdiction: order.TaxJurisdiction,
+		InvoiceNumber:   invoice.Number,
+
+		StoreCreditApplied: order.StoreCreditApplied,
+		AmountDue:          order.AmountDue(),
+
+		ChargeCurrency: order.ChargeCurrency,
+		ExchangeRate:   order.ExchangeRate,
+		ChargedAmount:  order.ChargedAmount,
+		LocationID:     order.LocationID,
+	}
+	if order.Product != nil {
+		response.ProductName = order.Product.ProductName
 	}
 
 	return response, nil
 }
 
 // executeOrderTransaction handles the complete order transaction
-func (uc *OrderUseCase) executeOrderTransaction(ctx context.Context, order *entities.Order, product *entities.Product, quantity int) error {
-	// This should be wrapped in a database transaction
-	// For now, we'll handle the sequence manually
+// All steps run in a single unit of work, so a failure at any step rolls back everything
+func (uc *OrderUseCase) executeOrderTransaction(ctx context.Context, order *entities.Order, items []*saleItem, reservations []*entities.StockReservation, redemptions []*entities.PromotionRedemption, invoice *entities.Invoice) error {
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		return uc.applyOrder(ctx, order, items, reservations, redemptions, invoice)
+	})
+}
 
-	// 1. Reduce product quantity
-	if err := product.ReduceQuantity(quantity); err != nil {
-		return fmt.Errorf("failed to reduce product quantity: %w", err)
+// applyOrder performs the order steps; it must run inside a unit of work
+// items are the order's lines split into the stock they move,
+// redemptions what promotions took off it and invoice the order's draft invoice
+func (uc *OrderUseCase) applyOrder(ctx context.Context, order *entities.Order, items []*saleItem, reservations []*entities.StockReservation, redemptions []*entities.PromotionRedemption, invoice *entities.Invoice) error {
+	// 1. Take the stock for every product with conditional decrements so
+	// concurrent orders cannot oversell; a bundle takes each of its components.
+	// Products are reserved in ID order so two baskets sharing products lock
+	// their rows in the same order. Held units were set aside already and are
+	// taken when the holds are confirmed
+	held := heldQuantities(reservations)
+	required := make(map[string]int, len(items))
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if _, seen := required[item.productID]; !seen {
+			productIDs = append(productIDs, item.productID)
+		}
+		required[item.productID] += item.quantity
+	}
+	slices.Sort(productIDs)
+	for _, productID := range productIDs {
+		if unheld := required[productID] - held[productID]; unheld > 0 {
+			source := entities.MovementSource{
+				Reason:      entities.MovementReasonSale,
+				LocationID:  order.LocationID,
+				Actor:       order.CustomerID,
+				ReferenceID: order.ID,
+			}
+			if err := uc.productUseCase.TakeStock(ctx, productID, unheld, source); err != nil {
+				return err
+			}
+		}
 	}
 
-	// 2. Save order
+	// 2. Save order and its lines, then confirm the holds into it
 	if err := uc.orderRepo.Create(ctx, order); err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
+	if err := uc.reservationUseCase.confirmForOrder(ctx, reservations, order); err != nil {
+		return err
+	}
 
-	// 3. Update product in repository
-	if err := uc.productUseCase.productRepo.Update(ctx, product); err != nil {
-		return fmt.Errorf("failed to update product quantity: %w", err)
+	// 3. Create a transaction record per line, carrying what its units cost;
+	// a bundle's revenue is shared between records for each of its components
+	if err := uc.costSaleItems(ctx, order.ID, items); err != nil {
+		return err
 	}
+	for _, item := range items {
+		transactionID, err := uc.idGenerator.NewID(ctx, entities.TransactionIDPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to generate transaction ID: %w", err)
+		}
 
-	// 4. Create transaction record
-	transactionID, err := generateTransactionID()
-	if err != nil {
-		return fmt.Errorf("failed to generate transaction ID: %w", err)
+		transaction := &entities.Transaction{
+			ID:        transactionID,
+			CreatedAt: time.Now().UTC(),
+		}
+		transaction.CreateFromOrderLine(order, item.line, item.cost)
+		if item.bundled {
+			transaction.AllocateToComponent(item.productID, item.quantity, item.revenue)
+		}
+
+		if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
 	}
 
-	transaction := &entities.Transaction{
-		ID:        transactionID,
-		CreatedAt: time.Now().UTC(),
+	// 4. Record the promotions redeemed and count the use of the coupon
+	if err := uc.promotionUseCase.redeemPromotions(ctx, order, redemptions); err != nil {
+		return err
 	}
-	transaction.CreateFromOrder(order)
 
-	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
-		return fmt.Errorf("failed to create transaction: %w", err)
+	// 5. Spend store credit, if any was applied
+	if err := uc.walletUseCase.RedeemCredit(ctx, order); err != nil {
+		return err
 	}
 
-	// 5. Update customer cooldown
+	// 6. Update customer cooldown once for the whole order
 	if err := uc.customerUseCase.UpdateCustomerCooldown(ctx, order.CustomerID); err != nil {
 		return fmt.Errorf("failed to update customer cooldown: %w", err)
 	}
 
+	// 7. Issue the invoice last, so the counter row is locked for as short a time as possible
+	if err := uc.invoiceUseCase.issueInvoice(ctx, invoice); err != nil {
+		return fmt.Errorf("failed to issue invoice: %w", err)
+	}
+
+	return nil
+}
+
+// orderSaleItems splits each line of an order into the stock it moves, in line
+// order; a bundle line moves each of its components
+func (uc *OrderUseCase) orderSaleItems(ctx context.Context, order *entities.Order) ([]*saleItem, error) {
+	var items []*saleItem
+	for _, line := range order.OrderLines() {
+		lineItems, err := uc.productUseCase.saleItems(ctx, line.ProductID, line.Quantity, line.LineTotal)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range lineItems {
+			item.line = line
+		}
+		items = append(items, lineItems...)
+	}
+	return items, nil
+}
+
+// costSaleItems splits what each product of an order cost across the items
+// that sold it in proportion to their quantities; the last item of a product
+// takes what rounding leaves over
+func (uc *OrderUseCase) costSaleItems(ctx context.Context, orderID string, items []*saleItem) error {
+	sold, err := uc.productUseCase.saleCosts(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		item.cost = entities.NewMoney(0, "")
+		left, ok := sold[item.productID]
+		if !ok || left.quantity <= 0 {
+			continue
+		}
+
+		item.cost = left.cost
+		if item.quantity < left.quantity {
+			item.cost = left.cost.MulRat(int64(item.quantity), int64(left.quantity))
+		}
+		if left.cost, err = left.cost.Sub(item.cost); err != nil {
+			return fmt.Errorf("%w: %v", entities.ErrInvalidAmount, err)
+		}
+		left.quantity -= item.quantity
+	}
 	return nil
 }
 
-// GetOrderHistory gets order history for a customer
-func (uc *OrderUseCase) GetOrderHistory(ctx context.Context, customerID string, limit, offset int) ([]*entities.Order, error) {
+// ConfirmOrder moves a pending order to confirmed
+func (uc *OrderUseCase) ConfirmOrder(ctx context.Context, orderID string, req *OrderStatusRequest) (*entities.Order, error) {
+	return uc.TransitionOrder(ctx, orderID, entities.OrderStatusConfirmed, req)
+}
+
+// CompleteOrder moves a confirmed order to completed
+func (uc *OrderUseCase) CompleteOrder(ctx context.Context, orderID string, req *OrderStatusRequest) (*entities.Order, error) {
+	return uc.TransitionOrder(ctx, orderID, entities.OrderStatusCompleted, req)
+}
+
+// TransitionOrder moves an order to the next status and records who did it
+func (uc *OrderUseCase) TransitionOrder(ctx context.Context, orderID string, next entities.OrderStatus, req *OrderStatusRequest) (*entities.Order, error) {
+	if orderID == "" {
+		return nil, fmt.Errorf("order ID is required")
+	}
+
+	actor := defaultActor
+	reason := ""
+	if req != nil {
+		if req.Actor != "" {
+			actor = req.Actor
+		}
+		reason = req.Reason
+	}
+
+	var order *entities.Order
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = uc.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		change, err := order.TransitionTo(next, actor, reason)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.orderRepo.UpdateStatus(ctx, order.ID, change); err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CancelOrder cancels an order inside the cancellation window
+// The stock is returned, a compensating refund is written, the promotions the
+// order redeemed no longer count, any store credit spent on the order goes back
+// to the wallet and, if requested, the customer's cooldown is cleared, all in
+// a single unit of work
+// Orders with requested or approved returns cannot be cancelled, as the
+// returned units would be restocked and refunded twice
+func (uc *OrderUseCase) CancelOrder(ctx context.Context, orderID string, req *CancelOrderRequest) (*entities.Order, error) {
+	if orderID == "" {
+		return nil, fmt.Errorf("order ID is required")
+	}
+	if req == nil {
+		req = &CancelOrderRequest{}
+	}
+
+	actor := req.Actor
+	if actor == "" {
+		actor = defaultActor
+	}
+
+	var order *entities.Order
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Hold the order so no return is raised or approved while it is cancelled
+		if err := uc.orderRepo.LockForUpdate(ctx, orderID); err != nil {
+			return err
+		}
+
+		var err error
+		order, err = uc.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		returns, err := uc.returnRepo.CountActiveReturns(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		if returns > 0 {
+			return fmt.Errorf("%w: order %s has %d requested or approved returns", entities.ErrOrderHasReturns, order.ID, returns)
+		}
+
+		// 1. Move to cancelled, enforcing the window and the state machine
+		change, err := order.Cancel(uc.cancellationWindow, actor, req.Reason)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.orderRepo.UpdateStatus(ctx, order.ID, change); err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+
+		items, err := uc.orderSaleItems(ctx, order)
+		if err != nil {
+			return err
+		}
+		if err := uc.costSaleItems(ctx, order.ID, items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			// 2. Return each line's quantity, or its bundle's components, to
+			// stock at what it cost when sold
+			unitCost := item.cost.Div(item.quantity)
+			source := entities.MovementSource{
+				Reason:      entities.MovementReasonCancellation,
+				LocationID:  order.LocationID,
+				Actor:       actor,
+				ReferenceID: order.ID,
+				Note:        req.Reason,
+				UnitCost:    &unitCost,
+			}
+			if err := uc.productUseCase.ReturnStock(ctx, item.productID, item.quantity, source); err != nil {
+				return err
+			}
+
+			// 3. Reverse the line's ledger entry
+			transactionID, err := uc.idGenerator.NewID(ctx, entities.TransactionIDPrefix)
+			if err != nil {
+				return fmt.Errorf("failed to generate transaction ID: %w", err)
+			}
+
+			refund := &entities.Transaction{
+				ID:        transactionID,
+				CreatedAt: time.Now().UTC(),
+			}
+			refund.CreateRefundForOrderLine(order, item.line, item.cost, req.Reason)
+			if item.bundled {
+				refund.AllocateToComponent(item.productID, item.quantity, item.revenue)
+			}
+
+			if err := uc.transactionRepo.Create(ctx, refund); err != nil {
+				return fmt.Errorf("failed to create refund transaction: %w", err)
+			}
+		}
+
+		// 4. Take back the promotions the order redeemed, freeing its coupon
+		if err := uc.promotionUseCase.releasePromotions(ctx, order); err != nil {
+			return err
+		}
+
+		// 5. Give back the store credit that paid for the order
+		if order.StoreCreditApplied.IsPositive() {
+			description := fmt.Sprintf("Store credit returned for cancelled order %s", order.ID)
+			if _, err := uc.walletUseCase.AddCredit(ctx, order.CustomerID, order.StoreCreditApplied, order.ID, description); err != nil {
+				return err
+			}
+		}
+
+		// 6. Optionally let the customer order again
+		if req.ClearCooldown {
+			if err := uc.customerUseCase.ClearCustomerCooldown(ctx, order.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetOrderHistory gets order history for a customer, optionally filtered by status
+func (uc *OrderUseCase) GetOrderHistory(ctx context.Context, customerID string, status entities.OrderStatus, limit, offset int) ([]*entities.Order, error) {
 	if customerID == "" {
 		return nil, fmt.Errorf("customer ID is required")
 	}
@@ -195,7 +720,16 @@ func (uc *OrderUseCase) GetOrderHistory(ctx context.Context, customerID string,
 		offset = 0
 	}
 
-	orders, err := uc.orderRepo.GetByCustomerID(ctx, customerID, limit, offset)
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("invalid order status: %s", status)
+	}
+
+	orders, err := uc.orderRepo.Find(ctx, repositories.OrderFilter{
+		CustomerID: customerID,
+		Status:     status,
+		Limit:      limit,
+		Offset:     offset,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order history: %w", err)
 	}
@@ -203,8 +737,8 @@ func (uc *OrderUseCase) GetOrderHistory(ctx context.Context, customerID string,
 	return orders, nil
 }
 
-// GetAllOrders gets all orders with pagination (for admin/retailer view)
-func (uc *OrderUseCase) GetAllOrders(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
+// GetAllOrders gets all orders with pagination (for admin/retailer view), optionally filtered by status
+func (uc *OrderUseCase) GetAllOrders(ctx context.Context, status entities.OrderStatus, limit, offset int) ([]*entities.Order, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -212,7 +746,15 @@ func (uc *OrderUseCase) GetAllOrders(ctx context.Context, limit, offset int) ([]
 		offset = 0
 	}
 
-	orders, err := uc.orderRepo.GetOrdersWithDetails(ctx, limit, offset)
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("invalid order status: %s", status)
+	}
+
+	orders, err := uc.orderRepo.Find(ctx, repositories.OrderFilter{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all orders: %w", err)
 	}
@@ -244,6 +786,9 @@ func (uc *OrderUseCase) GetTodaysOrders(ctx context.Context) ([]*entities.Order,
 	return orders, nil
 }
 
+// defaultActor is recorded on status changes when the caller does not identify itself
+const defaultActor = "system"
+
 // CooldownError represents a cooldown violation error
 type CooldownError struct {
 	CustomerID     string
@@ -255,35 +800,3 @@ func (e *CooldownError) Error() string {
 	return fmt.Sprintf("customer %s is in cooldown period, remaining: %v",
 		e.CustomerID, e.RemainingTime)
 }
-
-// generateOrderID generates a unique order ID in format ORD12345
-func generateOrderID() (string, error) {
-	max := big.NewInt(99999)
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		return "", err
-	}
-
-	number := n.Int64() + 10000
-	if number > 99999 {
-		number = number%90000 + 10000
-	}
-
-	return fmt.Sprintf("ORD%05d", number), nil
-}
-
-// generateTransactionID generates a unique transaction ID in format TXN12345
-func generateTransactionID() (string, error) {
-	max := big.NewInt(99999)
-	n, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		return "", err
-	}
-
-	number := n.Int64() + 10000
-	if number > 99999 {
-		number = number%90000 + 10000
-	}
-
-	return fmt.Sprintf("TXN%05d", number), nil
-}
@@ -0,0 +1,365 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// activateBatchSize is how many due prices the scheduler applies per query
+const activateBatchSize = 100
+
+// PricingUseCase resolves what a customer pays for a product
+// A product's price is its list price; price rules lower it for a price list,
+// from a quantity or for a while, and the lowest price that applies wins
+// List prices can be scheduled ahead, and every list price a product has had
+// is kept as its price history
+type PricingUseCase struct {
+	priceRuleRepo   repositories.PriceRuleRepository
+	priceRepo       repositories.ProductPriceRepository
+	productUseCase  *ProductUseCase
+	customerRepo    repositories.CustomerRepository
+	transactionRepo repositories.TransactionRepository
+	unitOfWork      repositories.UnitOfWork
+	idGenerator     repositories.IDGenerator
+}
+
+// NewPricingUseCase creates a new pricing use case
+func NewPricingUseCase(
+	priceRuleRepo repositories.PriceRuleRepository,
+	priceRepo repositories.ProductPriceRepository,
+	productUseCase *ProductUseCase,
+	customerRepo repositories.CustomerRepository,
+	transactionRepo repositories.TransactionRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+) *PricingUseCase {
+	return &PricingUseCase{
+		priceRuleRepo:   priceRuleRepo,
+		priceRepo:       priceRepo,
+		productUseCase:  productUseCase,
+		customerRepo:    customerRepo,
+		transactionRepo: transactionRepo,
+		unitOfWork:      unitOfWork,
+		idGenerator:     idGenerator,
+	}
+}
+
+// CreatePriceRuleRequest represents the request to add a price rule
+// Set either Price, a fixed unit price for one product, or DiscountPercent
+type CreatePriceRuleRequest struct {
+	Name            string             `json:"name" binding:"required"`
+	PriceList       entities.PriceList `json:"price_list,omitempty" binding:"omitempty,oneof=retail wholesale"`
+	ProductID       string             `json:"product_id,omitempty"`
+	MinQuantity     int                `json:"min_quantity" binding:"gte=0"`
+	Price           entities.Money     `json:"price"`
+	DiscountPercent entities.Percent   `json:"discount_percent"`
+
+	// StartsAt defaults to now; EndsAt to never
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// CreatePriceRule adds a price rule
+func (uc *PricingUseCase) CreatePriceRule(ctx context.Context, req *CreatePriceRuleRequest) (*entities.PriceRule, error) {
+	id, err := uc.idGenerator.NewID(ctx, entities.PriceRuleIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate price rule ID: %w", err)
+	}
+
+	rule := &entities.PriceRule{
+		ID:              id,
+		Name:            strings.TrimSpace(req.Name),
+		PriceList:       req.PriceList,
+		ProductID:       strings.TrimSpace(req.ProductID),
+		MinQuantity:     req.MinQuantity,
+		Price:           req.Price,
+		DiscountPercent: req.DiscountPercent,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if req.StartsAt != nil {
+		startsAt := req.StartsAt.UTC()
+		rule.StartsAt = &startsAt
+	}
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		rule.EndsAt = &endsAt
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if rule.ProductID != "" {
+		product, err := uc.productUseCase.GetProduct(ctx, rule.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if !rule.Price.IsZero() && rule.Price.Currency() != product.Price.Currency() {
+			return nil, fmt.Errorf("%w: product %s is priced in %s, not %s",
+				entities.ErrInvalidPriceRule, product.ID, product.Price.Currency(), rule.Price.Currency())
+		}
+	}
+
+	if err := uc.priceRuleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// GetPriceRule retrieves a price rule by ID
+func (uc *PricingUseCase) GetPriceRule(ctx context.Context, id string) (*entities.PriceRule, error) {
+	rule, err := uc.priceRuleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetPriceRules lists the rules for a product, or every rule when productID is empty
+func (uc *PricingUseCase) GetPriceRules(ctx context.Context, productID string) ([]*entities.PriceRule, error) {
+	rules, err := uc.priceRuleRepo.GetAll(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// EndPriceRule stops a price rule applying from now on
+// Orders it already priced keep their prices
+func (uc *PricingUseCase) EndPriceRule(ctx context.Context, id string) (*entities.PriceRule, error) {
+	rule, err := uc.GetPriceRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rule.End(time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := uc.priceRuleRepo.End(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// SchedulePriceRequest represents the request to change a product's list price at a future time
+type SchedulePriceRequest struct {
+	Price         entities.Money `json:"price"`
+	EffectiveFrom time.Time      `json:"effective_from" binding:"required"`
+	Actor         string         `json:"actor,omitempty"`
+}
+
+// SchedulePrice plans a change to a product's list price
+// The scheduler makes it the product's price once EffectiveFrom has passed;
+// until then the current price stays in effect
+func (uc *PricingUseCase) SchedulePrice(ctx context.Context, productID string, req *SchedulePriceRequest) (*entities.ProductPrice, error) {
+	if err := validateRequestedAmount("price", req.Price, false); err != nil {
+		return nil, err
+	}
+
+	product, err := uc.productUseCase.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	price := &entities.ProductPrice{
+		ProductID:     product.ID,
+		Price:         req.Price,
+		EffectiveFrom: req.EffectiveFrom.UTC(),
+		Actor:         strings.TrimSpace(req.Actor),
+		CreatedAt:     now,
+	}
+	if err := price.Validate(); err != nil {
+		return nil, err
+	}
+	if !price.EffectiveFrom.After(now) {
+		return nil, fmt.Errorf("%w: a scheduled price must take effect in the future; update the product to change its price now",
+			entities.ErrInvalidPriceSchedule)
+	}
+
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.productUseCase.backfillPrice(ctx, product, product.Price); err != nil {
+			return err
+		}
+		return uc.priceRepo.Record(ctx, price)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return price, nil
+}
+
+// CancelScheduledPrice drops a price change that has not been activated yet;
+// the price before it stays in effect until the one after it
+func (uc *PricingUseCase) CancelScheduledPrice(ctx context.Context, productID string, id uint) error {
+	price, err := uc.priceRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get scheduled price: %w", err)
+	}
+	if price.ProductID != productID {
+		return fmt.Errorf("price %d of product %s %w", id, productID, repositories.ErrNotFound)
+	}
+	if !price.IsScheduled() {
+		return fmt.Errorf("%w: price %d was activated at %s and is part of the product's history",
+			entities.ErrInvalidPriceSchedule, id, price.ActivatedAt.Format(time.RFC3339))
+	}
+
+	return uc.priceRepo.Cancel(ctx, price)
+}
+
+// ActivateScheduledPrices applies every scheduled price that has taken effect
+// and returns how many were activated
+// It is run periodically by the scheduler; each price is applied in its own
+// unit of work, the earliest first, so a product ends up at its latest price
+func (uc *PricingUseCase) ActivateScheduledPrices(ctx context.Context) (int, error) {
+	activated := 0
+	for {
+		now := time.Now().UTC()
+		prices, err := uc.priceRepo.GetDue(ctx, now, activateBatchSize)
+		if err != nil {
+			return activated, err
+		}
+
+		for _, price := range prices {
+			err := uc.productUseCase.applyScheduledPrice(ctx, price, now)
+			switch {
+			case err == nil:
+				activated++
+			case errors.Is(err, repositories.ErrNotFound):
+				// Activated or cancelled since it was read
+			default:
+				return activated, fmt.Errorf("failed to activate price %d of product %s: %w", price.ID, price.ProductID, err)
+			}
+		}
+
+		if len(prices) < activateBatchSize {
+			return activated, nil
+		}
+	}
+}
+
+// GetPriceHistory lists every list price a product has had or is scheduled to
+// have, oldest first, with the units sold and revenue booked against the
+// product while each price was in effect
+func (uc *PricingUseCase) GetPriceHistory(ctx context.Context, productID string) ([]*entities.PricePeriod, error) {
+	if _, err := uc.productUseCase.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	prices, err := uc.priceRepo.GetByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]*entities.PricePeriod, len(prices))
+	for i, price := range prices {
+		periods[i] = &entities.PricePeriod{ProductPrice: price, Revenue: entities.NewMoney(0, "")}
+		if price.IsScheduled() {
+			continue
+		}
+
+		sales, err := uc.transactionRepo.GetProductSales(ctx, productID, price.EffectiveFrom, price.EffectiveTo)
+		if err != nil {
+			return nil, err
+		}
+		periods[i].UnitsSold = sales.QuantitySold
+		periods[i].Revenue = sales.TotalRevenue
+	}
+
+	return periods, nil
+}
+
+// QuotePrice prices quantity units of a product for a customer as an order
+// placed now would; without a customer the retail price list applies
+func (uc *PricingUseCase) QuotePrice(ctx context.Context, productID, customerID string, quantity int) (*entities.PriceQuote, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than zero: %d", ErrInvalidOrderItems, quantity)
+	}
+
+	product, err := uc.productUseCase.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Type == entities.ProductTypeParent {
+		return nil, fmt.Errorf("%w: %s; quote one of its variants", entities.ErrProductHasVariants, product.ID)
+	}
+
+	priceList := entities.PriceListRetail
+	if customerID != "" {
+		customer, err := uc.customerRepo.GetByID(ctx, customerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get customer: %w", err)
+		}
+		priceList = customer.PriceList
+	}
+
+	quotes, err := uc.quote(ctx, priceList, map[*entities.Product]int{product: quantity}, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	quote := quotes[product.ID]
+	quote.CustomerID = customerID
+	return quote, nil
+}
+
+// priceOrder prices every line of an order for its customer at the order's
+// creation time; products holds each line's product by ID
+func (uc *PricingUseCase) priceOrder(ctx context.Context, order *entities.Order, products map[string]*entities.Product) error {
+	quantities := make(map[*entities.Product]int, len(order.Lines))
+	for _, line := range order.OrderLines() {
+		quantities[products[line.ProductID]] = line.Quantity
+	}
+
+	priceList := entities.PriceListRetail
+	if order.Customer != nil && order.Customer.PriceList != "" {
+		priceList = order.Customer.PriceList
+	}
+
+	quotes, err := uc.quote(ctx, priceList, quantities, order.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, line := range order.OrderLines() {
+		if err := line.ApplyQuote(quotes[line.ProductID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quote prices each product for its quantity with the rules in effect at the given time
+func (uc *PricingUseCase) quote(ctx context.Context, priceList entities.PriceList, quantities map[*entities.Product]int, at time.Time) (map[string]*entities.PriceQuote, error) {
+	// Rules for a parent price its variants too
+	productIDs := make([]string, 0, len(quantities))
+	for product := range quantities {
+		productIDs = append(productIDs, product.ID)
+		if product.ParentID != "" {
+			productIDs = append(productIDs, product.ParentID)
+		}
+	}
+
+	rules, err := uc.priceRuleRepo.GetActive(ctx, at, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make(map[string]*entities.PriceQuote, len(quantities))
+	for product, quantity := range quantities {
+		quote, err := entities.NewPriceQuote(product, priceList, quantity, rules, at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price product %s: %w", product.ID, err)
+		}
+		quotes[product.ID] = quote
+	}
+	return quotes, nil
+}
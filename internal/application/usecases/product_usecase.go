@@ -2,9 +2,9 @@ package usecases
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"math/big"
+	"strings"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -12,34 +12,192 @@ import (
 )
 
 // ProductUseCase encapsulates business logic for product operations
+// Every change to stock on hand is made at a location and recorded in the
+// inventory movement ledger in the same unit of work as the change itself;
+// the product's quantity is the total over all locations
+// Stock coming in opens a cost batch and moves the average cost; stock going
+// out draws on the oldest batches, and its movement is costed by the
+// configured valuation method
+// Every price a product is given is recorded in its price history
 type ProductUseCase struct {
-	productRepo repositories.ProductRepository
+	productRepo     repositories.ProductRepository
+	locationRepo    repositories.LocationRepository
+	movementRepo    repositories.MovementRepository
+	batchRepo       repositories.BatchRepository
+	supplierRepo    repositories.SupplierRepository
+	categoryRepo    repositories.CategoryRepository
+	priceRepo       repositories.ProductPriceRepository
+	unitOfWork      repositories.UnitOfWork
+	idGenerator     repositories.IDGenerator
+	valuationMethod entities.ValuationMethod
 }
 
 // NewProductUseCase creates a new product use case
-func NewProductUseCase(productRepo repositories.ProductRepository) *ProductUseCase {
+// An empty valuation method uses FIFO
+func NewProductUseCase(
+	productRepo repositories.ProductRepository,
+	locationRepo repositories.LocationRepository,
+	movementRepo repositories.MovementRepository,
+	batchRepo repositories.BatchRepository,
+	supplierRepo repositories.SupplierRepository,
+	categoryRepo repositories.CategoryRepository,
+	priceRepo repositories.ProductPriceRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+	valuationMethod entities.ValuationMethod,
+) *ProductUseCase {
+	if valuationMethod == "" {
+		valuationMethod = entities.ValuationFIFO
+	}
+
 	return &ProductUseCase{
-		productRepo: productRepo,
+		productRepo:     productRepo,
+		locationRepo:    locationRepo,
+		movementRepo:    movementRepo,
+		batchRepo:       batchRepo,
+		supplierRepo:    supplierRepo,
+		categoryRepo:    categoryRepo,
+		priceRepo:       priceRepo,
+		unitOfWork:      unitOfWork,
+		idGenerator:     idGenerator,
+		valuationMethod: valuationMethod,
 	}
 }
 
 // CreateProductRequest represents the request to create a product
 type CreateProductRequest struct {
-	ProductName string  `json:"product_name" binding:"required"`
-	Price       float64 `json:"price" binding:"required,gt=0"`
-	Quantity    int     `json:"quantity" binding:"required,gte=0"`
+	ProductName string         `json:"product_name" binding:"required"`
+	Price       entities.Money `json:"price"`
+	Quantity    int            `json:"quantity" binding:"required_unless=Type parent|required_unless=Type bundle,gte=0"`
+
+	// Type is simple (the default), parent or bundle; a parent holds no stock
+	// and is sold through the variants added to it, and a bundle holds none
+	// and is sold as a kit of its Components at its own price
+	Type       entities.ProductType     `json:"type,omitempty"`
+	Components []BundleComponentRequest `json:"components,omitempty" binding:"omitempty,dive"`
+
+	// LocationID is where the opening stock is held; it defaults to the default location
+	LocationID string `json:"location_id,omitempty"`
+
+	// CostPrice is what each unit of the opening stock cost
+	CostPrice entities.Money `json:"cost_price,omitempty"`
+
+	// Replenishment policy used by reorder suggestions
+	ReorderPoint    int    `json:"reorder_point,omitempty" binding:"gte=0"`
+	ReorderQuantity int    `json:"reorder_quantity,omitempty" binding:"gte=0"`
+	LeadTimeDays    int    `json:"lead_time_days,omitempty" binding:"gte=0"`
+	SupplierID      string `json:"supplier_id,omitempty"`
+
+	// Catalogue details; SKU and barcode must not be used by another product
+	SKU         string            `json:"sku,omitempty"`
+	Barcode     string            `json:"barcode,omitempty"`
+	Description string            `json:"description,omitempty"`
+	CategoryID  string            `json:"category_id,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+
+	// TaxClass picks the tax rates the product is sold under; without one it is not taxed
+	TaxClass string `json:"tax_class,omitempty"`
 }
 
 // UpdateProductRequest represents the request to update a product
 type UpdateProductRequest struct {
-	Price    *float64 `json:"price,omitempty" binding:"omitempty,gt=0"`
-	Quantity *int     `json:"quantity,omitempty" binding:"omitempty,gte=0"`
+	Price    *entities.Money `json:"price,omitempty"`
+	Quantity *int            `json:"quantity,omitempty" binding:"omitempty,gte=0"`
+
+	// Reason is required when the quantity changes: adjustment, stocktake, damage or receipt
+	// The change is made at LocationID, or at the default location when it is empty
+	Reason      entities.MovementReason `json:"reason,omitempty"`
+	LocationID  string                  `json:"location_id,omitempty"`
+	Actor       string                  `json:"actor,omitempty"`
+	ReferenceID string                  `json:"reference_id,omitempty"`
+	Note        string                  `json:"note,omitempty"`
+
+	// UnitCost is what each unit added cost; without it units added are costed
+	// at the average cost. It is ignored when the quantity goes down
+	UnitCost *entities.Money `json:"unit_cost,omitempty"`
+
+	// Replenishment policy; an empty SupplierID removes the preferred supplier
+	ReorderPoint    *int    `json:"reorder_point,omitempty" binding:"omitempty,gte=0"`
+	ReorderQuantity *int    `json:"reorder_quantity,omitempty" binding:"omitempty,gte=0"`
+	LeadTimeDays    *int    `json:"lead_time_days,omitempty" binding:"omitempty,gte=0"`
+	SupplierID      *string `json:"supplier_id,omitempty"`
+
+	// Catalogue details; an empty string removes the SKU, barcode or category
+	// Attributes, when given, replace all of the product's attributes
+	SKU         *string                 `json:"sku,omitempty"`
+	Barcode     *string                 `json:"barcode,omitempty"`
+	Description *string                 `json:"description,omitempty"`
+	CategoryID  *string                 `json:"category_id,omitempty"`
+	Attributes  map[string]string       `json:"attributes,omitempty"`
+	Status      *entities.ProductStatus `json:"status,omitempty"`
+
+	// TaxClass, when given, changes the tax class; an empty string stops taxing the product
+	TaxClass *string `json:"tax_class,omitempty"`
+}
+
+// BundleComponentRequest is one product in a bundle and how many units of it each bundle holds
+type BundleComponentRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// CreateVariantRequest represents the request to add a variant to a parent product
+type CreateVariantRequest struct {
+	// ProductName defaults to the parent's name followed by the attribute values
+	ProductName string `json:"product_name,omitempty"`
+	// Price overrides the parent's price; without it the variant follows the parent
+	Price    *entities.Money `json:"price,omitempty"`
+	Quantity int             `json:"quantity" binding:"gte=0"`
+
+	// LocationID and CostPrice are where the opening stock is held and what each unit cost
+	LocationID string         `json:"location_id,omitempty"`
+	CostPrice  entities.Money `json:"cost_price,omitempty"`
+
+	// Replenishment policy used by reorder suggestions
+	ReorderPoint    int    `json:"reorder_point,omitempty" binding:"gte=0"`
+	ReorderQuantity int    `json:"reorder_quantity,omitempty" binding:"gte=0"`
+	LeadTimeDays    int    `json:"lead_time_days,omitempty" binding:"gte=0"`
+	SupplierID      string `json:"supplier_id,omitempty"`
+
+	// Attributes set the variant apart from its siblings, such as size and colour
+	SKU        string            `json:"sku,omitempty"`
+	Barcode    string            `json:"barcode,omitempty"`
+	Attributes map[string]string `json:"attributes" binding:"required"`
+}
+
+// ProductQuery filters the product catalogue
+// Variants are listed under their parents, except when looking up a SKU or barcode
+type ProductQuery struct {
+	CategoryID string            // the category or any category below it
+	Attributes map[string]string // every attribute must match, on a product or on one of its variants
+	Status     string            // active (the default), archived or all
+	SKU        string
+	Barcode    string
+	Limit      int
+	Offset     int
 }
 
+// ProductStatusAll lists products whatever their status
+const ProductStatusAll = "all"
+
 // CreateProduct creates a new product
 func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductRequest) (*entities.Product, error) {
+	if err := validateRequestedAmount("price", req.Price, false); err != nil {
+		return nil, err
+	}
+	if err := validateRequestedAmount("cost_price", req.CostPrice, true); err != nil {
+		return nil, err
+	}
+	productType := req.Type
+	if productType == "" {
+		productType = entities.ProductTypeSimple
+	}
+	if productType == entities.ProductTypeVariant {
+		return nil, fmt.Errorf("%w: variants are added to their parent product", entities.ErrInvalidProduct)
+	}
+
 	// Generate unique product ID
-	id, err := generateProductID()
+	id, err := uc.idGenerator.NewID(ctx, entities.ProductIDPrefix)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate product ID: %w", err)
 	}
@@ -50,24 +208,159 @@ func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductR
 		ProductName: req.ProductName,
 		Price:       req.Price,
 		Quantity:    req.Quantity,
+		AverageCost: req.CostPrice,
+		Description: strings.TrimSpace(req.Description),
+		Status:      entities.ProductStatusActive,
+		TaxClass:    entities.NormalizeTaxClass(req.TaxClass),
+		Type:        productType,
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
 	}
+	if err := product.UpdateReorderPolicy(req.ReorderPoint, req.ReorderQuantity, req.LeadTimeDays); err != nil {
+		return nil, fmt.Errorf("product validation failed: %w", err)
+	}
+	if err := uc.setSupplier(ctx, product, req.SupplierID); err != nil {
+		return nil, err
+	}
+	product.SetSKU(req.SKU)
+	product.SetBarcode(req.Barcode)
+	if err := product.SetAttributes(req.Attributes); err != nil {
+		return nil, fmt.Errorf("product validation failed: %w", err)
+	}
+	if err := uc.setCategory(ctx, product, req.CategoryID); err != nil {
+		return nil, err
+	}
+	for _, component := range req.Components {
+		if err := uc.addComponent(ctx, product, component); err != nil {
+			return nil, err
+		}
+	}
 
 	// Validate business rules
 	if err := product.Validate(); err != nil {
 		return nil, fmt.Errorf("product validation failed: %w", err)
 	}
 
-	// Save to repository
-	if err := uc.productRepo.Create(ctx, product); err != nil {
-		return nil, fmt.Errorf("failed to create product: %w", err)
+	if err := uc.createWithStock(ctx, product, req.LocationID, req.CostPrice); err != nil {
+		return nil, err
+	}
+	if product.Type == entities.ProductTypeBundle {
+		// Read back with the components' stock
+		return uc.GetProduct(ctx, product.ID)
 	}
 
 	return product, nil
 }
 
-// GetProduct retrieves a product by ID
+// addComponent puts an existing product in a bundle
+func (uc *ProductUseCase) addComponent(ctx context.Context, bundle *entities.Product, req BundleComponentRequest) error {
+	component, err := uc.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		return fmt.Errorf("failed to get component: %w", err)
+	}
+	if err := bundle.AddComponent(component, req.Quantity); err != nil {
+		return fmt.Errorf("product validation failed: %w", err)
+	}
+	return nil
+}
+
+// CreateVariant adds a variant to a parent product
+// The variant shares the parent's category, and its price unless one is given;
+// no two variants of a parent can have the same attributes
+func (uc *ProductUseCase) CreateVariant(ctx context.Context, parentID string, req *CreateVariantRequest) (*entities.Product, error) {
+	if req.Price != nil {
+		if err := validateRequestedAmount("price", *req.Price, false); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateRequestedAmount("cost_price", req.CostPrice, true); err != nil {
+		return nil, err
+	}
+
+	parent, err := uc.GetProduct(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uc.idGenerator.NewID(ctx, entities.ProductIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate product ID: %w", err)
+	}
+
+	variant, err := parent.NewVariant(id, strings.TrimSpace(req.ProductName), req.Price, req.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("product validation failed: %w", err)
+	}
+	variant.Quantity = req.Quantity
+	variant.AverageCost = req.CostPrice
+	if err := variant.UpdateReorderPolicy(req.ReorderPoint, req.ReorderQuantity, req.LeadTimeDays); err != nil {
+		return nil, fmt.Errorf("product validation failed: %w", err)
+	}
+	if err := uc.setSupplier(ctx, variant, req.SupplierID); err != nil {
+		return nil, err
+	}
+	variant.SetSKU(req.SKU)
+	variant.SetBarcode(req.Barcode)
+
+	if err := variant.Validate(); err != nil {
+		return nil, fmt.Errorf("product validation failed: %w", err)
+	}
+	for _, sibling := range parent.Variants {
+		if sibling.SameAttributes(variant) {
+			return nil, fmt.Errorf("product %s already has variant %s with attributes %s: %w",
+				parent.ID, sibling.ID, variant.AttributeSummary(), repositories.ErrAlreadyExists)
+		}
+	}
+
+	if err := uc.createWithStock(ctx, variant, req.LocationID, req.CostPrice); err != nil {
+		return nil, err
+	}
+
+	return variant, nil
+}
+
+// GetVariants lists the variants of a parent product, oldest first
+func (uc *ProductUseCase) GetVariants(ctx context.Context, parentID string) ([]*entities.Product, error) {
+	parent, err := uc.GetProduct(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Type != entities.ProductTypeParent {
+		return nil, fmt.Errorf("%w: %s is not a parent product", entities.ErrInvalidProduct, parentID)
+	}
+
+	return parent.Variants, nil
+}
+
+// createWithStock saves a new product with its opening price, and its opening
+// stock received at the location as the first movement
+func (uc *ProductUseCase) createWithStock(ctx context.Context, product *entities.Product, locationID string, costPrice entities.Money) error {
+	location, err := uc.stockLocation(ctx, locationID)
+	if err != nil {
+		return err
+	}
+
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.productRepo.Create(ctx, product); err != nil {
+			return fmt.Errorf("failed to create product: %w", err)
+		}
+		if err := uc.recordPrice(ctx, product, product.CreatedAt, ""); err != nil {
+			return err
+		}
+		if product.Quantity == 0 {
+			return nil
+		}
+		_, err := uc.moveLocationStock(ctx, product.ID, product.Quantity, entities.MovementSource{
+			Reason:     entities.MovementReasonReceipt,
+			LocationID: location.ID,
+			Note:       "Opening stock",
+			UnitCost:   &costPrice,
+		})
+		return err
+	})
+}
+
+// GetProduct retrieves a product by ID, with its variants when it is a parent
 func (uc *ProductUseCase) GetProduct(ctx context.Context, id string) (*entities.Product, error) {
 	if id == "" {
 		return nil, fmt.Errorf("product ID is required")
@@ -77,29 +370,131 @@ func (uc *ProductUseCase) GetProduct(ctx context.Context, id string) (*entities.
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
+	if err := uc.withVariants(ctx, product); err != nil {
+		return nil, err
+	}
 
 	return product, nil
 }
 
-// GetAllProducts retrieves all products with pagination
-func (uc *ProductUseCase) GetAllProducts(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
-	if limit <= 0 {
-		limit = 50 // Default limit
+// GetSellableProduct retrieves a product that can be ordered; archived products fail with ErrProductArchived
+// and parent products with ErrProductHasVariants
+func (uc *ProductUseCase) GetSellableProduct(ctx context.Context, id string) (*entities.Product, error) {
+	product, err := uc.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	if offset < 0 {
-		offset = 0
+	if err := uc.checkSellable(ctx, product); err != nil {
+		return nil, err
 	}
 
-	products, err := uc.productRepo.GetAll(ctx, limit, offset)
+	return product, nil
+}
+
+// checkSellable checks the product can be ordered; a variant cannot be while
+// its parent is archived, nor a bundle while any of its components cannot be
+func (uc *ProductUseCase) checkSellable(ctx context.Context, product *entities.Product) error {
+	if err := product.CheckSellable(); err != nil {
+		return err
+	}
+	for _, component := range product.Components {
+		if err := uc.checkSellable(ctx, component.Product); err != nil {
+			return fmt.Errorf("bundle %s: %w", product.ID, err)
+		}
+	}
+	if !product.IsVariant() {
+		return nil
+	}
+
+	parent, err := uc.productRepo.GetByID(ctx, product.ParentID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent product: %w", err)
+	}
+	if parent.Status == entities.ProductStatusArchived {
+		return fmt.Errorf("%w: %s is a variant of %s", entities.ErrProductArchived, product.ID, parent.ID)
+	}
+	return nil
+}
+
+// withVariants loads the variants of the parents among products
+func (uc *ProductUseCase) withVariants(ctx context.Context, products ...*entities.Product) error {
+	parents := make(map[string]*entities.Product)
+	for _, product := range products {
+		if product.Type == entities.ProductTypeParent {
+			parents[product.ID] = product
+			product.Variants = []*entities.Product{}
+		}
+	}
+	if len(parents) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(parents))
+	for id := range parents {
+		ids = append(ids, id)
+	}
+	variants, err := uc.productRepo.GetVariants(ctx, ids...)
+	if err != nil {
+		return fmt.Errorf("failed to get variants: %w", err)
+	}
+	for _, variant := range variants {
+		parent := parents[variant.ParentID]
+		parent.Variants = append(parent.Variants, variant)
+	}
+	return nil
+}
+
+// GetProducts lists the catalogue with pagination, filtered by query
+// A category includes the products of all its subcategories
+func (uc *ProductUseCase) GetProducts(ctx context.Context, query *ProductQuery) ([]*entities.Product, error) {
+	filter := repositories.ProductFilter{
+		Attributes: make(map[string]string, len(query.Attributes)),
+		SKU:        strings.ToUpper(strings.TrimSpace(query.SKU)),
+		Barcode:    strings.TrimSpace(query.Barcode),
+		Limit:      query.Limit,
+		Offset:     max(query.Offset, 0),
+	}
+	filter.TopLevel = filter.SKU == "" && filter.Barcode == ""
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // Default limit
+	}
+
+	switch status := query.Status; status {
+	case "":
+		filter.Status = entities.ProductStatusActive
+	case ProductStatusAll:
+	default:
+		if filter.Status = entities.ProductStatus(status); !filter.Status.IsValid() {
+			return nil, fmt.Errorf("%w: unknown status %q", entities.ErrInvalidProduct, status)
+		}
+	}
+
+	for name, value := range query.Attributes {
+		filter.Attributes[entities.NormalizeAttributeName(name)] = strings.TrimSpace(value)
+	}
+
+	if query.CategoryID != "" {
+		category, err := uc.categoryRepo.GetByID(ctx, query.CategoryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		filter.CategoryPath = category.Path
+	}
+
+	products, err := uc.productRepo.Find(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
+	if err := uc.withVariants(ctx, products...); err != nil {
+		return nil, err
+	}
 
 	return products, nil
 }
 
-// UpdateProduct updates a product's price and/or quantity
-func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest) (*entities.Product, error) {
+// UpdateProduct updates a product's price, quantity, replenishment policy and catalogue details
+// expectedVersion is the version the caller last read; a stale version is rejected
+func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, expectedVersion int, req *UpdateProductRequest) (*entities.Product, error) {
 	if id == "" {
 		return nil, fmt.Errorf("product ID is required")
 	}
@@ -110,14 +505,74 @@ func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *Upd
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
 
+	if product.Version != expectedVersion {
+		return nil, fmt.Errorf("product with ID %s is at version %d, not %d: %w",
+			id, product.Version, expectedVersion, repositories.ErrVersionConflict)
+	}
+
+	// A parent's price, category and tax class carry over to its variants
+	if !product.Type.HoldsStock() && req.Quantity != nil && *req.Quantity != 0 {
+		return nil, fmt.Errorf("%w: a %s product holds no stock of its own", entities.ErrInvalidProduct, product.Type)
+	}
+	if product.IsVariant() && req.CategoryID != nil {
+		return nil, fmt.Errorf("%w: a variant is filed under its parent's category", entities.ErrInvalidProduct)
+	}
+	if product.IsVariant() && req.TaxClass != nil {
+		return nil, fmt.Errorf("%w: a variant is taxed under its parent's tax class", entities.ErrInvalidProduct)
+	}
+	price, categoryID, taxClass := product.Price, product.CategoryID, product.TaxClass
+
 	// Update fields if provided
 	if req.Price != nil {
+		if err := validateRequestedAmount("price", *req.Price, false); err != nil {
+			return nil, err
+		}
 		if err := product.UpdatePrice(*req.Price); err != nil {
 			return nil, fmt.Errorf("failed to update price: %w", err)
 		}
+		product.PriceOverride = product.IsVariant()
 	}
 
-	if req.Quantity != nil {
+	if req.ReorderPoint != nil || req.ReorderQuantity != nil || req.LeadTimeDays != nil {
+		err := product.UpdateReorderPolicy(
+			valueOr(req.ReorderPoint, product.ReorderPoint),
+			valueOr(req.ReorderQuantity, product.ReorderQuantity),
+			valueOr(req.LeadTimeDays, product.LeadTimeDays),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update reorder policy: %w", err)
+		}
+	}
+	if req.SupplierID != nil {
+		if err := uc.setSupplier(ctx, product, *req.SupplierID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.updateCatalogue(ctx, product, req); err != nil {
+		return nil, err
+	}
+
+	if req.UnitCost != nil {
+		if err := validateRequestedAmount("unit_cost", *req.UnitCost, true); err != nil {
+			return nil, err
+		}
+	}
+
+	before := product.Quantity
+	var location *entities.Location
+	if req.Quantity != nil && *req.Quantity != before {
+		if !req.Reason.IsManual() || req.Reason == entities.MovementReasonTransfer {
+			return nil, fmt.Errorf("%w: changing the quantity requires a reason (adjustment, stocktake, damage or receipt)",
+				entities.ErrInvalidMovement)
+		}
+		if location, err = uc.stockLocation(ctx, req.LocationID); err != nil {
+			return nil, err
+		}
+		// Reserved units are promised to their holders; only the rest can be taken away
+		if *req.Quantity < product.Reserved {
+			return nil, &InsufficientStockError{ProductID: product.ID, Available: product.Available(), Requested: before - *req.Quantity}
+		}
 		if err := product.UpdateQuantity(*req.Quantity); err != nil {
 			return nil, fmt.Errorf("failed to update quantity: %w", err)
 		}
@@ -128,14 +583,156 @@ func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *Upd
 		return nil, fmt.Errorf("product validation failed: %w", err)
 	}
 
-	// Save to repository
-	if err := uc.productRepo.Update(ctx, product); err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+	// Save to repository; the version check guarantees no other change to the
+	// quantity slipped in between the read and the movement
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.productRepo.Update(ctx, product); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		if product.Type == entities.ProductTypeParent &&
+			(!product.Price.Equal(price) || product.CategoryID != categoryID || product.TaxClass != taxClass) {
+			if err := uc.productRepo.SyncVariants(ctx, product); err != nil {
+				return err
+			}
+		}
+		if !product.Price.Equal(price) {
+			if err := uc.recordPriceChange(ctx, product, price, product.UpdatedAt, req.Actor); err != nil {
+				return err
+			}
+		}
+		if product.Quantity == before {
+			return nil
+		}
+		movement, err := uc.moveLocationStock(ctx, product.ID, product.Quantity-before, entities.MovementSource{
+			Reason:      req.Reason,
+			LocationID:  location.ID,
+			Actor:       req.Actor,
+			ReferenceID: req.ReferenceID,
+			Note:        req.Note,
+			UnitCost:    req.UnitCost,
+		})
+		if err != nil || movement.Change < 0 {
+			return err
+		}
+		// Keep the returned product in step with the average the units added moved
+		return product.AddCost(movement.Change, movement.Cost.Div(movement.Change))
+	})
+	if err != nil {
+		if location != nil && errors.Is(err, repositories.ErrInsufficientStock) {
+			return nil, uc.stockError(ctx, id, before-product.Quantity, location.ID, "failed to update product", err)
+		}
+		return nil, err
+	}
+	if err := uc.withVariants(ctx, product); err != nil {
+		return nil, err
 	}
 
 	return product, nil
 }
 
+// applyScheduledPrice makes a due scheduled price the product's price, and
+// that of the variants following it when the product is a parent
+// A price that a later one has already replaced is only marked as activated
+func (uc *ProductUseCase) applyScheduledPrice(ctx context.Context, scheduled *entities.ProductPrice, at time.Time) error {
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		scheduled.ActivatedAt = &at
+		if err := uc.priceRepo.Activate(ctx, scheduled); err != nil {
+			return err
+		}
+		if scheduled.EffectiveTo != nil && !scheduled.EffectiveTo.After(at) {
+			return nil
+		}
+
+		product, err := uc.productRepo.GetByID(ctx, scheduled.ProductID)
+		if err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		previous := product.Price
+		if err := product.UpdatePrice(scheduled.Price); err != nil {
+			return fmt.Errorf("failed to update price: %w", err)
+		}
+		product.PriceOverride = product.IsVariant()
+
+		if err := uc.productRepo.Update(ctx, product); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		if product.Type != entities.ProductTypeParent || previous.Equal(product.Price) {
+			return nil
+		}
+		if err := uc.productRepo.SyncVariants(ctx, product); err != nil {
+			return err
+		}
+		return uc.recordVariantPrices(ctx, product, previous, scheduled.EffectiveFrom, scheduled.Actor)
+	})
+}
+
+// recordPriceChange records the product's new price from the given time, and
+// the new price of every variant following it when the product is a parent;
+// previous is the price it replaces
+func (uc *ProductUseCase) recordPriceChange(ctx context.Context, product *entities.Product, previous entities.Money, from time.Time, actor string) error {
+	if err := uc.backfillPrice(ctx, product, previous); err != nil {
+		return err
+	}
+	if err := uc.recordPrice(ctx, product, from, actor); err != nil {
+		return err
+	}
+	if product.Type != entities.ProductTypeParent {
+		return nil
+	}
+	return uc.recordVariantPrices(ctx, product, previous, from, actor)
+}
+
+// recordVariantPrices records the parent's price for every variant following it
+func (uc *ProductUseCase) recordVariantPrices(ctx context.Context, parent *entities.Product, previous entities.Money, from time.Time, actor string) error {
+	variants, err := uc.productRepo.GetVariants(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get variants: %w", err)
+	}
+
+	for _, variant := range variants {
+		if variant.PriceOverride {
+			continue
+		}
+		if err := uc.backfillPrice(ctx, variant, previous); err != nil {
+			return err
+		}
+		if err := uc.recordPrice(ctx, variant, from, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillPrice records the price a product has had since it was created when
+// it has no price history yet, as is the case for products added before
+// prices were recorded
+func (uc *ProductUseCase) backfillPrice(ctx context.Context, product *entities.Product, price entities.Money) error {
+	history, err := uc.priceRepo.GetByProductID(ctx, product.ID)
+	if err != nil || len(history) > 0 {
+		return err
+	}
+
+	return uc.priceRepo.Record(ctx, &entities.ProductPrice{
+		ProductID:     product.ID,
+		Price:         price,
+		EffectiveFrom: product.CreatedAt,
+		ActivatedAt:   &product.CreatedAt,
+		CreatedAt:     time.Now().UTC(),
+	})
+}
+
+// recordPrice records the product's current price as in effect from the given time
+func (uc *ProductUseCase) recordPrice(ctx context.Context, product *entities.Product, from time.Time, actor string) error {
+	return uc.priceRepo.Record(ctx, &entities.ProductPrice{
+		ProductID:     product.ID,
+		Price:         product.Price,
+		EffectiveFrom: from,
+		ActivatedAt:   &from,
+		Actor:         actor,
+		CreatedAt:     time.Now().UTC(),
+	})
+}
+
 // GetAvailableProducts gets products that have quantity > 0
 func (uc *ProductUseCase) GetAvailableProducts(ctx context.Context) ([]*entities.Product, error) {
 	products, err := uc.productRepo.GetAvailableProducts(ctx)
@@ -146,21 +743,550 @@ func (uc *ProductUseCase) GetAvailableProducts(ctx context.Context) ([]*entities
 	return products, nil
 }
 
-// CheckProductAvailability checks if a product has sufficient quantity
+// CheckProductAvailability checks if a product can be sold and has sufficient quantity
+// A bundle is available as many times as its components' stock makes it up
 func (uc *ProductUseCase) CheckProductAvailability(ctx context.Context, productID string, requestedQuantity int) (*entities.Product, error) {
 	product, err := uc.productRepo.GetByID(ctx, productID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
+	if err := uc.checkSellable(ctx, product); err != nil {
+		return product, err
+	}
 
 	if !product.IsAvailable(requestedQuantity) {
-		return product, fmt.Errorf("insufficient quantity: available=%d, requested=%d",
-			product.Quantity, requestedQuantity)
+		_, _, available := product.Stock()
+		return product, &InsufficientStockError{
+			ProductID: productID,
+			Available: available,
+			Requested: requestedQuantity,
+		}
 	}
 
 	return product, nil
 }
 
+// TakeStock atomically takes quantity out of unheld stock at the source's location;
+// the units leave the product for good, unlike HoldStock which only sets them aside
+// It joins the caller's unit of work when one is active
+func (uc *ProductUseCase) TakeStock(ctx context.Context, productID string, quantity int, source entities.MovementSource) error {
+	err := uc.moveStock(ctx, productID, -quantity, &source, func(ctx context.Context) error {
+		return uc.productRepo.ReduceQuantity(ctx, productID, quantity)
+	})
+	if err != nil {
+		return uc.stockError(ctx, productID, quantity, source.LocationID, "failed to reduce product quantity", err)
+	}
+
+	return nil
+}
+
+// ReturnStock atomically puts quantity back into stock at the source's location
+// It joins the caller's unit of work when one is active
+func (uc *ProductUseCase) ReturnStock(ctx context.Context, productID string, quantity int, source entities.MovementSource) error {
+	err := uc.moveStock(ctx, productID, quantity, &source, func(ctx context.Context) error {
+		return uc.productRepo.IncreaseQuantity(ctx, productID, quantity)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to increase product quantity: %w", err)
+	}
+
+	return nil
+}
+
+// HoldStock atomically sets quantity aside for a reservation at one location
+// and returns that location; the units stay on hand but are no longer
+// available to other orders
+// Without a locationID the hold goes where the most units are unheld, so long
+// as that covers the whole quantity; a hold must be shippable from one place
+func (uc *ProductUseCase) HoldStock(ctx context.Context, productID string, quantity int, locationID string) (*entities.Location, error) {
+	location, err := uc.holdLocation(ctx, productID, quantity, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.productRepo.HoldQuantity(ctx, productID, quantity); err != nil {
+			return err
+		}
+		return uc.locationRepo.HoldStock(ctx, location.ID, productID, quantity)
+	})
+	if err != nil {
+		return nil, uc.stockError(ctx, productID, quantity, location.ID, "failed to hold product quantity", err)
+	}
+
+	return location, nil
+}
+
+// holdLocation returns the requested location, or else the one with the most
+// unheld units of the product, when that is enough for quantity
+func (uc *ProductUseCase) holdLocation(ctx context.Context, productID string, quantity int, locationID string) (*entities.Location, error) {
+	if locationID != "" {
+		return uc.stockLocation(ctx, locationID)
+	}
+
+	levels, err := uc.locationRepo.GetProductStock(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	var best *entities.StockLevel
+	for _, level := range levels {
+		if best == nil || level.Available() > best.Available() {
+			best = level
+		}
+	}
+	if best == nil || best.Available() < quantity {
+		stockErr := &InsufficientStockError{ProductID: productID, Requested: quantity}
+		if best != nil {
+			stockErr.Available = best.Available()
+		}
+		return nil, stockErr
+	}
+
+	return uc.stockLocation(ctx, best.LocationID)
+}
+
+// ConfirmHeldStock atomically takes held quantity out of stock for an order
+// heldAt is the location the units are held at, which they leave from; holds
+// placed before locations were recorded pass none and leave from the source's location
+func (uc *ProductUseCase) ConfirmHeldStock(ctx context.Context, productID string, quantity int, heldAt string, source entities.MovementSource) error {
+	if heldAt != "" {
+		source.LocationID = heldAt
+	}
+	err := uc.moveStock(ctx, productID, -quantity, &source, func(ctx context.Context) error {
+		if err := uc.productRepo.ConfirmHold(ctx, productID, quantity); err != nil {
+			return err
+		}
+		if heldAt == "" {
+			return nil
+		}
+		return uc.locationRepo.ReleaseHold(ctx, heldAt, productID, quantity)
+	})
+	if err != nil {
+		return uc.stockError(ctx, productID, quantity, source.LocationID, "failed to take held product quantity", err)
+	}
+
+	return nil
+}
+
+// ReleaseHeldStock atomically makes quantity held at heldAt available again
+func (uc *ProductUseCase) ReleaseHeldStock(ctx context.Context, productID string, quantity int, heldAt string) error {
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.productRepo.ReleaseHold(ctx, productID, quantity); err != nil {
+			return err
+		}
+		if heldAt == "" {
+			return nil
+		}
+		return uc.locationRepo.ReleaseHold(ctx, heldAt, productID, quantity)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release held product quantity: %w", err)
+	}
+
+	return nil
+}
+
+// GetProductMovements lists the stock movements of a product, newest first,
+// optionally only those with one reason
+func (uc *ProductUseCase) GetProductMovements(ctx context.Context, productID string, reason entities.MovementReason, limit, offset int) ([]*entities.InventoryMovement, error) {
+	if reason != "" && !reason.IsValid() {
+		return nil, fmt.Errorf("%w: invalid reason: %s", entities.ErrInvalidMovement, reason)
+	}
+	if _, err := uc.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	movements, err := uc.movementRepo.Find(ctx, repositories.MovementFilter{
+		ProductID: productID,
+		Reason:    reason,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// TransferStockRequest represents the request to move stock between locations
+type TransferStockRequest struct {
+	FromLocationID string `json:"from_location_id" binding:"required"`
+	ToLocationID   string `json:"to_location_id" binding:"required"`
+	Quantity       int    `json:"quantity" binding:"required,gt=0"`
+	Actor          string `json:"actor,omitempty"`
+	Note           string `json:"note,omitempty"`
+}
+
+// TransferStock moves stock of a product from one location to another
+// The product's total is unchanged; the two movements share the transfer ID as their reference
+func (uc *ProductUseCase) TransferStock(ctx context.Context, productID string, req *TransferStockRequest) ([]*entities.InventoryMovement, error) {
+	if req.FromLocationID == req.ToLocationID {
+		return nil, fmt.Errorf("%w: a transfer needs two different locations", entities.ErrInvalidMovement)
+	}
+	if _, err := uc.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	from, err := uc.stockLocation(ctx, req.FromLocationID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := uc.stockLocation(ctx, req.ToLocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	transferID, err := uc.idGenerator.NewID(ctx, entities.TransferIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate transfer ID: %w", err)
+	}
+
+	source := entities.MovementSource{
+		Reason:      entities.MovementReasonTransfer,
+		Actor:       req.Actor,
+		ReferenceID: transferID,
+		Note:        req.Note,
+	}
+	movements := make([]*entities.InventoryMovement, 0, 2)
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		for _, leg := range []struct {
+			location string
+			change   int
+		}{{from.ID, -req.Quantity}, {to.ID, req.Quantity}} {
+			source.LocationID = leg.location
+			movement, err := uc.moveLocationStock(ctx, productID, leg.change, source)
+			if err != nil {
+				return err
+			}
+			movements = append(movements, movement)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, uc.stockError(ctx, productID, req.Quantity, from.ID, "failed to transfer stock", err)
+	}
+
+	return movements, nil
+}
+
+// GetProductStock lists how much of a product each location holds
+func (uc *ProductUseCase) GetProductStock(ctx context.Context, productID string) ([]*entities.StockLevel, error) {
+	if _, err := uc.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	return uc.locationRepo.GetProductStock(ctx, productID)
+}
+
+// moveStock applies a change to a product's total and to the stock at the
+// source's location, and records it in the ledger
+// An empty source location is resolved to the default location in place
+func (uc *ProductUseCase) moveStock(ctx context.Context, productID string, change int, source *entities.MovementSource, apply func(ctx context.Context) error) error {
+	location, err := uc.stockLocation(ctx, source.LocationID)
+	if err != nil {
+		return err
+	}
+	source.LocationID = location.ID
+
+	return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := apply(ctx); err != nil {
+			return err
+		}
+		_, err := uc.moveLocationStock(ctx, productID, change, *source)
+		return err
+	})
+}
+
+// moveLocationStock changes the stock of a product at the source's location
+// and records the movement with its cost; it must run inside a unit of work,
+// after the product's total has changed
+// The quantity after the change is read back while the updated row is still
+// locked, so concurrent changes cannot blur the before and after quantities
+func (uc *ProductUseCase) moveLocationStock(ctx context.Context, productID string, change int, source entities.MovementSource) (*entities.InventoryMovement, error) {
+	if err := uc.locationRepo.AdjustStock(ctx, source.LocationID, productID, change); err != nil {
+		return nil, err
+	}
+
+	after, err := uc.locationRepo.GetStock(ctx, source.LocationID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transfers leave the product's total, and so its cost, where it was
+	cost := entities.NewMoney(0, "")
+	if source.Reason != entities.MovementReasonTransfer {
+		if cost, err = uc.bookCost(ctx, productID, change, source); err != nil {
+			return nil, err
+		}
+	}
+	return uc.appendMovement(ctx, productID, after-change, after, cost, source)
+}
+
+// bookCost keeps the cost batches and average cost of a product in step with
+// a change to its total, and returns what the units moved cost, with the sign
+// of the change
+// Units coming in open a batch at the source's unit cost, or at the average
+// cost when it has none. Units going out are taken from the oldest batches;
+// any not covered by a batch, such as stock from before batches were kept, are
+// costed at the average cost
+func (uc *ProductUseCase) bookCost(ctx context.Context, productID string, change int, source entities.MovementSource) (entities.Money, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return entities.Money{}, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if change > 0 {
+		unitCost := valueOr(source.UnitCost, product.AverageCost)
+		id, err := uc.idGenerator.NewID(ctx, entities.BatchIDPrefix)
+		if err != nil {
+			return entities.Money{}, fmt.Errorf("failed to generate batch ID: %w", err)
+		}
+		batch, err := entities.NewInventoryBatch(id, productID, source.ReferenceID, change, unitCost)
+		if err != nil {
+			return entities.Money{}, err
+		}
+		if err := uc.batchRepo.Create(ctx, batch); err != nil {
+			return entities.Money{}, err
+		}
+
+		if err := product.AddCost(change, unitCost); err != nil {
+			return entities.Money{}, err
+		}
+		if err := uc.productRepo.UpdateAverageCost(ctx, productID, product.AverageCost); err != nil {
+			return entities.Money{}, err
+		}
+		return unitCost.Mul(change)
+	}
+
+	batches, err := uc.batchRepo.GetOpen(ctx, productID)
+	if err != nil {
+		return entities.Money{}, err
+	}
+	used, taken, cost, err := entities.ConsumeFIFO(batches, -change)
+	if err != nil {
+		return entities.Money{}, err
+	}
+	for _, batch := range used {
+		if err := uc.batchRepo.UpdateRemaining(ctx, batch.ID, batch.Remaining); err != nil {
+			return entities.Money{}, err
+		}
+	}
+
+	if uc.valuationMethod == entities.ValuationWeightedAverage {
+		return product.AverageCost.Mul(change)
+	}
+	unbatched, err := product.AverageCost.Mul(-change - taken)
+	if err != nil {
+		return entities.Money{}, err
+	}
+	cost, err = cost.Add(unbatched)
+	if err != nil {
+		return entities.Money{}, fmt.Errorf("%w: %v", entities.ErrInvalidAmount, err)
+	}
+	return cost.Neg(), nil
+}
+
+// appendMovement writes one entry to the inventory movement ledger
+func (uc *ProductUseCase) appendMovement(ctx context.Context, productID string, before, after int, cost entities.Money, source entities.MovementSource) (*entities.InventoryMovement, error) {
+	source.Actor = actorOrDefault(source.Actor)
+
+	id, err := uc.idGenerator.NewID(ctx, entities.MovementIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate movement ID: %w", err)
+	}
+
+	movement, err := entities.NewInventoryMovement(id, productID, before, after, source)
+	if err != nil {
+		return nil, err
+	}
+	movement.Cost = cost
+	if err := uc.movementRepo.Create(ctx, movement); err != nil {
+		return nil, fmt.Errorf("failed to record inventory movement: %w", err)
+	}
+
+	return movement, nil
+}
+
+// stockCost is a number of units of one product and what they cost
+type stockCost struct {
+	quantity int
+	cost     entities.Money
+}
+
+// saleCosts returns, per product, the units an order took out of stock and
+// what they cost, from the order's sale movements
+func (uc *ProductUseCase) saleCosts(ctx context.Context, orderID string) (map[string]*stockCost, error) {
+	movements, err := uc.movementRepo.Find(ctx, repositories.MovementFilter{
+		Reason:      entities.MovementReasonSale,
+		ReferenceID: orderID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sale movements: %w", err)
+	}
+
+	costs := make(map[string]*stockCost)
+	for _, movement := range movements {
+		sold, ok := costs[movement.ProductID]
+		if !ok {
+			sold = &stockCost{cost: entities.NewMoney(0, "")}
+			costs[movement.ProductID] = sold
+		}
+		sold.quantity -= movement.Change
+		if sold.cost, err = sold.cost.Sub(movement.Cost); err != nil {
+			return nil, fmt.Errorf("%w: %v", entities.ErrInvalidAmount, err)
+		}
+	}
+	return costs, nil
+}
+
+// saleItem is the stock one sale moves: the product sold or, for a bundle,
+// one of its components with its share of the sale's revenue
+type saleItem struct {
+	line      *entities.OrderLine // the order line sold, when there is one
+	productID string
+	quantity  int
+	revenue   entities.Money
+	cost      entities.Money
+	bundled   bool // a component of the bundle that was sold
+}
+
+// saleItems splits quantity units of a product sold for revenue into the stock they move
+func (uc *ProductUseCase) saleItems(ctx context.Context, productID string, quantity int, revenue entities.Money) ([]*saleItem, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product.Type != entities.ProductTypeBundle {
+		return []*saleItem{{productID: productID, quantity: quantity, revenue: revenue}}, nil
+	}
+
+	shares, err := product.AllocateRevenue(revenue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share revenue of bundle %s: %w", product.ID, err)
+	}
+	items := make([]*saleItem, len(product.Components))
+	for i, component := range product.Components {
+		items[i] = &saleItem{
+			productID: component.ProductID,
+			quantity:  quantity * component.Quantity,
+			revenue:   shares[i],
+			bundled:   true,
+		}
+	}
+	return items, nil
+}
+
+// setSupplier makes an existing supplier the product's preferred supplier; an empty ID removes it
+func (uc *ProductUseCase) setSupplier(ctx context.Context, product *entities.Product, supplierID string) error {
+	if supplierID != "" {
+		if _, err := uc.supplierRepo.GetByID(ctx, supplierID); err != nil {
+			return fmt.Errorf("failed to get supplier: %w", err)
+		}
+	}
+
+	product.SupplierID = supplierID
+	return nil
+}
+
+// setCategory files the product under an existing category; an empty ID removes it
+func (uc *ProductUseCase) setCategory(ctx context.Context, product *entities.Product, categoryID string) error {
+	if categoryID != "" {
+		if _, err := uc.categoryRepo.GetByID(ctx, categoryID); err != nil {
+			return fmt.Errorf("failed to get category: %w", err)
+		}
+	}
+
+	product.CategoryID = categoryID
+	return nil
+}
+
+// updateCatalogue applies the catalogue details given in an update request
+func (uc *ProductUseCase) updateCatalogue(ctx context.Context, product *entities.Product, req *UpdateProductRequest) error {
+	if req.SKU != nil {
+		product.SetSKU(*req.SKU)
+	}
+	if req.Barcode != nil {
+		product.SetBarcode(*req.Barcode)
+	}
+	if req.Description != nil {
+		product.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Attributes != nil {
+		if err := product.SetAttributes(req.Attributes); err != nil {
+			return fmt.Errorf("failed to update attributes: %w", err)
+		}
+	}
+	if req.CategoryID != nil {
+		if err := uc.setCategory(ctx, product, *req.CategoryID); err != nil {
+			return err
+		}
+	}
+	if req.Status != nil {
+		if err := product.UpdateStatus(*req.Status); err != nil {
+			return fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+	if req.TaxClass != nil {
+		product.TaxClass = entities.NormalizeTaxClass(*req.TaxClass)
+	}
+	return nil
+}
+
+// valueOr returns the value behind p, or fallback when p is nil
+func valueOr[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
+
+// stockLocation resolves the location stock is moved at; an empty ID means the default location
+func (uc *ProductUseCase) stockLocation(ctx context.Context, id string) (*entities.Location, error) {
+	var location *entities.Location
+	var err error
+	if id == "" {
+		location, err = uc.locationRepo.GetDefault(ctx)
+	} else {
+		location, err = uc.locationRepo.GetByID(ctx, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	return location, nil
+}
+
+// stockError turns a failed conditional stock update into an InsufficientStockError
+// reporting the stock level another order left behind, at the location when one is given
+func (uc *ProductUseCase) stockError(ctx context.Context, productID string, quantity int, locationID, message string, err error) error {
+	if !errors.Is(err, repositories.ErrInsufficientStock) {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	stockErr := &InsufficientStockError{ProductID: productID, Requested: quantity}
+	if product, getErr := uc.productRepo.GetByID(ctx, productID); getErr == nil {
+		stockErr.Available = product.Available()
+	}
+	if locationID != "" {
+		if levels, getErr := uc.locationRepo.GetProductStock(ctx, productID); getErr == nil {
+			atLocation := 0
+			for _, level := range levels {
+				if level.LocationID == locationID {
+					atLocation = level.Available()
+				}
+			}
+			stockErr.Available = min(stockErr.Available, atLocation)
+		}
+	}
+	return stockErr
+}
+
 // SearchProducts searches products by name
 func (uc *ProductUseCase) SearchProducts(ctx context.Context, name string) ([]*entities.Product, error) {
 	if name == "" {
@@ -175,32 +1301,188 @@ func (uc *ProductUseCase) SearchProducts(ctx context.Context, name string) ([]*e
 	return products, nil
 }
 
-// GetLowStockProducts gets products with quantity below threshold
-func (uc *ProductUseCase) GetLowStockProducts(ctx context.Context, threshold int) ([]*entities.Product, error) {
+// GetLowStockProducts gets products with quantity below threshold, at one
+// location or, when locationID is empty, across all locations
+func (uc *ProductUseCase) GetLowStockProducts(ctx context.Context, threshold int, locationID string) ([]*entities.StockLevel, error) {
 	if threshold < 0 {
 		threshold = 5 // Default threshold
 	}
 
+	if locationID != "" {
+		if _, err := uc.stockLocation(ctx, locationID); err != nil {
+			return nil, err
+		}
+		levels, err := uc.locationRepo.GetLowStock(ctx, locationID, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get low stock products: %w", err)
+		}
+		return levels, nil
+	}
+
 	products, err := uc.productRepo.GetLowStockProducts(ctx, threshold)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get low stock products: %w", err)
 	}
 
-	return products, nil
+	levels := make([]*entities.StockLevel, len(products))
+	for i, product := range products {
+		levels[i] = &entities.StockLevel{
+			ProductID:   product.ID,
+			ProductName: product.ProductName,
+			Quantity:    product.Quantity,
+		}
+	}
+	return levels, nil
+}
+
+// GetTotalValue values stock at selling price, at one location or, when
+// locationID is empty, across all locations
+func (uc *ProductUseCase) GetTotalValue(ctx context.Context, locationID string) (entities.Money, error) {
+	if locationID == "" {
+		return uc.productRepo.GetTotalValue(ctx)
+	}
+
+	if _, err := uc.stockLocation(ctx, locationID); err != nil {
+		return entities.Money{}, err
+	}
+	return uc.locationRepo.GetTotalValue(ctx, locationID)
 }
 
-// generateProductID generates a unique product ID in format PROD12345
-func generateProductID() (string, error) {
-	max := big.NewInt(99999)
-	n, err := rand.Int(rand.Reader, max)
+// GetInventoryValuation values stock on hand by a valuation method, at one
+// location or, when locationID is empty, across all locations
+// Retail values stock at selling price as GetTotalValue does. FIFO values the
+// units remaining in open batches at their batch cost, and weighted average at
+// the product's average cost; units no batch covers are valued at the average
+// cost. A location holds its share of each product's value by quantity
+func (uc *ProductUseCase) GetInventoryValuation(ctx context.Context, method entities.ValuationMethod, locationID string) (*entities.InventoryValuation, error) {
+	if method == "" {
+		method = entities.ValuationRetail
+	}
+	if !method.IsValid() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrInvalidValuationMethod, method)
+	}
+
+	valuation := &entities.InventoryValuation{Method: method, LocationID: locationID}
+	if method == entities.ValuationRetail {
+		value, err := uc.GetTotalValue(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		valuation.Value = value
+		return valuation, nil
+	}
+
+	products, err := uc.productRepo.GetStockedProducts(ctx)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	held := make(map[string]int, len(products))
+	for _, product := range products {
+		held[product.ID] = product.Quantity
+	}
+	if locationID != "" {
+		if _, err := uc.stockLocation(ctx, locationID); err != nil {
+			return nil, err
+		}
+		levels, err := uc.locationRepo.GetLocationStock(ctx, locationID)
+		if err != nil {
+			return nil, err
+		}
+		clear(held)
+		for _, level := range levels {
+			held[level.ProductID] = level.Quantity
+		}
+	}
+
+	batches, err := uc.batchRepo.GetOpen(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batched := make(map[string]*stockCost)
+	for _, batch := range batches {
+		open, ok := batched[batch.ProductID]
+		if !ok {
+			open = &stockCost{cost: entities.NewMoney(0, "")}
+			batched[batch.ProductID] = open
+		}
+		open.quantity += batch.Remaining
+		batchCost, err := batch.UnitCost.Mul(batch.Remaining)
+		if err != nil {
+			return nil, err
+		}
+		if open.cost, err = open.cost.Add(batchCost); err != nil {
+			return nil, fmt.Errorf("%w: %v", entities.ErrInvalidAmount, err)
+		}
+	}
+
+	valuation.Value = entities.NewMoney(0, "")
+	valuation.Products = make([]*entities.ProductValuation, 0, len(products))
+	for _, product := range products {
+		quantity := held[product.ID]
+		if quantity <= 0 {
+			continue
+		}
+
+		value, err := product.AverageCost.Mul(product.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if open, ok := batched[product.ID]; ok && method == entities.ValuationFIFO {
+			unbatched, err := product.AverageCost.Mul(max(product.Quantity-open.quantity, 0))
+			if err != nil {
+				return nil, err
+			}
+			if value, err = open.cost.Add(unbatched); err != nil {
+				return nil, fmt.Errorf("%w: %v", entities.ErrInvalidAmount, err)
+			}
+		}
+		if quantity < product.Quantity {
+			value = value.MulRat(int64(quantity), int64(product.Quantity))
+		}
+
+		valuation.Products = append(valuation.Products, &entities.ProductValuation{
+			ProductID:   product.ID,
+			ProductName: product.ProductName,
+			Quantity:    quantity,
+			UnitValue:   value.Div(quantity),
+			Value:       value,
+		})
+		if valuation.Value, err = valuation.Value.Add(value); err != nil {
+			return nil, fmt.Errorf("%w: %v", entities.ErrInvalidAmount, err)
+		}
 	}
 
-	number := n.Int64() + 10000
-	if number > 99999 {
-		number = number%90000 + 10000
+	return valuation, nil
+}
+
+// GetProductBatches lists the cost batches a product's stock came in as, newest first
+func (uc *ProductUseCase) GetProductBatches(ctx context.Context, productID string, limit, offset int) ([]*entities.InventoryBatch, error) {
+	if _, err := uc.GetProduct(ctx, productID); err != nil {
+		return nil, err
 	}
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	batches, err := uc.batchRepo.GetByProduct(ctx, productID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+// InsufficientStockError represents an order for more units than are in stock
+type InsufficientStockError struct {
+	ProductID string
+	Available int
+	Requested int
+}
 
-	return fmt.Sprintf("PROD%05d", number), nil
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient quantity for product %s: available=%d, requested=%d",
+		e.ProductID, e.Available, e.Requested)
 }
@@ -0,0 +1,473 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// PromotionUseCase runs promotions and the coupon codes that unlock them
+// Promotions take money off orders after price rules have set the unit
+// prices: each line gets the best automatic promotion that covers it, and a
+// coupon the customer enters applies its promotion on top of that
+type PromotionUseCase struct {
+	promotionRepo  repositories.PromotionRepository
+	productUseCase *ProductUseCase
+	categoryRepo   repositories.CategoryRepository
+	customerRepo   repositories.CustomerRepository
+	idGenerator    repositories.IDGenerator
+}
+
+// NewPromotionUseCase creates a new promotion use case
+func NewPromotionUseCase(
+	promotionRepo repositories.PromotionRepository,
+	productUseCase *ProductUseCase,
+	categoryRepo repositories.CategoryRepository,
+	customerRepo repositories.CustomerRepository,
+	idGenerator repositories.IDGenerator,
+) *PromotionUseCase {
+	return &PromotionUseCase{
+		promotionRepo:  promotionRepo,
+		productUseCase: productUseCase,
+		categoryRepo:   categoryRepo,
+		customerRepo:   customerRepo,
+		idGenerator:    idGenerator,
+	}
+}
+
+// CreatePromotionRequest represents the request to add a promotion
+// Set DiscountPercent for percent_off, Amount for amount_off and BuyQuantity
+// and GetQuantity for buy_x_get_y
+type CreatePromotionRequest struct {
+	Name            string                 `json:"name" binding:"required"`
+	Type            entities.PromotionType `json:"type" binding:"required,oneof=percent_off amount_off buy_x_get_y"`
+	DiscountPercent entities.Percent       `json:"discount_percent"`
+	Amount          entities.Money         `json:"amount"`
+	BuyQuantity     int                    `json:"buy_quantity" binding:"gte=0"`
+	GetQuantity     int                    `json:"get_quantity" binding:"gte=0"`
+
+	// ProductID or CategoryID limits the promotion; without either it covers everything
+	ProductID  string `json:"product_id,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+
+	// CouponOnly promotions apply only to orders with one of their coupon codes
+	CouponOnly bool `json:"coupon_only"`
+
+	// StartsAt defaults to now; EndsAt to never
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// CreatePromotion adds a promotion
+func (uc *PromotionUseCase) CreatePromotion(ctx context.Context, req *CreatePromotionRequest) (*entities.Promotion, error) {
+	if err := validateRequestedAmount("amount", req.Amount, true); err != nil {
+		return nil, err
+	}
+
+	id, err := uc.idGenerator.NewID(ctx, entities.PromotionIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate promotion ID: %w", err)
+	}
+
+	promotion := &entities.Promotion{
+		ID:              id,
+		Name:            strings.TrimSpace(req.Name),
+		Type:            req.Type,
+		DiscountPercent: req.DiscountPercent,
+		Amount:          req.Amount,
+		BuyQuantity:     req.BuyQuantity,
+		GetQuantity:     req.GetQuantity,
+		ProductID:       strings.TrimSpace(req.ProductID),
+		CategoryID:      strings.TrimSpace(req.CategoryID),
+		CouponOnly:      req.CouponOnly,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if req.StartsAt != nil {
+		startsAt := req.StartsAt.UTC()
+		promotion.StartsAt = &startsAt
+	}
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		promotion.EndsAt = &endsAt
+	}
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+
+	if promotion.ProductID != "" {
+		if _, err := uc.productUseCase.GetProduct(ctx, promotion.ProductID); err != nil {
+			return nil, err
+		}
+	}
+	if promotion.CategoryID != "" {
+		if _, err := uc.categoryRepo.GetByID(ctx, promotion.CategoryID); err != nil {
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+	}
+
+	if err := uc.promotionRepo.Create(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// GetPromotion retrieves a promotion by ID
+func (uc *PromotionUseCase) GetPromotion(ctx context.Context, id string) (*entities.Promotion, error) {
+	promotion, err := uc.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	return promotion, nil
+}
+
+// GetPromotions lists every promotion, oldest first
+func (uc *PromotionUseCase) GetPromotions(ctx context.Context) ([]*entities.Promotion, error) {
+	promotions, err := uc.promotionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotions: %w", err)
+	}
+
+	return promotions, nil
+}
+
+// EndPromotion stops a promotion applying from now on, its coupons included
+// Orders it already discounted keep their discounts
+func (uc *PromotionUseCase) EndPromotion(ctx context.Context, id string) (*entities.Promotion, error) {
+	promotion, err := uc.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := promotion.End(time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := uc.promotionRepo.End(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// CreateCouponRequest represents the request to issue a coupon code for a promotion
+// MaxRedemptions 1 makes a single-use coupon; 0 leaves either limit off
+type CreateCouponRequest struct {
+	Code           string     `json:"code" binding:"required"`
+	MaxRedemptions int        `json:"max_redemptions" binding:"gte=0"`
+	MaxPerCustomer int        `json:"max_per_customer" binding:"gte=0"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateCoupon issues a coupon code for a coupon-only promotion
+func (uc *PromotionUseCase) CreateCoupon(ctx context.Context, promotionID string, req *CreateCouponRequest) (*entities.Coupon, error) {
+	promotion, err := uc.GetPromotion(ctx, promotionID)
+	if err != nil {
+		return nil, err
+	}
+	if !promotion.CouponOnly {
+		return nil, fmt.Errorf("%w: promotion %s applies automatically and needs no coupon", entities.ErrInvalidPromotion, promotion.ID)
+	}
+
+	coupon := &entities.Coupon{
+		Code:           entities.NormalizeCouponCode(req.Code),
+		PromotionID:    promotion.ID,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerCustomer: req.MaxPerCustomer,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		coupon.ExpiresAt = &expiresAt
+	}
+	if err := coupon.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.promotionRepo.CreateCoupon(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// GetCoupons lists a promotion's coupons, oldest first
+func (uc *PromotionUseCase) GetCoupons(ctx context.Context, promotionID string) ([]*entities.Coupon, error) {
+	if _, err := uc.GetPromotion(ctx, promotionID); err != nil {
+		return nil, err
+	}
+
+	coupons, err := uc.promotionRepo.GetCoupons(ctx, promotionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+
+	return coupons, nil
+}
+
+// GetPromotionStats reports the redemptions and discount cost of every
+// promotion redeemed in the period, the costliest first
+func (uc *PromotionUseCase) GetPromotionStats(ctx context.Context, period StatsPeriod) ([]*entities.PromotionStats, error) {
+	start, end := period.timeRange()
+	stats, err := uc.promotionRepo.GetStats(ctx, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotion stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// applyPromotions discounts a priced order at its creation time and returns
+// what each promotion took off, to be recorded with the order
+// Each line gets the automatic promotion that takes the most off it; the
+// promotion of couponCode, if given, then applies to what is left to pay.
+// products holds each line's product by ID
+func (uc *PromotionUseCase) applyPromotions(ctx context.Context, order *entities.Order, products map[string]*entities.Product, couponCode string) ([]*entities.PromotionRedemption, error) {
+	at := order.CreatedAt
+	covers, err := uc.newCoverage(ctx, products)
+	if err != nil {
+		return nil, err
+	}
+
+	automatic, err := uc.promotionRepo.GetAutomatic(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work out every automatic promotion's discount first, so one that is
+	// shared across lines is judged on each line's share
+	lines := order.OrderLines()
+	best := make([]entities.Money, len(lines))
+	bestPromotion := make([]*entities.Promotion, len(lines))
+	for _, promotion := range automatic {
+		covered, index := covers.lines(promotion, lines)
+		if len(covered) == 0 {
+			continue
+		}
+		discounts, err := promotion.Discounts(covered)
+		if err != nil {
+			return nil, fmt.Errorf("promotion %s: %w", promotion.ID, err)
+		}
+		for i, discount := range discounts {
+			if discount.Minor() > best[index[i]].Minor() {
+				best[index[i]], bestPromotion[index[i]] = discount, promotion
+			}
+		}
+	}
+
+	redeemed := newRedemptions(order)
+	for i, line := range lines {
+		if bestPromotion[i] == nil {
+			continue
+		}
+		applied, err := line.ApplyDiscount(best[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := redeemed.add(bestPromotion[i].ID, "", applied); err != nil {
+			return nil, err
+		}
+	}
+
+	if code := entities.NormalizeCouponCode(couponCode); code != "" {
+		if err := uc.applyCoupon(ctx, order, code, covers, redeemed); err != nil {
+			return nil, err
+		}
+		order.CouponCode = code
+	}
+
+	return redeemed.list, nil
+}
+
+// applyCoupon applies the promotion of a coupon to what the order's lines have left to pay
+func (uc *PromotionUseCase) applyCoupon(ctx context.Context, order *entities.Order, code string, covers *coverage, redeemed *redemptions) error {
+	coupon, err := uc.promotionRepo.GetCoupon(ctx, code)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return fmt.Errorf("%w: there is no coupon %s", entities.ErrCouponNotUsable, code)
+	}
+	if err != nil {
+		return err
+	}
+
+	used, err := uc.promotionRepo.CountCustomerRedemptions(ctx, coupon.Code, order.CustomerID)
+	if err != nil {
+		return err
+	}
+	if err := coupon.CheckRedeemable(order.CreatedAt, used); err != nil {
+		return err
+	}
+
+	promotion, err := uc.GetPromotion(ctx, coupon.PromotionID)
+	if err != nil {
+		return err
+	}
+	if !promotion.IsActive(order.CreatedAt) {
+		return fmt.Errorf("%w: the %s promotion is not running", entities.ErrCouponNotUsable, promotion.Name)
+	}
+
+	covered, _ := covers.lines(promotion, order.OrderLines())
+	discounts, err := promotion.Discounts(covered)
+	if err != nil {
+		return fmt.Errorf("promotion %s: %w", promotion.ID, err)
+	}
+
+	total := entities.NewMoney(0, "")
+	for i, line := range covered {
+		applied, err := line.ApplyDiscount(discounts[i])
+		if err != nil {
+			return err
+		}
+		if total, err = total.Add(applied); err != nil {
+			return err
+		}
+	}
+	if !total.IsPositive() {
+		return fmt.Errorf("%w: %s takes nothing off this order", entities.ErrCouponNotUsable, coupon.Code)
+	}
+
+	return redeemed.add(promotion.ID, coupon.Code, total)
+}
+
+// coverage matches promotions to the products on an order
+type coverage struct {
+	products    map[string]*entities.Product
+	categoryIDs map[string][]string // each product's category and every category above it
+}
+
+// newCoverage looks up the categories of products, by ID, for matching category-wide promotions
+func (uc *PromotionUseCase) newCoverage(ctx context.Context, products map[string]*entities.Product) (*coverage, error) {
+	c := &coverage{products: products, categoryIDs: make(map[string][]string, len(products))}
+	paths := make(map[string][]string)
+	for id, product := range products {
+		if product.CategoryID == "" {
+			continue
+		}
+		if _, ok := paths[product.CategoryID]; !ok {
+			category, err := uc.categoryRepo.GetByID(ctx, product.CategoryID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get category: %w", err)
+			}
+			paths[product.CategoryID] = category.AncestorIDs()
+		}
+		c.categoryIDs[id] = paths[product.CategoryID]
+	}
+	return c, nil
+}
+
+// lines returns the lines a promotion covers and the index of each in lines
+func (c *coverage) lines(promotion *entities.Promotion, lines []*entities.OrderLine) ([]*entities.OrderLine, []int) {
+	var covered []*entities.OrderLine
+	var index []int
+	for i, line := range lines {
+		if promotion.Covers(c.products[line.ProductID], c.categoryIDs[line.ProductID]) {
+			covered = append(covered, line)
+			index = append(index, i)
+		}
+	}
+	return covered, index
+}
+
+// redeemPromotions records what promotions took off a placed order and
+// counts the use of its coupon; it must run inside the order's unit of work
+func (uc *PromotionUseCase) redeemPromotions(ctx context.Context, order *entities.Order, redeemed []*entities.PromotionRedemption) error {
+	if order.CouponCode != "" {
+		err := uc.promotionRepo.RedeemCoupon(ctx, order.CouponCode)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("%w: %s has been used up", entities.ErrCouponNotUsable, order.CouponCode)
+		}
+		if err != nil {
+			return err
+		}
+		if err := uc.checkCustomerRedemptions(ctx, order); err != nil {
+			return err
+		}
+	}
+
+	for _, redemption := range redeemed {
+		redemption.OrderID = order.ID
+		if err := uc.promotionRepo.RecordRedemption(ctx, redemption); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCustomerRedemptions counts the customer's uses of the order's coupon
+// again before this one is recorded. The count taken when the order was priced
+// can be stale by now; holding the customer row stops two checkouts of theirs
+// from both passing it. The coupon row is already held, which keeps the lock
+// order the same as cancellation's
+func (uc *PromotionUseCase) checkCustomerRedemptions(ctx context.Context, order *entities.Order) error {
+	coupon, err := uc.promotionRepo.GetCoupon(ctx, order.CouponCode)
+	if err != nil {
+		return err
+	}
+	if coupon.MaxPerCustomer == 0 {
+		return nil
+	}
+
+	if err := uc.customerRepo.LockForUpdate(ctx, order.CustomerID); err != nil {
+		return err
+	}
+	used, err := uc.promotionRepo.CountCustomerRedemptions(ctx, coupon.Code, order.CustomerID)
+	if err != nil {
+		return err
+	}
+	if used >= coupon.MaxPerCustomer {
+		return fmt.Errorf("%w: %s can be used %d times per customer", entities.ErrCouponNotUsable, coupon.Code, coupon.MaxPerCustomer)
+	}
+	return nil
+}
+
+// releasePromotions takes back the promotions redeemed on a cancelled order,
+// so its discount no longer counts and its coupon can be used again; it must
+// run inside the cancellation's unit of work
+func (uc *PromotionUseCase) releasePromotions(ctx context.Context, order *entities.Order) error {
+	if err := uc.promotionRepo.DeleteRedemptionsByOrderID(ctx, order.ID); err != nil {
+		return err
+	}
+	if order.CouponCode != "" {
+		return uc.promotionRepo.ReleaseCoupon(ctx, order.CouponCode)
+	}
+	return nil
+}
+
+// redemptions collects what each promotion took off an order, one entry per promotion
+type redemptions struct {
+	order *entities.Order
+	list  []*entities.PromotionRedemption
+}
+
+// newRedemptions starts collecting the redemptions of an order
+func newRedemptions(order *entities.Order) *redemptions {
+	return &redemptions{order: order}
+}
+
+// add counts a discount towards a promotion
+func (r *redemptions) add(promotionID, couponCode string, discount entities.Money) error {
+	if !discount.IsPositive() {
+		return nil
+	}
+	for _, redemption := range r.list {
+		if redemption.PromotionID == promotionID {
+			var err error
+			redemption.Discount, err = redemption.Discount.Add(discount)
+			return err
+		}
+	}
+
+	r.list = append(r.list, &entities.PromotionRedemption{
+		PromotionID: promotionID,
+		CouponCode:  couponCode,
+		OrderID:     r.order.ID,
+		CustomerID:  r.order.CustomerID,
+		Discount:    discount,
+		RedeemedAt:  r.order.CreatedAt,
+	})
+	return nil
+}
@@ -0,0 +1,458 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// demandWindowDays is how far back sales are looked at to estimate demand over a lead time
+const demandWindowDays = 30
+
+// PurchaseOrderUseCase encapsulates business logic for buying stock from suppliers
+// Stock only arrives through goods receipts; each receipt line records the
+// landed unit cost of the units it brought in
+type PurchaseOrderUseCase struct {
+	purchaseOrderRepo repositories.PurchaseOrderRepository
+	supplierRepo      repositories.SupplierRepository
+	productRepo       repositories.ProductRepository
+	productUseCase    *ProductUseCase
+	movementRepo      repositories.MovementRepository
+	unitOfWork        repositories.UnitOfWork
+	idGenerator       repositories.IDGenerator
+}
+
+// NewPurchaseOrderUseCase creates a new purchase order use case
+func NewPurchaseOrderUseCase(
+	purchaseOrderRepo repositories.PurchaseOrderRepository,
+	supplierRepo repositories.SupplierRepository,
+	productRepo repositories.ProductRepository,
+	productUseCase *ProductUseCase,
+	movementRepo repositories.MovementRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+) *PurchaseOrderUseCase {
+	return &PurchaseOrderUseCase{
+		purchaseOrderRepo: purchaseOrderRepo,
+		supplierRepo:      supplierRepo,
+		productRepo:       productRepo,
+		productUseCase:    productUseCase,
+		movementRepo:      movementRepo,
+		unitOfWork:        unitOfWork,
+		idGenerator:       idGenerator,
+	}
+}
+
+// PurchaseOrderLineRequest is one product to buy
+type PurchaseOrderLineRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+
+	// UnitCost may be left out on a draft but is required before it is sent
+	UnitCost entities.Money `json:"unit_cost"`
+}
+
+// CreatePurchaseOrderRequest represents the request to draft a purchase order
+type CreatePurchaseOrderRequest struct {
+	SupplierID string                     `json:"supplier_id" binding:"required"`
+	Lines      []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+	Note       string                     `json:"note,omitempty"`
+
+	// LocationID is where the goods are delivered; it defaults to the default location
+	LocationID string `json:"location_id,omitempty"`
+}
+
+// UpdatePurchaseOrderRequest represents the request to edit a draft
+// Lines, when given, replace all of the draft's lines
+type UpdatePurchaseOrderRequest struct {
+	Lines []PurchaseOrderLineRequest `json:"lines,omitempty" binding:"omitempty,min=1,dive"`
+	Note  *string                    `json:"note,omitempty"`
+}
+
+// ReceiptLineRequest is the quantity of one product that arrived
+type ReceiptLineRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// ReceivePurchaseOrderRequest represents a delivery against a purchase order
+type ReceivePurchaseOrderRequest struct {
+	// Lines lists what arrived; when empty everything outstanding is received
+	Lines []ReceiptLineRequest `json:"lines,omitempty" binding:"omitempty,dive"`
+
+	// AdditionalCost is freight, duty and handling, spread over the lines by value
+	AdditionalCost entities.Money `json:"additional_cost"`
+
+	// LocationID overrides where the goods are put away
+	LocationID string `json:"location_id,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+	Note       string `json:"note,omitempty"`
+}
+
+// ReorderSuggestion is the outcome of a reorder run
+type ReorderSuggestion struct {
+	PurchaseOrders []*entities.PurchaseOrder `json:"purchase_orders"`
+
+	// WithoutSupplier lists products that need reordering but have no supplier to buy from
+	WithoutSupplier []string `json:"without_supplier"`
+}
+
+// CreatePurchaseOrder drafts a purchase order with a supplier
+func (uc *PurchaseOrderUseCase) CreatePurchaseOrder(ctx context.Context, req *CreatePurchaseOrderRequest) (*entities.PurchaseOrder, error) {
+	if _, err := uc.supplierRepo.GetByID(ctx, req.SupplierID); err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+	location, err := uc.productUseCase.stockLocation(ctx, req.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uc.idGenerator.NewID(ctx, entities.PurchaseOrderIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate purchase order ID: %w", err)
+	}
+
+	purchaseOrder := entities.NewPurchaseOrder(id, req.SupplierID, location.ID, req.Note)
+	if err := uc.addLines(ctx, purchaseOrder, req.Lines); err != nil {
+		return nil, err
+	}
+
+	if err := uc.purchaseOrderRepo.Create(ctx, purchaseOrder); err != nil {
+		return nil, err
+	}
+
+	return purchaseOrder, nil
+}
+
+// GetPurchaseOrder retrieves a purchase order by ID
+func (uc *PurchaseOrderUseCase) GetPurchaseOrder(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	purchaseOrder, err := uc.purchaseOrderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase order: %w", err)
+	}
+
+	return purchaseOrder, nil
+}
+
+// GetPurchaseOrders lists purchase orders, newest first
+func (uc *PurchaseOrderUseCase) GetPurchaseOrders(ctx context.Context, filter repositories.PurchaseOrderFilter) ([]*entities.PurchaseOrder, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: invalid status: %s", entities.ErrInvalidPurchaseOrder, filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50 // Default limit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	purchaseOrders, err := uc.purchaseOrderRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase orders: %w", err)
+	}
+
+	return purchaseOrders, nil
+}
+
+// UpdatePurchaseOrder edits the lines or note of a draft
+func (uc *PurchaseOrderUseCase) UpdatePurchaseOrder(ctx context.Context, id string, req *UpdatePurchaseOrderRequest) (*entities.PurchaseOrder, error) {
+	purchaseOrder, err := uc.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if purchaseOrder.Status != entities.PurchaseOrderStatusDraft {
+		return nil, fmt.Errorf("%w: purchase order %s is %s", entities.ErrPurchaseOrderStatus, id, purchaseOrder.Status)
+	}
+
+	if req.Lines != nil {
+		if err := purchaseOrder.ClearLines(); err != nil {
+			return nil, err
+		}
+		if err := uc.addLines(ctx, purchaseOrder, req.Lines); err != nil {
+			return nil, err
+		}
+	}
+	if req.Note != nil {
+		purchaseOrder.Note = *req.Note
+		purchaseOrder.UpdatedAt = time.Now().UTC()
+	}
+
+	if err := uc.purchaseOrderRepo.Update(ctx, purchaseOrder); err != nil {
+		return nil, err
+	}
+
+	return purchaseOrder, nil
+}
+
+// SendPurchaseOrder marks a draft as sent to the supplier
+// The goods are expected after the longest lead time of the products on it,
+// using the supplier's lead time for products without their own
+func (uc *PurchaseOrderUseCase) SendPurchaseOrder(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	purchaseOrder, err := uc.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	supplier, err := uc.supplierRepo.GetByID(ctx, purchaseOrder.SupplierID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	leadTimeDays := 0
+	for _, line := range purchaseOrder.Lines {
+		product, err := uc.productUseCase.GetProduct(ctx, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		leadTimeDays = max(leadTimeDays, leadTime(product, supplier))
+	}
+
+	if err := purchaseOrder.Send(time.Duration(leadTimeDays) * 24 * time.Hour); err != nil {
+		return nil, err
+	}
+	if err := uc.purchaseOrderRepo.Update(ctx, purchaseOrder); err != nil {
+		return nil, err
+	}
+
+	return purchaseOrder, nil
+}
+
+// ReceivePurchaseOrder books a delivery against a sent purchase order
+// The received units are added to stock at the delivery location and the
+// receipt, the order and the stock change are saved together
+func (uc *PurchaseOrderUseCase) ReceivePurchaseOrder(ctx context.Context, id string, req *ReceivePurchaseOrderRequest) (*entities.GoodsReceipt, error) {
+	if err := validateRequestedAmount("additional cost", req.AdditionalCost, true); err != nil {
+		return nil, err
+	}
+
+	receiptID, err := uc.idGenerator.NewID(ctx, entities.ReceiptIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate receipt ID: %w", err)
+	}
+
+	var receipt *entities.GoodsReceipt
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		purchaseOrder, err := uc.GetPurchaseOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		locationID := purchaseOrder.LocationID
+		if req.LocationID != "" {
+			location, err := uc.productUseCase.stockLocation(ctx, req.LocationID)
+			if err != nil {
+				return err
+			}
+			locationID = location.ID
+		}
+
+		receipt = &entities.GoodsReceipt{
+			ID:              receiptID,
+			PurchaseOrderID: purchaseOrder.ID,
+			LocationID:      locationID,
+			AdditionalCost:  req.AdditionalCost,
+			Actor:           actorOrDefault(req.Actor),
+			Note:            req.Note,
+			ReceivedAt:      time.Now().UTC(),
+		}
+		for _, line := range req.Lines {
+			receipt.Lines = append(receipt.Lines, &entities.GoodsReceiptLine{ProductID: line.ProductID, Quantity: line.Quantity})
+		}
+		if len(req.Lines) == 0 {
+			for _, line := range purchaseOrder.Lines {
+				if line.Outstanding() > 0 {
+					receipt.Lines = append(receipt.Lines, &entities.GoodsReceiptLine{ProductID: line.ProductID, Quantity: line.Outstanding()})
+				}
+			}
+		}
+
+		if err := purchaseOrder.Receive(receipt); err != nil {
+			return err
+		}
+		if err := uc.purchaseOrderRepo.Update(ctx, purchaseOrder); err != nil {
+			return err
+		}
+		if err := uc.purchaseOrderRepo.CreateReceipt(ctx, receipt); err != nil {
+			return err
+		}
+
+		for _, line := range receipt.Lines {
+			source := entities.MovementSource{
+				Reason:      entities.MovementReasonReceipt,
+				LocationID:  receipt.LocationID,
+				Actor:       receipt.Actor,
+				ReferenceID: receipt.ID,
+				Note:        receipt.Note,
+				UnitCost:    &line.LandedUnitCost,
+			}
+			if err := uc.productUseCase.ReturnStock(ctx, line.ProductID, line.Quantity, source); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// GetReceipts lists the deliveries booked against a purchase order, oldest first
+func (uc *PurchaseOrderUseCase) GetReceipts(ctx context.Context, id string) ([]*entities.GoodsReceipt, error) {
+	if _, err := uc.GetPurchaseOrder(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return uc.purchaseOrderRepo.GetReceipts(ctx, id)
+}
+
+// SuggestReorders drafts a purchase order per supplier for every product whose
+// available stock plus units already on order is at or below its reorder point
+// Each product is topped up to its reorder point plus the demand expected over
+// its lead time, judged by the last 30 days of sales, and at least its reorder
+// quantity is bought; unit costs are taken from the last order sent for it
+func (uc *PurchaseOrderUseCase) SuggestReorders(ctx context.Context) (*ReorderSuggestion, error) {
+	candidates, err := uc.productRepo.GetBelowReorderPoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products below reorder point: %w", err)
+	}
+
+	suggestion := &ReorderSuggestion{
+		PurchaseOrders:  []*entities.PurchaseOrder{},
+		WithoutSupplier: []string{},
+	}
+	if len(candidates) == 0 {
+		return suggestion, nil
+	}
+
+	productIDs := make([]string, len(candidates))
+	for i, product := range candidates {
+		productIDs[i] = product.ID
+	}
+	onOrder, err := uc.purchaseOrderRepo.GetOnOrder(ctx, productIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quantities on order: %w", err)
+	}
+	lastCosts, err := uc.purchaseOrderRepo.GetLastUnitCosts(ctx, productIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last unit costs: %w", err)
+	}
+	since := time.Now().UTC().AddDate(0, 0, -demandWindowDays)
+	sold, err := uc.movementRepo.GetNetChanges(ctx, since, entities.MovementReasonSale, entities.MovementReasonCancellation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent sales: %w", err)
+	}
+
+	location, err := uc.productUseCase.stockLocation(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		suppliers := make(map[string]*entities.Supplier)
+		bySupplier := make(map[string]*entities.PurchaseOrder)
+		for _, product := range candidates {
+			if !product.NeedsReorder(onOrder[product.ID]) {
+				continue
+			}
+			if product.SupplierID == "" {
+				suggestion.WithoutSupplier = append(suggestion.WithoutSupplier, product.ID)
+				continue
+			}
+
+			supplier, ok := suppliers[product.SupplierID]
+			if !ok {
+				var err error
+				if supplier, err = uc.supplierRepo.GetByID(ctx, product.SupplierID); err != nil {
+					return fmt.Errorf("failed to get supplier: %w", err)
+				}
+				suppliers[supplier.ID] = supplier
+			}
+			purchaseOrder, ok := bySupplier[supplier.ID]
+			if !ok {
+				var err error
+				if purchaseOrder, err = uc.draftReorder(ctx, supplier, location.ID); err != nil {
+					return err
+				}
+				bySupplier[supplier.ID] = purchaseOrder
+				suggestion.PurchaseOrders = append(suggestion.PurchaseOrders, purchaseOrder)
+			}
+
+			demand := max(-sold[product.ID], 0)
+			quantity := reorderQuantity(product, onOrder[product.ID], demand, leadTime(product, supplier))
+
+			unitCost, ok := lastCosts[product.ID]
+			if !ok {
+				unitCost = entities.NewMoney(0, "")
+			}
+			if err := purchaseOrder.AddLine(product.ID, product.ProductName, quantity, unitCost); err != nil {
+				return err
+			}
+		}
+
+		for _, purchaseOrder := range suggestion.PurchaseOrders {
+			if err := uc.purchaseOrderRepo.Create(ctx, purchaseOrder); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestion, nil
+}
+
+// addLines adds the requested products to a draft
+// Unit costs are in the default currency and may be zero until the draft is sent
+func (uc *PurchaseOrderUseCase) addLines(ctx context.Context, purchaseOrder *entities.PurchaseOrder, lines []PurchaseOrderLineRequest) error {
+	for _, line := range lines {
+		if err := validateRequestedAmount("unit cost", line.UnitCost, true); err != nil {
+			return err
+		}
+
+		product, err := uc.productUseCase.GetProduct(ctx, line.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := purchaseOrder.AddLine(product.ID, product.ProductName, line.Quantity, line.UnitCost); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// draftReorder starts an empty draft for a reorder run
+func (uc *PurchaseOrderUseCase) draftReorder(ctx context.Context, supplier *entities.Supplier, locationID string) (*entities.PurchaseOrder, error) {
+	id, err := uc.idGenerator.NewID(ctx, entities.PurchaseOrderIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate purchase order ID: %w", err)
+	}
+
+	return entities.NewPurchaseOrder(id, supplier.ID, locationID, "Suggested by reorder run"), nil
+}
+
+// leadTime returns how many days a product takes to arrive, falling back to
+// the supplier's lead time when the product has none of its own
+func leadTime(product *entities.Product, supplier *entities.Supplier) int {
+	if product.LeadTimeDays > 0 {
+		return product.LeadTimeDays
+	}
+	return supplier.LeadTimeDays
+}
+
+// reorderQuantity works out how many units of a product to buy
+// recentDemand is the units sold over the demand window; the expected demand
+// over the lead time is rounded up so a slow seller still counts
+func reorderQuantity(product *entities.Product, onOrder, recentDemand, leadTimeDays int) int {
+	leadTimeDemand := (recentDemand*leadTimeDays + demandWindowDays - 1) / demandWindowDays
+	shortfall := product.ReorderPoint + leadTimeDemand - (product.Available() + onOrder)
+
+	// Buying only up to the reorder point would leave it due again straight away
+	return max(product.ReorderQuantity, shortfall+1)
+}
@@ -0,0 +1,261 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// expireBatchSize is how many expired holds the sweeper ends per query
+const expireBatchSize = 100
+
+// ReservationUseCase encapsulates business logic for stock reservations
+// A hold moves stock from available to reserved; confirming it into an order
+// takes the stock off hand, while releasing or expiring it makes it available again
+type ReservationUseCase struct {
+	reservationRepo repositories.ReservationRepository
+	productUseCase  *ProductUseCase
+	customerRepo    repositories.CustomerRepository
+	unitOfWork      repositories.UnitOfWork
+	idGenerator     repositories.IDGenerator
+
+	defaultTTL time.Duration
+}
+
+// NewReservationUseCase creates a new reservation use case
+// A ttlMinutes of 0 or less uses entities.DefaultReservationTTL
+func NewReservationUseCase(
+	reservationRepo repositories.ReservationRepository,
+	productUseCase *ProductUseCase,
+	customerRepo repositories.CustomerRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+	ttlMinutes int,
+) *ReservationUseCase {
+	defaultTTL := time.Duration(ttlMinutes) * time.Minute
+	if defaultTTL <= 0 {
+		defaultTTL = entities.DefaultReservationTTL
+	}
+
+	return &ReservationUseCase{
+		reservationRepo: reservationRepo,
+		productUseCase:  productUseCase,
+		customerRepo:    customerRepo,
+		unitOfWork:      unitOfWork,
+		idGenerator:     idGenerator,
+		defaultTTL:      defaultTTL,
+	}
+}
+
+// CreateReservationRequest represents the request to hold stock of a product
+type CreateReservationRequest struct {
+	CustomerID string `json:"customer_id,omitempty"`
+	Quantity   int    `json:"quantity" binding:"required,gt=0"`
+
+	// LocationID holds the units at one location; by default they are held
+	// where the most are available
+	LocationID string `json:"location_id,omitempty"`
+
+	// ExpiresInMinutes overrides the configured hold time
+	ExpiresInMinutes int `json:"expires_in_minutes,omitempty" binding:"omitempty,gt=0"`
+}
+
+// CreateReservation holds stock of a product until the hold expires
+// A hold for a customer can only be confirmed into that customer's orders
+func (uc *ReservationUseCase) CreateReservation(ctx context.Context, productID string, req *CreateReservationRequest) (*entities.StockReservation, error) {
+	product, err := uc.productUseCase.GetSellableProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Type == entities.ProductTypeBundle {
+		return nil, fmt.Errorf("%w: %s is a bundle; hold its components instead", entities.ErrInvalidReservation, productID)
+	}
+	if req.CustomerID != "" {
+		if _, err := uc.customerRepo.GetByID(ctx, req.CustomerID); err != nil {
+			return nil, fmt.Errorf("failed to get customer: %w", err)
+		}
+	}
+
+	id, err := uc.idGenerator.NewID(ctx, entities.ReservationIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate reservation ID: %w", err)
+	}
+
+	ttl := uc.defaultTTL
+	if req.ExpiresInMinutes > 0 {
+		ttl = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	reservation, err := entities.NewStockReservation(id, productID, req.CustomerID, req.Quantity, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := func() error {
+		return uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+			location, err := uc.productUseCase.HoldStock(ctx, productID, reservation.Quantity, req.LocationID)
+			if err != nil {
+				return err
+			}
+			reservation.LocationID = location.ID
+			return uc.reservationRepo.Create(ctx, reservation)
+		})
+	}
+
+	err = hold()
+	var stockErr *InsufficientStockError
+	if errors.As(err, &stockErr) {
+		// Holds that ran out since the last sweep may be what is in the way
+		if expired, sweepErr := uc.ExpireStale(ctx); sweepErr == nil && expired > 0 {
+			err = hold()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// GetReservation retrieves a reservation by ID
+func (uc *ReservationUseCase) GetReservation(ctx context.Context, id string) (*entities.StockReservation, error) {
+	reservation, err := uc.reservationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// GetProductReservations lists the reservations on a product, optionally only those in one status
+func (uc *ReservationUseCase) GetProductReservations(ctx context.Context, productID string, status entities.ReservationStatus) ([]*entities.StockReservation, error) {
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("%w: invalid status: %s", entities.ErrInvalidReservation, status)
+	}
+	if _, err := uc.productUseCase.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	return uc.reservationRepo.Find(ctx, repositories.ReservationFilter{ProductID: productID, Status: status})
+}
+
+// ReleaseReservation ends a hold early and makes its stock available again
+func (uc *ReservationUseCase) ReleaseReservation(ctx context.Context, id string) (*entities.StockReservation, error) {
+	var reservation *entities.StockReservation
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if reservation, err = uc.reservationRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := reservation.Release(); err != nil {
+			return err
+		}
+		return uc.end(ctx, reservation, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// ExpireStale ends every hold whose expiry has passed and returns how many were ended
+// It is run periodically by the sweeper; each hold is ended in its own unit of
+// work so one failure does not keep the others' stock locked up
+func (uc *ReservationUseCase) ExpireStale(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		reservations, err := uc.reservationRepo.GetExpired(ctx, time.Now().UTC(), expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, reservation := range reservations {
+			err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+				if err := reservation.Expire(); err != nil {
+					return err
+				}
+				return uc.end(ctx, reservation, nil)
+			})
+			switch {
+			case err == nil:
+				expired++
+			case errors.Is(err, entities.ErrReservationNotActive):
+				// Confirmed or released since it was read
+			default:
+				return expired, err
+			}
+		}
+
+		if len(reservations) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// heldForOrder loads the holds a customer wants to confirm into an order
+func (uc *ReservationUseCase) heldForOrder(ctx context.Context, customerID string, ids []string) ([]*entities.StockReservation, error) {
+	reservations := make([]*entities.StockReservation, 0, len(ids))
+	now := time.Now().UTC()
+	for _, id := range ids {
+		if slices.ContainsFunc(reservations, func(r *entities.StockReservation) bool { return r.ID == id }) {
+			continue
+		}
+
+		reservation, err := uc.reservationRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reservation: %w", err)
+		}
+		if !reservation.IsActive(now) {
+			return nil, fmt.Errorf("%w: %s", entities.ErrReservationNotActive, id)
+		}
+		if reservation.CustomerID != "" && reservation.CustomerID != customerID {
+			return nil, fmt.Errorf("%w: reservation %s belongs to another customer", entities.ErrInvalidReservation, id)
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	return reservations, nil
+}
+
+// confirmForOrder takes the held stock off hand for an order
+// It must run inside the order's unit of work
+func (uc *ReservationUseCase) confirmForOrder(ctx context.Context, reservations []*entities.StockReservation, order *entities.Order) error {
+	for _, reservation := range reservations {
+		if err := reservation.Confirm(order.ID); err != nil {
+			return err
+		}
+		if err := uc.end(ctx, reservation, order); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// end persists the end of a hold and moves its stock accordingly
+// It must run inside a unit of work; order is the order a confirmed hold ships with
+func (uc *ReservationUseCase) end(ctx context.Context, reservation *entities.StockReservation, order *entities.Order) error {
+	if err := uc.reservationRepo.End(ctx, reservation); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return fmt.Errorf("%w: %v", entities.ErrReservationNotActive, err)
+		}
+		return err
+	}
+
+	if reservation.Status == entities.ReservationStatusConfirmed {
+		source := entities.MovementSource{
+			Reason:      entities.MovementReasonSale,
+			LocationID:  order.LocationID,
+			Actor:       order.CustomerID,
+			ReferenceID: order.ID,
+		}
+		return uc.productUseCase.ConfirmHeldStock(ctx, reservation.ProductID, reservation.Quantity, reservation.LocationID, source)
+	}
+	return uc.productUseCase.ReleaseHeldStock(ctx, reservation.ProductID, reservation.Quantity, reservation.LocationID)
+}
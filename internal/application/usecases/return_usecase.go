@@ -0,0 +1,319 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// ReturnUseCase encapsulates business logic for returns and refunds
+type ReturnUseCase struct {
+	returnRepo      repositories.ReturnRepository
+	orderRepo       repositories.OrderRepository
+	productUseCase  *ProductUseCase
+	walletUseCase   *WalletUseCase
+	transactionRepo repositories.TransactionRepository
+	unitOfWork      repositories.UnitOfWork
+	idGenerator     repositories.IDGenerator
+}
+
+// NewReturnUseCase creates a new return use case
+func NewReturnUseCase(
+	returnRepo repositories.ReturnRepository,
+	orderRepo repositories.OrderRepository,
+	productUseCase *ProductUseCase,
+	walletUseCase *WalletUseCase,
+	transactionRepo repositories.TransactionRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+) *ReturnUseCase {
+	return &ReturnUseCase{
+		returnRepo:      returnRepo,
+		orderRepo:       orderRepo,
+		productUseCase:  productUseCase,
+		walletUseCase:   walletUseCase,
+		transactionRepo: transactionRepo,
+		unitOfWork:      unitOfWork,
+		idGenerator:     idGenerator,
+	}
+}
+
+// CreateReturnRequest represents the request to return part of an order
+type CreateReturnRequest struct {
+	// ProductID picks the order line; it may be left out for single-line orders
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	Reason    string `json:"reason"`
+}
+
+// ResolveReturnRequest represents the request to approve or reject a return
+type ResolveReturnRequest struct {
+	// Disposition is required when approving: restock or write_off
+	Disposition entities.ReturnDisposition `json:"disposition"`
+	Actor       string                     `json:"actor"`
+	Note        string                     `json:"note"`
+
+	// RefundToStoreCredit pays the refund into the customer's wallet instead of back to them
+	RefundToStoreCredit bool `json:"refund_to_store_credit"`
+}
+
+// RequestReturn opens a return for part or all of an order
+func (uc *ReturnUseCase) RequestReturn(ctx context.Context, orderID string, req *CreateReturnRequest) (*entities.OrderReturn, error) {
+	if orderID == "" {
+		return nil, fmt.Errorf("order ID is required")
+	}
+
+	returnID, err := uc.idGenerator.NewID(ctx, entities.ReturnIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate return ID: %w", err)
+	}
+
+	orderReturn := &entities.OrderReturn{ID: returnID}
+	err = uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		// Hold the order so it is not cancelled while the return is raised
+		if err := uc.orderRepo.LockForUpdate(ctx, orderID); err != nil {
+			return err
+		}
+
+		order, err := uc.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		line, err := returnedLine(order, req.ProductID)
+		if err != nil {
+			return err
+		}
+
+		returned, err := uc.returnRepo.GetReturnedQuantity(ctx, order.ID, line.ProductID)
+		if err != nil {
+			return err
+		}
+
+		if err := orderReturn.CreateFromOrder(order, line, req.Quantity, returned, req.Reason); err != nil {
+			return err
+		}
+
+		if err := uc.returnRepo.Create(ctx, orderReturn); err != nil {
+			return fmt.Errorf("failed to save return: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orderReturn, nil
+}
+
+// ApproveReturn accepts a return, restocks or writes off the goods and posts a refund
+// All steps run in a single unit of work
+func (uc *ReturnUseCase) ApproveReturn(ctx context.Context, returnID string, req *ResolveReturnRequest) (*entities.OrderReturn, error) {
+	if returnID == "" {
+		return nil, fmt.Errorf("return ID is required")
+	}
+
+	var orderReturn *entities.OrderReturn
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		orderReturn, err = uc.returnRepo.GetByID(ctx, returnID)
+		if err != nil {
+			return fmt.Errorf("failed to get return: %w", err)
+		}
+
+		// Reload the order under a lock; a cancelled order has already been
+		// restocked and refunded in full
+		if err := uc.orderRepo.LockForUpdate(ctx, orderReturn.OrderID); err != nil {
+			return err
+		}
+		order, err := uc.orderRepo.GetByID(ctx, orderReturn.OrderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order.Status == entities.OrderStatusCancelled {
+			return fmt.Errorf("%w: order %s is cancelled", entities.ErrOrderNotReturnable, order.ID)
+		}
+
+		if err := orderReturn.Approve(req.Disposition, actorOrDefault(req.Actor), req.Note); err != nil {
+			return err
+		}
+
+		// A returned bundle comes back as its components
+		items, err := uc.productUseCase.saleItems(ctx, orderReturn.ProductID, orderReturn.Quantity, orderReturn.RefundAmount)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			// 1. Put sellable goods back on the shelf at what they cost when sold;
+			// written-off goods stay in cost of goods sold
+			item.cost = entities.NewMoney(0, "")
+			if orderReturn.IsRestocked() {
+				if item.cost, err = uc.returnedCost(ctx, orderReturn.OrderID, item); err != nil {
+					return err
+				}
+				unitCost := item.cost.Div(item.quantity)
+				source := entities.MovementSource{
+					Reason:      entities.MovementReasonReturn,
+					LocationID:  order.LocationID,
+					Actor:       actorOrDefault(req.Actor),
+					ReferenceID: orderReturn.ID,
+					Note:        req.Note,
+					UnitCost:    &unitCost,
+				}
+				if err := uc.productUseCase.ReturnStock(ctx, item.productID, item.quantity, source); err != nil {
+					return err
+				}
+			}
+
+			// 2. Post the refund to the ledger; the return keeps the first entry
+			transactionID, err := uc.idGenerator.NewID(ctx, entities.TransactionIDPrefix)
+			if err != nil {
+				return fmt.Errorf("failed to generate transaction ID: %w", err)
+			}
+
+			refund := &entities.Transaction{
+				ID:        transactionID,
+				CreatedAt: time.Now().UTC(),
+			}
+			refund.CreateRefundForReturn(orderReturn, order, item.cost)
+			if item.bundled {
+				refund.AllocateToComponent(item.productID, item.quantity, item.revenue)
+			}
+
+			if err := uc.transactionRepo.Create(ctx, refund); err != nil {
+				return fmt.Errorf("failed to create refund transaction: %w", err)
+			}
+			if orderReturn.RefundTransactionID == "" {
+				orderReturn.RefundTransactionID = refund.ID
+			}
+		}
+
+		// 3. Pay the refund out as store credit when asked to
+		if req.RefundToStoreCredit {
+			description := fmt.Sprintf("Store credit for return %s", orderReturn.ID)
+			if _, err := uc.walletUseCase.AddCredit(ctx, orderReturn.CustomerID, orderReturn.RefundAmount, orderReturn.OrderID, description); err != nil {
+				return err
+			}
+		}
+
+		// 4. Record the resolution
+		if err := uc.returnRepo.Resolve(ctx, orderReturn); err != nil {
+			return fmt.Errorf("failed to approve return: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orderReturn, nil
+}
+
+// RejectReturn declines a return; stock and the ledger are left untouched
+func (uc *ReturnUseCase) RejectReturn(ctx context.Context, returnID string, req *ResolveReturnRequest) (*entities.OrderReturn, error) {
+	if returnID == "" {
+		return nil, fmt.Errorf("return ID is required")
+	}
+
+	orderReturn, err := uc.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+
+	if err := orderReturn.Reject(actorOrDefault(req.Actor), req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := uc.returnRepo.Resolve(ctx, orderReturn); err != nil {
+		return nil, fmt.Errorf("failed to reject return: %w", err)
+	}
+
+	return orderReturn, nil
+}
+
+// GetReturn gets a return by ID
+func (uc *ReturnUseCase) GetReturn(ctx context.Context, returnID string) (*entities.OrderReturn, error) {
+	if returnID == "" {
+		return nil, fmt.Errorf("return ID is required")
+	}
+
+	orderReturn, err := uc.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+
+	return orderReturn, nil
+}
+
+// GetReturns lists returns, optionally for one order and/or in one status
+func (uc *ReturnUseCase) GetReturns(ctx context.Context, orderID string, status entities.ReturnStatus, limit, offset int) ([]*entities.OrderReturn, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if status != "" && !status.IsValid() {
+		return nil, fmt.Errorf("invalid return status: %s", status)
+	}
+
+	returns, err := uc.returnRepo.Find(ctx, repositories.ReturnFilter{
+		OrderID: orderID,
+		Status:  status,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get returns: %w", err)
+	}
+
+	return returns, nil
+}
+
+// returnedLine finds the order line a return is raised against
+func returnedLine(order *entities.Order, productID string) (*entities.OrderLine, error) {
+	if productID == "" {
+		lines := order.OrderLines()
+		if len(lines) != 1 {
+			return nil, fmt.Errorf("%w: order %s has %d lines, product ID is required",
+				entities.ErrOrderLineNotFound, order.ID, len(lines))
+		}
+		return lines[0], nil
+	}
+
+	line := order.FindLine(productID)
+	if line == nil {
+		return nil, fmt.Errorf("%w: product %s is not on order %s", entities.ErrOrderLineNotFound, productID, order.ID)
+	}
+	return line, nil
+}
+
+// returnedCost is the returned units' share of what the order's units of the
+// product cost when they were sold
+func (uc *ReturnUseCase) returnedCost(ctx context.Context, orderID string, item *saleItem) (entities.Money, error) {
+	sold, err := uc.productUseCase.saleCosts(ctx, orderID)
+	if err != nil {
+		return entities.Money{}, err
+	}
+
+	product, ok := sold[item.productID]
+	if !ok || product.quantity <= 0 {
+		return entities.NewMoney(0, ""), nil
+	}
+	return product.cost.MulRat(int64(min(item.quantity, product.quantity)), int64(product.quantity)), nil
+}
+
+// actorOrDefault falls back to the default actor when the caller does not identify itself
+func actorOrDefault(actor string) string {
+	if actor == "" {
+		return defaultActor
+	}
+	return actor
+}
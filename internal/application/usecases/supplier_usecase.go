@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// SupplierUseCase encapsulates business logic for suppliers
+type SupplierUseCase struct {
+	supplierRepo repositories.SupplierRepository
+	idGenerator  repositories.IDGenerator
+}
+
+// NewSupplierUseCase creates a new supplier use case
+func NewSupplierUseCase(supplierRepo repositories.SupplierRepository, idGenerator repositories.IDGenerator) *SupplierUseCase {
+	return &SupplierUseCase{
+		supplierRepo: supplierRepo,
+		idGenerator:  idGenerator,
+	}
+}
+
+// CreateSupplierRequest represents the request to add a supplier
+type CreateSupplierRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	LeadTimeDays int    `json:"lead_time_days" binding:"gte=0"`
+}
+
+// CreateSupplier adds a supplier
+func (uc *SupplierUseCase) CreateSupplier(ctx context.Context, req *CreateSupplierRequest) (*entities.Supplier, error) {
+	id, err := uc.idGenerator.NewID(ctx, entities.SupplierIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate supplier ID: %w", err)
+	}
+
+	supplier := &entities.Supplier{
+		ID:           id,
+		Name:         strings.TrimSpace(req.Name),
+		Email:        strings.TrimSpace(req.Email),
+		Phone:        strings.TrimSpace(req.Phone),
+		LeadTimeDays: req.LeadTimeDays,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+	if err := supplier.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.supplierRepo.Create(ctx, supplier); err != nil {
+		return nil, err
+	}
+
+	return supplier, nil
+}
+
+// GetSupplier retrieves a supplier by ID
+func (uc *SupplierUseCase) GetSupplier(ctx context.Context, id string) (*entities.Supplier, error) {
+	supplier, err := uc.supplierRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	return supplier, nil
+}
+
+// GetSuppliers lists suppliers by name with pagination
+func (uc *SupplierUseCase) GetSuppliers(ctx context.Context, limit, offset int) ([]*entities.Supplier, error) {
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	suppliers, err := uc.supplierRepo.GetAll(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suppliers: %w", err)
+	}
+
+	return suppliers, nil
+}
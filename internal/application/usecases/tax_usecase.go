@@ -0,0 +1,139 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// TaxUseCase encapsulates business logic for tax rates and the tax on orders
+// Products are taxed by their tax class at the rate in effect in the
+// customer's jurisdiction, or the business's own when the customer has none
+type TaxUseCase struct {
+	taxRateRepo repositories.TaxRateRepository
+
+	jurisdiction     string
+	pricesIncludeTax bool
+}
+
+// NewTaxUseCase creates a new tax use case
+// jurisdiction is where the business charges tax by default; with
+// pricesIncludeTax, product prices already include tax
+func NewTaxUseCase(taxRateRepo repositories.TaxRateRepository, jurisdiction string, pricesIncludeTax bool) *TaxUseCase {
+	return &TaxUseCase{
+		taxRateRepo:      taxRateRepo,
+		jurisdiction:     entities.NormalizeJurisdiction(jurisdiction),
+		pricesIncludeTax: pricesIncludeTax,
+	}
+}
+
+// CreateTaxRateRequest represents the request to publish a new tax rate
+type CreateTaxRateRequest struct {
+	Jurisdiction string                   `json:"jurisdiction" binding:"required"`
+	TaxClass     string                   `json:"tax_class" binding:"required"`
+	Components   []*entities.TaxComponent `json:"components" binding:"required"`
+
+	// EffectiveFrom defaults to now; a future date schedules the rate
+	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+}
+
+// CreateRate publishes a rate for a tax class in a jurisdiction that applies
+// from its effective date until the next rate for the same class and jurisdiction
+func (uc *TaxUseCase) CreateRate(ctx context.Context, req *CreateTaxRateRequest) (*entities.TaxRate, error) {
+	rate := &entities.TaxRate{
+		Jurisdiction:  entities.NormalizeJurisdiction(req.Jurisdiction),
+		TaxClass:      entities.NormalizeTaxClass(req.TaxClass),
+		Components:    req.Components,
+		EffectiveFrom: time.Now().UTC(),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if req.EffectiveFrom != nil {
+		rate.EffectiveFrom = req.EffectiveFrom.UTC()
+	}
+	for _, component := range rate.Components {
+		if component == nil {
+			return nil, fmt.Errorf("%w: component is empty", entities.ErrInvalidTaxRate)
+		}
+	}
+	rate.SumComponents()
+
+	if err := rate.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Two rates taking effect at the same instant would make the tax ambiguous
+	current, err := uc.taxRateRepo.GetEffective(ctx, rate.Jurisdiction, rate.TaxClass, rate.EffectiveFrom)
+	switch {
+	case err == nil && current.EffectiveFrom.Equal(rate.EffectiveFrom):
+		return nil, fmt.Errorf("%w: a rate for %s in %s already takes effect at %s",
+			entities.ErrInvalidTaxRate, rate.TaxClass, rate.Jurisdiction, rate.EffectiveFrom.Format(time.RFC3339))
+	case err != nil && !errors.Is(err, repositories.ErrNotFound):
+		return nil, err
+	}
+
+	if err := uc.taxRateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// GetRates lists the rates for a jurisdiction and tax class; either may be empty to list all
+func (uc *TaxUseCase) GetRates(ctx context.Context, jurisdiction, taxClass string) ([]*entities.TaxRate, error) {
+	return uc.taxRateRepo.Find(ctx, entities.NormalizeJurisdiction(jurisdiction), entities.NormalizeTaxClass(taxClass))
+}
+
+// applyTax charges tax on every line of a priced and discounted order
+// Each line is taxed by its product's tax class at the rate in effect when
+// the order was created; a taxed product with no rate in effect fails the order
+func (uc *TaxUseCase) applyTax(ctx context.Context, order *entities.Order, products map[string]*entities.Product) error {
+	order.TaxJurisdiction = uc.jurisdiction
+	if order.Customer != nil && order.Customer.TaxJurisdiction != "" {
+		order.TaxJurisdiction = order.Customer.TaxJurisdiction
+	}
+
+	rates := make(map[string]*entities.TaxRate)
+	for _, line := range order.OrderLines() {
+		class := products[line.ProductID].TaxClass
+		if class == "" {
+			if err := line.ApplyTax(nil, false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rate, ok := rates[class]
+		if !ok {
+			var err error
+			if rate, err = uc.effectiveRate(ctx, order.TaxJurisdiction, class, order.CreatedAt); err != nil {
+				return err
+			}
+			rates[class] = rate
+		}
+		if err := line.ApplyTax(rate, uc.pricesIncludeTax); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// effectiveRate returns the rate for a tax class in a jurisdiction in effect at the given time
+func (uc *TaxUseCase) effectiveRate(ctx context.Context, jurisdiction, taxClass string, at time.Time) (*entities.TaxRate, error) {
+	if jurisdiction == "" {
+		return nil, fmt.Errorf("%w: %s is taxed but no tax jurisdiction is set", entities.ErrNoTaxRate, taxClass)
+	}
+
+	rate, err := uc.taxRateRepo.GetEffective(ctx, jurisdiction, taxClass, at.UTC())
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s in %s at %s", entities.ErrNoTaxRate, taxClass, jurisdiction, at.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
@@ -3,6 +3,8 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -11,9 +13,11 @@ import (
 
 // TransactionUseCase encapsulates business logic for transaction and analytics operations
 type TransactionUseCase struct {
-	transactionRepo repositories.TransactionRepository
-	customerRepo    repositories.CustomerRepository
-	productRepo     repositories.ProductRepository
+	transactionRepo     repositories.TransactionRepository
+	customerRepo        repositories.CustomerRepository
+	productRepo         repositories.ProductRepository
+	categoryRepo        repositories.CategoryRepository
+	exchangeRateUseCase *ExchangeRateUseCase
 }
 
 // NewTransactionUseCase creates a new transaction use case
@@ -21,11 +25,15 @@ func NewTransactionUseCase(
 	transactionRepo repositories.TransactionRepository,
 	customerRepo repositories.CustomerRepository,
 	productRepo repositories.ProductRepository,
+	categoryRepo repositories.CategoryRepository,
+	exchangeRateUseCase *ExchangeRateUseCase,
 ) *TransactionUseCase {
 	return &TransactionUseCase{
-		transactionRepo: transactionRepo,
-		customerRepo:    customerRepo,
-		productRepo:     productRepo,
+		transactionRepo:     transactionRepo,
+		customerRepo:        customerRepo,
+		productRepo:         productRepo,
+		categoryRepo:        categoryRepo,
+		exchangeRateUseCase: exchangeRateUseCase,
 	}
 }
 
@@ -75,29 +83,16 @@ func (uc *TransactionUseCase) GetTransactionHistory(ctx context.Context, filters
 }
 
 // GetBusinessStats gets comprehensive business statistics
-func (uc *TransactionUseCase) GetBusinessStats(ctx context.Context, period StatsPeriod) (map[string]any, error) {
-	var start, end *time.Time
-	now := time.Now().UTC()
-
-	// Calculate time ranges based on period
-	switch period {
-	case StatsPeriodToday:
-		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		start = &startOfDay
-		end = &now
-	case StatsPeriodThisWeek:
-		weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-		weekStart = time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, weekStart.Location())
-		start = &weekStart
-		end = &now
-	case StatsPeriodThisMonth:
-		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		start = &monthStart
-		end = &now
-	case StatsPeriodAllTime:
-		// No time filter for all-time stats
+// Amounts are reported in currency, converting each transaction at the rate in
+// effect when it was booked; an empty currency means the base currency
+func (uc *TransactionUseCase) GetBusinessStats(ctx context.Context, period StatsPeriod, currency string) (map[string]any, error) {
+	currency, err := uc.reportingCurrency(currency)
+	if err != nil {
+		return nil, err
 	}
 
+	start, end := period.timeRange()
+
 	// Get business stats from repository
 	stats, err := uc.transactionRepo.GetBusinessStats(ctx, start, end)
 	if err != nil {
@@ -113,13 +108,26 @@ func (uc *TransactionUseCase) GetBusinessStats(ctx context.Context, period Stats
 		}
 	}
 
+	if currency != uc.exchangeRateUseCase.BaseCurrency() {
+		if err := uc.convertBusinessStats(ctx, stats, currency, start, end); err != nil {
+			return nil, err
+		}
+	}
+
 	// Format response
 	response := map[string]any{
+		"currency":            currency,
 		"total_revenue":       stats.TotalRevenue,
+		"gross_revenue":       stats.GrossRevenue,
+		"refunded_amount":     stats.RefundedAmount,
+		"refund_count":        stats.RefundCount,
 		"order_count":         stats.OrderCount,
 		"average_order_value": stats.AverageOrderValue,
 		"total_quantity_sold": stats.TotalQuantitySold,
 		"unique_customers":    stats.UniqueCustomers,
+		"cost_of_goods":       stats.CostOfGoods,
+		"gross_margin":        stats.GrossMargin,
+		"margin_percent":      stats.MarginPercent,
 	}
 
 	if len(stats.TopSellingProducts) > 0 {
@@ -129,25 +137,101 @@ func (uc *TransactionUseCase) GetBusinessStats(ctx context.Context, period Stats
 	return response, nil
 }
 
-// GetComprehensiveStats gets stats for multiple periods
-func (uc *TransactionUseCase) GetComprehensiveStats(ctx context.Context) (map[string]any, error) {
+// GetTopSellingCategories rolls product sales up the category tree: each
+// category counts the sales of its own products and of every category below it
+// The limit categories that sold the most units are returned, reported in currency
+func (uc *TransactionUseCase) GetTopSellingCategories(ctx context.Context, period StatsPeriod, currency string, limit int) ([]*entities.CategorySales, error) {
+	currency, err := uc.reportingCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := period.timeRange()
+	products, err := uc.transactionRepo.GetTopSellingProducts(ctx, 0, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product sales: %w", err)
+	}
+	if currency != uc.exchangeRateUseCase.BaseCurrency() {
+		stats := &entities.BusinessStats{TopSellingProducts: make([]entities.ProductSales, len(products))}
+		for i, product := range products {
+			stats.TopSellingProducts[i] = *product
+		}
+		if err := uc.convertBusinessStats(ctx, stats, currency, start, end); err != nil {
+			return nil, err
+		}
+		for i := range products {
+			products[i] = &stats.TopSellingProducts[i]
+		}
+	}
+
+	categories, err := uc.categoryRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	tree := categoryTree(categories)
+
+	sales := make(map[string]*entities.CategorySales)
+	for _, product := range products {
+		category, ok := tree[product.CategoryID]
+		if !ok {
+			continue // uncategorised
+		}
+		for _, id := range category.AncestorIDs() {
+			if sales[id] == nil {
+				sales[id] = &entities.CategorySales{
+					CategoryID:   id,
+					CategoryName: tree[id].Name,
+					ParentID:     tree[id].ParentID,
+					Depth:        tree[id].Depth(),
+					TotalRevenue: entities.NewMoney(0, currency),
+					CostOfGoods:  entities.NewMoney(0, currency),
+				}
+			}
+			if err := sales[id].AddProduct(product); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ranked := make([]*entities.CategorySales, 0, len(sales))
+	for _, category := range sales {
+		if err := category.CalculateMargin(); err != nil {
+			return nil, fmt.Errorf("failed to calculate gross margin: %w", err)
+		}
+		ranked = append(ranked, category)
+	}
+	slices.SortFunc(ranked, func(a, b *entities.CategorySales) int {
+		if a.QuantitySold != b.QuantitySold {
+			return b.QuantitySold - a.QuantitySold
+		}
+		return strings.Compare(a.CategoryID, b.CategoryID)
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	return ranked, nil
+}
+
+// GetComprehensiveStats gets stats for multiple periods, reported in currency
+func (uc *TransactionUseCase) GetComprehensiveStats(ctx context.Context, currency string) (map[string]any, error) {
 	// Get stats for different periods
-	allTimeStats, err := uc.GetBusinessStats(ctx, StatsPeriodAllTime)
+	allTimeStats, err := uc.GetBusinessStats(ctx, StatsPeriodAllTime, currency)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all-time stats: %w", err)
 	}
 
-	todayStats, err := uc.GetBusinessStats(ctx, StatsPeriodToday)
+	todayStats, err := uc.GetBusinessStats(ctx, StatsPeriodToday, currency)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get today's stats: %w", err)
 	}
 
-	thisWeekStats, err := uc.GetBusinessStats(ctx, StatsPeriodThisWeek)
+	thisWeekStats, err := uc.GetBusinessStats(ctx, StatsPeriodThisWeek, currency)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get this week's stats: %w", err)
 	}
 
-	thisMonthStats, err := uc.GetBusinessStats(ctx, StatsPeriodThisMonth)
+	thisMonthStats, err := uc.GetBusinessStats(ctx, StatsPeriodThisMonth, currency)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get this month's stats: %w", err)
 	}
@@ -186,12 +270,31 @@ func (uc *TransactionUseCase) GetCustomerTransactionSummary(ctx context.Context,
 	return summary, nil
 }
 
-// GetRevenueAnalytics gets detailed revenue analytics
-func (uc *TransactionUseCase) GetRevenueAnalytics(ctx context.Context, days int) (map[string]any, error) {
+// GetTaxLiability reports the tax collected and refunded from start until end,
+// both included, per jurisdiction, tax class and rate
+func (uc *TransactionUseCase) GetTaxLiability(ctx context.Context, start, end time.Time) ([]*entities.TaxLiability, error) {
+	liabilities, err := uc.transactionRepo.GetTaxLiability(ctx, &start, &end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax liability: %w", err)
+	}
+
+	return liabilities, nil
+}
+
+// GetRevenueAnalytics gets detailed revenue analytics, reported in currency
+func (uc *TransactionUseCase) GetRevenueAnalytics(ctx context.Context, days int, currency string) (map[string]any, error) {
 	if days <= 0 {
 		days = 30 // Default to last 30 days
 	}
 
+	currency, err := uc.reportingCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency != uc.exchangeRateUseCase.BaseCurrency() {
+		return uc.convertedRevenueAnalytics(ctx, days, currency)
+	}
+
 	// Get daily revenue
 	dailyRevenue, err := uc.transactionRepo.GetDailyRevenue(ctx, days)
 	if err != nil {
@@ -211,12 +314,186 @@ func (uc *TransactionUseCase) GetRevenueAnalytics(ctx context.Context, days int)
 	}
 
 	return map[string]any{
+		"currency":        currency,
 		"daily_revenue":   dailyRevenue,
 		"monthly_revenue": monthlyRevenue,
 		"growth":          growth,
 	}, nil
 }
 
+// reportingCurrency normalises a requested reporting currency; empty means the base currency
+func (uc *TransactionUseCase) reportingCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return uc.exchangeRateUseCase.BaseCurrency(), nil
+	}
+	if _, err := entities.ParseMoney("0", currency); err != nil {
+		return "", fmt.Errorf("%w: unknown reporting currency %q", entities.ErrInvalidExchangeRate, currency)
+	}
+	return currency, nil
+}
+
+// convertBusinessStats restates the amounts in stats in currency
+// Gross revenue, refunds and cost of goods are converted separately, each
+// transaction at its historical rate, and the net figures and margins are
+// derived from the converted amounts
+func (uc *TransactionUseCase) convertBusinessStats(ctx context.Context, stats *entities.BusinessStats, currency string, start, end *time.Time) error {
+	converter, err := uc.exchangeRateUseCase.newRevenueConverter(ctx, currency)
+	if err != nil {
+		return err
+	}
+
+	revenue, err := uc.transactionRepo.GetRevenueByExchangeRate(ctx, currency, repositories.RevenueByProduct, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to get revenue by exchange rate: %w", err)
+	}
+
+	gross := entities.NewMoney(0, currency)
+	refunded := entities.NewMoney(0, currency)
+	cost := entities.NewMoney(0, currency)
+	productRevenue := make(map[string]entities.Money)
+	productCost := make(map[string]entities.Money)
+	for _, part := range revenue {
+		partGross, partRefunded, err := converter.convert(part)
+		if err != nil {
+			return err
+		}
+		partNet, err := partGross.Sub(partRefunded)
+		if err != nil {
+			return err
+		}
+		partCost, err := converter.convertCost(part)
+		if err != nil {
+			return err
+		}
+
+		if gross, err = gross.Add(partGross); err != nil {
+			return err
+		}
+		if refunded, err = refunded.Add(partRefunded); err != nil {
+			return err
+		}
+		if cost, err = cost.Add(partCost); err != nil {
+			return err
+		}
+		if productRevenue[part.Key], err = productRevenue[part.Key].Add(partNet); err != nil {
+			return err
+		}
+		if productCost[part.Key], err = productCost[part.Key].Add(partCost); err != nil {
+			return err
+		}
+	}
+
+	stats.GrossRevenue = gross
+	stats.RefundedAmount = refunded
+	if stats.TotalRevenue, err = gross.Sub(refunded); err != nil {
+		return err
+	}
+	stats.CostOfGoods = cost
+	stats.CalculateAverageOrderValue()
+	if err := stats.CalculateMargin(); err != nil {
+		return err
+	}
+
+	for i := range stats.TopSellingProducts {
+		product := &stats.TopSellingProducts[i]
+		product.TotalRevenue = entities.NewMoney(productRevenue[product.ProductID].Minor(), currency)
+		product.CostOfGoods = entities.NewMoney(productCost[product.ProductID].Minor(), currency)
+		if err := product.CalculateMargin(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// convertedRevenueAnalytics builds daily and monthly net revenue and growth in
+// currency from daily revenue converted at each transaction's historical rate
+func (uc *TransactionUseCase) convertedRevenueAnalytics(ctx context.Context, days int, currency string) (map[string]any, error) {
+	converter, err := uc.exchangeRateUseCase.newRevenueConverter(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dailyStart := now.AddDate(0, 0, -days).Truncate(24 * time.Hour)
+	monthlyStart := now.AddDate(0, -12, 0)
+	start := dailyStart
+	if monthlyStart.Before(start) {
+		start = monthlyStart
+	}
+
+	revenue, err := uc.transactionRepo.GetRevenueByExchangeRate(ctx, currency, repositories.RevenueByDay, &start, &now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by exchange rate: %w", err)
+	}
+
+	daily := make(map[string]entities.Money)
+	monthly := make(map[string]entities.Money)
+	for _, part := range revenue {
+		gross, refunded, err := converter.convert(part)
+		if err != nil {
+			return nil, err
+		}
+		net, err := gross.Sub(refunded)
+		if err != nil {
+			return nil, err
+		}
+
+		date, err := time.Parse("2006-01-02", part.Key)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected revenue date %q: %w", part.Key, err)
+		}
+		if !date.Before(dailyStart) {
+			if daily[part.Key], err = daily[part.Key].Add(net); err != nil {
+				return nil, err
+			}
+		}
+		if !date.Before(monthlyStart) {
+			month := part.Key[:7]
+			if monthly[month], err = monthly[month].Add(net); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Newest first, as for the base currency
+	revenueRows := func(byPeriod map[string]entities.Money, keyName string) []map[string]any {
+		keys := make([]string, 0, len(byPeriod))
+		for key := range byPeriod {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		slices.Reverse(keys)
+
+		rows := make([]map[string]any, len(keys))
+		for i, key := range keys {
+			rows[i] = map[string]any{keyName: key, "revenue": byPeriod[key]}
+		}
+		return rows
+	}
+
+	currentRevenue := entities.NewMoney(monthly[currentMonth.Format("2006-01")].Minor(), currency)
+	previousRevenue := entities.NewMoney(monthly[currentMonth.AddDate(0, -1, 0).Format("2006-01")].Minor(), currency)
+	var growthPercentage float64
+	if previousRevenue.IsPositive() {
+		change := currentRevenue.Minor() - previousRevenue.Minor()
+		growthPercentage = float64(change) / float64(previousRevenue.Minor()) * 100
+	}
+
+	return map[string]any{
+		"currency":        currency,
+		"daily_revenue":   revenueRows(daily, "date"),
+		"monthly_revenue": revenueRows(monthly, "month"),
+		"growth": map[string]any{
+			"current_month_revenue":  currentRevenue,
+			"previous_month_revenue": previousRevenue,
+			"growth_percentage":      growthPercentage,
+		},
+	}, nil
+}
+
 // enrichTransaction adds related customer and product data to transaction
 func (uc *TransactionUseCase) enrichTransaction(ctx context.Context, transaction *entities.Transaction) error {
 	// Get customer data
@@ -258,3 +535,24 @@ const (
 	StatsPeriodThisMonth StatsPeriod = "this_month"
 	StatsPeriodAllTime   StatsPeriod = "all_time"
 )
+
+// timeRange returns the start and end of the period, both nil for all time
+func (p StatsPeriod) timeRange() (start, end *time.Time) {
+	now := time.Now().UTC()
+
+	switch p {
+	case StatsPeriodToday:
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return &startOfDay, &now
+	case StatsPeriodThisWeek:
+		weekStart := now.AddDate(0, 0, -int(now.Weekday()))
+		weekStart = time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, weekStart.Location())
+		return &weekStart, &now
+	case StatsPeriodThisMonth:
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return &monthStart, &now
+	}
+
+	// No time filter for all-time stats
+	return nil, nil
+}
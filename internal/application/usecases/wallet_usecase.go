@@ -0,0 +1,179 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+)
+
+// WalletUseCase encapsulates business logic for customer store credit
+// The balance is never stored; it is always summed from credit ledger entries
+type WalletUseCase struct {
+	transactionRepo repositories.TransactionRepository
+	customerRepo    repositories.CustomerRepository
+	unitOfWork      repositories.UnitOfWork
+	idGenerator     repositories.IDGenerator
+}
+
+// NewWalletUseCase creates a new wallet use case
+func NewWalletUseCase(
+	transactionRepo repositories.TransactionRepository,
+	customerRepo repositories.CustomerRepository,
+	unitOfWork repositories.UnitOfWork,
+	idGenerator repositories.IDGenerator,
+) *WalletUseCase {
+	return &WalletUseCase{
+		transactionRepo: transactionRepo,
+		customerRepo:    customerRepo,
+		unitOfWork:      unitOfWork,
+		idGenerator:     idGenerator,
+	}
+}
+
+// IssueCreditRequest represents the request to add store credit to a wallet
+type IssueCreditRequest struct {
+	Amount  entities.Money `json:"amount"`
+	Reason  string         `json:"reason" binding:"required"`
+	OrderID string         `json:"order_id"`
+}
+
+// IssueCredit adds store credit to a customer's wallet, e.g. as a goodwill gesture
+func (uc *WalletUseCase) IssueCredit(ctx context.Context, customerID string, req *IssueCreditRequest) (*entities.Transaction, error) {
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID is required")
+	}
+
+	if err := validateRequestedAmount("amount", req.Amount, false); err != nil {
+		return nil, err
+	}
+
+	var credit *entities.Transaction
+	err := uc.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		credit, err = uc.AddCredit(ctx, customerID, req.Amount, req.OrderID, req.Reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return credit, nil
+}
+
+// AddCredit writes a credit entry for the customer
+// It joins the caller's unit of work when one is active
+func (uc *WalletUseCase) AddCredit(ctx context.Context, customerID string, amount entities.Money, orderID, description string) (*entities.Transaction, error) {
+	if err := uc.customerRepo.LockForUpdate(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	transactionID, err := uc.idGenerator.NewID(ctx, entities.TransactionIDPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+
+	credit := &entities.Transaction{
+		ID:        transactionID,
+		CreatedAt: time.Now().UTC(),
+	}
+	credit.CreateCreditIssue(customerID, amount, orderID, description)
+
+	if err := credit.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.Create(ctx, credit); err != nil {
+		return nil, fmt.Errorf("failed to create credit transaction: %w", err)
+	}
+
+	return credit, nil
+}
+
+// RedeemCredit spends the order's StoreCreditApplied from the customer's wallet
+// It must run inside the order's unit of work; the customer row lock stops two
+// checkouts from spending the same balance
+func (uc *WalletUseCase) RedeemCredit(ctx context.Context, order *entities.Order) error {
+	if !order.StoreCreditApplied.IsPositive() {
+		return nil
+	}
+
+	if err := uc.customerRepo.LockForUpdate(ctx, order.CustomerID); err != nil {
+		return err
+	}
+
+	balance, err := uc.transactionRepo.GetCreditBalance(ctx, order.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	c, err := balance.Cmp(order.StoreCreditApplied)
+	if err != nil {
+		return err
+	}
+	if c < 0 {
+		return &InsufficientCreditError{
+			CustomerID: order.CustomerID,
+			Available:  balance,
+			Requested:  order.StoreCreditApplied,
+		}
+	}
+
+	transactionID, err := uc.idGenerator.NewID(ctx, entities.TransactionIDPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+
+	redemption := &entities.Transaction{
+		ID:        transactionID,
+		CreatedAt: time.Now().UTC(),
+	}
+	redemption.CreateCreditRedemption(order)
+
+	if err := uc.transactionRepo.Create(ctx, redemption); err != nil {
+		return fmt.Errorf("failed to create credit redemption: %w", err)
+	}
+
+	return nil
+}
+
+// GetBalance returns the customer's current store credit balance
+func (uc *WalletUseCase) GetBalance(ctx context.Context, customerID string) (entities.Money, error) {
+	if customerID == "" {
+		return entities.Money{}, fmt.Errorf("customer ID is required")
+	}
+
+	return uc.transactionRepo.GetCreditBalance(ctx, customerID)
+}
+
+// GetWallet returns the customer's store credit statement
+func (uc *WalletUseCase) GetWallet(ctx context.Context, customerID string) (*entities.WalletStatement, error) {
+	if customerID == "" {
+		return nil, fmt.Errorf("customer ID is required")
+	}
+
+	if _, err := uc.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	transactions, err := uc.transactionRepo.GetWalletTransactions(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.NewWalletStatement(customerID, transactions)
+}
+
+// InsufficientCreditError represents an attempt to spend more store credit than the wallet holds
+type InsufficientCreditError struct {
+	CustomerID string
+	Available  entities.Money
+	Requested  entities.Money
+}
+
+func (e *InsufficientCreditError) Error() string {
+	return fmt.Sprintf("insufficient store credit for customer %s: available %s, requested %s",
+		e.CustomerID, e.Available, e.Requested)
+}
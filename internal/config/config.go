@@ -18,6 +18,8 @@ type AppConfig struct {
 	Business BusinessSettings `mapstructure:"business"`
 	Security SecuritySettings `mapstructure:"security"`
 	Cache    CacheSettings    `mapstructure:"cache"`
+	IDs      IDSettings       `mapstructure:"ids"`
+	Invoice  InvoiceSettings  `mapstructure:"invoice"`
 }
 
 // AppSettings contains general application settings
@@ -68,9 +70,18 @@ type LoggingSettings struct {
 
 // BusinessSettings contains business logic configuration
 type BusinessSettings struct {
-	CooldownPeriodMinutes int    `mapstructure:"cooldown_period_minutes"`
-	DefaultCurrency       string `mapstructure:"default_currency"`
-	CurrencyPrecision     int    `mapstructure:"currency_precision"`
+	CooldownPeriodMinutes     int    `mapstructure:"cooldown_period_minutes"`
+	CancellationWindowMinutes int    `mapstructure:"cancellation_window_minutes"`
+	DefaultCurrency           string `mapstructure:"default_currency"`          // ISO 4217 code amounts are in when none is given
+	CurrencyPrecision         int    `mapstructure:"currency_precision"`        // decimal places of the default currency; 0 uses the ISO 4217 value
+	IdempotencyKeyTTLHours    int    `mapstructure:"idempotency_key_ttl_hours"` // how long responses are kept for Idempotency-Key replays
+	ReservationTTLMinutes     int    `mapstructure:"reservation_ttl_minutes"`   // how long stock reservations hold stock by default
+	ReservationSweepSeconds   int    `mapstructure:"reservation_sweep_seconds"` // how often expired reservations are released
+	PriceScheduleSeconds      int    `mapstructure:"price_schedule_seconds"`    // how often scheduled price changes that are due are applied
+	FulfilmentStrategy        string `mapstructure:"fulfilment_strategy"`       // how orders naming no location pick one: most_stock (default) or nearest
+	ValuationMethod           string `mapstructure:"valuation_method"`          // how cost of goods sold is valued: fifo (default) or weighted_average
+	TaxJurisdiction           string `mapstructure:"tax_jurisdiction"`          // where customers without a jurisdiction of their own are taxed, e.g. IN-KA
+	PricesIncludeTax          bool   `mapstructure:"prices_include_tax"`        // whether product prices already include tax, or tax is added on top
 }
 
 // SecuritySettings contains security-related configuration
@@ -90,6 +101,39 @@ type CacheSettings struct {
 	DB       int    `mapstructure:"db"`
 }
 
+// IDSettings selects how new entity IDs are generated
+type IDSettings struct {
+	Strategy string `mapstructure:"strategy"` // ulid (default), sequence or snowflake
+	NodeID   int64  `mapstructure:"node_id"`  // Snowflake node, unique per running instance
+}
+
+// InvoiceSettings holds the seller details printed on invoices and how they are numbered
+type InvoiceSettings struct {
+	NumberPrefix  string `mapstructure:"number_prefix"`  // put in front of the sequence number; empty uses INV-
+	SellerName    string `mapstructure:"seller_name"`    // the business invoices are issued by
+	SellerAddress string `mapstructure:"seller_address"` // may span several lines
+	SellerTaxID   string `mapstructure:"seller_tax_id"`  // e.g. the GSTIN
+}
+
+// Supported ID generation strategies
+const (
+	IDStrategyULID      = "ulid"
+	IDStrategySequence  = "sequence"
+	IDStrategySnowflake = "snowflake"
+)
+
+// Supported fulfilment strategies
+const (
+	FulfilmentMostStock = "most_stock"
+	FulfilmentNearest   = "nearest"
+)
+
+// Supported inventory valuation methods for cost of goods sold
+const (
+	ValuationFIFO            = "fifo"
+	ValuationWeightedAverage = "weighted_average"
+)
+
 // Global configuration instance
 var Config *AppConfig
 
@@ -175,6 +219,32 @@ func validateConfig() error {
 		return fmt.Errorf("invalid server port: %d", Config.Server.Port)
 	}
 
+	if Config.Business.CurrencyPrecision < 0 || Config.Business.CurrencyPrecision > 4 {
+		return fmt.Errorf("invalid currency_precision: %d", Config.Business.CurrencyPrecision)
+	}
+
+	validStrategies := []string{"", IDStrategyULID, IDStrategySequence, IDStrategySnowflake}
+	if !slices.Contains(validStrategies, Config.IDs.Strategy) {
+		return fmt.Errorf("unsupported ID strategy: %s. Supported: %v",
+			Config.IDs.Strategy, validStrategies[1:])
+	}
+
+	validFulfilment := []string{"", FulfilmentMostStock, FulfilmentNearest}
+	if !slices.Contains(validFulfilment, Config.Business.FulfilmentStrategy) {
+		return fmt.Errorf("unsupported fulfilment strategy: %s. Supported: %v",
+			Config.Business.FulfilmentStrategy, validFulfilment[1:])
+	}
+
+	validValuation := []string{"", ValuationFIFO, ValuationWeightedAverage}
+	if !slices.Contains(validValuation, Config.Business.ValuationMethod) {
+		return fmt.Errorf("unsupported valuation method: %s. Supported: %v",
+			Config.Business.ValuationMethod, validValuation[1:])
+	}
+
+	if Config.IDs.NodeID < 0 || Config.IDs.NodeID > 1023 {
+		return fmt.Errorf("invalid ID node_id: %d", Config.IDs.NodeID)
+	}
+
 	return nil
 }
 
@@ -192,6 +262,9 @@ func (db *DatabaseSettings) GetDSN() string {
 			db.PostgresSSLMode, db.PostgresTimezone)
 
 	case "sqlite":
+		if db.SQLitePath == "" {
+			return db.Name
+		}
 		return db.SQLitePath
 
 	default:
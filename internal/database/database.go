@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -105,6 +106,14 @@ func (d *DatabaseImpl) connect(cfg *config.DatabaseSettings) error {
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime) * time.Second)
 
+	// An in-memory SQLite database lives and dies with its connection, so pin
+	// the pool to a single long-lived connection
+	if cfg.Dialect == "sqlite" && cfg.Name == ":memory:" {
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+	}
+
 	// Test connection
 	if err := sqlDB.Ping(); err != nil {
 		return fmt.Errorf("failed to ping database: %w", err)
@@ -163,6 +172,15 @@ func (d *DatabaseImpl) Migrate() error {
 		d.db.Exec("PRAGMA foreign_keys = OFF")
 	}
 
+	// Databases from before amounts were kept in minor units are converted
+	// first; AutoMigrate cannot add those NOT NULL columns to populated tables
+	if err := migrateLegacyMoney(d.db); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	if err := checkReservedStock(d.db); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
 	err := d.db.AutoMigrate(modelsToMigrate...)
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
@@ -177,6 +195,30 @@ func (d *DatabaseImpl) Migrate() error {
 	return nil
 }
 
+// checkReservedStock stops the migration when products hold fewer units than
+// are reserved, which earlier versions allowed; the check that forbids it
+// (chk_products_reserved_stock) cannot be added until their stock is fixed
+func checkReservedStock(db *gorm.DB) error {
+	product := &persistence.Product{}
+	if !db.Migrator().HasTable(product) || db.Migrator().HasConstraint(product, "chk_products_reserved_stock") {
+		return nil
+	}
+	columns, err := columnNames(db, product)
+	if err != nil || !columns["reserved_quantity"] {
+		return err
+	}
+
+	var ids []string
+	if err := db.Model(product).Where("reserved_quantity > quantity").Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to check reserved stock: %w", err)
+	}
+	if len(ids) > 0 {
+		return fmt.Errorf("products %s have more units reserved than in stock; add stock or release their reservations",
+			strings.Join(ids, ", "))
+	}
+	return nil
+}
+
 // getModelsToMigrate returns all models that need to be migrated
 func getModelsToMigrate() []any {
 	return persistence.GetModelsToMigrate()
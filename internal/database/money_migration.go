@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"day5/internal/domain/entities"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// legacyMoneyColumn is an amount column that databases created before money was
+// stored in minor units held as a decimal, and the model field replacing it
+type legacyMoneyColumn struct {
+	decimal string
+	field   string
+}
+
+// legacyMoneyTables lists the decimal amount columns of each table
+var legacyMoneyTables = []struct {
+	model   any
+	columns []legacyMoneyColumn
+}{
+	{&persistence.Product{}, []legacyMoneyColumn{
+		{decimal: "price", field: "PriceMinor"},
+	}},
+	{&persistence.Order{}, []legacyMoneyColumn{
+		{decimal: "unit_price", field: "UnitPriceMinor"},
+		{decimal: "total_amount", field: "TotalAmountMinor"},
+		{decimal: "store_credit_applied", field: "StoreCreditMinor"},
+	}},
+	{&persistence.OrderLine{}, []legacyMoneyColumn{
+		{decimal: "unit_price", field: "UnitPriceMinor"},
+		{decimal: "line_total", field: "LineTotalMinor"},
+	}},
+	{&persistence.OrderReturn{}, []legacyMoneyColumn{
+		{decimal: "unit_price", field: "UnitPriceMinor"},
+		{decimal: "refund_amount", field: "RefundAmountMinor"},
+	}},
+	{&persistence.Transaction{}, []legacyMoneyColumn{
+		{decimal: "amount", field: "AmountMinor"},
+		{decimal: "unit_price", field: "UnitPriceMinor"},
+	}},
+}
+
+// migrateLegacyMoney moves the amounts of a database created before money was
+// stored in minor units into its *_minor and currency columns
+// AutoMigrate cannot do this itself: it would add those columns as NOT NULL
+// without a default, which fails on tables that already have rows, and it
+// never copies the decimals across. So, for each table still holding decimals:
+//  1. the *_minor and currency columns are added as nullable
+//  2. they are filled from the decimals, in the default currency
+//  3. they are made NOT NULL, and the decimal columns and their checks are dropped
+//
+// AutoMigrate then adds the *_minor checks. Every step is skipped once done, so
+// an interrupted migration carries on where it stopped
+func migrateLegacyMoney(db *gorm.DB) error {
+	currency := entities.DefaultCurrency()
+	scale := int64(1)
+	for i := 0; i < entities.CurrencyPrecision(currency); i++ {
+		scale *= 10
+	}
+
+	for _, table := range legacyMoneyTables {
+		if err := migrateLegacyMoneyTable(db, table.model, table.columns, currency, scale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLegacyMoneyTable converts one table's decimal amount columns
+func migrateLegacyMoneyTable(db *gorm.DB, model any, columns []legacyMoneyColumn, currency string, scale int64) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return fmt.Errorf("failed to parse %T: %w", model, err)
+	}
+	table := clause.Table{Name: stmt.Table}
+
+	existing, err := columnNames(db, model)
+	if err != nil {
+		return err
+	}
+
+	var legacy []legacyMoneyColumn
+	for _, column := range columns {
+		if existing[column.decimal] {
+			legacy = append(legacy, column)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+	log.Printf("Converting decimal amounts in %s to minor units of %s", stmt.Table, currency)
+
+	// 1 and 2. Add the new columns as nullable and fill them from the decimals
+	for _, column := range legacy {
+		minor := stmt.Schema.LookUpField(column.field).DBName
+		if !existing[minor] {
+			if err := db.Exec("ALTER TABLE ? ADD ? BIGINT", table, clause.Column{Name: minor}).Error; err != nil {
+				return fmt.Errorf("failed to add %s.%s: %w", stmt.Table, minor, err)
+			}
+		}
+
+		if err := db.Exec("UPDATE ? SET ? = ROUND(? * ?) WHERE ? IS NULL",
+			table, clause.Column{Name: minor}, clause.Column{Name: column.decimal}, scale, clause.Column{Name: minor}).Error; err != nil {
+			return fmt.Errorf("failed to fill %s.%s: %w", stmt.Table, minor, err)
+		}
+	}
+
+	if !existing["currency"] {
+		if err := db.Exec("ALTER TABLE ? ADD ? VARCHAR(3)", table, clause.Column{Name: "currency"}).Error; err != nil {
+			return fmt.Errorf("failed to add %s.currency: %w", stmt.Table, err)
+		}
+	}
+	if err := db.Exec("UPDATE ? SET ? = ? WHERE ? IS NULL",
+		table, clause.Column{Name: "currency"}, currency, clause.Column{Name: "currency"}).Error; err != nil {
+		return fmt.Errorf("failed to fill %s.currency: %w", stmt.Table, err)
+	}
+
+	// 3. Enforce NOT NULL now every row has a value, then drop the decimals
+	for _, column := range legacy {
+		if err := migrator.AlterColumn(model, column.field); err != nil {
+			return fmt.Errorf("failed to alter %s.%s: %w", stmt.Table, column.field, err)
+		}
+	}
+	if err := migrator.AlterColumn(model, "Currency"); err != nil {
+		return fmt.Errorf("failed to alter %s.currency: %w", stmt.Table, err)
+	}
+
+	for _, column := range legacy {
+		check := db.NamingStrategy.CheckerName(stmt.Table, column.decimal)
+		if migrator.HasConstraint(model, check) {
+			if err := migrator.DropConstraint(model, check); err != nil {
+				return fmt.Errorf("failed to drop %s: %w", check, err)
+			}
+		}
+		if err := migrator.DropColumn(model, column.decimal); err != nil {
+			return fmt.Errorf("failed to drop %s.%s: %w", stmt.Table, column.decimal, err)
+		}
+	}
+
+	return nil
+}
+
+// columnNames returns the names of a table's columns
+func columnNames(db *gorm.DB, model any) (map[string]bool, error) {
+	columnTypes, err := db.Migrator().ColumnTypes(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %T: %w", model, err)
+	}
+
+	names := make(map[string]bool, len(columnTypes))
+	for _, columnType := range columnTypes {
+		names[columnType.Name()] = true
+	}
+	return names, nil
+}
@@ -0,0 +1,97 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCartEmpty is returned when checking out a cart with nothing in it
+var ErrCartEmpty = errors.New("cart is empty")
+
+// Cart is a customer's basket of products waiting to be checked out
+// Prices are not locked in: items are always shown at what the customer
+// would pay for them now
+type Cart struct {
+	CustomerID  string      `json:"customer_id"`
+	Items       []*CartItem `json:"items"`
+	ItemCount   int         `json:"item_count"`
+	TotalAmount Money       `json:"total_amount"`
+}
+
+// CartItem is one product in a customer's cart
+type CartItem struct {
+	CustomerID  string    `json:"customer_id"`
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name,omitempty"`
+	Quantity    int       `json:"quantity"`
+	UnitPrice   Money     `json:"unit_price"`
+	LineTotal   Money     `json:"line_total"`
+	PriceRuleID string    `json:"price_rule_id,omitempty"`
+	Available   bool      `json:"available"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Navigation property (not persisted, used for pricing)
+	Product *Product `json:"-"`
+}
+
+// Validate performs business rule validation for cart items
+func (i *CartItem) Validate() error {
+	if i.CustomerID == "" {
+		return fmt.Errorf("customer ID is required")
+	}
+
+	if i.ProductID == "" {
+		return fmt.Errorf("product ID is required")
+	}
+
+	if i.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero: %d", i.Quantity)
+	}
+
+	return nil
+}
+
+// NewCart builds a cart from its items, pricing each one from its quote, or
+// at its product's price when it has none
+// Every product in the cart must be priced in the same currency
+func NewCart(customerID string, items []*CartItem, quotes map[string]*PriceQuote) (*Cart, error) {
+	cart := &Cart{
+		CustomerID: customerID,
+		Items:      items,
+	}
+	if cart.Items == nil {
+		cart.Items = []*CartItem{}
+	}
+
+	for _, item := range cart.Items {
+		if item.Product != nil {
+			item.ProductName = item.Product.ProductName
+			item.UnitPrice = item.Product.Price
+			item.Available = item.Product.IsAvailable(item.Quantity)
+		}
+		if quote, ok := quotes[item.ProductID]; ok {
+			item.UnitPrice, item.PriceRuleID = quote.UnitPrice, quote.PriceRuleID
+		}
+		lineTotal, err := item.UnitPrice.Mul(item.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("cart item %s: %w", item.ProductID, err)
+		}
+		item.LineTotal = lineTotal
+
+		total, err := cart.TotalAmount.Add(item.LineTotal)
+		if err != nil {
+			return nil, fmt.Errorf("cart items must share one currency: %w", err)
+		}
+		cart.ItemCount += item.Quantity
+		cart.TotalAmount = total
+	}
+
+	return cart, nil
+}
+
+// IsEmpty returns true if the cart has no items
+func (c *Cart) IsEmpty() bool {
+	return len(c.Items) == 0
+}
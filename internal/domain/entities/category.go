@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxCategoryDepth is how many levels the category tree may have
+const MaxCategoryDepth = 10
+
+// Category groups products in a tree
+// A product belongs to at most one category and is counted in every category above it
+type Category struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  string    `json:"parent_id,omitempty"` // empty for a top-level category
+	Path      string    `json:"path"`                // IDs from the top down to this category, e.g. /CAT1/CAT2/
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrInvalidCategory is returned when a category breaks a business rule
+var ErrInvalidCategory = errors.New("invalid category")
+
+// SetParent places the category under parent, or at the top when parent is nil
+// A category cannot be moved under itself or one of its descendants
+func (c *Category) SetParent(parent *Category) error {
+	if parent == nil {
+		c.ParentID = ""
+		c.Path = "/" + c.ID + "/"
+		return nil
+	}
+
+	if c.Path != "" && strings.HasPrefix(parent.Path, c.Path) {
+		return fmt.Errorf("%w: %s cannot be moved under itself or one of its subcategories", ErrInvalidCategory, c.ID)
+	}
+	if parent.Depth() >= MaxCategoryDepth {
+		return fmt.Errorf("%w: categories can be at most %d levels deep", ErrInvalidCategory, MaxCategoryDepth)
+	}
+
+	c.ParentID = parent.ID
+	c.Path = parent.Path + c.ID + "/"
+	return nil
+}
+
+// AncestorIDs returns the IDs on the category's path, the top-level category
+// first and the category itself last
+func (c *Category) AncestorIDs() []string {
+	return strings.Split(strings.Trim(c.Path, "/"), "/")
+}
+
+// Depth returns the category's level in the tree, 1 for a top-level category
+func (c *Category) Depth() int {
+	return strings.Count(c.Path, "/") - 1
+}
+
+// Validate performs business rule validation for categories
+func (c *Category) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+	if len(c.Name) > 100 {
+		return fmt.Errorf("%w: name must be at most 100 characters", ErrInvalidCategory)
+	}
+	if !strings.HasSuffix(c.Path, "/"+c.ID+"/") {
+		return fmt.Errorf("%w: path %q does not end at %s", ErrInvalidCategory, c.Path, c.ID)
+	}
+	return nil
+}
+
+// CategorySales is what the products in a category and its subcategories sold
+type CategorySales struct {
+	CategoryID    string  `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	ParentID      string  `json:"parent_id,omitempty"`
+	Depth         int     `json:"depth"`
+	QuantitySold  int     `json:"quantity_sold"`
+	TotalRevenue  Money   `json:"total_revenue"`
+	CostOfGoods   Money   `json:"cost_of_goods"`
+	GrossMargin   Money   `json:"gross_margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
+
+// AddProduct counts a product's sales towards the category
+func (cs *CategorySales) AddProduct(sales *ProductSales) error {
+	revenue, err := cs.TotalRevenue.Add(sales.TotalRevenue)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	cost, err := cs.CostOfGoods.Add(sales.CostOfGoods)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+
+	cs.QuantitySold += sales.QuantitySold
+	cs.TotalRevenue, cs.CostOfGoods = revenue, cost
+	return nil
+}
+
+// CalculateMargin works out the category's gross margin from its revenue and cost
+func (cs *CategorySales) CalculateMargin() error {
+	margin, percent, err := grossMargin(cs.TotalRevenue, cs.CostOfGoods)
+	if err != nil {
+		return err
+	}
+	cs.GrossMargin, cs.MarginPercent = margin, percent
+	return nil
+}
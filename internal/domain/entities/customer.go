@@ -12,6 +12,13 @@ type Customer struct {
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
 	Phone     string    `json:"phone"`
+	PriceList PriceList `json:"price_list"`
+
+	// TaxJurisdiction is where the customer's orders are taxed, such as
+	// "IN-KA"; without one the configured jurisdiction applies
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
+
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -39,6 +46,14 @@ func (c *Customer) Validate() error {
 		return fmt.Errorf("phone number is required")
 	}
 
+	if !c.PriceList.IsValid() {
+		return fmt.Errorf("unknown price list: %q", c.PriceList)
+	}
+
+	if err := ValidateJurisdiction(c.TaxJurisdiction); err != nil {
+		return err
+	}
+
 	return nil
 }
 
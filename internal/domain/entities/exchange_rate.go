@@ -0,0 +1,157 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RateDecimals is the number of decimal places an exchange rate is held to
+const RateDecimals = 8
+
+// rateScale is 10^RateDecimals
+const rateScale int64 = 100_000_000
+
+var (
+	// ErrInvalidExchangeRate is returned when an exchange rate cannot be parsed or breaks a business rule
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+
+	// ErrNoExchangeRate is returned when no rate for a currency was in effect at the time asked for
+	ErrNoExchangeRate = errors.New("no exchange rate in effect")
+)
+
+// Rate is an exact exchange rate held to RateDecimals decimal places
+type Rate struct {
+	scaled int64
+}
+
+// IdentityRate is the rate between a currency and itself
+var IdentityRate = Rate{scaled: rateScale}
+
+// ParseRate parses a decimal rate such as "0.0112"
+func ParseRate(value string) (Rate, error) {
+	scaled, err := parseDecimal(value, RateDecimals)
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: %s", ErrInvalidExchangeRate, err)
+	}
+	return Rate{scaled: scaled}, nil
+}
+
+// NewRateFromScaled creates a rate from its value times 10^RateDecimals
+func NewRateFromScaled(scaled int64) Rate {
+	return Rate{scaled: scaled}
+}
+
+// Scaled returns the rate times 10^RateDecimals
+func (r Rate) Scaled() int64 {
+	return r.scaled
+}
+
+// IsPositive returns true if the rate is greater than zero
+func (r Rate) IsPositive() bool {
+	return r.scaled > 0
+}
+
+// String renders the rate without trailing zeros, e.g. "0.0112"
+func (r Rate) String() string {
+	s := formatDecimal(r.scaled, RateDecimals)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// MarshalJSON renders the rate as a string so clients never parse it as a float
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts the rate as a string or a number
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	value := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseRate(value)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Exchange converts the amount into currency at rate, the number of units of
+// currency one unit of m's currency buys, rounding half to even
+func (m Money) Exchange(currency string, rate Rate) Money {
+	currency = normalizeCurrency(currency)
+	from, to := CurrencyPrecision(m.Currency()), CurrencyPrecision(currency)
+
+	// minor_to = minor_from * rate * 10^to / 10^from
+	num, den := rate.scaled, rateScale
+	for ; to > 0; to-- {
+		num *= 10
+	}
+	for ; from > 0; from-- {
+		den *= 10
+	}
+
+	converted := m.MulRat(num, den)
+	return Money{minor: converted.minor, currency: currency}
+}
+
+// ExchangeRate is the rate from the base currency to another currency, in
+// effect from EffectiveFrom until the next rate for the same currency
+type ExchangeRate struct {
+	ID            uint      `json:"id"`
+	BaseCurrency  string    `json:"base_currency"`
+	Currency      string    `json:"currency"`
+	Rate          Rate      `json:"rate"` // units of Currency one unit of BaseCurrency buys
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate performs business rule validation for exchange rates
+func (e *ExchangeRate) Validate() error {
+	if !isCurrencyCode(e.BaseCurrency) {
+		return fmt.Errorf("%w: invalid base currency %q", ErrInvalidExchangeRate, e.BaseCurrency)
+	}
+	if !isCurrencyCode(e.Currency) {
+		return fmt.Errorf("%w: invalid currency %q", ErrInvalidExchangeRate, e.Currency)
+	}
+	if e.Currency == e.BaseCurrency {
+		return fmt.Errorf("%w: %s is the base currency", ErrInvalidExchangeRate, e.Currency)
+	}
+	if !e.Rate.IsPositive() {
+		return fmt.Errorf("%w: rate must be greater than zero", ErrInvalidExchangeRate)
+	}
+	if e.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effective date is required", ErrInvalidExchangeRate)
+	}
+	return nil
+}
+
+// Convert converts an amount in the base currency into the rate's currency
+func (e *ExchangeRate) Convert(amount Money) (Money, error) {
+	if amount.Currency() != e.BaseCurrency && !amount.IsZero() {
+		return Money{}, fmt.Errorf("%w: rate converts %s, not %s", ErrCurrencyMismatch, e.BaseCurrency, amount.Currency())
+	}
+	return amount.Exchange(e.Currency, e.Rate), nil
+}
+
+// RevenueAtRate is revenue in the base currency that converts to a reporting
+// currency at a single exchange rate
+type RevenueAtRate struct {
+	RateID   uint   // 0 when no rate was in effect
+	Key      string // product ID or date, depending on how revenue was grouped
+	Gross    Money
+	Refunded Money
+	Cost     Money // cost of goods sold, net of goods that went back into stock
+}
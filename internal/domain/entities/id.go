@@ -0,0 +1,25 @@
+package entities
+
+// Human-readable prefixes for generated entity IDs
+// An ID is its prefix followed by the generator's unique part, e.g. ORD01J9Z3KQ7R8X4M2N6P5T0VWYAB
+const (
+	ProductIDPrefix       = "PROD"
+	CustomerIDPrefix      = "CUST"
+	OrderIDPrefix         = "ORD"
+	TransactionIDPrefix   = "TXN"
+	ReturnIDPrefix        = "RMA"
+	ReservationIDPrefix   = "RSV"
+	MovementIDPrefix      = "MOV"
+	LocationIDPrefix      = "LOC"
+	TransferIDPrefix      = "TRF"
+	SupplierIDPrefix      = "SUP"
+	PurchaseOrderIDPrefix = "PO"
+	ReceiptIDPrefix       = "GRN"
+	BatchIDPrefix         = "BAT"
+	CategoryIDPrefix      = "CAT"
+	PriceRuleIDPrefix     = "PRC"
+	PromotionIDPrefix     = "PRM"
+)
+
+// MaxIDLength is the widest ID any generator may produce; ID columns are sized for it
+const MaxIDLength = 32
@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxIdempotencyKeyLength is the longest Idempotency-Key a client may send
+const MaxIdempotencyKeyLength = 255
+
+// DefaultIdempotencyKeyTTL is how long a response is kept for replay
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+var (
+	// ErrInvalidIdempotencyKey is returned when an Idempotency-Key is empty or too long
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+
+	// ErrIdempotencyKeyInProgress is returned when the first request with a key has not finished yet
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyRecord remembers the response to a request sent with an
+// Idempotency-Key so that retries of the same request get the same response
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"` // hash of the method, path and body of the request
+	StatusCode  int       `json:"status_code"` // 0 while the first request is still being handled
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// NewIdempotencyRecord starts a record for a request that is about to be handled
+func NewIdempotencyRecord(key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	now := time.Now().UTC()
+	return &IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}, nil
+}
+
+// IsCompleted returns true once the response has been stored
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
+
+// IsExpired returns true if the record may no longer be replayed
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Complete stores the response that will be replayed for retries
+func (r *IdempotencyRecord) Complete(statusCode int, contentType string, body []byte) {
+	r.StatusCode = statusCode
+	r.ContentType = contentType
+	r.Body = body
+}
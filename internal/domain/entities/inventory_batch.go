@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InventoryBatch is a lot of stock that came in at one unit cost
+// Every unit that enters stock belongs to a batch; units leave from the oldest
+// batch first, so the remaining units of open batches value stock on a FIFO basis
+type InventoryBatch struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"product_id"`
+	ReferenceID string    `json:"reference_id,omitempty"` // goods receipt, order, return or document that brought it in
+	Quantity    int       `json:"quantity"`               // units that came in
+	Remaining   int       `json:"remaining"`              // units still on hand
+	UnitCost    Money     `json:"unit_cost"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ValuationMethod selects how stock on hand and the cost of goods sold are valued
+type ValuationMethod string
+
+const (
+	// ValuationFIFO values units at the cost of the batches they came from, oldest first
+	ValuationFIFO ValuationMethod = "fifo"
+	// ValuationWeightedAverage values units at the product's running average cost
+	ValuationWeightedAverage ValuationMethod = "weighted_average"
+	// ValuationRetail values units at the current selling price; it is not a cost method
+	ValuationRetail ValuationMethod = "retail"
+)
+
+// ErrInvalidValuationMethod is returned for an unknown valuation method
+var ErrInvalidValuationMethod = errors.New("invalid valuation method")
+
+// IsValid checks if the method is one of the known valuation methods
+func (m ValuationMethod) IsValid() bool {
+	return m == ValuationFIFO || m == ValuationWeightedAverage || m == ValuationRetail
+}
+
+// IsCostMethod returns true for the methods that can cost goods sold
+func (m ValuationMethod) IsCostMethod() bool {
+	return m == ValuationFIFO || m == ValuationWeightedAverage
+}
+
+// NewInventoryBatch records quantity units coming into stock at unitCost
+func NewInventoryBatch(id, productID, referenceID string, quantity int, unitCost Money) (*InventoryBatch, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: batch quantity must be greater than zero: %d", ErrInvalidMovement, quantity)
+	}
+	if unitCost.IsNegative() {
+		return nil, fmt.Errorf("%w: unit cost cannot be negative: %s", ErrInvalidAmount, unitCost)
+	}
+
+	return &InventoryBatch{
+		ID:          id,
+		ProductID:   productID,
+		ReferenceID: referenceID,
+		Quantity:    quantity,
+		Remaining:   quantity,
+		UnitCost:    unitCost,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// ConsumeFIFO takes quantity units out of batches, which must be ordered oldest
+// first, and returns the batches it drew from and what the units cost
+// Units beyond what the batches hold are left for the caller to cost
+func ConsumeFIFO(batches []*InventoryBatch, quantity int) (used []*InventoryBatch, taken int, cost Money, err error) {
+	cost = NewMoney(0, "")
+	for _, batch := range batches {
+		if taken == quantity {
+			break
+		}
+
+		units := min(batch.Remaining, quantity-taken)
+		if units <= 0 {
+			continue
+		}
+		value, err := batch.UnitCost.Mul(units)
+		if err != nil {
+			return nil, 0, Money{}, err
+		}
+		if cost, err = cost.Add(value); err != nil {
+			return nil, 0, Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+		}
+		batch.Remaining -= units
+		taken += units
+		used = append(used, batch)
+	}
+
+	return used, taken, cost, nil
+}
+
+// InventoryValuation is the value of stock on hand under one valuation method
+type InventoryValuation struct {
+	Method     ValuationMethod     `json:"method"`
+	LocationID string              `json:"location_id,omitempty"` // empty for all locations
+	Value      Money               `json:"value"`
+	Products   []*ProductValuation `json:"products"`
+}
+
+// ProductValuation is the value of one product's stock on hand
+type ProductValuation struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitValue   Money  `json:"unit_value"` // value divided by quantity, rounded
+	Value       Money  `json:"value"`
+}
@@ -0,0 +1,135 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InventoryMovement is an immutable ledger entry recording one change to a product's stock on hand
+// Replaying a product's movements in order explains how it reached its current quantity;
+// the quantities before and after are those at the movement's location
+type InventoryMovement struct {
+	ID             string         `json:"id"`
+	ProductID      string         `json:"product_id"`
+	LocationID     string         `json:"location_id,omitempty"`
+	Reason         MovementReason `json:"reason"`
+	Change         int            `json:"change"` // positive into stock, negative out of it
+	QuantityBefore int            `json:"quantity_before"`
+	QuantityAfter  int            `json:"quantity_after"`
+	Actor          string         `json:"actor"`
+	ReferenceID    string         `json:"reference_id,omitempty"` // order, return or document that caused it
+	Note           string         `json:"note,omitempty"`
+
+	// Cost is what the units moved cost under the configured valuation method,
+	// with the same sign as Change; transfers move no cost
+	Cost Money `json:"cost"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MovementReason explains why a product's stock changed
+type MovementReason string
+
+const (
+	MovementReasonSale         MovementReason = "sale"
+	MovementReasonCancellation MovementReason = "cancellation"
+	MovementReasonReturn       MovementReason = "return"
+	MovementReasonAdjustment   MovementReason = "adjustment"
+	MovementReasonStocktake    MovementReason = "stocktake"
+	MovementReasonDamage       MovementReason = "damage"
+	MovementReasonReceipt      MovementReason = "receipt"
+	MovementReasonTransfer     MovementReason = "transfer"
+)
+
+// ErrInvalidMovement is returned when a stock change breaks a ledger rule
+var ErrInvalidMovement = errors.New("invalid inventory movement")
+
+// MovementSource identifies why, by whom and for what a stock change is made
+// An empty LocationID means the default location
+type MovementSource struct {
+	Reason      MovementReason
+	LocationID  string
+	Actor       string
+	ReferenceID string
+	Note        string
+
+	// UnitCost is what each unit coming into stock cost; nil means the
+	// product's average cost. It is ignored for stock going out
+	UnitCost *Money
+}
+
+// IsValid checks if the reason is one of the known movement reasons
+func (r MovementReason) IsValid() bool {
+	switch r {
+	case MovementReasonSale, MovementReasonCancellation, MovementReasonReturn,
+		MovementReasonAdjustment, MovementReasonStocktake, MovementReasonDamage, MovementReasonReceipt,
+		MovementReasonTransfer:
+		return true
+	}
+	return false
+}
+
+// IsManual returns true for reasons a retailer may give when editing stock by hand
+// Sales, cancellations and returns are only recorded by the order and return flows
+func (r MovementReason) IsManual() bool {
+	switch r {
+	case MovementReasonAdjustment, MovementReasonStocktake, MovementReasonDamage, MovementReasonReceipt:
+		return true
+	}
+	return false
+}
+
+// allowsChange checks that the direction of a change fits the reason
+func (r MovementReason) allowsChange(change int) bool {
+	switch r {
+	case MovementReasonSale, MovementReasonDamage:
+		return change < 0
+	case MovementReasonCancellation, MovementReasonReturn, MovementReasonReceipt:
+		return change > 0
+	default:
+		return change != 0
+	}
+}
+
+// NewInventoryMovement records a change of stock from before to after
+func NewInventoryMovement(id, productID string, before, after int, source MovementSource) (*InventoryMovement, error) {
+	movement := &InventoryMovement{
+		ID:             id,
+		ProductID:      productID,
+		LocationID:     source.LocationID,
+		Reason:         source.Reason,
+		Change:         after - before,
+		QuantityBefore: before,
+		QuantityAfter:  after,
+		Actor:          source.Actor,
+		ReferenceID:    source.ReferenceID,
+		Note:           source.Note,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	if err := movement.Validate(); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// Validate performs business rule validation for movements
+func (m *InventoryMovement) Validate() error {
+	if m.ProductID == "" {
+		return fmt.Errorf("%w: product ID is required", ErrInvalidMovement)
+	}
+	if !m.Reason.IsValid() {
+		return fmt.Errorf("%w: invalid reason: %q", ErrInvalidMovement, m.Reason)
+	}
+	if !m.Reason.allowsChange(m.Change) {
+		return fmt.Errorf("%w: a %s cannot change stock by %d", ErrInvalidMovement, m.Reason, m.Change)
+	}
+	if m.QuantityBefore < 0 || m.QuantityAfter < 0 {
+		return fmt.Errorf("%w: quantities cannot be negative", ErrInvalidMovement)
+	}
+	if m.Actor == "" {
+		return fmt.Errorf("%w: actor is required", ErrInvalidMovement)
+	}
+	return nil
+}
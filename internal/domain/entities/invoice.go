@@ -0,0 +1,229 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrInvalidInvoice is returned when an invoice does not match the order it is for
+var ErrInvalidInvoice = errors.New("invalid invoice")
+
+// DefaultInvoicePrefix is put in front of invoice numbers unless another prefix is configured
+const DefaultInvoicePrefix = "INV-"
+
+// invoicePrefixPattern is what an invoice number prefix may look like; with
+// six digits after it the number stays within the 16 characters GST allows
+var invoicePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9/-]{0,10}$`)
+
+// ValidateInvoicePrefix checks a configured invoice number prefix
+func ValidateInvoicePrefix(prefix string) error {
+	if !invoicePrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("%w: an invoice prefix is up to 10 letters, digits, dashes or slashes: %q", ErrInvalidInvoice, prefix)
+	}
+	return nil
+}
+
+// FormatInvoiceNumber renders the invoice number for a sequence number, e.g. INV-000042
+func FormatInvoiceNumber(prefix string, sequence int64) string {
+	return fmt.Sprintf("%s%06d", prefix, sequence)
+}
+
+// InvoiceParty is the seller or the customer named on an invoice, as they
+// were when the invoice was issued
+type InvoiceParty struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name"`
+	Address         string `json:"address,omitempty"`
+	TaxID           string `json:"tax_id,omitempty"`
+	Email           string `json:"email,omitempty"`
+	Phone           string `json:"phone,omitempty"`
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
+}
+
+// Invoice is the bill for an order, issued when the order is placed
+// It snapshots the customer, products, prices and tax at that time, so it
+// reads the same however the catalogue, customer or rates change later.
+// Sequence numbers invoices one after another with no gaps; Number is its
+// rendering with the configured prefix
+type Invoice struct {
+	Number          string         `json:"number"`
+	Sequence        int64          `json:"sequence"`
+	OrderID         string         `json:"order_id"`
+	IssuedAt        time.Time      `json:"issued_at"`
+	Seller          InvoiceParty   `json:"seller"`
+	Customer        InvoiceParty   `json:"customer"`
+	TaxJurisdiction string         `json:"tax_jurisdiction,omitempty"`
+	Lines           []*InvoiceLine `json:"lines"`
+
+	// Subtotal is what the lines cost after discounts and without tax;
+	// Subtotal plus Tax is Total, what the customer pays
+	Subtotal Money `json:"subtotal"`
+	Discount Money `json:"discount"`
+	Tax      Money `json:"tax"`
+	Total    Money `json:"total"`
+
+	// TaxSummary totals the tax by component and rate, worked out from the lines
+	TaxSummary []*InvoiceTax `json:"tax_summary"`
+}
+
+// InvoiceLine is one product on an invoice
+type InvoiceLine struct {
+	LineNumber    int        `json:"line_number"`
+	ProductID     string     `json:"product_id"`
+	SKU           string     `json:"sku,omitempty"`
+	ProductName   string     `json:"product_name"`
+	Quantity      int        `json:"quantity"`
+	UnitPrice     Money      `json:"unit_price"`
+	Discount      Money      `json:"discount"`
+	TaxableAmount Money      `json:"taxable_amount"`
+	TaxClass      string     `json:"tax_class,omitempty"`
+	TaxRate       Percent    `json:"tax_rate"`
+	TaxInclusive  bool       `json:"tax_inclusive"`
+	Tax           Money      `json:"tax"`
+	Taxes         []*LineTax `json:"taxes,omitempty"`
+	LineTotal     Money      `json:"line_total"`
+}
+
+// InvoiceTax is the tax an invoice charges under one component at one rate
+type InvoiceTax struct {
+	Name          string  `json:"name"`
+	Rate          Percent `json:"rate"`
+	TaxableAmount Money   `json:"taxable_amount"`
+	Amount        Money   `json:"amount"`
+}
+
+// NewInvoice drafts the invoice for a priced and taxed order from the seller,
+// the order's customer and its products; the number is given when it is issued
+func NewInvoice(order *Order, seller InvoiceParty, products map[string]*Product) (*Invoice, error) {
+	if order.Customer == nil {
+		return nil, fmt.Errorf("%w: order %s has no customer details", ErrInvalidInvoice, order.ID)
+	}
+
+	invoice := &Invoice{
+		OrderID:  order.ID,
+		IssuedAt: order.OrderDate,
+		Seller:   seller,
+		Customer: InvoiceParty{
+			ID:              order.Customer.ID,
+			Name:            order.Customer.Name,
+			Email:           order.Customer.Email,
+			Phone:           order.Customer.Phone,
+			TaxJurisdiction: order.Customer.TaxJurisdiction,
+		},
+		TaxJurisdiction: order.TaxJurisdiction,
+		Discount:        order.Discount,
+		Tax:             order.Tax,
+		Total:           order.TotalAmount,
+	}
+
+	subtotal := NewMoney(0, order.TotalAmount.Currency())
+	for _, line := range order.OrderLines() {
+		invoiceLine := &InvoiceLine{
+			LineNumber:    line.LineNumber,
+			ProductID:     line.ProductID,
+			ProductName:   line.ProductName,
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			Discount:      line.Discount,
+			TaxableAmount: line.TaxableAmount,
+			TaxClass:      line.TaxClass,
+			TaxRate:       line.TaxRate,
+			TaxInclusive:  line.TaxInclusive,
+			Tax:           line.Tax,
+			LineTotal:     line.LineTotal,
+		}
+		for _, tax := range line.Taxes {
+			copied := *tax
+			invoiceLine.Taxes = append(invoiceLine.Taxes, &copied)
+		}
+		if product, ok := products[line.ProductID]; ok {
+			invoiceLine.ProductName = product.ProductName
+			invoiceLine.SKU = product.SKU
+		}
+
+		var err error
+		if subtotal, err = subtotal.Add(line.TaxableAmount); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidInvoice, err)
+		}
+		invoice.Lines = append(invoice.Lines, invoiceLine)
+	}
+	invoice.Subtotal = subtotal
+	invoice.Summarize()
+
+	if err := invoice.Validate(); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// Issue gives the invoice its place in the invoice sequence
+func (i *Invoice) Issue(prefix string, sequence int64) {
+	i.Sequence = sequence
+	i.Number = FormatInvoiceNumber(prefix, sequence)
+}
+
+// Summarize works out TaxSummary from the lines' taxes, one entry per
+// component and rate in the order they first appear
+func (i *Invoice) Summarize() {
+	i.TaxSummary = nil
+	for _, line := range i.Lines {
+		for _, tax := range line.Taxes {
+			var summary *InvoiceTax
+			for _, existing := range i.TaxSummary {
+				if existing.Name == tax.Name && existing.Rate.Hundredths() == tax.Rate.Hundredths() {
+					summary = existing
+					break
+				}
+			}
+			if summary == nil {
+				summary = &InvoiceTax{
+					Name:          tax.Name,
+					Rate:          tax.Rate,
+					TaxableAmount: NewMoney(0, line.TaxableAmount.Currency()),
+					Amount:        NewMoney(0, tax.Amount.Currency()),
+				}
+				i.TaxSummary = append(i.TaxSummary, summary)
+			}
+			summary.TaxableAmount = NewMoney(summary.TaxableAmount.Minor()+line.TaxableAmount.Minor(), summary.TaxableAmount.Currency())
+			summary.Amount = NewMoney(summary.Amount.Minor()+tax.Amount.Minor(), summary.Amount.Currency())
+		}
+	}
+}
+
+// IsTaxInvoice reports whether any line was taxed, so the document is a tax invoice
+func (i *Invoice) IsTaxInvoice() bool {
+	for _, line := range i.Lines {
+		if line.TaxClass != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the invoice adds up
+func (i *Invoice) Validate() error {
+	if i.OrderID == "" {
+		return fmt.Errorf("%w: order ID is required", ErrInvalidInvoice)
+	}
+	if len(i.Lines) == 0 {
+		return fmt.Errorf("%w: an invoice needs at least one line", ErrInvalidInvoice)
+	}
+
+	var total, tax int64
+	for _, line := range i.Lines {
+		total += line.LineTotal.Minor()
+		tax += line.Tax.Minor()
+	}
+	if total != i.Total.Minor() {
+		return fmt.Errorf("%w: lines add up to %d minor units, not the total %s", ErrInvalidInvoice, total, i.Total)
+	}
+	if tax != i.Tax.Minor() {
+		return fmt.Errorf("%w: line taxes add up to %d minor units, not the tax %s", ErrInvalidInvoice, tax, i.Tax)
+	}
+	if i.Subtotal.Minor()+i.Tax.Minor() != i.Total.Minor() {
+		return fmt.Errorf("%w: subtotal %s and tax %s do not make the total %s", ErrInvalidInvoice, i.Subtotal, i.Tax, i.Total)
+	}
+	return nil
+}
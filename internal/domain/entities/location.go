@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultLocationCode is the code of the location created for stock that has no other home
+const DefaultLocationCode = "MAIN"
+
+// Location is a place that holds stock, such as a store or a warehouse
+// Every unit of a product's quantity is held at exactly one location
+type Location struct {
+	ID        string       `json:"id"`
+	Code      string       `json:"code"`
+	Name      string       `json:"name"`
+	Type      LocationType `json:"type"`
+	Position  *GeoPoint    `json:"position,omitempty"`
+	IsDefault bool         `json:"is_default"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// LocationType distinguishes stores from warehouses
+type LocationType string
+
+const (
+	LocationTypeStore     LocationType = "store"
+	LocationTypeWarehouse LocationType = "warehouse"
+)
+
+// ErrInvalidLocation is returned when a location breaks a business rule
+var ErrInvalidLocation = errors.New("invalid location")
+
+// IsValid checks if the type is one of the known location types
+func (t LocationType) IsValid() bool {
+	return t == LocationTypeStore || t == LocationTypeWarehouse
+}
+
+// GeoPoint is a position on Earth in decimal degrees
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// Validate checks that the point lies within the valid coordinate ranges
+func (p GeoPoint) Validate() error {
+	if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("%w: coordinates out of range: %v, %v", ErrInvalidLocation, p.Latitude, p.Longitude)
+	}
+	return nil
+}
+
+// DistanceKm returns the great-circle distance between two points in kilometres
+func (p GeoPoint) DistanceKm(other GeoPoint) float64 {
+	lat1, lat2 := p.Latitude*math.Pi/180, other.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - p.Longitude) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Validate performs business rule validation for locations
+func (l *Location) Validate() error {
+	if strings.TrimSpace(l.Code) == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidLocation)
+	}
+	if len(l.Code) > 20 {
+		return fmt.Errorf("%w: code must be at most 20 characters", ErrInvalidLocation)
+	}
+	if strings.TrimSpace(l.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidLocation)
+	}
+	if !l.Type.IsValid() {
+		return fmt.Errorf("%w: invalid type: %q", ErrInvalidLocation, l.Type)
+	}
+	if l.Position != nil {
+		return l.Position.Validate()
+	}
+	return nil
+}
+
+// StockLevel is the quantity of a product held at one location, or across
+// all locations when LocationID is empty
+// Reserved is the part of it set aside by active holds placed at the location
+type StockLevel struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	LocationID  string `json:"location_id,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Reserved    int    `json:"reserved"`
+}
+
+// Available returns the units at the location that are not held
+func (l *StockLevel) Available() int {
+	return l.Quantity - l.Reserved
+}
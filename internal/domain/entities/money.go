@@ -0,0 +1,409 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Money is an exact amount of one currency, held as an integer number of minor
+// units (cents, paise) so that sums and comparisons never drift
+// The zero value is zero in no particular currency and combines with any currency
+type Money struct {
+	minor    int64
+	currency string
+}
+
+var (
+	// ErrInvalidAmount is returned when an amount cannot be parsed or breaks a business rule
+	ErrInvalidAmount = errors.New("invalid amount")
+
+	// ErrCurrencyMismatch is returned when combining amounts of different currencies
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// currencyPrecisions lists ISO 4217 currencies that do not have two decimal places
+var currencyPrecisions = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+// maxPrecision caps the number of decimal places a currency may have
+const maxPrecision = 4
+
+var (
+	currencyMu         sync.RWMutex
+	defaultCurrency    = "INR"
+	precisionOverrides = map[string]int{}
+)
+
+// ConfigureDefaultCurrency sets the currency used for amounts given without one,
+// and the number of decimal places it is held to; a precision of 0 keeps the
+// currency's ISO 4217 precision
+func ConfigureDefaultCurrency(code string, precision int) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !isCurrencyCode(code) {
+		return fmt.Errorf("invalid currency code: %q", code)
+	}
+	if precision < 0 || precision > maxPrecision {
+		return fmt.Errorf("currency precision must be between 0 and %d: %d", maxPrecision, precision)
+	}
+
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+	defaultCurrency = code
+	if precision > 0 {
+		precisionOverrides[code] = precision
+	} else {
+		delete(precisionOverrides, code)
+	}
+	return nil
+}
+
+// DefaultCurrency returns the currency used for amounts given without one
+func DefaultCurrency() string {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	return defaultCurrency
+}
+
+// CurrencyPrecision returns the number of decimal places of a currency
+func CurrencyPrecision(code string) int {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+
+	if precision, ok := precisionOverrides[code]; ok {
+		return precision
+	}
+	if precision, ok := currencyPrecisions[code]; ok {
+		return precision
+	}
+	return 2
+}
+
+// isCurrencyCode checks for a three-letter upper-case ISO 4217 style code
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeCurrency upper-cases code and falls back to the default currency when it is empty
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency()
+	}
+	return code
+}
+
+// NewMoney creates an amount from minor units; an empty currency means the default currency
+func NewMoney(minor int64, currency string) Money {
+	return Money{minor: minor, currency: normalizeCurrency(currency)}
+}
+
+// ParseMoney parses a decimal amount such as "29.99" in the given currency
+// An empty currency means the default currency. Amounts with more decimal
+// places than the currency has are rejected rather than silently rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	currency = normalizeCurrency(currency)
+	if !isCurrencyCode(currency) {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidAmount, currency)
+	}
+	precision := CurrencyPrecision(currency)
+
+	minor, err := parseDecimal(amount, precision)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %s for %s", ErrInvalidAmount, err, currency)
+	}
+
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// parseDecimal parses a plain decimal such as "-29.99" into an integer scaled
+// by 10^places. More significant decimal places than that are an error.
+func parseDecimal(value string, places int) (int64, error) {
+	s := strings.TrimSpace(value)
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("%q is not a decimal", value)
+	}
+
+	// Trailing zeros beyond the precision carry no value
+	trimmed := strings.TrimRight(fraction, "0")
+	if len(trimmed) > places {
+		return 0, fmt.Errorf("%q has more than %d decimal places", value, places)
+	}
+	digits := whole + trimmed + strings.Repeat("0", places-len(trimmed))
+
+	var n int64
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%q is not a decimal", value)
+		}
+		if n > (math.MaxInt64-int64(r-'0'))/10 {
+			return 0, fmt.Errorf("%q is too large", value)
+		}
+		n = n*10 + int64(r-'0')
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
+
+// formatDecimal renders an integer scaled by 10^places as a plain decimal
+func formatDecimal(n int64, places int) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absInt64(n), 10)
+	if places == 0 {
+		return sign + digits
+	}
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-places] + "." + digits[len(digits)-places:]
+}
+
+// MustParseMoney is like ParseMoney but panics on error; meant for constants and tests
+func MustParseMoney(amount, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the currency code, or the default currency for the zero value
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return DefaultCurrency()
+	}
+	return m.currency
+}
+
+// IsZero returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsPositive returns true if the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// IsNegative returns true if the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// common returns the currency two amounts share; a zero value takes the other's currency
+func (m Money) common(o Money) (string, error) {
+	switch {
+	case m.currency == o.currency:
+		return m.currency, nil
+	case m.currency == "" && m.minor == 0:
+		return o.currency, nil
+	case o.currency == "" && o.minor == 0:
+		return m.currency, nil
+	default:
+		return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency(), o.Currency())
+	}
+}
+
+// Add returns m + o; both amounts must be in the same currency
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.common(o)
+	if err != nil {
+		return Money{}, err
+	}
+	if (o.minor > 0 && m.minor > math.MaxInt64-o.minor) || (o.minor < 0 && m.minor < math.MinInt64-o.minor) {
+		return Money{}, fmt.Errorf("%w: %s + %s overflows", ErrInvalidAmount, m, o)
+	}
+	return Money{minor: m.minor + o.minor, currency: currency}, nil
+}
+
+// Sub returns m - o; both amounts must be in the same currency
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Cmp compares m and o, returning -1, 0 or +1; both amounts must be in the same currency
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.common(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.minor < o.minor:
+		return -1, nil
+	case m.minor > o.minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Equal returns true if m and o are the same amount of the same currency
+func (m Money) Equal(o Money) bool {
+	c, err := m.Cmp(o)
+	return err == nil && c == 0
+}
+
+// Mul returns the amount multiplied by a whole number, e.g. a unit price by a quantity
+func (m Money) Mul(n int) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(int64(n)))
+	if !product.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s × %d overflows", ErrInvalidAmount, m, n)
+	}
+	return Money{minor: product.Int64(), currency: m.currency}, nil
+}
+
+// MulRat returns the amount multiplied by num/den, rounded half to even to the
+// nearest minor unit; it is the single place where amounts are rounded
+func (m Money) MulRat(num, den int64) Money {
+	if den == 0 {
+		panic("entities: Money.MulRat with zero denominator")
+	}
+
+	product := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(num))
+	divisor := big.NewInt(den)
+	if divisor.Sign() < 0 {
+		product.Neg(product)
+		divisor.Neg(divisor)
+	}
+
+	quotient, remainder := new(big.Int).QuoRem(product, divisor, new(big.Int))
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	if c := twice.Cmp(divisor); c > 0 || (c == 0 && quotient.Bit(0) == 1) {
+		if product.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return Money{minor: quotient.Int64(), currency: m.currency}
+}
+
+// Div returns the amount divided by n, rounded half to even
+func (m Money) Div(n int) Money {
+	return m.MulRat(1, int64(n))
+}
+
+// Amount renders the amount as a plain decimal with the currency's number of places, e.g. "29.99"
+func (m Money) Amount() string {
+	return formatDecimal(m.minor, CurrencyPrecision(m.Currency()))
+}
+
+// String renders the amount with its currency, e.g. "29.99 USD"
+func (m Money) String() string {
+	return m.Amount() + " " + m.Currency()
+}
+
+// absInt64 returns the magnitude of n, valid for math.MinInt64 too
+func absInt64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// moneyJSON is the wire format of Money; the amount is a string so clients never parse it as a float
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON renders the amount as {"amount": "29.99", "currency": "USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount(), Currency: m.Currency()})
+}
+
+// UnmarshalJSON accepts {"amount": "29.99", "currency": "USD"}, or a bare
+// number or string in the default currency, so existing clients keep working
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var amount, currency string
+	switch data[0] {
+	case '{':
+		var wire struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &wire); err != nil {
+			return err
+		}
+		if len(wire.Amount) == 0 {
+			return fmt.Errorf("%w: amount is required", ErrInvalidAmount)
+		}
+		if err := json.Unmarshal(wire.Amount, &amount); err != nil {
+			amount = string(wire.Amount)
+		}
+		currency = wire.Currency
+	case '"':
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return err
+		}
+	default:
+		amount = string(data)
+	}
+
+	parsed, err := ParseMoney(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// SumMoney adds up amounts that must all be in the same currency
+func SumMoney(amounts ...Money) (Money, error) {
+	var total Money
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
@@ -1,27 +1,72 @@
 package entities
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 )
 
 // Order represents the core order entity
+// Lines is the source of truth for what was ordered. ProductID, Quantity and
+// UnitPrice summarise it for older clients: ProductID and UnitPrice are only
+// set on single-line orders, Quantity is the total number of units
 type Order struct {
-	ID          string    `json:"id"`
-	CustomerID  string    `json:"customer_id"`
-	ProductID   string    `json:"product_id"`
-	Quantity    int       `json:"quantity"`
-	UnitPrice   float64   `json:"unit_price"`
-	TotalAmount float64   `json:"total_amount"`
-	OrderDate   time.Time `json:"order_date"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string      `json:"id"`
+	CustomerID  string      `json:"customer_id"`
+	ProductID   string      `json:"product_id"`
+	Quantity    int         `json:"quantity"`
+	UnitPrice   Money       `json:"unit_price"`
+	TotalAmount Money       `json:"total_amount"`
+	Status      OrderStatus `json:"status"`
+	OrderDate   time.Time   `json:"order_date"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// Discount is what promotions took off the order, the sum of its lines'
+	// discounts, and CouponCode the coupon the customer redeemed, if any
+	Discount   Money  `json:"discount"`
+	CouponCode string `json:"coupon_code,omitempty"`
+
+	// Tax is the tax charged on the order, the sum of its lines' tax, under
+	// the rates of TaxJurisdiction; TotalAmount includes it
+	Tax             Money  `json:"tax"`
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"`
+
+	// StoreCreditApplied is the part of TotalAmount paid from the customer's wallet
+	StoreCreditApplied Money `json:"store_credit_applied"`
+
+	// ChargeCurrency is the currency the customer paid the amount due in, at
+	// ExchangeRate units per unit of the order currency; ChargedAmount is the result
+	ChargeCurrency string `json:"charge_currency"`
+	ExchangeRate   Rate   `json:"exchange_rate"`
+	ChargedAmount  Money  `json:"charged_amount"`
+
+	// LocationID is the store or warehouse the order ships from; orders
+	// placed before stock was tracked per location have none
+	LocationID string `json:"location_id,omitempty"`
+
+	// Lines lists the ordered products, in the order they were added
+	Lines []*OrderLine `json:"lines,omitempty"`
+
+	// StatusHistory records every status transition, oldest first
+	StatusHistory []*OrderStatusChange `json:"status_history,omitempty"`
 
 	// Navigation properties (not persisted, used for responses)
 	Customer *Customer `json:"customer,omitempty"`
 	Product  *Product  `json:"product,omitempty"`
 }
 
+// OrderStatusChange records a single status transition of an order
+type OrderStatusChange struct {
+	OrderID    string      `json:"order_id"`
+	FromStatus OrderStatus `json:"from_status,omitempty"`
+	ToStatus   OrderStatus `json:"to_status"`
+	Actor      string      `json:"actor"`
+	Reason     string      `json:"reason,omitempty"`
+	ChangedAt  time.Time   `json:"changed_at"`
+}
+
 // OrderStatus represents the status of an order
 type OrderStatus string
 
@@ -32,6 +77,39 @@ const (
 	OrderStatusCompleted OrderStatus = "completed"
 )
 
+// ErrInvalidStatusTransition is returned when an order cannot move to the requested status
+var ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
+// ErrCancellationWindowClosed is returned when an order is too old to be cancelled
+var ErrCancellationWindowClosed = errors.New("order cancellation window has closed")
+
+// DefaultCancellationWindow is how long after placement an order may be cancelled
+const DefaultCancellationWindow = 30 * time.Minute
+
+// orderTransitions lists the statuses each status may move to
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusCompleted, OrderStatusCancelled},
+	OrderStatusCancelled: {},
+	OrderStatusCompleted: {},
+}
+
+// IsValid checks if the status is one of the known order statuses
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo checks if the state machine allows moving from s to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], next)
+}
+
+// IsFinal returns true if no further transitions are possible
+func (s OrderStatus) IsFinal() bool {
+	return s.IsValid() && len(orderTransitions[s]) == 0
+}
+
 // Business logic methods
 
 // Validate performs business rule validation for orders
@@ -40,31 +118,222 @@ func (o *Order) Validate() error {
 		return fmt.Errorf("customer ID is required")
 	}
 
-	if o.ProductID == "" {
-		return fmt.Errorf("product ID is required")
+	if o.Status != "" && !o.Status.IsValid() {
+		return fmt.Errorf("invalid order status: %s", o.Status)
 	}
 
-	if o.Quantity <= 0 {
-		return fmt.Errorf("quantity must be greater than zero: %d", o.Quantity)
+	lines := o.OrderLines()
+	if len(lines) == 0 {
+		return fmt.Errorf("order must have at least one line")
 	}
 
-	if o.UnitPrice <= 0 {
-		return fmt.Errorf("unit price must be greater than zero: %f", o.UnitPrice)
+	seen := make(map[string]bool, len(lines))
+	var expectedTotal, expectedDiscount, expectedTax Money
+	for _, line := range lines {
+		if err := line.Validate(); err != nil {
+			return err
+		}
+		if seen[line.ProductID] {
+			return fmt.Errorf("product %s appears on more than one line", line.ProductID)
+		}
+		seen[line.ProductID] = true
+
+		var err error
+		if expectedTotal, err = expectedTotal.Add(line.LineTotal); err != nil {
+			return fmt.Errorf("order lines must share one currency: %w", err)
+		}
+		if expectedDiscount, err = expectedDiscount.Add(line.Discount); err != nil {
+			return fmt.Errorf("order lines must share one currency: %w", err)
+		}
+		if expectedTax, err = expectedTax.Add(line.Tax); err != nil {
+			return fmt.Errorf("order lines must share one currency: %w", err)
+		}
 	}
 
-	// Validate total amount calculation
-	expectedTotal := float64(o.Quantity) * o.UnitPrice
-	if abs(o.TotalAmount-expectedTotal) > 0.01 { // Allow for floating point precision
-		return fmt.Errorf("total amount mismatch: expected %f, got %f",
+	// Amounts are exact, so the total must match to the minor unit
+	if !o.TotalAmount.Equal(expectedTotal) {
+		return fmt.Errorf("total amount mismatch: expected %s, got %s",
 			expectedTotal, o.TotalAmount)
 	}
+	if o.Discount.Minor() != expectedDiscount.Minor() {
+		return fmt.Errorf("discount mismatch: expected %s, got %s", expectedDiscount, o.Discount)
+	}
+	if o.Tax.Minor() != expectedTax.Minor() {
+		return fmt.Errorf("tax mismatch: expected %s, got %s", expectedTax, o.Tax)
+	}
+
+	if o.ChargeCurrency != "" && !o.ExchangeRate.IsPositive() {
+		return fmt.Errorf("%w: order charged in %s has no rate", ErrInvalidExchangeRate, o.ChargeCurrency)
+	}
+
+	return nil
+}
+
+// AddLine adds quantity of a product to the order at the product's current price
+// Adding a product that is already on the order increases that line instead
+func (o *Order) AddLine(product *Product, quantity int) (*OrderLine, error) {
+	if line := o.FindLine(product.ID); line != nil {
+		line.Quantity += quantity
+		if err := line.CalculateTotal(); err != nil {
+			return nil, err
+		}
+		return line, nil
+	}
+
+	line := &OrderLine{
+		OrderID:     o.ID,
+		LineNumber:  len(o.Lines) + 1,
+		ProductID:   product.ID,
+		ProductName: product.ProductName,
+		Quantity:    quantity,
+		UnitPrice:   product.Price,
+		ListPrice:   product.Price,
+	}
+	if err := line.CalculateTotal(); err != nil {
+		return nil, err
+	}
+	o.Lines = append(o.Lines, line)
+	return line, nil
+}
+
+// FindLine returns the line for a product, or nil if the product is not on the order
+func (o *Order) FindLine(productID string) *OrderLine {
+	for _, line := range o.OrderLines() {
+		if line.ProductID == productID {
+			return line
+		}
+	}
+	return nil
+}
+
+// OrderLines returns the order's lines
+// Orders stored before lines existed get a single line built from ProductID,
+// Quantity and UnitPrice
+func (o *Order) OrderLines() []*OrderLine {
+	if len(o.Lines) > 0 || o.ProductID == "" {
+		return o.Lines
+	}
+
+	line := &OrderLine{
+		OrderID:    o.ID,
+		LineNumber: 1,
+		ProductID:  o.ProductID,
+		Quantity:   o.Quantity,
+		UnitPrice:  o.UnitPrice,
+	}
+	if o.Product != nil {
+		line.ProductName = o.Product.ProductName
+	}
+	// The order's total was checked when it was placed, so this cannot overflow
+	_ = line.CalculateTotal()
+	o.Lines = []*OrderLine{line}
+	return o.Lines
+}
+
+// CalculateTotal calculates and sets the total amount across all lines
+// and refreshes the single-product summary fields
+// All lines must be priced in the same currency
+func (o *Order) CalculateTotal() error {
+	lines := o.OrderLines()
 
+	var total, discount, tax Money
+	o.Quantity = 0
+	for _, line := range lines {
+		line.OrderID = o.ID
+		var err error
+		if total, err = total.Add(line.LineTotal); err != nil {
+			return fmt.Errorf("order lines must share one currency: %w", err)
+		}
+		if discount, err = discount.Add(line.Discount); err != nil {
+			return fmt.Errorf("order lines must share one currency: %w", err)
+		}
+		if tax, err = tax.Add(line.Tax); err != nil {
+			return fmt.Errorf("order lines must share one currency: %w", err)
+		}
+		o.Quantity += line.Quantity
+	}
+	o.TotalAmount = total
+	o.Discount = NewMoney(discount.Minor(), total.Currency())
+	o.Tax = NewMoney(tax.Minor(), total.Currency())
+
+	if len(lines) == 1 {
+		o.ProductID = lines[0].ProductID
+		o.UnitPrice = lines[0].UnitPrice
+	} else {
+		o.ProductID = ""
+		o.UnitPrice = NewMoney(0, total.Currency())
+	}
 	return nil
 }
 
-// CalculateTotal calculates and sets the total amount
-func (o *Order) CalculateTotal() {
-	o.TotalAmount = float64(o.Quantity) * o.UnitPrice
+// ApplyStoreCredit pays up to amount of the order from store credit
+// The amount is capped at the order total and the applied amount is returned
+func (o *Order) ApplyStoreCredit(amount Money) (Money, error) {
+	if !amount.IsPositive() {
+		o.StoreCreditApplied = NewMoney(0, o.TotalAmount.Currency())
+		return o.StoreCreditApplied, nil
+	}
+
+	c, err := amount.Cmp(o.TotalAmount)
+	if err != nil {
+		return Money{}, fmt.Errorf("store credit must be in the order currency: %w", err)
+	}
+	if c > 0 {
+		amount = o.TotalAmount
+	}
+	o.StoreCreditApplied = amount
+	return o.StoreCreditApplied, nil
+}
+
+// AmountDue returns what the customer still has to pay after store credit
+// ApplyStoreCredit keeps the applied credit in the order currency
+func (o *Order) AmountDue() Money {
+	return NewMoney(o.TotalAmount.Minor()-o.StoreCreditApplied.Minor(), o.TotalAmount.Currency())
+}
+
+// ChargeIn records that the amount due is paid in currency, converted at rate
+// from the order currency; call it after store credit has been applied
+func (o *Order) ChargeIn(currency string, rate Rate) {
+	o.ChargeCurrency = normalizeCurrency(currency)
+	o.ExchangeRate = rate
+	o.ChargedAmount = o.AmountDue().Exchange(o.ChargeCurrency, rate)
+}
+
+// MarkPlaced puts a new order into the pending status
+func (o *Order) MarkPlaced(actor string) *OrderStatusChange {
+	change := &OrderStatusChange{
+		OrderID:   o.ID,
+		ToStatus:  OrderStatusPending,
+		Actor:     actor,
+		Reason:    "order placed",
+		ChangedAt: time.Now().UTC(),
+	}
+	o.Status = OrderStatusPending
+	o.StatusHistory = append(o.StatusHistory, change)
+	return change
+}
+
+// TransitionTo moves the order to the next status, enforcing the state machine
+// It returns the recorded change so it can be persisted
+func (o *Order) TransitionTo(next OrderStatus, actor, reason string) (*OrderStatusChange, error) {
+	if !o.Status.CanTransitionTo(next) {
+		return nil, fmt.Errorf("%w: cannot move order %s from %s to %s",
+			ErrInvalidStatusTransition, o.ID, o.Status, next)
+	}
+
+	change := &OrderStatusChange{
+		OrderID:    o.ID,
+		FromStatus: o.Status,
+		ToStatus:   next,
+		Actor:      actor,
+		Reason:     reason,
+		ChangedAt:  time.Now().UTC(),
+	}
+
+	o.Status = next
+	o.UpdatedAt = change.ChangedAt
+	o.StatusHistory = append(o.StatusHistory, change)
+	return change, nil
 }
 
 // SetOrderDate sets the order date to current time
@@ -72,30 +341,37 @@ func (o *Order) SetOrderDate() {
 	o.OrderDate = time.Now().UTC()
 }
 
-// CanBeCancelled checks if the order can be cancelled
-func (o *Order) CanBeCancelled() bool {
-	// Business rule: orders can be cancelled within 30 minutes
-	return time.Since(o.OrderDate) <= 30*time.Minute
+// CanBeCancelledWithin checks if the order can be cancelled under a given window
+// Orders that have already reached a final status can never be cancelled
+func (o *Order) CanBeCancelledWithin(window time.Duration) bool {
+	if o.Status != "" && !o.Status.CanTransitionTo(OrderStatusCancelled) {
+		return false
+	}
+	return time.Since(o.OrderDate) <= window
 }
 
-// GetOrderSummary returns a summary of the order
-func (o *Order) GetOrderSummary() map[string]any {
+// Cancel moves the order to cancelled if it is still inside the cancellation window
+func (o *Order) Cancel(window time.Duration, actor, reason string) (*OrderStatusChange, error) {
+	if time.Since(o.OrderDate) > window {
+		return nil, fmt.Errorf("%w: order %s was placed more than %s ago",
+			ErrCancellationWindowClosed, o.ID, window)
+	}
+	return o.TransitionTo(OrderStatusCancelled, actor, reason)
+}
+
+// GetOrderSummary returns a summary of the order, judging can_cancel against
+// the given cancellation window
+func (o *Order) GetOrderSummary(cancellationWindow time.Duration) map[string]any {
 	return map[string]any{
 		"id":           o.ID,
 		"customer_id":  o.CustomerID,
 		"product_id":   o.ProductID,
 		"quantity":     o.Quantity,
 		"unit_price":   o.UnitPrice,
+		"line_count":   len(o.OrderLines()),
 		"total_amount": o.TotalAmount,
+		"status":       o.Status,
 		"order_date":   o.OrderDate,
-		"can_cancel":   o.CanBeCancelled(),
-	}
-}
-
-// Helper function for floating point comparison
-func abs(x float64) float64 {
-	if x < 0 {
-		return -x
+		"can_cancel":   o.CanBeCancelledWithin(cancellationWindow),
 	}
-	return x
 }
@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOrderLineNotFound is returned when an order has no line for the requested product
+var ErrOrderLineNotFound = errors.New("order line not found")
+
+// OrderLine is one product on an order, priced when the order was placed
+type OrderLine struct {
+	OrderID     string `json:"order_id"`
+	LineNumber  int    `json:"line_number"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   Money  `json:"unit_price"`
+	LineTotal   Money  `json:"line_total"` // UnitPrice × Quantity less Discount, plus Tax unless TaxInclusive
+
+	// Discount is what promotions took off the line
+	Discount Money `json:"discount"`
+
+	// Tax is charged at TaxRate on TaxableAmount, what the line costs after
+	// discounts and without tax, and broken down into Taxes. With TaxInclusive
+	// the unit price already included it; otherwise it was added on top.
+	// Lines of products without a tax class are not taxed
+	TaxClass      string     `json:"tax_class,omitempty"`
+	TaxRate       Percent    `json:"tax_rate"`
+	TaxInclusive  bool       `json:"tax_inclusive"`
+	TaxableAmount Money      `json:"taxable_amount"`
+	Tax           Money      `json:"tax"`
+	Taxes         []*LineTax `json:"taxes,omitempty"`
+
+	// ListPrice is the product's price when the order was placed and
+	// PriceRuleID the price rule that set UnitPrice instead, if any
+	ListPrice   Money  `json:"list_price"`
+	PriceRuleID string `json:"price_rule_id,omitempty"`
+}
+
+// Validate performs business rule validation for order lines
+func (l *OrderLine) Validate() error {
+	if l.ProductID == "" {
+		return fmt.Errorf("product ID is required")
+	}
+
+	if l.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero: %d", l.Quantity)
+	}
+
+	if !l.UnitPrice.IsPositive() {
+		return fmt.Errorf("unit price must be greater than zero: %s", l.UnitPrice)
+	}
+
+	if l.Discount.IsNegative() {
+		return fmt.Errorf("discount cannot be negative: %s", l.Discount)
+	}
+
+	if l.Tax.IsNegative() {
+		return fmt.Errorf("tax cannot be negative: %s", l.Tax)
+	}
+	var taxes Money
+	for _, tax := range l.Taxes {
+		var err error
+		if taxes, err = taxes.Add(tax.Amount); err != nil {
+			return fmt.Errorf("line tax for product %s: %w", l.ProductID, err)
+		}
+	}
+	if taxes.Minor() != l.Tax.Minor() {
+		return fmt.Errorf("tax mismatch for product %s: components add up to %s, not %s", l.ProductID, taxes, l.Tax)
+	}
+
+	expectedTotal, err := l.beforeTax()
+	if err != nil {
+		return err
+	}
+	if !l.TaxInclusive {
+		if expectedTotal, err = expectedTotal.Add(l.Tax); err != nil {
+			return fmt.Errorf("line tax for product %s: %w", l.ProductID, err)
+		}
+	}
+	if !l.LineTotal.Equal(expectedTotal) {
+		return fmt.Errorf("line total mismatch for product %s: expected %s, got %s",
+			l.ProductID, expectedTotal, l.LineTotal)
+	}
+	if !l.LineTotal.IsPositive() {
+		return fmt.Errorf("discount on product %s leaves nothing to pay", l.ProductID)
+	}
+
+	return nil
+}
+
+// ApplyQuote prices the line at a quoted unit price
+func (l *OrderLine) ApplyQuote(quote *PriceQuote) error {
+	l.ListPrice = quote.ListPrice
+	l.UnitPrice = quote.UnitPrice
+	l.PriceRuleID = quote.PriceRuleID
+	return l.CalculateTotal()
+}
+
+// ApplyDiscount takes up to amount off the line and returns what was taken
+// The line always keeps at least one minor unit to pay; discounts are
+// applied before tax
+func (l *OrderLine) ApplyDiscount(amount Money) (Money, error) {
+	if amount.IsNegative() {
+		return Money{}, fmt.Errorf("%w: discount cannot be negative: %s", ErrInvalidAmount, amount)
+	}
+	if _, err := amount.Cmp(l.LineTotal); err != nil {
+		return Money{}, err
+	}
+	if most := NewMoney(l.LineTotal.Minor()-1, l.LineTotal.Currency()); amount.Minor() > most.Minor() {
+		amount = most
+	}
+
+	discount, err := l.Discount.Add(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	l.Discount = discount
+	if err := l.CalculateTotal(); err != nil {
+		return Money{}, err
+	}
+	return amount, nil
+}
+
+// ApplyTax charges tax on the line at rate, on what it costs after discounts
+// With inclusive prices the tax is taken out of that amount; otherwise it is
+// added to it. A nil rate leaves the line untaxed
+func (l *OrderLine) ApplyTax(rate *TaxRate, inclusive bool) error {
+	l.TaxClass, l.TaxRate, l.TaxInclusive, l.Taxes = "", Percent{}, false, nil
+	l.Tax = NewMoney(0, l.UnitPrice.Currency())
+	if rate != nil {
+		before, err := l.beforeTax()
+		if err != nil {
+			return err
+		}
+		l.TaxClass, l.TaxRate, l.TaxInclusive = rate.TaxClass, rate.Rate, inclusive
+		l.Tax, l.Taxes = rate.Charge(before, inclusive)
+	}
+	return l.CalculateTotal()
+}
+
+// CalculateTotal calculates and sets the line total and taxable amount
+func (l *OrderLine) CalculateTotal() error {
+	total, err := l.beforeTax()
+	if err != nil {
+		return err
+	}
+	if !l.TaxInclusive {
+		if total, err = total.Add(l.Tax); err != nil {
+			return fmt.Errorf("line tax for product %s: %w", l.ProductID, err)
+		}
+	}
+	l.LineTotal = total
+	l.TaxableAmount = NewMoney(l.LineTotal.Minor()-l.Tax.Minor(), l.LineTotal.Currency())
+	return nil
+}
+
+// beforeTax returns what the line costs after discounts, as priced
+func (l *OrderLine) beforeTax() (Money, error) {
+	subtotal, err := l.UnitPrice.Mul(l.Quantity)
+	if err != nil {
+		return Money{}, fmt.Errorf("line total for product %s: %w", l.ProductID, err)
+	}
+	total, err := subtotal.Sub(l.Discount)
+	if err != nil {
+		return Money{}, fmt.Errorf("line discount for product %s: %w", l.ProductID, err)
+	}
+	return total, nil
+}
@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderReturn represents a return (RMA) for part or all of an order's quantity
+type OrderReturn struct {
+	ID           string            `json:"id"`
+	OrderID      string            `json:"order_id"`
+	CustomerID   string            `json:"customer_id"`
+	ProductID    string            `json:"product_id"`
+	Quantity     int               `json:"quantity"`
+	UnitPrice    Money             `json:"unit_price"`
+	RefundAmount Money             `json:"refund_amount"`
+	Reason       string            `json:"reason"`
+	Status       ReturnStatus      `json:"status"`
+	Disposition  ReturnDisposition `json:"disposition,omitempty"`
+
+	// Resolution details, set once the return is approved or rejected
+	RefundTransactionID string     `json:"refund_transaction_id,omitempty"`
+	ResolvedBy          string     `json:"resolved_by,omitempty"`
+	ResolutionNote      string     `json:"resolution_note,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+
+	RequestedAt time.Time `json:"requested_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ReturnStatus represents the status of a return
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+)
+
+// ReturnDisposition describes what happens to returned goods
+type ReturnDisposition string
+
+const (
+	// ReturnDispositionRestock puts the returned units back into sellable stock
+	ReturnDispositionRestock ReturnDisposition = "restock"
+	// ReturnDispositionWriteOff discards the returned units (damaged, opened, etc.)
+	ReturnDispositionWriteOff ReturnDisposition = "write_off"
+)
+
+var (
+	// ErrOrderNotReturnable is returned when an order is in a status that cannot be returned
+	ErrOrderNotReturnable = errors.New("order cannot be returned")
+	// ErrReturnQuantityExceeded is returned when a return asks for more than is left on the order
+	ErrReturnQuantityExceeded = errors.New("return quantity exceeds the quantity left on the order")
+	// ErrReturnAlreadyResolved is returned when approving or rejecting a return that is no longer requested
+	ErrReturnAlreadyResolved = errors.New("return has already been resolved")
+	// ErrOrderHasReturns is returned when cancelling an order that has requested or approved returns
+	ErrOrderHasReturns = errors.New("order has returns")
+)
+
+// IsValid checks if the status is one of the known return statuses
+func (s ReturnStatus) IsValid() bool {
+	return s == ReturnStatusRequested || s == ReturnStatusApproved || s == ReturnStatusRejected
+}
+
+// IsValid checks if the disposition is one of the known dispositions
+func (d ReturnDisposition) IsValid() bool {
+	return d == ReturnDispositionRestock || d == ReturnDispositionWriteOff
+}
+
+// Business logic methods
+
+// CreateFromOrder opens a return request against one line of an order
+// Only confirmed and completed orders can be returned; pending orders are
+// cancelled instead
+// returnedSoFar is the quantity of that line already covered by other open or approved returns
+func (r *OrderReturn) CreateFromOrder(order *Order, line *OrderLine, quantity, returnedSoFar int, reason string) error {
+	if order.Status != OrderStatusConfirmed && order.Status != OrderStatusCompleted {
+		return fmt.Errorf("%w: order %s is %s", ErrOrderNotReturnable, order.ID, order.Status)
+	}
+
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero: %d", quantity)
+	}
+
+	if remaining := line.Quantity - returnedSoFar; quantity > remaining {
+		return fmt.Errorf("%w: requested %d, %d of product %s left on order %s",
+			ErrReturnQuantityExceeded, quantity, remaining, line.ProductID, order.ID)
+	}
+
+	now := time.Now().UTC()
+	r.OrderID = order.ID
+	r.CustomerID = order.CustomerID
+	r.ProductID = line.ProductID
+	r.Quantity = quantity
+	r.UnitPrice = line.UnitPrice
+	r.RefundAmount = line.LineTotal.MulRat(int64(quantity), int64(line.Quantity)) // net of any discount
+	r.Reason = strings.TrimSpace(reason)
+	r.Status = ReturnStatusRequested
+	r.RequestedAt = now
+
+	return r.Validate()
+}
+
+// Validate performs business rule validation for returns
+func (r *OrderReturn) Validate() error {
+	if r.OrderID == "" {
+		return fmt.Errorf("order ID is required")
+	}
+
+	if r.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero: %d", r.Quantity)
+	}
+
+	if !r.RefundAmount.IsPositive() {
+		return fmt.Errorf("refund amount must be greater than zero: %s", r.RefundAmount)
+	}
+
+	if !r.Status.IsValid() {
+		return fmt.Errorf("invalid return status: %s", r.Status)
+	}
+
+	if r.Disposition != "" && !r.Disposition.IsValid() {
+		return fmt.Errorf("invalid return disposition: %s", r.Disposition)
+	}
+
+	return nil
+}
+
+// Approve accepts the return and records what happens to the goods
+func (r *OrderReturn) Approve(disposition ReturnDisposition, actor, note string) error {
+	if !disposition.IsValid() {
+		return fmt.Errorf("invalid return disposition: %s", disposition)
+	}
+
+	if err := r.resolve(ReturnStatusApproved, actor, note); err != nil {
+		return err
+	}
+
+	r.Disposition = disposition
+	return nil
+}
+
+// Reject declines the return; nothing is restocked or refunded
+func (r *OrderReturn) Reject(actor, note string) error {
+	return r.resolve(ReturnStatusRejected, actor, note)
+}
+
+// resolve moves a requested return to its final status
+func (r *OrderReturn) resolve(status ReturnStatus, actor, note string) error {
+	if r.Status != ReturnStatusRequested {
+		return fmt.Errorf("%w: return %s is %s", ErrReturnAlreadyResolved, r.ID, r.Status)
+	}
+
+	now := time.Now().UTC()
+	r.Status = status
+	r.ResolvedBy = actor
+	r.ResolutionNote = strings.TrimSpace(note)
+	r.ResolvedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+// IsRestocked returns true if approving the return put the goods back on the shelf
+func (r *OrderReturn) IsRestocked() bool {
+	return r.Status == ReturnStatusApproved && r.Disposition == ReturnDispositionRestock
+}
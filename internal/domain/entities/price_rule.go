@@ -0,0 +1,246 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PriceList is the set of prices a customer buys at
+type PriceList string
+
+const (
+	PriceListRetail    PriceList = "retail"
+	PriceListWholesale PriceList = "wholesale"
+)
+
+// IsValid checks if the price list is one of the known price lists
+func (l PriceList) IsValid() bool {
+	return l == PriceListRetail || l == PriceListWholesale
+}
+
+// PercentDecimals is the number of decimal places a percentage is held to
+const PercentDecimals = 2
+
+// percentScale is 100% in hundredths of a percent
+const percentScale int64 = 10_000
+
+// ErrInvalidPriceRule is returned when a price rule breaks a business rule
+var ErrInvalidPriceRule = errors.New("invalid price rule")
+
+// Percent is an exact percentage held to PercentDecimals decimal places
+type Percent struct {
+	hundredths int64
+}
+
+// ParsePercent parses a decimal percentage such as "8" or "12.5"
+func ParsePercent(value string) (Percent, error) {
+	hundredths, err := parseDecimal(value, PercentDecimals)
+	if err != nil {
+		return Percent{}, fmt.Errorf("%w: %s", ErrInvalidAmount, err)
+	}
+	return Percent{hundredths: hundredths}, nil
+}
+
+// NewPercentFromHundredths creates a percentage from hundredths of a percent
+func NewPercentFromHundredths(hundredths int64) Percent {
+	return Percent{hundredths: hundredths}
+}
+
+// Hundredths returns the percentage in hundredths of a percent
+func (p Percent) Hundredths() int64 {
+	return p.hundredths
+}
+
+// IsZero returns true if the percentage is zero
+func (p Percent) IsZero() bool {
+	return p.hundredths == 0
+}
+
+// Off returns the amount less this percentage of it, rounded half to even
+func (p Percent) Off(amount Money) Money {
+	return amount.MulRat(percentScale-p.hundredths, percentScale)
+}
+
+// String renders the percentage without trailing zeros, e.g. "12.5"
+func (p Percent) String() string {
+	s := formatDecimal(p.hundredths, PercentDecimals)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// MarshalJSON renders the percentage as a string so clients never parse it as a float
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON accepts the percentage as a string or a number
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	value := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParsePercent(value)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// PriceRule changes what customers pay for a product, or for every product
+// It either sets a fixed unit price or takes a percentage off the list price,
+// for one price list or all of them, from a minimum quantity and between
+// optional start and end times
+type PriceRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	// PriceList limits the rule to customers on that price list; empty applies to everyone
+	PriceList PriceList `json:"price_list,omitempty"`
+
+	// ProductID limits the rule to one product, or to every variant of a
+	// parent; empty applies to the whole catalogue
+	ProductID string `json:"product_id,omitempty"`
+
+	// MinQuantity is the fewest units on an order line the rule applies to
+	MinQuantity int `json:"min_quantity"`
+
+	// Exactly one of Price and DiscountPercent is set
+	Price           Money   `json:"price"`
+	DiscountPercent Percent `json:"discount_percent"`
+
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Validate performs business rule validation for price rules
+func (r *PriceRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPriceRule)
+	}
+	if r.PriceList != "" && !r.PriceList.IsValid() {
+		return fmt.Errorf("%w: unknown price list %q", ErrInvalidPriceRule, r.PriceList)
+	}
+	if r.MinQuantity < 0 {
+		return fmt.Errorf("%w: minimum quantity cannot be negative: %d", ErrInvalidPriceRule, r.MinQuantity)
+	}
+
+	switch {
+	case r.Price.IsZero() == r.DiscountPercent.IsZero():
+		return fmt.Errorf("%w: set either a price or a discount percentage", ErrInvalidPriceRule)
+	case r.Price.IsNegative():
+		return fmt.Errorf("%w: price must be greater than zero: %s", ErrInvalidPriceRule, r.Price)
+	case r.Price.IsPositive() && r.ProductID == "":
+		return fmt.Errorf("%w: a fixed price needs a product", ErrInvalidPriceRule)
+	case r.DiscountPercent.hundredths < 0 || r.DiscountPercent.hundredths >= percentScale:
+		return fmt.Errorf("%w: discount must be between 0 and 100 percent: %s", ErrInvalidPriceRule, r.DiscountPercent)
+	}
+
+	if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+		return fmt.Errorf("%w: a rule must end after it starts", ErrInvalidPriceRule)
+	}
+	return nil
+}
+
+// IsActive checks if the rule is in effect at the given time
+func (r *PriceRule) IsActive(at time.Time) bool {
+	if r.StartsAt != nil && at.Before(*r.StartsAt) {
+		return false
+	}
+	return r.EndsAt == nil || at.Before(*r.EndsAt)
+}
+
+// AppliesTo checks if the rule prices quantity units of product for a customer on priceList at the given time
+func (r *PriceRule) AppliesTo(product *Product, priceList PriceList, quantity int, at time.Time) bool {
+	if r.ProductID != "" && r.ProductID != product.ID && r.ProductID != product.ParentID {
+		return false
+	}
+	if r.PriceList != "" && r.PriceList != priceList {
+		return false
+	}
+	return quantity >= r.MinQuantity && r.IsActive(at)
+}
+
+// UnitPrice returns the unit price the rule sets for a product listed at listPrice
+func (r *PriceRule) UnitPrice(listPrice Money) Money {
+	if r.Price.IsPositive() {
+		return r.Price
+	}
+	return r.DiscountPercent.Off(listPrice)
+}
+
+// End stops the rule applying from the given time
+// A rule that has not started yet ends as it starts, so it never applies
+func (r *PriceRule) End(at time.Time) error {
+	if r.EndsAt != nil && !at.Before(*r.EndsAt) {
+		return fmt.Errorf("%w: rule %s already ended at %s", ErrInvalidPriceRule, r.ID, r.EndsAt.Format(time.RFC3339))
+	}
+	if r.StartsAt != nil && at.Before(*r.StartsAt) {
+		at = *r.StartsAt
+	}
+	r.EndsAt = &at
+	return nil
+}
+
+// PriceQuote is what a customer pays for a quantity of a product, and the rule that set the price
+type PriceQuote struct {
+	ProductID  string    `json:"product_id"`
+	CustomerID string    `json:"customer_id,omitempty"`
+	PriceList  PriceList `json:"price_list"`
+	Quantity   int       `json:"quantity"`
+	ListPrice  Money     `json:"list_price"`
+	UnitPrice  Money     `json:"unit_price"`
+	Total      Money     `json:"total"`
+	QuotedAt   time.Time `json:"quoted_at"`
+
+	// PriceRuleID and PriceRuleName are empty when the list price applies
+	PriceRuleID   string `json:"price_rule_id,omitempty"`
+	PriceRuleName string `json:"price_rule_name,omitempty"`
+}
+
+// NewPriceQuote prices quantity units of product for a customer on priceList
+// at the given time: the lowest of the list price and the price of every rule
+// that applies, the earliest rule winning a tie
+func NewPriceQuote(product *Product, priceList PriceList, quantity int, rules []*PriceRule, at time.Time) (*PriceQuote, error) {
+	quote := &PriceQuote{
+		ProductID: product.ID,
+		PriceList: priceList,
+		Quantity:  quantity,
+		ListPrice: product.Price,
+		UnitPrice: product.Price,
+		QuotedAt:  at,
+	}
+
+	for _, rule := range rules {
+		if !rule.AppliesTo(product, priceList, quantity, at) {
+			continue
+		}
+		price := rule.UnitPrice(product.Price)
+		cmp, err := price.Cmp(quote.UnitPrice)
+		if err != nil {
+			return nil, fmt.Errorf("price rule %s: %w", rule.ID, err)
+		}
+		if cmp < 0 && price.IsPositive() {
+			quote.UnitPrice, quote.PriceRuleID, quote.PriceRuleName = price, rule.ID, rule.Name
+		}
+	}
+
+	total, err := quote.UnitPrice.Mul(quantity)
+	if err != nil {
+		return nil, err
+	}
+	quote.Total = total
+	return quote, nil
+}
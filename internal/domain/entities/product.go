@@ -1,33 +1,370 @@
 package entities
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
 // Product represents the core product entity
 // Domain entities contain business logic but no external dependencies
 type Product struct {
-	ID          string    `json:"id"`
-	ProductName string    `json:"product_name"`
-	Price       float64   `json:"price"`
-	Quantity    int       `json:"quantity"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string `json:"id"`
+	ProductName string `json:"product_name"`
+	Price       Money  `json:"price"`
+	Quantity    int    `json:"quantity"`
+	Reserved    int    `json:"reserved"` // units held by active stock reservations
+
+	// Catalogue: SKU and Barcode are unique when set; Attributes are free-form,
+	// such as size and colour, keyed by lower-case name
+	SKU         string            `json:"sku,omitempty"`
+	Barcode     string            `json:"barcode,omitempty"`
+	Description string            `json:"description,omitempty"`
+	CategoryID  string            `json:"category_id,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Status      ProductStatus     `json:"status"`
+
+	// TaxClass picks the tax rates charged on the product, such as "gst_18";
+	// a product without one is not taxed
+	TaxClass string `json:"tax_class,omitempty"`
+
+	// Variants: a parent holds the shared name, description, category and
+	// attributes and is sold only through its variants; each variant has its
+	// own SKU, attributes and stock, takes its category and tax class from ParentID and
+	// follows the parent's price unless PriceOverride is set
+	Type          ProductType `json:"type"`
+	ParentID      string      `json:"parent_id,omitempty"`
+	PriceOverride bool        `json:"price_override,omitempty"`
+	Variants      []*Product  `json:"variants,omitempty"` // loaded for parents, not stored with them
+
+	// Bundles: a bundle is a kit of other products sold at its own price; it
+	// holds no stock, so what can be sold follows from its components' stock
+	Components []*BundleComponent `json:"components,omitempty"`
+
+	// Replenishment: when available stock falls to ReorderPoint, ReorderQuantity
+	// or more is bought from SupplierID, taking LeadTimeDays to arrive
+	// (the supplier's lead time when zero)
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	LeadTimeDays    int    `json:"lead_time_days"`
+	SupplierID      string `json:"supplier_id,omitempty"`
+
+	// AverageCost is the weighted average cost of the units on hand; it moves
+	// when stock comes in and is left alone when stock goes out
+	AverageCost Money `json:"average_cost"`
+
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductStatus says whether a product can still be sold
+type ProductStatus string
+
+const (
+	ProductStatusActive ProductStatus = "active"
+	// ProductStatusArchived products stay on record for past orders but cannot
+	// be ordered, reserved or added to a cart
+	ProductStatusArchived ProductStatus = "archived"
+)
+
+// IsValid checks if the status is one of the known product statuses
+func (s ProductStatus) IsValid() bool {
+	return s == ProductStatusActive || s == ProductStatusArchived
 }
 
+// ProductType says how a product is stocked and sold
+type ProductType string
+
+const (
+	// ProductTypeSimple products are stocked and sold as they are
+	ProductTypeSimple ProductType = "simple"
+	// ProductTypeParent products group variants; they hold no stock of their own
+	ProductTypeParent ProductType = "parent"
+	// ProductTypeVariant products are one option, such as a size and colour, of a parent
+	ProductTypeVariant ProductType = "variant"
+	// ProductTypeBundle products are kits of other products sold at one price
+	ProductTypeBundle ProductType = "bundle"
+)
+
+// IsValid checks if the type is one of the known product types
+func (t ProductType) IsValid() bool {
+	return t == ProductTypeSimple || t == ProductTypeParent || t == ProductTypeVariant || t == ProductTypeBundle
+}
+
+// HoldsStock returns false for parents and bundles, whose stock is their variants' or components'
+func (t ProductType) HoldsStock() bool {
+	return t != ProductTypeParent && t != ProductTypeBundle
+}
+
+// BundleComponent is one product in a bundle and how many units of it each bundle holds
+// ListPrice is the component's price when the bundle was made; the bundle's
+// revenue is shared between its components in proportion to ListPrice × Quantity
+type BundleComponent struct {
+	ProductID string   `json:"product_id"`
+	Quantity  int      `json:"quantity"`
+	ListPrice Money    `json:"list_price"`
+	Product   *Product `json:"-"` // loaded with the bundle
+}
+
+var (
+	// ErrInvalidProduct is returned when catalogue details break a business rule
+	ErrInvalidProduct = errors.New("invalid product")
+	// ErrProductArchived is returned when an archived product is ordered, reserved or added to a cart
+	ErrProductArchived = errors.New("product is archived")
+	// ErrProductHasVariants is returned when a parent product is ordered instead of one of its variants
+	ErrProductHasVariants = errors.New("product is sold by variant")
+)
+
+// Catalogue limits
+const (
+	maxSKULength            = 64
+	maxAttributes           = 50
+	maxAttributeNameLength  = 50
+	maxAttributeValueLength = 255
+	maxDescriptionLength    = 2000
+)
+
 // Business logic methods on the entity
 
-// IsAvailable checks if the product has sufficient quantity
+// CheckSellable returns ErrProductArchived unless the product is active, and
+// ErrProductHasVariants for a parent, whose variants are sold instead
+func (p *Product) CheckSellable() error {
+	if p.Status == ProductStatusArchived {
+		return fmt.Errorf("%w: %s", ErrProductArchived, p.ID)
+	}
+	if p.Type == ProductTypeParent {
+		return fmt.Errorf("%w: %s; order one of its variants", ErrProductHasVariants, p.ID)
+	}
+	return nil
+}
+
+// IsVariant returns true for a variant of a parent product
+func (p *Product) IsVariant() bool {
+	return p.Type == ProductTypeVariant
+}
+
+// Stock returns the units on hand, held by reservations and available; for a
+// parent they are the totals over its loaded variants, and for a bundle the
+// number of whole bundles its loaded components make up
+func (p *Product) Stock() (quantity, reserved, available int) {
+	switch p.Type {
+	case ProductTypeParent:
+		for _, variant := range p.Variants {
+			quantity += variant.Quantity
+			reserved += variant.Reserved
+			available += variant.Available()
+		}
+	case ProductTypeBundle:
+		for i, component := range p.Components {
+			if component.Product == nil {
+				return 0, 0, 0
+			}
+			kits, free := component.Product.Quantity/component.Quantity, component.Product.Available()/component.Quantity
+			if i == 0 || kits < quantity {
+				quantity = kits
+			}
+			if i == 0 || free < available {
+				available = free
+			}
+		}
+		reserved = quantity - available
+	default:
+		return p.Quantity, p.Reserved, p.Available()
+	}
+	return quantity, reserved, available
+}
+
+// AddComponent puts quantity units of a product in the bundle at its current price
+// Parents and bundles cannot be components, and each product is listed once
+func (p *Product) AddComponent(component *Product, quantity int) error {
+	if p.Type != ProductTypeBundle {
+		return fmt.Errorf("%w: only bundles have components", ErrInvalidProduct)
+	}
+	if quantity <= 0 {
+		return fmt.Errorf("%w: component quantity must be greater than zero", ErrInvalidProduct)
+	}
+	if !component.Type.HoldsStock() {
+		return fmt.Errorf("%w: %s is a %s product and cannot be a component", ErrInvalidProduct, component.ID, component.Type)
+	}
+	if component.Price.Currency() != p.Price.Currency() {
+		return fmt.Errorf("%w: component %s is priced in %s, the bundle in %s",
+			ErrInvalidProduct, component.ID, component.Price.Currency(), p.Price.Currency())
+	}
+	for _, existing := range p.Components {
+		if existing.ProductID == component.ID {
+			return fmt.Errorf("%w: component %s is listed more than once", ErrInvalidProduct, component.ID)
+		}
+	}
+
+	p.Components = append(p.Components, &BundleComponent{
+		ProductID: component.ID,
+		Quantity:  quantity,
+		ListPrice: component.Price,
+		Product:   component,
+	})
+	return nil
+}
+
+// AllocateRevenue splits revenue from the bundle between its components in
+// proportion to ListPrice × Quantity, in component order
+// Each share is the rounded running total less what the components before it
+// took, so the shares always add up to the revenue
+func (p *Product) AllocateRevenue(revenue Money) ([]Money, error) {
+	weights := make([]int64, len(p.Components))
+	var total int64
+	for i, component := range p.Components {
+		weight, err := component.ListPrice.Mul(component.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		sum, err := NewMoney(total, "").Add(NewMoney(weight.Minor(), ""))
+		if err != nil {
+			return nil, err
+		}
+		weights[i], total = weight.Minor(), sum.Minor()
+	}
+
+	shares := make([]Money, len(p.Components))
+	allocated, running := NewMoney(0, revenue.Currency()), int64(0)
+	for i := range p.Components {
+		running += weights[i]
+		upTo := revenue.MulRat(running, total)
+		shares[i] = NewMoney(upTo.Minor()-allocated.Minor(), revenue.Currency())
+		allocated = upTo
+	}
+	return shares, nil
+}
+
+// NewVariant creates a variant of the parent with its own attributes; its
+// category comes from the parent, and its price too unless price is given
+func (p *Product) NewVariant(id, name string, price *Money, attributes map[string]string) (*Product, error) {
+	if p.Type != ProductTypeParent {
+		return nil, fmt.Errorf("%w: %s is not a parent product", ErrInvalidProduct, p.ID)
+	}
+
+	variant := &Product{
+		ID:          id,
+		ProductName: name,
+		Price:       p.Price,
+		CategoryID:  p.CategoryID,
+		TaxClass:    p.TaxClass,
+		Status:      ProductStatusActive,
+		Type:        ProductTypeVariant,
+		ParentID:    p.ID,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if price != nil {
+		variant.Price, variant.PriceOverride = *price, true
+	}
+	if err := variant.SetAttributes(attributes); err != nil {
+		return nil, err
+	}
+	if len(variant.Attributes) == 0 {
+		return nil, fmt.Errorf("%w: a variant needs attributes that set it apart, such as size or colour", ErrInvalidProduct)
+	}
+	if variant.ProductName == "" {
+		variant.ProductName = p.ProductName + " (" + variant.AttributeSummary() + ")"
+	}
+	return variant, nil
+}
+
+// AttributeSummary lists the attribute values in name order, e.g. "Red, M" for colour and size
+func (p *Product) AttributeSummary() string {
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = p.Attributes[name]
+	}
+	return strings.Join(values, ", ")
+}
+
+// SameAttributes returns true when both products have exactly the same attributes,
+// comparing values case-insensitively
+func (p *Product) SameAttributes(other *Product) bool {
+	if len(p.Attributes) != len(other.Attributes) {
+		return false
+	}
+	for name, value := range p.Attributes {
+		if !strings.EqualFold(other.Attributes[name], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSKU sets the stock keeping unit, trimmed and in upper case; empty removes it
+func (p *Product) SetSKU(sku string) {
+	p.SKU = strings.ToUpper(strings.TrimSpace(sku))
+}
+
+// SetBarcode sets the barcode with spaces removed; empty removes it
+func (p *Product) SetBarcode(barcode string) {
+	p.Barcode = strings.ReplaceAll(strings.TrimSpace(barcode), " ", "")
+}
+
+// SetAttributes replaces the product's attributes; names are trimmed and put in
+// lower case, values are trimmed, and attributes with an empty value are dropped
+func (p *Product) SetAttributes(attributes map[string]string) error {
+	normalized := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		name, value = NormalizeAttributeName(name), strings.TrimSpace(value)
+		if name == "" {
+			return fmt.Errorf("%w: attribute names cannot be empty", ErrInvalidProduct)
+		}
+		if _, ok := normalized[name]; ok {
+			return fmt.Errorf("%w: attribute %q is given more than once", ErrInvalidProduct, name)
+		}
+		if value != "" {
+			normalized[name] = value
+		}
+	}
+
+	p.Attributes = normalized
+	p.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// NormalizeAttributeName puts an attribute name in the form it is stored and matched in
+func NormalizeAttributeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// UpdateStatus archives the product or makes it active again
+func (p *Product) UpdateStatus(status ProductStatus) error {
+	if !status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidProduct, status)
+	}
+	p.Status = status
+	p.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// Available returns the quantity on hand that is not held by reservations
+func (p *Product) Available() int {
+	return max(p.Quantity-p.Reserved, 0)
+}
+
+// IsAvailable checks if the product has sufficient unreserved quantity, or
+// for a bundle if its components make up enough bundles
 func (p *Product) IsAvailable(requestedQuantity int) bool {
-	return p.Quantity >= requestedQuantity && requestedQuantity > 0
+	_, _, available := p.Stock()
+	return available >= requestedQuantity && requestedQuantity > 0
 }
 
 // ReduceQuantity reduces the product quantity (for order processing)
 func (p *Product) ReduceQuantity(amount int) error {
 	if !p.IsAvailable(amount) {
 		return fmt.Errorf("insufficient quantity: available=%d, requested=%d",
-			p.Quantity, amount)
+			p.Available(), amount)
 	}
 	p.Quantity -= amount
 	p.UpdatedAt = time.Now().UTC()
@@ -35,9 +372,9 @@ func (p *Product) ReduceQuantity(amount int) error {
 }
 
 // UpdatePrice updates the product price with validation
-func (p *Product) UpdatePrice(newPrice float64) error {
-	if newPrice <= 0 {
-		return fmt.Errorf("price must be greater than zero: %f", newPrice)
+func (p *Product) UpdatePrice(newPrice Money) error {
+	if !newPrice.IsPositive() {
+		return fmt.Errorf("%w: price must be greater than zero: %s", ErrInvalidAmount, newPrice)
 	}
 	p.Price = newPrice
 	p.UpdatedAt = time.Now().UTC()
@@ -54,9 +391,52 @@ func (p *Product) UpdateQuantity(newQuantity int) error {
 	return nil
 }
 
+// UpdateReorderPolicy sets when and how much of the product is bought
+func (p *Product) UpdateReorderPolicy(reorderPoint, reorderQuantity, leadTimeDays int) error {
+	if reorderPoint < 0 || reorderQuantity < 0 || leadTimeDays < 0 {
+		return fmt.Errorf("reorder point, reorder quantity and lead time cannot be negative")
+	}
+	p.ReorderPoint = reorderPoint
+	p.ReorderQuantity = reorderQuantity
+	p.LeadTimeDays = leadTimeDays
+	p.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// NeedsReorder returns true if the product has a reorder point and available
+// stock plus onOrder units still to arrive is at or below it
+func (p *Product) NeedsReorder(onOrder int) bool {
+	return p.ReorderPoint > 0 && p.Available()+onOrder <= p.ReorderPoint
+}
+
+// AddCost works out the new average cost after quantity units costing unitCost
+// each were added; Quantity must already include them
+func (p *Product) AddCost(quantity int, unitCost Money) error {
+	before := p.Quantity - quantity
+	if before <= 0 {
+		p.AverageCost = unitCost
+		return nil
+	}
+
+	held, err := p.AverageCost.Mul(before)
+	if err != nil {
+		return err
+	}
+	added, err := unitCost.Mul(quantity)
+	if err != nil {
+		return err
+	}
+	total, err := held.Add(added)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	p.AverageCost = total.Div(p.Quantity)
+	return nil
+}
+
 // CalculateValue calculates the total value of the product inventory
-func (p *Product) CalculateValue() float64 {
-	return p.Price * float64(p.Quantity)
+func (p *Product) CalculateValue() (Money, error) {
+	return p.Price.Mul(p.Quantity)
 }
 
 // Validate performs business rule validation
@@ -64,11 +444,86 @@ func (p *Product) Validate() error {
 	if p.ProductName == "" {
 		return fmt.Errorf("product name is required")
 	}
-	if p.Price <= 0 {
-		return fmt.Errorf("price must be greater than zero")
+	if !p.Price.IsPositive() {
+		return fmt.Errorf("%w: price must be greater than zero", ErrInvalidAmount)
 	}
 	if p.Quantity < 0 {
 		return fmt.Errorf("quantity cannot be negative")
 	}
+	if p.ReorderPoint < 0 || p.ReorderQuantity < 0 || p.LeadTimeDays < 0 {
+		return fmt.Errorf("reorder point, reorder quantity and lead time cannot be negative")
+	}
+	return p.validateCatalogue()
+}
+
+// validateCatalogue checks the SKU, barcode, description, attributes, tax class and status
+func (p *Product) validateCatalogue() error {
+	if len(p.SKU) > maxSKULength {
+		return fmt.Errorf("%w: SKU must be at most %d characters", ErrInvalidProduct, maxSKULength)
+	}
+	for _, r := range p.SKU {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && !strings.ContainsRune("-_.", r) {
+			return fmt.Errorf("%w: SKU may only contain letters, digits, '-', '_' and '.': %q", ErrInvalidProduct, p.SKU)
+		}
+	}
+	if p.Barcode != "" && !ValidGTIN(p.Barcode) {
+		return fmt.Errorf("%w: barcode %q is not a valid EAN-8, UPC-A, EAN-13 or GTIN-14", ErrInvalidProduct, p.Barcode)
+	}
+	if len(p.Description) > maxDescriptionLength {
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidProduct, maxDescriptionLength)
+	}
+	if len(p.Attributes) > maxAttributes {
+		return fmt.Errorf("%w: a product can have at most %d attributes", ErrInvalidProduct, maxAttributes)
+	}
+	for name, value := range p.Attributes {
+		if len(name) > maxAttributeNameLength || len(value) > maxAttributeValueLength {
+			return fmt.Errorf("%w: attribute names must be at most %d characters and values at most %d",
+				ErrInvalidProduct, maxAttributeNameLength, maxAttributeValueLength)
+		}
+	}
+	if p.TaxClass != "" && !taxClassPattern.MatchString(p.TaxClass) {
+		return fmt.Errorf("%w: a tax class is 1 to 32 lower-case letters, digits, dashes or underscores: %q", ErrInvalidProduct, p.TaxClass)
+	}
+	if !p.Status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidProduct, p.Status)
+	}
+	if !p.Type.IsValid() {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidProduct, p.Type)
+	}
+	if (p.Type == ProductTypeVariant) != (p.ParentID != "") {
+		return fmt.Errorf("%w: variants, and only variants, have a parent product", ErrInvalidProduct)
+	}
+	if !p.Type.HoldsStock() && (p.Quantity != 0 || p.Reserved != 0) {
+		return fmt.Errorf("%w: a %s product holds no stock of its own", ErrInvalidProduct, p.Type)
+	}
+	if (p.Type == ProductTypeBundle) != (len(p.Components) > 0) {
+		return fmt.Errorf("%w: bundles, and only bundles, are made of components", ErrInvalidProduct)
+	}
 	return nil
 }
+
+// ValidGTIN checks the length and check digit of an EAN-8, UPC-A, EAN-13 or GTIN-14 barcode
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	// Digits are weighted 3 and 1 alternately from the right, the check digit excluded
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := code[i]
+		if d < '0' || d > '9' {
+			return false
+		}
+		weight := 1
+		if (len(code)-2-i)%2 == 0 {
+			weight = 3
+		}
+		sum += int(d-'0') * weight
+	}
+
+	check := code[len(code)-1]
+	return check >= '0' && check <= '9' && int(check-'0') == (10-sum%10)%10
+}
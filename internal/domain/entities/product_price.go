@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidPriceSchedule is returned when a scheduled price breaks a business rule
+var ErrInvalidPriceSchedule = errors.New("invalid price schedule")
+
+// ProductPrice is a product's list price from EffectiveFrom until EffectiveTo,
+// or until further notice while EffectiveTo is nil
+// Every price a product has had is kept, so its price history can always be
+// reproduced; a price that has not been activated yet is scheduled, and the
+// scheduler makes it the product's price once EffectiveFrom has passed
+type ProductPrice struct {
+	ID            uint       `json:"id"`
+	ProductID     string     `json:"product_id"`
+	Price         Money      `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+	ActivatedAt   *time.Time `json:"activated_at,omitempty"` // nil while scheduled
+	Actor         string     `json:"actor,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Validate performs business rule validation for product prices
+func (p *ProductPrice) Validate() error {
+	if p.ProductID == "" {
+		return fmt.Errorf("%w: product ID is required", ErrInvalidPriceSchedule)
+	}
+	if !p.Price.IsPositive() {
+		return fmt.Errorf("%w: price must be greater than zero: %s", ErrInvalidAmount, p.Price)
+	}
+	if p.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effective date is required", ErrInvalidPriceSchedule)
+	}
+	return nil
+}
+
+// IsScheduled checks if the price has yet to be applied to the product
+func (p *ProductPrice) IsScheduled() bool {
+	return p.ActivatedAt == nil
+}
+
+// IsDue checks if a scheduled price should have been applied by the given time
+func (p *ProductPrice) IsDue(at time.Time) bool {
+	return p.IsScheduled() && !at.Before(p.EffectiveFrom)
+}
+
+// PricePeriod is a stretch of a product's price history with the units sold
+// and revenue booked against the product while that price was in effect
+type PricePeriod struct {
+	*ProductPrice
+	UnitsSold int   `json:"units_sold"`
+	Revenue   Money `json:"revenue"`
+}
@@ -0,0 +1,259 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PromotionType is how a promotion works out its discount
+type PromotionType string
+
+const (
+	// PromotionTypePercentOff takes a percentage off every line it covers
+	PromotionTypePercentOff PromotionType = "percent_off"
+	// PromotionTypeAmountOff takes a fixed amount off the lines it covers, shared in proportion to their totals
+	PromotionTypeAmountOff PromotionType = "amount_off"
+	// PromotionTypeBuyXGetY gives GetQuantity units free for every BuyQuantity units bought
+	PromotionTypeBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+// IsValid checks if the promotion type is one of the known types
+func (t PromotionType) IsValid() bool {
+	return t == PromotionTypePercentOff || t == PromotionTypeAmountOff || t == PromotionTypeBuyXGetY
+}
+
+// ErrInvalidPromotion is returned when a promotion or coupon breaks a business rule
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+// ErrCouponNotUsable is returned when an order names a coupon that cannot be redeemed
+var ErrCouponNotUsable = errors.New("coupon cannot be used")
+
+// Promotion takes money off orders on top of price rules
+// It covers one product (or every variant of a parent), one category and its
+// subcategories, or the whole catalogue, between optional start and end times.
+// An automatic promotion applies to every order it covers; a coupon-only
+// promotion applies only to orders that name one of its coupon codes
+type Promotion struct {
+	ID   string        `json:"id"`
+	Name string        `json:"name"`
+	Type PromotionType `json:"type"`
+
+	// DiscountPercent is set for percent_off, Amount for amount_off and
+	// BuyQuantity and GetQuantity for buy_x_get_y
+	DiscountPercent Percent `json:"discount_percent"`
+	Amount          Money   `json:"amount"`
+	BuyQuantity     int     `json:"buy_quantity,omitempty"`
+	GetQuantity     int     `json:"get_quantity,omitempty"`
+
+	// At most one of ProductID and CategoryID is set; neither covers the whole catalogue
+	ProductID  string `json:"product_id,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+
+	CouponOnly bool `json:"coupon_only"`
+
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Validate performs business rule validation for promotions
+func (p *Promotion) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPromotion)
+	}
+	if p.ProductID != "" && p.CategoryID != "" {
+		return fmt.Errorf("%w: a promotion covers a product or a category, not both", ErrInvalidPromotion)
+	}
+
+	switch p.Type {
+	case PromotionTypePercentOff:
+		if p.DiscountPercent.hundredths <= 0 || p.DiscountPercent.hundredths >= percentScale {
+			return fmt.Errorf("%w: discount must be between 0 and 100 percent: %s", ErrInvalidPromotion, p.DiscountPercent)
+		}
+	case PromotionTypeAmountOff:
+		if !p.Amount.IsPositive() {
+			return fmt.Errorf("%w: amount must be greater than zero: %s", ErrInvalidPromotion, p.Amount)
+		}
+	case PromotionTypeBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy and get quantities must be greater than zero", ErrInvalidPromotion)
+		}
+	default:
+		return fmt.Errorf("%w: unknown promotion type %q", ErrInvalidPromotion, p.Type)
+	}
+
+	if p.Type != PromotionTypePercentOff && !p.DiscountPercent.IsZero() ||
+		p.Type != PromotionTypeAmountOff && !p.Amount.IsZero() ||
+		p.Type != PromotionTypeBuyXGetY && (p.BuyQuantity != 0 || p.GetQuantity != 0) {
+		return fmt.Errorf("%w: only set the discount of a %s promotion", ErrInvalidPromotion, p.Type)
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: a promotion must end after it starts", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// IsActive checks if the promotion is in effect at the given time
+func (p *Promotion) IsActive(at time.Time) bool {
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || at.Before(*p.EndsAt)
+}
+
+// Covers checks if the promotion applies to a product in the category with
+// the given ancestor IDs, the category itself included
+func (p *Promotion) Covers(product *Product, categoryIDs []string) bool {
+	switch {
+	case p.ProductID != "":
+		return p.ProductID == product.ID || p.ProductID == product.ParentID
+	case p.CategoryID != "":
+		return slices.Contains(categoryIDs, p.CategoryID)
+	default:
+		return true
+	}
+}
+
+// End stops the promotion applying from the given time
+// A promotion that has not started yet ends as it starts, so it never applies
+func (p *Promotion) End(at time.Time) error {
+	if p.EndsAt != nil && !at.Before(*p.EndsAt) {
+		return fmt.Errorf("%w: promotion %s already ended at %s", ErrInvalidPromotion, p.ID, p.EndsAt.Format(time.RFC3339))
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		at = *p.StartsAt
+	}
+	p.EndsAt = &at
+	return nil
+}
+
+// Discounts works out what the promotion takes off each of lines, all of
+// which it covers, from what they cost after any earlier discounts
+// Every line keeps at least one minor unit to pay, so a fixed amount larger
+// than the lines is capped
+func (p *Promotion) Discounts(lines []*OrderLine) ([]Money, error) {
+	discounts := make([]Money, len(lines))
+	switch p.Type {
+	case PromotionTypePercentOff:
+		for i, line := range lines {
+			var err error
+			if discounts[i], err = line.LineTotal.Sub(p.DiscountPercent.Off(line.LineTotal)); err != nil {
+				return nil, err
+			}
+		}
+
+	case PromotionTypeBuyXGetY:
+		for i, line := range lines {
+			free := line.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+			discounts[i] = line.LineTotal.MulRat(int64(free), int64(line.Quantity))
+		}
+
+	case PromotionTypeAmountOff:
+		var total Money
+		for _, line := range lines {
+			var err error
+			if total, err = total.Add(line.LineTotal); err != nil {
+				return nil, err
+			}
+		}
+		amount := p.Amount
+		if capped := NewMoney(total.Minor()-int64(len(lines)), total.Currency()); amount.Minor() > capped.Minor() {
+			amount = capped
+		}
+		if !amount.IsPositive() {
+			break
+		}
+
+		// Each line takes its share; the last takes what rounding leaves over
+		left := amount
+		for i, line := range lines {
+			discounts[i] = left
+			if i < len(lines)-1 {
+				discounts[i] = amount.MulRat(line.LineTotal.Minor(), total.Minor())
+			}
+			var err error
+			if left, err = left.Sub(discounts[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return discounts, nil
+}
+
+// couponCodePattern is what a coupon code may look like once normalised
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{2,31}$`)
+
+// NormalizeCouponCode returns a coupon code as it is stored; codes are not case-sensitive
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Coupon is a code customers enter to get a coupon-only promotion
+// A single-use coupon has MaxRedemptions 1; 0 means there is no limit, for
+// the coupon as a whole and for MaxPerCustomer alike
+type Coupon struct {
+	Code           string     `json:"code"`
+	PromotionID    string     `json:"promotion_id"`
+	MaxRedemptions int        `json:"max_redemptions"`
+	MaxPerCustomer int        `json:"max_per_customer"`
+	Redemptions    int        `json:"redemptions"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Validate performs business rule validation for coupons
+func (c *Coupon) Validate() error {
+	if !couponCodePattern.MatchString(c.Code) {
+		return fmt.Errorf("%w: a coupon code is 3 to 32 letters, digits, dashes or underscores: %q", ErrInvalidPromotion, c.Code)
+	}
+	if c.PromotionID == "" {
+		return fmt.Errorf("%w: promotion ID is required", ErrInvalidPromotion)
+	}
+	if c.MaxRedemptions < 0 || c.MaxPerCustomer < 0 {
+		return fmt.Errorf("%w: redemption limits cannot be negative", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// CheckRedeemable checks if a customer who has redeemed the coupon
+// customerRedemptions times before may redeem it at the given time
+func (c *Coupon) CheckRedeemable(at time.Time, customerRedemptions int) error {
+	if c.ExpiresAt != nil && !at.Before(*c.ExpiresAt) {
+		return fmt.Errorf("%w: %s expired at %s", ErrCouponNotUsable, c.Code, c.ExpiresAt.Format(time.RFC3339))
+	}
+	if c.MaxRedemptions > 0 && c.Redemptions >= c.MaxRedemptions {
+		return fmt.Errorf("%w: %s has been used up", ErrCouponNotUsable, c.Code)
+	}
+	if c.MaxPerCustomer > 0 && customerRedemptions >= c.MaxPerCustomer {
+		return fmt.Errorf("%w: %s can be used %d times per customer", ErrCouponNotUsable, c.Code, c.MaxPerCustomer)
+	}
+	return nil
+}
+
+// PromotionRedemption records what one promotion took off one order
+// CouponCode is empty when the promotion applied automatically
+type PromotionRedemption struct {
+	ID          uint      `json:"id"`
+	PromotionID string    `json:"promotion_id"`
+	CouponCode  string    `json:"coupon_code,omitempty"`
+	OrderID     string    `json:"order_id"`
+	CustomerID  string    `json:"customer_id"`
+	Discount    Money     `json:"discount"`
+	RedeemedAt  time.Time `json:"redeemed_at"`
+}
+
+// PromotionStats is what a promotion cost and how often it was redeemed;
+// redemptions on cancelled orders are not counted
+type PromotionStats struct {
+	PromotionID  string        `json:"promotion_id"`
+	Name         string        `json:"name"`
+	Type         PromotionType `json:"type"`
+	Redemptions  int           `json:"redemptions"`
+	Customers    int           `json:"customers"`
+	DiscountCost Money         `json:"discount_cost"`
+}
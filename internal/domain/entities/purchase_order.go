@@ -0,0 +1,351 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PurchaseOrder is an order for stock placed with a supplier
+// It is edited while a draft, sent to the supplier, and then received in one
+// or more deliveries until every line has arrived
+type PurchaseOrder struct {
+	ID         string               `json:"id"`
+	SupplierID string               `json:"supplier_id"`
+	LocationID string               `json:"location_id"` // where the goods are delivered
+	Status     PurchaseOrderStatus  `json:"status"`
+	Lines      []*PurchaseOrderLine `json:"lines"`
+	Total      Money                `json:"total"` // unit costs times quantities ordered
+	Note       string               `json:"note,omitempty"`
+
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+	ExpectedAt *time.Time `json:"expected_at,omitempty"` // sent date plus the lead time
+	ReceivedAt *time.Time `json:"received_at,omitempty"` // when the last outstanding unit arrived
+
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PurchaseOrderLine is one product on a purchase order
+type PurchaseOrderLine struct {
+	PurchaseOrderID  string `json:"purchase_order_id"`
+	LineNumber       int    `json:"line_number"`
+	ProductID        string `json:"product_id"`
+	ProductName      string `json:"product_name,omitempty"`
+	QuantityOrdered  int    `json:"quantity_ordered"`
+	QuantityReceived int    `json:"quantity_received"`
+	UnitCost         Money  `json:"unit_cost"` // agreed price per unit, before freight and duty
+}
+
+// PurchaseOrderStatus represents the status of a purchase order
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderStatusSent              PurchaseOrderStatus = "sent"
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"
+)
+
+var (
+	// ErrInvalidPurchaseOrder is returned when a purchase order or receipt breaks a business rule
+	ErrInvalidPurchaseOrder = errors.New("invalid purchase order")
+
+	// ErrPurchaseOrderStatus is returned when a purchase order's status does not allow a change,
+	// such as editing one that was sent or receiving a draft
+	ErrPurchaseOrderStatus = errors.New("purchase order status does not allow this")
+)
+
+// IsValid checks if the status is one of the known purchase order statuses
+func (s PurchaseOrderStatus) IsValid() bool {
+	switch s {
+	case PurchaseOrderStatusDraft, PurchaseOrderStatusSent, PurchaseOrderStatusPartiallyReceived, PurchaseOrderStatusReceived:
+		return true
+	}
+	return false
+}
+
+// IsOpen returns true while goods on the order are still to come
+func (s PurchaseOrderStatus) IsOpen() bool {
+	return s == PurchaseOrderStatusDraft || s == PurchaseOrderStatusSent || s == PurchaseOrderStatusPartiallyReceived
+}
+
+// Outstanding returns how many units of the line have not arrived yet
+func (l *PurchaseOrderLine) Outstanding() int {
+	return l.QuantityOrdered - l.QuantityReceived
+}
+
+// Validate performs business rule validation for purchase order lines
+func (l *PurchaseOrderLine) Validate() error {
+	if l.ProductID == "" {
+		return fmt.Errorf("%w: product ID is required", ErrInvalidPurchaseOrder)
+	}
+	if l.QuantityOrdered <= 0 {
+		return fmt.Errorf("%w: quantity must be greater than zero: %d", ErrInvalidPurchaseOrder, l.QuantityOrdered)
+	}
+	if l.QuantityReceived < 0 || l.QuantityReceived > l.QuantityOrdered {
+		return fmt.Errorf("%w: received %d of %d ordered for product %s",
+			ErrInvalidPurchaseOrder, l.QuantityReceived, l.QuantityOrdered, l.ProductID)
+	}
+	if l.UnitCost.IsNegative() {
+		return fmt.Errorf("%w: unit cost cannot be negative: %s", ErrInvalidPurchaseOrder, l.UnitCost)
+	}
+	return nil
+}
+
+// NewPurchaseOrder creates an empty draft purchase order
+func NewPurchaseOrder(id, supplierID, locationID, note string) *PurchaseOrder {
+	now := time.Now().UTC()
+	return &PurchaseOrder{
+		ID:         id,
+		SupplierID: supplierID,
+		LocationID: locationID,
+		Status:     PurchaseOrderStatusDraft,
+		Total:      NewMoney(0, ""),
+		Note:       note,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// AddLine adds a product to a draft; each product may appear only once
+// A unit cost of zero is allowed on a draft but must be filled in before it is sent
+func (po *PurchaseOrder) AddLine(productID, productName string, quantity int, unitCost Money) error {
+	if err := po.requireStatus(PurchaseOrderStatusDraft); err != nil {
+		return err
+	}
+	if po.FindLine(productID) != nil {
+		return fmt.Errorf("%w: product %s is listed twice", ErrInvalidPurchaseOrder, productID)
+	}
+
+	line := &PurchaseOrderLine{
+		PurchaseOrderID: po.ID,
+		LineNumber:      len(po.Lines) + 1,
+		ProductID:       productID,
+		ProductName:     productName,
+		QuantityOrdered: quantity,
+		UnitCost:        unitCost,
+	}
+	if err := line.Validate(); err != nil {
+		return err
+	}
+
+	po.Lines = append(po.Lines, line)
+	return po.calculateTotal()
+}
+
+// ClearLines removes every line from a draft so it can be filled again
+func (po *PurchaseOrder) ClearLines() error {
+	if err := po.requireStatus(PurchaseOrderStatusDraft); err != nil {
+		return err
+	}
+
+	po.Lines = nil
+	po.Total = NewMoney(0, "")
+	po.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// FindLine returns the line for a product, or nil if the product is not on the order
+func (po *PurchaseOrder) FindLine(productID string) *PurchaseOrderLine {
+	for _, line := range po.Lines {
+		if line.ProductID == productID {
+			return line
+		}
+	}
+	return nil
+}
+
+// Send marks a draft as sent to the supplier; the goods are expected after leadTime
+func (po *PurchaseOrder) Send(leadTime time.Duration) error {
+	if err := po.requireStatus(PurchaseOrderStatusDraft); err != nil {
+		return err
+	}
+	if len(po.Lines) == 0 {
+		return fmt.Errorf("%w: purchase order %s has no lines", ErrInvalidPurchaseOrder, po.ID)
+	}
+	for _, line := range po.Lines {
+		if !line.UnitCost.IsPositive() {
+			return fmt.Errorf("%w: unit cost of product %s is required before sending", ErrInvalidPurchaseOrder, line.ProductID)
+		}
+	}
+
+	now := time.Now().UTC()
+	expected := now.Add(leadTime)
+	po.Status = PurchaseOrderStatusSent
+	po.SentAt = &now
+	po.ExpectedAt = &expected
+	po.UpdatedAt = now
+	return nil
+}
+
+// Receive books a delivery against the order
+// Each receipt line must be for a product on the order and no more than is
+// still outstanding; the receipt's unit and landed costs are filled in
+func (po *PurchaseOrder) Receive(receipt *GoodsReceipt) error {
+	if err := po.requireStatus(PurchaseOrderStatusSent, PurchaseOrderStatusPartiallyReceived); err != nil {
+		return err
+	}
+	if len(receipt.Lines) == 0 {
+		return fmt.Errorf("%w: nothing to receive", ErrInvalidPurchaseOrder)
+	}
+
+	for i, received := range receipt.Lines {
+		line := po.FindLine(received.ProductID)
+		if line == nil {
+			return fmt.Errorf("%w: product %s is not on purchase order %s", ErrInvalidPurchaseOrder, received.ProductID, po.ID)
+		}
+		if slices.ContainsFunc(receipt.Lines[:i], func(l *GoodsReceiptLine) bool { return l.ProductID == received.ProductID }) {
+			return fmt.Errorf("%w: product %s is listed twice", ErrInvalidPurchaseOrder, received.ProductID)
+		}
+		if received.Quantity <= 0 || received.Quantity > line.Outstanding() {
+			return fmt.Errorf("%w: cannot receive %d of product %s, %d outstanding",
+				ErrInvalidPurchaseOrder, received.Quantity, received.ProductID, line.Outstanding())
+		}
+
+		received.LineNumber = i + 1
+		received.UnitCost = line.UnitCost
+		line.QuantityReceived += received.Quantity
+	}
+
+	if err := receipt.allocateLandedCost(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	po.Status = PurchaseOrderStatusReceived
+	for _, line := range po.Lines {
+		if line.Outstanding() > 0 {
+			po.Status = PurchaseOrderStatusPartiallyReceived
+		}
+	}
+	if po.Status == PurchaseOrderStatusReceived {
+		po.ReceivedAt = &now
+	}
+	po.UpdatedAt = now
+	return nil
+}
+
+// Validate performs business rule validation for purchase orders
+func (po *PurchaseOrder) Validate() error {
+	if po.SupplierID == "" {
+		return fmt.Errorf("%w: supplier ID is required", ErrInvalidPurchaseOrder)
+	}
+	if !po.Status.IsValid() {
+		return fmt.Errorf("%w: invalid status: %s", ErrInvalidPurchaseOrder, po.Status)
+	}
+	for _, line := range po.Lines {
+		if err := line.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// calculateTotal sums the cost of every line
+func (po *PurchaseOrder) calculateTotal() error {
+	amounts := make([]Money, len(po.Lines))
+	for i, line := range po.Lines {
+		amount, err := line.UnitCost.Mul(line.QuantityOrdered)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPurchaseOrder, err)
+		}
+		amounts[i] = amount
+	}
+
+	total, err := SumMoney(amounts...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPurchaseOrder, err)
+	}
+
+	po.Total = total
+	po.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// requireStatus checks that the order is in one of the given statuses
+func (po *PurchaseOrder) requireStatus(statuses ...PurchaseOrderStatus) error {
+	if !slices.Contains(statuses, po.Status) {
+		return fmt.Errorf("%w: purchase order %s is %s", ErrPurchaseOrderStatus, po.ID, po.Status)
+	}
+	return nil
+}
+
+// GoodsReceipt records one delivery against a purchase order
+// Each line is a batch of stock bought at its landed unit cost
+type GoodsReceipt struct {
+	ID              string              `json:"id"`
+	PurchaseOrderID string              `json:"purchase_order_id"`
+	LocationID      string              `json:"location_id"`
+	Lines           []*GoodsReceiptLine `json:"lines"`
+
+	// AdditionalCost is freight, duty and handling for the delivery; it is
+	// spread over the lines in proportion to their value
+	AdditionalCost Money `json:"additional_cost"`
+
+	Actor      string    `json:"actor"`
+	Note       string    `json:"note,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// GoodsReceiptLine is the quantity of one product received in a delivery
+type GoodsReceiptLine struct {
+	ReceiptID      string `json:"receipt_id"`
+	LineNumber     int    `json:"line_number"`
+	ProductID      string `json:"product_id"`
+	Quantity       int    `json:"quantity"`
+	UnitCost       Money  `json:"unit_cost"`
+	LandedUnitCost Money  `json:"landed_unit_cost"` // unit cost plus its share of the additional cost
+}
+
+// allocateLandedCost spreads the additional cost over the lines by value and
+// works out each line's landed unit cost; the last line takes any rounding remainder
+func (r *GoodsReceipt) allocateLandedCost() error {
+	if r.AdditionalCost.IsNegative() {
+		return fmt.Errorf("%w: additional cost cannot be negative: %s", ErrInvalidPurchaseOrder, r.AdditionalCost)
+	}
+
+	values := make([]Money, len(r.Lines))
+	var totalQuantity int64
+	for i, line := range r.Lines {
+		var err error
+		if values[i], err = line.UnitCost.Mul(line.Quantity); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPurchaseOrder, err)
+		}
+		totalQuantity += int64(line.Quantity)
+	}
+	total, err := SumMoney(values...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPurchaseOrder, err)
+	}
+	totalValue := total.Minor()
+
+	remaining := r.AdditionalCost
+	for i, line := range r.Lines {
+		value := values[i]
+
+		share := remaining
+		if i < len(r.Lines)-1 {
+			if totalValue > 0 {
+				share = r.AdditionalCost.MulRat(value.Minor(), totalValue)
+			} else {
+				share = r.AdditionalCost.MulRat(int64(line.Quantity), totalQuantity)
+			}
+		}
+
+		var err error
+		if remaining, err = remaining.Sub(share); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPurchaseOrder, err)
+		}
+		landed, err := value.Add(share)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPurchaseOrder, err)
+		}
+		line.ReceiptID = r.ID
+		line.LandedUnitCost = landed.Div(line.Quantity)
+	}
+
+	return nil
+}
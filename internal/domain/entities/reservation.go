@@ -0,0 +1,157 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultReservationTTL is how long stock is held when no expiry is requested
+const DefaultReservationTTL = 15 * time.Minute
+
+// MaxReservationTTL is the longest a single hold may last
+const MaxReservationTTL = 24 * time.Hour
+
+// StockReservation holds product stock for a customer between checkout and payment
+// Held units stay on hand but are no longer available to other orders until
+// the hold is confirmed into an order, released, or expires
+type StockReservation struct {
+	ID         string            `json:"id"`
+	ProductID  string            `json:"product_id"`
+	CustomerID string            `json:"customer_id,omitempty"`
+	Quantity   int               `json:"quantity"`
+	Status     ReservationStatus `json:"status"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+
+	// LocationID is where the units are held, and so where an order confirming
+	// the hold ships from. Holds placed before locations were recorded have none
+	LocationID string `json:"location_id,omitempty"`
+
+	// OrderID is the order the hold was confirmed into
+	OrderID string `json:"order_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReservationStatus represents the status of a stock reservation
+type ReservationStatus string
+
+const (
+	ReservationStatusActive    ReservationStatus = "active"
+	ReservationStatusConfirmed ReservationStatus = "confirmed"
+	ReservationStatusReleased  ReservationStatus = "released"
+	ReservationStatusExpired   ReservationStatus = "expired"
+)
+
+var (
+	// ErrReservationNotActive is returned when confirming or releasing a hold that has already ended
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+
+	// ErrInvalidReservation is returned when a reservation request breaks a business rule
+	ErrInvalidReservation = errors.New("invalid reservation")
+)
+
+// IsValid checks if the status is one of the known reservation statuses
+func (s ReservationStatus) IsValid() bool {
+	switch s {
+	case ReservationStatusActive, ReservationStatusConfirmed, ReservationStatusReleased, ReservationStatusExpired:
+		return true
+	}
+	return false
+}
+
+// NewStockReservation creates an active hold on quantity units of a product
+// A ttl of zero uses DefaultReservationTTL
+func NewStockReservation(id, productID, customerID string, quantity int, ttl time.Duration) (*StockReservation, error) {
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl < 0 || ttl > MaxReservationTTL {
+		return nil, fmt.Errorf("%w: expiry must be positive and at most %s", ErrInvalidReservation, MaxReservationTTL)
+	}
+
+	now := time.Now().UTC()
+	reservation := &StockReservation{
+		ID:         id,
+		ProductID:  productID,
+		CustomerID: customerID,
+		Quantity:   quantity,
+		Status:     ReservationStatusActive,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := reservation.Validate(); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Validate performs business rule validation for reservations
+func (r *StockReservation) Validate() error {
+	if r.ProductID == "" {
+		return fmt.Errorf("%w: product ID is required", ErrInvalidReservation)
+	}
+	if r.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be greater than zero: %d", ErrInvalidReservation, r.Quantity)
+	}
+	if !r.Status.IsValid() {
+		return fmt.Errorf("%w: invalid status: %s", ErrInvalidReservation, r.Status)
+	}
+	return nil
+}
+
+// IsActive returns true if the hold still counts against available stock at the given time
+func (r *StockReservation) IsActive(now time.Time) bool {
+	return r.Status == ReservationStatusActive && now.Before(r.ExpiresAt)
+}
+
+// IsExpired returns true if the hold is still active but has run out of time
+func (r *StockReservation) IsExpired(now time.Time) bool {
+	return r.Status == ReservationStatusActive && !now.Before(r.ExpiresAt)
+}
+
+// Confirm turns the hold into part of an order
+func (r *StockReservation) Confirm(orderID string) error {
+	if !r.IsActive(time.Now().UTC()) {
+		return fmt.Errorf("%w: reservation %s is %s", ErrReservationNotActive, r.ID, r.displayStatus())
+	}
+
+	r.OrderID = orderID
+	r.end(ReservationStatusConfirmed)
+	return nil
+}
+
+// Release gives the held stock back before the hold expires
+func (r *StockReservation) Release() error {
+	if r.Status != ReservationStatusActive {
+		return fmt.Errorf("%w: reservation %s is %s", ErrReservationNotActive, r.ID, r.Status)
+	}
+	r.end(ReservationStatusReleased)
+	return nil
+}
+
+// Expire ends a hold whose time has run out
+func (r *StockReservation) Expire() error {
+	if !r.IsExpired(time.Now().UTC()) {
+		return fmt.Errorf("%w: reservation %s has not expired", ErrReservationNotActive, r.ID)
+	}
+	r.end(ReservationStatusExpired)
+	return nil
+}
+
+// end moves an active hold to its final status
+func (r *StockReservation) end(status ReservationStatus) {
+	r.Status = status
+	r.UpdatedAt = time.Now().UTC()
+}
+
+// displayStatus reports an active hold past its expiry as expired
+func (r *StockReservation) displayStatus() ReservationStatus {
+	if r.IsExpired(time.Now().UTC()) {
+		return ReservationStatusExpired
+	}
+	return r.Status
+}
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supplier is a business that stock is bought from
+type Supplier struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+
+	// LeadTimeDays is how long the supplier usually takes to deliver;
+	// a product's own lead time takes precedence
+	LeadTimeDays int `json:"lead_time_days"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ErrInvalidSupplier is returned when a supplier breaks a business rule
+var ErrInvalidSupplier = errors.New("invalid supplier")
+
+// Validate performs business rule validation for suppliers
+func (s *Supplier) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSupplier)
+	}
+	if s.Email != "" && !strings.Contains(s.Email, "@") {
+		return fmt.Errorf("%w: invalid email format: %s", ErrInvalidSupplier, s.Email)
+	}
+	if s.LeadTimeDays < 0 {
+		return fmt.Errorf("%w: lead time cannot be negative: %d", ErrInvalidSupplier, s.LeadTimeDays)
+	}
+	return nil
+}
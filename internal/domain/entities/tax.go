@@ -0,0 +1,176 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidTaxRate is returned when a tax rate, tax class or jurisdiction breaks a business rule
+	ErrInvalidTaxRate = errors.New("invalid tax rate")
+
+	// ErrNoTaxRate is returned when a taxed product is sold where no rate for its tax class is in effect
+	ErrNoTaxRate = errors.New("no tax rate in effect")
+)
+
+// taxClassPattern is what a tax class may look like once normalised, e.g. "gst_18"
+var taxClassPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// jurisdictionPattern is an ISO 3166-1 country code, optionally followed by
+// an ISO 3166-2 subdivision, e.g. "IN" or "IN-KA"
+var jurisdictionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// maxTaxComponentName is the longest a tax component's name may be
+const maxTaxComponentName = 20
+
+// NormalizeTaxClass returns a tax class as it is stored; classes are not case-sensitive
+func NormalizeTaxClass(class string) string {
+	return strings.ToLower(strings.TrimSpace(class))
+}
+
+// ValidateTaxClass checks a normalised tax class; the empty class means the product is not taxed
+func ValidateTaxClass(class string) error {
+	if class != "" && !taxClassPattern.MatchString(class) {
+		return fmt.Errorf("%w: a tax class is 1 to 32 lower-case letters, digits, dashes or underscores: %q", ErrInvalidTaxRate, class)
+	}
+	return nil
+}
+
+// NormalizeJurisdiction returns a tax jurisdiction as it is stored
+func NormalizeJurisdiction(jurisdiction string) string {
+	return strings.ToUpper(strings.TrimSpace(jurisdiction))
+}
+
+// ValidateJurisdiction checks a normalised tax jurisdiction; the empty jurisdiction is allowed
+func ValidateJurisdiction(jurisdiction string) error {
+	if jurisdiction != "" && !jurisdictionPattern.MatchString(jurisdiction) {
+		return fmt.Errorf("%w: a jurisdiction is a country code with an optional subdivision, such as IN or IN-KA: %q",
+			ErrInvalidTaxRate, jurisdiction)
+	}
+	return nil
+}
+
+// TaxComponent is one tax a rate is made of, such as CGST or SGST
+type TaxComponent struct {
+	Name string  `json:"name"`
+	Rate Percent `json:"rate"`
+}
+
+// TaxRate is the tax charged on products of one tax class in one
+// jurisdiction, in effect from EffectiveFrom until the next rate for the same
+// class and jurisdiction
+// Rate is the sum of the components; GST, for example, is charged as CGST and
+// SGST halves within a state and as IGST between states
+type TaxRate struct {
+	ID            uint            `json:"id"`
+	Jurisdiction  string          `json:"jurisdiction"`
+	TaxClass      string          `json:"tax_class"`
+	Rate          Percent         `json:"rate"`
+	Components    []*TaxComponent `json:"components"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Validate performs business rule validation for tax rates
+func (r *TaxRate) Validate() error {
+	if r.Jurisdiction == "" {
+		return fmt.Errorf("%w: jurisdiction is required", ErrInvalidTaxRate)
+	}
+	if err := ValidateJurisdiction(r.Jurisdiction); err != nil {
+		return err
+	}
+	if r.TaxClass == "" {
+		return fmt.Errorf("%w: tax class is required", ErrInvalidTaxRate)
+	}
+	if err := ValidateTaxClass(r.TaxClass); err != nil {
+		return err
+	}
+	if len(r.Components) == 0 {
+		return fmt.Errorf("%w: a rate needs at least one component", ErrInvalidTaxRate)
+	}
+
+	var total int64
+	seen := make(map[string]bool, len(r.Components))
+	for _, component := range r.Components {
+		if component.Name == "" || len(component.Name) > maxTaxComponentName {
+			return fmt.Errorf("%w: component names are 1 to %d characters", ErrInvalidTaxRate, maxTaxComponentName)
+		}
+		if seen[component.Name] {
+			return fmt.Errorf("%w: component %s appears more than once", ErrInvalidTaxRate, component.Name)
+		}
+		seen[component.Name] = true
+		if component.Rate.hundredths < 0 || component.Rate.hundredths >= percentScale {
+			return fmt.Errorf("%w: %s must be at least 0 and below 100 percent: %s", ErrInvalidTaxRate, component.Name, component.Rate)
+		}
+		total += component.Rate.hundredths
+	}
+	if r.Rate.hundredths != total {
+		return fmt.Errorf("%w: rate %s is not the sum of its components", ErrInvalidTaxRate, r.Rate)
+	}
+
+	if r.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effective date is required", ErrInvalidTaxRate)
+	}
+	return nil
+}
+
+// SumComponents sets Rate to the sum of the component rates
+func (r *TaxRate) SumComponents() {
+	var total int64
+	for _, component := range r.Components {
+		total += component.Rate.hundredths
+	}
+	r.Rate = Percent{hundredths: total}
+}
+
+// Charge works out the tax on amount at this rate and splits it between the
+// components; when inclusive, amount already includes the tax
+// Tax is rounded half to even once, on the total, and each component but the
+// last takes its share of it, so the components always add up to the total
+func (r *TaxRate) Charge(amount Money, inclusive bool) (Money, []*LineTax) {
+	rate := r.Rate.hundredths
+	total := amount.MulRat(rate, percentScale)
+	if inclusive {
+		total = amount.MulRat(rate, percentScale+rate)
+	}
+
+	taxes := make([]*LineTax, len(r.Components))
+	left := total
+	for i, component := range r.Components {
+		share := left
+		if i < len(r.Components)-1 {
+			share = NewMoney(0, total.Currency())
+			if rate > 0 {
+				share = total.MulRat(component.Rate.hundredths, rate)
+			}
+		}
+		left = NewMoney(left.Minor()-share.Minor(), total.Currency())
+		taxes[i] = &LineTax{Name: component.Name, Rate: component.Rate, Amount: share}
+	}
+	return total, taxes
+}
+
+// LineTax is one tax charged on an order line
+type LineTax struct {
+	Name   string  `json:"name"`
+	Rate   Percent `json:"rate"`
+	Amount Money   `json:"amount"`
+}
+
+// TaxLiability is the tax owed on the sales of one tax class at one rate in
+// one jurisdiction over a period, net of refunds
+// Taxable amounts exclude the tax itself, whether prices included it or not
+type TaxLiability struct {
+	Jurisdiction     string  `json:"jurisdiction"`
+	TaxClass         string  `json:"tax_class"`
+	Rate             Percent `json:"rate"`
+	TaxableSales     Money   `json:"taxable_sales"`
+	TaxableRefunds   Money   `json:"taxable_refunds"`
+	TaxCollected     Money   `json:"tax_collected"`
+	TaxRefunded      Money   `json:"tax_refunded"`
+	TaxDue           Money   `json:"tax_due"` // collected less refunded
+	TransactionCount int     `json:"transaction_count"`
+}
@@ -12,22 +12,35 @@ type TransactionType string
 const (
 	TransactionTypeOrder  TransactionType = "order"
 	TransactionTypeRefund TransactionType = "refund"
+	// TransactionTypeCredit adds store credit to a customer's wallet
 	TransactionTypeCredit TransactionType = "credit"
+	// TransactionTypeCreditRedemption spends store credit at checkout
+	TransactionTypeCreditRedemption TransactionType = "credit_redemption"
 )
 
 // Transaction represents the core transaction entity for business analytics
 type Transaction struct {
-	ID            string          `json:"id"`
-	OrderID       string          `json:"order_id"`
-	CustomerID    string          `json:"customer_id"`
-	ProductID     string          `json:"product_id"`
-	Type          TransactionType `json:"type"`
-	Amount        float64         `json:"amount"`
-	Quantity      int             `json:"quantity"`
-	UnitPrice     float64         `json:"unit_price"`
-	Description   string          `json:"description"`
-	TransactionAt time.Time       `json:"transaction_at"`
-	CreatedAt     time.Time       `json:"created_at"`
+	ID          string          `json:"id"`
+	OrderID     string          `json:"order_id"`
+	CustomerID  string          `json:"customer_id"`
+	ProductID   string          `json:"product_id"`
+	Type        TransactionType `json:"type"`
+	Amount      Money           `json:"amount"`
+	Quantity    int             `json:"quantity"`
+	UnitPrice   Money           `json:"unit_price"`
+	CostOfGoods Money           `json:"cost_of_goods"` // cost of the units sold, or taken back by a refund
+	Discount    Money           `json:"discount"`      // what promotions took off Amount
+	Description string          `json:"description"`
+
+	// Tax is the part of Amount that is tax, charged at TaxRate on products of
+	// TaxClass under the rates of TaxJurisdiction; untaxed sales have no class
+	Tax             Money   `json:"tax"`
+	TaxRate         Percent `json:"tax_rate"`
+	TaxClass        string  `json:"tax_class,omitempty"`
+	TaxJurisdiction string  `json:"tax_jurisdiction,omitempty"`
+
+	TransactionAt time.Time `json:"transaction_at"`
+	CreatedAt     time.Time `json:"created_at"`
 
 	// Navigation properties
 	Order    *Order    `json:"order,omitempty"`
@@ -38,25 +51,41 @@ type Transaction struct {
 // Business logic methods
 
 // Validate performs business rule validation for transactions
+// Store credit entries belong to a customer only; order and product are optional
 func (t *Transaction) Validate() error {
-	if t.OrderID == "" {
-		return fmt.Errorf("order ID is required")
-	}
-
 	if t.CustomerID == "" {
 		return fmt.Errorf("customer ID is required")
 	}
 
-	if t.ProductID == "" {
-		return fmt.Errorf("product ID is required")
-	}
-
 	if !t.IsValidType() {
 		return fmt.Errorf("invalid transaction type: %s", t.Type)
 	}
 
-	if t.Amount <= 0 {
-		return fmt.Errorf("amount must be greater than zero: %f", t.Amount)
+	if !t.Amount.IsPositive() {
+		return fmt.Errorf("amount must be greater than zero: %s", t.Amount)
+	}
+
+	if t.CostOfGoods.IsNegative() {
+		return fmt.Errorf("cost of goods cannot be negative: %s", t.CostOfGoods)
+	}
+
+	if t.Tax.IsNegative() || t.Tax.Minor() > t.Amount.Minor() {
+		return fmt.Errorf("tax must be between zero and the amount: %s", t.Tax)
+	}
+
+	if t.IsWalletEntry() {
+		if t.Type == TransactionTypeCreditRedemption && t.OrderID == "" {
+			return fmt.Errorf("order ID is required to spend store credit")
+		}
+		return nil
+	}
+
+	if t.OrderID == "" {
+		return fmt.Errorf("order ID is required")
+	}
+
+	if t.ProductID == "" {
+		return fmt.Errorf("product ID is required")
 	}
 
 	if t.Quantity <= 0 {
@@ -72,10 +101,29 @@ func (t *Transaction) IsValidType() bool {
 		TransactionTypeOrder,
 		TransactionTypeRefund,
 		TransactionTypeCredit,
+		TransactionTypeCreditRedemption,
 	}
 	return slices.Contains(validTypes, t.Type)
 }
 
+// IsWalletEntry returns true if this transaction moves store credit
+func (t *Transaction) IsWalletEntry() bool {
+	return t.Type == TransactionTypeCredit || t.Type == TransactionTypeCreditRedemption
+}
+
+// GetCreditAmount returns the effect on the customer's store credit balance
+// (positive when credit is issued, negative when it is spent)
+func (t *Transaction) GetCreditAmount() Money {
+	switch t.Type {
+	case TransactionTypeCredit:
+		return t.Amount
+	case TransactionTypeCreditRedemption:
+		return t.Amount.Neg()
+	default:
+		return NewMoney(0, t.Amount.Currency())
+	}
+}
+
 // SetTransactionTime sets the transaction time to current time
 func (t *Transaction) SetTransactionTime() {
 	t.TransactionAt = time.Now().UTC()
@@ -92,53 +140,259 @@ func (t *Transaction) IsRefund() bool {
 }
 
 // GetRevenueAmount returns the revenue amount (positive for orders, negative for refunds)
-func (t *Transaction) GetRevenueAmount() float64 {
+func (t *Transaction) GetRevenueAmount() Money {
 	switch t.Type {
 	case TransactionTypeOrder:
 		return t.Amount
 	case TransactionTypeRefund:
-		return -t.Amount
+		return t.Amount.Neg()
+	default:
+		return NewMoney(0, t.Amount.Currency())
+	}
+}
+
+// GetCostAmount returns the effect on cost of goods sold (positive for orders,
+// negative for refunds of goods that went back into stock)
+func (t *Transaction) GetCostAmount() Money {
+	switch t.Type {
+	case TransactionTypeOrder:
+		return t.CostOfGoods
+	case TransactionTypeRefund:
+		return t.CostOfGoods.Neg()
 	default:
-		return 0
+		return NewMoney(0, t.Amount.Currency())
 	}
 }
 
-// CreateFromOrder creates a transaction from an order
-func (t *Transaction) CreateFromOrder(order *Order) {
+// CreateFromOrderLine creates the sale transaction for one line of an order
+// costOfGoods is what the line's units cost
+func (t *Transaction) CreateFromOrderLine(order *Order, line *OrderLine, costOfGoods Money) {
 	t.OrderID = order.ID
 	t.CustomerID = order.CustomerID
-	t.ProductID = order.ProductID
+	t.ProductID = line.ProductID
 	t.Type = TransactionTypeOrder
-	t.Amount = order.TotalAmount
-	t.Quantity = order.Quantity
-	t.UnitPrice = order.UnitPrice
-	t.Description = fmt.Sprintf("Order for %d units", order.Quantity)
+	t.Amount = line.LineTotal
+	t.Discount = line.Discount
+	t.Quantity = line.Quantity
+	t.UnitPrice = line.UnitPrice
+	t.CostOfGoods = costOfGoods
+	t.chargeTax(order, line)
+	t.Description = fmt.Sprintf("Order for %d units", line.Quantity)
 	t.SetTransactionTime()
 }
 
+// CreateRefundForOrderLine creates a compensating refund for one line of a cancelled order
+// costOfGoods is the cost of the units going back into stock
+func (t *Transaction) CreateRefundForOrderLine(order *Order, line *OrderLine, costOfGoods Money, reason string) {
+	t.OrderID = order.ID
+	t.CustomerID = order.CustomerID
+	t.ProductID = line.ProductID
+	t.Type = TransactionTypeRefund
+	t.Amount = line.LineTotal
+	t.Discount = line.Discount
+	t.Quantity = line.Quantity
+	t.UnitPrice = line.UnitPrice
+	t.CostOfGoods = costOfGoods
+	t.chargeTax(order, line)
+	t.Description = fmt.Sprintf("Refund for cancelled order %s (%d units)", order.ID, line.Quantity)
+	if reason != "" {
+		t.Description += ": " + reason
+	}
+	t.SetTransactionTime()
+}
+
+// CreateRefundForReturn creates a refund for the returned part of an order
+// costOfGoods is the cost of the units restocked; written-off goods stay in cost of goods sold
+// order is the order the return is against, whose line gives the tax refunded
+func (t *Transaction) CreateRefundForReturn(orderReturn *OrderReturn, order *Order, costOfGoods Money) {
+	t.OrderID = orderReturn.OrderID
+	t.CustomerID = orderReturn.CustomerID
+	t.ProductID = orderReturn.ProductID
+	t.Type = TransactionTypeRefund
+	t.Amount = orderReturn.RefundAmount
+	t.Quantity = orderReturn.Quantity
+	t.UnitPrice = orderReturn.UnitPrice
+	t.CostOfGoods = costOfGoods
+	if line := order.FindLine(orderReturn.ProductID); line != nil {
+		t.chargeTax(order, line)
+	}
+	t.Description = fmt.Sprintf("Refund for return %s (%d units, %s)",
+		orderReturn.ID, orderReturn.Quantity, orderReturn.Disposition)
+	t.SetTransactionTime()
+}
+
+// AllocateToComponent moves a bundle's sale or refund onto one of its
+// components, with the component's units and share of the amount
+func (t *Transaction) AllocateToComponent(productID string, quantity int, amount Money) {
+	t.Description += fmt.Sprintf(" - %d units of %s from bundle %s", quantity, productID, t.ProductID)
+	t.ProductID = productID
+	t.Quantity = quantity
+	if t.Amount.IsPositive() {
+		t.Discount = t.Discount.MulRat(amount.Minor(), t.Amount.Minor())
+		t.Tax = t.Tax.MulRat(amount.Minor(), t.Amount.Minor())
+	}
+	t.Amount = amount
+	t.UnitPrice = amount.Div(quantity)
+}
+
+// chargeTax records the tax of the transaction's units of an order line,
+// their share of the line's tax
+func (t *Transaction) chargeTax(order *Order, line *OrderLine) {
+	t.Tax = line.Tax.MulRat(int64(t.Quantity), int64(line.Quantity))
+	t.TaxRate = line.TaxRate
+	t.TaxClass = line.TaxClass
+	t.TaxJurisdiction = order.TaxJurisdiction
+}
+
+// CreateCreditIssue creates a store credit entry for a customer
+// orderID is optional and links the credit to the order it came from
+func (t *Transaction) CreateCreditIssue(customerID string, amount Money, orderID, description string) {
+	t.OrderID = orderID
+	t.CustomerID = customerID
+	t.Type = TransactionTypeCredit
+	t.Amount = amount
+	t.Description = description
+	t.SetTransactionTime()
+}
+
+// CreateCreditRedemption creates the entry for store credit spent on an order
+func (t *Transaction) CreateCreditRedemption(order *Order) {
+	t.OrderID = order.ID
+	t.CustomerID = order.CustomerID
+	t.Type = TransactionTypeCreditRedemption
+	t.Amount = order.StoreCreditApplied
+	t.Description = fmt.Sprintf("Store credit applied to order %s", order.ID)
+	t.SetTransactionTime()
+}
+
+// WalletStatement is a customer's store credit balance with the ledger entries behind it
+type WalletStatement struct {
+	CustomerID  string         `json:"customer_id"`
+	Balance     Money          `json:"balance"`
+	TotalIssued Money          `json:"total_issued"`
+	TotalSpent  Money          `json:"total_spent"`
+	Entries     []*WalletEntry `json:"entries"`
+}
+
+// WalletEntry is one movement of store credit, oldest first
+type WalletEntry struct {
+	TransactionID  string          `json:"transaction_id"`
+	Type           TransactionType `json:"type"`
+	OrderID        string          `json:"order_id,omitempty"`
+	Amount         Money           `json:"amount"`
+	RunningBalance Money           `json:"running_balance"`
+	Description    string          `json:"description"`
+	TransactionAt  time.Time       `json:"transaction_at"`
+}
+
+// NewWalletStatement builds a statement from wallet transactions in chronological order
+func NewWalletStatement(customerID string, transactions []*Transaction) (*WalletStatement, error) {
+	statement := &WalletStatement{
+		CustomerID: customerID,
+		Entries:    make([]*WalletEntry, 0, len(transactions)),
+	}
+
+	for _, t := range transactions {
+		amount := t.GetCreditAmount()
+
+		var err error
+		if amount.IsPositive() {
+			statement.TotalIssued, err = statement.TotalIssued.Add(amount)
+		} else {
+			statement.TotalSpent, err = statement.TotalSpent.Sub(amount)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("wallet entries must share one currency: %w", err)
+		}
+		if statement.Balance, err = statement.Balance.Add(amount); err != nil {
+			return nil, fmt.Errorf("wallet entries must share one currency: %w", err)
+		}
+
+		statement.Entries = append(statement.Entries, &WalletEntry{
+			TransactionID:  t.ID,
+			Type:           t.Type,
+			OrderID:        t.OrderID,
+			Amount:         amount,
+			RunningBalance: statement.Balance,
+			Description:    t.Description,
+			TransactionAt:  t.TransactionAt,
+		})
+	}
+
+	return statement, nil
+}
+
 // BusinessStats represents business statistics
+// TotalRevenue is net of refunds; GrossRevenue is order value before refunds
+// CostOfGoods is net of goods that went back into stock, and GrossMargin is
+// TotalRevenue less CostOfGoods
 type BusinessStats struct {
-	TotalRevenue       float64        `json:"total_revenue"`
+	TotalRevenue       Money          `json:"total_revenue"`
+	GrossRevenue       Money          `json:"gross_revenue"`
+	RefundedAmount     Money          `json:"refunded_amount"`
+	RefundCount        int            `json:"refund_count"`
 	OrderCount         int            `json:"order_count"`
-	AverageOrderValue  float64        `json:"average_order_value"`
+	AverageOrderValue  Money          `json:"average_order_value"`
 	TotalQuantitySold  int            `json:"total_quantity_sold"`
 	UniqueCustomers    int            `json:"unique_customers"`
+	CostOfGoods        Money          `json:"cost_of_goods"`
+	GrossMargin        Money          `json:"gross_margin"`
+	MarginPercent      float64        `json:"margin_percent"`
 	TopSellingProducts []ProductSales `json:"top_selling_products,omitempty"`
 }
 
 // ProductSales represents sales data for a product
 type ProductSales struct {
-	ProductID    string  `json:"product_id"`
-	ProductName  string  `json:"product_name"`
-	QuantitySold int     `json:"quantity_sold"`
-	TotalRevenue float64 `json:"total_revenue"`
+	ProductID     string  `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	CategoryID    string  `json:"category_id,omitempty"`
+	QuantitySold  int     `json:"quantity_sold"`
+	TotalRevenue  Money   `json:"total_revenue"`
+	CostOfGoods   Money   `json:"cost_of_goods"`
+	GrossMargin   Money   `json:"gross_margin"`
+	MarginPercent float64 `json:"margin_percent"`
+}
+
+// CalculateMargin works out the product's gross margin from its revenue and cost
+func (ps *ProductSales) CalculateMargin() error {
+	margin, percent, err := grossMargin(ps.TotalRevenue, ps.CostOfGoods)
+	if err != nil {
+		return err
+	}
+	ps.GrossMargin, ps.MarginPercent = margin, percent
+	return nil
+}
+
+// CalculateMargin works out the gross margin from net revenue and cost of goods
+func (bs *BusinessStats) CalculateMargin() error {
+	margin, percent, err := grossMargin(bs.TotalRevenue, bs.CostOfGoods)
+	if err != nil {
+		return err
+	}
+	bs.GrossMargin, bs.MarginPercent = margin, percent
+	return nil
+}
+
+// grossMargin returns revenue less cost, and that as a percentage of revenue
+// rounded to two decimal places; the percentage is 0 without revenue
+func grossMargin(revenue, cost Money) (Money, float64, error) {
+	margin, err := revenue.Sub(cost)
+	if err != nil {
+		return Money{}, 0, err
+	}
+	if !revenue.IsPositive() {
+		return margin, 0, nil
+	}
+
+	basisPoints := margin.MulRat(10000, revenue.Minor()).Minor()
+	return margin, float64(basisPoints) / 100, nil
 }
 
-// CalculateAverageOrderValue calculates the average order value
+// CalculateAverageOrderValue calculates the average order value, rounded to the minor unit
 func (bs *BusinessStats) CalculateAverageOrderValue() {
 	if bs.OrderCount > 0 {
-		bs.AverageOrderValue = bs.TotalRevenue / float64(bs.OrderCount)
+		bs.AverageOrderValue = bs.TotalRevenue.Div(bs.OrderCount)
 	} else {
-		bs.AverageOrderValue = 0
+		bs.AverageOrderValue = NewMoney(0, bs.TotalRevenue.Currency())
 	}
 }
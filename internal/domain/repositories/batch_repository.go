@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// BatchRepository defines the contract for the cost batches stock came in as
+// A batch's remaining quantity is the only part that changes after it is created
+type BatchRepository interface {
+	Create(ctx context.Context, batch *entities.InventoryBatch) error
+	// GetOpen gets the batches with units remaining, oldest first, for the given
+	// products or, when none are given, for all products
+	GetOpen(ctx context.Context, productIDs ...string) ([]*entities.InventoryBatch, error)
+	GetByProduct(ctx context.Context, productID string, limit, offset int) ([]*entities.InventoryBatch, error)
+	UpdateRemaining(ctx context.Context, batchID string, remaining int) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// CartRepository defines the contract for shopping cart operations
+// A customer has a single cart, made up of at most one item per product
+type CartRepository interface {
+	// GetItems returns the customer's cart items with their products, oldest first
+	GetItems(ctx context.Context, customerID string) ([]*entities.CartItem, error)
+	GetItem(ctx context.Context, customerID, productID string) (*entities.CartItem, error)
+
+	// SaveItem inserts the item or replaces the quantity of an existing one
+	SaveItem(ctx context.Context, item *entities.CartItem) error
+	RemoveItem(ctx context.Context, customerID, productID string) error
+	Clear(ctx context.Context, customerID string) error
+}
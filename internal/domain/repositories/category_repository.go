@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// CategoryRepository defines the contract for the product category tree
+type CategoryRepository interface {
+	Create(ctx context.Context, category *entities.Category) error
+	GetByID(ctx context.Context, id string) (*entities.Category, error)
+	// GetAll retrieves every category, ordered by path so parents come before their children
+	GetAll(ctx context.Context) ([]*entities.Category, error)
+	// Update saves the category's name and place in the tree; when its path
+	// changed, the paths of its descendants are moved with it
+	Update(ctx context.Context, category *entities.Category, oldPath string) error
+}
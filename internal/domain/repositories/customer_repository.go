@@ -15,6 +15,10 @@ type CustomerRepository interface {
 	Update(ctx context.Context, customer *entities.Customer) error
 	Delete(ctx context.Context, id string) error
 
+	// LockForUpdate holds the customer row until the surrounding unit of work ends,
+	// serializing operations such as store credit spending for one customer
+	LockForUpdate(ctx context.Context, id string) error
+
 	// Business-specific queries
 	SearchByName(ctx context.Context, name string) ([]*entities.Customer, error)
 	GetRecentCustomers(ctx context.Context, days int) ([]*entities.Customer, error)
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"errors"
+)
+
+// Sentinel errors returned by repository implementations
+// Callers should match them with errors.Is rather than comparing messages
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrInsufficientStock is returned when a stock decrement would drive quantity below zero
+	ErrInsufficientStock = errors.New("insufficient stock")
+
+	// ErrVersionConflict is returned when an update was based on a stale version of the record
+	ErrVersionConflict = errors.New("version conflict")
+
+	// ErrAlreadyExists is returned when creating a record whose key is already taken
+	ErrAlreadyExists = errors.New("already exists")
+)
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// ExchangeRateRepository defines the contract for exchange rate operations
+// Rates are never updated or deleted; a new rate supersedes the previous one
+// from its effective date, so past conversions can always be reproduced
+type ExchangeRateRepository interface {
+	Create(ctx context.Context, rate *entities.ExchangeRate) error
+	GetByCurrency(ctx context.Context, currency string) ([]*entities.ExchangeRate, error)
+	GetAll(ctx context.Context) ([]*entities.ExchangeRate, error)
+
+	// GetEffective returns the rate for currency in effect at the given time,
+	// or ErrNotFound if no rate had taken effect yet
+	GetEffective(ctx context.Context, currency string, at time.Time) (*entities.ExchangeRate, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+)
+
+// IDGenerator defines the contract for issuing entity IDs
+// Implementations must never return the same ID twice for a prefix, across
+// restarts and across every instance sharing the database
+type IDGenerator interface {
+	// NewID returns a new unique ID starting with prefix
+	NewID(ctx context.Context, prefix string) (string, error)
+}
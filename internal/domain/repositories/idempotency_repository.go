@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// IdempotencyRepository defines the contract for stored idempotent responses
+type IdempotencyRepository interface {
+	// Create claims a key; it returns ErrAlreadyExists if the key is taken,
+	// which makes it safe for concurrent retries of the same request
+	Create(ctx context.Context, record *entities.IdempotencyRecord) error
+	GetByKey(ctx context.Context, key string) (*entities.IdempotencyRecord, error)
+	Update(ctx context.Context, record *entities.IdempotencyRecord) error
+	Delete(ctx context.Context, key string) error
+
+	// DeleteIfExpired releases key only if it expired at or before now, so a
+	// claim made on the key since it was read is left alone
+	DeleteIfExpired(ctx context.Context, key string, now time.Time) error
+
+	// DeleteExpired removes records that expired at or before the given time
+	// and returns how many were removed
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
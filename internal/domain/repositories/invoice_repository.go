@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// InvoiceRepository defines the contract for invoice operations
+// Invoices are never updated or deleted: each one records what was billed
+// under its number
+type InvoiceRepository interface {
+	// NextSequence allocates the next invoice sequence number. It must run in
+	// the unit of work that creates the invoice, so a rolled-back invoice
+	// gives its number back and the sequence has no gaps
+	NextSequence(ctx context.Context) (int64, error)
+
+	Create(ctx context.Context, invoice *entities.Invoice) error
+
+	// GetByOrderID returns the invoice for an order, or ErrNotFound if the order has none
+	GetByOrderID(ctx context.Context, orderID string) (*entities.Invoice, error)
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// LocationRepository defines the contract for locations and the stock held at them
+// Product totals are kept in step through ProductRepository in the same unit of work
+type LocationRepository interface {
+	// Location operations
+	// Create fails with ErrAlreadyExists when the code is taken
+	Create(ctx context.Context, location *entities.Location) error
+	GetByID(ctx context.Context, id string) (*entities.Location, error)
+	GetAll(ctx context.Context) ([]*entities.Location, error)
+	GetDefault(ctx context.Context) (*entities.Location, error)
+
+	// Stock operations
+	// AdjustStock adds change to the stock of a product at a location; it fails
+	// with ErrInsufficientStock rather than take the location below what is held there
+	AdjustStock(ctx context.Context, locationID, productID string, change int) error
+	// HoldStock sets quantity aside at a location, failing with
+	// ErrInsufficientStock when fewer units there are unheld; ReleaseHold gives them back
+	HoldStock(ctx context.Context, locationID, productID string, quantity int) error
+	ReleaseHold(ctx context.Context, locationID, productID string, quantity int) error
+	GetStock(ctx context.Context, locationID, productID string) (int, error)
+	GetProductStock(ctx context.Context, productIDs ...string) ([]*entities.StockLevel, error)
+	GetLocationStock(ctx context.Context, locationID string) ([]*entities.StockLevel, error)
+
+	// Statistics
+	// UnlocatedStock returns, per product, the part of products.quantity not held at any location
+	UnlocatedStock(ctx context.Context) ([]*entities.StockLevel, error)
+	GetLowStock(ctx context.Context, locationID string, threshold int) ([]*entities.StockLevel, error)
+	GetTotalValue(ctx context.Context, locationID string) (entities.Money, error)
+}
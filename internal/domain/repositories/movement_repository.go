@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// MovementRepository defines the contract for the inventory movement ledger
+// Movements are append-only; they are never updated or deleted
+type MovementRepository interface {
+	Create(ctx context.Context, movement *entities.InventoryMovement) error
+	Find(ctx context.Context, filter MovementFilter) ([]*entities.InventoryMovement, error)
+
+	// GetNetChanges sums, per product, the change of movements with the given
+	// reasons recorded since a point in time
+	GetNetChanges(ctx context.Context, since time.Time, reasons ...entities.MovementReason) (map[string]int, error)
+}
+
+// MovementFilter narrows down movement listings; zero-valued fields are ignored
+type MovementFilter struct {
+	ProductID   string
+	Reason      entities.MovementReason
+	ReferenceID string
+	Limit       int
+	Offset      int
+}
@@ -11,9 +11,16 @@ type OrderRepository interface {
 	// Basic CRUD operations
 	Create(ctx context.Context, order *entities.Order) error
 	GetByID(ctx context.Context, id string) (*entities.Order, error)
+	// LockForUpdate holds the order row until the surrounding unit of work ends,
+	// so cancellation and returns against the same order run one at a time
+	LockForUpdate(ctx context.Context, id string) error
 	GetAll(ctx context.Context, limit, offset int) ([]*entities.Order, error)
 	Update(ctx context.Context, order *entities.Order) error
 	Delete(ctx context.Context, id string) error
+	Find(ctx context.Context, filter OrderFilter) ([]*entities.Order, error)
+
+	// Lifecycle operations
+	UpdateStatus(ctx context.Context, orderID string, change *entities.OrderStatusChange) error
 	
 	// Customer-specific queries
 	GetByCustomerID(ctx context.Context, customerID string, limit, offset int) ([]*entities.Order, error)
@@ -29,10 +36,18 @@ type OrderRepository interface {
 	
 	// Business analytics
 	GetOrdersWithDetails(ctx context.Context, limit, offset int) ([]*entities.Order, error)
-	GetTotalRevenue(ctx context.Context, start, end *time.Time) (float64, error)
+	GetTotalRevenue(ctx context.Context, start, end *time.Time) (entities.Money, error)
 	GetOrderCountByPeriod(ctx context.Context, start, end time.Time) (int, error)
 	
 	// Statistics
 	Count(ctx context.Context) (int, error)
-	GetAverageOrderValue(ctx context.Context) (float64, error)
+	GetAverageOrderValue(ctx context.Context) (entities.Money, error)
+}
+
+// OrderFilter narrows down order listings; zero-valued fields are ignored
+type OrderFilter struct {
+	CustomerID string
+	Status     entities.OrderStatus
+	Limit      int
+	Offset     int
 }
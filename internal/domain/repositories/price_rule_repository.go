@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// PriceRuleRepository defines the contract for price rule operations
+// Rules are never deleted, because order lines record the rule that priced
+// them; a rule that should no longer apply is ended instead
+type PriceRuleRepository interface {
+	Create(ctx context.Context, rule *entities.PriceRule) error
+	GetByID(ctx context.Context, id string) (*entities.PriceRule, error)
+
+	// GetAll lists the rules for a product, or every rule when productID is empty, oldest first
+	GetAll(ctx context.Context, productID string) ([]*entities.PriceRule, error)
+
+	// GetActive lists the rules in effect at the given time that apply to any
+	// of productIDs or to the whole catalogue, oldest first
+	GetActive(ctx context.Context, at time.Time, productIDs ...string) ([]*entities.PriceRule, error)
+
+	// End stores the rule's end time
+	End(ctx context.Context, rule *entities.PriceRule) error
+}
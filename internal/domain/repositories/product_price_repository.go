@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// ProductPriceRepository defines the contract for product price history operations
+// Each product's prices form an unbroken chain: a price is in effect until the
+// next one takes over, so adding or cancelling a price re-links its neighbours
+type ProductPriceRepository interface {
+	// Record adds a price to a product's history, ending the price before it
+	// where it starts and ending it where the next one starts, if any; a price
+	// starting at the same time as another fails with ErrAlreadyExists
+	Record(ctx context.Context, price *entities.ProductPrice) error
+	GetByID(ctx context.Context, id uint) (*entities.ProductPrice, error)
+
+	// GetByProductID lists a product's prices, scheduled ones included, oldest first
+	GetByProductID(ctx context.Context, productID string) ([]*entities.ProductPrice, error)
+
+	// GetDue lists up to limit scheduled prices that took effect at or before
+	// the given time, the earliest first
+	GetDue(ctx context.Context, at time.Time, limit int) ([]*entities.ProductPrice, error)
+
+	// Activate stores the price's activation time; a price that was already
+	// activated or cancelled fails with ErrNotFound
+	Activate(ctx context.Context, price *entities.ProductPrice) error
+
+	// Cancel removes a scheduled price, and the price before it stays in
+	// effect until the one after it; an activated price fails with ErrNotFound
+	Cancel(ctx context.Context, price *entities.ProductPrice) error
+}
@@ -9,6 +9,7 @@ import (
 // This interface belongs to the domain layer but is implemented in infrastructure
 type ProductRepository interface {
 	// Basic CRUD operations
+	// Create and Update fail with ErrAlreadyExists when another product has the SKU or barcode
 	Create(ctx context.Context, product *entities.Product) error
 	GetByID(ctx context.Context, id string) (*entities.Product, error)
 	GetAll(ctx context.Context, limit, offset int) ([]*entities.Product, error)
@@ -17,17 +18,56 @@ type ProductRepository interface {
 
 	// Business-specific queries
 	GetAvailableProducts(ctx context.Context) ([]*entities.Product, error)
-	GetByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*entities.Product, error)
+	// GetStockedProducts gets products with any stock on hand, held by reservations or not
+	GetStockedProducts(ctx context.Context) ([]*entities.Product, error)
+	GetByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money) ([]*entities.Product, error)
 	GetLowStockProducts(ctx context.Context, threshold int) ([]*entities.Product, error)
+	// GetBelowReorderPoint gets products with a reorder point whose available stock is at or below it
+	GetBelowReorderPoint(ctx context.Context) ([]*entities.Product, error)
 
 	// Inventory operations
+	// ReduceQuantity only takes unreserved stock and fails with ErrInsufficientStock otherwise
 	ReduceQuantity(ctx context.Context, productID string, quantity int) error
 	IncreaseQuantity(ctx context.Context, productID string, quantity int) error
+	// UpdateAverageCost stores the weighted average cost; Update leaves it alone
+	UpdateAverageCost(ctx context.Context, productID string, averageCost entities.Money) error
+
+	// Reservation operations keep reserved_quantity in step with active holds
+	// HoldQuantity marks unreserved stock as held; ConfirmHold takes held stock
+	// off hand for an order; ReleaseHold makes held stock available again
+	HoldQuantity(ctx context.Context, productID string, quantity int) error
+	ConfirmHold(ctx context.Context, productID string, quantity int) error
+	ReleaseHold(ctx context.Context, productID string, quantity int) error
 
 	// Search operations
 	SearchByName(ctx context.Context, name string) ([]*entities.Product, error)
+	// Find lists the products matching every condition of the filter, newest first
+	Find(ctx context.Context, filter ProductFilter) ([]*entities.Product, error)
+
+	// Variant operations
+	// GetVariants gets the variants of the given parents, oldest first
+	GetVariants(ctx context.Context, parentIDs ...string) ([]*entities.Product, error)
+	// SyncVariants copies a parent's category and tax class to all of its
+	// variants, and its price to the variants without a price of their own
+	SyncVariants(ctx context.Context, parent *entities.Product) error
 
 	// Statistics
-	GetTotalValue(ctx context.Context) (float64, error)
+	GetTotalValue(ctx context.Context) (entities.Money, error)
 	Count(ctx context.Context) (int, error)
 }
+
+// ProductFilter narrows a product listing; zero fields do not filter
+type ProductFilter struct {
+	// CategoryPath matches products in the category with this path or any category below it
+	CategoryPath string
+	// Attributes must all be set on the product, or for a parent on the parent
+	// or one of its variants; values match case-insensitively
+	Attributes map[string]string
+	// TopLevel leaves out variants, which are listed under their parents
+	TopLevel bool
+	Status   entities.ProductStatus
+	SKU      string
+	Barcode  string
+	Limit    int
+	Offset   int
+}
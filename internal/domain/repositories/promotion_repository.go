@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// PromotionRepository defines the contract for promotion, coupon and redemption operations
+// Promotions are never deleted, because redemptions record what they cost; a
+// promotion that should no longer apply is ended instead
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *entities.Promotion) error
+	GetByID(ctx context.Context, id string) (*entities.Promotion, error)
+
+	// GetAll lists every promotion, oldest first
+	GetAll(ctx context.Context) ([]*entities.Promotion, error)
+
+	// GetAutomatic lists the promotions in effect at the given time that apply
+	// without a coupon, oldest first
+	GetAutomatic(ctx context.Context, at time.Time) ([]*entities.Promotion, error)
+
+	// End stores the promotion's end time
+	End(ctx context.Context, promotion *entities.Promotion) error
+
+	// CreateCoupon stores a coupon; a code that is taken fails with ErrAlreadyExists
+	CreateCoupon(ctx context.Context, coupon *entities.Coupon) error
+	GetCoupon(ctx context.Context, code string) (*entities.Coupon, error)
+
+	// GetCoupons lists a promotion's coupons, oldest first
+	GetCoupons(ctx context.Context, promotionID string) ([]*entities.Coupon, error)
+
+	// RedeemCoupon counts one more use of a coupon with a conditional update;
+	// a coupon that has reached its limit fails with ErrNotFound
+	RedeemCoupon(ctx context.Context, code string) error
+
+	// ReleaseCoupon takes back one use of a coupon
+	ReleaseCoupon(ctx context.Context, code string) error
+
+	// CountCustomerRedemptions counts the orders a customer used a coupon on
+	CountCustomerRedemptions(ctx context.Context, code, customerID string) (int, error)
+
+	RecordRedemption(ctx context.Context, redemption *entities.PromotionRedemption) error
+	GetRedemptionsByOrderID(ctx context.Context, orderID string) ([]*entities.PromotionRedemption, error)
+	DeleteRedemptionsByOrderID(ctx context.Context, orderID string) error
+
+	// GetStats reports the redemptions and discount cost of every promotion
+	// redeemed between start and end, or at any time when both are nil, the
+	// costliest first
+	GetStats(ctx context.Context, start, end *time.Time) ([]*entities.PromotionStats, error)
+}
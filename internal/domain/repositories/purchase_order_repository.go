@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// PurchaseOrderRepository defines the contract for purchase orders and the goods received against them
+type PurchaseOrderRepository interface {
+	// Basic operations; purchase orders are always loaded with their lines
+	Create(ctx context.Context, purchaseOrder *entities.PurchaseOrder) error
+	GetByID(ctx context.Context, id string) (*entities.PurchaseOrder, error)
+	Find(ctx context.Context, filter PurchaseOrderFilter) ([]*entities.PurchaseOrder, error)
+
+	// Update saves the order and its lines if its version has not moved since
+	// it was read, and fails with ErrVersionConflict otherwise
+	Update(ctx context.Context, purchaseOrder *entities.PurchaseOrder) error
+
+	// Receipts are append-only
+	CreateReceipt(ctx context.Context, receipt *entities.GoodsReceipt) error
+	GetReceipts(ctx context.Context, purchaseOrderID string) ([]*entities.GoodsReceipt, error)
+
+	// Business-specific queries
+	// GetOnOrder sums, per product, the units on open purchase orders that have not arrived yet
+	GetOnOrder(ctx context.Context, productIDs ...string) (map[string]int, error)
+	// GetLastUnitCosts returns, per product, the unit cost on the most recently sent purchase order
+	GetLastUnitCosts(ctx context.Context, productIDs ...string) (map[string]entities.Money, error)
+}
+
+// PurchaseOrderFilter narrows down purchase order listings; zero-valued fields are ignored
+type PurchaseOrderFilter struct {
+	SupplierID string
+	Status     entities.PurchaseOrderStatus
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// ReservationRepository defines the contract for stock reservation operations
+// Stock levels are adjusted through ProductRepository in the same unit of work
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *entities.StockReservation) error
+	GetByID(ctx context.Context, id string) (*entities.StockReservation, error)
+	Find(ctx context.Context, filter ReservationFilter) ([]*entities.StockReservation, error)
+
+	// End persists a confirmation, release or expiry; it fails with
+	// ErrVersionConflict if the hold was ended by someone else in the meantime
+	End(ctx context.Context, reservation *entities.StockReservation) error
+
+	// GetExpired returns up to limit holds that are still active but expired at or before the given time
+	GetExpired(ctx context.Context, at time.Time, limit int) ([]*entities.StockReservation, error)
+}
+
+// ReservationFilter narrows down reservation listings; zero-valued fields are ignored
+type ReservationFilter struct {
+	ProductID  string
+	CustomerID string
+	Status     entities.ReservationStatus
+	Limit      int
+	Offset     int
+}
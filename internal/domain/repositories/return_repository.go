@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// ReturnRepository defines the contract for order return (RMA) operations
+type ReturnRepository interface {
+	// Basic operations
+	Create(ctx context.Context, orderReturn *entities.OrderReturn) error
+	GetByID(ctx context.Context, id string) (*entities.OrderReturn, error)
+	Find(ctx context.Context, filter ReturnFilter) ([]*entities.OrderReturn, error)
+
+	// Lifecycle operations
+	// Resolve persists an approval or rejection; it fails with ErrVersionConflict
+	// if the return was resolved by someone else in the meantime
+	Resolve(ctx context.Context, orderReturn *entities.OrderReturn) error
+
+	// Business-specific queries
+	// GetReturnedQuantity sums the quantity of requested and approved returns for one product on an order
+	GetReturnedQuantity(ctx context.Context, orderID, productID string) (int, error)
+	// CountActiveReturns counts the requested and approved returns on an order
+	CountActiveReturns(ctx context.Context, orderID string) (int, error)
+}
+
+// ReturnFilter narrows down return listings; zero-valued fields are ignored
+type ReturnFilter struct {
+	OrderID    string
+	CustomerID string
+	Status     entities.ReturnStatus
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+)
+
+// SupplierRepository defines the contract for supplier data operations
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *entities.Supplier) error
+	GetByID(ctx context.Context, id string) (*entities.Supplier, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*entities.Supplier, error)
+}
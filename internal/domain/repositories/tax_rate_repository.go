@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"day5/internal/domain/entities"
+	"time"
+)
+
+// TaxRateRepository defines the contract for tax rate operations
+// Rates are never updated or deleted; a new rate supersedes the previous one
+// for its jurisdiction and tax class from its effective date, so the tax on
+// past orders can always be explained
+type TaxRateRepository interface {
+	Create(ctx context.Context, rate *entities.TaxRate) error
+
+	// Find returns the rates matching the given jurisdiction and tax class,
+	// either of which may be empty to match any, newest first
+	Find(ctx context.Context, jurisdiction, taxClass string) ([]*entities.TaxRate, error)
+
+	// GetEffective returns the rate for a tax class in a jurisdiction in
+	// effect at the given time, or ErrNotFound if no rate had taken effect yet
+	GetEffective(ctx context.Context, jurisdiction, taxClass string, at time.Time) (*entities.TaxRate, error)
+}
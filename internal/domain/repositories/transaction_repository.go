@@ -28,17 +28,41 @@ type TransactionRepository interface {
 
 	// Business analytics and reporting
 	GetBusinessStats(ctx context.Context, start, end *time.Time) (*entities.BusinessStats, error)
-	GetRevenueByPeriod(ctx context.Context, start, end time.Time) (float64, error)
+	GetRevenueByPeriod(ctx context.Context, start, end time.Time) (entities.Money, error)
 	GetTopSellingProducts(ctx context.Context, limit int, start, end *time.Time) ([]*entities.ProductSales, error)
+	// GetProductSales sums a product's sales net of refunds booked from start
+	// until end, or from start on when end is nil
+	GetProductSales(ctx context.Context, productID string, start time.Time, end *time.Time) (*entities.ProductSales, error)
 	GetCustomerTransactionSummary(ctx context.Context, customerID string) (map[string]any, error)
 
 	// Statistics
 	Count(ctx context.Context) (int, error)
-	GetTotalRevenue(ctx context.Context) (float64, error)
+	GetTotalRevenue(ctx context.Context) (entities.Money, error)
 	GetTransactionCountByType(ctx context.Context, transactionType entities.TransactionType) (int, error)
 
 	// Advanced analytics
 	GetDailyRevenue(ctx context.Context, days int) ([]map[string]any, error)
 	GetMonthlyRevenue(ctx context.Context, months int) ([]map[string]any, error)
 	GetRevenueGrowth(ctx context.Context) (map[string]any, error)
+
+	// Reporting in another currency: revenue split by the exchange rate to
+	// currency that was in effect when each transaction was booked, so every
+	// part can be converted at its historical rate
+	GetRevenueByExchangeRate(ctx context.Context, currency string, groupBy RevenueGrouping, start, end *time.Time) ([]*entities.RevenueAtRate, error)
+
+	// Tax reporting: tax on sales and refunds of taxed products, by
+	// jurisdiction, tax class and rate
+	GetTaxLiability(ctx context.Context, start, end *time.Time) ([]*entities.TaxLiability, error)
+
+	// Store credit (the wallet balance is always derived from these entries)
+	GetCreditBalance(ctx context.Context, customerID string) (entities.Money, error)
+	GetWalletTransactions(ctx context.Context, customerID string) ([]*entities.Transaction, error)
 }
+
+// RevenueGrouping selects how GetRevenueByExchangeRate splits revenue besides by rate
+type RevenueGrouping string
+
+const (
+	RevenueByProduct RevenueGrouping = "product" // Key is the product ID
+	RevenueByDay     RevenueGrouping = "day"     // Key is the date as YYYY-MM-DD
+)
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+)
+
+// UnitOfWork defines the contract for running several repository operations atomically
+// Repositories called with the context passed to fn join the same database transaction
+type UnitOfWork interface {
+	// Do runs fn inside a transaction, committing if it returns nil and rolling back otherwise.
+	// Calls nested inside an active unit of work join the outer transaction.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
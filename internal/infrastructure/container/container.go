@@ -1,12 +1,16 @@
 package container
 
 import (
+	"context"
+	"fmt"
 	"sync"
 
 	"day5/internal/application/usecases"
 	"day5/internal/config"
 	"day5/internal/database"
+	"day5/internal/domain/entities"
 	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/idgen"
 	infraRepo "day5/internal/infrastructure/repositories"
 
 	"gorm.io/gorm"
@@ -24,12 +28,44 @@ type Container struct {
 	cooldownRepo    repositories.CustomerCooldownRepository
 	orderRepo       repositories.OrderRepository
 	transactionRepo repositories.TransactionRepository
+	returnRepo      repositories.ReturnRepository
+	cartRepo        repositories.CartRepository
+	exchangeRepo    repositories.ExchangeRateRepository
+	idempotencyRepo repositories.IdempotencyRepository
+	reservationRepo repositories.ReservationRepository
+	movementRepo    repositories.MovementRepository
+	locationRepo    repositories.LocationRepository
+	supplierRepo    repositories.SupplierRepository
+	purchaseRepo    repositories.PurchaseOrderRepository
+	batchRepo       repositories.BatchRepository
+	categoryRepo    repositories.CategoryRepository
+	priceRuleRepo   repositories.PriceRuleRepository
+	priceRepo       repositories.ProductPriceRepository
+	promotionRepo   repositories.PromotionRepository
+	taxRateRepo     repositories.TaxRateRepository
+	invoiceRepo     repositories.InvoiceRepository
+	unitOfWork      repositories.UnitOfWork
+	idGenerator     repositories.IDGenerator
 
 	// Use Cases (application layer)
 	productUseCase     *usecases.ProductUseCase
 	customerUseCase    *usecases.CustomerUseCase
 	orderUseCase       *usecases.OrderUseCase
 	transactionUseCase *usecases.TransactionUseCase
+	returnUseCase      *usecases.ReturnUseCase
+	walletUseCase      *usecases.WalletUseCase
+	cartUseCase        *usecases.CartUseCase
+	exchangeUseCase    *usecases.ExchangeRateUseCase
+	idempotencyUseCase *usecases.IdempotencyUseCase
+	reservationUseCase *usecases.ReservationUseCase
+	locationUseCase    *usecases.LocationUseCase
+	supplierUseCase    *usecases.SupplierUseCase
+	purchaseUseCase    *usecases.PurchaseOrderUseCase
+	categoryUseCase    *usecases.CategoryUseCase
+	pricingUseCase     *usecases.PricingUseCase
+	promotionUseCase   *usecases.PromotionUseCase
+	taxUseCase         *usecases.TaxUseCase
+	invoiceUseCase     *usecases.InvoiceUseCase
 
 	// Thread safety
 	mu   sync.RWMutex
@@ -46,6 +82,23 @@ func (c *Container) Initialize(cfg *config.AppConfig) error {
 	var err error
 
 	c.once.Do(func() {
+		// Amounts given without a currency are in the configured default currency
+		if cfg.Business.DefaultCurrency != "" {
+			err = entities.ConfigureDefaultCurrency(cfg.Business.DefaultCurrency, cfg.Business.CurrencyPrecision)
+			if err != nil {
+				return
+			}
+		}
+
+		// Customers without a jurisdiction of their own are taxed in the configured one
+		err = entities.ValidateJurisdiction(entities.NormalizeJurisdiction(cfg.Business.TaxJurisdiction))
+		if err != nil {
+			return
+		}
+		if err = entities.ValidateInvoicePrefix(cfg.Invoice.NumberPrefix); err != nil {
+			return
+		}
+
 		// Initialize database
 		c.database, err = database.InitDatabase(&cfg.Database)
 		if err != nil {
@@ -57,9 +110,16 @@ func (c *Container) Initialize(cfg *config.AppConfig) error {
 
 		// Initialize repositories (infrastructure layer)
 		c.initializeRepositories(db)
+		c.initializeUnitOfWork()
+		if err = c.initializeIDGenerator(&cfg.IDs, db); err != nil {
+			return
+		}
 
 		// Initialize use cases (application layer) with repository dependencies
 		c.initializeUseCases(cfg)
+
+		// All stock is held somewhere, at the default location unless moved
+		err = c.locationUseCase.EnsureDefaultLocation(context.Background())
 	})
 
 	return err
@@ -76,6 +136,53 @@ func (c *Container) initializeRepositories(db *gorm.DB) {
 	c.cooldownRepo = infraRepo.NewCustomerCooldownRepository(db)
 	c.orderRepo = infraRepo.NewOrderRepository(db)
 	c.transactionRepo = infraRepo.NewTransactionRepository(db)
+	c.returnRepo = infraRepo.NewReturnRepository(db)
+	c.cartRepo = infraRepo.NewCartRepository(db)
+	c.exchangeRepo = infraRepo.NewExchangeRateRepository(db)
+	c.idempotencyRepo = infraRepo.NewIdempotencyRepository(db)
+	c.reservationRepo = infraRepo.NewReservationRepository(db)
+	c.movementRepo = infraRepo.NewMovementRepository(db)
+	c.locationRepo = infraRepo.NewLocationRepository(db)
+	c.supplierRepo = infraRepo.NewSupplierRepository(db)
+	c.categoryRepo = infraRepo.NewCategoryRepository(db)
+	c.purchaseRepo = infraRepo.NewPurchaseOrderRepository(db)
+	c.batchRepo = infraRepo.NewBatchRepository(db)
+	c.priceRuleRepo = infraRepo.NewPriceRuleRepository(db)
+	c.priceRepo = infraRepo.NewProductPriceRepository(db)
+	c.promotionRepo = infraRepo.NewPromotionRepository(db)
+	c.taxRateRepo = infraRepo.NewTaxRateRepository(db)
+	c.invoiceRepo = infraRepo.NewInvoiceRepository(db)
+}
+
+// initializeUnitOfWork sets up the transaction boundary shared by repositories
+func (c *Container) initializeUnitOfWork() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unitOfWork = infraRepo.NewUnitOfWork(database.NewDatabaseManager(c.database))
+}
+
+// initializeIDGenerator sets up the configured ID generation strategy
+func (c *Container) initializeIDGenerator(cfg *config.IDSettings, db *gorm.DB) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch cfg.Strategy {
+	case config.IDStrategySequence:
+		c.idGenerator = infraRepo.NewSequenceIDGenerator(db)
+	case config.IDStrategySnowflake:
+		generator, err := idgen.NewSnowflakeGenerator(cfg.NodeID)
+		if err != nil {
+			return err
+		}
+		c.idGenerator = generator
+	case config.IDStrategyULID, "":
+		c.idGenerator = idgen.NewULIDGenerator()
+	default:
+		return fmt.Errorf("unsupported ID strategy: %s", cfg.Strategy)
+	}
+
+	return nil
 }
 
 // initializeUseCases sets up all use cases with their dependencies
@@ -84,26 +191,147 @@ func (c *Container) initializeUseCases(cfg *config.AppConfig) {
 	defer c.mu.Unlock()
 
 	// Application layer use cases with injected dependencies
-	c.productUseCase = usecases.NewProductUseCase(c.productRepo)
+	c.productUseCase = usecases.NewProductUseCase(
+		c.productRepo,
+		c.locationRepo,
+		c.movementRepo,
+		c.batchRepo,
+		c.supplierRepo,
+		c.categoryRepo,
+		c.priceRepo,
+		c.unitOfWork,
+		c.idGenerator,
+		entities.ValuationMethod(cfg.Business.ValuationMethod),
+	)
+
+	c.categoryUseCase = usecases.NewCategoryUseCase(c.categoryRepo, c.unitOfWork, c.idGenerator)
+
+	c.supplierUseCase = usecases.NewSupplierUseCase(c.supplierRepo, c.idGenerator)
+
+	c.purchaseUseCase = usecases.NewPurchaseOrderUseCase(
+		c.purchaseRepo,
+		c.supplierRepo,
+		c.productRepo,
+		c.productUseCase,
+		c.movementRepo,
+		c.unitOfWork,
+		c.idGenerator,
+	)
+
+	c.locationUseCase = usecases.NewLocationUseCase(
+		c.locationRepo,
+		c.unitOfWork,
+		c.idGenerator,
+		usecases.FulfilmentStrategy(cfg.Business.FulfilmentStrategy),
+	)
+
+	c.exchangeUseCase = usecases.NewExchangeRateUseCase(c.exchangeRepo)
 
 	c.customerUseCase = usecases.NewCustomerUseCase(
 		c.customerRepo,
 		c.cooldownRepo,
+		c.idGenerator,
 		cfg.Business.CooldownPeriodMinutes,
 	)
 
+	c.pricingUseCase = usecases.NewPricingUseCase(
+		c.priceRuleRepo,
+		c.priceRepo,
+		c.productUseCase,
+		c.customerRepo,
+		c.transactionRepo,
+		c.unitOfWork,
+		c.idGenerator,
+	)
+
+	c.promotionUseCase = usecases.NewPromotionUseCase(
+		c.promotionRepo,
+		c.productUseCase,
+		c.categoryRepo,
+		c.customerRepo,
+		c.idGenerator,
+	)
+
+	c.taxUseCase = usecases.NewTaxUseCase(
+		c.taxRateRepo,
+		cfg.Business.TaxJurisdiction,
+		cfg.Business.PricesIncludeTax,
+	)
+
+	c.invoiceUseCase = usecases.NewInvoiceUseCase(
+		c.invoiceRepo,
+		c.orderRepo,
+		entities.InvoiceParty{
+			Name:    cfg.Invoice.SellerName,
+			Address: cfg.Invoice.SellerAddress,
+			TaxID:   cfg.Invoice.SellerTaxID,
+		},
+		cfg.Invoice.NumberPrefix,
+	)
+
+	c.walletUseCase = usecases.NewWalletUseCase(
+		c.transactionRepo,
+		c.customerRepo,
+		c.unitOfWork,
+		c.idGenerator,
+	)
+
+	c.reservationUseCase = usecases.NewReservationUseCase(
+		c.reservationRepo,
+		c.productUseCase,
+		c.customerRepo,
+		c.unitOfWork,
+		c.idGenerator,
+		cfg.Business.ReservationTTLMinutes,
+	)
+
 	c.orderUseCase = usecases.NewOrderUseCase(
 		c.orderRepo,
 		c.customerUseCase,
 		c.productUseCase,
+		c.walletUseCase,
+		c.exchangeUseCase,
+		c.reservationUseCase,
+		c.locationUseCase,
+		c.pricingUseCase,
+		c.promotionUseCase,
+		c.taxUseCase,
+		c.invoiceUseCase,
 		c.transactionRepo,
+		c.returnRepo,
+		c.unitOfWork,
+		c.idGenerator,
+		cfg.Business.CancellationWindowMinutes,
 	)
 
 	c.transactionUseCase = usecases.NewTransactionUseCase(
 		c.transactionRepo,
 		c.customerRepo,
 		c.productRepo,
+		c.categoryRepo,
+		c.exchangeUseCase,
+	)
+
+	c.returnUseCase = usecases.NewReturnUseCase(
+		c.returnRepo,
+		c.orderRepo,
+		c.productUseCase,
+		c.walletUseCase,
+		c.transactionRepo,
+		c.unitOfWork,
+		c.idGenerator,
+	)
+
+	c.cartUseCase = usecases.NewCartUseCase(
+		c.cartRepo,
+		c.customerUseCase,
+		c.productUseCase,
+		c.orderUseCase,
+		c.pricingUseCase,
+		c.unitOfWork,
 	)
+
+	c.idempotencyUseCase = usecases.NewIdempotencyUseCase(c.idempotencyRepo, cfg.Business.IdempotencyKeyTTLHours)
 }
 
 // Getters for dependencies (thread-safe)
@@ -146,6 +374,108 @@ func (c *Container) GetTransactionRepository() repositories.TransactionRepositor
 	return c.transactionRepo
 }
 
+func (c *Container) GetReturnRepository() repositories.ReturnRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.returnRepo
+}
+
+func (c *Container) GetCartRepository() repositories.CartRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cartRepo
+}
+
+func (c *Container) GetExchangeRateRepository() repositories.ExchangeRateRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.exchangeRepo
+}
+
+func (c *Container) GetIdempotencyRepository() repositories.IdempotencyRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.idempotencyRepo
+}
+
+func (c *Container) GetReservationRepository() repositories.ReservationRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reservationRepo
+}
+
+func (c *Container) GetMovementRepository() repositories.MovementRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.movementRepo
+}
+
+func (c *Container) GetLocationRepository() repositories.LocationRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.locationRepo
+}
+
+func (c *Container) GetSupplierRepository() repositories.SupplierRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.supplierRepo
+}
+
+func (c *Container) GetCategoryRepository() repositories.CategoryRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.categoryRepo
+}
+
+func (c *Container) GetPurchaseOrderRepository() repositories.PurchaseOrderRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.purchaseRepo
+}
+
+func (c *Container) GetBatchRepository() repositories.BatchRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.batchRepo
+}
+
+func (c *Container) GetPriceRuleRepository() repositories.PriceRuleRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.priceRuleRepo
+}
+
+func (c *Container) GetProductPriceRepository() repositories.ProductPriceRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.priceRepo
+}
+
+func (c *Container) GetPromotionRepository() repositories.PromotionRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.promotionRepo
+}
+
+func (c *Container) GetInvoiceRepository() repositories.InvoiceRepository {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.invoiceRepo
+}
+
+func (c *Container) GetUnitOfWork() repositories.UnitOfWork {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.unitOfWork
+}
+
+func (c *Container) GetIDGenerator() repositories.IDGenerator {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.idGenerator
+}
+
 // Use case getters
 func (c *Container) GetProductUseCase() *usecases.ProductUseCase {
 	c.mu.RLock()
@@ -171,6 +501,90 @@ func (c *Container) GetTransactionUseCase() *usecases.TransactionUseCase {
 	return c.transactionUseCase
 }
 
+func (c *Container) GetReturnUseCase() *usecases.ReturnUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.returnUseCase
+}
+
+func (c *Container) GetWalletUseCase() *usecases.WalletUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.walletUseCase
+}
+
+func (c *Container) GetCartUseCase() *usecases.CartUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cartUseCase
+}
+
+func (c *Container) GetExchangeRateUseCase() *usecases.ExchangeRateUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.exchangeUseCase
+}
+
+func (c *Container) GetIdempotencyUseCase() *usecases.IdempotencyUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.idempotencyUseCase
+}
+
+func (c *Container) GetReservationUseCase() *usecases.ReservationUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reservationUseCase
+}
+
+func (c *Container) GetLocationUseCase() *usecases.LocationUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.locationUseCase
+}
+
+func (c *Container) GetSupplierUseCase() *usecases.SupplierUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.supplierUseCase
+}
+
+func (c *Container) GetCategoryUseCase() *usecases.CategoryUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.categoryUseCase
+}
+
+func (c *Container) GetPurchaseOrderUseCase() *usecases.PurchaseOrderUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.purchaseUseCase
+}
+
+func (c *Container) GetPricingUseCase() *usecases.PricingUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pricingUseCase
+}
+
+func (c *Container) GetPromotionUseCase() *usecases.PromotionUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.promotionUseCase
+}
+
+func (c *Container) GetTaxUseCase() *usecases.TaxUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.taxUseCase
+}
+
+func (c *Container) GetInvoiceUseCase() *usecases.InvoiceUseCase {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.invoiceUseCase
+}
+
 // Cleanup closes all resources
 func (c *Container) Cleanup() error {
 	c.mu.Lock()
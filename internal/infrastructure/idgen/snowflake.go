@@ -0,0 +1,75 @@
+package idgen
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"day5/internal/domain/repositories"
+)
+
+// Snowflake layout: 41 bits of milliseconds since snowflakeEpoch, 10 bits of
+// node ID and 12 bits of per-millisecond sequence
+const (
+	snowflakeEpoch = int64(1704067200000) // 2024-01-01T00:00:00Z
+	nodeBits       = 10
+	sequenceBits   = 12
+	MaxNodeID      = int64(1<<nodeBits - 1)
+	maxSequence    = int64(1<<sequenceBits - 1)
+)
+
+// SnowflakeGenerator issues prefixed Snowflake IDs rendered in decimal
+// Every instance sharing a database must be configured with its own node ID
+type SnowflakeGenerator struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	node     int64
+	lastMS   int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator creates a Snowflake generator for the given node
+func NewSnowflakeGenerator(node int64) (repositories.IDGenerator, error) {
+	if node < 0 || node > MaxNodeID {
+		return nil, fmt.Errorf("snowflake node ID must be between 0 and %d: %d", MaxNodeID, node)
+	}
+
+	return &SnowflakeGenerator{
+		now:  time.Now,
+		node: node,
+	}, nil
+}
+
+// NewID returns prefix followed by a new Snowflake ID
+func (g *SnowflakeGenerator) NewID(ctx context.Context, prefix string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().UnixMilli() - snowflakeEpoch
+	if ms < g.lastMS {
+		// The clock went backwards: keep counting in the last millisecond seen
+		ms = g.lastMS
+	}
+
+	if ms == g.lastMS {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			// Sequence exhausted for this millisecond: wait for the next one
+			for ms <= g.lastMS {
+				if err := ctx.Err(); err != nil {
+					return "", err
+				}
+				time.Sleep(100 * time.Microsecond)
+				ms = g.now().UnixMilli() - snowflakeEpoch
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMS = ms
+
+	id := ms<<(nodeBits+sequenceBits) | g.node<<sequenceBits | g.sequence
+	return prefix + strconv.FormatInt(id, 10), nil
+}
@@ -0,0 +1,106 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"day5/internal/domain/repositories"
+)
+
+// crockford is the Crockford base32 alphabet used to encode ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLength is the number of characters in an encoded ULID
+const ulidLength = 26
+
+// errULIDOverflow is returned when more IDs are requested in one millisecond than the random part can count
+var errULIDOverflow = errors.New("ulid random component overflow")
+
+// ULIDGenerator issues prefixed ULIDs: a 48-bit millisecond timestamp followed
+// by 80 random bits, 26 characters in Crockford base32
+// IDs from one generator sort in creation order, even within a millisecond
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entropy io.Reader
+	lastMS  uint64
+	lastRnd [10]byte
+}
+
+// NewULIDGenerator creates a ULID generator backed by crypto/rand
+func NewULIDGenerator() repositories.IDGenerator {
+	return &ULIDGenerator{
+		now:     time.Now,
+		entropy: rand.Reader,
+	}
+}
+
+// NewID returns prefix followed by a new ULID
+func (g *ULIDGenerator) NewID(ctx context.Context, prefix string) (string, error) {
+	id, err := g.next()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ULID: %w", err)
+	}
+	return prefix + id, nil
+}
+
+// next returns the next ULID, incrementing the random part when the clock has not moved on
+func (g *ULIDGenerator) next() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms <= g.lastMS {
+		// Same millisecond, or the clock went backwards: stay monotonic
+		ms = g.lastMS
+		if !incrementBytes(g.lastRnd[:]) {
+			return "", errULIDOverflow
+		}
+	} else {
+		if _, err := io.ReadFull(g.entropy, g.lastRnd[:]); err != nil {
+			return "", err
+		}
+		g.lastMS = ms
+	}
+
+	var raw [16]byte
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(ms >> (8 * (5 - i)))
+	}
+	copy(raw[6:], g.lastRnd[:])
+
+	return encodeULID(raw), nil
+}
+
+// incrementBytes adds one to b as a big-endian number, reporting false on overflow
+func incrementBytes(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID renders 128 bits as 26 Crockford base32 characters, most significant first
+func encodeULID(raw [16]byte) string {
+	var hi, lo uint64
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(raw[i])
+		lo = lo<<8 | uint64(raw[i+8])
+	}
+
+	var out [ulidLength]byte
+	for i := ulidLength - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,197 @@
+// Package pdf writes simple PDF documents of text and lines without any
+// dependency outside the standard library
+// Documents use the standard Helvetica fonts every PDF reader provides, so no
+// fonts are embedded; text is encoded as WinAnsi and characters outside it are
+// printed as "?"
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Font is one of the standard fonts a document can use
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+// fonts are the PDF names of the fonts, in the order of their resource names F1, F2, ...
+var fonts = []string{"Helvetica", "Helvetica-Bold"}
+
+// Document is a PDF being laid out page by page
+// Coordinates are in points from the top-left corner of the page, and y is
+// the baseline of text
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+}
+
+// New creates an empty document with the given title
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage starts a new page; drawing goes to it until the next page is added
+func (d *Document) AddPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+}
+
+// PageCount returns the number of pages added so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// EachPage calls fn for every page with its number, from 1, while drawing goes to that page
+// It is meant for headers and footers that need the number of pages
+func (d *Document) EachPage(fn func(page int)) {
+	current := d.page
+	for i, page := range d.pages {
+		d.page = page
+		fn(i + 1)
+	}
+	d.page = current
+}
+
+// Text draws text starting at x
+func (d *Document) Text(x, y float64, font Font, size float64, text string) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		font+1, number(size), number(x), number(PageHeight-y), escape(encode(text)))
+}
+
+// TextRight draws text ending at x
+func (d *Document) TextRight(x, y float64, font Font, size float64, text string) {
+	d.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// Line draws a straight line of the given width
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "%s w %s %s m %s %s l S\n",
+		number(width), number(x1), number(PageHeight-y1), number(x2), number(PageHeight-y2))
+}
+
+// FillRect fills a rectangle whose top-left corner is at x, y in a shade of
+// grey, from 0 (black) to 1 (white)
+func (d *Document) FillRect(x, y, width, height, grey float64) {
+	if d.page == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.page, "%s g %s %s %s %s re f 0 g\n",
+		number(grey), number(x), number(PageHeight-y-height), number(width), number(height))
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects are numbered 1: catalog, 2: page tree, 3: info, then the
+	// fonts, then a page object and its content stream for every page
+	firstFont := 4
+	firstPage := firstFont + len(fonts)
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object(fmt.Sprintf("<< /Title (%s) /Producer (day5) >>", escape(encode(d.title))))
+
+	resources := make([]string, len(fonts))
+	for i, font := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+		resources[i] = fmt.Sprintf("/F%d %d 0 R", i+1, firstFont+i)
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			number(PageWidth), number(PageHeight), strings.Join(resources, " "), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", page.Len(), page.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+// number renders a coordinate or size with at most two decimal places
+func number(n float64) string {
+	return strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64)
+}
+
+// winAnsi maps the characters WinAnsi encodes differently from Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode converts text to WinAnsi, replacing characters it cannot encode with "?"
+func encode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		case winAnsi[r] != 0:
+			encoded = append(encoded, winAnsi[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// escape renders encoded text as the inside of a PDF literal string
+func escape(text []byte) string {
+	var b strings.Builder
+	for _, c := range text {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,251 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"day5/internal/domain/entities"
+)
+
+// Invoice layout, in points
+const (
+	margin       = 40.0
+	right        = PageWidth - margin
+	pageTop      = 50.0
+	pageBottom   = PageHeight - 60
+	tableSize    = 8.5
+	detailSize   = 7.0
+	rowHeight    = 14.0
+	detailHeight = 10.0
+)
+
+// invoiceColumn is a column of the invoice's line table; numbers are right-aligned at x
+type invoiceColumn struct {
+	title string
+	x     float64
+	left  bool
+}
+
+var invoiceColumns = []invoiceColumn{
+	{title: "#", x: margin, left: true},
+	{title: "Item", x: margin + 20, left: true},
+	{title: "Qty", x: 240},
+	{title: "Unit price", x: 295},
+	{title: "Discount", x: 345},
+	{title: "Taxable", x: 400},
+	{title: "Rate", x: 440},
+	{title: "Tax", x: 495},
+	{title: "Total", x: right},
+}
+
+// itemWidth is how wide an item name may be before it is cut short
+const itemWidth = 240 - 30 - (margin + 20)
+
+// RenderInvoice lays an invoice out as an A4 PDF
+func RenderInvoice(invoice *entities.Invoice) ([]byte, error) {
+	r := &invoiceRenderer{
+		doc:     New("Invoice " + invoice.Number),
+		invoice: invoice,
+	}
+	r.render()
+
+	var out bytes.Buffer
+	if _, err := r.doc.WriteTo(&out); err != nil {
+		return nil, fmt.Errorf("failed to render invoice %s: %w", invoice.Number, err)
+	}
+	return out.Bytes(), nil
+}
+
+// invoiceRenderer keeps track of where the next row goes while an invoice is laid out
+type invoiceRenderer struct {
+	doc     *Document
+	invoice *entities.Invoice
+	y       float64
+}
+
+func (r *invoiceRenderer) render() {
+	r.doc.AddPage()
+	r.header()
+	r.parties()
+
+	r.tableHeader()
+	for _, line := range r.invoice.Lines {
+		r.line(line)
+	}
+	r.totals()
+
+	pages := r.doc.PageCount()
+	r.doc.EachPage(func(page int) {
+		footer := fmt.Sprintf("Invoice %s · Page %d of %d", r.invoice.Number, page, pages)
+		r.doc.Line(margin, PageHeight-45, right, PageHeight-45, 0.5)
+		r.doc.TextRight(right, PageHeight-32, Helvetica, detailSize, footer)
+	})
+}
+
+// header prints the title and what identifies the invoice
+func (r *invoiceRenderer) header() {
+	title := "INVOICE"
+	if r.invoice.IsTaxInvoice() {
+		title = "TAX INVOICE"
+	}
+	r.doc.Text(margin, pageTop+6, HelveticaBold, 18, title)
+
+	r.doc.TextRight(right, pageTop-4, HelveticaBold, 10, "Invoice "+r.invoice.Number)
+	r.doc.TextRight(right, pageTop+9, Helvetica, 9, "Date: "+r.invoice.IssuedAt.Format("2 Jan 2006"))
+	r.doc.TextRight(right, pageTop+22, Helvetica, 9, "Order: "+r.invoice.OrderID)
+	r.y = pageTop + 50
+}
+
+// parties prints the seller on the left and the customer on the right
+func (r *invoiceRenderer) parties() {
+	top := r.y
+	left := top
+	if seller := r.invoice.Seller; seller.Name != "" {
+		left = r.party(margin, top, "Sold by", seller.Name, append(
+			strings.Split(strings.TrimSpace(seller.Address), "\n"),
+			labelled("Tax ID", seller.TaxID),
+		))
+	}
+
+	customer := r.invoice.Customer
+	bottom := r.party(PageWidth/2+20, top, "Bill to", customer.Name, []string{
+		customer.Email,
+		customer.Phone,
+		labelled("Customer ID", customer.ID),
+		labelled("Place of supply", r.invoice.TaxJurisdiction),
+	})
+
+	r.y = max(left, bottom) + 16
+}
+
+// party prints a labelled block of name and details at x, skipping empty
+// details, and returns where the block ends
+func (r *invoiceRenderer) party(x, y float64, label, name string, details []string) float64 {
+	width := PageWidth/2 - margin - 20
+	r.doc.Text(x, y, HelveticaBold, 8, strings.ToUpper(label))
+	y += 14
+	r.doc.Text(x, y, HelveticaBold, 10, Truncate(HelveticaBold, 10, width, name))
+	for _, detail := range details {
+		if detail = strings.TrimSpace(detail); detail == "" {
+			continue
+		}
+		y += 12
+		r.doc.Text(x, y, Helvetica, 9, Truncate(Helvetica, 9, width, detail))
+	}
+	return y
+}
+
+// tableHeader prints the column titles of the line table
+func (r *invoiceRenderer) tableHeader() {
+	r.doc.FillRect(margin, r.y, right-margin, 16, 0.9)
+	for _, column := range invoiceColumns {
+		if column.left {
+			r.doc.Text(column.x+2, r.y+11, HelveticaBold, tableSize, column.title)
+		} else {
+			r.doc.TextRight(column.x-2, r.y+11, HelveticaBold, tableSize, column.title)
+		}
+	}
+	r.y += 16 + rowHeight
+}
+
+// line prints one invoice line, with its SKU and tax breakdown beneath it
+func (r *invoiceRenderer) line(line *entities.InvoiceLine) {
+	var details []string
+	if line.SKU != "" {
+		details = append(details, "SKU "+line.SKU)
+	}
+	for _, tax := range line.Taxes {
+		details = append(details, fmt.Sprintf("%s %s%% %s", tax.Name, tax.Rate, tax.Amount.Amount()))
+	}
+
+	height := rowHeight
+	if len(details) > 0 {
+		height += detailHeight
+	}
+	if r.y+height > pageBottom {
+		r.doc.AddPage()
+		r.y = pageTop
+		r.tableHeader()
+	}
+
+	rate, tax := "-", "-"
+	if line.TaxClass != "" {
+		rate, tax = line.TaxRate.String()+"%", line.Tax.Amount()
+	}
+	cells := []string{
+		fmt.Sprint(line.LineNumber),
+		Truncate(Helvetica, tableSize, itemWidth, line.ProductName),
+		fmt.Sprint(line.Quantity),
+		line.UnitPrice.Amount(),
+		line.Discount.Amount(),
+		line.TaxableAmount.Amount(),
+		rate,
+		tax,
+		line.LineTotal.Amount(),
+	}
+	for i, column := range invoiceColumns {
+		if column.left {
+			r.doc.Text(column.x+2, r.y, Helvetica, tableSize, cells[i])
+		} else {
+			r.doc.TextRight(column.x-2, r.y, Helvetica, tableSize, cells[i])
+		}
+	}
+
+	if len(details) > 0 {
+		r.y += detailHeight
+		detail := Truncate(Helvetica, detailSize, right-margin-22, strings.Join(details, " · "))
+		r.doc.Text(margin+22, r.y, Helvetica, detailSize, detail)
+	}
+	r.y += rowHeight - 6
+	r.doc.Line(margin, r.y, right, r.y, 0.25)
+	r.y += rowHeight
+}
+
+// totals prints what the invoice comes to, with the tax by component and rate
+func (r *invoiceRenderer) totals() {
+	invoice := r.invoice
+	rows := [][2]string{{"Taxable amount", invoice.Subtotal.Amount()}}
+	if invoice.Discount.IsPositive() {
+		rows = append(rows, [2]string{"Discounts given", invoice.Discount.Amount()})
+	}
+	for _, tax := range invoice.TaxSummary {
+		label := fmt.Sprintf("%s @ %s%% on %s", tax.Name, tax.Rate, tax.TaxableAmount.Amount())
+		rows = append(rows, [2]string{label, tax.Amount.Amount()})
+	}
+	rows = append(rows, [2]string{"Total tax", invoice.Tax.Amount()})
+
+	height := float64(len(rows))*rowHeight + 3*rowHeight
+	if r.y+height > pageBottom {
+		r.doc.AddPage()
+		r.y = pageTop
+	}
+
+	labelX := right - 90
+	for _, row := range rows {
+		r.doc.TextRight(labelX, r.y, Helvetica, 9, row[0])
+		r.doc.TextRight(right, r.y, Helvetica, 9, row[1])
+		r.y += rowHeight
+	}
+
+	r.doc.Line(labelX-150, r.y-8, right, r.y-8, 0.75)
+	r.y += 4
+	r.doc.TextRight(labelX, r.y, HelveticaBold, 11, fmt.Sprintf("Total (%s)", invoice.Total.Currency()))
+	r.doc.TextRight(right, r.y, HelveticaBold, 11, invoice.Total.Amount())
+	r.y += rowHeight * 1.5
+
+	for _, line := range invoice.Lines {
+		if line.TaxInclusive {
+			r.doc.Text(margin, r.y, Helvetica, detailSize, "Prices include tax.")
+			break
+		}
+	}
+}
+
+// labelled renders a detail with its label, or nothing when the detail is empty
+func labelled(label, detail string) string {
+	if detail == "" {
+		return ""
+	}
+	return label + ": " + detail
+}
@@ -1,6 +1,9 @@
 package persistence
 
 import (
+	"slices"
+	"strings"
+
 	"day5/internal/domain/entities"
 )
 
@@ -12,14 +15,52 @@ func ProductToModel(entity *entities.Product) *Product {
 		return nil
 	}
 
-	return &Product{
-		ID:          entity.ID,
-		ProductName: entity.ProductName,
-		Price:       entity.Price,
-		Quantity:    entity.Quantity,
-		CreatedAt:   entity.CreatedAt,
-		UpdatedAt:   entity.UpdatedAt,
+	model := &Product{
+		ID:               entity.ID,
+		ProductName:      entity.ProductName,
+		PriceMinor:       entity.Price.Minor(),
+		Currency:         entity.Price.Currency(),
+		Quantity:         entity.Quantity,
+		AverageCostMinor: entity.AverageCost.Minor(),
+		Reserved:         entity.Reserved,
+		ReorderPoint:     entity.ReorderPoint,
+		ReorderQuantity:  entity.ReorderQuantity,
+		LeadTimeDays:     entity.LeadTimeDays,
+		SupplierID:       nullableID(entity.SupplierID),
+		SKU:              nullableID(entity.SKU),
+		Barcode:          nullableID(entity.Barcode),
+		Description:      entity.Description,
+		CategoryID:       nullableID(entity.CategoryID),
+		Status:           string(entity.Status),
+		TaxClass:         entity.TaxClass,
+		Type:             string(entity.Type),
+		ParentID:         nullableID(entity.ParentID),
+		PriceOverride:    entity.PriceOverride,
+		Version:          entity.Version,
+		CreatedAt:        entity.CreatedAt,
+		UpdatedAt:        entity.UpdatedAt,
+	}
+	if model.Status == "" {
+		model.Status = string(entities.ProductStatusActive)
+	}
+	if model.Type == "" {
+		model.Type = string(entities.ProductTypeSimple)
 	}
+	for name, value := range entity.Attributes {
+		model.Attributes = append(model.Attributes, ProductAttribute{ProductID: entity.ID, Name: name, Value: value})
+	}
+	// Stored in a fixed order so writes are repeatable
+	slices.SortFunc(model.Attributes, func(a, b ProductAttribute) int { return strings.Compare(a.Name, b.Name) })
+	for _, component := range entity.Components {
+		model.Components = append(model.Components, BundleComponent{
+			BundleID:       entity.ID,
+			ProductID:      component.ProductID,
+			Quantity:       component.Quantity,
+			ListPriceMinor: component.ListPrice.Minor(),
+			Currency:       component.ListPrice.Currency(),
+		})
+	}
+	return model
 }
 
 // ModelToProduct converts persistence model to domain entity
@@ -30,8 +71,44 @@ func ModelToProduct(model *Product, entity *entities.Product) {
 
 	entity.ID = model.ID
 	entity.ProductName = model.ProductName
-	entity.Price = model.Price
+	entity.Price = entities.NewMoney(model.PriceMinor, model.Currency)
 	entity.Quantity = model.Quantity
+	entity.AverageCost = entities.NewMoney(model.AverageCostMinor, model.Currency)
+	entity.Reserved = model.Reserved
+	entity.ReorderPoint = model.ReorderPoint
+	entity.ReorderQuantity = model.ReorderQuantity
+	entity.LeadTimeDays = model.LeadTimeDays
+	entity.SupplierID = idValue(model.SupplierID)
+	entity.SKU = idValue(model.SKU)
+	entity.Barcode = idValue(model.Barcode)
+	entity.Description = model.Description
+	entity.CategoryID = idValue(model.CategoryID)
+	entity.Status = entities.ProductStatus(model.Status)
+	entity.TaxClass = model.TaxClass
+	entity.Type = entities.ProductType(model.Type)
+	entity.ParentID = idValue(model.ParentID)
+	entity.PriceOverride = model.PriceOverride
+	entity.Attributes = nil
+	if len(model.Attributes) > 0 {
+		entity.Attributes = make(map[string]string, len(model.Attributes))
+		for _, attribute := range model.Attributes {
+			entity.Attributes[attribute.Name] = attribute.Value
+		}
+	}
+	entity.Components = nil
+	for _, component := range model.Components {
+		bundled := &entities.BundleComponent{
+			ProductID: component.ProductID,
+			Quantity:  component.Quantity,
+			ListPrice: entities.NewMoney(component.ListPriceMinor, component.Currency),
+		}
+		if component.Product != nil {
+			bundled.Product = &entities.Product{}
+			ModelToProduct(component.Product, bundled.Product)
+		}
+		entity.Components = append(entity.Components, bundled)
+	}
+	entity.Version = model.Version
 	entity.CreatedAt = model.CreatedAt
 	entity.UpdatedAt = model.UpdatedAt
 }
@@ -44,14 +121,21 @@ func CustomerToModel(entity *entities.Customer) *Customer {
 		return nil
 	}
 
-	return &Customer{
-		ID:        entity.ID,
-		Name:      entity.Name,
-		Email:     entity.Email,
-		Phone:     entity.Phone,
-		CreatedAt: entity.CreatedAt,
-		UpdatedAt: entity.UpdatedAt,
+	model := &Customer{
+		ID:              entity.ID,
+		Name:            entity.Name,
+		Email:           entity.Email,
+		Phone:           entity.Phone,
+		PriceList:       string(entity.PriceList),
+		TaxJurisdiction: entity.TaxJurisdiction,
+		Version:         entity.Version,
+		CreatedAt:       entity.CreatedAt,
+		UpdatedAt:       entity.UpdatedAt,
+	}
+	if model.PriceList == "" {
+		model.PriceList = string(entities.PriceListRetail)
 	}
+	return model
 }
 
 // ModelToCustomer converts persistence model to domain entity
@@ -64,6 +148,9 @@ func ModelToCustomer(model *Customer, entity *entities.Customer) {
 	entity.Name = model.Name
 	entity.Email = model.Email
 	entity.Phone = model.Phone
+	entity.PriceList = entities.PriceList(model.PriceList)
+	entity.TaxJurisdiction = model.TaxJurisdiction
+	entity.Version = model.Version
 	entity.CreatedAt = model.CreatedAt
 	entity.UpdatedAt = model.UpdatedAt
 }
@@ -77,15 +164,28 @@ func OrderToModel(entity *entities.Order) *Order {
 	}
 
 	return &Order{
-		ID:          entity.ID,
-		CustomerID:  entity.CustomerID,
-		ProductID:   entity.ProductID,
-		Quantity:    entity.Quantity,
-		UnitPrice:   entity.UnitPrice,
-		TotalAmount: entity.TotalAmount,
-		OrderDate:   entity.OrderDate,
-		CreatedAt:   entity.CreatedAt,
-		UpdatedAt:   entity.UpdatedAt,
+		ID:               entity.ID,
+		CustomerID:       entity.CustomerID,
+		ProductID:        nullableID(entity.ProductID),
+		Quantity:         entity.Quantity,
+		UnitPriceMinor:   entity.UnitPrice.Minor(),
+		TotalAmountMinor: entity.TotalAmount.Minor(),
+		StoreCreditMinor: entity.StoreCreditApplied.Minor(),
+		DiscountMinor:    entity.Discount.Minor(),
+		CouponCode:       nullableID(entity.CouponCode),
+		TaxMinor:         entity.Tax.Minor(),
+		TaxJurisdiction:  entity.TaxJurisdiction,
+		Currency:         entity.TotalAmount.Currency(),
+		ChargeCurrency:   entity.ChargeCurrency,
+		ExchangeRate:     entity.ExchangeRate.Scaled(),
+		ChargedMinor:     entity.ChargedAmount.Minor(),
+		LocationID:       nullableID(entity.LocationID),
+		Lines:            OrderLinesToModels(entity.OrderLines()),
+		Status:           string(entity.Status),
+		OrderDate:        entity.OrderDate,
+		CreatedAt:        entity.CreatedAt,
+		UpdatedAt:        entity.UpdatedAt,
+		StatusHistory:    StatusChangesToModels(entity.StatusHistory),
 	}
 }
 
@@ -97,10 +197,25 @@ func ModelToOrder(model *Order, entity *entities.Order) {
 
 	entity.ID = model.ID
 	entity.CustomerID = model.CustomerID
-	entity.ProductID = model.ProductID
+	entity.ProductID = idValue(model.ProductID)
 	entity.Quantity = model.Quantity
-	entity.UnitPrice = model.UnitPrice
-	entity.TotalAmount = model.TotalAmount
+	entity.UnitPrice = entities.NewMoney(model.UnitPriceMinor, model.Currency)
+	entity.TotalAmount = entities.NewMoney(model.TotalAmountMinor, model.Currency)
+	entity.StoreCreditApplied = entities.NewMoney(model.StoreCreditMinor, model.Currency)
+	entity.Discount = entities.NewMoney(model.DiscountMinor, model.Currency)
+	entity.CouponCode = idValue(model.CouponCode)
+	entity.Tax = entities.NewMoney(model.TaxMinor, model.Currency)
+	entity.TaxJurisdiction = model.TaxJurisdiction
+	if model.ChargeCurrency != "" {
+		entity.ChargeCurrency = model.ChargeCurrency
+		entity.ExchangeRate = entities.NewRateFromScaled(model.ExchangeRate)
+		entity.ChargedAmount = entities.NewMoney(model.ChargedMinor, model.ChargeCurrency)
+	} else {
+		// Orders from before charge currencies were paid in the order currency
+		entity.ChargeIn(model.Currency, entities.IdentityRate)
+	}
+	entity.LocationID = idValue(model.LocationID)
+	entity.Status = entities.OrderStatus(model.Status)
 	entity.OrderDate = model.OrderDate
 	entity.CreatedAt = model.CreatedAt
 	entity.UpdatedAt = model.UpdatedAt
@@ -115,6 +230,164 @@ func ModelToOrder(model *Order, entity *entities.Order) {
 		entity.Product = &entities.Product{}
 		ModelToProduct(&model.Product, entity.Product)
 	}
+
+	if len(model.Lines) > 0 {
+		entity.Lines = ModelsToOrderLines(model.Lines)
+	}
+
+	if len(model.StatusHistory) > 0 {
+		entity.StatusHistory = ModelsToStatusChanges(model.StatusHistory)
+	}
+}
+
+// OrderLine conversions
+
+// OrderLineToModel converts domain entity to persistence model
+func OrderLineToModel(entity *entities.OrderLine) *OrderLine {
+	if entity == nil {
+		return nil
+	}
+
+	model := &OrderLine{
+		OrderID:           entity.OrderID,
+		LineNumber:        entity.LineNumber,
+		ProductID:         entity.ProductID,
+		Quantity:          entity.Quantity,
+		UnitPriceMinor:    entity.UnitPrice.Minor(),
+		LineTotalMinor:    entity.LineTotal.Minor(),
+		DiscountMinor:     entity.Discount.Minor(),
+		Currency:          entity.UnitPrice.Currency(),
+		ListPriceMinor:    entity.ListPrice.Minor(),
+		PriceRuleID:       nullableID(entity.PriceRuleID),
+		TaxClass:          entity.TaxClass,
+		TaxRateHundredths: entity.TaxRate.Hundredths(),
+		TaxInclusive:      entity.TaxInclusive,
+		TaxMinor:          entity.Tax.Minor(),
+	}
+	for i, tax := range entity.Taxes {
+		model.Taxes = append(model.Taxes, OrderLineTax{
+			OrderID:        entity.OrderID,
+			LineNumber:     entity.LineNumber,
+			Position:       i + 1,
+			Name:           tax.Name,
+			RateHundredths: tax.Rate.Hundredths(),
+			AmountMinor:    tax.Amount.Minor(),
+			Currency:       tax.Amount.Currency(),
+		})
+	}
+	return model
+}
+
+// ModelToOrderLine converts persistence model to domain entity
+func ModelToOrderLine(model *OrderLine, entity *entities.OrderLine) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.OrderID = model.OrderID
+	entity.LineNumber = model.LineNumber
+	entity.ProductID = model.ProductID
+	entity.Quantity = model.Quantity
+	entity.UnitPrice = entities.NewMoney(model.UnitPriceMinor, model.Currency)
+	entity.LineTotal = entities.NewMoney(model.LineTotalMinor, model.Currency)
+	entity.Discount = entities.NewMoney(model.DiscountMinor, model.Currency)
+	entity.ListPrice = entity.UnitPrice
+	if model.ListPriceMinor > 0 {
+		entity.ListPrice = entities.NewMoney(model.ListPriceMinor, model.Currency)
+	}
+	entity.PriceRuleID = idValue(model.PriceRuleID)
+	entity.TaxClass = model.TaxClass
+	entity.TaxRate = entities.NewPercentFromHundredths(model.TaxRateHundredths)
+	entity.TaxInclusive = model.TaxInclusive
+	entity.Tax = entities.NewMoney(model.TaxMinor, model.Currency)
+	entity.TaxableAmount = entities.NewMoney(model.LineTotalMinor-model.TaxMinor, model.Currency)
+	entity.Taxes = nil
+	for _, tax := range model.Taxes {
+		entity.Taxes = append(entity.Taxes, &entities.LineTax{
+			Name:   tax.Name,
+			Rate:   entities.NewPercentFromHundredths(tax.RateHundredths),
+			Amount: entities.NewMoney(tax.AmountMinor, tax.Currency),
+		})
+	}
+	entity.ProductName = model.Product.ProductName
+}
+
+// OrderLinesToModels converts slice of entities to slice of models
+func OrderLinesToModels(lines []*entities.OrderLine) []OrderLine {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	models := make([]OrderLine, len(lines))
+	for i, line := range lines {
+		models[i] = *OrderLineToModel(line)
+	}
+	return models
+}
+
+// ModelsToOrderLines converts slice of models to slice of entities
+func ModelsToOrderLines(models []OrderLine) []*entities.OrderLine {
+	lines := make([]*entities.OrderLine, len(models))
+	for i, model := range models {
+		lines[i] = &entities.OrderLine{}
+		ModelToOrderLine(&model, lines[i])
+	}
+	return lines
+}
+
+// OrderStatusHistory conversions
+
+// StatusChangeToModel converts domain entity to persistence model
+func StatusChangeToModel(entity *entities.OrderStatusChange) *OrderStatusHistory {
+	if entity == nil {
+		return nil
+	}
+
+	return &OrderStatusHistory{
+		OrderID:    entity.OrderID,
+		FromStatus: string(entity.FromStatus),
+		ToStatus:   string(entity.ToStatus),
+		Actor:      entity.Actor,
+		Reason:     entity.Reason,
+		ChangedAt:  entity.ChangedAt,
+	}
+}
+
+// ModelToStatusChange converts persistence model to domain entity
+func ModelToStatusChange(model *OrderStatusHistory, entity *entities.OrderStatusChange) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.OrderID = model.OrderID
+	entity.FromStatus = entities.OrderStatus(model.FromStatus)
+	entity.ToStatus = entities.OrderStatus(model.ToStatus)
+	entity.Actor = model.Actor
+	entity.Reason = model.Reason
+	entity.ChangedAt = model.ChangedAt
+}
+
+// StatusChangesToModels converts slice of entities to slice of models
+func StatusChangesToModels(changes []*entities.OrderStatusChange) []OrderStatusHistory {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	models := make([]OrderStatusHistory, len(changes))
+	for i, change := range changes {
+		models[i] = *StatusChangeToModel(change)
+	}
+	return models
+}
+
+// ModelsToStatusChanges converts slice of models to slice of entities
+func ModelsToStatusChanges(models []OrderStatusHistory) []*entities.OrderStatusChange {
+	changes := make([]*entities.OrderStatusChange, len(models))
+	for i, model := range models {
+		changes[i] = &entities.OrderStatusChange{}
+		ModelToStatusChange(&model, changes[i])
+	}
+	return changes
 }
 
 // Transaction conversions
@@ -126,17 +399,24 @@ func TransactionToModel(entity *entities.Transaction) *Transaction {
 	}
 
 	return &Transaction{
-		ID:            entity.ID,
-		OrderID:       entity.OrderID,
-		CustomerID:    entity.CustomerID,
-		ProductID:     entity.ProductID,
-		Type:          string(entity.Type),
-		Amount:        entity.Amount,
-		Quantity:      entity.Quantity,
-		UnitPrice:     entity.UnitPrice,
-		Description:   entity.Description,
-		TransactionAt: entity.TransactionAt,
-		CreatedAt:     entity.CreatedAt,
+		ID:                entity.ID,
+		OrderID:           nullableID(entity.OrderID),
+		CustomerID:        entity.CustomerID,
+		ProductID:         nullableID(entity.ProductID),
+		Type:              string(entity.Type),
+		AmountMinor:       entity.Amount.Minor(),
+		Quantity:          entity.Quantity,
+		UnitPriceMinor:    entity.UnitPrice.Minor(),
+		CostOfGoodsMinor:  entity.CostOfGoods.Minor(),
+		DiscountMinor:     entity.Discount.Minor(),
+		TaxMinor:          entity.Tax.Minor(),
+		TaxRateHundredths: entity.TaxRate.Hundredths(),
+		TaxClass:          entity.TaxClass,
+		TaxJurisdiction:   entity.TaxJurisdiction,
+		Currency:          entity.Amount.Currency(),
+		Description:       entity.Description,
+		TransactionAt:     entity.TransactionAt,
+		CreatedAt:         entity.CreatedAt,
 	}
 }
 
@@ -147,13 +427,19 @@ func ModelToTransaction(model *Transaction, entity *entities.Transaction) {
 	}
 
 	entity.ID = model.ID
-	entity.OrderID = model.OrderID
+	entity.OrderID = idValue(model.OrderID)
 	entity.CustomerID = model.CustomerID
-	entity.ProductID = model.ProductID
+	entity.ProductID = idValue(model.ProductID)
 	entity.Type = entities.TransactionType(model.Type)
-	entity.Amount = model.Amount
+	entity.Amount = entities.NewMoney(model.AmountMinor, model.Currency)
 	entity.Quantity = model.Quantity
-	entity.UnitPrice = model.UnitPrice
+	entity.UnitPrice = entities.NewMoney(model.UnitPriceMinor, model.Currency)
+	entity.CostOfGoods = entities.NewMoney(model.CostOfGoodsMinor, model.Currency)
+	entity.Discount = entities.NewMoney(model.DiscountMinor, model.Currency)
+	entity.Tax = entities.NewMoney(model.TaxMinor, model.Currency)
+	entity.TaxRate = entities.NewPercentFromHundredths(model.TaxRateHundredths)
+	entity.TaxClass = model.TaxClass
+	entity.TaxJurisdiction = model.TaxJurisdiction
 	entity.Description = model.Description
 	entity.TransactionAt = model.TransactionAt
 	entity.CreatedAt = model.CreatedAt
@@ -175,6 +461,61 @@ func ModelToTransaction(model *Transaction, entity *entities.Transaction) {
 	}
 }
 
+// OrderReturn conversions
+
+// ReturnToModel converts domain entity to persistence model
+func ReturnToModel(entity *entities.OrderReturn) *OrderReturn {
+	if entity == nil {
+		return nil
+	}
+
+	return &OrderReturn{
+		ID:                  entity.ID,
+		OrderID:             entity.OrderID,
+		CustomerID:          entity.CustomerID,
+		ProductID:           entity.ProductID,
+		Quantity:            entity.Quantity,
+		UnitPriceMinor:      entity.UnitPrice.Minor(),
+		RefundAmountMinor:   entity.RefundAmount.Minor(),
+		Currency:            entity.RefundAmount.Currency(),
+		Reason:              entity.Reason,
+		Status:              string(entity.Status),
+		Disposition:         string(entity.Disposition),
+		RefundTransactionID: entity.RefundTransactionID,
+		ResolvedBy:          entity.ResolvedBy,
+		ResolutionNote:      entity.ResolutionNote,
+		ResolvedAt:          entity.ResolvedAt,
+		RequestedAt:         entity.RequestedAt,
+		CreatedAt:           entity.CreatedAt,
+		UpdatedAt:           entity.UpdatedAt,
+	}
+}
+
+// ModelToReturn converts persistence model to domain entity
+func ModelToReturn(model *OrderReturn, entity *entities.OrderReturn) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.OrderID = model.OrderID
+	entity.CustomerID = model.CustomerID
+	entity.ProductID = model.ProductID
+	entity.Quantity = model.Quantity
+	entity.UnitPrice = entities.NewMoney(model.UnitPriceMinor, model.Currency)
+	entity.RefundAmount = entities.NewMoney(model.RefundAmountMinor, model.Currency)
+	entity.Reason = model.Reason
+	entity.Status = entities.ReturnStatus(model.Status)
+	entity.Disposition = entities.ReturnDisposition(model.Disposition)
+	entity.RefundTransactionID = model.RefundTransactionID
+	entity.ResolvedBy = model.ResolvedBy
+	entity.ResolutionNote = model.ResolutionNote
+	entity.ResolvedAt = model.ResolvedAt
+	entity.RequestedAt = model.RequestedAt
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+}
+
 // CustomerCooldown conversions
 
 // CooldownToModel converts domain entity to persistence model
@@ -201,6 +542,69 @@ func ModelToCooldown(model *CustomerCooldown, entity *entities.CustomerCooldown)
 	entity.UpdatedAt = model.UpdatedAt
 }
 
+// Optional reference helpers
+
+// CartItem conversions
+
+// CartItemToModel converts domain entity to persistence model
+func CartItemToModel(entity *entities.CartItem) *CartItem {
+	if entity == nil {
+		return nil
+	}
+
+	return &CartItem{
+		CustomerID: entity.CustomerID,
+		ProductID:  entity.ProductID,
+		Quantity:   entity.Quantity,
+		CreatedAt:  entity.CreatedAt,
+		UpdatedAt:  entity.UpdatedAt,
+	}
+}
+
+// ModelToCartItem converts persistence model to domain entity
+func ModelToCartItem(model *CartItem, entity *entities.CartItem) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.CustomerID = model.CustomerID
+	entity.ProductID = model.ProductID
+	entity.Quantity = model.Quantity
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+
+	if model.Product.ID != "" {
+		entity.Product = &entities.Product{}
+		ModelToProduct(&model.Product, entity.Product)
+	}
+}
+
+// ModelsToCartItems converts slice of models to slice of entities
+func ModelsToCartItems(models []CartItem) []*entities.CartItem {
+	items := make([]*entities.CartItem, len(models))
+	for i, model := range models {
+		items[i] = &entities.CartItem{}
+		ModelToCartItem(&model, items[i])
+	}
+	return items
+}
+
+// nullableID maps an empty entity ID to a NULL column
+func nullableID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+// idValue maps a NULL column back to an empty entity ID
+func idValue(id *string) string {
+	if id == nil {
+		return ""
+	}
+	return *id
+}
+
 // Batch conversion helpers
 
 // ModelsToProducts converts slice of models to slice of entities
@@ -233,6 +637,16 @@ func ModelsToOrders(models []Order) []*entities.Order {
 	return orders
 }
 
+// ModelsToReturns converts slice of models to slice of entities
+func ModelsToReturns(models []OrderReturn) []*entities.OrderReturn {
+	returns := make([]*entities.OrderReturn, len(models))
+	for i, model := range models {
+		returns[i] = &entities.OrderReturn{}
+		ModelToReturn(&model, returns[i])
+	}
+	return returns
+}
+
 // ModelsToTransactions converts slice of models to slice of entities
 func ModelsToTransactions(models []Transaction) []*entities.Transaction {
 	transactions := make([]*entities.Transaction, len(models))
@@ -242,3 +656,926 @@ func ModelsToTransactions(models []Transaction) []*entities.Transaction {
 	}
 	return transactions
 }
+
+// ExchangeRate conversions
+
+// ExchangeRateToModel converts domain entity to persistence model
+func ExchangeRateToModel(entity *entities.ExchangeRate) *ExchangeRate {
+	if entity == nil {
+		return nil
+	}
+
+	return &ExchangeRate{
+		ID:            entity.ID,
+		BaseCurrency:  entity.BaseCurrency,
+		Currency:      entity.Currency,
+		Rate:          entity.Rate.Scaled(),
+		EffectiveFrom: entity.EffectiveFrom,
+		CreatedAt:     entity.CreatedAt,
+	}
+}
+
+// ModelToExchangeRate converts persistence model to domain entity
+func ModelToExchangeRate(model *ExchangeRate, entity *entities.ExchangeRate) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.BaseCurrency = model.BaseCurrency
+	entity.Currency = model.Currency
+	entity.Rate = entities.NewRateFromScaled(model.Rate)
+	entity.EffectiveFrom = model.EffectiveFrom
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToExchangeRates converts a slice of exchange rate models to entities
+func ModelsToExchangeRates(models []ExchangeRate) []*entities.ExchangeRate {
+	rates := make([]*entities.ExchangeRate, len(models))
+	for i, model := range models {
+		rates[i] = &entities.ExchangeRate{}
+		ModelToExchangeRate(&model, rates[i])
+	}
+	return rates
+}
+
+// TaxRate conversions
+
+// TaxRateToModel converts domain entity to persistence model
+func TaxRateToModel(entity *entities.TaxRate) *TaxRate {
+	if entity == nil {
+		return nil
+	}
+
+	model := &TaxRate{
+		ID:             entity.ID,
+		Jurisdiction:   entity.Jurisdiction,
+		TaxClass:       entity.TaxClass,
+		RateHundredths: entity.Rate.Hundredths(),
+		EffectiveFrom:  entity.EffectiveFrom,
+		CreatedAt:      entity.CreatedAt,
+	}
+	for i, component := range entity.Components {
+		model.Components = append(model.Components, TaxRateComponent{
+			TaxRateID:      entity.ID,
+			Position:       i + 1,
+			Name:           component.Name,
+			RateHundredths: component.Rate.Hundredths(),
+		})
+	}
+	return model
+}
+
+// ModelToTaxRate converts persistence model to domain entity
+func ModelToTaxRate(model *TaxRate, entity *entities.TaxRate) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.Jurisdiction = model.Jurisdiction
+	entity.TaxClass = model.TaxClass
+	entity.Rate = entities.NewPercentFromHundredths(model.RateHundredths)
+	entity.Components = make([]*entities.TaxComponent, len(model.Components))
+	for i, component := range model.Components {
+		entity.Components[i] = &entities.TaxComponent{
+			Name: component.Name,
+			Rate: entities.NewPercentFromHundredths(component.RateHundredths),
+		}
+	}
+	entity.EffectiveFrom = model.EffectiveFrom
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToTaxRates converts a slice of tax rate models to entities
+func ModelsToTaxRates(models []TaxRate) []*entities.TaxRate {
+	rates := make([]*entities.TaxRate, len(models))
+	for i, model := range models {
+		rates[i] = &entities.TaxRate{}
+		ModelToTaxRate(&model, rates[i])
+	}
+	return rates
+}
+
+// Invoice conversions
+
+// InvoiceToModel converts domain entity to persistence model
+func InvoiceToModel(entity *entities.Invoice) *Invoice {
+	if entity == nil {
+		return nil
+	}
+
+	model := &Invoice{
+		Number:                  entity.Number,
+		Sequence:                entity.Sequence,
+		OrderID:                 entity.OrderID,
+		IssuedAt:                entity.IssuedAt,
+		SellerName:              entity.Seller.Name,
+		SellerAddress:           entity.Seller.Address,
+		SellerTaxID:             entity.Seller.TaxID,
+		CustomerID:              entity.Customer.ID,
+		CustomerName:            entity.Customer.Name,
+		CustomerEmail:           entity.Customer.Email,
+		CustomerPhone:           entity.Customer.Phone,
+		CustomerTaxJurisdiction: entity.Customer.TaxJurisdiction,
+		TaxJurisdiction:         entity.TaxJurisdiction,
+		SubtotalMinor:           entity.Subtotal.Minor(),
+		DiscountMinor:           entity.Discount.Minor(),
+		TaxMinor:                entity.Tax.Minor(),
+		TotalMinor:              entity.Total.Minor(),
+		Currency:                entity.Total.Currency(),
+	}
+	for _, line := range entity.Lines {
+		lineModel := InvoiceLine{
+			InvoiceNumber:     entity.Number,
+			LineNumber:        line.LineNumber,
+			ProductID:         line.ProductID,
+			SKU:               line.SKU,
+			ProductName:       line.ProductName,
+			Quantity:          line.Quantity,
+			UnitPriceMinor:    line.UnitPrice.Minor(),
+			DiscountMinor:     line.Discount.Minor(),
+			TaxableMinor:      line.TaxableAmount.Minor(),
+			TaxClass:          line.TaxClass,
+			TaxRateHundredths: line.TaxRate.Hundredths(),
+			TaxInclusive:      line.TaxInclusive,
+			TaxMinor:          line.Tax.Minor(),
+			LineTotalMinor:    line.LineTotal.Minor(),
+			Currency:          line.LineTotal.Currency(),
+		}
+		for i, tax := range line.Taxes {
+			lineModel.Taxes = append(lineModel.Taxes, InvoiceLineTax{
+				InvoiceNumber:  entity.Number,
+				LineNumber:     line.LineNumber,
+				Position:       i + 1,
+				Name:           tax.Name,
+				RateHundredths: tax.Rate.Hundredths(),
+				AmountMinor:    tax.Amount.Minor(),
+				Currency:       tax.Amount.Currency(),
+			})
+		}
+		model.Lines = append(model.Lines, lineModel)
+	}
+	return model
+}
+
+// ModelToInvoice converts persistence model to domain entity
+func ModelToInvoice(model *Invoice, entity *entities.Invoice) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.Number = model.Number
+	entity.Sequence = model.Sequence
+	entity.OrderID = model.OrderID
+	entity.IssuedAt = model.IssuedAt
+	entity.Seller = entities.InvoiceParty{
+		Name:    model.SellerName,
+		Address: model.SellerAddress,
+		TaxID:   model.SellerTaxID,
+	}
+	entity.Customer = entities.InvoiceParty{
+		ID:              model.CustomerID,
+		Name:            model.CustomerName,
+		Email:           model.CustomerEmail,
+		Phone:           model.CustomerPhone,
+		TaxJurisdiction: model.CustomerTaxJurisdiction,
+	}
+	entity.TaxJurisdiction = model.TaxJurisdiction
+	entity.Subtotal = entities.NewMoney(model.SubtotalMinor, model.Currency)
+	entity.Discount = entities.NewMoney(model.DiscountMinor, model.Currency)
+	entity.Tax = entities.NewMoney(model.TaxMinor, model.Currency)
+	entity.Total = entities.NewMoney(model.TotalMinor, model.Currency)
+
+	entity.Lines = make([]*entities.InvoiceLine, len(model.Lines))
+	for i, line := range model.Lines {
+		entity.Lines[i] = &entities.InvoiceLine{
+			LineNumber:    line.LineNumber,
+			ProductID:     line.ProductID,
+			SKU:           line.SKU,
+			ProductName:   line.ProductName,
+			Quantity:      line.Quantity,
+			UnitPrice:     entities.NewMoney(line.UnitPriceMinor, line.Currency),
+			Discount:      entities.NewMoney(line.DiscountMinor, line.Currency),
+			TaxableAmount: entities.NewMoney(line.TaxableMinor, line.Currency),
+			TaxClass:      line.TaxClass,
+			TaxRate:       entities.NewPercentFromHundredths(line.TaxRateHundredths),
+			TaxInclusive:  line.TaxInclusive,
+			Tax:           entities.NewMoney(line.TaxMinor, line.Currency),
+			LineTotal:     entities.NewMoney(line.LineTotalMinor, line.Currency),
+		}
+		for _, tax := range line.Taxes {
+			entity.Lines[i].Taxes = append(entity.Lines[i].Taxes, &entities.LineTax{
+				Name:   tax.Name,
+				Rate:   entities.NewPercentFromHundredths(tax.RateHundredths),
+				Amount: entities.NewMoney(tax.AmountMinor, tax.Currency),
+			})
+		}
+	}
+	entity.Summarize()
+}
+
+// PriceRule conversions
+
+// PriceRuleToModel converts domain entity to persistence model
+func PriceRuleToModel(entity *entities.PriceRule) *PriceRule {
+	if entity == nil {
+		return nil
+	}
+
+	return &PriceRule{
+		ID:                 entity.ID,
+		Name:               entity.Name,
+		PriceList:          string(entity.PriceList),
+		ProductID:          nullableID(entity.ProductID),
+		MinQuantity:        entity.MinQuantity,
+		PriceMinor:         entity.Price.Minor(),
+		Currency:           entity.Price.Currency(),
+		DiscountHundredths: entity.DiscountPercent.Hundredths(),
+		StartsAt:           entity.StartsAt,
+		EndsAt:             entity.EndsAt,
+		CreatedAt:          entity.CreatedAt,
+	}
+}
+
+// ModelToPriceRule converts persistence model to domain entity
+func ModelToPriceRule(model *PriceRule, entity *entities.PriceRule) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.Name = model.Name
+	entity.PriceList = entities.PriceList(model.PriceList)
+	entity.ProductID = idValue(model.ProductID)
+	entity.MinQuantity = model.MinQuantity
+	entity.Price = entities.NewMoney(model.PriceMinor, model.Currency)
+	entity.DiscountPercent = entities.NewPercentFromHundredths(model.DiscountHundredths)
+	entity.StartsAt = model.StartsAt
+	entity.EndsAt = model.EndsAt
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToPriceRules converts a slice of price rule models to entities
+func ModelsToPriceRules(models []PriceRule) []*entities.PriceRule {
+	rules := make([]*entities.PriceRule, len(models))
+	for i, model := range models {
+		rules[i] = &entities.PriceRule{}
+		ModelToPriceRule(&model, rules[i])
+	}
+	return rules
+}
+
+// ProductPrice conversions
+
+// ProductPriceToModel converts domain entity to persistence model
+func ProductPriceToModel(entity *entities.ProductPrice) *ProductPrice {
+	if entity == nil {
+		return nil
+	}
+
+	return &ProductPrice{
+		ID:            entity.ID,
+		ProductID:     entity.ProductID,
+		PriceMinor:    entity.Price.Minor(),
+		Currency:      entity.Price.Currency(),
+		EffectiveFrom: entity.EffectiveFrom,
+		EffectiveTo:   entity.EffectiveTo,
+		ActivatedAt:   entity.ActivatedAt,
+		Actor:         entity.Actor,
+		CreatedAt:     entity.CreatedAt,
+	}
+}
+
+// ModelToProductPrice converts persistence model to domain entity
+func ModelToProductPrice(model *ProductPrice, entity *entities.ProductPrice) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.ProductID = model.ProductID
+	entity.Price = entities.NewMoney(model.PriceMinor, model.Currency)
+	entity.EffectiveFrom = model.EffectiveFrom
+	entity.EffectiveTo = model.EffectiveTo
+	entity.ActivatedAt = model.ActivatedAt
+	entity.Actor = model.Actor
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToProductPrices converts a slice of product price models to entities
+func ModelsToProductPrices(models []ProductPrice) []*entities.ProductPrice {
+	prices := make([]*entities.ProductPrice, len(models))
+	for i, model := range models {
+		prices[i] = &entities.ProductPrice{}
+		ModelToProductPrice(&model, prices[i])
+	}
+	return prices
+}
+
+// Promotion conversions
+
+// PromotionToModel converts domain entity to persistence model
+func PromotionToModel(entity *entities.Promotion) *Promotion {
+	if entity == nil {
+		return nil
+	}
+
+	return &Promotion{
+		ID:                 entity.ID,
+		Name:               entity.Name,
+		Type:               string(entity.Type),
+		DiscountHundredths: entity.DiscountPercent.Hundredths(),
+		AmountMinor:        entity.Amount.Minor(),
+		Currency:           entity.Amount.Currency(),
+		BuyQuantity:        entity.BuyQuantity,
+		GetQuantity:        entity.GetQuantity,
+		ProductID:          nullableID(entity.ProductID),
+		CategoryID:         nullableID(entity.CategoryID),
+		CouponOnly:         entity.CouponOnly,
+		StartsAt:           entity.StartsAt,
+		EndsAt:             entity.EndsAt,
+		CreatedAt:          entity.CreatedAt,
+	}
+}
+
+// ModelToPromotion converts persistence model to domain entity
+func ModelToPromotion(model *Promotion, entity *entities.Promotion) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.Name = model.Name
+	entity.Type = entities.PromotionType(model.Type)
+	entity.DiscountPercent = entities.NewPercentFromHundredths(model.DiscountHundredths)
+	entity.Amount = entities.NewMoney(model.AmountMinor, model.Currency)
+	entity.BuyQuantity = model.BuyQuantity
+	entity.GetQuantity = model.GetQuantity
+	entity.ProductID = idValue(model.ProductID)
+	entity.CategoryID = idValue(model.CategoryID)
+	entity.CouponOnly = model.CouponOnly
+	entity.StartsAt = model.StartsAt
+	entity.EndsAt = model.EndsAt
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToPromotions converts a slice of promotion models to entities
+func ModelsToPromotions(models []Promotion) []*entities.Promotion {
+	promotions := make([]*entities.Promotion, len(models))
+	for i, model := range models {
+		promotions[i] = &entities.Promotion{}
+		ModelToPromotion(&model, promotions[i])
+	}
+	return promotions
+}
+
+// CouponToModel converts domain entity to persistence model
+func CouponToModel(entity *entities.Coupon) *Coupon {
+	if entity == nil {
+		return nil
+	}
+
+	return &Coupon{
+		Code:           entity.Code,
+		PromotionID:    entity.PromotionID,
+		MaxRedemptions: entity.MaxRedemptions,
+		MaxPerCustomer: entity.MaxPerCustomer,
+		Redemptions:    entity.Redemptions,
+		ExpiresAt:      entity.ExpiresAt,
+		CreatedAt:      entity.CreatedAt,
+	}
+}
+
+// ModelToCoupon converts persistence model to domain entity
+func ModelToCoupon(model *Coupon, entity *entities.Coupon) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.Code = model.Code
+	entity.PromotionID = model.PromotionID
+	entity.MaxRedemptions = model.MaxRedemptions
+	entity.MaxPerCustomer = model.MaxPerCustomer
+	entity.Redemptions = model.Redemptions
+	entity.ExpiresAt = model.ExpiresAt
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToCoupons converts a slice of coupon models to entities
+func ModelsToCoupons(models []Coupon) []*entities.Coupon {
+	coupons := make([]*entities.Coupon, len(models))
+	for i, model := range models {
+		coupons[i] = &entities.Coupon{}
+		ModelToCoupon(&model, coupons[i])
+	}
+	return coupons
+}
+
+// RedemptionToModel converts domain entity to persistence model
+func RedemptionToModel(entity *entities.PromotionRedemption) *PromotionRedemption {
+	if entity == nil {
+		return nil
+	}
+
+	return &PromotionRedemption{
+		ID:            entity.ID,
+		PromotionID:   entity.PromotionID,
+		CouponCode:    nullableID(entity.CouponCode),
+		OrderID:       entity.OrderID,
+		CustomerID:    entity.CustomerID,
+		DiscountMinor: entity.Discount.Minor(),
+		Currency:      entity.Discount.Currency(),
+		RedeemedAt:    entity.RedeemedAt,
+	}
+}
+
+// ModelToRedemption converts persistence model to domain entity
+func ModelToRedemption(model *PromotionRedemption, entity *entities.PromotionRedemption) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.PromotionID = model.PromotionID
+	entity.CouponCode = idValue(model.CouponCode)
+	entity.OrderID = model.OrderID
+	entity.CustomerID = model.CustomerID
+	entity.Discount = entities.NewMoney(model.DiscountMinor, model.Currency)
+	entity.RedeemedAt = model.RedeemedAt
+}
+
+// ModelsToRedemptions converts a slice of redemption models to entities
+func ModelsToRedemptions(models []PromotionRedemption) []*entities.PromotionRedemption {
+	redemptions := make([]*entities.PromotionRedemption, len(models))
+	for i, model := range models {
+		redemptions[i] = &entities.PromotionRedemption{}
+		ModelToRedemption(&model, redemptions[i])
+	}
+	return redemptions
+}
+
+// IdempotencyRecordToModel converts domain entity to persistence model
+func IdempotencyRecordToModel(entity *entities.IdempotencyRecord) *IdempotencyKey {
+	if entity == nil {
+		return nil
+	}
+
+	return &IdempotencyKey{
+		Key:         entity.Key,
+		Fingerprint: entity.Fingerprint,
+		StatusCode:  entity.StatusCode,
+		ContentType: entity.ContentType,
+		Body:        entity.Body,
+		CreatedAt:   entity.CreatedAt,
+		ExpiresAt:   entity.ExpiresAt,
+	}
+}
+
+// ModelToIdempotencyRecord converts persistence model to domain entity
+func ModelToIdempotencyRecord(model *IdempotencyKey, entity *entities.IdempotencyRecord) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.Key = model.Key
+	entity.Fingerprint = model.Fingerprint
+	entity.StatusCode = model.StatusCode
+	entity.ContentType = model.ContentType
+	entity.Body = model.Body
+	entity.CreatedAt = model.CreatedAt
+	entity.ExpiresAt = model.ExpiresAt
+}
+
+// Stock reservation conversions
+
+// ReservationToModel converts domain entity to persistence model
+func ReservationToModel(entity *entities.StockReservation) *StockReservation {
+	if entity == nil {
+		return nil
+	}
+
+	return &StockReservation{
+		ID:         entity.ID,
+		ProductID:  entity.ProductID,
+		CustomerID: nullableID(entity.CustomerID),
+		Quantity:   entity.Quantity,
+		Status:     string(entity.Status),
+		ExpiresAt:  entity.ExpiresAt,
+		OrderID:    nullableID(entity.OrderID),
+		LocationID: nullableID(entity.LocationID),
+		CreatedAt:  entity.CreatedAt,
+		UpdatedAt:  entity.UpdatedAt,
+	}
+}
+
+// ModelToReservation converts persistence model to domain entity
+func ModelToReservation(model *StockReservation, entity *entities.StockReservation) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.ProductID = model.ProductID
+	entity.CustomerID = idValue(model.CustomerID)
+	entity.Quantity = model.Quantity
+	entity.Status = entities.ReservationStatus(model.Status)
+	entity.ExpiresAt = model.ExpiresAt
+	entity.OrderID = idValue(model.OrderID)
+	entity.LocationID = idValue(model.LocationID)
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+}
+
+// ModelsToReservations converts a slice of reservation models to entities
+func ModelsToReservations(models []StockReservation) []*entities.StockReservation {
+	reservations := make([]*entities.StockReservation, len(models))
+	for i, model := range models {
+		reservations[i] = &entities.StockReservation{}
+		ModelToReservation(&model, reservations[i])
+	}
+	return reservations
+}
+
+// MovementToModel converts domain entity to persistence model
+func MovementToModel(entity *entities.InventoryMovement) *InventoryMovement {
+	if entity == nil {
+		return nil
+	}
+
+	return &InventoryMovement{
+		ID:             entity.ID,
+		ProductID:      entity.ProductID,
+		LocationID:     nullableID(entity.LocationID),
+		Reason:         string(entity.Reason),
+		Change:         entity.Change,
+		QuantityBefore: entity.QuantityBefore,
+		QuantityAfter:  entity.QuantityAfter,
+		Actor:          entity.Actor,
+		ReferenceID:    entity.ReferenceID,
+		Note:           entity.Note,
+		CostMinor:      entity.Cost.Minor(),
+		Currency:       entity.Cost.Currency(),
+		CreatedAt:      entity.CreatedAt,
+	}
+}
+
+// ModelToMovement converts persistence model to domain entity
+func ModelToMovement(model *InventoryMovement, entity *entities.InventoryMovement) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.ProductID = model.ProductID
+	entity.LocationID = idValue(model.LocationID)
+	entity.Reason = entities.MovementReason(model.Reason)
+	entity.Change = model.Change
+	entity.QuantityBefore = model.QuantityBefore
+	entity.QuantityAfter = model.QuantityAfter
+	entity.Actor = model.Actor
+	entity.ReferenceID = model.ReferenceID
+	entity.Note = model.Note
+	entity.Cost = entities.NewMoney(model.CostMinor, model.Currency)
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToMovements converts a slice of movement models to entities
+func ModelsToMovements(models []InventoryMovement) []*entities.InventoryMovement {
+	movements := make([]*entities.InventoryMovement, len(models))
+	for i, model := range models {
+		movements[i] = &entities.InventoryMovement{}
+		ModelToMovement(&model, movements[i])
+	}
+	return movements
+}
+
+// BatchToModel converts domain entity to persistence model
+func BatchToModel(entity *entities.InventoryBatch) *InventoryBatch {
+	if entity == nil {
+		return nil
+	}
+
+	return &InventoryBatch{
+		ID:            entity.ID,
+		ProductID:     entity.ProductID,
+		ReferenceID:   entity.ReferenceID,
+		Quantity:      entity.Quantity,
+		Remaining:     entity.Remaining,
+		UnitCostMinor: entity.UnitCost.Minor(),
+		Currency:      entity.UnitCost.Currency(),
+		CreatedAt:     entity.CreatedAt,
+	}
+}
+
+// ModelToBatch converts persistence model to domain entity
+func ModelToBatch(model *InventoryBatch, entity *entities.InventoryBatch) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.ProductID = model.ProductID
+	entity.ReferenceID = model.ReferenceID
+	entity.Quantity = model.Quantity
+	entity.Remaining = model.Remaining
+	entity.UnitCost = entities.NewMoney(model.UnitCostMinor, model.Currency)
+	entity.CreatedAt = model.CreatedAt
+}
+
+// ModelsToBatches converts a slice of batch models to entities
+func ModelsToBatches(models []InventoryBatch) []*entities.InventoryBatch {
+	batches := make([]*entities.InventoryBatch, len(models))
+	for i, model := range models {
+		batches[i] = &entities.InventoryBatch{}
+		ModelToBatch(&model, batches[i])
+	}
+	return batches
+}
+
+// LocationToModel converts domain entity to persistence model
+func LocationToModel(entity *entities.Location) *Location {
+	if entity == nil {
+		return nil
+	}
+
+	model := &Location{
+		ID:        entity.ID,
+		Code:      entity.Code,
+		Name:      entity.Name,
+		Type:      string(entity.Type),
+		IsDefault: entity.IsDefault,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+	if entity.Position != nil {
+		model.Latitude = &entity.Position.Latitude
+		model.Longitude = &entity.Position.Longitude
+	}
+	return model
+}
+
+// ModelToLocation converts persistence model to domain entity
+func ModelToLocation(model *Location, entity *entities.Location) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.Code = model.Code
+	entity.Name = model.Name
+	entity.Type = entities.LocationType(model.Type)
+	entity.Position = nil
+	if model.Latitude != nil && model.Longitude != nil {
+		entity.Position = &entities.GeoPoint{Latitude: *model.Latitude, Longitude: *model.Longitude}
+	}
+	entity.IsDefault = model.IsDefault
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+}
+
+// ModelsToLocations converts a slice of location models to entities
+func ModelsToLocations(models []Location) []*entities.Location {
+	locations := make([]*entities.Location, len(models))
+	for i, model := range models {
+		locations[i] = &entities.Location{}
+		ModelToLocation(&model, locations[i])
+	}
+	return locations
+}
+
+// Supplier conversions
+
+// SupplierToModel converts domain entity to persistence model
+func SupplierToModel(entity *entities.Supplier) *Supplier {
+	if entity == nil {
+		return nil
+	}
+
+	return &Supplier{
+		ID:           entity.ID,
+		Name:         entity.Name,
+		Email:        entity.Email,
+		Phone:        entity.Phone,
+		LeadTimeDays: entity.LeadTimeDays,
+		CreatedAt:    entity.CreatedAt,
+		UpdatedAt:    entity.UpdatedAt,
+	}
+}
+
+// ModelToSupplier converts persistence model to domain entity
+func ModelToSupplier(model *Supplier, entity *entities.Supplier) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.Name = model.Name
+	entity.Email = model.Email
+	entity.Phone = model.Phone
+	entity.LeadTimeDays = model.LeadTimeDays
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+}
+
+// ModelsToSuppliers converts a slice of supplier models to entities
+func ModelsToSuppliers(models []Supplier) []*entities.Supplier {
+	suppliers := make([]*entities.Supplier, len(models))
+	for i, model := range models {
+		suppliers[i] = &entities.Supplier{}
+		ModelToSupplier(&model, suppliers[i])
+	}
+	return suppliers
+}
+
+// Purchase order conversions
+
+// PurchaseOrderToModel converts domain entity to persistence model, lines included
+func PurchaseOrderToModel(entity *entities.PurchaseOrder) *PurchaseOrder {
+	if entity == nil {
+		return nil
+	}
+
+	model := &PurchaseOrder{
+		ID:         entity.ID,
+		SupplierID: entity.SupplierID,
+		LocationID: entity.LocationID,
+		Status:     string(entity.Status),
+		TotalMinor: entity.Total.Minor(),
+		Currency:   entity.Total.Currency(),
+		Note:       entity.Note,
+		SentAt:     entity.SentAt,
+		ExpectedAt: entity.ExpectedAt,
+		ReceivedAt: entity.ReceivedAt,
+		Version:    entity.Version,
+		CreatedAt:  entity.CreatedAt,
+		UpdatedAt:  entity.UpdatedAt,
+	}
+	for _, line := range entity.Lines {
+		model.Lines = append(model.Lines, PurchaseOrderLine{
+			PurchaseOrderID:  entity.ID,
+			LineNumber:       line.LineNumber,
+			ProductID:        line.ProductID,
+			QuantityOrdered:  line.QuantityOrdered,
+			QuantityReceived: line.QuantityReceived,
+			UnitCostMinor:    line.UnitCost.Minor(),
+			Currency:         line.UnitCost.Currency(),
+		})
+	}
+	return model
+}
+
+// ModelToPurchaseOrder converts persistence model to domain entity
+// Product names are filled in when the lines were loaded with their product
+func ModelToPurchaseOrder(model *PurchaseOrder, entity *entities.PurchaseOrder) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.SupplierID = model.SupplierID
+	entity.LocationID = model.LocationID
+	entity.Status = entities.PurchaseOrderStatus(model.Status)
+	entity.Total = entities.NewMoney(model.TotalMinor, model.Currency)
+	entity.Note = model.Note
+	entity.SentAt = model.SentAt
+	entity.ExpectedAt = model.ExpectedAt
+	entity.ReceivedAt = model.ReceivedAt
+	entity.Version = model.Version
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+
+	entity.Lines = make([]*entities.PurchaseOrderLine, len(model.Lines))
+	for i, line := range model.Lines {
+		entity.Lines[i] = &entities.PurchaseOrderLine{
+			PurchaseOrderID:  line.PurchaseOrderID,
+			LineNumber:       line.LineNumber,
+			ProductID:        line.ProductID,
+			ProductName:      line.Product.ProductName,
+			QuantityOrdered:  line.QuantityOrdered,
+			QuantityReceived: line.QuantityReceived,
+			UnitCost:         entities.NewMoney(line.UnitCostMinor, line.Currency),
+		}
+	}
+}
+
+// ModelsToPurchaseOrders converts a slice of purchase order models to entities
+func ModelsToPurchaseOrders(models []PurchaseOrder) []*entities.PurchaseOrder {
+	orders := make([]*entities.PurchaseOrder, len(models))
+	for i, model := range models {
+		orders[i] = &entities.PurchaseOrder{}
+		ModelToPurchaseOrder(&model, orders[i])
+	}
+	return orders
+}
+
+// Goods receipt conversions
+
+// ReceiptToModel converts domain entity to persistence model, lines included
+func ReceiptToModel(entity *entities.GoodsReceipt) *GoodsReceipt {
+	if entity == nil {
+		return nil
+	}
+
+	model := &GoodsReceipt{
+		ID:                  entity.ID,
+		PurchaseOrderID:     entity.PurchaseOrderID,
+		LocationID:          entity.LocationID,
+		AdditionalCostMinor: entity.AdditionalCost.Minor(),
+		Currency:            entity.AdditionalCost.Currency(),
+		Actor:               entity.Actor,
+		Note:                entity.Note,
+		ReceivedAt:          entity.ReceivedAt,
+	}
+	for _, line := range entity.Lines {
+		model.Lines = append(model.Lines, GoodsReceiptLine{
+			ReceiptID:           entity.ID,
+			LineNumber:          line.LineNumber,
+			ProductID:           line.ProductID,
+			Quantity:            line.Quantity,
+			UnitCostMinor:       line.UnitCost.Minor(),
+			LandedUnitCostMinor: line.LandedUnitCost.Minor(),
+			Currency:            line.UnitCost.Currency(),
+		})
+	}
+	return model
+}
+
+// ModelToReceipt converts persistence model to domain entity
+func ModelToReceipt(model *GoodsReceipt, entity *entities.GoodsReceipt) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.PurchaseOrderID = model.PurchaseOrderID
+	entity.LocationID = model.LocationID
+	entity.AdditionalCost = entities.NewMoney(model.AdditionalCostMinor, model.Currency)
+	entity.Actor = model.Actor
+	entity.Note = model.Note
+	entity.ReceivedAt = model.ReceivedAt
+
+	entity.Lines = make([]*entities.GoodsReceiptLine, len(model.Lines))
+	for i, line := range model.Lines {
+		entity.Lines[i] = &entities.GoodsReceiptLine{
+			ReceiptID:      line.ReceiptID,
+			LineNumber:     line.LineNumber,
+			ProductID:      line.ProductID,
+			Quantity:       line.Quantity,
+			UnitCost:       entities.NewMoney(line.UnitCostMinor, line.Currency),
+			LandedUnitCost: entities.NewMoney(line.LandedUnitCostMinor, line.Currency),
+		}
+	}
+}
+
+// ModelsToReceipts converts a slice of goods receipt models to entities
+func ModelsToReceipts(models []GoodsReceipt) []*entities.GoodsReceipt {
+	receipts := make([]*entities.GoodsReceipt, len(models))
+	for i, model := range models {
+		receipts[i] = &entities.GoodsReceipt{}
+		ModelToReceipt(&model, receipts[i])
+	}
+	return receipts
+}
+
+// Category conversions
+
+// CategoryToModel converts domain entity to persistence model
+func CategoryToModel(entity *entities.Category) *Category {
+	if entity == nil {
+		return nil
+	}
+
+	return &Category{
+		ID:        entity.ID,
+		Name:      entity.Name,
+		ParentID:  nullableID(entity.ParentID),
+		Path:      entity.Path,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+}
+
+// ModelToCategory converts persistence model to domain entity
+func ModelToCategory(model *Category, entity *entities.Category) {
+	if model == nil || entity == nil {
+		return
+	}
+
+	entity.ID = model.ID
+	entity.Name = model.Name
+	entity.ParentID = idValue(model.ParentID)
+	entity.Path = model.Path
+	entity.CreatedAt = model.CreatedAt
+	entity.UpdatedAt = model.UpdatedAt
+}
+
+// ModelsToCategories converts a slice of category models to entities
+func ModelsToCategories(models []Category) []*entities.Category {
+	categories := make([]*entities.Category, len(models))
+	for i, model := range models {
+		categories[i] = &entities.Category{}
+		ModelToCategory(&model, categories[i])
+	}
+	return categories
+}
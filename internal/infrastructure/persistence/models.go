@@ -8,32 +8,103 @@ import (
 
 // Database models with GORM tags for persistence
 // These models are separate from domain entities to maintain Clean Architecture
+// Money is stored as integer minor units (*_minor columns) next to a
+// currency column, so sums in SQL are exact on every dialect
 
 // Product represents the database model for products
+// reserved_quantity is held by active reservations and never exceeds quantity
 type Product struct {
-	ID          string    `gorm:"type:varchar(20);primaryKey;not null"`
-	ProductName string    `gorm:"type:varchar(255);not null;index"`
-	Price       float64   `gorm:"type:decimal(10,2);not null;check:price > 0"`
-	Quantity    int       `gorm:"not null;check:quantity >= 0;index"`
-	CreatedAt   time.Time `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+	ID               string    `gorm:"type:varchar(32);primaryKey;not null"`
+	ProductName      string    `gorm:"type:varchar(255);not null;index"`
+	PriceMinor       int64     `gorm:"not null;check:price_minor > 0"`
+	Currency         string    `gorm:"type:varchar(3);not null"`
+	Quantity         int       `gorm:"not null;check:quantity >= 0;index"`
+	AverageCostMinor int64     `gorm:"not null;default:0;check:average_cost_minor >= 0"` // in the product's currency
+	Reserved         int       `gorm:"column:reserved_quantity;not null;default:0;check:chk_products_reserved_stock,reserved_quantity >= 0 AND quantity >= reserved_quantity"`
+	ReorderPoint     int       `gorm:"not null;default:0;check:reorder_point >= 0"`
+	ReorderQuantity  int       `gorm:"not null;default:0;check:reorder_quantity >= 0"`
+	LeadTimeDays     int       `gorm:"not null;default:0;check:lead_time_days >= 0"`
+	SupplierID       *string   `gorm:"type:varchar(32);index"`
+	SKU              *string   `gorm:"column:sku;type:varchar(64);uniqueIndex"` // NULL when unset, so any number of products can lack one
+	Barcode          *string   `gorm:"type:varchar(14);uniqueIndex"`
+	Description      string    `gorm:"type:varchar(2000);not null;default:''"`
+	CategoryID       *string   `gorm:"type:varchar(32);index"`
+	Status           string    `gorm:"type:varchar(20);not null;default:'active';index;check:status IN ('active','archived')"`
+	TaxClass         string    `gorm:"type:varchar(32);not null;default:''"` // empty when the product is not taxed
+	Type             string    `gorm:"type:varchar(20);not null;default:'simple'"`
+	ParentID         *string   `gorm:"type:varchar(32);index"` // set on variants
+	PriceOverride    bool      `gorm:"not null;default:false"` // variant priced apart from its parent
+	Version          int       `gorm:"not null;default:1"`
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
 
+	// Relationships
+	Supplier     *Supplier          `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Category     *Category          `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Parent       *Product           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Attributes   []ProductAttribute `gorm:"foreignKey:ProductID"`
+	Components   []BundleComponent  `gorm:"foreignKey:BundleID"`
+	Orders       []Order            `gorm:"foreignKey:ProductID"`
+	Transactions []Transaction      `gorm:"foreignKey:ProductID"`
+}
 
+// ProductAttribute represents the database model for one free-form attribute of a product
+type ProductAttribute struct {
+	ProductID string `gorm:"type:varchar(32);primaryKey;not null"`
+	Name      string `gorm:"type:varchar(50);primaryKey;not null;index:idx_product_attributes_name_value"`
+	Value     string `gorm:"type:varchar(255);not null;index:idx_product_attributes_name_value"`
 
-	// Relationships
-	Orders       []Order       `gorm:"foreignKey:ProductID"`
-	Transactions []Transaction `gorm:"foreignKey:ProductID"`
+	// Foreign key relationships
+	Product *Product `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
-// Customer represents the database model for customers
-type Customer struct {
-	ID        string    `gorm:"type:varchar(20);primaryKey;not null"`
-	Name      string    `gorm:"type:varchar(255);not null;index"`
-	Email     string    `gorm:"type:varchar(255);unique;not null;index"`
-	Phone     string    `gorm:"type:varchar(20);not null"`
+func (ProductAttribute) TableName() string { return "product_attributes" }
+
+// BundleComponent represents the database model for one product in a bundle
+type BundleComponent struct {
+	BundleID       string `gorm:"type:varchar(32);primaryKey;not null"`
+	ProductID      string `gorm:"type:varchar(32);primaryKey;not null;index"`
+	Quantity       int    `gorm:"not null;check:quantity > 0"`
+	ListPriceMinor int64  `gorm:"not null;check:list_price_minor > 0"` // the component's price when the bundle was made
+	Currency       string `gorm:"type:varchar(3);not null"`
+
+	// Foreign key relationships
+	Bundle  *Product `gorm:"foreignKey:BundleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Product *Product `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func (BundleComponent) TableName() string { return "bundle_components" }
+
+// Category represents the database model for a node of the product category tree
+// Path lists the IDs from the top-level category down, so a subtree is every
+// row whose path starts with the path of its root
+type Category struct {
+	ID        string    `gorm:"type:varchar(32);primaryKey;not null"`
+	Name      string    `gorm:"type:varchar(100);not null"`
+	ParentID  *string   `gorm:"type:varchar(32);index"`
+	Path      string    `gorm:"type:varchar(400);not null;index"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 
+	// Foreign key relationships
+	Parent *Category `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func (Category) TableName() string { return "categories" }
+
+// Customer represents the database model for customers
+type Customer struct {
+	ID        string `gorm:"type:varchar(32);primaryKey;not null"`
+	Name      string `gorm:"type:varchar(255);not null;index"`
+	Email     string `gorm:"type:varchar(255);unique;not null;index"`
+	Phone     string `gorm:"type:varchar(20);not null"`
+	PriceList string `gorm:"type:varchar(20);not null;default:'retail';check:price_list IN ('retail','wholesale')"`
+	// TaxJurisdiction is empty when the customer is taxed where the business is
+	TaxJurisdiction string    `gorm:"type:varchar(6);not null;default:''"`
+	Version         int       `gorm:"not null;default:1"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
+
 	// Relationships
 	Orders       []Order          `gorm:"foreignKey:CustomerID"`
 	Transactions []Transaction    `gorm:"foreignKey:CustomerID"`
@@ -41,38 +112,153 @@ type Customer struct {
 }
 
 // Order represents the database model for orders
+// ProductID and UnitPrice summarise single-line orders and are NULL/0 on
+// multi-line orders; order_lines holds what was actually ordered
+// Every amount on the order and its lines is in the order's currency, except
+// ChargedMinor, which is in ChargeCurrency; orders placed before charge
+// currencies existed have an empty ChargeCurrency
 type Order struct {
-	ID          string    `gorm:"type:varchar(20);primaryKey;not null"`
-	CustomerID  string    `gorm:"type:varchar(20);not null;index"`
-	ProductID   string    `gorm:"type:varchar(20);not null;index"`
-	Quantity    int       `gorm:"not null;check:quantity > 0"`
-	UnitPrice   float64   `gorm:"type:decimal(10,2);not null;check:unit_price > 0"`
-	TotalAmount float64   `gorm:"type:decimal(10,2);not null;check:total_amount > 0"`
-	OrderDate   time.Time `gorm:"not null;index"`
-	CreatedAt   time.Time `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+	ID               string    `gorm:"type:varchar(32);primaryKey;not null"`
+	CustomerID       string    `gorm:"type:varchar(32);not null;index"`
+	ProductID        *string   `gorm:"type:varchar(32);index"`
+	Quantity         int       `gorm:"not null;check:quantity > 0"`
+	UnitPriceMinor   int64     `gorm:"not null;default:0;check:unit_price_minor >= 0"`
+	TotalAmountMinor int64     `gorm:"not null;check:total_amount_minor > 0"`
+	StoreCreditMinor int64     `gorm:"not null;default:0;check:store_credit_minor >= 0"`
+	DiscountMinor    int64     `gorm:"not null;default:0;check:discount_minor >= 0"`
+	CouponCode       *string   `gorm:"type:varchar(32);index"`
+	TaxMinor         int64     `gorm:"not null;default:0;check:tax_minor >= 0"`
+	TaxJurisdiction  string    `gorm:"type:varchar(6);not null;default:''"`
+	Currency         string    `gorm:"type:varchar(3);not null"`
+	ChargeCurrency   string    `gorm:"type:varchar(3);not null;default:''"`
+	ExchangeRate     int64     `gorm:"not null;default:0"` // scaled by 10^entities.RateDecimals
+	ChargedMinor     int64     `gorm:"not null;default:0"`
+	LocationID       *string   `gorm:"type:varchar(32);index"`
+	Status           string    `gorm:"type:varchar(20);not null;default:'pending';index;check:status IN ('pending','confirmed','cancelled','completed')"`
+	OrderDate        time.Time `gorm:"not null;index"`
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime"`
 
 	// Foreign key relationships
-	Customer Customer `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
-	Product  Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Customer Customer  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Product  Product   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Location *Location `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 
 	// Relationships
-	Transactions []Transaction `gorm:"foreignKey:OrderID"`
+	Lines         []OrderLine          `gorm:"foreignKey:OrderID"`
+	Transactions  []Transaction        `gorm:"foreignKey:OrderID"`
+	StatusHistory []OrderStatusHistory `gorm:"foreignKey:OrderID"`
+}
+
+// OrderLine represents the database model for the products on an order
+type OrderLine struct {
+	OrderID        string `gorm:"type:varchar(32);primaryKey;uniqueIndex:idx_order_lines_product"`
+	LineNumber     int    `gorm:"primaryKey;autoIncrement:false"`
+	ProductID      string `gorm:"type:varchar(32);not null;index;uniqueIndex:idx_order_lines_product"`
+	Quantity       int    `gorm:"not null;check:quantity > 0"`
+	UnitPriceMinor int64  `gorm:"not null;check:unit_price_minor > 0"`
+	LineTotalMinor int64  `gorm:"not null;check:line_total_minor > 0"`
+	DiscountMinor  int64  `gorm:"not null;default:0;check:discount_minor >= 0"`
+	Currency       string `gorm:"type:varchar(3);not null"`
+
+	// ListPriceMinor is 0 on lines stored before price rules existed
+	ListPriceMinor int64   `gorm:"not null;default:0"`
+	PriceRuleID    *string `gorm:"type:varchar(32);index"`
+
+	// LineTotalMinor includes TaxMinor unless TaxInclusive is false, in which
+	// case the tax was added on top of the price
+	TaxClass          string `gorm:"type:varchar(32);not null;default:''"`
+	TaxRateHundredths int64  `gorm:"not null;default:0;check:tax_rate_hundredths >= 0"`
+	TaxInclusive      bool   `gorm:"not null;default:false"`
+	TaxMinor          int64  `gorm:"not null;default:0;check:tax_minor >= 0"`
+
+	// Foreign key relationships
+	Order     *Order     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Product   Product    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	PriceRule *PriceRule `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+
+	// Relationships
+	Taxes []OrderLineTax `gorm:"foreignKey:OrderID,LineNumber;references:OrderID,LineNumber"`
+}
+
+// OrderLineTax represents the database model for one tax charged on an order line
+// The amounts of a line's taxes add up to the line's TaxMinor
+type OrderLineTax struct {
+	OrderID        string `gorm:"type:varchar(32);primaryKey;not null"`
+	LineNumber     int    `gorm:"primaryKey;autoIncrement:false"`
+	Position       int    `gorm:"primaryKey;autoIncrement:false"`
+	Name           string `gorm:"type:varchar(20);not null"`
+	RateHundredths int64  `gorm:"not null;check:rate_hundredths >= 0"`
+	AmountMinor    int64  `gorm:"not null;check:amount_minor >= 0"`
+	Currency       string `gorm:"type:varchar(3);not null"`
+}
+
+func (OrderLineTax) TableName() string { return "order_line_taxes" }
+
+// OrderStatusHistory represents the database model for order status transitions
+// Rows are append-only: each one records who moved the order and when
+type OrderStatusHistory struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	OrderID    string    `gorm:"type:varchar(32);not null;index"`
+	FromStatus string    `gorm:"type:varchar(20)"`
+	ToStatus   string    `gorm:"type:varchar(20);not null"`
+	Actor      string    `gorm:"type:varchar(100);not null"`
+	Reason     string    `gorm:"type:text"`
+	ChangedAt  time.Time `gorm:"not null;index"`
+
+	// Foreign key relationship
+	Order *Order `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// OrderReturn represents the database model for order returns (RMAs)
+type OrderReturn struct {
+	ID                  string `gorm:"type:varchar(32);primaryKey;not null"`
+	OrderID             string `gorm:"type:varchar(32);not null;index"`
+	CustomerID          string `gorm:"type:varchar(32);not null;index"`
+	ProductID           string `gorm:"type:varchar(32);not null;index"`
+	Quantity            int    `gorm:"not null;check:quantity > 0"`
+	UnitPriceMinor      int64  `gorm:"not null;check:unit_price_minor > 0"`
+	RefundAmountMinor   int64  `gorm:"not null;check:refund_amount_minor > 0"`
+	Currency            string `gorm:"type:varchar(3);not null"`
+	Reason              string `gorm:"type:text"`
+	Status              string `gorm:"type:varchar(20);not null;default:'requested';index;check:status IN ('requested','approved','rejected')"`
+	Disposition         string `gorm:"type:varchar(20)"`
+	RefundTransactionID string `gorm:"type:varchar(32)"`
+	ResolvedBy          string `gorm:"type:varchar(100)"`
+	ResolutionNote      string `gorm:"type:text"`
+	ResolvedAt          *time.Time
+	RequestedAt         time.Time `gorm:"not null;index"`
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime"`
+
+	// Foreign key relationships
+	Order    Order    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Customer Customer `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Product  Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
 }
 
 // Transaction represents the database model for transactions
+// Store credit entries have no product, and goodwill credit has no order,
+// so those references are nullable
 type Transaction struct {
-	ID            string    `gorm:"type:varchar(20);primaryKey;not null"`
-	OrderID       string    `gorm:"type:varchar(20);not null;index"`
-	CustomerID    string    `gorm:"type:varchar(20);not null;index"`
-	ProductID     string    `gorm:"type:varchar(20);not null;index"`
-	Type          string    `gorm:"type:varchar(20);not null;index;check:type IN ('order','refund','credit')"`
-	Amount        float64   `gorm:"type:decimal(10,2);not null;check:amount > 0"`
-	Quantity      int       `gorm:"not null;check:quantity > 0"`
-	UnitPrice     float64   `gorm:"type:decimal(10,2);not null;check:unit_price > 0"`
-	Description   string    `gorm:"type:text"`
-	TransactionAt time.Time `gorm:"not null;index"`
-	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	ID                string    `gorm:"type:varchar(32);primaryKey;not null"`
+	OrderID           *string   `gorm:"type:varchar(32);index"`
+	CustomerID        string    `gorm:"type:varchar(32);not null;index"`
+	ProductID         *string   `gorm:"type:varchar(32);index"`
+	Type              string    `gorm:"type:varchar(20);not null;index;check:type IN ('order','refund','credit','credit_redemption')"`
+	AmountMinor       int64     `gorm:"not null;check:amount_minor > 0"`
+	Quantity          int       `gorm:"not null;default:0;check:quantity >= 0"`
+	UnitPriceMinor    int64     `gorm:"not null;default:0;check:unit_price_minor >= 0"`
+	CostOfGoodsMinor  int64     `gorm:"not null;default:0;check:cost_of_goods_minor >= 0"`
+	DiscountMinor     int64     `gorm:"not null;default:0;check:discount_minor >= 0"`
+	TaxMinor          int64     `gorm:"not null;default:0;check:tax_minor >= 0"` // included in AmountMinor
+	TaxRateHundredths int64     `gorm:"not null;default:0"`
+	TaxClass          string    `gorm:"type:varchar(32);not null;default:'';index"`
+	TaxJurisdiction   string    `gorm:"type:varchar(6);not null;default:''"`
+	Currency          string    `gorm:"type:varchar(3);not null;index"`
+	Description       string    `gorm:"type:text"`
+	TransactionAt     time.Time `gorm:"not null;index"`
+	CreatedAt         time.Time `gorm:"autoCreateTime"`
 
 	// Foreign key relationships
 	Order    Order    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
@@ -82,7 +268,7 @@ type Transaction struct {
 
 // CustomerCooldown represents the database model for customer cooldowns
 type CustomerCooldown struct {
-	CustomerID    string    `gorm:"type:varchar(20);primaryKey;not null"`
+	CustomerID    string    `gorm:"type:varchar(32);primaryKey;not null"`
 	LastOrderTime time.Time `gorm:"not null;index"`
 	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
 
@@ -91,14 +277,19 @@ type CustomerCooldown struct {
 }
 
 // TableName methods to customize table names if needed
-func (Product) TableName() string          { return "products" }
-func (Customer) TableName() string         { return "customers" }
-func (Order) TableName() string            { return "orders" }
-func (Transaction) TableName() string      { return "transactions" }
-func (CustomerCooldown) TableName() string { return "customer_cooldowns" }
+func (Product) TableName() string            { return "products" }
+func (Customer) TableName() string           { return "customers" }
+func (Order) TableName() string              { return "orders" }
+func (Transaction) TableName() string        { return "transactions" }
+func (CustomerCooldown) TableName() string   { return "customer_cooldowns" }
+func (OrderStatusHistory) TableName() string { return "order_status_history" }
+func (OrderReturn) TableName() string        { return "order_returns" }
 
 // BeforeCreate hooks for generating IDs if not set
 func (p *Product) BeforeCreate(tx *gorm.DB) error {
+	if p.Version == 0 {
+		p.Version = 1
+	}
 	if p.ID == "" {
 		// ID should be generated in the use case layer
 		return nil
@@ -107,6 +298,9 @@ func (p *Product) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (c *Customer) BeforeCreate(tx *gorm.DB) error {
+	if c.Version == 0 {
+		c.Version = 1
+	}
 	if c.ID == "" {
 		// ID should be generated in the use case layer
 		return nil
@@ -130,13 +324,483 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// CartItem represents the database model for a product in a customer's cart
+type CartItem struct {
+	CustomerID string    `gorm:"type:varchar(32);primaryKey"`
+	ProductID  string    `gorm:"type:varchar(32);primaryKey;index"`
+	Quantity   int       `gorm:"not null;check:quantity > 0"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	// Foreign key relationships
+	Customer Customer `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Product  Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// ExchangeRate represents the database model for a rate from the base currency
+// Rows are append-only; a rate applies from EffectiveFrom until the next row
+// for the same currency
+type ExchangeRate struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	BaseCurrency  string    `gorm:"type:varchar(3);not null"`
+	Currency      string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_effective"`
+	Rate          int64     `gorm:"not null;check:rate > 0"` // scaled by 10^entities.RateDecimals
+	EffectiveFrom time.Time `gorm:"not null;uniqueIndex:idx_exchange_rates_effective"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+func (ExchangeRate) TableName() string { return "exchange_rates" }
+
+// TaxRate represents the database model for the tax on one tax class in one jurisdiction
+// Rows are append-only; a rate applies from EffectiveFrom until the next row
+// for the same jurisdiction and class
+type TaxRate struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	Jurisdiction   string    `gorm:"type:varchar(6);not null;uniqueIndex:idx_tax_rates_effective"`
+	TaxClass       string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_tax_rates_effective"`
+	RateHundredths int64     `gorm:"not null;check:rate_hundredths >= 0"`
+	EffectiveFrom  time.Time `gorm:"not null;uniqueIndex:idx_tax_rates_effective"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+
+	// Relationships
+	Components []TaxRateComponent `gorm:"foreignKey:TaxRateID"`
+}
+
+func (TaxRate) TableName() string { return "tax_rates" }
+
+// TaxRateComponent represents the database model for one tax a rate is made of
+type TaxRateComponent struct {
+	TaxRateID      uint   `gorm:"primaryKey;autoIncrement:false"`
+	Position       int    `gorm:"primaryKey;autoIncrement:false"`
+	Name           string `gorm:"type:varchar(20);not null"`
+	RateHundredths int64  `gorm:"not null;check:rate_hundredths >= 0"`
+
+	// Foreign key relationships
+	TaxRate *TaxRate `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (TaxRateComponent) TableName() string { return "tax_rate_components" }
+
+// Invoice represents the database model for the invoice issued for an order
+// Rows are append-only and copy what was billed rather than referring to the
+// catalogue; Sequence comes from the "invoice" counter in id_sequences
+type Invoice struct {
+	Number                  string    `gorm:"type:varchar(32);primaryKey;not null"`
+	Sequence                int64     `gorm:"not null;uniqueIndex"`
+	OrderID                 string    `gorm:"type:varchar(32);not null;uniqueIndex"`
+	IssuedAt                time.Time `gorm:"not null;index"`
+	SellerName              string    `gorm:"type:varchar(255);not null;default:''"`
+	SellerAddress           string    `gorm:"type:text"`
+	SellerTaxID             string    `gorm:"type:varchar(32);not null;default:''"`
+	CustomerID              string    `gorm:"type:varchar(32);not null;index"`
+	CustomerName            string    `gorm:"type:varchar(255);not null"`
+	CustomerEmail           string    `gorm:"type:varchar(255);not null;default:''"`
+	CustomerPhone           string    `gorm:"type:varchar(20);not null;default:''"`
+	CustomerTaxJurisdiction string    `gorm:"type:varchar(6);not null;default:''"`
+	TaxJurisdiction         string    `gorm:"type:varchar(6);not null;default:''"`
+	SubtotalMinor           int64     `gorm:"not null;check:subtotal_minor > 0"`
+	DiscountMinor           int64     `gorm:"not null;default:0;check:discount_minor >= 0"`
+	TaxMinor                int64     `gorm:"not null;default:0;check:tax_minor >= 0"`
+	TotalMinor              int64     `gorm:"not null;check:total_minor > 0"`
+	Currency                string    `gorm:"type:varchar(3);not null"`
+	CreatedAt               time.Time `gorm:"autoCreateTime"`
+
+	// Foreign key relationships
+	Order *Order `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+
+	// Relationships
+	Lines []InvoiceLine `gorm:"foreignKey:InvoiceNumber"`
+}
+
+func (Invoice) TableName() string { return "invoices" }
+
+// InvoiceLine represents the database model for one product on an invoice
+type InvoiceLine struct {
+	InvoiceNumber     string `gorm:"type:varchar(32);primaryKey"`
+	LineNumber        int    `gorm:"primaryKey;autoIncrement:false"`
+	ProductID         string `gorm:"type:varchar(32);not null"`
+	SKU               string `gorm:"type:varchar(64);not null;default:''"`
+	ProductName       string `gorm:"type:varchar(255);not null"`
+	Quantity          int    `gorm:"not null;check:quantity > 0"`
+	UnitPriceMinor    int64  `gorm:"not null;check:unit_price_minor > 0"`
+	DiscountMinor     int64  `gorm:"not null;default:0;check:discount_minor >= 0"`
+	TaxableMinor      int64  `gorm:"not null;check:taxable_minor > 0"`
+	TaxClass          string `gorm:"type:varchar(32);not null;default:''"`
+	TaxRateHundredths int64  `gorm:"not null;default:0;check:tax_rate_hundredths >= 0"`
+	TaxInclusive      bool   `gorm:"not null;default:false"`
+	TaxMinor          int64  `gorm:"not null;default:0;check:tax_minor >= 0"`
+	LineTotalMinor    int64  `gorm:"not null;check:line_total_minor > 0"`
+	Currency          string `gorm:"type:varchar(3);not null"`
+
+	// Foreign key relationships
+	Invoice *Invoice `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	// Relationships
+	Taxes []InvoiceLineTax `gorm:"foreignKey:InvoiceNumber,LineNumber;references:InvoiceNumber,LineNumber"`
+}
+
+func (InvoiceLine) TableName() string { return "invoice_lines" }
+
+// InvoiceLineTax represents the database model for one tax charged on an invoice line
+type InvoiceLineTax struct {
+	InvoiceNumber  string `gorm:"type:varchar(32);primaryKey;not null"`
+	LineNumber     int    `gorm:"primaryKey;autoIncrement:false"`
+	Position       int    `gorm:"primaryKey;autoIncrement:false"`
+	Name           string `gorm:"type:varchar(20);not null"`
+	RateHundredths int64  `gorm:"not null;check:rate_hundredths >= 0"`
+	AmountMinor    int64  `gorm:"not null;check:amount_minor >= 0"`
+	Currency       string `gorm:"type:varchar(3);not null"`
+}
+
+func (InvoiceLineTax) TableName() string { return "invoice_line_taxes" }
+
+// PriceRule represents the database model for a rule that changes what customers pay
+// Exactly one of PriceMinor and DiscountHundredths is set; rules are never
+// deleted, because order lines record the rule that priced them
+type PriceRule struct {
+	ID                 string     `gorm:"type:varchar(32);primaryKey;not null"`
+	Name               string     `gorm:"type:varchar(255);not null"`
+	PriceList          string     `gorm:"type:varchar(20);not null;default:''"`
+	ProductID          *string    `gorm:"type:varchar(32);index"`
+	MinQuantity        int        `gorm:"not null;default:0;check:min_quantity >= 0"`
+	PriceMinor         int64      `gorm:"not null;default:0;check:price_minor >= 0"`
+	Currency           string     `gorm:"type:varchar(3);not null"`
+	DiscountHundredths int64      `gorm:"not null;default:0;check:discount_hundredths >= 0 AND discount_hundredths < 10000"`
+	StartsAt           *time.Time `gorm:"index"`
+	EndsAt             *time.Time `gorm:"index"`
+	CreatedAt          time.Time  `gorm:"not null"`
+
+	// Foreign key relationships
+	Product *Product `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (PriceRule) TableName() string { return "price_rules" }
+
+// ProductPrice represents the database model for a product's price over a period
+// ActivatedAt stays NULL while the price is scheduled
+type ProductPrice struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID     string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_product_prices_effective"`
+	PriceMinor    int64     `gorm:"not null;check:price_minor > 0"`
+	Currency      string    `gorm:"type:varchar(3);not null"`
+	EffectiveFrom time.Time `gorm:"not null;uniqueIndex:idx_product_prices_effective"`
+	EffectiveTo   *time.Time
+	ActivatedAt   *time.Time `gorm:"index"`
+	Actor         string     `gorm:"type:varchar(100);not null;default:''"`
+	CreatedAt     time.Time  `gorm:"not null"`
+
+	// Foreign key relationships
+	Product *Product `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (ProductPrice) TableName() string { return "product_prices" }
+
+// Promotion represents the database model for a promotion
+// Only the discount columns of the promotion's type are set; promotions are
+// never deleted, because redemptions record what they cost
+type Promotion struct {
+	ID                 string     `gorm:"type:varchar(32);primaryKey;not null"`
+	Name               string     `gorm:"type:varchar(255);not null"`
+	Type               string     `gorm:"type:varchar(20);not null;check:type IN ('percent_off','amount_off','buy_x_get_y')"`
+	DiscountHundredths int64      `gorm:"not null;default:0;check:discount_hundredths >= 0 AND discount_hundredths < 10000"`
+	AmountMinor        int64      `gorm:"not null;default:0;check:amount_minor >= 0"`
+	Currency           string     `gorm:"type:varchar(3);not null"`
+	BuyQuantity        int        `gorm:"not null;default:0;check:buy_quantity >= 0"`
+	GetQuantity        int        `gorm:"not null;default:0;check:get_quantity >= 0"`
+	ProductID          *string    `gorm:"type:varchar(32);index"`
+	CategoryID         *string    `gorm:"type:varchar(32);index"`
+	CouponOnly         bool       `gorm:"not null;default:false"`
+	StartsAt           *time.Time `gorm:"index"`
+	EndsAt             *time.Time `gorm:"index"`
+	CreatedAt          time.Time  `gorm:"not null"`
+
+	// Foreign key relationships
+	Product  *Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Category *Category `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (Promotion) TableName() string { return "promotions" }
+
+// Coupon represents the database model for a coupon code
+// Redemptions counts the coupon's uses on orders that were not cancelled and
+// is changed with conditional updates, so it never passes MaxRedemptions
+type Coupon struct {
+	Code           string     `gorm:"type:varchar(32);primaryKey;not null"`
+	PromotionID    string     `gorm:"type:varchar(32);not null;index"`
+	MaxRedemptions int        `gorm:"not null;default:0;check:max_redemptions >= 0"`
+	MaxPerCustomer int        `gorm:"not null;default:0;check:max_per_customer >= 0"`
+	Redemptions    int        `gorm:"not null;default:0;check:redemptions >= 0"`
+	ExpiresAt      *time.Time `gorm:"index"`
+	CreatedAt      time.Time  `gorm:"not null"`
+
+	// Foreign key relationships
+	Promotion *Promotion `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (Coupon) TableName() string { return "coupons" }
+
+// PromotionRedemption represents the database model for what a promotion took off an order
+// There is one row per promotion per order; rows go when the order is cancelled
+type PromotionRedemption struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	PromotionID   string    `gorm:"type:varchar(32);not null;index"`
+	CouponCode    *string   `gorm:"type:varchar(32);index"`
+	OrderID       string    `gorm:"type:varchar(32);not null;index"`
+	CustomerID    string    `gorm:"type:varchar(32);not null;index"`
+	DiscountMinor int64     `gorm:"not null;check:discount_minor > 0"`
+	Currency      string    `gorm:"type:varchar(3);not null"`
+	RedeemedAt    time.Time `gorm:"not null;index"`
+
+	// Foreign key relationships
+	Promotion *Promotion `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Order     *Order     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (PromotionRedemption) TableName() string { return "promotion_redemptions" }
+
+// IDSequence represents the database model for a counter behind sequence-generated IDs
+// There is one row per ID prefix; LastValue is the last number handed out
+type IDSequence struct {
+	Name      string    `gorm:"type:varchar(32);primaryKey;not null"`
+	LastValue int64     `gorm:"not null;default:0"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (IDSequence) TableName() string { return "id_sequences" }
+
+// StockReservation represents the database model for a hold on product stock
+// While a row is active its quantity is counted in products.reserved_quantity
+type StockReservation struct {
+	ID         string    `gorm:"type:varchar(32);primaryKey;not null"`
+	ProductID  string    `gorm:"type:varchar(32);not null;index"`
+	CustomerID *string   `gorm:"type:varchar(32);index"`
+	Quantity   int       `gorm:"not null;check:quantity > 0"`
+	Status     string    `gorm:"type:varchar(20);not null;default:'active';index:idx_stock_reservations_expiry;check:status IN ('active','confirmed','released','expired')"`
+	ExpiresAt  time.Time `gorm:"not null;index:idx_stock_reservations_expiry"`
+	OrderID    *string   `gorm:"type:varchar(32);index"`
+	LocationID *string   `gorm:"type:varchar(32);index"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	// Foreign key relationships
+	Product  Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Customer Customer `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (StockReservation) TableName() string { return "stock_reservations" }
+
+// InventoryMovement represents the database model for an entry in the stock ledger
+// Rows are only ever inserted
+type InventoryMovement struct {
+	ID             string    `gorm:"type:varchar(32);primaryKey;not null"`
+	ProductID      string    `gorm:"type:varchar(32);not null;index:idx_inventory_movements_product"`
+	LocationID     *string   `gorm:"type:varchar(32);index"`
+	Reason         string    `gorm:"type:varchar(20);not null;index;check:reason IN ('sale','cancellation','return','adjustment','stocktake','damage','receipt','transfer')"`
+	Change         int       `gorm:"column:quantity_change;not null"`
+	QuantityBefore int       `gorm:"not null;check:quantity_before >= 0"`
+	QuantityAfter  int       `gorm:"not null;check:quantity_after >= 0"`
+	Actor          string    `gorm:"type:varchar(100);not null"`
+	ReferenceID    string    `gorm:"type:varchar(32);index"`
+	Note           string    `gorm:"type:text"`
+	CostMinor      int64     `gorm:"not null;default:0"` // same sign as quantity_change
+	Currency       string    `gorm:"type:varchar(3);not null;default:''"`
+	CreatedAt      time.Time `gorm:"not null;index:idx_inventory_movements_product"`
+
+	// Foreign key relationships
+	Product  Product   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Location *Location `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+func (InventoryMovement) TableName() string { return "inventory_movements" }
+
+// InventoryBatch represents the database model for a lot of stock that came in at one unit cost
+// Only remaining changes once a batch is written
+type InventoryBatch struct {
+	ID            string    `gorm:"type:varchar(32);primaryKey;not null"`
+	ProductID     string    `gorm:"type:varchar(32);not null;index:idx_inventory_batches_product"`
+	ReferenceID   string    `gorm:"type:varchar(32);index"`
+	Quantity      int       `gorm:"not null;check:quantity > 0"`
+	Remaining     int       `gorm:"not null;check:remaining >= 0 AND remaining <= quantity"`
+	UnitCostMinor int64     `gorm:"not null;check:unit_cost_minor >= 0"`
+	Currency      string    `gorm:"type:varchar(3);not null"`
+	CreatedAt     time.Time `gorm:"not null;index:idx_inventory_batches_product"`
+
+	// Foreign key relationships
+	Product Product `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (InventoryBatch) TableName() string { return "inventory_batches" }
+
+// Location represents the database model for a store or warehouse
+// At most one location is the default, which takes stock that names no location
+type Location struct {
+	ID        string    `gorm:"type:varchar(32);primaryKey;not null"`
+	Code      string    `gorm:"type:varchar(20);uniqueIndex;not null"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	Type      string    `gorm:"type:varchar(20);not null;check:type IN ('store','warehouse')"`
+	Latitude  *float64  `gorm:""`
+	Longitude *float64  `gorm:""`
+	IsDefault bool      `gorm:"not null;default:false;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (Location) TableName() string { return "locations" }
+
+// LocationStock represents the database model for the stock of a product at a location
+// For every product, quantity summed over its rows equals products.quantity;
+// reserved_quantity is held there by active reservations and never exceeds quantity
+type LocationStock struct {
+	LocationID string    `gorm:"type:varchar(32);primaryKey;not null"`
+	ProductID  string    `gorm:"type:varchar(32);primaryKey;not null;index"`
+	Quantity   int       `gorm:"not null;default:0;check:quantity >= 0"`
+	Reserved   int       `gorm:"column:reserved_quantity;not null;default:0;check:chk_location_stock_reserved_stock,reserved_quantity >= 0 AND quantity >= reserved_quantity"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	// Foreign key relationships
+	Location Location `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Product  Product  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (LocationStock) TableName() string { return "location_stock" }
+
+// Supplier represents the database model for a business stock is bought from
+type Supplier struct {
+	ID           string    `gorm:"type:varchar(32);primaryKey;not null"`
+	Name         string    `gorm:"type:varchar(255);not null;index"`
+	Email        string    `gorm:"type:varchar(255)"`
+	Phone        string    `gorm:"type:varchar(20)"`
+	LeadTimeDays int       `gorm:"not null;default:0;check:lead_time_days >= 0"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+// PurchaseOrder represents the database model for an order placed with a supplier
+type PurchaseOrder struct {
+	ID         string     `gorm:"type:varchar(32);primaryKey;not null"`
+	SupplierID string     `gorm:"type:varchar(32);not null;index"`
+	LocationID string     `gorm:"type:varchar(32);not null"`
+	Status     string     `gorm:"type:varchar(20);not null;index;check:status IN ('draft','sent','partially_received','received')"`
+	TotalMinor int64      `gorm:"not null;default:0"`
+	Currency   string     `gorm:"type:varchar(3);not null"`
+	Note       string     `gorm:"type:text"`
+	SentAt     *time.Time `gorm:""`
+	ExpectedAt *time.Time `gorm:""`
+	ReceivedAt *time.Time `gorm:""`
+	Version    int        `gorm:"not null;default:1"`
+	CreatedAt  time.Time  `gorm:"not null;index"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+
+	// Foreign key relationships
+	Supplier Supplier `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Location Location `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+
+	// Relationships
+	Lines []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID"`
+}
+
+// PurchaseOrderLine represents the database model for one product on a purchase order
+type PurchaseOrderLine struct {
+	PurchaseOrderID  string `gorm:"type:varchar(32);primaryKey"`
+	LineNumber       int    `gorm:"primaryKey;autoIncrement:false"`
+	ProductID        string `gorm:"type:varchar(32);not null;index"`
+	QuantityOrdered  int    `gorm:"not null;check:quantity_ordered > 0"`
+	QuantityReceived int    `gorm:"not null;default:0;check:quantity_received >= 0"`
+	UnitCostMinor    int64  `gorm:"not null;check:unit_cost_minor >= 0"`
+	Currency         string `gorm:"type:varchar(3);not null"`
+
+	// Foreign key relationships
+	PurchaseOrder *PurchaseOrder `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Product       Product        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+// GoodsReceipt represents the database model for a delivery against a purchase order
+// Receipts are append-only
+type GoodsReceipt struct {
+	ID                  string    `gorm:"type:varchar(32);primaryKey;not null"`
+	PurchaseOrderID     string    `gorm:"type:varchar(32);not null;index"`
+	LocationID          string    `gorm:"type:varchar(32);not null"`
+	AdditionalCostMinor int64     `gorm:"not null;default:0;check:additional_cost_minor >= 0"`
+	Currency            string    `gorm:"type:varchar(3);not null"`
+	Actor               string    `gorm:"type:varchar(100);not null"`
+	Note                string    `gorm:"type:text"`
+	ReceivedAt          time.Time `gorm:"not null;index"`
+
+	// Foreign key relationships
+	PurchaseOrder *PurchaseOrder `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Location      Location       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+
+	// Relationships
+	Lines []GoodsReceiptLine `gorm:"foreignKey:ReceiptID"`
+}
+
+// GoodsReceiptLine represents the database model for a batch of one product received in a delivery
+type GoodsReceiptLine struct {
+	ReceiptID           string `gorm:"type:varchar(32);primaryKey"`
+	LineNumber          int    `gorm:"primaryKey;autoIncrement:false"`
+	ProductID           string `gorm:"type:varchar(32);not null;index"`
+	Quantity            int    `gorm:"not null;check:quantity > 0"`
+	UnitCostMinor       int64  `gorm:"not null;check:unit_cost_minor >= 0"`
+	LandedUnitCostMinor int64  `gorm:"not null;check:landed_unit_cost_minor >= 0"`
+	Currency            string `gorm:"type:varchar(3);not null"`
+
+	// Foreign key relationships
+	Receipt *GoodsReceipt `gorm:"foreignKey:ReceiptID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Product Product       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+// IdempotencyKey represents the database model for a request sent with an Idempotency-Key
+// StatusCode is 0 until the first request has finished and its response is stored
+type IdempotencyKey struct {
+	Key         string    `gorm:"column:idempotency_key;type:varchar(255);primaryKey;not null"` // "key" is reserved in MySQL
+	Fingerprint string    `gorm:"type:varchar(64);not null"`
+	StatusCode  int       `gorm:"not null;default:0"`
+	ContentType string    `gorm:"type:varchar(100);not null;default:''"`
+	Body        []byte    `gorm:""`
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (IdempotencyKey) TableName() string { return "idempotency_keys" }
+
 // GetModelsToMigrate returns all models that need to be migrated
 func GetModelsToMigrate() []any {
 	return []any{
+		&Supplier{},
+		&Category{},
 		&Product{},
+		&ProductAttribute{},
+		&BundleComponent{},
+		&PriceRule{},
+		&ProductPrice{},
 		&Customer{},
 		&Order{},
+		&OrderLine{},
+		&OrderLineTax{},
 		&Transaction{},
+		&Promotion{},
+		&Coupon{},
+		&PromotionRedemption{},
 		&CustomerCooldown{},
+		&OrderStatusHistory{},
+		&OrderReturn{},
+		&CartItem{},
+		&IDSequence{},
+		&ExchangeRate{},
+		&TaxRate{},
+		&TaxRateComponent{},
+		&Invoice{},
+		&InvoiceLine{},
+		&InvoiceLineTax{},
+		&IdempotencyKey{},
+		&StockReservation{},
+		&Location{},
+		&LocationStock{},
+		&InventoryMovement{},
+		&InventoryBatch{},
+		&PurchaseOrder{},
+		&PurchaseOrderLine{},
+		&GoodsReceipt{},
+		&GoodsReceiptLine{},
 	}
 }
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// BatchRepositoryImpl implements the BatchRepository interface
+type BatchRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewBatchRepository creates a new inventory batch repository implementation
+func NewBatchRepository(db *gorm.DB) repositories.BatchRepository {
+	return &BatchRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create records a new batch
+func (r *BatchRepositoryImpl) Create(ctx context.Context, batch *entities.InventoryBatch) error {
+	model := persistence.BatchToModel(batch)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create inventory batch: %w", err)
+	}
+
+	persistence.ModelToBatch(model, batch)
+	return nil
+}
+
+// GetOpen retrieves batches with units remaining, oldest first
+func (r *BatchRepositoryImpl) GetOpen(ctx context.Context, productIDs ...string) ([]*entities.InventoryBatch, error) {
+	var models []persistence.InventoryBatch
+	query := dbFromContext(ctx, r.db).Where("remaining > 0").Order("created_at ASC, id ASC")
+	if len(productIDs) > 0 {
+		query = query.Where("product_id IN ?", productIDs)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get open inventory batches: %w", err)
+	}
+
+	return persistence.ModelsToBatches(models), nil
+}
+
+// GetByProduct retrieves all batches of a product, newest first
+func (r *BatchRepositoryImpl) GetByProduct(ctx context.Context, productID string, limit, offset int) ([]*entities.InventoryBatch, error) {
+	var models []persistence.InventoryBatch
+	if err := dbFromContext(ctx, r.db).
+		Where("product_id = ?", productID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get inventory batches: %w", err)
+	}
+
+	return persistence.ModelsToBatches(models), nil
+}
+
+// UpdateRemaining sets how many units of a batch are still on hand
+func (r *BatchRepositoryImpl) UpdateRemaining(ctx context.Context, batchID string, remaining int) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.InventoryBatch{}).
+		Where("id = ?", batchID).
+		Update("remaining", remaining)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update inventory batch: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("inventory batch with ID %s %w", batchID, repositories.ErrNotFound)
+	}
+
+	return nil
+}
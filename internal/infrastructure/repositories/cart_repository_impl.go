@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CartRepositoryImpl implements the CartRepository interface
+type CartRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewCartRepository creates a new cart repository implementation
+func NewCartRepository(db *gorm.DB) repositories.CartRepository {
+	return &CartRepositoryImpl{
+		db: db,
+	}
+}
+
+// GetItems returns the customer's cart items with their products, oldest first
+func (r *CartRepositoryImpl) GetItems(ctx context.Context, customerID string) ([]*entities.CartItem, error) {
+	var models []persistence.CartItem
+	if err := dbFromContext(ctx, r.db).
+		Preload("Product").
+		Preload("Product.Components.Product"). // a bundle's availability comes from its components
+		Where("customer_id = ?", customerID).
+		Order("created_at ASC, product_id ASC").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
+	}
+
+	return persistence.ModelsToCartItems(models), nil
+}
+
+// GetItem returns one item of the customer's cart
+func (r *CartRepositoryImpl) GetItem(ctx context.Context, customerID, productID string) (*entities.CartItem, error) {
+	var model persistence.CartItem
+	if err := dbFromContext(ctx, r.db).
+		Preload("Product").
+		First(&model, "customer_id = ? AND product_id = ?", customerID, productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product %s in cart of customer %s %w", productID, customerID, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get cart item: %w", err)
+	}
+
+	item := &entities.CartItem{}
+	persistence.ModelToCartItem(&model, item)
+	return item, nil
+}
+
+// SaveItem inserts the item or replaces the quantity of an existing one
+func (r *CartRepositoryImpl) SaveItem(ctx context.Context, item *entities.CartItem) error {
+	model := persistence.CartItemToModel(item)
+	model.UpdatedAt = time.Now().UTC()
+
+	if err := dbFromContext(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "customer_id"}, {Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"quantity", "updated_at"}),
+		}).
+		Create(model).Error; err != nil {
+		return fmt.Errorf("failed to save cart item: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveItem removes a product from the customer's cart
+func (r *CartRepositoryImpl) RemoveItem(ctx context.Context, customerID, productID string) error {
+	result := dbFromContext(ctx, r.db).
+		Delete(&persistence.CartItem{}, "customer_id = ? AND product_id = ?", customerID, productID)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove cart item: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("product %s in cart of customer %s %w", productID, customerID, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Clear empties the customer's cart
+func (r *CartRepositoryImpl) Clear(ctx context.Context, customerID string) error {
+	if err := dbFromContext(ctx, r.db).
+		Delete(&persistence.CartItem{}, "customer_id = ?", customerID).Error; err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+
+	return nil
+}
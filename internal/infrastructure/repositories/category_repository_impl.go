@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// CategoryRepositoryImpl implements the CategoryRepository interface
+type CategoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository creates a new category repository implementation
+func NewCategoryRepository(db *gorm.DB) repositories.CategoryRepository {
+	return &CategoryRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new category
+func (r *CategoryRepositoryImpl) Create(ctx context.Context, category *entities.Category) error {
+	model := persistence.CategoryToModel(category)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	persistence.ModelToCategory(model, category)
+	return nil
+}
+
+// GetByID retrieves a category by ID
+func (r *CategoryRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Category, error) {
+	var model persistence.Category
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("category with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	category := &entities.Category{}
+	persistence.ModelToCategory(&model, category)
+	return category, nil
+}
+
+// GetAll retrieves every category, parents before their children
+func (r *CategoryRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Category, error) {
+	var models []persistence.Category
+	if err := dbFromContext(ctx, r.db).Order("path").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	return persistence.ModelsToCategories(models), nil
+}
+
+// Update saves a category's name, parent and path, moving its descendants'
+// paths from oldPath to the new one in the same transaction
+func (r *CategoryRepositoryImpl) Update(ctx context.Context, category *entities.Category, oldPath string) error {
+	model := persistence.CategoryToModel(category)
+	model.UpdatedAt = time.Now().UTC()
+
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&persistence.Category{}).
+			Where("id = ?", model.ID).
+			Updates(map[string]any{
+				"name":       model.Name,
+				"parent_id":  model.ParentID,
+				"path":       model.Path,
+				"updated_at": model.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("category with ID %s %w", model.ID, repositories.ErrNotFound)
+		}
+		if oldPath == model.Path {
+			return nil
+		}
+
+		// Rewrite each descendant's path; LIKE matches the old subtree, which
+		// no longer includes the category itself
+		var descendants []persistence.Category
+		if err := tx.Where("path LIKE ?", oldPath+"%").Find(&descendants).Error; err != nil {
+			return err
+		}
+		for _, descendant := range descendants {
+			path := model.Path + descendant.Path[len(oldPath):]
+			if err := tx.Model(&persistence.Category{}).Where("id = ?", descendant.ID).
+				Updates(map[string]any{"path": path, "updated_at": model.UpdatedAt}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	persistence.ModelToCategory(model, category)
+	return nil
+}
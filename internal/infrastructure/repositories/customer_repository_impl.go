@@ -3,7 +3,6 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -11,12 +10,12 @@ import (
 	"day5/internal/infrastructure/persistence"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CustomerRepositoryImpl implements the CustomerRepository interface
 type CustomerRepositoryImpl struct {
 	db *gorm.DB
-	mu sync.RWMutex
 }
 
 // NewCustomerRepository creates a new customer repository implementation
@@ -26,13 +25,10 @@ func NewCustomerRepository(db *gorm.DB) repositories.CustomerRepository {
 	}
 }
 
-// Create creates a new customer with thread safety
+// Create creates a new customer
 func (r *CustomerRepositoryImpl) Create(ctx context.Context, customer *entities.Customer) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.CustomerToModel(customer)
-	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
 		return fmt.Errorf("failed to create customer: %w", err)
 	}
 
@@ -42,13 +38,10 @@ func (r *CustomerRepositoryImpl) Create(ctx context.Context, customer *entities.
 
 // GetByID retrieves a customer by ID
 func (r *CustomerRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Customer, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.Customer
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("customer with ID %s not found", id)
+			return nil, fmt.Errorf("customer with ID %s %w", id, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
@@ -58,15 +51,29 @@ func (r *CustomerRepositoryImpl) GetByID(ctx context.Context, id string) (*entit
 	return customer, nil
 }
 
+// LockForUpdate locks the customer row with SELECT ... FOR UPDATE
+// SQLite has no row locks; its single writer already serializes the unit of work
+func (r *CustomerRepositoryImpl) LockForUpdate(ctx context.Context, id string) error {
+	var model persistence.Customer
+	if err := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("customer with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return fmt.Errorf("failed to lock customer: %w", err)
+	}
+
+	return nil
+}
+
 // GetByEmail retrieves a customer by email
 func (r *CustomerRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entities.Customer, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.Customer
-	if err := r.db.WithContext(ctx).First(&model, "email = ?", email).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).First(&model, "email = ?", email).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("customer with email %s not found", email)
+			return nil, fmt.Errorf("customer with email %s %w", email, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get customer by email: %w", err)
 	}
@@ -78,11 +85,8 @@ func (r *CustomerRepositoryImpl) GetByEmail(ctx context.Context, email string) (
 
 // GetAll retrieves all customers with pagination
 func (r *CustomerRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entities.Customer, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Customer
-	query := r.db.WithContext(ctx).Order("created_at DESC")
+	query := dbFromContext(ctx, r.db).Order("created_at DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -98,31 +102,51 @@ func (r *CustomerRepositoryImpl) GetAll(ctx context.Context, limit, offset int)
 	return persistence.ModelsToCustomers(models), nil
 }
 
-// Update updates a customer
+// Update updates a customer if its version has not moved since it was read
 func (r *CustomerRepositoryImpl) Update(ctx context.Context, customer *entities.Customer) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.CustomerToModel(customer)
-	if err := r.db.WithContext(ctx).Save(model).Error; err != nil {
-		return fmt.Errorf("failed to update customer: %w", err)
+	model.UpdatedAt = time.Now().UTC()
+
+	result := dbFromContext(ctx, r.db).Model(&persistence.Customer{}).
+		Where("id = ? AND version = ?", model.ID, model.Version).
+		Updates(map[string]any{
+			"name":             model.Name,
+			"email":            model.Email,
+			"phone":            model.Phone,
+			"price_list":       model.PriceList,
+			"tax_jurisdiction": model.TaxJurisdiction,
+			"version":          gorm.Expr("version + 1"),
+			"updated_at":       model.UpdatedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update customer: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := dbFromContext(ctx, r.db).Model(&persistence.Customer{}).
+			Where("id = ?", model.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to update customer: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("customer with ID %s %w", model.ID, repositories.ErrNotFound)
+		}
+		return fmt.Errorf("customer with ID %s was modified concurrently: %w", model.ID, repositories.ErrVersionConflict)
 	}
 
+	model.Version++
 	persistence.ModelToCustomer(model, customer)
 	return nil
 }
 
 // Delete deletes a customer
 func (r *CustomerRepositoryImpl) Delete(ctx context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	result := r.db.WithContext(ctx).Delete(&persistence.Customer{}, "id = ?", id)
+	result := dbFromContext(ctx, r.db).Delete(&persistence.Customer{}, "id = ?", id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete customer: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("customer with ID %s not found", id)
+		return fmt.Errorf("customer with ID %s %w", id, repositories.ErrNotFound)
 	}
 
 	return nil
@@ -130,12 +154,9 @@ func (r *CustomerRepositoryImpl) Delete(ctx context.Context, id string) error {
 
 // SearchByName searches customers by name
 func (r *CustomerRepositoryImpl) SearchByName(ctx context.Context, name string) ([]*entities.Customer, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Customer
 	searchPattern := "%" + name + "%"
-	if err := r.db.WithContext(ctx).Where("name LIKE ?", searchPattern).Find(&models).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("name LIKE ?", searchPattern).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to search customers by name: %w", err)
 	}
 
@@ -144,12 +165,9 @@ func (r *CustomerRepositoryImpl) SearchByName(ctx context.Context, name string)
 
 // GetRecentCustomers gets customers registered in the last N days
 func (r *CustomerRepositoryImpl) GetRecentCustomers(ctx context.Context, days int) ([]*entities.Customer, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	since := time.Now().AddDate(0, 0, -days)
 	var models []persistence.Customer
-	if err := r.db.WithContext(ctx).Where("created_at >= ?", since).Order("created_at DESC").Find(&models).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Where("created_at >= ?", since).Order("created_at DESC").Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to get recent customers: %w", err)
 	}
 
@@ -158,11 +176,8 @@ func (r *CustomerRepositoryImpl) GetRecentCustomers(ctx context.Context, days in
 
 // Count returns the total number of customers
 func (r *CustomerRepositoryImpl) Count(ctx context.Context) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Customer{}).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Customer{}).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count customers: %w", err)
 	}
 
@@ -171,14 +186,11 @@ func (r *CustomerRepositoryImpl) Count(ctx context.Context) (int, error) {
 
 // GetActiveCustomers returns customers who have placed orders in the last N days
 func (r *CustomerRepositoryImpl) GetActiveCustomers(ctx context.Context, days int) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	since := time.Now().AddDate(0, 0, -days)
 	var count int64
 
 	subQuery := r.db.Select("DISTINCT customer_id").Table("orders").Where("created_at >= ?", since)
-	if err := r.db.WithContext(ctx).Model(&persistence.Customer{}).Where("id IN (?)", subQuery).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Customer{}).Where("id IN (?)", subQuery).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count active customers: %w", err)
 	}
 
@@ -188,7 +200,6 @@ func (r *CustomerRepositoryImpl) GetActiveCustomers(ctx context.Context, days in
 // CustomerCooldownRepositoryImpl implements the CustomerCooldownRepository interface
 type CustomerCooldownRepositoryImpl struct {
 	db *gorm.DB
-	mu sync.RWMutex
 }
 
 // NewCustomerCooldownRepository creates a new customer cooldown repository
@@ -200,13 +211,10 @@ func NewCustomerCooldownRepository(db *gorm.DB) repositories.CustomerCooldownRep
 
 // GetByCustomerID gets cooldown record by customer ID
 func (r *CustomerCooldownRepositoryImpl) GetByCustomerID(ctx context.Context, customerID string) (*entities.CustomerCooldown, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.CustomerCooldown
-	if err := r.db.WithContext(ctx).First(&model, "customer_id = ?", customerID).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).First(&model, "customer_id = ?", customerID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("cooldown record for customer %s not found", customerID)
+			return nil, fmt.Errorf("cooldown record for customer %s %w", customerID, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get cooldown: %w", err)
 	}
@@ -218,13 +226,10 @@ func (r *CustomerCooldownRepositoryImpl) GetByCustomerID(ctx context.Context, cu
 
 // Upsert creates or updates a cooldown record
 func (r *CustomerCooldownRepositoryImpl) Upsert(ctx context.Context, cooldown *entities.CustomerCooldown) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.CooldownToModel(cooldown)
 
 	// Use GORM's Clauses for proper upsert
-	if err := r.db.WithContext(ctx).Save(model).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Save(model).Error; err != nil {
 		return fmt.Errorf("failed to upsert cooldown: %w", err)
 	}
 
@@ -234,10 +239,7 @@ func (r *CustomerCooldownRepositoryImpl) Upsert(ctx context.Context, cooldown *e
 
 // Delete deletes a cooldown record
 func (r *CustomerCooldownRepositoryImpl) Delete(ctx context.Context, customerID string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	result := r.db.WithContext(ctx).Delete(&persistence.CustomerCooldown{}, "customer_id = ?", customerID)
+	result := dbFromContext(ctx, r.db).Delete(&persistence.CustomerCooldown{}, "customer_id = ?", customerID)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete cooldown: %w", result.Error)
 	}
@@ -247,11 +249,8 @@ func (r *CustomerCooldownRepositoryImpl) Delete(ctx context.Context, customerID
 
 // DeleteExpiredCooldowns deletes cooldown records older than specified hours
 func (r *CustomerCooldownRepositoryImpl) DeleteExpiredCooldowns(ctx context.Context, olderThanHours int) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	cutoff := time.Now().Add(-time.Duration(olderThanHours) * time.Hour)
-	result := r.db.WithContext(ctx).Where("last_order_time < ?", cutoff).Delete(&persistence.CustomerCooldown{})
+	result := dbFromContext(ctx, r.db).Where("last_order_time < ?", cutoff).Delete(&persistence.CustomerCooldown{})
 
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete expired cooldowns: %w", result.Error)
@@ -262,11 +261,8 @@ func (r *CustomerCooldownRepositoryImpl) DeleteExpiredCooldowns(ctx context.Cont
 
 // GetActiveCooldowns gets all active cooldown records
 func (r *CustomerCooldownRepositoryImpl) GetActiveCooldowns(ctx context.Context) ([]*entities.CustomerCooldown, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.CustomerCooldown
-	if err := r.db.WithContext(ctx).Find(&models).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to get active cooldowns: %w", err)
 	}
 
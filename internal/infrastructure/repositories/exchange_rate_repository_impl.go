@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRateRepositoryImpl implements the ExchangeRateRepository interface
+type ExchangeRateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository creates a new exchange rate repository implementation
+func NewExchangeRateRepository(db *gorm.DB) repositories.ExchangeRateRepository {
+	return &ExchangeRateRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create stores a new exchange rate
+func (r *ExchangeRateRepositoryImpl) Create(ctx context.Context, rate *entities.ExchangeRate) error {
+	model := persistence.ExchangeRateToModel(rate)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create exchange rate: %w", err)
+	}
+
+	persistence.ModelToExchangeRate(model, rate)
+	return nil
+}
+
+// GetByCurrency retrieves every rate for a currency, newest first
+func (r *ExchangeRateRepositoryImpl) GetByCurrency(ctx context.Context, currency string) ([]*entities.ExchangeRate, error) {
+	var models []persistence.ExchangeRate
+	if err := dbFromContext(ctx, r.db).Where("currency = ?", currency).
+		Order("effective_from DESC").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	return persistence.ModelsToExchangeRates(models), nil
+}
+
+// GetAll retrieves every rate, grouped by currency and newest first
+func (r *ExchangeRateRepositoryImpl) GetAll(ctx context.Context) ([]*entities.ExchangeRate, error) {
+	var models []persistence.ExchangeRate
+	if err := dbFromContext(ctx, r.db).Order("currency ASC, effective_from DESC").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+
+	return persistence.ModelsToExchangeRates(models), nil
+}
+
+// GetEffective retrieves the latest rate for a currency that took effect at or before at
+func (r *ExchangeRateRepositoryImpl) GetEffective(ctx context.Context, currency string, at time.Time) (*entities.ExchangeRate, error) {
+	var model persistence.ExchangeRate
+	if err := dbFromContext(ctx, r.db).Where("currency = ? AND effective_from <= ?", currency, at).
+		Order("effective_from DESC").First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("exchange rate for %s at %s %w", currency, at.Format(time.RFC3339), repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+
+	rate := &entities.ExchangeRate{}
+	persistence.ModelToExchangeRate(&model, rate)
+	return rate, nil
+}
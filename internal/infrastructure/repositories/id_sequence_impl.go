@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SequenceIDGenerator issues IDs from a per-prefix counter in the id_sequences
+// table, zero-padded to nine digits, e.g. ORD000123456
+// Inside a unit of work the counter row stays locked until the transaction
+// ends, so numbers are never reused but concurrent writers queue behind it
+type SequenceIDGenerator struct {
+	db *gorm.DB
+}
+
+// NewSequenceIDGenerator creates an ID generator backed by the id_sequences table
+func NewSequenceIDGenerator(db *gorm.DB) repositories.IDGenerator {
+	return &SequenceIDGenerator{
+		db: db,
+	}
+}
+
+// NewID increments the counter for prefix and returns the prefixed value
+func (g *SequenceIDGenerator) NewID(ctx context.Context, prefix string) (string, error) {
+	var value int64
+	next := func(tx *gorm.DB) error {
+		var err error
+		value, err = nextSequenceValue(tx, prefix)
+		return err
+	}
+
+	var err error
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		err = next(tx.WithContext(ctx))
+	} else {
+		err = g.db.WithContext(ctx).Transaction(next)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate %s ID: %w", prefix, err)
+	}
+
+	return fmt.Sprintf("%s%09d", prefix, value), nil
+}
+
+// nextSequenceValue bumps the counter for name, creating it on first use, and returns the new value
+func nextSequenceValue(tx *gorm.DB, name string) (int64, error) {
+	increment := func() (int64, error) {
+		result := tx.Model(&persistence.IDSequence{}).
+			Where("name = ?", name).
+			Update("last_value", gorm.Expr("last_value + 1"))
+		return result.RowsAffected, result.Error
+	}
+
+	updated, err := increment()
+	if err != nil {
+		return 0, err
+	}
+	if updated == 0 {
+		// First ID for this prefix; another writer may create the row concurrently
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&persistence.IDSequence{Name: name}).Error; err != nil {
+			return 0, err
+		}
+		if _, err := increment(); err != nil {
+			return 0, err
+		}
+	}
+
+	var sequence persistence.IDSequence
+	if err := tx.First(&sequence, "name = ?", name).Error; err != nil {
+		return 0, err
+	}
+
+	return sequence.LastValue, nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepositoryImpl implements the IdempotencyRepository interface
+type IdempotencyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency repository implementation
+func NewIdempotencyRepository(db *gorm.DB) repositories.IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create claims the record's key
+// ON CONFLICT DO NOTHING keeps the insert atomic on every dialect without
+// relying on driver-specific duplicate key errors
+func (r *IdempotencyRepositoryImpl) Create(ctx context.Context, record *entities.IdempotencyRecord) error {
+	result := dbFromContext(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(persistence.IdempotencyRecordToModel(record))
+	if result.Error != nil {
+		return fmt.Errorf("failed to create idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("idempotency key %s %w", record.Key, repositories.ErrAlreadyExists)
+	}
+
+	return nil
+}
+
+// GetByKey retrieves a record by its key
+func (r *IdempotencyRepositoryImpl) GetByKey(ctx context.Context, key string) (*entities.IdempotencyRecord, error) {
+	var model persistence.IdempotencyKey
+	if err := dbFromContext(ctx, r.db).Where("idempotency_key = ?", key).First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("idempotency key %s %w", key, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	record := &entities.IdempotencyRecord{}
+	persistence.ModelToIdempotencyRecord(&model, record)
+	return record, nil
+}
+
+// Update stores the response of a completed request
+func (r *IdempotencyRepositoryImpl) Update(ctx context.Context, record *entities.IdempotencyRecord) error {
+	model := persistence.IdempotencyRecordToModel(record)
+	result := dbFromContext(ctx, r.db).Model(model).Select("StatusCode", "ContentType", "Body").Updates(model)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update idempotency key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("idempotency key %s %w", record.Key, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Delete releases a key so the request can be retried
+func (r *IdempotencyRepositoryImpl) Delete(ctx context.Context, key string) error {
+	if err := dbFromContext(ctx, r.db).Delete(&persistence.IdempotencyKey{Key: key}).Error; err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIfExpired releases an expired key so it can be claimed again
+// The expiry is part of the delete, so two requests taking over the same key
+// cannot remove each other's fresh claim
+func (r *IdempotencyRepositoryImpl) DeleteIfExpired(ctx context.Context, key string, now time.Time) error {
+	if err := dbFromContext(ctx, r.db).Where("idempotency_key = ? AND expires_at <= ?", key, now).
+		Delete(&persistence.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("failed to delete expired idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes records that can no longer be replayed
+func (r *IdempotencyRepositoryImpl) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := dbFromContext(ctx, r.db).Where("expires_at <= ?", before).Delete(&persistence.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// invoiceSequence is the id_sequences counter invoice numbers are taken from
+const invoiceSequence = "invoice"
+
+// InvoiceRepositoryImpl implements the InvoiceRepository interface
+type InvoiceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewInvoiceRepository creates a new invoice repository implementation
+func NewInvoiceRepository(db *gorm.DB) repositories.InvoiceRepository {
+	return &InvoiceRepositoryImpl{
+		db: db,
+	}
+}
+
+// NextSequence bumps the invoice counter inside the active unit of work
+// The counter row stays locked until the transaction ends, so concurrent
+// orders take their numbers one after another and a rollback returns the number
+func (r *InvoiceRepositoryImpl) NextSequence(ctx context.Context) (int64, error) {
+	tx, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	if !ok {
+		return 0, errors.New("invoice numbers can only be allocated inside a unit of work")
+	}
+
+	sequence, err := nextSequenceValue(tx.WithContext(ctx), invoiceSequence)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+	return sequence, nil
+}
+
+// Create stores a new invoice with its lines and their taxes
+func (r *InvoiceRepositoryImpl) Create(ctx context.Context, invoice *entities.Invoice) error {
+	model := persistence.InvoiceToModel(invoice)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+	return nil
+}
+
+// GetByOrderID retrieves the invoice issued for an order
+func (r *InvoiceRepositoryImpl) GetByOrderID(ctx context.Context, orderID string) (*entities.Invoice, error) {
+	var model persistence.Invoice
+	err := dbFromContext(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number ASC")
+		}).
+		Preload("Lines.Taxes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("order_id = ?", orderID).First(&model).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("invoice for order %s %w", orderID, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	invoice := &entities.Invoice{}
+	persistence.ModelToInvoice(&model, invoice)
+	return invoice, nil
+}
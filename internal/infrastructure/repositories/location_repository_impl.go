@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LocationRepositoryImpl implements the LocationRepository interface
+type LocationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewLocationRepository creates a new location repository implementation
+func NewLocationRepository(db *gorm.DB) repositories.LocationRepository {
+	return &LocationRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new location unless its code is already taken
+func (r *LocationRepositoryImpl) Create(ctx context.Context, location *entities.Location) error {
+	model := persistence.LocationToModel(location)
+	result := dbFromContext(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(model)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create location: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("location with code %s %w", location.Code, repositories.ErrAlreadyExists)
+	}
+
+	persistence.ModelToLocation(model, location)
+	return nil
+}
+
+// GetByID retrieves a location by ID
+func (r *LocationRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Location, error) {
+	var model persistence.Location
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("location with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get location: %w", err)
+	}
+
+	location := &entities.Location{}
+	persistence.ModelToLocation(&model, location)
+	return location, nil
+}
+
+// GetAll retrieves all locations, the default first
+func (r *LocationRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Location, error) {
+	var models []persistence.Location
+	if err := dbFromContext(ctx, r.db).Order("is_default DESC, code").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get locations: %w", err)
+	}
+
+	return persistence.ModelsToLocations(models), nil
+}
+
+// GetDefault retrieves the location that takes stock naming no location
+func (r *LocationRepositoryImpl) GetDefault(ctx context.Context) (*entities.Location, error) {
+	var model persistence.Location
+	if err := dbFromContext(ctx, r.db).Where("is_default = ?", true).Order("created_at").First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("default location %w", repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get default location: %w", err)
+	}
+
+	location := &entities.Location{}
+	persistence.ModelToLocation(&model, location)
+	return location, nil
+}
+
+// AdjustStock changes the stock of a product at a location atomically
+// Additions upsert the row; removals are conditional so the stock can never
+// drop below what holds have set aside there
+func (r *LocationRepositoryImpl) AdjustStock(ctx context.Context, locationID, productID string, change int) error {
+	db := dbFromContext(ctx, r.db)
+	now := time.Now().UTC()
+
+	if change >= 0 {
+		err := db.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "location_id"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"quantity":   gorm.Expr("location_stock.quantity + ?", change),
+				"updated_at": now,
+			}),
+		}).Create(&persistence.LocationStock{
+			LocationID: locationID,
+			ProductID:  productID,
+			Quantity:   change,
+			UpdatedAt:  now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to adjust location stock: %w", err)
+		}
+		return nil
+	}
+
+	result := db.Model(&persistence.LocationStock{}).
+		Where("location_id = ? AND product_id = ? AND quantity - reserved_quantity >= ?", locationID, productID, -change).
+		Updates(map[string]any{
+			"quantity":   gorm.Expr("quantity + ?", change),
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to adjust location stock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("product with ID %s at location %s: %w", productID, locationID, repositories.ErrInsufficientStock)
+	}
+
+	return nil
+}
+
+// HoldStock moves unheld stock at a location into reserved_quantity atomically
+func (r *LocationRepositoryImpl) HoldStock(ctx context.Context, locationID, productID string, quantity int) error {
+	return r.adjustHeld(ctx, locationID, productID, quantity, "quantity - reserved_quantity >= ?", quantity)
+}
+
+// ReleaseHold makes held stock at a location available again atomically
+func (r *LocationRepositoryImpl) ReleaseHold(ctx context.Context, locationID, productID string, quantity int) error {
+	return r.adjustHeld(ctx, locationID, productID, -quantity, "reserved_quantity >= ?", quantity)
+}
+
+// adjustHeld adds change to the units held at a location when condition matches
+func (r *LocationRepositoryImpl) adjustHeld(ctx context.Context, locationID, productID string, change int, condition string, args ...any) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.LocationStock{}).
+		Where("location_id = ? AND product_id = ?", locationID, productID).Where(condition, args...).
+		Updates(map[string]any{
+			"reserved_quantity": gorm.Expr("reserved_quantity + ?", change),
+			"updated_at":        time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to adjust held location stock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("product with ID %s at location %s: %w", productID, locationID, repositories.ErrInsufficientStock)
+	}
+
+	return nil
+}
+
+// GetStock returns the stock of a product at a location; a product never stocked there has none
+func (r *LocationRepositoryImpl) GetStock(ctx context.Context, locationID, productID string) (int, error) {
+	var quantities []int
+	if err := dbFromContext(ctx, r.db).Model(&persistence.LocationStock{}).
+		Where("location_id = ? AND product_id = ?", locationID, productID).
+		Pluck("quantity", &quantities).Error; err != nil {
+		return 0, fmt.Errorf("failed to get location stock: %w", err)
+	}
+
+	if len(quantities) == 0 {
+		return 0, nil
+	}
+	return quantities[0], nil
+}
+
+// GetProductStock returns the stock of the given products at every location holding them
+func (r *LocationRepositoryImpl) GetProductStock(ctx context.Context, productIDs ...string) ([]*entities.StockLevel, error) {
+	return r.stockLevels(ctx, "ls.product_id IN ?", productIDs)
+}
+
+// GetLocationStock returns the stock of every product held at a location
+func (r *LocationRepositoryImpl) GetLocationStock(ctx context.Context, locationID string) ([]*entities.StockLevel, error) {
+	return r.stockLevels(ctx, "ls.location_id = ?", locationID)
+}
+
+// UnlocatedStock returns, per product, the part of products.quantity not held at any location
+// Products created before stock was tracked per location are the usual source
+func (r *LocationRepositoryImpl) UnlocatedStock(ctx context.Context) ([]*entities.StockLevel, error) {
+	var levels []*entities.StockLevel
+	if err := dbFromContext(ctx, r.db).Table("products p").
+		Select("p.id AS product_id, p.product_name, p.quantity - COALESCE(SUM(ls.quantity), 0) AS quantity").
+		Joins("LEFT JOIN location_stock ls ON ls.product_id = p.id").
+		Group("p.id, p.product_name, p.quantity").
+		Having("p.quantity - COALESCE(SUM(ls.quantity), 0) > 0").
+		Order("p.id").
+		Scan(&levels).Error; err != nil {
+		return nil, fmt.Errorf("failed to get unlocated stock: %w", err)
+	}
+
+	return levels, nil
+}
+
+// GetLowStock gets the products stocked at a location with less than threshold units there
+func (r *LocationRepositoryImpl) GetLowStock(ctx context.Context, locationID string, threshold int) ([]*entities.StockLevel, error) {
+	return r.stockLevels(ctx, "ls.location_id = ? AND ls.quantity < ?", locationID, threshold)
+}
+
+// GetTotalValue calculates the value of the stock at a location at selling price
+func (r *LocationRepositoryImpl) GetTotalValue(ctx context.Context, locationID string) (entities.Money, error) {
+	var totalValue int64
+	if err := dbFromContext(ctx, r.db).Table("location_stock ls").
+		Joins("JOIN products p ON p.id = ls.product_id").
+		Where("ls.location_id = ?", locationID).
+		Select("COALESCE(SUM(p.price_minor * ls.quantity), 0)").Scan(&totalValue).Error; err != nil {
+		return entities.Money{}, fmt.Errorf("failed to calculate location stock value: %w", err)
+	}
+
+	return entities.NewMoney(totalValue, ""), nil
+}
+
+// stockLevels lists location stock rows matching a condition, with product names
+func (r *LocationRepositoryImpl) stockLevels(ctx context.Context, condition string, args ...any) ([]*entities.StockLevel, error) {
+	var levels []*entities.StockLevel
+	if err := dbFromContext(ctx, r.db).Table("location_stock ls").
+		Select("ls.product_id, p.product_name, ls.location_id, ls.quantity, ls.reserved_quantity AS reserved").
+		Joins("JOIN products p ON p.id = ls.product_id").
+		Where(condition, args...).
+		Order("ls.product_id, ls.location_id").
+		Scan(&levels).Error; err != nil {
+		return nil, fmt.Errorf("failed to get location stock: %w", err)
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// MovementRepositoryImpl implements the MovementRepository interface
+type MovementRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewMovementRepository creates a new inventory movement repository implementation
+func NewMovementRepository(db *gorm.DB) repositories.MovementRepository {
+	return &MovementRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create appends a movement to the ledger
+func (r *MovementRepositoryImpl) Create(ctx context.Context, movement *entities.InventoryMovement) error {
+	model := persistence.MovementToModel(movement)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create inventory movement: %w", err)
+	}
+
+	persistence.ModelToMovement(model, movement)
+	return nil
+}
+
+// Find retrieves movements matching the filter, newest first
+func (r *MovementRepositoryImpl) Find(ctx context.Context, filter repositories.MovementFilter) ([]*entities.InventoryMovement, error) {
+	var models []persistence.InventoryMovement
+	query := dbFromContext(ctx, r.db).Order("created_at DESC, id DESC")
+
+	if filter.ProductID != "" {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", string(filter.Reason))
+	}
+	if filter.ReferenceID != "" {
+		query = query.Where("reference_id = ?", filter.ReferenceID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find inventory movements: %w", err)
+	}
+
+	return persistence.ModelsToMovements(models), nil
+}
+
+// GetNetChanges sums, per product, the change of movements with the given reasons since a point in time
+func (r *MovementRepositoryImpl) GetNetChanges(ctx context.Context, since time.Time, reasons ...entities.MovementReason) (map[string]int, error) {
+	var rows []struct {
+		ProductID string
+		Total     int
+	}
+	if err := dbFromContext(ctx, r.db).Model(&persistence.InventoryMovement{}).
+		Select("product_id, SUM(quantity_change) AS total").
+		Where("created_at >= ? AND reason IN ?", since, reasons).
+		Group("product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum inventory movements: %w", err)
+	}
+
+	totals := make(map[string]int, len(rows))
+	for _, row := range rows {
+		totals[row.ProductID] = row.Total
+	}
+	return totals, nil
+}
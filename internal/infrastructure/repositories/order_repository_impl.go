@@ -3,7 +3,6 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -11,12 +10,12 @@ import (
 	"day5/internal/infrastructure/persistence"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // OrderRepositoryImpl implements the OrderRepository interface
 type OrderRepositoryImpl struct {
 	db *gorm.DB
-	mu sync.RWMutex
 }
 
 // NewOrderRepository creates a new order repository implementation
@@ -26,13 +25,10 @@ func NewOrderRepository(db *gorm.DB) repositories.OrderRepository {
 	}
 }
 
-// Create creates a new order with thread safety
+// Create creates a new order
 func (r *OrderRepositoryImpl) Create(ctx context.Context, order *entities.Order) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.OrderToModel(order)
-	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
 		return fmt.Errorf("failed to create order: %w", err)
 	}
 
@@ -42,16 +38,14 @@ func (r *OrderRepositoryImpl) Create(ctx context.Context, order *entities.Order)
 
 // GetByID retrieves an order by ID
 func (r *OrderRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Order, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.Order
-	if err := r.db.WithContext(ctx).
-		Preload("Customer").
-		Preload("Product").
+	if err := withOrderDetails(dbFromContext(ctx, r.db)).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB {
+			return db.Order("changed_at ASC, id ASC")
+		}).
 		First(&model, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("order with ID %s not found", id)
+			return nil, fmt.Errorf("order with ID %s %w", id, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
@@ -61,15 +55,27 @@ func (r *OrderRepositoryImpl) GetByID(ctx context.Context, id string) (*entities
 	return order, nil
 }
 
+// LockForUpdate locks the order row with SELECT ... FOR UPDATE
+// SQLite has no row locks; its single writer already serializes the unit of work
+func (r *OrderRepositoryImpl) LockForUpdate(ctx context.Context, id string) error {
+	var model persistence.Order
+	if err := dbFromContext(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("order with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	return nil
+}
+
 // GetAll retrieves all orders with pagination
 func (r *OrderRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entities.Order, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Order
-	query := r.db.WithContext(ctx).
-		Preload("Customer").
-		Preload("Product").
+	query := withOrderDetails(dbFromContext(ctx, r.db)).
 		Order("created_at DESC")
 	
 	if limit > 0 {
@@ -88,11 +94,11 @@ func (r *OrderRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]
 
 // Update updates an order
 func (r *OrderRepositoryImpl) Update(ctx context.Context, order *entities.Order) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.OrderToModel(order)
-	if err := r.db.WithContext(ctx).Save(model).Error; err != nil {
+
+	// Status history is append-only and written through UpdateStatus;
+	// lines are fixed once the order is placed
+	if err := dbFromContext(ctx, r.db).Omit("StatusHistory", "Lines").Save(model).Error; err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
@@ -100,17 +106,74 @@ func (r *OrderRepositoryImpl) Update(ctx context.Context, order *entities.Order)
 	return nil
 }
 
+// Find retrieves orders matching the filter, newest first
+func (r *OrderRepositoryImpl) Find(ctx context.Context, filter repositories.OrderFilter) ([]*entities.Order, error) {
+	var models []persistence.Order
+	query := withOrderDetails(dbFromContext(ctx, r.db)).
+		Order("created_at DESC")
+
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find orders: %w", err)
+	}
+
+	return persistence.ModelsToOrders(models), nil
+}
+
+// UpdateStatus applies a status change and appends it to the order's history
+// The update only matches while the order is still in the change's source status,
+// so two concurrent transitions cannot both succeed
+func (r *OrderRepositoryImpl) UpdateStatus(ctx context.Context, orderID string, change *entities.OrderStatusChange) error {
+	db := dbFromContext(ctx, r.db)
+
+	result := db.Model(&persistence.Order{}).
+		Where("id = ? AND status = ?", orderID, string(change.FromStatus)).
+		Updates(map[string]any{
+			"status":     string(change.ToStatus),
+			"updated_at": change.ChangedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update order status: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := db.Model(&persistence.Order{}).Where("id = ?", orderID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("order with ID %s %w", orderID, repositories.ErrNotFound)
+		}
+		return fmt.Errorf("order with ID %s is no longer %s: %w", orderID, change.FromStatus, repositories.ErrVersionConflict)
+	}
+
+	if err := db.Create(persistence.StatusChangeToModel(change)).Error; err != nil {
+		return fmt.Errorf("failed to record order status change: %w", err)
+	}
+
+	return nil
+}
+
 // Delete deletes an order
 func (r *OrderRepositoryImpl) Delete(ctx context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	result := r.db.WithContext(ctx).Delete(&persistence.Order{}, "id = ?", id)
+	result := dbFromContext(ctx, r.db).Delete(&persistence.Order{}, "id = ?", id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete order: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("order with ID %s not found", id)
+		return fmt.Errorf("order with ID %s %w", id, repositories.ErrNotFound)
 	}
 
 	return nil
@@ -118,13 +181,8 @@ func (r *OrderRepositoryImpl) Delete(ctx context.Context, id string) error {
 
 // GetByCustomerID gets orders for a specific customer
 func (r *OrderRepositoryImpl) GetByCustomerID(ctx context.Context, customerID string, limit, offset int) ([]*entities.Order, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Order
-	query := r.db.WithContext(ctx).
-		Preload("Customer").
-		Preload("Product").
+	query := withOrderDetails(dbFromContext(ctx, r.db)).
 		Where("customer_id = ?", customerID).
 		Order("created_at DESC")
 	
@@ -144,11 +202,8 @@ func (r *OrderRepositoryImpl) GetByCustomerID(ctx context.Context, customerID st
 
 // GetCustomerOrderCount gets the total number of orders for a customer
 func (r *OrderRepositoryImpl) GetCustomerOrderCount(ctx context.Context, customerID string) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Order{}).
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Order{}).
 		Where("customer_id = ?", customerID).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count customer orders: %w", err)
 	}
@@ -158,14 +213,9 @@ func (r *OrderRepositoryImpl) GetCustomerOrderCount(ctx context.Context, custome
 
 // GetByProductID gets orders for a specific product
 func (r *OrderRepositoryImpl) GetByProductID(ctx context.Context, productID string, limit, offset int) ([]*entities.Order, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Order
-	query := r.db.WithContext(ctx).
-		Preload("Customer").
-		Preload("Product").
-		Where("product_id = ?", productID).
+	query := withOrderDetails(dbFromContext(ctx, r.db)).
+		Where("product_id = ? OR id IN (?)", productID, r.ordersWithProduct(ctx, productID)).
 		Order("created_at DESC")
 	
 	if limit > 0 {
@@ -182,15 +232,17 @@ func (r *OrderRepositoryImpl) GetByProductID(ctx context.Context, productID stri
 	return persistence.ModelsToOrders(models), nil
 }
 
+// ordersWithProduct selects the IDs of orders with a line for the product
+func (r *OrderRepositoryImpl) ordersWithProduct(ctx context.Context, productID string) *gorm.DB {
+	return dbFromContext(ctx, r.db).Model(&persistence.OrderLine{}).
+		Select("order_id").
+		Where("product_id = ?", productID)
+}
+
 // GetByDateRange gets orders within a date range
 func (r *OrderRepositoryImpl) GetByDateRange(ctx context.Context, start, end time.Time) ([]*entities.Order, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Order
-	if err := r.db.WithContext(ctx).
-		Preload("Customer").
-		Preload("Product").
+	if err := withOrderDetails(dbFromContext(ctx, r.db)).
 		Where("order_date BETWEEN ? AND ?", start, end).
 		Order("order_date DESC").
 		Find(&models).Error; err != nil {
@@ -211,15 +263,10 @@ func (r *OrderRepositoryImpl) GetTodaysOrders(ctx context.Context) ([]*entities.
 
 // GetRecentOrders gets orders from the last N hours
 func (r *OrderRepositoryImpl) GetRecentOrders(ctx context.Context, hours int) ([]*entities.Order, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	since := time.Now().Add(-time.Duration(hours) * time.Hour)
 	
 	var models []persistence.Order
-	if err := r.db.WithContext(ctx).
-		Preload("Customer").
-		Preload("Product").
+	if err := withOrderDetails(dbFromContext(ctx, r.db)).
 		Where("order_date >= ?", since).
 		Order("order_date DESC").
 		Find(&models).Error; err != nil {
@@ -235,32 +282,26 @@ func (r *OrderRepositoryImpl) GetOrdersWithDetails(ctx context.Context, limit, o
 }
 
 // GetTotalRevenue calculates total revenue for a period
-func (r *OrderRepositoryImpl) GetTotalRevenue(ctx context.Context, start, end *time.Time) (float64, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var totalRevenue float64
-	query := r.db.WithContext(ctx).Model(&persistence.Order{}).
-		Select("SUM(total_amount)")
+func (r *OrderRepositoryImpl) GetTotalRevenue(ctx context.Context, start, end *time.Time) (entities.Money, error) {
+	var totalRevenue int64
+	query := dbFromContext(ctx, r.db).Model(&persistence.Order{}).
+		Select("COALESCE(SUM(total_amount_minor), 0)")
 	
 	if start != nil && end != nil {
 		query = query.Where("order_date BETWEEN ? AND ?", *start, *end)
 	}
 
 	if err := query.Scan(&totalRevenue).Error; err != nil {
-		return 0, fmt.Errorf("failed to calculate total revenue: %w", err)
+		return entities.Money{}, fmt.Errorf("failed to calculate total revenue: %w", err)
 	}
 
-	return totalRevenue, nil
+	return entities.NewMoney(totalRevenue, ""), nil
 }
 
 // GetOrderCountByPeriod gets order count for a specific period
 func (r *OrderRepositoryImpl) GetOrderCountByPeriod(ctx context.Context, start, end time.Time) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Order{}).
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Order{}).
 		Where("order_date BETWEEN ? AND ?", start, end).
 		Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count orders by period: %w", err)
@@ -271,11 +312,8 @@ func (r *OrderRepositoryImpl) GetOrderCountByPeriod(ctx context.Context, start,
 
 // Count returns the total number of orders
 func (r *OrderRepositoryImpl) Count(ctx context.Context) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Order{}).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Order{}).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count orders: %w", err)
 	}
 
@@ -283,15 +321,35 @@ func (r *OrderRepositoryImpl) Count(ctx context.Context) (int, error) {
 }
 
 // GetAverageOrderValue calculates the average order value
-func (r *OrderRepositoryImpl) GetAverageOrderValue(ctx context.Context) (float64, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// The sum is taken in SQL and divided here so the result rounds like every other amount
+func (r *OrderRepositoryImpl) GetAverageOrderValue(ctx context.Context) (entities.Money, error) {
+	var totals struct {
+		Total int64
+		Count int64
+	}
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Order{}).
+		Select("COALESCE(SUM(total_amount_minor), 0) AS total, COUNT(*) AS count").
+		Scan(&totals).Error; err != nil {
+		return entities.Money{}, fmt.Errorf("failed to calculate average order value: %w", err)
+	}
 
-	var avgValue float64
-	if err := r.db.WithContext(ctx).Model(&persistence.Order{}).
-		Select("AVG(total_amount)").Scan(&avgValue).Error; err != nil {
-		return 0, fmt.Errorf("failed to calculate average order value: %w", err)
+	total := entities.NewMoney(totals.Total, "")
+	if totals.Count == 0 {
+		return total, nil
 	}
+	return total.Div(int(totals.Count)), nil
+}
 
-	return avgValue, nil
+// withOrderDetails preloads the customer, product and lines, with their taxes, returned with an order
+func withOrderDetails(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Customer").
+		Preload("Product").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number ASC")
+		}).
+		Preload("Lines.Product").
+		Preload("Lines.Taxes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		})
 }
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// PriceRuleRepositoryImpl implements the PriceRuleRepository interface
+type PriceRuleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPriceRuleRepository creates a new price rule repository implementation
+func NewPriceRuleRepository(db *gorm.DB) repositories.PriceRuleRepository {
+	return &PriceRuleRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create stores a new price rule
+func (r *PriceRuleRepositoryImpl) Create(ctx context.Context, rule *entities.PriceRule) error {
+	model := persistence.PriceRuleToModel(rule)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create price rule: %w", err)
+	}
+
+	persistence.ModelToPriceRule(model, rule)
+	return nil
+}
+
+// GetByID retrieves a price rule by ID
+func (r *PriceRuleRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.PriceRule, error) {
+	var model persistence.PriceRule
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("price rule with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get price rule: %w", err)
+	}
+
+	rule := &entities.PriceRule{}
+	persistence.ModelToPriceRule(&model, rule)
+	return rule, nil
+}
+
+// GetAll retrieves the rules for a product, or every rule, oldest first
+func (r *PriceRuleRepositoryImpl) GetAll(ctx context.Context, productID string) ([]*entities.PriceRule, error) {
+	var models []persistence.PriceRule
+	query := dbFromContext(ctx, r.db).Order("created_at, id")
+	if productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get price rules: %w", err)
+	}
+
+	return persistence.ModelsToPriceRules(models), nil
+}
+
+// GetActive retrieves the rules in effect at the given time for any of
+// productIDs or the whole catalogue, oldest first
+func (r *PriceRuleRepositoryImpl) GetActive(ctx context.Context, at time.Time, productIDs ...string) ([]*entities.PriceRule, error) {
+	var models []persistence.PriceRule
+	query := dbFromContext(ctx, r.db).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at)
+	if len(productIDs) > 0 {
+		query = query.Where("product_id IS NULL OR product_id IN ?", productIDs)
+	} else {
+		query = query.Where("product_id IS NULL")
+	}
+
+	if err := query.Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get price rules: %w", err)
+	}
+
+	return persistence.ModelsToPriceRules(models), nil
+}
+
+// End stores the rule's end time
+func (r *PriceRuleRepositoryImpl) End(ctx context.Context, rule *entities.PriceRule) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.PriceRule{}).
+		Where("id = ?", rule.ID).
+		Update("ends_at", rule.EndsAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to end price rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("price rule with ID %s %w", rule.ID, repositories.ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// ProductPriceRepositoryImpl implements the ProductPriceRepository interface
+type ProductPriceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewProductPriceRepository creates a new product price repository implementation
+func NewProductPriceRepository(db *gorm.DB) repositories.ProductPriceRepository {
+	return &ProductPriceRepositoryImpl{
+		db: db,
+	}
+}
+
+// Record stores a new price and links it into the product's history
+func (r *ProductPriceRepositoryImpl) Record(ctx context.Context, price *entities.ProductPrice) error {
+	model := persistence.ProductPriceToModel(price)
+
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var clashes int64
+		if err := tx.Model(&persistence.ProductPrice{}).
+			Where("product_id = ? AND effective_from = ?", model.ProductID, model.EffectiveFrom).
+			Count(&clashes).Error; err != nil {
+			return err
+		}
+		if clashes > 0 {
+			return fmt.Errorf("price for product %s from %s %w",
+				model.ProductID, model.EffectiveFrom.Format(time.RFC3339), repositories.ErrAlreadyExists)
+		}
+
+		// The new price lasts until the next one takes over
+		var next []persistence.ProductPrice
+		if err := tx.Where("product_id = ? AND effective_from > ?", model.ProductID, model.EffectiveFrom).
+			Order("effective_from").Limit(1).Find(&next).Error; err != nil {
+			return err
+		}
+		model.EffectiveTo = nil
+		if len(next) > 0 {
+			model.EffectiveTo = &next[0].EffectiveFrom
+		}
+
+		// and the price before it ends where it starts
+		if err := tx.Model(&persistence.ProductPrice{}).
+			Where("product_id = ? AND effective_from < ?", model.ProductID, model.EffectiveFrom).
+			Where("effective_to IS NULL OR effective_to > ?", model.EffectiveFrom).
+			Update("effective_to", model.EffectiveFrom).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(model).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record price: %w", err)
+	}
+
+	persistence.ModelToProductPrice(model, price)
+	return nil
+}
+
+// GetByID retrieves a product price by ID
+func (r *ProductPriceRepositoryImpl) GetByID(ctx context.Context, id uint) (*entities.ProductPrice, error) {
+	var model persistence.ProductPrice
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("product price with ID %d %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get product price: %w", err)
+	}
+
+	price := &entities.ProductPrice{}
+	persistence.ModelToProductPrice(&model, price)
+	return price, nil
+}
+
+// GetByProductID retrieves a product's prices, oldest first
+func (r *ProductPriceRepositoryImpl) GetByProductID(ctx context.Context, productID string) ([]*entities.ProductPrice, error) {
+	var models []persistence.ProductPrice
+	if err := dbFromContext(ctx, r.db).Where("product_id = ?", productID).
+		Order("effective_from").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+
+	return persistence.ModelsToProductPrices(models), nil
+}
+
+// GetDue retrieves scheduled prices that took effect at or before at, the earliest first
+func (r *ProductPriceRepositoryImpl) GetDue(ctx context.Context, at time.Time, limit int) ([]*entities.ProductPrice, error) {
+	var models []persistence.ProductPrice
+	if err := dbFromContext(ctx, r.db).
+		Where("activated_at IS NULL AND effective_from <= ?", at).
+		Order("effective_from, id").Limit(limit).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get scheduled prices: %w", err)
+	}
+
+	return persistence.ModelsToProductPrices(models), nil
+}
+
+// Activate stores the activation time of a scheduled price
+func (r *ProductPriceRepositoryImpl) Activate(ctx context.Context, price *entities.ProductPrice) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.ProductPrice{}).
+		Where("id = ? AND activated_at IS NULL", price.ID).
+		Update("activated_at", price.ActivatedAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to activate price: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("scheduled price with ID %d %w", price.ID, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Cancel deletes a scheduled price and closes the gap it leaves
+func (r *ProductPriceRepositoryImpl) Cancel(ctx context.Context, price *entities.ProductPrice) error {
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var model persistence.ProductPrice
+		if err := tx.First(&model, "id = ? AND activated_at IS NULL", price.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("scheduled price with ID %d %w", price.ID, repositories.ErrNotFound)
+			}
+			return err
+		}
+
+		if err := tx.Delete(&model).Error; err != nil {
+			return err
+		}
+		return tx.Model(&persistence.ProductPrice{}).
+			Where("product_id = ? AND effective_to = ?", model.ProductID, model.EffectiveFrom).
+			Update("effective_to", model.EffectiveTo).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel price: %w", err)
+	}
+
+	return nil
+}
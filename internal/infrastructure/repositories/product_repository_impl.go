@@ -3,19 +3,21 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"sync"
+	"slices"
+	"strings"
+	"time"
 
 	"day5/internal/domain/entities"
 	"day5/internal/domain/repositories"
 	"day5/internal/infrastructure/persistence"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepositoryImpl implements the ProductRepository interface
 type ProductRepositoryImpl struct {
 	db *gorm.DB
-	mu sync.RWMutex // Thread safety
 }
 
 // NewProductRepository creates a new product repository implementation
@@ -25,13 +27,16 @@ func NewProductRepository(db *gorm.DB) repositories.ProductRepository {
 	}
 }
 
-// Create creates a new product with thread safety
+// Create creates a new product with its attributes unless its SKU or barcode is taken
 func (r *ProductRepositoryImpl) Create(ctx context.Context, product *entities.Product) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.ProductToModel(product)
-	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkCatalogueCodes(tx, model); err != nil {
+			return err
+		}
+		return tx.Create(model).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 
@@ -40,15 +45,12 @@ func (r *ProductRepositoryImpl) Create(ctx context.Context, product *entities.Pr
 	return nil
 }
 
-// GetByID retrieves a product by ID with thread safety
+// GetByID retrieves a product by ID
 func (r *ProductRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Product, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.Product
-	if err := r.db.WithContext(ctx).First(&model, "id = ?", id).Error; err != nil {
+	if err := r.withDetails(ctx).First(&model, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("product with ID %s not found", id)
+			return nil, fmt.Errorf("product with ID %s %w", id, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get product: %w", err)
 	}
@@ -58,13 +60,10 @@ func (r *ProductRepositoryImpl) GetByID(ctx context.Context, id string) (*entiti
 	return product, nil
 }
 
-// GetAll retrieves all products with pagination and thread safety
+// GetAll retrieves all products with pagination
 func (r *ProductRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entities.Product, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Product
-	query := r.db.WithContext(ctx).Order("created_at DESC")
+	query := r.withDetails(ctx).Order("created_at DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit)
@@ -86,43 +85,86 @@ func (r *ProductRepositoryImpl) GetAll(ctx context.Context, limit, offset int) (
 	return products, nil
 }
 
-// Update updates a product with thread safety
+// Update updates a product if its version has not moved since it was read
 func (r *ProductRepositoryImpl) Update(ctx context.Context, product *entities.Product) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.ProductToModel(product)
-	if err := r.db.WithContext(ctx).Save(model).Error; err != nil {
+	model.UpdatedAt = time.Now().UTC()
+
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := checkCatalogueCodes(tx, model); err != nil {
+			return err
+		}
+
+		result := tx.Model(&persistence.Product{}).
+			Where("id = ? AND version = ?", model.ID, model.Version).
+			Updates(map[string]any{
+				"product_name":     model.ProductName,
+				"price_minor":      model.PriceMinor,
+				"currency":         model.Currency,
+				"quantity":         model.Quantity,
+				"reorder_point":    model.ReorderPoint,
+				"reorder_quantity": model.ReorderQuantity,
+				"lead_time_days":   model.LeadTimeDays,
+				"supplier_id":      model.SupplierID,
+				"sku":              model.SKU,
+				"barcode":          model.Barcode,
+				"description":      model.Description,
+				"category_id":      model.CategoryID,
+				"status":           model.Status,
+				"tax_class":        model.TaxClass,
+				"price_override":   model.PriceOverride,
+				"version":          gorm.Expr("version + 1"),
+				"updated_at":       model.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&persistence.Product{}).Where("id = ?", model.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("product with ID %s %w", model.ID, repositories.ErrNotFound)
+			}
+			return fmt.Errorf("product with ID %s was modified concurrently: %w", model.ID, repositories.ErrVersionConflict)
+		}
+
+		// Attributes are replaced as a whole
+		if err := tx.Where("product_id = ?", model.ID).Delete(&persistence.ProductAttribute{}).Error; err != nil {
+			return err
+		}
+		if len(model.Attributes) == 0 {
+			return nil
+		}
+		return tx.Omit("Product").Create(&model.Attributes).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
+	model.Version++
 	persistence.ModelToProduct(model, product)
 	return nil
 }
 
-// Delete deletes a product with thread safety
+// Delete deletes a product
 func (r *ProductRepositoryImpl) Delete(ctx context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	result := r.db.WithContext(ctx).Delete(&persistence.Product{}, "id = ?", id)
+	result := dbFromContext(ctx, r.db).Delete(&persistence.Product{}, "id = ?", id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete product: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("product with ID %s not found", id)
+		return fmt.Errorf("product with ID %s %w", id, repositories.ErrNotFound)
 	}
 
 	return nil
 }
 
-// GetAvailableProducts gets products with quantity > 0
+// GetAvailableProducts gets products with stock that is not held by reservations
 func (r *ProductRepositoryImpl) GetAvailableProducts(ctx context.Context) ([]*entities.Product, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Product
-	if err := r.db.WithContext(ctx).Where("quantity > 0").Find(&models).Error; err != nil {
+	if err := r.withDetails(ctx).Where("quantity > reserved_quantity").Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to get available products: %w", err)
 	}
 
@@ -135,13 +177,26 @@ func (r *ProductRepositoryImpl) GetAvailableProducts(ctx context.Context) ([]*en
 	return products, nil
 }
 
-// GetByPriceRange gets products within a price range
-func (r *ProductRepositoryImpl) GetByPriceRange(ctx context.Context, minPrice, maxPrice float64) ([]*entities.Product, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// GetStockedProducts gets products with stock on hand, reserved or not
+func (r *ProductRepositoryImpl) GetStockedProducts(ctx context.Context) ([]*entities.Product, error) {
+	var models []persistence.Product
+	if err := r.withDetails(ctx).Where("quantity > 0").Order("id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get stocked products: %w", err)
+	}
+
+	return persistence.ModelsToProducts(models), nil
+}
+
+// GetByPriceRange gets products priced within a range, in the range's currency
+func (r *ProductRepositoryImpl) GetByPriceRange(ctx context.Context, minPrice, maxPrice entities.Money) ([]*entities.Product, error) {
+	if _, err := minPrice.Cmp(maxPrice); err != nil {
+		return nil, fmt.Errorf("invalid price range: %w", err)
+	}
 
 	var models []persistence.Product
-	if err := r.db.WithContext(ctx).Where("price BETWEEN ? AND ?", minPrice, maxPrice).Find(&models).Error; err != nil {
+	if err := r.withDetails(ctx).
+		Where("currency = ? AND price_minor BETWEEN ? AND ?", minPrice.Currency(), minPrice.Minor(), maxPrice.Minor()).
+		Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to get products by price range: %w", err)
 	}
 
@@ -156,11 +211,8 @@ func (r *ProductRepositoryImpl) GetByPriceRange(ctx context.Context, minPrice, m
 
 // GetLowStockProducts gets products with quantity below threshold
 func (r *ProductRepositoryImpl) GetLowStockProducts(ctx context.Context, threshold int) ([]*entities.Product, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Product
-	if err := r.db.WithContext(ctx).Where("quantity < ?", threshold).Find(&models).Error; err != nil {
+	if err := r.withDetails(ctx).Where("quantity < ?", threshold).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to get low stock products: %w", err)
 	}
 
@@ -173,39 +225,108 @@ func (r *ProductRepositoryImpl) GetLowStockProducts(ctx context.Context, thresho
 	return products, nil
 }
 
+// GetBelowReorderPoint gets products with a reorder point whose available stock is at or below it
+func (r *ProductRepositoryImpl) GetBelowReorderPoint(ctx context.Context) ([]*entities.Product, error) {
+	var models []persistence.Product
+	if err := r.withDetails(ctx).
+		Where("reorder_point > 0 AND quantity - reserved_quantity <= reorder_point").
+		Order("id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get products below reorder point: %w", err)
+	}
+
+	return persistence.ModelsToProducts(models), nil
+}
+
 // ReduceQuantity reduces product quantity atomically
+// The conditional UPDATE only matches while enough unreserved stock remains, so
+// concurrent orders across processes can never oversell or overwrite each other
 func (r *ProductRepositoryImpl) ReduceQuantity(ctx context.Context, productID string, quantity int) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	return r.adjustStock(ctx, productID, map[string]any{
+		"quantity": gorm.Expr("quantity - ?", quantity),
+	}, "quantity - reserved_quantity >= ?", quantity)
+}
 
-	result := r.db.WithContext(ctx).Model(&persistence.Product{}).
-		Where("id = ? AND quantity >= ?", productID, quantity).
-		Update("quantity", gorm.Expr("quantity - ?", quantity))
+// IncreaseQuantity increases product quantity atomically
+func (r *ProductRepositoryImpl) IncreaseQuantity(ctx context.Context, productID string, quantity int) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.Product{}).
+		Where("id = ?", productID).
+		Updates(map[string]any{
+			"quantity": gorm.Expr("quantity + ?", quantity),
+			"version":  gorm.Expr("version + 1"),
+		})
 
 	if result.Error != nil {
-		return fmt.Errorf("failed to reduce quantity: %w", result.Error)
+		return fmt.Errorf("failed to increase quantity: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("insufficient quantity or product not found")
+		return fmt.Errorf("product with ID %s %w", productID, repositories.ErrNotFound)
 	}
 
 	return nil
 }
 
-// IncreaseQuantity increases product quantity atomically
-func (r *ProductRepositoryImpl) IncreaseQuantity(ctx context.Context, productID string, quantity int) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	result := r.db.WithContext(ctx).Model(&persistence.Product{}).
+// UpdateAverageCost stores a product's weighted average cost
+// It is called after the stock change that moved the average, in the same
+// unit of work, so the product row is already locked
+func (r *ProductRepositoryImpl) UpdateAverageCost(ctx context.Context, productID string, averageCost entities.Money) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.Product{}).
 		Where("id = ?", productID).
-		Update("quantity", gorm.Expr("quantity + ?", quantity))
+		Update("average_cost_minor", averageCost.Minor())
 
 	if result.Error != nil {
-		return fmt.Errorf("failed to increase quantity: %w", result.Error)
+		return fmt.Errorf("failed to update average cost: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return fmt.Errorf("product with ID %s %w", productID, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// HoldQuantity moves unreserved stock into reserved_quantity atomically
+func (r *ProductRepositoryImpl) HoldQuantity(ctx context.Context, productID string, quantity int) error {
+	return r.adjustStock(ctx, productID, map[string]any{
+		"reserved_quantity": gorm.Expr("reserved_quantity + ?", quantity),
+	}, "quantity - reserved_quantity >= ?", quantity)
+}
+
+// ConfirmHold takes held stock off hand atomically
+func (r *ProductRepositoryImpl) ConfirmHold(ctx context.Context, productID string, quantity int) error {
+	return r.adjustStock(ctx, productID, map[string]any{
+		"quantity":          gorm.Expr("quantity - ?", quantity),
+		"reserved_quantity": gorm.Expr("reserved_quantity - ?", quantity),
+	}, "reserved_quantity >= ? AND quantity >= ?", quantity, quantity)
+}
+
+// ReleaseHold makes held stock available again atomically
+func (r *ProductRepositoryImpl) ReleaseHold(ctx context.Context, productID string, quantity int) error {
+	return r.adjustStock(ctx, productID, map[string]any{
+		"reserved_quantity": gorm.Expr("reserved_quantity - ?", quantity),
+	}, "reserved_quantity >= ?", quantity)
+}
+
+// adjustStock applies a conditional stock update to one product
+// When condition does not match, the product is either missing (ErrNotFound)
+// or short of stock (ErrInsufficientStock)
+func (r *ProductRepositoryImpl) adjustStock(ctx context.Context, productID string, updates map[string]any, condition string, args ...any) error {
+	updates["version"] = gorm.Expr("version + 1")
+	result := dbFromContext(ctx, r.db).Model(&persistence.Product{}).
+		Where("id = ?", productID).Where(condition, args...).
+		Updates(updates)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to adjust stock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// Distinguish a missing product from one that lacks stock
+		exists, err := r.exists(ctx, productID)
+		if err != nil {
+			return fmt.Errorf("failed to adjust stock: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("product with ID %s %w", productID, repositories.ErrNotFound)
+		}
+		return fmt.Errorf("product with ID %s: %w", productID, repositories.ErrInsufficientStock)
 	}
 
 	return nil
@@ -213,12 +334,9 @@ func (r *ProductRepositoryImpl) IncreaseQuantity(ctx context.Context, productID
 
 // SearchByName searches products by name
 func (r *ProductRepositoryImpl) SearchByName(ctx context.Context, name string) ([]*entities.Product, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Product
 	searchPattern := "%" + name + "%"
-	if err := r.db.WithContext(ctx).Where("product_name LIKE ?", searchPattern).Find(&models).Error; err != nil {
+	if err := r.withDetails(ctx).Where("product_name LIKE ?", searchPattern).Find(&models).Error; err != nil {
 		return nil, fmt.Errorf("failed to search products by name: %w", err)
 	}
 
@@ -231,27 +349,180 @@ func (r *ProductRepositoryImpl) SearchByName(ctx context.Context, name string) (
 	return products, nil
 }
 
-// GetTotalValue calculates total inventory value
-func (r *ProductRepositoryImpl) GetTotalValue(ctx context.Context) (float64, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// Find lists the products matching every condition of the filter, newest first
+func (r *ProductRepositoryImpl) Find(ctx context.Context, filter repositories.ProductFilter) ([]*entities.Product, error) {
+	query := r.withDetails(ctx).Order("created_at DESC, id")
+
+	if filter.CategoryPath != "" {
+		query = query.Where("category_id IN (?)",
+			dbFromContext(ctx, r.db).Model(&persistence.Category{}).Select("id").Where("path LIKE ?", filter.CategoryPath+"%"))
+	}
+	if len(filter.Attributes) > 0 {
+		query = query.Where(attributeCondition(filter.Attributes))
+	}
+	if filter.TopLevel {
+		query = query.Where("parent_id IS NULL")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SKU != "" {
+		query = query.Where("sku = ?", filter.SKU)
+	}
+	if filter.Barcode != "" {
+		query = query.Where("barcode = ?", filter.Barcode)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var models []persistence.Product
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find products: %w", err)
+	}
+
+	return persistence.ModelsToProducts(models), nil
+}
+
+// GetVariants gets the variants of the given parents, oldest first
+func (r *ProductRepositoryImpl) GetVariants(ctx context.Context, parentIDs ...string) ([]*entities.Product, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	var models []persistence.Product
+	if err := r.withDetails(ctx).Where("parent_id IN ?", parentIDs).
+		Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+
+	return persistence.ModelsToProducts(models), nil
+}
+
+// SyncVariants copies a parent's category and tax class to its variants, and
+// its price to the variants that follow it
+func (r *ProductRepositoryImpl) SyncVariants(ctx context.Context, parent *entities.Product) error {
+	model := persistence.ProductToModel(parent)
+	now := time.Now().UTC()
+
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&persistence.Product{}).
+			Where("parent_id = ?", model.ID).
+			Updates(map[string]any{
+				"category_id": model.CategoryID,
+				"tax_class":   model.TaxClass,
+				"version":     gorm.Expr("version + 1"),
+				"updated_at":  now,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&persistence.Product{}).
+			Where("parent_id = ? AND price_override = ?", model.ID, false).
+			Updates(map[string]any{
+				"price_minor": model.PriceMinor,
+				"currency":    model.Currency,
+				"version":     gorm.Expr("version + 1"),
+				"updated_at":  now,
+			}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update variants: %w", err)
+	}
 
-	var totalValue float64
-	if err := r.db.WithContext(ctx).Model(&persistence.Product{}).
-		Select("SUM(price * quantity)").Scan(&totalValue).Error; err != nil {
-		return 0, fmt.Errorf("failed to calculate total value: %w", err)
+	return nil
+}
+
+// GetTotalValue calculates total inventory value at selling price
+func (r *ProductRepositoryImpl) GetTotalValue(ctx context.Context) (entities.Money, error) {
+	var totalValue int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Product{}).
+		Select("COALESCE(SUM(price_minor * quantity), 0)").Scan(&totalValue).Error; err != nil {
+		return entities.Money{}, fmt.Errorf("failed to calculate total value: %w", err)
 	}
 
-	return totalValue, nil
+	return entities.NewMoney(totalValue, ""), nil
+}
+
+// attributeCondition matches products that have every attribute, or parents
+// with a variant that has every attribute either itself or through the parent
+func attributeCondition(attributes map[string]string) clause.Expr {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	const match = "EXISTS (SELECT 1 FROM product_attributes pa WHERE pa.product_id IN (%s) " +
+		"AND pa.name = ? AND LOWER(pa.value) = LOWER(?))"
+	own := make([]string, len(names))
+	inherited := make([]string, len(names))
+	var ownArgs, inheritedArgs []any
+	for i, name := range names {
+		own[i] = fmt.Sprintf(match, "products.id")
+		inherited[i] = fmt.Sprintf(match, "v.id, products.id")
+		ownArgs = append(ownArgs, name, attributes[name])
+		inheritedArgs = append(inheritedArgs, name, attributes[name])
+	}
+
+	sql := "((" + strings.Join(own, " AND ") + ") OR EXISTS (SELECT 1 FROM products v WHERE v.parent_id = products.id AND " +
+		strings.Join(inherited, " AND ") + "))"
+	return gorm.Expr(sql, append(ownArgs, inheritedArgs...)...)
+}
+
+// withDetails starts a product query that loads each product's attributes and,
+// for bundles, the components with their products
+func (r *ProductRepositoryImpl) withDetails(ctx context.Context) *gorm.DB {
+	return dbFromContext(ctx, r.db).
+		Preload("Attributes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("name")
+		}).
+		Preload("Components", func(db *gorm.DB) *gorm.DB {
+			return db.Order("product_id")
+		}).
+		Preload("Components.Product")
+}
+
+// checkCatalogueCodes fails with ErrAlreadyExists when another product has the
+// model's SKU or barcode; the unique indexes catch any race this check loses
+func checkCatalogueCodes(tx *gorm.DB, model *persistence.Product) error {
+	codes := []struct {
+		column string
+		value  *string
+	}{{"sku", model.SKU}, {"barcode", model.Barcode}}
+
+	for _, code := range codes {
+		if code.value == nil {
+			continue
+		}
+		var count int64
+		if err := tx.Model(&persistence.Product{}).
+			Where(code.column+" = ? AND id <> ?", *code.value, model.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("product with %s %s %w", code.column, *code.value, repositories.ErrAlreadyExists)
+		}
+	}
+	return nil
+}
+
+// exists reports whether a product with the given ID is stored
+func (r *ProductRepositoryImpl) exists(ctx context.Context, id string) (bool, error) {
+	var count int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Product{}).
+		Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 // Count returns the total number of products
 func (r *ProductRepositoryImpl) Count(ctx context.Context) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Product{}).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Product{}).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count products: %w", err)
 	}
 
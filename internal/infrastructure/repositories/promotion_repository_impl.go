@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// PromotionRepositoryImpl implements the PromotionRepository interface
+type PromotionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new promotion repository implementation
+func NewPromotionRepository(db *gorm.DB) repositories.PromotionRepository {
+	return &PromotionRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create stores a new promotion
+func (r *PromotionRepositoryImpl) Create(ctx context.Context, promotion *entities.Promotion) error {
+	model := persistence.PromotionToModel(promotion)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	persistence.ModelToPromotion(model, promotion)
+	return nil
+}
+
+// GetByID retrieves a promotion by ID
+func (r *PromotionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Promotion, error) {
+	var model persistence.Promotion
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("promotion with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	promotion := &entities.Promotion{}
+	persistence.ModelToPromotion(&model, promotion)
+	return promotion, nil
+}
+
+// GetAll retrieves every promotion, oldest first
+func (r *PromotionRepositoryImpl) GetAll(ctx context.Context) ([]*entities.Promotion, error) {
+	var models []persistence.Promotion
+	if err := dbFromContext(ctx, r.db).Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get promotions: %w", err)
+	}
+
+	return persistence.ModelsToPromotions(models), nil
+}
+
+// GetAutomatic retrieves the promotions in effect at the given time that need no coupon, oldest first
+func (r *PromotionRepositoryImpl) GetAutomatic(ctx context.Context, at time.Time) ([]*entities.Promotion, error) {
+	var models []persistence.Promotion
+	if err := dbFromContext(ctx, r.db).
+		Where("coupon_only = ?", false).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at).
+		Order("created_at, id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get promotions: %w", err)
+	}
+
+	return persistence.ModelsToPromotions(models), nil
+}
+
+// End stores the promotion's end time
+func (r *PromotionRepositoryImpl) End(ctx context.Context, promotion *entities.Promotion) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.Promotion{}).
+		Where("id = ?", promotion.ID).
+		Update("ends_at", promotion.EndsAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to end promotion: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("promotion with ID %s %w", promotion.ID, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// CreateCoupon stores a new coupon
+func (r *PromotionRepositoryImpl) CreateCoupon(ctx context.Context, coupon *entities.Coupon) error {
+	model := persistence.CouponToModel(coupon)
+
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&persistence.Coupon{}).Where("code = ?", model.Code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return fmt.Errorf("coupon %s %w", model.Code, repositories.ErrAlreadyExists)
+		}
+
+		return tx.Create(model).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	persistence.ModelToCoupon(model, coupon)
+	return nil
+}
+
+// GetCoupon retrieves a coupon by code
+func (r *PromotionRepositoryImpl) GetCoupon(ctx context.Context, code string) (*entities.Coupon, error) {
+	var model persistence.Coupon
+	if err := dbFromContext(ctx, r.db).First(&model, "code = ?", code).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("coupon %s %w", code, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	coupon := &entities.Coupon{}
+	persistence.ModelToCoupon(&model, coupon)
+	return coupon, nil
+}
+
+// GetCoupons retrieves a promotion's coupons, oldest first
+func (r *PromotionRepositoryImpl) GetCoupons(ctx context.Context, promotionID string) ([]*entities.Coupon, error) {
+	var models []persistence.Coupon
+	if err := dbFromContext(ctx, r.db).Where("promotion_id = ?", promotionID).
+		Order("created_at, code").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+
+	return persistence.ModelsToCoupons(models), nil
+}
+
+// RedeemCoupon counts one more use of a coupon unless it has reached its limit
+// The check and the increment are one statement, so concurrent orders cannot
+// redeem a coupon more often than it allows
+func (r *PromotionRepositoryImpl) RedeemCoupon(ctx context.Context, code string) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.Coupon{}).
+		Where("code = ? AND (max_redemptions = 0 OR redemptions < max_redemptions)", code).
+		Update("redemptions", gorm.Expr("redemptions + 1"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to redeem coupon: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("coupon %s with uses left %w", code, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ReleaseCoupon takes back one use of a coupon
+func (r *PromotionRepositoryImpl) ReleaseCoupon(ctx context.Context, code string) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.Coupon{}).
+		Where("code = ? AND redemptions > 0", code).
+		Update("redemptions", gorm.Expr("redemptions - 1"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to release coupon: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("redeemed coupon %s %w", code, repositories.ErrNotFound)
+	}
+
+	return nil
+}
+
+// CountCustomerRedemptions counts the orders a customer used a coupon on
+func (r *PromotionRepositoryImpl) CountCustomerRedemptions(ctx context.Context, code, customerID string) (int, error) {
+	var count int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.PromotionRedemption{}).
+		Where("coupon_code = ? AND customer_id = ?", code, customerID).
+		Distinct("order_id").Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count coupon redemptions: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// RecordRedemption stores what a promotion took off an order
+func (r *PromotionRepositoryImpl) RecordRedemption(ctx context.Context, redemption *entities.PromotionRedemption) error {
+	model := persistence.RedemptionToModel(redemption)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to record promotion redemption: %w", err)
+	}
+
+	persistence.ModelToRedemption(model, redemption)
+	return nil
+}
+
+// GetRedemptionsByOrderID retrieves the promotions redeemed on an order
+func (r *PromotionRepositoryImpl) GetRedemptionsByOrderID(ctx context.Context, orderID string) ([]*entities.PromotionRedemption, error) {
+	var models []persistence.PromotionRedemption
+	if err := dbFromContext(ctx, r.db).Where("order_id = ?", orderID).
+		Order("id").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get promotion redemptions: %w", err)
+	}
+
+	return persistence.ModelsToRedemptions(models), nil
+}
+
+// DeleteRedemptionsByOrderID removes the promotions redeemed on an order
+func (r *PromotionRepositoryImpl) DeleteRedemptionsByOrderID(ctx context.Context, orderID string) error {
+	if err := dbFromContext(ctx, r.db).Where("order_id = ?", orderID).
+		Delete(&persistence.PromotionRedemption{}).Error; err != nil {
+		return fmt.Errorf("failed to delete promotion redemptions: %w", err)
+	}
+
+	return nil
+}
+
+// GetStats calculates each promotion's redemptions and discount cost, the costliest first
+func (r *PromotionRepositoryImpl) GetStats(ctx context.Context, start, end *time.Time) ([]*entities.PromotionStats, error) {
+	query := dbFromContext(ctx, r.db).Table("promotion_redemptions").
+		Joins("JOIN promotions ON promotions.id = promotion_redemptions.promotion_id")
+	if start != nil && end != nil {
+		query = query.Where("promotion_redemptions.redeemed_at BETWEEN ? AND ?", *start, *end)
+	}
+
+	var rows []struct {
+		PromotionID  string
+		Name         string
+		Type         string
+		Redemptions  int64
+		Customers    int64
+		DiscountCost int64
+	}
+	if err := query.Select(
+		"promotions.id AS promotion_id, promotions.name, promotions.type, " +
+			"COUNT(promotion_redemptions.id) AS redemptions, " +
+			"COUNT(DISTINCT promotion_redemptions.customer_id) AS customers, " +
+			"COALESCE(SUM(promotion_redemptions.discount_minor), 0) AS discount_cost",
+	).Group("promotions.id, promotions.name, promotions.type").
+		Order("discount_cost DESC, promotions.id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate promotion stats: %w", err)
+	}
+
+	stats := make([]*entities.PromotionStats, len(rows))
+	for i, row := range rows {
+		stats[i] = &entities.PromotionStats{
+			PromotionID:  row.PromotionID,
+			Name:         row.Name,
+			Type:         entities.PromotionType(row.Type),
+			Redemptions:  int(row.Redemptions),
+			Customers:    int(row.Customers),
+			DiscountCost: entities.NewMoney(row.DiscountCost, ""),
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseOrderRepositoryImpl implements the PurchaseOrderRepository interface
+type PurchaseOrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPurchaseOrderRepository creates a new purchase order repository implementation
+func NewPurchaseOrderRepository(db *gorm.DB) repositories.PurchaseOrderRepository {
+	return &PurchaseOrderRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new purchase order with its lines
+func (r *PurchaseOrderRepositoryImpl) Create(ctx context.Context, purchaseOrder *entities.PurchaseOrder) error {
+	model := persistence.PurchaseOrderToModel(purchaseOrder)
+	model.Version = 1
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create purchase order: %w", err)
+	}
+
+	purchaseOrder.Version = model.Version
+	return nil
+}
+
+// GetByID retrieves a purchase order with its lines
+func (r *PurchaseOrderRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.PurchaseOrder, error) {
+	var model persistence.PurchaseOrder
+	if err := withPurchaseOrderLines(dbFromContext(ctx, r.db)).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("purchase order with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get purchase order: %w", err)
+	}
+
+	purchaseOrder := &entities.PurchaseOrder{}
+	persistence.ModelToPurchaseOrder(&model, purchaseOrder)
+	return purchaseOrder, nil
+}
+
+// Find retrieves purchase orders matching the filter, newest first
+func (r *PurchaseOrderRepositoryImpl) Find(ctx context.Context, filter repositories.PurchaseOrderFilter) ([]*entities.PurchaseOrder, error) {
+	var models []persistence.PurchaseOrder
+	query := withPurchaseOrderLines(dbFromContext(ctx, r.db)).Order("created_at DESC, id DESC")
+
+	if filter.SupplierID != "" {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find purchase orders: %w", err)
+	}
+
+	return persistence.ModelsToPurchaseOrders(models), nil
+}
+
+// Update saves a purchase order and replaces its lines
+// The version check makes concurrent edits and receipts of the same order
+// fail instead of booking the same goods twice
+func (r *PurchaseOrderRepositoryImpl) Update(ctx context.Context, purchaseOrder *entities.PurchaseOrder) error {
+	model := persistence.PurchaseOrderToModel(purchaseOrder)
+	model.UpdatedAt = time.Now().UTC()
+
+	err := dbFromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&persistence.PurchaseOrder{}).
+			Where("id = ? AND version = ?", model.ID, model.Version).
+			Updates(map[string]any{
+				"status":      model.Status,
+				"total_minor": model.TotalMinor,
+				"currency":    model.Currency,
+				"note":        model.Note,
+				"sent_at":     model.SentAt,
+				"expected_at": model.ExpectedAt,
+				"received_at": model.ReceivedAt,
+				"version":     gorm.Expr("version + 1"),
+				"updated_at":  model.UpdatedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&persistence.PurchaseOrder{}).Where("id = ?", model.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("purchase order with ID %s %w", model.ID, repositories.ErrNotFound)
+			}
+			return fmt.Errorf("purchase order with ID %s was modified concurrently: %w", model.ID, repositories.ErrVersionConflict)
+		}
+
+		if err := tx.Where("purchase_order_id = ?", model.ID).Delete(&persistence.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		if len(model.Lines) == 0 {
+			return nil
+		}
+		return tx.Omit("Product", "PurchaseOrder").Create(&model.Lines).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update purchase order: %w", err)
+	}
+
+	purchaseOrder.Version++
+	purchaseOrder.UpdatedAt = model.UpdatedAt
+	return nil
+}
+
+// CreateReceipt records a delivery with its lines
+func (r *PurchaseOrderRepositoryImpl) CreateReceipt(ctx context.Context, receipt *entities.GoodsReceipt) error {
+	model := persistence.ReceiptToModel(receipt)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create goods receipt: %w", err)
+	}
+
+	return nil
+}
+
+// GetReceipts lists the deliveries against a purchase order, oldest first
+func (r *PurchaseOrderRepositoryImpl) GetReceipts(ctx context.Context, purchaseOrderID string) ([]*entities.GoodsReceipt, error) {
+	var models []persistence.GoodsReceipt
+	if err := dbFromContext(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number ASC")
+		}).
+		Where("purchase_order_id = ?", purchaseOrderID).
+		Order("received_at ASC, id ASC").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get goods receipts: %w", err)
+	}
+
+	return persistence.ModelsToReceipts(models), nil
+}
+
+// GetOnOrder sums, per product, the units on open purchase orders that have not arrived yet
+func (r *PurchaseOrderRepositoryImpl) GetOnOrder(ctx context.Context, productIDs ...string) (map[string]int, error) {
+	var rows []struct {
+		ProductID   string
+		Outstanding int
+	}
+	if err := dbFromContext(ctx, r.db).Table("purchase_order_lines l").
+		Select("l.product_id, SUM(l.quantity_ordered - l.quantity_received) AS outstanding").
+		Joins("JOIN purchase_orders po ON po.id = l.purchase_order_id").
+		Where("po.status <> ? AND l.product_id IN ?", string(entities.PurchaseOrderStatusReceived), productIDs).
+		Group("l.product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum quantity on order: %w", err)
+	}
+
+	onOrder := make(map[string]int, len(rows))
+	for _, row := range rows {
+		onOrder[row.ProductID] = row.Outstanding
+	}
+	return onOrder, nil
+}
+
+// GetLastUnitCosts returns, per product, the unit cost on the most recently sent purchase order
+func (r *PurchaseOrderRepositoryImpl) GetLastUnitCosts(ctx context.Context, productIDs ...string) (map[string]entities.Money, error) {
+	var rows []struct {
+		ProductID     string
+		UnitCostMinor int64
+		Currency      string
+	}
+	if err := dbFromContext(ctx, r.db).Table("purchase_order_lines l").
+		Select("l.product_id, l.unit_cost_minor, l.currency").
+		Joins("JOIN purchase_orders po ON po.id = l.purchase_order_id").
+		Where("po.sent_at IS NOT NULL AND l.product_id IN ?", productIDs).
+		Order("po.sent_at DESC, po.id DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get last unit costs: %w", err)
+	}
+
+	costs := make(map[string]entities.Money, len(productIDs))
+	for _, row := range rows {
+		if _, seen := costs[row.ProductID]; !seen {
+			costs[row.ProductID] = entities.NewMoney(row.UnitCostMinor, row.Currency)
+		}
+	}
+	return costs, nil
+}
+
+// withPurchaseOrderLines loads the lines of purchase orders in order, with their product names
+func withPurchaseOrderLines(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Lines", func(db *gorm.DB) *gorm.DB {
+			return db.Order("line_number ASC")
+		}).
+		Preload("Lines.Product")
+}
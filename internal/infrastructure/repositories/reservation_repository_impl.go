@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// ReservationRepositoryImpl implements the ReservationRepository interface
+type ReservationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewReservationRepository creates a new reservation repository implementation
+func NewReservationRepository(db *gorm.DB) repositories.ReservationRepository {
+	return &ReservationRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new reservation
+func (r *ReservationRepositoryImpl) Create(ctx context.Context, reservation *entities.StockReservation) error {
+	model := persistence.ReservationToModel(reservation)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	persistence.ModelToReservation(model, reservation)
+	return nil
+}
+
+// GetByID retrieves a reservation by ID
+func (r *ReservationRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.StockReservation, error) {
+	var model persistence.StockReservation
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("reservation with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	reservation := &entities.StockReservation{}
+	persistence.ModelToReservation(&model, reservation)
+	return reservation, nil
+}
+
+// Find retrieves reservations matching the filter, newest first
+func (r *ReservationRepositoryImpl) Find(ctx context.Context, filter repositories.ReservationFilter) ([]*entities.StockReservation, error) {
+	var models []persistence.StockReservation
+	query := dbFromContext(ctx, r.db).Order("created_at DESC")
+
+	if filter.ProductID != "" {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find reservations: %w", err)
+	}
+
+	return persistence.ModelsToReservations(models), nil
+}
+
+// End persists a confirmation, release or expiry
+// The update only matches while the hold is still active, so its stock is
+// given back or taken exactly once even when the sweeper races a checkout
+func (r *ReservationRepositoryImpl) End(ctx context.Context, reservation *entities.StockReservation) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.StockReservation{}).
+		Where("id = ? AND status = ?", reservation.ID, string(entities.ReservationStatusActive)).
+		Updates(map[string]any{
+			"status":     string(reservation.Status),
+			"order_id":   persistence.ReservationToModel(reservation).OrderID,
+			"updated_at": reservation.UpdatedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to end reservation: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, reservation.ID); err != nil {
+			return err
+		}
+		return fmt.Errorf("reservation with ID %s is no longer active: %w", reservation.ID, repositories.ErrVersionConflict)
+	}
+
+	return nil
+}
+
+// GetExpired returns active holds whose expiry has passed, oldest first
+func (r *ReservationRepositoryImpl) GetExpired(ctx context.Context, at time.Time, limit int) ([]*entities.StockReservation, error) {
+	var models []persistence.StockReservation
+	query := dbFromContext(ctx, r.db).
+		Where("status = ? AND expires_at <= ?", string(entities.ReservationStatusActive), at).
+		Order("expires_at ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get expired reservations: %w", err)
+	}
+
+	return persistence.ModelsToReservations(models), nil
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// ReturnRepositoryImpl implements the ReturnRepository interface
+type ReturnRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewReturnRepository creates a new return repository implementation
+func NewReturnRepository(db *gorm.DB) repositories.ReturnRepository {
+	return &ReturnRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new return request
+func (r *ReturnRepositoryImpl) Create(ctx context.Context, orderReturn *entities.OrderReturn) error {
+	model := persistence.ReturnToModel(orderReturn)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create return: %w", err)
+	}
+
+	persistence.ModelToReturn(model, orderReturn)
+	return nil
+}
+
+// GetByID retrieves a return by ID
+func (r *ReturnRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.OrderReturn, error) {
+	var model persistence.OrderReturn
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("return with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get return: %w", err)
+	}
+
+	orderReturn := &entities.OrderReturn{}
+	persistence.ModelToReturn(&model, orderReturn)
+	return orderReturn, nil
+}
+
+// Find retrieves returns matching the filter, newest first
+func (r *ReturnRepositoryImpl) Find(ctx context.Context, filter repositories.ReturnFilter) ([]*entities.OrderReturn, error) {
+	var models []persistence.OrderReturn
+	query := dbFromContext(ctx, r.db).Order("requested_at DESC")
+
+	if filter.OrderID != "" {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to find returns: %w", err)
+	}
+
+	return persistence.ModelsToReturns(models), nil
+}
+
+// Resolve persists an approval or rejection
+// The update only matches while the return is still requested, so a return
+// cannot be approved twice by concurrent callers
+func (r *ReturnRepositoryImpl) Resolve(ctx context.Context, orderReturn *entities.OrderReturn) error {
+	result := dbFromContext(ctx, r.db).Model(&persistence.OrderReturn{}).
+		Where("id = ? AND status = ?", orderReturn.ID, string(entities.ReturnStatusRequested)).
+		Updates(map[string]any{
+			"status":                string(orderReturn.Status),
+			"disposition":           string(orderReturn.Disposition),
+			"refund_transaction_id": orderReturn.RefundTransactionID,
+			"resolved_by":           orderReturn.ResolvedBy,
+			"resolution_note":       orderReturn.ResolutionNote,
+			"resolved_at":           orderReturn.ResolvedAt,
+			"updated_at":            orderReturn.UpdatedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to resolve return: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ctx, orderReturn.ID); err != nil {
+			return err
+		}
+		return fmt.Errorf("return with ID %s is no longer requested: %w", orderReturn.ID, repositories.ErrVersionConflict)
+	}
+
+	return nil
+}
+
+// GetReturnedQuantity sums the quantity of requested and approved returns for one product on an order
+func (r *ReturnRepositoryImpl) GetReturnedQuantity(ctx context.Context, orderID, productID string) (int, error) {
+	var quantity int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.OrderReturn{}).
+		Where("order_id = ? AND product_id = ? AND status <> ?", orderID, productID, string(entities.ReturnStatusRejected)).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&quantity).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum returned quantity: %w", err)
+	}
+
+	return int(quantity), nil
+}
+
+// CountActiveReturns counts the requested and approved returns on an order
+func (r *ReturnRepositoryImpl) CountActiveReturns(ctx context.Context, orderID string) (int, error) {
+	var count int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.OrderReturn{}).
+		Where("order_id = ? AND status <> ?", orderID, string(entities.ReturnStatusRejected)).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count returns: %w", err)
+	}
+
+	return int(count), nil
+}
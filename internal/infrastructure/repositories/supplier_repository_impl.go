@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// SupplierRepositoryImpl implements the SupplierRepository interface
+type SupplierRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSupplierRepository creates a new supplier repository implementation
+func NewSupplierRepository(db *gorm.DB) repositories.SupplierRepository {
+	return &SupplierRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create creates a new supplier
+func (r *SupplierRepositoryImpl) Create(ctx context.Context, supplier *entities.Supplier) error {
+	model := persistence.SupplierToModel(supplier)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create supplier: %w", err)
+	}
+
+	persistence.ModelToSupplier(model, supplier)
+	return nil
+}
+
+// GetByID retrieves a supplier by ID
+func (r *SupplierRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Supplier, error) {
+	var model persistence.Supplier
+	if err := dbFromContext(ctx, r.db).First(&model, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("supplier with ID %s %w", id, repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+
+	supplier := &entities.Supplier{}
+	persistence.ModelToSupplier(&model, supplier)
+	return supplier, nil
+}
+
+// GetAll retrieves all suppliers by name with pagination
+func (r *SupplierRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entities.Supplier, error) {
+	var models []persistence.Supplier
+	query := dbFromContext(ctx, r.db).Order("name, id")
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get suppliers: %w", err)
+	}
+
+	return persistence.ModelsToSuppliers(models), nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/persistence"
+
+	"gorm.io/gorm"
+)
+
+// TaxRateRepositoryImpl implements the TaxRateRepository interface
+type TaxRateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaxRateRepository creates a new tax rate repository implementation
+func NewTaxRateRepository(db *gorm.DB) repositories.TaxRateRepository {
+	return &TaxRateRepositoryImpl{
+		db: db,
+	}
+}
+
+// Create stores a new tax rate with its components
+func (r *TaxRateRepositoryImpl) Create(ctx context.Context, rate *entities.TaxRate) error {
+	model := persistence.TaxRateToModel(rate)
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
+		return fmt.Errorf("failed to create tax rate: %w", err)
+	}
+
+	persistence.ModelToTaxRate(model, rate)
+	return nil
+}
+
+// Find retrieves the rates for a jurisdiction and tax class, either of which
+// may be empty, grouped by jurisdiction and class and newest first
+func (r *TaxRateRepositoryImpl) Find(ctx context.Context, jurisdiction, taxClass string) ([]*entities.TaxRate, error) {
+	query := withTaxComponents(dbFromContext(ctx, r.db))
+	if jurisdiction != "" {
+		query = query.Where("jurisdiction = ?", jurisdiction)
+	}
+	if taxClass != "" {
+		query = query.Where("tax_class = ?", taxClass)
+	}
+
+	var models []persistence.TaxRate
+	if err := query.Order("jurisdiction ASC, tax_class ASC, effective_from DESC").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+
+	return persistence.ModelsToTaxRates(models), nil
+}
+
+// GetEffective retrieves the latest rate for a tax class in a jurisdiction that took effect at or before at
+func (r *TaxRateRepositoryImpl) GetEffective(ctx context.Context, jurisdiction, taxClass string, at time.Time) (*entities.TaxRate, error) {
+	var model persistence.TaxRate
+	if err := withTaxComponents(dbFromContext(ctx, r.db)).
+		Where("jurisdiction = ? AND tax_class = ? AND effective_from <= ?", jurisdiction, taxClass, at).
+		Order("effective_from DESC").First(&model).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("tax rate for %s in %s at %s %w",
+				taxClass, jurisdiction, at.Format(time.RFC3339), repositories.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get tax rate: %w", err)
+	}
+
+	rate := &entities.TaxRate{}
+	persistence.ModelToTaxRate(&model, rate)
+	return rate, nil
+}
+
+// withTaxComponents preloads a rate's components in the order they were given
+func withTaxComponents(db *gorm.DB) *gorm.DB {
+	return db.Preload("Components", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
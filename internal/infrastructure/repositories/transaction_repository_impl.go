@@ -3,7 +3,6 @@ package repositories
 import (
 	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"day5/internal/domain/entities"
@@ -16,7 +15,6 @@ import (
 // TransactionRepositoryImpl implements the TransactionRepository interface
 type TransactionRepositoryImpl struct {
 	db *gorm.DB
-	mu sync.RWMutex // Thread safety
 }
 
 // NewTransactionRepository creates a new transaction repository implementation
@@ -26,13 +24,10 @@ func NewTransactionRepository(db *gorm.DB) repositories.TransactionRepository {
 	}
 }
 
-// Create creates a new transaction with thread safety
+// Create creates a new transaction
 func (r *TransactionRepositoryImpl) Create(ctx context.Context, transaction *entities.Transaction) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.TransactionToModel(transaction)
-	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Create(model).Error; err != nil {
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
 
@@ -41,16 +36,13 @@ func (r *TransactionRepositoryImpl) Create(ctx context.Context, transaction *ent
 	return nil
 }
 
-// GetByID retrieves a transaction by ID with thread safety
+// GetByID retrieves a transaction by ID
 func (r *TransactionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.Transaction
-	if err := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
+	if err := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
 		First(&model, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("transaction with ID %s not found", id)
+			return nil, fmt.Errorf("transaction with ID %s %w", id, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
@@ -60,13 +52,10 @@ func (r *TransactionRepositoryImpl) GetByID(ctx context.Context, id string) (*en
 	return transaction, nil
 }
 
-// GetAll retrieves all transactions with pagination and thread safety
+// GetAll retrieves all transactions with pagination
 func (r *TransactionRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Transaction
-	query := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
+	query := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
 		Order("transaction_at DESC")
 
 	if limit > 0 {
@@ -89,13 +78,10 @@ func (r *TransactionRepositoryImpl) GetAll(ctx context.Context, limit, offset in
 	return transactions, nil
 }
 
-// Update updates a transaction with thread safety
+// Update updates a transaction
 func (r *TransactionRepositoryImpl) Update(ctx context.Context, transaction *entities.Transaction) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	model := persistence.TransactionToModel(transaction)
-	if err := r.db.WithContext(ctx).Save(model).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Save(model).Error; err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
 
@@ -103,17 +89,14 @@ func (r *TransactionRepositoryImpl) Update(ctx context.Context, transaction *ent
 	return nil
 }
 
-// Delete deletes a transaction with thread safety
+// Delete deletes a transaction
 func (r *TransactionRepositoryImpl) Delete(ctx context.Context, id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	result := r.db.WithContext(ctx).Delete(&persistence.Transaction{}, "id = ?", id)
+	result := dbFromContext(ctx, r.db).Delete(&persistence.Transaction{}, "id = ?", id)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete transaction: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("transaction with ID %s not found", id)
+		return fmt.Errorf("transaction with ID %s %w", id, repositories.ErrNotFound)
 	}
 
 	return nil
@@ -121,11 +104,8 @@ func (r *TransactionRepositoryImpl) Delete(ctx context.Context, id string) error
 
 // GetByCustomerID retrieves transactions by customer ID
 func (r *TransactionRepositoryImpl) GetByCustomerID(ctx context.Context, customerID string, limit, offset int) ([]*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Transaction
-	query := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
+	query := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
 		Where("customer_id = ?", customerID).Order("transaction_at DESC")
 
 	if limit > 0 {
@@ -150,11 +130,8 @@ func (r *TransactionRepositoryImpl) GetByCustomerID(ctx context.Context, custome
 
 // GetByProductID retrieves transactions by product ID
 func (r *TransactionRepositoryImpl) GetByProductID(ctx context.Context, productID string, limit, offset int) ([]*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Transaction
-	query := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
+	query := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
 		Where("product_id = ?", productID).Order("transaction_at DESC")
 
 	if limit > 0 {
@@ -177,16 +154,14 @@ func (r *TransactionRepositoryImpl) GetByProductID(ctx context.Context, productI
 	return transactions, nil
 }
 
-// GetByOrderID retrieves transaction by order ID
+// GetByOrderID retrieves the sale transaction for an order
+// Refunds posted against the same order are not returned
 func (r *TransactionRepositoryImpl) GetByOrderID(ctx context.Context, orderID string) (*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var model persistence.Transaction
-	if err := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
-		First(&model, "order_id = ?", orderID).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
+		First(&model, "order_id = ? AND type = ?", orderID, string(entities.TransactionTypeOrder)).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("transaction with order ID %s not found", orderID)
+			return nil, fmt.Errorf("transaction with order ID %s %w", orderID, repositories.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get transaction by order ID: %w", err)
 	}
@@ -198,11 +173,8 @@ func (r *TransactionRepositoryImpl) GetByOrderID(ctx context.Context, orderID st
 
 // GetByType retrieves transactions by type
 func (r *TransactionRepositoryImpl) GetByType(ctx context.Context, transactionType entities.TransactionType, limit, offset int) ([]*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Transaction
-	query := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
+	query := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
 		Where("type = ?", string(transactionType)).Order("transaction_at DESC")
 
 	if limit > 0 {
@@ -227,11 +199,8 @@ func (r *TransactionRepositoryImpl) GetByType(ctx context.Context, transactionTy
 
 // GetByDateRange retrieves transactions within a date range
 func (r *TransactionRepositoryImpl) GetByDateRange(ctx context.Context, start, end time.Time, limit, offset int) ([]*entities.Transaction, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var models []persistence.Transaction
-	query := r.db.WithContext(ctx).Preload("Order").Preload("Customer").Preload("Product").
+	query := dbFromContext(ctx, r.db).Preload("Order").Preload("Customer").Preload("Product").
 		Where("transaction_at BETWEEN ? AND ?", start, end).Order("transaction_at DESC")
 
 	if limit > 0 {
@@ -268,76 +237,96 @@ func (r *TransactionRepositoryImpl) GetTransactionsByPeriod(ctx context.Context,
 	return r.GetByDateRange(ctx, start, end, 0, 0)
 }
 
+// Revenue is reported net of refunds: order amounts count positively, refund
+// amounts negatively, and every other transaction type (e.g. credit) not at all.
+// The same applies to quantities, so returned units are not counted as sold,
+// and to cost of goods, so restocked units are not counted as a cost.
+const (
+	netAmountExpr   = "CASE WHEN type = 'order' THEN amount_minor WHEN type = 'refund' THEN -amount_minor ELSE 0 END"
+	netQuantityExpr = "CASE WHEN type = 'order' THEN quantity WHEN type = 'refund' THEN -quantity ELSE 0 END"
+	netCostExpr     = "CASE WHEN type = 'order' THEN cost_of_goods_minor WHEN type = 'refund' THEN -cost_of_goods_minor ELSE 0 END"
+)
+
+// revenueTypes are the transaction types that affect revenue
+var revenueTypes = []string{string(entities.TransactionTypeOrder), string(entities.TransactionTypeRefund)}
+
+// Store credit is issued by credit entries and spent by credit_redemption entries
+const creditAmountExpr = "CASE WHEN type = 'credit' THEN amount_minor WHEN type = 'credit_redemption' THEN -amount_minor ELSE 0 END"
+
+// walletTypes are the transaction types that move store credit
+var walletTypes = []string{string(entities.TransactionTypeCredit), string(entities.TransactionTypeCreditRedemption)}
+
 // GetBusinessStats calculates business statistics
+// TotalRevenue is net of refunds; GrossRevenue and RefundedAmount show the split
+// The gross margin is net revenue less net cost of goods
 func (r *TransactionRepositoryImpl) GetBusinessStats(ctx context.Context, start, end *time.Time) (*entities.BusinessStats, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var stats entities.BusinessStats
-	query := r.db.WithContext(ctx).Model(&persistence.Transaction{}).Where("type = ?", "order")
+	query := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).Where("type IN ?", revenueTypes)
 
 	if start != nil && end != nil {
 		query = query.Where("transaction_at BETWEEN ? AND ?", *start, *end)
 	}
 
-	// Get total revenue and order count
-	var totalRevenue float64
-	var orderCount int64
-
-	if err := query.Select("COALESCE(SUM(amount), 0)").Scan(&totalRevenue).Error; err != nil {
-		return nil, fmt.Errorf("failed to calculate total revenue: %w", err)
-	}
-
-	if err := query.Count(&orderCount).Error; err != nil {
-		return nil, fmt.Errorf("failed to count orders: %w", err)
-	}
-
-	stats.TotalRevenue = totalRevenue
-	stats.OrderCount = int(orderCount)
+	var totals struct {
+		GrossRevenue    int64
+		RefundedAmount  int64
+		OrderCount      int64
+		RefundCount     int64
+		NetQuantity     int64
+		UniqueCustomers int64
+		CostOfGoods     int64
+	}
+
+	if err := query.Select(
+		"COALESCE(SUM(CASE WHEN type = 'order' THEN amount_minor ELSE 0 END), 0) AS gross_revenue, " +
+			"COALESCE(SUM(CASE WHEN type = 'refund' THEN amount_minor ELSE 0 END), 0) AS refunded_amount, " +
+			"COUNT(DISTINCT CASE WHEN type = 'order' THEN order_id END) AS order_count, " +
+			"COALESCE(SUM(CASE WHEN type = 'refund' THEN 1 ELSE 0 END), 0) AS refund_count, " +
+			"COALESCE(SUM(" + netQuantityExpr + "), 0) AS net_quantity, " +
+			"COUNT(DISTINCT CASE WHEN type = 'order' THEN customer_id END) AS unique_customers, " +
+			"COALESCE(SUM(" + netCostExpr + "), 0) AS cost_of_goods",
+	).Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate business stats: %w", err)
+	}
+
+	stats.GrossRevenue = entities.NewMoney(totals.GrossRevenue, "")
+	stats.RefundedAmount = entities.NewMoney(totals.RefundedAmount, "")
+	stats.TotalRevenue = entities.NewMoney(totals.GrossRevenue-totals.RefundedAmount, "")
+	stats.OrderCount = int(totals.OrderCount)
+	stats.RefundCount = int(totals.RefundCount)
+	stats.TotalQuantitySold = int(totals.NetQuantity)
+	stats.UniqueCustomers = int(totals.UniqueCustomers)
+	stats.CostOfGoods = entities.NewMoney(totals.CostOfGoods, "")
 	stats.CalculateAverageOrderValue()
-
-	// Get total quantity sold
-	var totalQuantity int64
-	if err := query.Select("COALESCE(SUM(quantity), 0)").Scan(&totalQuantity).Error; err != nil {
-		return nil, fmt.Errorf("failed to calculate total quantity: %w", err)
-	}
-	stats.TotalQuantitySold = int(totalQuantity)
-
-	// Get unique customers
-	var uniqueCustomers int64
-	if err := query.Select("COUNT(DISTINCT customer_id)").Scan(&uniqueCustomers).Error; err != nil {
-		return nil, fmt.Errorf("failed to count unique customers: %w", err)
+	if err := stats.CalculateMargin(); err != nil {
+		return nil, fmt.Errorf("failed to calculate gross margin: %w", err)
 	}
-	stats.UniqueCustomers = int(uniqueCustomers)
 
 	return &stats, nil
 }
 
-// GetRevenueByPeriod calculates revenue for a specific period
-func (r *TransactionRepositoryImpl) GetRevenueByPeriod(ctx context.Context, start, end time.Time) (float64, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var revenue float64
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
-		Where("type = ? AND transaction_at BETWEEN ? AND ?", "order", start, end).
-		Select("COALESCE(SUM(amount), 0)").Scan(&revenue).Error; err != nil {
-		return 0, fmt.Errorf("failed to calculate revenue: %w", err)
+// GetRevenueByPeriod calculates net revenue for a specific period
+func (r *TransactionRepositoryImpl) GetRevenueByPeriod(ctx context.Context, start, end time.Time) (entities.Money, error) {
+	var revenue int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
+		Where("type IN ? AND transaction_at BETWEEN ? AND ?", revenueTypes, start, end).
+		Select("COALESCE(SUM(" + netAmountExpr + "), 0)").Scan(&revenue).Error; err != nil {
+		return entities.Money{}, fmt.Errorf("failed to calculate revenue: %w", err)
 	}
 
-	return revenue, nil
+	return entities.NewMoney(revenue, ""), nil
 }
 
-// GetTopSellingProducts gets top selling products
+// GetTopSellingProducts gets top selling products, net of returns, with their gross margin
 func (r *TransactionRepositoryImpl) GetTopSellingProducts(ctx context.Context, limit int, start, end *time.Time) ([]*entities.ProductSales, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	query := r.db.WithContext(ctx).Table("transactions t").
-		Select("t.product_id, p.product_name, SUM(t.quantity) as quantity_sold, SUM(t.amount) as total_revenue").
+	query := dbFromContext(ctx, r.db).Table("transactions t").
+		Select("t.product_id, p.product_name, COALESCE(p.category_id, '') AS category_id, "+
+			"SUM(CASE WHEN t.type = 'order' THEN t.quantity ELSE -t.quantity END) as quantity_sold, "+
+			"SUM(CASE WHEN t.type = 'order' THEN t.amount_minor ELSE -t.amount_minor END) as total_revenue, "+
+			"SUM(CASE WHEN t.type = 'order' THEN t.cost_of_goods_minor ELSE -t.cost_of_goods_minor END) as cost_of_goods").
 		Joins("JOIN products p ON t.product_id = p.id").
-		Where("t.type = ?", "order").
-		Group("t.product_id, p.product_name").
+		Where("t.type IN ?", revenueTypes).
+		Group("t.product_id, p.product_name, p.category_id").
 		Order("quantity_sold DESC")
 
 	if start != nil && end != nil {
@@ -349,10 +338,12 @@ func (r *TransactionRepositoryImpl) GetTopSellingProducts(ctx context.Context, l
 	}
 
 	type productSalesResult struct {
-		ProductID    string  `json:"product_id"`
-		ProductName  string  `json:"product_name"`
-		QuantitySold int     `json:"quantity_sold"`
-		TotalRevenue float64 `json:"total_revenue"`
+		ProductID    string `json:"product_id"`
+		ProductName  string `json:"product_name"`
+		CategoryID   string `json:"category_id"`
+		QuantitySold int    `json:"quantity_sold"`
+		TotalRevenue int64  `json:"total_revenue"`
+		CostOfGoods  int64  `json:"cost_of_goods"`
 	}
 
 	var results []productSalesResult
@@ -365,39 +356,74 @@ func (r *TransactionRepositoryImpl) GetTopSellingProducts(ctx context.Context, l
 		productSales[i] = &entities.ProductSales{
 			ProductID:    result.ProductID,
 			ProductName:  result.ProductName,
+			CategoryID:   result.CategoryID,
 			QuantitySold: result.QuantitySold,
-			TotalRevenue: result.TotalRevenue,
+			TotalRevenue: entities.NewMoney(result.TotalRevenue, ""),
+			CostOfGoods:  entities.NewMoney(result.CostOfGoods, ""),
+		}
+		if err := productSales[i].CalculateMargin(); err != nil {
+			return nil, fmt.Errorf("failed to calculate gross margin: %w", err)
 		}
 	}
 
 	return productSales, nil
 }
 
+// GetProductSales sums a product's sales net of refunds from start until end
+func (r *TransactionRepositoryImpl) GetProductSales(ctx context.Context, productID string, start time.Time, end *time.Time) (*entities.ProductSales, error) {
+	query := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
+		Select("COALESCE(SUM("+netQuantityExpr+"), 0) AS quantity_sold, "+
+			"COALESCE(SUM("+netAmountExpr+"), 0) AS total_revenue, "+
+			"COALESCE(SUM("+netCostExpr+"), 0) AS cost_of_goods").
+		Where("product_id = ? AND type IN ? AND transaction_at >= ?", productID, revenueTypes, start)
+	if end != nil {
+		query = query.Where("transaction_at < ?", *end)
+	}
+
+	var result struct {
+		QuantitySold int
+		TotalRevenue int64
+		CostOfGoods  int64
+	}
+	if err := query.Scan(&result).Error; err != nil {
+		return nil, fmt.Errorf("failed to get product sales: %w", err)
+	}
+
+	sales := &entities.ProductSales{
+		ProductID:    productID,
+		QuantitySold: result.QuantitySold,
+		TotalRevenue: entities.NewMoney(result.TotalRevenue, ""),
+		CostOfGoods:  entities.NewMoney(result.CostOfGoods, ""),
+	}
+	if err := sales.CalculateMargin(); err != nil {
+		return nil, fmt.Errorf("failed to calculate gross margin: %w", err)
+	}
+
+	return sales, nil
+}
+
 // GetCustomerTransactionSummary gets transaction summary for a customer
 func (r *TransactionRepositoryImpl) GetCustomerTransactionSummary(ctx context.Context, customerID string) (map[string]any, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	summary := make(map[string]any)
 
 	// Total transactions
 	var totalTransactions int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
 		Where("customer_id = ?", customerID).Count(&totalTransactions).Error; err != nil {
 		return nil, fmt.Errorf("failed to count transactions: %w", err)
 	}
 
-	// Total amount spent
-	var totalSpent float64
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
-		Where("customer_id = ? AND type = ?", customerID, "order").
-		Select("COALESCE(SUM(amount), 0)").Scan(&totalSpent).Error; err != nil {
+	// Total amount spent, net of refunds
+	var totalSpent int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
+		Where("customer_id = ? AND type IN ?", customerID, revenueTypes).
+		Select("COALESCE(SUM(" + netAmountExpr + "), 0)").Scan(&totalSpent).Error; err != nil {
 		return nil, fmt.Errorf("failed to calculate total spent: %w", err)
 	}
 
 	// First transaction date
 	var firstTransaction time.Time
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
 		Where("customer_id = ?", customerID).
 		Select("MIN(transaction_at)").Scan(&firstTransaction).Error; err != nil {
 		return nil, fmt.Errorf("failed to get first transaction: %w", err)
@@ -405,14 +431,14 @@ func (r *TransactionRepositoryImpl) GetCustomerTransactionSummary(ctx context.Co
 
 	// Last transaction date
 	var lastTransaction time.Time
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
 		Where("customer_id = ?", customerID).
 		Select("MAX(transaction_at)").Scan(&lastTransaction).Error; err != nil {
 		return nil, fmt.Errorf("failed to get last transaction: %w", err)
 	}
 
 	summary["total_transactions"] = totalTransactions
-	summary["total_spent"] = totalSpent
+	summary["total_spent"] = entities.NewMoney(totalSpent, "")
 	summary["first_transaction"] = firstTransaction
 	summary["last_transaction"] = lastTransaction
 
@@ -421,39 +447,30 @@ func (r *TransactionRepositoryImpl) GetCustomerTransactionSummary(ctx context.Co
 
 // Count returns the total number of transactions
 func (r *TransactionRepositoryImpl) Count(ctx context.Context) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).Count(&count).Error; err != nil {
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count transactions: %w", err)
 	}
 
 	return int(count), nil
 }
 
-// GetTotalRevenue calculates total revenue
-func (r *TransactionRepositoryImpl) GetTotalRevenue(ctx context.Context) (float64, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	var totalRevenue float64
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
-		Where("type = ?", "order").
-		Select("COALESCE(SUM(amount), 0)").Scan(&totalRevenue).Error; err != nil {
-		return 0, fmt.Errorf("failed to calculate total revenue: %w", err)
+// GetTotalRevenue calculates total net revenue
+func (r *TransactionRepositoryImpl) GetTotalRevenue(ctx context.Context) (entities.Money, error) {
+	var totalRevenue int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
+		Where("type IN ?", revenueTypes).
+		Select("COALESCE(SUM(" + netAmountExpr + "), 0)").Scan(&totalRevenue).Error; err != nil {
+		return entities.Money{}, fmt.Errorf("failed to calculate total revenue: %w", err)
 	}
 
-	return totalRevenue, nil
+	return entities.NewMoney(totalRevenue, ""), nil
 }
 
 // GetTransactionCountByType returns count of transactions by type
 func (r *TransactionRepositoryImpl) GetTransactionCountByType(ctx context.Context, transactionType entities.TransactionType) (int, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&persistence.Transaction{}).
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
 		Where("type = ?", string(transactionType)).Count(&count).Error; err != nil {
 		return 0, fmt.Errorf("failed to count transactions by type: %w", err)
 	}
@@ -461,21 +478,18 @@ func (r *TransactionRepositoryImpl) GetTransactionCountByType(ctx context.Contex
 	return int(count), nil
 }
 
-// GetDailyRevenue gets daily revenue for the last N days
+// GetDailyRevenue gets daily net revenue for the last N days
 func (r *TransactionRepositoryImpl) GetDailyRevenue(ctx context.Context, days int) ([]map[string]any, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	startDate := time.Now().AddDate(0, 0, -days).Truncate(24 * time.Hour)
 
 	var results []map[string]any
-	rows, err := r.db.WithContext(ctx).Raw(`
-		SELECT DATE(transaction_at) as date, COALESCE(SUM(amount), 0) as revenue
+	rows, err := dbFromContext(ctx, r.db).Raw(`
+		SELECT DATE(transaction_at) as date, COALESCE(SUM(`+netAmountExpr+`), 0) as revenue
 		FROM transactions 
-		WHERE type = 'order' AND transaction_at >= ?
+		WHERE type IN ? AND transaction_at >= ?
 		GROUP BY DATE(transaction_at)
 		ORDER BY date DESC
-	`, startDate).Rows()
+	`, revenueTypes, startDate).Rows()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily revenue: %w", err)
@@ -483,35 +497,32 @@ func (r *TransactionRepositoryImpl) GetDailyRevenue(ctx context.Context, days in
 	defer rows.Close()
 
 	for rows.Next() {
-		var date time.Time
-		var revenue float64
+		var date any
+		var revenue int64
 		if err := rows.Scan(&date, &revenue); err != nil {
 			return nil, fmt.Errorf("failed to scan daily revenue: %w", err)
 		}
 		results = append(results, map[string]any{
-			"date":    date.Format("2006-01-02"),
-			"revenue": revenue,
+			"date":    formatDate(date),
+			"revenue": entities.NewMoney(revenue, ""),
 		})
 	}
 
 	return results, nil
 }
 
-// GetMonthlyRevenue gets monthly revenue for the last N months
+// GetMonthlyRevenue gets monthly net revenue for the last N months
 func (r *TransactionRepositoryImpl) GetMonthlyRevenue(ctx context.Context, months int) ([]map[string]any, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	startDate := time.Now().AddDate(0, -months, 0)
 
 	var results []map[string]any
-	rows, err := r.db.WithContext(ctx).Raw(`
-		SELECT DATE_FORMAT(transaction_at, '%Y-%m') as month, COALESCE(SUM(amount), 0) as revenue
+	rows, err := dbFromContext(ctx, r.db).Raw(`
+		SELECT DATE_FORMAT(transaction_at, '%Y-%m') as month, COALESCE(SUM(`+netAmountExpr+`), 0) as revenue
 		FROM transactions 
-		WHERE type = 'order' AND transaction_at >= ?
+		WHERE type IN ? AND transaction_at >= ?
 		GROUP BY DATE_FORMAT(transaction_at, '%Y-%m')
 		ORDER BY month DESC
-	`, startDate).Rows()
+	`, revenueTypes, startDate).Rows()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monthly revenue: %w", err)
@@ -520,24 +531,21 @@ func (r *TransactionRepositoryImpl) GetMonthlyRevenue(ctx context.Context, month
 
 	for rows.Next() {
 		var month string
-		var revenue float64
+		var revenue int64
 		if err := rows.Scan(&month, &revenue); err != nil {
 			return nil, fmt.Errorf("failed to scan monthly revenue: %w", err)
 		}
 		results = append(results, map[string]any{
 			"month":   month,
-			"revenue": revenue,
+			"revenue": entities.NewMoney(revenue, ""),
 		})
 	}
 
 	return results, nil
 }
 
-// GetRevenueGrowth calculates revenue growth
+// GetRevenueGrowth calculates month-over-month growth in net revenue
 func (r *TransactionRepositoryImpl) GetRevenueGrowth(ctx context.Context) (map[string]any, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	// Get current month revenue
 	now := time.Now()
 	currentMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
@@ -557,10 +565,11 @@ func (r *TransactionRepositoryImpl) GetRevenueGrowth(ctx context.Context) (map[s
 		return nil, fmt.Errorf("failed to get previous month revenue: %w", err)
 	}
 
-	// Calculate growth percentage
+	// Calculate growth percentage; a ratio of two amounts, so a float is fine here
 	var growthPercentage float64
-	if previousRevenue > 0 {
-		growthPercentage = ((currentRevenue - previousRevenue) / previousRevenue) * 100
+	if previousRevenue.IsPositive() {
+		change := currentRevenue.Minor() - previousRevenue.Minor()
+		growthPercentage = float64(change) / float64(previousRevenue.Minor()) * 100
 	}
 
 	return map[string]any{
@@ -569,3 +578,151 @@ func (r *TransactionRepositoryImpl) GetRevenueGrowth(ctx context.Context) (map[s
 		"growth_percentage":      growthPercentage,
 	}, nil
 }
+
+// GetRevenueByExchangeRate sums revenue per exchange rate and product or day
+// The rate for each transaction is the latest one for currency that took
+// effect at or before the transaction; RateID is 0 where none had yet
+func (r *TransactionRepositoryImpl) GetRevenueByExchangeRate(ctx context.Context, currency string, groupBy repositories.RevenueGrouping, start, end *time.Time) ([]*entities.RevenueAtRate, error) {
+	var keyExpr string
+	switch groupBy {
+	case repositories.RevenueByProduct:
+		keyExpr = "t.product_id"
+	case repositories.RevenueByDay:
+		keyExpr = "DATE(t.transaction_at)"
+	default:
+		return nil, fmt.Errorf("unknown revenue grouping: %s", groupBy)
+	}
+
+	query := dbFromContext(ctx, r.db).Table("transactions t").
+		Select("(SELECT er.id FROM exchange_rates er WHERE er.currency = ? AND er.effective_from <= t.transaction_at "+
+			"ORDER BY er.effective_from DESC LIMIT 1) AS rate_id, "+
+			keyExpr+" AS group_key, "+
+			"COALESCE(SUM(CASE WHEN t.type = 'order' THEN t.amount_minor ELSE 0 END), 0) AS gross, "+
+			"COALESCE(SUM(CASE WHEN t.type = 'refund' THEN t.amount_minor ELSE 0 END), 0) AS refunded, "+
+			"COALESCE(SUM(CASE WHEN t.type = 'order' THEN t.cost_of_goods_minor ELSE -t.cost_of_goods_minor END), 0) AS cost", currency).
+		Where("t.type IN ?", revenueTypes).
+		Group("rate_id, group_key")
+
+	if start != nil && end != nil {
+		query = query.Where("t.transaction_at BETWEEN ? AND ?", *start, *end)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by exchange rate: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*entities.RevenueAtRate
+	for rows.Next() {
+		var rateID *uint
+		var key any
+		var gross, refunded, cost int64
+		if err := rows.Scan(&rateID, &key, &gross, &refunded, &cost); err != nil {
+			return nil, fmt.Errorf("failed to scan revenue by exchange rate: %w", err)
+		}
+
+		revenue := &entities.RevenueAtRate{
+			Key:      formatDate(key),
+			Gross:    entities.NewMoney(gross, ""),
+			Refunded: entities.NewMoney(refunded, ""),
+			Cost:     entities.NewMoney(cost, ""),
+		}
+		if rateID != nil {
+			revenue.RateID = *rateID
+		}
+		results = append(results, revenue)
+	}
+
+	return results, rows.Err()
+}
+
+// GetTaxLiability sums the tax on order and refund transactions of taxed
+// products per jurisdiction, tax class and rate
+func (r *TransactionRepositoryImpl) GetTaxLiability(ctx context.Context, start, end *time.Time) ([]*entities.TaxLiability, error) {
+	query := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
+		Select("tax_jurisdiction, tax_class, tax_rate_hundredths, "+
+			"COALESCE(SUM(CASE WHEN type = 'order' THEN amount_minor - tax_minor ELSE 0 END), 0) AS taxable_sales, "+
+			"COALESCE(SUM(CASE WHEN type = 'refund' THEN amount_minor - tax_minor ELSE 0 END), 0) AS taxable_refunds, "+
+			"COALESCE(SUM(CASE WHEN type = 'order' THEN tax_minor ELSE 0 END), 0) AS tax_collected, "+
+			"COALESCE(SUM(CASE WHEN type = 'refund' THEN tax_minor ELSE 0 END), 0) AS tax_refunded, "+
+			"COUNT(*) AS transaction_count").
+		Where("type IN ? AND tax_class <> ''", revenueTypes).
+		Group("tax_jurisdiction, tax_class, tax_rate_hundredths").
+		Order("tax_jurisdiction, tax_class, tax_rate_hundredths")
+
+	if start != nil && end != nil {
+		query = query.Where("transaction_at BETWEEN ? AND ?", *start, *end)
+	}
+
+	var rows []struct {
+		TaxJurisdiction   string
+		TaxClass          string
+		TaxRateHundredths int64
+		TaxableSales      int64
+		TaxableRefunds    int64
+		TaxCollected      int64
+		TaxRefunded       int64
+		TransactionCount  int
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to calculate tax liability: %w", err)
+	}
+
+	liabilities := make([]*entities.TaxLiability, len(rows))
+	for i, row := range rows {
+		liabilities[i] = &entities.TaxLiability{
+			Jurisdiction:     row.TaxJurisdiction,
+			TaxClass:         row.TaxClass,
+			Rate:             entities.NewPercentFromHundredths(row.TaxRateHundredths),
+			TaxableSales:     entities.NewMoney(row.TaxableSales, ""),
+			TaxableRefunds:   entities.NewMoney(row.TaxableRefunds, ""),
+			TaxCollected:     entities.NewMoney(row.TaxCollected, ""),
+			TaxRefunded:      entities.NewMoney(row.TaxRefunded, ""),
+			TaxDue:           entities.NewMoney(row.TaxCollected-row.TaxRefunded, ""),
+			TransactionCount: row.TransactionCount,
+		}
+	}
+	return liabilities, nil
+}
+
+// GetCreditBalance derives a customer's store credit balance from the ledger
+func (r *TransactionRepositoryImpl) GetCreditBalance(ctx context.Context, customerID string) (entities.Money, error) {
+	var balance int64
+	if err := dbFromContext(ctx, r.db).Model(&persistence.Transaction{}).
+		Where("customer_id = ? AND type IN ?", customerID, walletTypes).
+		Select("COALESCE(SUM(" + creditAmountExpr + "), 0)").Scan(&balance).Error; err != nil {
+		return entities.Money{}, fmt.Errorf("failed to calculate store credit balance: %w", err)
+	}
+
+	return entities.NewMoney(balance, ""), nil
+}
+
+// GetWalletTransactions retrieves a customer's store credit entries, oldest first
+func (r *TransactionRepositoryImpl) GetWalletTransactions(ctx context.Context, customerID string) ([]*entities.Transaction, error) {
+	var models []persistence.Transaction
+	if err := dbFromContext(ctx, r.db).
+		Where("customer_id = ? AND type IN ?", customerID, walletTypes).
+		Order("transaction_at ASC, created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("failed to get wallet transactions: %w", err)
+	}
+
+	return persistence.ModelsToTransactions(models), nil
+}
+
+// formatDate renders a DATE() column as YYYY-MM-DD
+// MySQL and PostgreSQL return a time value, SQLite returns text
+// Other values, such as product IDs grouped on, are returned as text
+func formatDate(value any) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format("2006-01-02")
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+
+	"day5/internal/database"
+	"day5/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey is the context key under which the active transaction is stored
+type txContextKey struct{}
+
+// UnitOfWorkImpl implements the UnitOfWork interface on top of DatabaseManager
+type UnitOfWorkImpl struct {
+	manager *database.DatabaseManager
+}
+
+// NewUnitOfWork creates a new unit of work backed by the database manager
+func NewUnitOfWork(manager *database.DatabaseManager) repositories.UnitOfWork {
+	return &UnitOfWorkImpl{
+		manager: manager,
+	}
+}
+
+// Do runs fn inside a database transaction shared by all repositories
+func (u *UnitOfWorkImpl) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Join the outer transaction if one is already active
+	if _, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return u.manager.Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// dbFromContext returns the transaction bound to ctx, or db when no unit of work is active
+func dbFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,219 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CartHandler handles HTTP requests for shopping carts
+type CartHandler struct {
+	cartUseCase *usecases.CartUseCase
+}
+
+// NewCartHandler creates a new cart handler with dependency injection
+func NewCartHandler(cartUseCase *usecases.CartUseCase) *CartHandler {
+	return &CartHandler{
+		cartUseCase: cartUseCase,
+	}
+}
+
+// GetCart handles GET /api/v1/customer/:id/cart
+// @Summary Get cart
+// @Description Retrieves the customer's cart priced at current product prices
+// @Tags Cart
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Success 200 {object} entities.Cart
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/customer/{id}/cart [get]
+func (h *CartHandler) GetCart(c *gin.Context) {
+	cart, err := h.cartUseCase.GetCart(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeCartError(c, err, "Failed to get cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// AddItem handles POST /api/v1/customer/:id/cart/items
+// @Summary Add to cart
+// @Description Adds a product to the cart; adding a product already in the cart increases its quantity
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param item body usecases.AddCartItemRequest true "Product and quantity"
+// @Success 200 {object} entities.Cart
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/customer/{id}/cart/items [post]
+func (h *CartHandler) AddItem(c *gin.Context) {
+	var req usecases.AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	cart, err := h.cartUseCase.AddItem(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writeCartError(c, err, "Failed to add item to cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// UpdateItem handles PUT /api/v1/customer/:id/cart/items/:product_id
+// @Summary Update cart item
+// @Description Sets the quantity of a product already in the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param product_id path string true "Product ID"
+// @Param item body usecases.UpdateCartItemRequest true "New quantity"
+// @Success 200 {object} entities.Cart
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/customer/{id}/cart/items/{product_id} [put]
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	var req usecases.UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	cart, err := h.cartUseCase.UpdateItem(c.Request.Context(), c.Param("id"), c.Param("product_id"), &req)
+	if err != nil {
+		writeCartError(c, err, "Failed to update cart item")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// RemoveItem handles DELETE /api/v1/customer/:id/cart/items/:product_id
+// @Summary Remove cart item
+// @Description Takes a product out of the cart
+// @Tags Cart
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param product_id path string true "Product ID"
+// @Success 200 {object} entities.Cart
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/customer/{id}/cart/items/{product_id} [delete]
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	cart, err := h.cartUseCase.RemoveItem(c.Request.Context(), c.Param("id"), c.Param("product_id"))
+	if err != nil {
+		writeCartError(c, err, "Failed to remove cart item")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// ClearCart handles DELETE /api/v1/customer/:id/cart
+// @Summary Clear cart
+// @Description Removes every item from the cart
+// @Tags Cart
+// @Param id path string true "Customer ID"
+// @Success 204
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/customer/{id}/cart [delete]
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	if err := h.cartUseCase.ClearCart(c.Request.Context(), c.Param("id")); err != nil {
+		writeCartError(c, err, "Failed to clear cart")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Checkout handles POST /api/v1/customer/:id/cart/checkout
+// @Summary Check out cart
+// @Description Places one order for everything in the cart, taking stock for all lines together, and empties the cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param checkout body usecases.CheckoutRequest false "Store credit to spend"
+// @Success 201 {object} OrderResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 429 {object} map[string]any
+// @Router /api/v1/customer/{id}/cart/checkout [post]
+func (h *CartHandler) Checkout(c *gin.Context) {
+	var req usecases.CheckoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	orderResponse, err := h.cartUseCase.Checkout(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		if errors.Is(err, entities.ErrCartEmpty) || errors.Is(err, repositories.ErrNotFound) {
+			writeCartError(c, err, "Failed to check out cart")
+			return
+		}
+		writePlaceOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, placedOrderResponse(orderResponse))
+}
+
+// writeCartError maps a failed cart operation to an HTTP response
+func writeCartError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Customer, product or cart item not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrProductHasVariants):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Product is sold by variant",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Cart total is out of range",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrProductArchived):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Product is archived",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrCartEmpty):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Cart is empty",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
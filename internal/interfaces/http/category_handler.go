@@ -0,0 +1,158 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryHandler handles HTTP requests for the product category tree
+type CategoryHandler struct {
+	categoryUseCase *usecases.CategoryUseCase
+}
+
+// NewCategoryHandler creates a new category handler with dependency injection
+func NewCategoryHandler(categoryUseCase *usecases.CategoryUseCase) *CategoryHandler {
+	return &CategoryHandler{
+		categoryUseCase: categoryUseCase,
+	}
+}
+
+// CategoryListResponse represents the response for listing categories
+type CategoryListResponse struct {
+	Categories []*entities.Category `json:"categories"`
+	Count      int                  `json:"count"`
+}
+
+// CreateCategory handles POST /api/v1/category
+// @Summary Add a category
+// @Description Creates a product category, at the top level or under a parent
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param category body usecases.CreateCategoryRequest true "Name and optional parent"
+// @Success 201 {object} entities.Category
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/category [post]
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var req usecases.CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	category, err := h.categoryUseCase.CreateCategory(c.Request.Context(), &req)
+	if err != nil {
+		writeCategoryError(c, err, "Failed to create category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// GetCategories handles GET /api/v1/categories
+// @Summary List categories
+// @Description Retrieves the whole category tree, parents before their children
+// @Tags Categories
+// @Produce json
+// @Success 200 {object} CategoryListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/categories [get]
+func (h *CategoryHandler) GetCategories(c *gin.Context) {
+	categories, err := h.categoryUseCase.GetCategories(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get categories",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, CategoryListResponse{
+		Categories: categories,
+		Count:      len(categories),
+	})
+}
+
+// GetCategory handles GET /api/v1/category/:id
+// @Summary Get a category
+// @Description Retrieves a category by ID
+// @Tags Categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} entities.Category
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/category/{id} [get]
+func (h *CategoryHandler) GetCategory(c *gin.Context) {
+	category, err := h.categoryUseCase.GetCategory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeCategoryError(c, err, "Failed to get category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// UpdateCategory handles PUT /api/v1/category/:id
+// @Summary Rename or move a category
+// @Description Renames a category and/or moves it, with its subcategories and their products, under another parent
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body usecases.UpdateCategoryRequest true "New name and/or parent; an empty parent_id moves it to the top level"
+// @Success 200 {object} entities.Category
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/category/{id} [put]
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	var req usecases.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	category, err := h.categoryUseCase.UpdateCategory(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writeCategoryError(c, err, "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// writeCategoryError maps category use case errors to HTTP responses
+func writeCategoryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Category not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrInvalidCategory):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
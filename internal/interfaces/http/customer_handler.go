@@ -1,11 +1,13 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"day5/internal/application/usecases"
 	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,10 +26,14 @@ func NewCustomerHandler(customerUseCase *usecases.CustomerUseCase) *CustomerHand
 
 // CustomerResponse represents the HTTP response for customer operations
 type CustomerResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Phone     string `json:"phone"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Version int    `json:"version"`
+
+	TaxJurisdiction string `json:"tax_jurisdiction,omitempty"` // empty when taxed where the business is
+
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 	Message   string `json:"message,omitempty"`
@@ -77,6 +83,13 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, entities.ErrInvalidTaxRate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create customer",
 			"details": err.Error(),
@@ -114,10 +127,95 @@ func (h *CustomerHandler) GetCustomer(c *gin.Context) {
 		return
 	}
 
+	setETag(c, customer.Version)
 	response := h.entityToResponse(customer, "")
 	c.JSON(http.StatusOK, response)
 }
 
+// UpdateCustomer handles PUT /api/v1/customer/:id
+// @Summary Update a customer
+// @Description Updates customer contact details; requires the ETag from GET as If-Match
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param If-Match header string true "ETag of the version being updated"
+// @Param customer body usecases.UpdateCustomerRequest true "Customer update details"
+// @Success 200 {object} CustomerResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 412 {object} map[string]any
+// @Failure 428 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/customer/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Customer ID is required",
+		})
+		return
+	}
+
+	// Optimistic concurrency: the client must say which version it is editing
+	expectedVersion, ok, err := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "If-Match header is required",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid If-Match header",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var req usecases.UpdateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	customer, err := h.customerUseCase.UpdateCustomer(c.Request.Context(), id, expectedVersion, &req)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Customer not found",
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"error":   "Customer was modified by someone else",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, entities.ErrInvalidTaxRate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update customer",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	setETag(c, customer.Version)
+	response := h.entityToResponse(customer, "Customer successfully updated")
+	c.JSON(http.StatusOK, response)
+}
+
 // GetCustomers handles GET /api/v1/customers
 // @Summary List all customers
 // @Description Retrieves a list of all customers with pagination
@@ -176,7 +274,7 @@ func (h *CustomerHandler) GetCooldownStatus(c *gin.Context) {
 
 	status, err := h.customerUseCase.GetCooldownStatus(c.Request.Context(), customerID)
 	if err != nil {
-		if err.Error() == "customer not found" {
+		if errors.Is(err, repositories.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Customer not found",
 			})
@@ -237,10 +335,14 @@ func (h *CustomerHandler) SearchCustomers(c *gin.Context) {
 // Helper method to convert domain entity to HTTP response
 func (h *CustomerHandler) entityToResponse(customer *entities.Customer, message string) *CustomerResponse {
 	return &CustomerResponse{
-		ID:        customer.ID,
-		Name:      customer.Name,
-		Email:     customer.Email,
-		Phone:     customer.Phone,
+		ID:      customer.ID,
+		Name:    customer.Name,
+		Email:   customer.Email,
+		Phone:   customer.Phone,
+		Version: customer.Version,
+
+		TaxJurisdiction: customer.TaxJurisdiction,
+
 		CreatedAt: customer.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		UpdatedAt: customer.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 		Message:   message,
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes a record version as a strong entity tag
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf("\"%d\"", version))
+}
+
+// parseIfMatch reads the version the client expects from the If-Match header
+// The second return value is false when the header is missing
+func parseIfMatch(c *gin.Context) (int, bool, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, false, nil
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	tag = strings.Trim(tag, "\"")
+
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, true, fmt.Errorf("invalid If-Match header: %s", header)
+	}
+
+	return version, true, nil
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExchangeRateHandler handles HTTP requests for exchange rates
+type ExchangeRateHandler struct {
+	exchangeRateUseCase *usecases.ExchangeRateUseCase
+}
+
+// NewExchangeRateHandler creates a new exchange rate handler with dependency injection
+func NewExchangeRateHandler(exchangeRateUseCase *usecases.ExchangeRateUseCase) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateUseCase: exchangeRateUseCase,
+	}
+}
+
+// ExchangeRateListResponse represents the HTTP response for listing exchange rates
+type ExchangeRateListResponse struct {
+	BaseCurrency  string                   `json:"base_currency"`
+	ExchangeRates []*entities.ExchangeRate `json:"exchange_rates"`
+	Count         int                      `json:"count"`
+}
+
+// CreateRate handles POST /api/v1/exchange-rate
+// @Summary Publish exchange rate
+// @Description Adds a rate from the base currency that applies from its effective date (default now) until the next rate for the currency
+// @Tags Exchange Rates
+// @Accept json
+// @Produce json
+// @Param rate body usecases.CreateExchangeRateRequest true "Currency, rate and effective date"
+// @Success 201 {object} entities.ExchangeRate
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/exchange-rate [post]
+func (h *ExchangeRateHandler) CreateRate(c *gin.Context) {
+	var req usecases.CreateExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rate, err := h.exchangeRateUseCase.CreateRate(c.Request.Context(), &req)
+	if err != nil {
+		writeExchangeRateError(c, err, "Failed to create exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// GetRates handles GET /api/v1/exchange-rates
+// @Summary List exchange rates
+// @Description Lists rate history, newest first per currency
+// @Tags Exchange Rates
+// @Produce json
+// @Param currency query string false "Only rates for this currency"
+// @Success 200 {object} ExchangeRateListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/exchange-rates [get]
+func (h *ExchangeRateHandler) GetRates(c *gin.Context) {
+	rates, err := h.exchangeRateUseCase.GetRates(c.Request.Context(), c.Query("currency"))
+	if err != nil {
+		writeExchangeRateError(c, err, "Failed to get exchange rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, ExchangeRateListResponse{
+		BaseCurrency:  h.exchangeRateUseCase.BaseCurrency(),
+		ExchangeRates: rates,
+		Count:         len(rates),
+	})
+}
+
+// GetEffectiveRate handles GET /api/v1/exchange-rate/:currency
+// @Summary Get effective exchange rate
+// @Description Retrieves the rate for a currency in effect now, or at the given time
+// @Tags Exchange Rates
+// @Produce json
+// @Param currency path string true "Currency code"
+// @Param at query string false "RFC 3339 time (default: now)"
+// @Success 200 {object} entities.ExchangeRate
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/exchange-rate/{currency} [get]
+func (h *ExchangeRateHandler) GetEffectiveRate(c *gin.Context) {
+	at := time.Now().UTC()
+	if param := c.Query("at"); param != "" {
+		parsed, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid at format. Use RFC 3339, e.g. 2024-01-15T10:00:00Z",
+			})
+			return
+		}
+		at = parsed
+	}
+
+	rate, err := h.exchangeRateUseCase.GetEffectiveRate(c.Request.Context(), c.Param("currency"), at)
+	if err != nil {
+		writeExchangeRateError(c, err, "Failed to get exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// writeExchangeRateError maps a failed exchange rate operation to an HTTP response
+func writeExchangeRateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid exchange rate",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrNoExchangeRate):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "No exchange rate in effect",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader lets clients retry a POST without repeating its effect
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks a response replayed from an earlier request
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// responseRecorder keeps a copy of the response body as it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency returns middleware that honours the Idempotency-Key header
+// The first request with a key is handled normally and its response stored;
+// a retry with the same key and body gets that response back byte-for-byte,
+// while the same key with a different body is rejected with 422.
+// Requests without the header are not affected.
+func Idempotency(idempotencyUseCase *usecases.IdempotencyUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Storing the outcome must not fail because the client gave up waiting
+		ctx := context.WithoutCancel(c.Request.Context())
+		fingerprint := idempotencyUseCase.Fingerprint(c.Request.Method, c.Request.URL.Path, body)
+		replay, err := idempotencyUseCase.Begin(ctx, key, fingerprint)
+		if err != nil {
+			writeIdempotencyError(c, err)
+			return
+		}
+
+		if replay != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(replay.StatusCode, replay.ContentType, replay.Body)
+			c.Abort()
+			return
+		}
+
+		// Release the key if the handler panics or fails unexpectedly, so the
+		// client can retry; anything else is a final answer worth replaying
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := idempotencyUseCase.Release(ctx, key); err != nil {
+				log.Printf("failed to release idempotency key %s: %v", key, err)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := idempotencyUseCase.Complete(ctx, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("failed to store response for idempotency key %s: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// writeIdempotencyError maps Idempotency-Key errors to HTTP responses
+func writeIdempotencyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidIdempotencyKey):
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid Idempotency-Key header",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrIdempotencyKeyReused):
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Idempotency-Key was already used for a different request",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrIdempotencyKeyInProgress):
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error":   "A request with this Idempotency-Key is still being processed",
+			"details": err.Error(),
+		})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to check Idempotency-Key",
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/pdf"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mimePDF is the media type of invoices rendered as PDF
+const mimePDF = "application/pdf"
+
+// InvoiceHandler handles HTTP requests for order invoices
+type InvoiceHandler struct {
+	invoiceUseCase *usecases.InvoiceUseCase
+}
+
+// NewInvoiceHandler creates a new invoice handler with dependency injection
+func NewInvoiceHandler(invoiceUseCase *usecases.InvoiceUseCase) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceUseCase: invoiceUseCase,
+	}
+}
+
+// GetInvoice handles GET /api/v1/order/:id/invoice
+// @Summary Get an order's invoice
+// @Description Returns the invoice issued when the order was placed, as JSON or, with Accept: application/pdf, as a PDF document
+// @Tags Orders
+// @Produce json
+// @Produce application/pdf
+// @Param id path string true "Order ID"
+// @Success 200 {object} entities.Invoice
+// @Failure 404 {object} map[string]any
+// @Failure 406 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/order/{id}/invoice [get]
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	c.Header("Vary", "Accept")
+	format := c.NegotiateFormat(gin.MIMEJSON, mimePDF)
+	if format == "" {
+		c.JSON(http.StatusNotAcceptable, gin.H{
+			"error":   "Not acceptable",
+			"details": fmt.Sprintf("invoices are available as %s or %s", gin.MIMEJSON, mimePDF),
+		})
+		return
+	}
+
+	invoice, err := h.invoiceUseCase.GetInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to get invoice")
+		return
+	}
+
+	if format == gin.MIMEJSON {
+		c.JSON(http.StatusOK, invoice)
+		return
+	}
+
+	document, err := pdf.RenderInvoice(invoice)
+	if err != nil {
+		writeInvoiceError(c, err, "Failed to render invoice")
+		return
+	}
+	filename := strings.ReplaceAll(invoice.Number, "/", "-") + ".pdf"
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Data(http.StatusOK, mimePDF, document)
+}
+
+// writeInvoiceError maps invoice use case errors to HTTP responses
+func writeInvoiceError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocationHandler handles HTTP requests for stores and warehouses
+type LocationHandler struct {
+	locationUseCase *usecases.LocationUseCase
+}
+
+// NewLocationHandler creates a new location handler with dependency injection
+func NewLocationHandler(locationUseCase *usecases.LocationUseCase) *LocationHandler {
+	return &LocationHandler{
+		locationUseCase: locationUseCase,
+	}
+}
+
+// LocationListResponse represents the response for listing locations
+type LocationListResponse struct {
+	Locations []*entities.Location `json:"locations"`
+	Count     int                  `json:"count"`
+}
+
+// CreateLocation handles POST /api/v1/location
+// @Summary Add a store or warehouse
+// @Description Creates a stock location; it starts without stock
+// @Tags Locations
+// @Accept json
+// @Produce json
+// @Param location body usecases.CreateLocationRequest true "Code, name, type and optional position"
+// @Success 201 {object} entities.Location
+// @Failure 400 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/location [post]
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	var req usecases.CreateLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	location, err := h.locationUseCase.CreateLocation(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrInvalidLocation):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+		case errors.Is(err, repositories.ErrAlreadyExists):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "A location with this code already exists",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to create location",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, location)
+}
+
+// GetLocations handles GET /api/v1/locations
+// @Summary List locations
+// @Description Retrieves all stores and warehouses, the default location first
+// @Tags Locations
+// @Produce json
+// @Success 200 {object} LocationListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/locations [get]
+func (h *LocationHandler) GetLocations(c *gin.Context) {
+	locations, err := h.locationUseCase.GetLocations(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get locations",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, LocationListResponse{
+		Locations: locations,
+		Count:     len(locations),
+	})
+}
+
+// GetLocation handles GET /api/v1/location/:id
+// @Summary Get a location
+// @Description Retrieves a store or warehouse by ID
+// @Tags Locations
+// @Produce json
+// @Param id path string true "Location ID"
+// @Success 200 {object} entities.Location
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/location/{id} [get]
+func (h *LocationHandler) GetLocation(c *gin.Context) {
+	location, err := h.locationUseCase.GetLocation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeLocationError(c, err, "Failed to get location")
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// GetLocationStock handles GET /api/v1/location/:id/stock
+// @Summary Stock held at a location
+// @Description Retrieves the stock of every product held at a location
+// @Tags Locations
+// @Produce json
+// @Param id path string true "Location ID"
+// @Success 200 {object} StockLevelListResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/location/{id}/stock [get]
+func (h *LocationHandler) GetLocationStock(c *gin.Context) {
+	levels, err := h.locationUseCase.GetLocationStock(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeLocationError(c, err, "Failed to get location stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, StockLevelListResponse{
+		Levels: levels,
+		Count:  len(levels),
+	})
+}
+
+// writeLocationError maps a failed location lookup to an HTTP response
+func writeLocationError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Location not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"day5/internal/application/usecases"
 	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,17 +27,42 @@ func NewOrderHandler(orderUseCase *usecases.OrderUseCase) *OrderHandler {
 
 // OrderResponse represents the HTTP response for order operations
 type OrderResponse struct {
-	ID           string  `json:"id"`
-	CustomerID   string  `json:"customer_id"`
-	CustomerName string  `json:"customer_name,omitempty"`
-	ProductID    string  `json:"product_id"`
-	ProductName  string  `json:"product_name,omitempty"`
-	Quantity     int     `json:"quantity"`
-	UnitPrice    float64 `json:"unit_price"`
-	TotalAmount  float64 `json:"total_amount"`
-	OrderDate    string  `json:"order_date"`
-	CreatedAt    string  `json:"created_at"`
-	Message      string  `json:"message,omitempty"`
+	ID           string         `json:"id"`
+	CustomerID   string         `json:"customer_id"`
+	CustomerName string         `json:"customer_name,omitempty"`
+	ProductID    string         `json:"product_id,omitempty"`
+	ProductName  string         `json:"product_name,omitempty"`
+	Quantity     int            `json:"quantity"`
+	UnitPrice    entities.Money `json:"unit_price"`
+	TotalAmount  entities.Money `json:"total_amount"`
+	Status       string         `json:"status"`
+	OrderDate    string         `json:"order_date"`
+	CreatedAt    string         `json:"created_at"`
+	Message      string         `json:"message,omitempty"`
+
+	// Discount is what promotions took off TotalAmount
+	Discount   entities.Money `json:"discount"`
+	CouponCode string         `json:"coupon_code,omitempty"`
+
+	// Tax is the part of TotalAmount that is tax, charged under the rates of TaxJurisdiction
+	Tax             entities.Money `json:"tax"`
+	TaxJurisdiction string         `json:"tax_jurisdiction,omitempty"`
+
+	// InvoiceNumber is set when the order is placed; GET /api/v1/order/:id/invoice returns the invoice
+	InvoiceNumber string `json:"invoice_number,omitempty"`
+
+	StoreCreditApplied entities.Money `json:"store_credit_applied"`
+	AmountDue          entities.Money `json:"amount_due"`
+
+	// The amount due as charged to the customer, converted at ExchangeRate
+	ChargeCurrency string         `json:"charge_currency"`
+	ExchangeRate   entities.Rate  `json:"exchange_rate"`
+	ChargedAmount  entities.Money `json:"charged_amount"`
+
+	LocationID string `json:"location_id,omitempty"` // where the order ships from
+
+	Lines         []*entities.OrderLine         `json:"lines,omitempty"`
+	StatusHistory []*entities.OrderStatusChange `json:"status_history,omitempty"`
 }
 
 // OrderHistoryResponse represents the response for order history
@@ -46,13 +74,14 @@ type OrderHistoryResponse struct {
 
 // PlaceOrder handles POST /api/v1/order
 // @Summary Place a new order
-// @Description Places an order with cooldown validation and inventory management
+// @Description Places an order for one product (product_id, quantity) or several (items) with cooldown validation and inventory management
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param order body usecases.PlaceOrderRequest true "Order details"
 // @Success 201 {object} OrderResponse
 // @Failure 400 {object} map[string]any
+// @Failure 409 {object} map[string]any
 // @Failure 429 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/order [post]
@@ -74,40 +103,129 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 	// Call use case (business logic layer)
 	orderResponse, err := h.orderUseCase.PlaceOrder(c.Request.Context(), &req)
 	if err != nil {
-		// Handle cooldown errors specifically
-		if cooldownErr, ok := err.(*usecases.CooldownError); ok {
-			response := gin.H{
-				"error":                      "Customer is in cooldown period",
-				"cooldown_remaining_seconds": int(cooldownErr.RemainingTime.Seconds()),
-				"cooldown_remaining_minutes": cooldownErr.RemainingTime.Minutes(),
-			}
-			// Add full cooldown status if available
-			if cooldownErr.CooldownStatus != nil {
-				for k, v := range cooldownErr.CooldownStatus {
-					response[k] = v
-				}
+		writePlaceOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, placedOrderResponse(orderResponse))
+}
+
+// writePlaceOrderError maps a failed order placement to an HTTP response
+func writePlaceOrderError(c *gin.Context, err error) {
+	// Handle cooldown errors specifically
+	if cooldownErr, ok := err.(*usecases.CooldownError); ok {
+		response := gin.H{
+			"error":                      "Customer is in cooldown period",
+			"cooldown_remaining_seconds": int(cooldownErr.RemainingTime.Seconds()),
+			"cooldown_remaining_minutes": cooldownErr.RemainingTime.Minutes(),
+		}
+		// Add full cooldown status if available
+		if cooldownErr.CooldownStatus != nil {
+			for k, v := range cooldownErr.CooldownStatus {
+				response[k] = v
 			}
-			c.JSON(http.StatusTooManyRequests, response)
-			return
 		}
+		c.JSON(http.StatusTooManyRequests, response)
+		return
+	}
 
-		// Handle other business logic errors
-		if err.Error() == "insufficient quantity" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Insufficient product quantity",
-			})
-			return
-		}
+	// Handle other business logic errors
+	var stockErr *usecases.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":              "Insufficient product quantity",
+			"product_id":         stockErr.ProductID,
+			"available_quantity": stockErr.Available,
+			"requested_quantity": stockErr.Requested,
+		})
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to place order",
+	var creditErr *usecases.InsufficientCreditError
+	if errors.As(err, &creditErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":                  "Insufficient store credit",
+			"customer_id":            creditErr.CustomerID,
+			"available_store_credit": creditErr.Available,
+			"requested_store_credit": creditErr.Requested,
+		})
+		return
+	}
+
+	if errors.Is(err, entities.ErrProductArchived) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Product is archived",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	// Convert use case response to HTTP response
-	response := &OrderResponse{
+	if errors.Is(err, usecases.ErrInvalidOrderItems) || errors.Is(err, entities.ErrInvalidAmount) ||
+		errors.Is(err, entities.ErrInvalidLocation) || errors.Is(err, entities.ErrProductHasVariants) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, entities.ErrInvalidReservation) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid reservation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, entities.ErrReservationNotActive) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Reservation is no longer active",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, entities.ErrCouponNotUsable) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Coupon cannot be used",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, entities.ErrNoTaxRate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Product cannot be taxed",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, entities.ErrNoExchangeRate) || errors.Is(err, entities.ErrInvalidExchangeRate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Currency not accepted",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Customer, product, reservation or location not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to place order",
+		"details": err.Error(),
+	})
+}
+
+// placedOrderResponse converts the use case response to the HTTP response
+func placedOrderResponse(orderResponse *usecases.OrderResponse) *OrderResponse {
+	return &OrderResponse{
 		ID:           orderResponse.ID,
 		CustomerID:   orderResponse.CustomerID,
 		CustomerName: orderResponse.CustomerName,
@@ -116,11 +234,27 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 		Quantity:     orderResponse.Quantity,
 		UnitPrice:    orderResponse.UnitPrice,
 		TotalAmount:  orderResponse.TotalAmount,
+		Status:       string(orderResponse.Status),
 		OrderDate:    orderResponse.OrderDate.Format("2006-01-02T15:04:05Z"),
 		Message:      orderResponse.Message,
-	}
+		Lines:        orderResponse.Lines,
 
-	c.JSON(http.StatusCreated, response)
+		Discount:   orderResponse.Discount,
+		CouponCode: orderResponse.CouponCode,
+
+		Tax:             orderResponse.Tax,
+		TaxJurisdiction: orderResponse.TaxJurisdiction,
+		InvoiceNumber:   orderResponse.InvoiceNumber,
+
+		StoreCreditApplied: orderResponse.StoreCreditApplied,
+		AmountDue:          orderResponse.AmountDue,
+
+		ChargeCurrency: orderResponse.ChargeCurrency,
+		ExchangeRate:   orderResponse.ExchangeRate,
+		ChargedAmount:  orderResponse.ChargedAmount,
+
+		LocationID: orderResponse.LocationID,
+	}
 }
 
 // GetOrderHistory handles GET /api/v1/orders/customer/:customer_id
@@ -129,6 +263,7 @@ func (h *OrderHandler) PlaceOrder(c *gin.Context) {
 // @Tags Orders
 // @Produce json
 // @Param customer_id path string true "Customer ID"
+// @Param status query string false "Filter by status (pending, confirmed, cancelled, completed)"
 // @Param limit query int false "Limit number of results" default(50)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Success 200 {object} OrderHistoryResponse
@@ -144,12 +279,17 @@ func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
 		return
 	}
 
+	status, ok := parseStatusFilter(c)
+	if !ok {
+		return
+	}
+
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	orders, err := h.orderUseCase.GetOrderHistory(c.Request.Context(), customerID, limit, offset)
+	orders, err := h.orderUseCase.GetOrderHistory(c.Request.Context(), customerID, status, limit, offset)
 	if err != nil {
-		if err.Error() == "customer not found" {
+		if errors.Is(err, repositories.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Customer not found",
 			})
@@ -181,16 +321,23 @@ func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
 // @Description Retrieves all orders with pagination for retailer analytics
 // @Tags Orders
 // @Produce json
+// @Param status query string false "Filter by status (pending, confirmed, cancelled, completed)"
 // @Param limit query int false "Limit number of results" default(50)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Success 200 {object} OrderHistoryResponse
+// @Failure 400 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/orders [get]
 func (h *OrderHandler) GetAllOrders(c *gin.Context) {
+	status, ok := parseStatusFilter(c)
+	if !ok {
+		return
+	}
+
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	orders, err := h.orderUseCase.GetAllOrders(c.Request.Context(), limit, offset)
+	orders, err := h.orderUseCase.GetAllOrders(c.Request.Context(), status, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to retrieve orders",
@@ -240,9 +387,150 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	}
 
 	response := h.entityToResponse(order, "")
+	response.StatusHistory = order.StatusHistory
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmOrder handles POST /api/v1/order/:id/confirm
+// @Summary Confirm an order
+// @Description Moves a pending order to confirmed
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param transition body usecases.OrderStatusRequest false "Who is confirming and why"
+// @Success 200 {object} OrderResponse
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/order/{id}/confirm [post]
+func (h *OrderHandler) ConfirmOrder(c *gin.Context) {
+	h.transitionOrder(c, h.orderUseCase.ConfirmOrder, "Order confirmed")
+}
+
+// CompleteOrder handles POST /api/v1/order/:id/complete
+// @Summary Complete an order
+// @Description Moves a confirmed order to completed
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param transition body usecases.OrderStatusRequest false "Who is completing and why"
+// @Success 200 {object} OrderResponse
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/order/{id}/complete [post]
+func (h *OrderHandler) CompleteOrder(c *gin.Context) {
+	h.transitionOrder(c, h.orderUseCase.CompleteOrder, "Order completed")
+}
+
+// transitionOrder binds the optional transition body and applies a lifecycle use case
+func (h *OrderHandler) transitionOrder(
+	c *gin.Context,
+	transition func(ctx context.Context, orderID string, req *usecases.OrderStatusRequest) (*entities.Order, error),
+	message string,
+) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Order ID is required",
+		})
+		return
+	}
+
+	// The body is optional; an empty one records the default actor
+	var req usecases.OrderStatusRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	order, err := transition(c.Request.Context(), id, &req)
+	if err != nil {
+		writeTransitionError(c, err)
+		return
+	}
+
+	response := h.entityToResponse(order, message)
+	response.StatusHistory = order.StatusHistory
 	c.JSON(http.StatusOK, response)
 }
 
+// CancelOrder handles POST /api/v1/order/:id/cancel
+// @Summary Cancel an order
+// @Description Cancels an order inside the cancellation window, restocks it and writes a refund
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param cancellation body usecases.CancelOrderRequest false "Who is cancelling, why, and whether to clear the cooldown"
+// @Success 200 {object} OrderResponse
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/order/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Order ID is required",
+		})
+		return
+	}
+
+	var req usecases.CancelOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	order, err := h.orderUseCase.CancelOrder(c.Request.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, entities.ErrCancellationWindowClosed) || errors.Is(err, entities.ErrOrderHasReturns) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Order can no longer be cancelled",
+				"details": err.Error(),
+			})
+			return
+		}
+		writeTransitionError(c, err)
+		return
+	}
+
+	response := h.entityToResponse(order, "Order cancelled")
+	response.StatusHistory = order.StatusHistory
+	c.JSON(http.StatusOK, response)
+}
+
+// writeTransitionError maps a failed status change to an HTTP response
+func writeTransitionError(c *gin.Context, err error) {
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Order not found",
+		})
+		return
+	}
+	if errors.Is(err, entities.ErrInvalidStatusTransition) || errors.Is(err, repositories.ErrVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Order cannot move to the requested status",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "Failed to update order status",
+		"details": err.Error(),
+	})
+}
+
 // GetTodaysOrders handles GET /api/v1/orders/today
 // @Summary Get today's orders
 // @Description Retrieves all orders placed today
@@ -275,6 +563,19 @@ func (h *OrderHandler) GetTodaysOrders(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// parseStatusFilter reads the optional status query parameter
+// It writes a 400 response and returns false when the status is unknown
+func parseStatusFilter(c *gin.Context) (entities.OrderStatus, bool) {
+	status := entities.OrderStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order status: " + string(status),
+		})
+		return "", false
+	}
+	return status, true
+}
+
 // Helper method to convert domain entity to HTTP response
 func (h *OrderHandler) entityToResponse(order *entities.Order, message string) *OrderResponse {
 	response := &OrderResponse{
@@ -284,10 +585,27 @@ func (h *OrderHandler) entityToResponse(order *entities.Order, message string) *
 		Quantity:    order.Quantity,
 		UnitPrice:   order.UnitPrice,
 		TotalAmount: order.TotalAmount,
+		Status:      string(order.Status),
 		OrderDate:   order.OrderDate.Format("2006-01-02T15:04:05Z"),
 		CreatedAt:   order.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		Message:     message,
+
+		Discount:   order.Discount,
+		CouponCode: order.CouponCode,
+
+		Tax:             order.Tax,
+		TaxJurisdiction: order.TaxJurisdiction,
+
+		StoreCreditApplied: order.StoreCreditApplied,
+		AmountDue:          order.AmountDue(),
+
+		ChargeCurrency: order.ChargeCurrency,
+		ExchangeRate:   order.ExchangeRate,
+		ChargedAmount:  order.ChargedAmount,
+
+		LocationID: order.LocationID,
 	}
+	response.Lines = order.OrderLines()
 
 	// Add related entity information if available
 	if order.Customer != nil {
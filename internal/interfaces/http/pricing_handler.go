@@ -0,0 +1,280 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingHandler handles HTTP requests for price rules, price quotes and list price changes
+type PricingHandler struct {
+	pricingUseCase *usecases.PricingUseCase
+}
+
+// NewPricingHandler creates a new pricing handler with dependency injection
+func NewPricingHandler(pricingUseCase *usecases.PricingUseCase) *PricingHandler {
+	return &PricingHandler{
+		pricingUseCase: pricingUseCase,
+	}
+}
+
+// PriceRuleListResponse represents the response for listing price rules
+type PriceRuleListResponse struct {
+	PriceRules []*entities.PriceRule `json:"price_rules"`
+	Count      int                   `json:"count"`
+}
+
+// PriceHistoryResponse represents the response for a product's price history
+type PriceHistoryResponse struct {
+	ProductID string                  `json:"product_id"`
+	Prices    []*entities.PricePeriod `json:"prices"`
+	Count     int                     `json:"count"`
+}
+
+// CreatePriceRule handles POST /api/v1/price-rule
+// @Summary Add a price rule
+// @Description Sets a fixed price or a percentage off for a price list, from a minimum quantity and/or between two dates
+// @Tags Pricing
+// @Accept json
+// @Produce json
+// @Param rule body usecases.CreatePriceRuleRequest true "Who and what the rule prices, and the price or discount"
+// @Success 201 {object} entities.PriceRule
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/price-rule [post]
+func (h *PricingHandler) CreatePriceRule(c *gin.Context) {
+	var req usecases.CreatePriceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.pricingUseCase.CreatePriceRule(c.Request.Context(), &req)
+	if err != nil {
+		writePricingError(c, err, "Failed to create price rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// GetPriceRule handles GET /api/v1/price-rule/:id
+// @Summary Get a price rule
+// @Description Retrieves a price rule by ID
+// @Tags Pricing
+// @Produce json
+// @Param id path string true "Price rule ID"
+// @Success 200 {object} entities.PriceRule
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/price-rule/{id} [get]
+func (h *PricingHandler) GetPriceRule(c *gin.Context) {
+	rule, err := h.pricingUseCase.GetPriceRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePricingError(c, err, "Failed to get price rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// EndPriceRule handles POST /api/v1/price-rule/:id/end
+// @Summary End a price rule
+// @Description Stops a price rule applying from now on; orders it priced keep their prices
+// @Tags Pricing
+// @Produce json
+// @Param id path string true "Price rule ID"
+// @Success 200 {object} entities.PriceRule
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/price-rule/{id}/end [post]
+func (h *PricingHandler) EndPriceRule(c *gin.Context) {
+	rule, err := h.pricingUseCase.EndPriceRule(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePricingError(c, err, "Failed to end price rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetPriceRules handles GET /api/v1/price-rules
+// @Summary List price rules
+// @Description Retrieves the price rules for a product, or every rule, oldest first
+// @Tags Pricing
+// @Produce json
+// @Param product_id query string false "Only rules for this product"
+// @Success 200 {object} PriceRuleListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/price-rules [get]
+func (h *PricingHandler) GetPriceRules(c *gin.Context) {
+	rules, err := h.pricingUseCase.GetPriceRules(c.Request.Context(), c.Query("product_id"))
+	if err != nil {
+		writePricingError(c, err, "Failed to get price rules")
+		return
+	}
+
+	c.JSON(http.StatusOK, PriceRuleListResponse{
+		PriceRules: rules,
+		Count:      len(rules),
+	})
+}
+
+// QuotePrice handles GET /api/v1/product/:id/price
+// @Summary Quote a price
+// @Description Prices a quantity of a product for a customer as an order placed now would
+// @Tags Pricing
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param customer_id query string false "Customer to price for; without one the retail price list applies"
+// @Param quantity query int false "Number of units" default(1)
+// @Success 200 {object} entities.PriceQuote
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/price [get]
+func (h *PricingHandler) QuotePrice(c *gin.Context) {
+	quantity, err := strconv.Atoi(c.DefaultQuery("quantity", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid quantity",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.pricingUseCase.QuotePrice(c.Request.Context(), c.Param("id"), c.Query("customer_id"), quantity)
+	if err != nil {
+		writePricingError(c, err, "Failed to quote price")
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// SchedulePrice handles POST /api/v1/product/:id/scheduled-prices
+// @Summary Schedule a price change
+// @Description Changes a product's list price at a future time; a parent's price carries over to the variants following it
+// @Tags Pricing
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param price body usecases.SchedulePriceRequest true "New price and when it takes effect"
+// @Success 201 {object} entities.ProductPrice
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/scheduled-prices [post]
+func (h *PricingHandler) SchedulePrice(c *gin.Context) {
+	var req usecases.SchedulePriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	price, err := h.pricingUseCase.SchedulePrice(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writePricingError(c, err, "Failed to schedule price")
+		return
+	}
+
+	c.JSON(http.StatusCreated, price)
+}
+
+// CancelScheduledPrice handles DELETE /api/v1/product/:id/scheduled-prices/:price_id
+// @Summary Cancel a scheduled price change
+// @Description Drops a price change that has not been activated yet
+// @Tags Pricing
+// @Param id path string true "Product ID"
+// @Param price_id path int true "Scheduled price ID"
+// @Success 204
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/scheduled-prices/{price_id} [delete]
+func (h *PricingHandler) CancelScheduledPrice(c *gin.Context) {
+	priceID, err := strconv.ParseUint(c.Param("price_id"), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid price ID",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.pricingUseCase.CancelScheduledPrice(c.Request.Context(), c.Param("id"), uint(priceID)); err != nil {
+		writePricingError(c, err, "Failed to cancel scheduled price")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPriceHistory handles GET /api/v1/product/:id/price-history
+// @Summary Get a product's price history
+// @Description Lists every list price the product has had or is scheduled to have, oldest first, with the units sold and revenue while each was in effect
+// @Tags Pricing
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} PriceHistoryResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/price-history [get]
+func (h *PricingHandler) GetPriceHistory(c *gin.Context) {
+	prices, err := h.pricingUseCase.GetPriceHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePricingError(c, err, "Failed to get price history")
+		return
+	}
+
+	c.JSON(http.StatusOK, PriceHistoryResponse{
+		ProductID: c.Param("id"),
+		Prices:    prices,
+		Count:     len(prices),
+	})
+}
+
+// writePricingError maps pricing use case errors to HTTP responses
+func writePricingError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, repositories.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Price already scheduled",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrInvalidPriceRule),
+		errors.Is(err, entities.ErrInvalidPriceSchedule),
+		errors.Is(err, entities.ErrInvalidAmount),
+		errors.Is(err, entities.ErrProductHasVariants),
+		errors.Is(err, usecases.ErrInvalidOrderItems):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"day5/internal/application/usecases"
 	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,13 +28,42 @@ func NewProductHandler(productUseCase *usecases.ProductUseCase) *ProductHandler
 
 // ProductResponse represents the HTTP response for product operations
 type ProductResponse struct {
-	ID          string  `json:"id"`
-	ProductName string  `json:"product_name"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
-	Message     string  `json:"message,omitempty"`
+	ID          string         `json:"id"`
+	ProductName string         `json:"product_name"`
+	Price       entities.Money `json:"price"`
+	Quantity    int            `json:"quantity"`
+	Reserved    int            `json:"reserved"`  // held by active reservations
+	Available   int            `json:"available"` // quantity that can still be ordered
+	AverageCost entities.Money `json:"average_cost"`
+	Version     int            `json:"version"`
+
+	// Replenishment policy used by reorder suggestions
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
+	LeadTimeDays    int    `json:"lead_time_days"`
+	SupplierID      string `json:"supplier_id,omitempty"`
+
+	// Catalogue details
+	SKU         string                 `json:"sku,omitempty"`
+	Barcode     string                 `json:"barcode,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	CategoryID  string                 `json:"category_id,omitempty"`
+	Attributes  map[string]string      `json:"attributes,omitempty"`
+	Status      entities.ProductStatus `json:"status"`
+	TaxClass    string                 `json:"tax_class,omitempty"` // empty when the product is not taxed
+
+	// Variants and bundles: for a parent, quantity, reserved and available are
+	// the totals over its variants, which are listed with it; for a bundle they
+	// are the number of whole bundles its components' stock makes up
+	Type          entities.ProductType        `json:"type"`
+	ParentID      string                      `json:"parent_id,omitempty"`
+	PriceOverride bool                        `json:"price_override,omitempty"`
+	Variants      []*ProductResponse          `json:"variants,omitempty"`
+	Components    []*entities.BundleComponent `json:"components,omitempty"`
+
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	Message   string `json:"message,omitempty"`
 }
 
 // ProductListResponse represents the response for listing products
@@ -41,6 +73,33 @@ type ProductListResponse struct {
 	Message  string             `json:"message,omitempty"`
 }
 
+// MovementListResponse represents the response for listing inventory movements
+type MovementListResponse struct {
+	Movements []*entities.InventoryMovement `json:"movements"`
+	Count     int                           `json:"count"`
+}
+
+// StockLevelListResponse represents the response for listing stock levels
+type StockLevelListResponse struct {
+	Levels []*entities.StockLevel `json:"levels"`
+	Count  int                    `json:"count"`
+}
+
+// BatchListResponse represents the response for listing inventory batches
+type BatchListResponse struct {
+	Batches []*entities.InventoryBatch `json:"batches"`
+	Count   int                        `json:"count"`
+}
+
+// InventoryValueResponse represents the value of stock on hand
+// Products breaks the value down for the cost methods
+type InventoryValueResponse struct {
+	Method     entities.ValuationMethod     `json:"method"`
+	LocationID string                       `json:"location_id,omitempty"` // empty for all locations
+	Value      entities.Money               `json:"value"`
+	Products   []*entities.ProductValuation `json:"products,omitempty"`
+}
+
 // CreateProduct handles POST /api/v1/product
 // @Summary Create a new product
 // @Description Creates a new product with the provided details
@@ -50,14 +109,16 @@ type ProductListResponse struct {
 // @Param product body usecases.CreateProductRequest true "Product details"
 // @Success 201 {object} ProductResponse
 // @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/product [post]
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	var req usecases.CreateProductRequest
 
 	// Bind and validate request
-	// The binding:"required" tag ensures required fields are present
-	// The binding:"gt=0" tag ensures numeric values are greater than 0
+	// The binding:"required" tag ensures required fields are present;
+	// the price is checked by the use case and reported as ErrInvalidAmount
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request payload",
@@ -69,6 +130,27 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	// Call use case (business logic layer)
 	product, err := h.productUseCase.CreateProduct(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, entities.ErrInvalidAmount) || errors.Is(err, entities.ErrInvalidProduct) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Location, supplier or category not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "SKU or barcode is already used by another product",
+				"details": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create product",
 			"details": err.Error(),
@@ -88,6 +170,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Success 200 {object} ProductResponse
+// @Header 200 {string} ETag "Current product version"
 // @Failure 404 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/product/{id} [get]
@@ -108,18 +191,26 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
+	setETag(c, product.Version)
 	response := h.entityToResponse(product, "")
 	c.JSON(http.StatusOK, response)
 }
 
 // GetProducts handles GET /api/v1/products
-// @Summary List all products
-// @Description Retrieves a list of all products with optional pagination
+// @Summary List the product catalogue
+// @Description Retrieves products with optional filters and pagination; a category includes its subcategories
 // @Tags Products
 // @Produce json
+// @Param category_id query string false "Only products in this category or below it"
+// @Param attribute query []string false "name:value the product must have; repeat for more" collectionFormat(multi)
+// @Param status query string false "active, archived or all" default(active)
+// @Param sku query string false "Exact SKU"
+// @Param barcode query string false "Exact barcode"
 // @Param limit query int false "Limit number of results" default(50)
 // @Param offset query int false "Offset for pagination" default(0)
 // @Success 200 {object} ProductListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/products [get]
 func (h *ProductHandler) GetProducts(c *gin.Context) {
@@ -127,12 +218,45 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	products, err := h.productUseCase.GetAllProducts(c.Request.Context(), limit, offset)
+	query := &usecases.ProductQuery{
+		CategoryID: c.Query("category_id"),
+		Attributes: make(map[string]string),
+		Status:     c.Query("status"),
+		SKU:        c.Query("sku"),
+		Barcode:    c.Query("barcode"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	for _, attribute := range c.QueryArray("attribute") {
+		name, value, ok := strings.Cut(attribute, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid attribute filter",
+				"details": "attribute filters are written name:value, e.g. colour:red",
+			})
+			return
+		}
+		query.Attributes[name] = value
+	}
+
+	products, err := h.productUseCase.GetProducts(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve products",
-			"details": err.Error(),
-		})
+		switch {
+		case errors.Is(err, entities.ErrInvalidProduct):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid status filter",
+				"details": err.Error(),
+			})
+		case errors.Is(err, repositories.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Category not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to retrieve products",
+				"details": err.Error(),
+			})
+		}
 		return
 	}
 
@@ -153,15 +277,19 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 
 // UpdateProduct handles PUT /api/v1/product/:id
 // @Summary Update a product
-// @Description Updates product price and/or quantity
+// @Description Updates price, quantity, replenishment policy and catalogue details, or archives the product; requires the ETag from GET as If-Match
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the version being updated"
 // @Param product body usecases.UpdateProductRequest true "Product update details"
 // @Success 200 {object} ProductResponse
 // @Failure 400 {object} map[string]any
 // @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 412 {object} map[string]any
+// @Failure 428 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/product/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -173,6 +301,22 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	// Optimistic concurrency: the client must say which version it is editing
+	expectedVersion, ok, err := parseIfMatch(c)
+	if !ok {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "If-Match header is required",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid If-Match header",
+			"details": err.Error(),
+		})
+		return
+	}
+
 	var req usecases.UpdateProductRequest
 
 	// Bind and validate request
@@ -187,11 +331,38 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	}
 
 	// Call use case
-	product, err := h.productUseCase.UpdateProduct(c.Request.Context(), id, &req)
+	product, err := h.productUseCase.UpdateProduct(c.Request.Context(), id, expectedVersion, &req)
 	if err != nil {
-		if err.Error() == "product not found" {
+		var stockErr *usecases.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			writeInsufficientStock(c, stockErr)
+			return
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Product not found",
+				"error": "Product, location, supplier or category not found",
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "SKU or barcode is already used by another product",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, entities.ErrInvalidAmount) || errors.Is(err, entities.ErrInvalidMovement) ||
+			errors.Is(err, entities.ErrInvalidProduct) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{
+				"error":   "Product was modified by someone else",
+				"details": err.Error(),
 			})
 			return
 		}
@@ -202,10 +373,362 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	setETag(c, product.Version)
 	response := h.entityToResponse(product, "Product successfully updated")
 	c.JSON(http.StatusOK, response)
 }
 
+// CreateVariant handles POST /api/v1/product/:id/variants
+// @Summary Add a variant to a parent product
+// @Description Creates a variant with its own attributes, SKU and stock; it follows the parent's price unless given one
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Parent product ID"
+// @Param variant body usecases.CreateVariantRequest true "Variant details"
+// @Success 201 {object} ProductResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(c *gin.Context) {
+	var req usecases.CreateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	variant, err := h.productUseCase.CreateVariant(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidAmount) || errors.Is(err, entities.ErrInvalidProduct) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product, location or supplier not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, repositories.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "SKU, barcode or attributes are already used by another product",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create variant",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.entityToResponse(variant, "Variant successfully created"))
+}
+
+// GetVariants handles GET /api/v1/product/:id/variants
+// @Summary List the variants of a parent product
+// @Description Retrieves the variants of a parent product, oldest first
+// @Tags Products
+// @Produce json
+// @Param id path string true "Parent product ID"
+// @Success 200 {object} ProductListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/variants [get]
+func (h *ProductHandler) GetVariants(c *gin.Context) {
+	variants, err := h.productUseCase.GetVariants(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Product not found",
+			})
+		case errors.Is(err, entities.ErrInvalidProduct):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get variants",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	responses := make([]*ProductResponse, len(variants))
+	for i, variant := range variants {
+		responses[i] = h.entityToResponse(variant, "")
+	}
+
+	c.JSON(http.StatusOK, ProductListResponse{
+		Products: responses,
+		Count:    len(responses),
+	})
+}
+
+// GetProductMovements handles GET /api/v1/product/:id/movements
+// @Summary List stock movements for a product
+// @Description Retrieves the inventory ledger of a product, newest first
+// @Tags Products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param reason query string false "Filter by reason (sale, cancellation, return, adjustment, stocktake, damage, receipt, transfer)"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} MovementListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/movements [get]
+func (h *ProductHandler) GetProductMovements(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	reason := entities.MovementReason(c.Query("reason"))
+
+	movements, err := h.productUseCase.GetProductMovements(c.Request.Context(), c.Param("id"), reason, limit, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Product not found",
+			})
+		case errors.Is(err, entities.ErrInvalidMovement):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid movement reason",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get inventory movements",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, MovementListResponse{
+		Movements: movements,
+		Count:     len(movements),
+	})
+}
+
+// GetProductBatches handles GET /api/v1/product/:id/batches
+// @Summary List cost batches for a product
+// @Description Retrieves the batches a product's stock came in as, with what is left of each, newest first
+// @Tags Products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} BatchListResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/batches [get]
+func (h *ProductHandler) GetProductBatches(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	batches, err := h.productUseCase.GetProductBatches(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Product not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get inventory batches",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, BatchListResponse{
+		Batches: batches,
+		Count:   len(batches),
+	})
+}
+
+// GetProductStock handles GET /api/v1/product/:id/stock
+// @Summary Stock of a product per location
+// @Description Retrieves how much of a product each location holds
+// @Tags Products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} StockLevelListResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/stock [get]
+func (h *ProductHandler) GetProductStock(c *gin.Context) {
+	levels, err := h.productUseCase.GetProductStock(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Product not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get product stock",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, StockLevelListResponse{
+		Levels: levels,
+		Count:  len(levels),
+	})
+}
+
+// TransferStock handles POST /api/v1/product/:id/transfers
+// @Summary Transfer stock between locations
+// @Description Moves stock of a product from one location to another; the product's total is unchanged
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param transfer body usecases.TransferStockRequest true "Source and destination locations and quantity"
+// @Success 201 {object} MovementListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/product/{id}/transfers [post]
+func (h *ProductHandler) TransferStock(c *gin.Context) {
+	var req usecases.TransferStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	movements, err := h.productUseCase.TransferStock(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		var stockErr *usecases.InsufficientStockError
+		switch {
+		case errors.As(err, &stockErr):
+			writeInsufficientStock(c, stockErr)
+		case errors.Is(err, repositories.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product or location not found",
+				"details": err.Error(),
+			})
+		case errors.Is(err, entities.ErrInvalidMovement):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to transfer stock",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, MovementListResponse{
+		Movements: movements,
+		Count:     len(movements),
+	})
+}
+
+// GetLowStockProducts handles GET /api/v1/products/low-stock
+// @Summary List products running low
+// @Description Retrieves products with less stock than the threshold, at one location or across all locations
+// @Tags Products
+// @Produce json
+// @Param threshold query int false "Stock level below which a product is low" default(5)
+// @Param location_id query string false "Only stock held at this location"
+// @Success 200 {object} StockLevelListResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/products/low-stock [get]
+func (h *ProductHandler) GetLowStockProducts(c *gin.Context) {
+	threshold, _ := strconv.Atoi(c.DefaultQuery("threshold", "5"))
+
+	levels, err := h.productUseCase.GetLowStockProducts(c.Request.Context(), threshold, c.Query("location_id"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Location not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get low stock products",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, StockLevelListResponse{
+		Levels: levels,
+		Count:  len(levels),
+	})
+}
+
+// GetInventoryValue handles GET /api/v1/products/inventory-value
+// @Summary Value of stock on hand
+// @Description Values stock at selling price (retail), or at cost by FIFO or weighted average, at one location or across all locations
+// @Tags Products
+// @Produce json
+// @Param location_id query string false "Only stock held at this location"
+// @Param method query string false "Valuation method (retail, fifo, weighted_average)" default(retail)
+// @Success 200 {object} InventoryValueResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/products/inventory-value [get]
+func (h *ProductHandler) GetInventoryValue(c *gin.Context) {
+	locationID := c.Query("location_id")
+	method := entities.ValuationMethod(c.Query("method"))
+
+	valuation, err := h.productUseCase.GetInventoryValuation(c.Request.Context(), method, locationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Location not found",
+			})
+		case errors.Is(err, entities.ErrInvalidValuationMethod):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid valuation method",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to get inventory value",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, InventoryValueResponse{
+		Method:     valuation.Method,
+		LocationID: valuation.LocationID,
+		Value:      valuation.Value,
+		Products:   valuation.Products,
+	})
+}
+
 // SearchProducts handles GET /api/v1/products/search
 // @Summary Search products by name
 // @Description Searches for products containing the specified name
@@ -282,13 +805,53 @@ func (h *ProductHandler) GetAvailableProducts(c *gin.Context) {
 
 // Helper method to convert domain entity to HTTP response
 func (h *ProductHandler) entityToResponse(product *entities.Product, message string) *ProductResponse {
+	quantity, reserved, available := product.Stock()
+	var variants []*ProductResponse
+	for _, variant := range product.Variants {
+		variants = append(variants, h.entityToResponse(variant, ""))
+	}
+
 	return &ProductResponse{
 		ID:          product.ID,
 		ProductName: product.ProductName,
 		Price:       product.Price,
-		Quantity:    product.Quantity,
-		CreatedAt:   product.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:   product.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		Message:     message,
+		Quantity:    quantity,
+		Reserved:    reserved,
+		Available:   available,
+		AverageCost: product.AverageCost,
+		Version:     product.Version,
+
+		ReorderPoint:    product.ReorderPoint,
+		ReorderQuantity: product.ReorderQuantity,
+		LeadTimeDays:    product.LeadTimeDays,
+		SupplierID:      product.SupplierID,
+
+		SKU:         product.SKU,
+		Barcode:     product.Barcode,
+		Description: product.Description,
+		CategoryID:  product.CategoryID,
+		Attributes:  product.Attributes,
+		Status:      product.Status,
+		TaxClass:    product.TaxClass,
+
+		Type:          product.Type,
+		ParentID:      product.ParentID,
+		PriceOverride: product.PriceOverride,
+		Variants:      variants,
+		Components:    product.Components,
+
+		CreatedAt: product.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt: product.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		Message:   message,
 	}
 }
+
+// writeInsufficientStock reports a stock change that would take a location or product below zero
+func writeInsufficientStock(c *gin.Context, stockErr *usecases.InsufficientStockError) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":              "Insufficient product quantity",
+		"product_id":         stockErr.ProductID,
+		"available_quantity": stockErr.Available,
+		"requested_quantity": stockErr.Requested,
+	})
+}
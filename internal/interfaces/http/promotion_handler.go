@@ -0,0 +1,248 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromotionHandler handles HTTP requests for promotions, coupons and their analytics
+type PromotionHandler struct {
+	promotionUseCase *usecases.PromotionUseCase
+}
+
+// NewPromotionHandler creates a new promotion handler with dependency injection
+func NewPromotionHandler(promotionUseCase *usecases.PromotionUseCase) *PromotionHandler {
+	return &PromotionHandler{
+		promotionUseCase: promotionUseCase,
+	}
+}
+
+// PromotionListResponse represents the response for listing promotions
+type PromotionListResponse struct {
+	Promotions []*entities.Promotion `json:"promotions"`
+	Count      int                   `json:"count"`
+}
+
+// CouponListResponse represents the response for listing a promotion's coupons
+type CouponListResponse struct {
+	PromotionID string             `json:"promotion_id"`
+	Coupons     []*entities.Coupon `json:"coupons"`
+	Count       int                `json:"count"`
+}
+
+// PromotionStatsResponse represents the response for promotion analytics
+type PromotionStatsResponse struct {
+	Promotions []*entities.PromotionStats `json:"promotions"`
+	Count      int                        `json:"count"`
+}
+
+// CreatePromotion handles POST /api/v1/promotion
+// @Summary Add a promotion
+// @Description Takes a percentage or a fixed amount off, or gives units free, on a product, a category or everything, automatically or with a coupon
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param promotion body usecases.CreatePromotionRequest true "What the promotion covers and takes off"
+// @Success 201 {object} entities.Promotion
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/promotion [post]
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req usecases.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	promotion, err := h.promotionUseCase.CreatePromotion(c.Request.Context(), &req)
+	if err != nil {
+		writePromotionError(c, err, "Failed to create promotion")
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// GetPromotion handles GET /api/v1/promotion/:id
+// @Summary Get a promotion
+// @Description Retrieves a promotion by ID
+// @Tags Promotions
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} entities.Promotion
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/promotion/{id} [get]
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	promotion, err := h.promotionUseCase.GetPromotion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePromotionError(c, err, "Failed to get promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// GetPromotions handles GET /api/v1/promotions
+// @Summary List promotions
+// @Description Retrieves every promotion, oldest first
+// @Tags Promotions
+// @Produce json
+// @Success 200 {object} PromotionListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/promotions [get]
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	promotions, err := h.promotionUseCase.GetPromotions(c.Request.Context())
+	if err != nil {
+		writePromotionError(c, err, "Failed to get promotions")
+		return
+	}
+
+	c.JSON(http.StatusOK, PromotionListResponse{
+		Promotions: promotions,
+		Count:      len(promotions),
+	})
+}
+
+// EndPromotion handles POST /api/v1/promotion/:id/end
+// @Summary End a promotion
+// @Description Stops a promotion and its coupons applying from now on; orders it discounted keep their discounts
+// @Tags Promotions
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} entities.Promotion
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/promotion/{id}/end [post]
+func (h *PromotionHandler) EndPromotion(c *gin.Context) {
+	promotion, err := h.promotionUseCase.EndPromotion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePromotionError(c, err, "Failed to end promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// CreateCoupon handles POST /api/v1/promotion/:id/coupons
+// @Summary Issue a coupon code
+// @Description Adds a single-use or multi-use code for a coupon-only promotion, with optional per-customer limit and expiry
+// @Tags Promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Param coupon body usecases.CreateCouponRequest true "Code, limits and expiry"
+// @Success 201 {object} entities.Coupon
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/promotion/{id}/coupons [post]
+func (h *PromotionHandler) CreateCoupon(c *gin.Context) {
+	var req usecases.CreateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	coupon, err := h.promotionUseCase.CreateCoupon(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writePromotionError(c, err, "Failed to create coupon")
+		return
+	}
+
+	c.JSON(http.StatusCreated, coupon)
+}
+
+// GetCoupons handles GET /api/v1/promotion/:id/coupons
+// @Summary List a promotion's coupons
+// @Description Retrieves a promotion's coupon codes with how often each has been used, oldest first
+// @Tags Promotions
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} CouponListResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/promotion/{id}/coupons [get]
+func (h *PromotionHandler) GetCoupons(c *gin.Context) {
+	coupons, err := h.promotionUseCase.GetCoupons(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePromotionError(c, err, "Failed to get coupons")
+		return
+	}
+
+	c.JSON(http.StatusOK, CouponListResponse{
+		PromotionID: c.Param("id"),
+		Coupons:     coupons,
+		Count:       len(coupons),
+	})
+}
+
+// GetPromotionStats handles GET /api/v1/transactions/stats/promotions
+// @Summary Get promotion statistics
+// @Description Reports the redemptions, customers and discount cost of every promotion redeemed in the period, the costliest first; cancelled orders do not count
+// @Tags Transactions
+// @Produce json
+// @Param period query string false "Statistics period (today, this_week, this_month, all_time)" default("all_time")
+// @Success 200 {object} PromotionStatsResponse
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/transactions/stats/promotions [get]
+func (h *PromotionHandler) GetPromotionStats(c *gin.Context) {
+	period, ok := statsPeriod(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.promotionUseCase.GetPromotionStats(c.Request.Context(), period)
+	if err != nil {
+		writePromotionError(c, err, "Failed to retrieve promotion statistics")
+		return
+	}
+
+	c.JSON(http.StatusOK, PromotionStatsResponse{
+		Promotions: stats,
+		Count:      len(stats),
+	})
+}
+
+// writePromotionError maps promotion use case errors to HTTP responses
+func writePromotionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, repositories.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Coupon code already taken",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrInvalidPromotion),
+		errors.Is(err, entities.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurchaseOrderHandler handles HTTP requests for purchase orders and goods receipts
+type PurchaseOrderHandler struct {
+	purchaseOrderUseCase *usecases.PurchaseOrderUseCase
+}
+
+// NewPurchaseOrderHandler creates a new purchase order handler with dependency injection
+func NewPurchaseOrderHandler(purchaseOrderUseCase *usecases.PurchaseOrderUseCase) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		purchaseOrderUseCase: purchaseOrderUseCase,
+	}
+}
+
+// PurchaseOrderListResponse represents the response for listing purchase orders
+type PurchaseOrderListResponse struct {
+	PurchaseOrders []*entities.PurchaseOrder `json:"purchase_orders"`
+	Count          int                       `json:"count"`
+}
+
+// ReceiptListResponse represents the response for listing goods receipts
+type ReceiptListResponse struct {
+	Receipts []*entities.GoodsReceipt `json:"receipts"`
+	Count    int                      `json:"count"`
+}
+
+// CreatePurchaseOrder handles POST /api/v1/purchase-order
+// @Summary Draft a purchase order
+// @Description Creates a draft purchase order with a supplier; it can be edited until it is sent
+// @Tags Purchase Orders
+// @Accept json
+// @Produce json
+// @Param purchase_order body usecases.CreatePurchaseOrderRequest true "Supplier, delivery location and lines"
+// @Success 201 {object} entities.PurchaseOrder
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-order [post]
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req usecases.CreatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	purchaseOrder, err := h.purchaseOrderUseCase.CreatePurchaseOrder(c.Request.Context(), &req)
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to create purchase order")
+		return
+	}
+
+	c.JSON(http.StatusCreated, purchaseOrder)
+}
+
+// GetPurchaseOrder handles GET /api/v1/purchase-order/:id
+// @Summary Get a purchase order
+// @Description Retrieves a purchase order with its lines and quantities received
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Success 200 {object} entities.PurchaseOrder
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-order/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c *gin.Context) {
+	purchaseOrder, err := h.purchaseOrderUseCase.GetPurchaseOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to get purchase order")
+		return
+	}
+
+	c.JSON(http.StatusOK, purchaseOrder)
+}
+
+// GetPurchaseOrders handles GET /api/v1/purchase-orders
+// @Summary List purchase orders
+// @Description Retrieves purchase orders, newest first
+// @Tags Purchase Orders
+// @Produce json
+// @Param status query string false "Filter by status (draft, sent, partially_received, received)"
+// @Param supplier_id query string false "Filter by supplier"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} PurchaseOrderListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-orders [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	purchaseOrders, err := h.purchaseOrderUseCase.GetPurchaseOrders(c.Request.Context(), repositories.PurchaseOrderFilter{
+		SupplierID: c.Query("supplier_id"),
+		Status:     entities.PurchaseOrderStatus(c.Query("status")),
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to get purchase orders")
+		return
+	}
+
+	c.JSON(http.StatusOK, PurchaseOrderListResponse{
+		PurchaseOrders: purchaseOrders,
+		Count:          len(purchaseOrders),
+	})
+}
+
+// UpdatePurchaseOrder handles PUT /api/v1/purchase-order/:id
+// @Summary Edit a draft purchase order
+// @Description Replaces the lines or note of a purchase order that has not been sent
+// @Tags Purchase Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Param purchase_order body usecases.UpdatePurchaseOrderRequest true "New lines or note"
+// @Success 200 {object} entities.PurchaseOrder
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-order/{id} [put]
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(c *gin.Context) {
+	var req usecases.UpdatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	purchaseOrder, err := h.purchaseOrderUseCase.UpdatePurchaseOrder(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to update purchase order")
+		return
+	}
+
+	c.JSON(http.StatusOK, purchaseOrder)
+}
+
+// SendPurchaseOrder handles POST /api/v1/purchase-order/:id/send
+// @Summary Send a purchase order
+// @Description Marks a draft as sent to the supplier and works out when the goods are expected
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Success 200 {object} entities.PurchaseOrder
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-order/{id}/send [post]
+func (h *PurchaseOrderHandler) SendPurchaseOrder(c *gin.Context) {
+	purchaseOrder, err := h.purchaseOrderUseCase.SendPurchaseOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to send purchase order")
+		return
+	}
+
+	c.JSON(http.StatusOK, purchaseOrder)
+}
+
+// ReceivePurchaseOrder handles POST /api/v1/purchase-order/:id/receive
+// @Summary Receive goods
+// @Description Books a delivery against a sent purchase order and adds the goods to stock
+// @Tags Purchase Orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Param receipt body usecases.ReceivePurchaseOrderRequest false "Quantities received and additional cost; empty receives everything outstanding"
+// @Success 201 {object} entities.GoodsReceipt
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-order/{id}/receive [post]
+func (h *PurchaseOrderHandler) ReceivePurchaseOrder(c *gin.Context) {
+	var req usecases.ReceivePurchaseOrderRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	receipt, err := h.purchaseOrderUseCase.ReceivePurchaseOrder(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to receive goods")
+		return
+	}
+
+	c.JSON(http.StatusCreated, receipt)
+}
+
+// GetReceipts handles GET /api/v1/purchase-order/:id/receipts
+// @Summary List goods receipts
+// @Description Retrieves the deliveries booked against a purchase order, oldest first
+// @Tags Purchase Orders
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Success 200 {object} ReceiptListResponse
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-order/{id}/receipts [get]
+func (h *PurchaseOrderHandler) GetReceipts(c *gin.Context) {
+	receipts, err := h.purchaseOrderUseCase.GetReceipts(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to get receipts")
+		return
+	}
+
+	c.JSON(http.StatusOK, ReceiptListResponse{
+		Receipts: receipts,
+		Count:    len(receipts),
+	})
+}
+
+// SuggestReorders handles POST /api/v1/purchase-orders/reorder
+// @Summary Draft reorders
+// @Description Drafts a purchase order per supplier for products at or below their reorder point
+// @Tags Purchase Orders
+// @Produce json
+// @Success 201 {object} usecases.ReorderSuggestion
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/purchase-orders/reorder [post]
+func (h *PurchaseOrderHandler) SuggestReorders(c *gin.Context) {
+	suggestion, err := h.purchaseOrderUseCase.SuggestReorders(c.Request.Context())
+	if err != nil {
+		writePurchaseOrderError(c, err, "Failed to suggest reorders")
+		return
+	}
+
+	c.JSON(http.StatusCreated, suggestion)
+}
+
+// writePurchaseOrderError maps a failed purchase order operation to an HTTP response
+func writePurchaseOrderError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Purchase order, supplier, product or location not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrInvalidPurchaseOrder), errors.Is(err, entities.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrPurchaseOrderStatus):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Purchase order status does not allow this",
+			"details": err.Error(),
+		})
+	case errors.Is(err, repositories.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Purchase order was modified by someone else",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReservationHandler handles HTTP requests for stock reservations
+type ReservationHandler struct {
+	reservationUseCase *usecases.ReservationUseCase
+}
+
+// NewReservationHandler creates a new reservation handler with dependency injection
+func NewReservationHandler(reservationUseCase *usecases.ReservationUseCase) *ReservationHandler {
+	return &ReservationHandler{
+		reservationUseCase: reservationUseCase,
+	}
+}
+
+// ReservationListResponse represents the response for listing reservations
+type ReservationListResponse struct {
+	Reservations []*entities.StockReservation `json:"reservations"`
+	Count        int                          `json:"count"`
+}
+
+// CreateReservation handles POST /api/v1/product/:id/reservations
+// @Summary Reserve stock
+// @Description Holds stock of a product until the hold is confirmed into an order, released or expires
+// @Tags Reservations
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param reservation body usecases.CreateReservationRequest true "Quantity, optional customer and expiry"
+// @Success 201 {object} entities.StockReservation
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/product/{id}/reservations [post]
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	var req usecases.CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	reservation, err := h.reservationUseCase.CreateReservation(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writeReservationError(c, err, "Failed to reserve stock")
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// GetProductReservations handles GET /api/v1/product/:id/reservations
+// @Summary List reservations for a product
+// @Description Retrieves the reservations on a product, newest first
+// @Tags Reservations
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param status query string false "Filter by status (active, confirmed, released, expired)"
+// @Success 200 {object} ReservationListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/product/{id}/reservations [get]
+func (h *ReservationHandler) GetProductReservations(c *gin.Context) {
+	status := entities.ReservationStatus(c.Query("status"))
+	reservations, err := h.reservationUseCase.GetProductReservations(c.Request.Context(), c.Param("id"), status)
+	if err != nil {
+		writeReservationError(c, err, "Failed to get reservations")
+		return
+	}
+
+	c.JSON(http.StatusOK, ReservationListResponse{
+		Reservations: reservations,
+		Count:        len(reservations),
+	})
+}
+
+// GetReservation handles GET /api/v1/reservation/:id
+// @Summary Get a reservation
+// @Description Retrieves a reservation by ID
+// @Tags Reservations
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} entities.StockReservation
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/reservation/{id} [get]
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	reservation, err := h.reservationUseCase.GetReservation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReservationError(c, err, "Failed to get reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// ReleaseReservation handles POST /api/v1/reservation/:id/release
+// @Summary Release a reservation
+// @Description Ends a hold early and makes its stock available again
+// @Tags Reservations
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} entities.StockReservation
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/reservation/{id}/release [post]
+func (h *ReservationHandler) ReleaseReservation(c *gin.Context) {
+	reservation, err := h.reservationUseCase.ReleaseReservation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReservationError(c, err, "Failed to release reservation")
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// writeReservationError maps a failed reservation operation to an HTTP response
+func writeReservationError(c *gin.Context, err error, message string) {
+	var stockErr *usecases.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		writeInsufficientStock(c, stockErr)
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Product, customer or reservation not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrInvalidReservation):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrProductHasVariants):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Product is sold by variant",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrProductArchived):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Product is archived",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrReservationNotActive):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Reservation is no longer active",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
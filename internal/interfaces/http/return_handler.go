@@ -0,0 +1,247 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnHandler handles HTTP requests for returns and refunds
+type ReturnHandler struct {
+	returnUseCase *usecases.ReturnUseCase
+}
+
+// NewReturnHandler creates a new return handler with dependency injection
+func NewReturnHandler(returnUseCase *usecases.ReturnUseCase) *ReturnHandler {
+	return &ReturnHandler{
+		returnUseCase: returnUseCase,
+	}
+}
+
+// ReturnListResponse represents the response for listing returns
+type ReturnListResponse struct {
+	Returns []*entities.OrderReturn `json:"returns"`
+	Count   int                     `json:"count"`
+	Message string                  `json:"message,omitempty"`
+}
+
+// RequestReturn handles POST /api/v1/order/:id/returns
+// @Summary Request a return
+// @Description Opens a return for part or all of an order's quantity
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param return body usecases.CreateReturnRequest true "Quantity and reason"
+// @Success 201 {object} entities.OrderReturn
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/order/{id}/returns [post]
+func (h *ReturnHandler) RequestReturn(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var req usecases.CreateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	orderReturn, err := h.returnUseCase.RequestReturn(c.Request.Context(), orderID, &req)
+	if err != nil {
+		writeReturnError(c, err, "Failed to request return")
+		return
+	}
+
+	c.JSON(http.StatusCreated, orderReturn)
+}
+
+// ApproveReturn handles POST /api/v1/return/:id/approve
+// @Summary Approve a return
+// @Description Approves a return, restocks or writes off the goods and posts a refund transaction
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param resolution body usecases.ResolveReturnRequest true "Disposition (restock or write_off), actor and note"
+// @Success 200 {object} entities.OrderReturn
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/return/{id}/approve [post]
+func (h *ReturnHandler) ApproveReturn(c *gin.Context) {
+	var req usecases.ResolveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !req.Disposition.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Disposition must be restock or write_off",
+		})
+		return
+	}
+
+	orderReturn, err := h.returnUseCase.ApproveReturn(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writeReturnError(c, err, "Failed to approve return")
+		return
+	}
+
+	c.JSON(http.StatusOK, orderReturn)
+}
+
+// RejectReturn handles POST /api/v1/return/:id/reject
+// @Summary Reject a return
+// @Description Rejects a return; stock and the ledger are not changed
+// @Tags Returns
+// @Accept json
+// @Produce json
+// @Param id path string true "Return ID"
+// @Param resolution body usecases.ResolveReturnRequest false "Actor and note"
+// @Success 200 {object} entities.OrderReturn
+// @Failure 404 {object} map[string]any
+// @Failure 409 {object} map[string]any
+// @Router /api/v1/return/{id}/reject [post]
+func (h *ReturnHandler) RejectReturn(c *gin.Context) {
+	var req usecases.ResolveReturnRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	orderReturn, err := h.returnUseCase.RejectReturn(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writeReturnError(c, err, "Failed to reject return")
+		return
+	}
+
+	c.JSON(http.StatusOK, orderReturn)
+}
+
+// GetReturn handles GET /api/v1/return/:id
+// @Summary Get a return
+// @Description Retrieves a return by ID
+// @Tags Returns
+// @Produce json
+// @Param id path string true "Return ID"
+// @Success 200 {object} entities.OrderReturn
+// @Failure 404 {object} map[string]any
+// @Router /api/v1/return/{id} [get]
+func (h *ReturnHandler) GetReturn(c *gin.Context) {
+	orderReturn, err := h.returnUseCase.GetReturn(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeReturnError(c, err, "Failed to get return")
+		return
+	}
+
+	c.JSON(http.StatusOK, orderReturn)
+}
+
+// GetOrderReturns handles GET /api/v1/order/:id/returns
+// @Summary List returns for an order
+// @Description Retrieves every return raised against an order
+// @Tags Returns
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} ReturnListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/order/{id}/returns [get]
+func (h *ReturnHandler) GetOrderReturns(c *gin.Context) {
+	h.listReturns(c, c.Param("id"))
+}
+
+// GetReturns handles GET /api/v1/returns
+// @Summary List returns
+// @Description Retrieves returns, optionally filtered by status (retailer view)
+// @Tags Returns
+// @Produce json
+// @Param status query string false "Filter by status (requested, approved, rejected)"
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} ReturnListResponse
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/returns [get]
+func (h *ReturnHandler) GetReturns(c *gin.Context) {
+	h.listReturns(c, "")
+}
+
+// listReturns reads the status and pagination query parameters and writes the list
+func (h *ReturnHandler) listReturns(c *gin.Context, orderID string) {
+	status := entities.ReturnStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid return status: " + string(status),
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	returns, err := h.returnUseCase.GetReturns(c.Request.Context(), orderID, status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get returns",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReturnListResponse{
+		Returns: returns,
+		Count:   len(returns),
+	})
+}
+
+// writeReturnError maps a failed return operation to an HTTP response
+func writeReturnError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Order or return not found",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrReturnQuantityExceeded):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Return quantity exceeds what is left on the order",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrOrderLineNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Return must name a product on the order",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrOrderNotReturnable),
+		errors.Is(err, entities.ErrReturnAlreadyResolved),
+		errors.Is(err, repositories.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Return cannot be processed in its current state",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
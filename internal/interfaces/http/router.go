@@ -48,26 +48,104 @@ func (r *Router) setupAPIRoutes(router *gin.Engine) {
 	customerHandler := NewCustomerHandler(r.container.GetCustomerUseCase())
 	orderHandler := NewOrderHandler(r.container.GetOrderUseCase())
 	transactionHandler := NewTransactionHandler(r.container.GetTransactionUseCase())
+	returnHandler := NewReturnHandler(r.container.GetReturnUseCase())
+	walletHandler := NewWalletHandler(r.container.GetWalletUseCase())
+	cartHandler := NewCartHandler(r.container.GetCartUseCase())
+	exchangeRateHandler := NewExchangeRateHandler(r.container.GetExchangeRateUseCase())
+	reservationHandler := NewReservationHandler(r.container.GetReservationUseCase())
+	locationHandler := NewLocationHandler(r.container.GetLocationUseCase())
+	supplierHandler := NewSupplierHandler(r.container.GetSupplierUseCase())
+	purchaseOrderHandler := NewPurchaseOrderHandler(r.container.GetPurchaseOrderUseCase())
+	categoryHandler := NewCategoryHandler(r.container.GetCategoryUseCase())
+	pricingHandler := NewPricingHandler(r.container.GetPricingUseCase())
+	promotionHandler := NewPromotionHandler(r.container.GetPromotionUseCase())
+	taxHandler := NewTaxHandler(r.container.GetTaxUseCase())
+	invoiceHandler := NewInvoiceHandler(r.container.GetInvoiceUseCase())
+
+	// Replays responses to retried POSTs that carry an Idempotency-Key
+	idempotent := Idempotency(r.container.GetIdempotencyUseCase())
 
 	// === PRODUCT ROUTES (For Retailer) ===
 	productRoutes := api.Group("/product")
 	{
-		productRoutes.POST("", productHandler.CreateProduct)    // Create product
-		productRoutes.GET("/:id", productHandler.GetProduct)    // Get single product
-		productRoutes.PUT("/:id", productHandler.UpdateProduct) // Update product
+		productRoutes.POST("", idempotent, productHandler.CreateProduct)                             // Create product
+		productRoutes.GET("/:id", productHandler.GetProduct)                                         // Get single product
+		productRoutes.PUT("/:id", productHandler.UpdateProduct)                                      // Update product
+		productRoutes.POST("/:id/variants", idempotent, productHandler.CreateVariant)                // Add variant
+		productRoutes.GET("/:id/variants", productHandler.GetVariants)                               // Variants of a parent
+		productRoutes.POST("/:id/reservations", reservationHandler.CreateReservation)                // Hold stock
+		productRoutes.GET("/:id/reservations", reservationHandler.GetProductReservations)            // Holds on a product
+		productRoutes.GET("/:id/movements", productHandler.GetProductMovements)                      // Inventory ledger
+		productRoutes.GET("/:id/batches", productHandler.GetProductBatches)                          // Cost batches
+		productRoutes.GET("/:id/stock", productHandler.GetProductStock)                              // Stock per location
+		productRoutes.POST("/:id/transfers", productHandler.TransferStock)                           // Move stock between locations
+		productRoutes.GET("/:id/price", pricingHandler.QuotePrice)                                   // Price for a customer and quantity
+		productRoutes.GET("/:id/price-history", pricingHandler.GetPriceHistory)                      // List prices over time, with sales
+		productRoutes.POST("/:id/scheduled-prices", pricingHandler.SchedulePrice)                    // Change the price at a future time
+		productRoutes.DELETE("/:id/scheduled-prices/:price_id", pricingHandler.CancelScheduledPrice) // Drop a pending price change
 	}
 
 	// Products collection routes
-	api.GET("/products", productHandler.GetProducts)                    // List all products
-	api.GET("/products/search", productHandler.SearchProducts)          // Search products
-	api.GET("/products/available", productHandler.GetAvailableProducts) // Available products
+	api.GET("/products", productHandler.GetProducts)                       // Catalogue, by category and attributes
+	api.GET("/products/search", productHandler.SearchProducts)             // Search products
+	api.GET("/products/available", productHandler.GetAvailableProducts)    // Available products
+	api.GET("/products/low-stock", productHandler.GetLowStockProducts)     // Running low, per location or overall
+	api.GET("/products/inventory-value", productHandler.GetInventoryValue) // Stock value, per location or overall
+
+	// === CATEGORY ROUTES (Catalogue tree) ===
+	categoryRoutes := api.Group("/category")
+	{
+		categoryRoutes.POST("", categoryHandler.CreateCategory)    // Add category
+		categoryRoutes.GET("/:id", categoryHandler.GetCategory)    // Get single category
+		categoryRoutes.PUT("/:id", categoryHandler.UpdateCategory) // Rename or move
+	}
+	api.GET("/categories", categoryHandler.GetCategories) // List the tree
+
+	// === LOCATION ROUTES (Stores and warehouses) ===
+	locationRoutes := api.Group("/location")
+	{
+		locationRoutes.POST("", locationHandler.CreateLocation)            // Add location
+		locationRoutes.GET("/:id", locationHandler.GetLocation)            // Get single location
+		locationRoutes.GET("/:id/stock", locationHandler.GetLocationStock) // Stock held there
+	}
+	api.GET("/locations", locationHandler.GetLocations) // List locations
+
+	// === SUPPLIER ROUTES (Inbound stock) ===
+	supplierRoutes := api.Group("/supplier")
+	{
+		supplierRoutes.POST("", supplierHandler.CreateSupplier) // Add supplier
+		supplierRoutes.GET("/:id", supplierHandler.GetSupplier) // Get single supplier
+	}
+	api.GET("/suppliers", supplierHandler.GetSuppliers) // List suppliers
+
+	// === PURCHASE ORDER ROUTES ===
+	purchaseOrderRoutes := api.Group("/purchase-order")
+	{
+		purchaseOrderRoutes.POST("", idempotent, purchaseOrderHandler.CreatePurchaseOrder)  // Draft purchase order
+		purchaseOrderRoutes.GET("/:id", purchaseOrderHandler.GetPurchaseOrder)              // Get single purchase order
+		purchaseOrderRoutes.PUT("/:id", purchaseOrderHandler.UpdatePurchaseOrder)           // Edit draft
+		purchaseOrderRoutes.POST("/:id/send", purchaseOrderHandler.SendPurchaseOrder)       // Send to supplier
+		purchaseOrderRoutes.POST("/:id/receive", purchaseOrderHandler.ReceivePurchaseOrder) // Book delivery, add stock
+		purchaseOrderRoutes.GET("/:id/receipts", purchaseOrderHandler.GetReceipts)          // Deliveries booked
+	}
+	api.GET("/purchase-orders", purchaseOrderHandler.GetPurchaseOrders)        // List purchase orders
+	api.POST("/purchase-orders/reorder", purchaseOrderHandler.SuggestReorders) // Draft reorders for low stock
 
 	// === CUSTOMER ROUTES ===
 	customerRoutes := api.Group("/customer")
 	{
-		customerRoutes.POST("", customerHandler.CreateCustomer)                // Register customer
-		customerRoutes.GET("/:id", customerHandler.GetCustomer)                // Get single customer
-		customerRoutes.GET("/:id/cooldown", customerHandler.GetCooldownStatus) // Cooldown status
+		customerRoutes.POST("", idempotent, customerHandler.CreateCustomer)          // Register customer
+		customerRoutes.GET("/:id", customerHandler.GetCustomer)                      // Get single customer
+		customerRoutes.PUT("/:id", customerHandler.UpdateCustomer)                   // Update customer
+		customerRoutes.GET("/:id/cooldown", customerHandler.GetCooldownStatus)       // Cooldown status
+		customerRoutes.GET("/:id/wallet", walletHandler.GetWallet)                   // Store credit statement
+		customerRoutes.POST("/:id/wallet/credit", walletHandler.IssueCredit)         // Issue store credit
+		customerRoutes.GET("/:id/cart", cartHandler.GetCart)                         // View cart
+		customerRoutes.DELETE("/:id/cart", cartHandler.ClearCart)                    // Empty cart
+		customerRoutes.POST("/:id/cart/items", cartHandler.AddItem)                  // Add to cart
+		customerRoutes.PUT("/:id/cart/items/:product_id", cartHandler.UpdateItem)    // Change quantity
+		customerRoutes.DELETE("/:id/cart/items/:product_id", cartHandler.RemoveItem) // Remove from cart
+		customerRoutes.POST("/:id/cart/checkout", idempotent, cartHandler.Checkout)  // Place order from cart
 	}
 
 	// Customers collection routes
@@ -77,8 +155,14 @@ func (r *Router) setupAPIRoutes(router *gin.Engine) {
 	// === ORDER ROUTES ===
 	orderRoutes := api.Group("/order")
 	{
-		orderRoutes.POST("", orderHandler.PlaceOrder)  // Place order
-		orderRoutes.GET("/:id", orderHandler.GetOrder) // Get single order
+		orderRoutes.POST("", idempotent, orderHandler.PlaceOrder)      // Place order
+		orderRoutes.GET("/:id", orderHandler.GetOrder)                 // Get single order
+		orderRoutes.POST("/:id/confirm", orderHandler.ConfirmOrder)    // Confirm pending order
+		orderRoutes.POST("/:id/complete", orderHandler.CompleteOrder)  // Complete confirmed order
+		orderRoutes.POST("/:id/cancel", orderHandler.CancelOrder)      // Cancel, restock and refund
+		orderRoutes.POST("/:id/returns", returnHandler.RequestReturn)  // Request a return
+		orderRoutes.GET("/:id/returns", returnHandler.GetOrderReturns) // Returns for an order
+		orderRoutes.GET("/:id/invoice", invoiceHandler.GetInvoice)     // Invoice as JSON or PDF
 	}
 
 	// Orders collection routes
@@ -89,15 +173,65 @@ func (r *Router) setupAPIRoutes(router *gin.Engine) {
 		ordersRoutes.GET("/customer/:customer_id", orderHandler.GetOrderHistory) // Customer order history
 	}
 
+	// === RETURN ROUTES (RMA) ===
+	returnRoutes := api.Group("/return")
+	{
+		returnRoutes.GET("/:id", returnHandler.GetReturn)              // Get single return
+		returnRoutes.POST("/:id/approve", returnHandler.ApproveReturn) // Approve, restock/write off and refund
+		returnRoutes.POST("/:id/reject", returnHandler.RejectReturn)   // Reject return
+	}
+
+	// Returns collection routes
+	api.GET("/returns", returnHandler.GetReturns) // List returns (retailer)
+
+	// === RESERVATION ROUTES ===
+	reservationRoutes := api.Group("/reservation")
+	{
+		reservationRoutes.GET("/:id", reservationHandler.GetReservation)              // Get single reservation
+		reservationRoutes.POST("/:id/release", reservationHandler.ReleaseReservation) // Release held stock
+	}
+
 	// === TRANSACTION ROUTES (For Retailer Business Analytics) ===
 	transactionRoutes := api.Group("/transactions")
 	{
 		transactionRoutes.GET("", transactionHandler.GetTransactionHistory)                                       // Transaction history
 		transactionRoutes.GET("/stats", transactionHandler.GetTransactionStats)                                   // Business stats
 		transactionRoutes.GET("/stats/comprehensive", transactionHandler.GetComprehensiveStats)                   // All periods
+		transactionRoutes.GET("/stats/categories", transactionHandler.GetCategoryStats)                           // Top categories
+		transactionRoutes.GET("/stats/promotions", promotionHandler.GetPromotionStats)                            // Discount cost per promotion
 		transactionRoutes.GET("/customer/:customer_id/summary", transactionHandler.GetCustomerTransactionSummary) // Customer summary
 		transactionRoutes.GET("/revenue/analytics", transactionHandler.GetRevenueAnalytics)                       // Revenue analytics
+		transactionRoutes.GET("/tax-liability", transactionHandler.GetTaxLiability)                               // Tax due per jurisdiction and rate
+	}
+
+	// === EXCHANGE RATE ROUTES (For Retailer) ===
+	api.POST("/exchange-rate", exchangeRateHandler.CreateRate)                // Publish rate
+	api.GET("/exchange-rate/:currency", exchangeRateHandler.GetEffectiveRate) // Rate in effect
+	api.GET("/exchange-rates", exchangeRateHandler.GetRates)                  // Rate history
+
+	// === TAX ROUTES (For Retailer) ===
+	api.POST("/tax-rate", taxHandler.CreateRate) // Publish rate
+	api.GET("/tax-rates", taxHandler.GetRates)   // Rate history
+
+	// === PRICING ROUTES (For Retailer) ===
+	priceRuleRoutes := api.Group("/price-rule")
+	{
+		priceRuleRoutes.POST("", pricingHandler.CreatePriceRule)      // Add price rule
+		priceRuleRoutes.GET("/:id", pricingHandler.GetPriceRule)      // Get single price rule
+		priceRuleRoutes.POST("/:id/end", pricingHandler.EndPriceRule) // Stop a price rule applying
+	}
+	api.GET("/price-rules", pricingHandler.GetPriceRules) // List price rules
+
+	// === PROMOTION ROUTES (For Retailer) ===
+	promotionRoutes := api.Group("/promotion")
+	{
+		promotionRoutes.POST("", promotionHandler.CreatePromotion)          // Add promotion
+		promotionRoutes.GET("/:id", promotionHandler.GetPromotion)          // Get single promotion
+		promotionRoutes.POST("/:id/end", promotionHandler.EndPromotion)     // Stop a promotion applying
+		promotionRoutes.POST("/:id/coupons", promotionHandler.CreateCoupon) // Issue a coupon code
+		promotionRoutes.GET("/:id/coupons", promotionHandler.GetCoupons)    // List coupon codes and their use
 	}
+	api.GET("/promotions", promotionHandler.GetPromotions) // List promotions
 }
 
 // healthCheck provides a health check endpoint
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SupplierHandler handles HTTP requests for suppliers
+type SupplierHandler struct {
+	supplierUseCase *usecases.SupplierUseCase
+}
+
+// NewSupplierHandler creates a new supplier handler with dependency injection
+func NewSupplierHandler(supplierUseCase *usecases.SupplierUseCase) *SupplierHandler {
+	return &SupplierHandler{
+		supplierUseCase: supplierUseCase,
+	}
+}
+
+// SupplierListResponse represents the response for listing suppliers
+type SupplierListResponse struct {
+	Suppliers []*entities.Supplier `json:"suppliers"`
+	Count     int                  `json:"count"`
+}
+
+// CreateSupplier handles POST /api/v1/supplier
+// @Summary Add a supplier
+// @Description Creates a supplier that purchase orders can be placed with
+// @Tags Suppliers
+// @Accept json
+// @Produce json
+// @Param supplier body usecases.CreateSupplierRequest true "Name, contact details and usual lead time"
+// @Success 201 {object} entities.Supplier
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/supplier [post]
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
+	var req usecases.CreateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	supplier, err := h.supplierUseCase.CreateSupplier(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidSupplier) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request payload",
+				"details": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create supplier",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, supplier)
+}
+
+// GetSupplier handles GET /api/v1/supplier/:id
+// @Summary Get a supplier
+// @Description Retrieves a supplier by ID
+// @Tags Suppliers
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} entities.Supplier
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/supplier/{id} [get]
+func (h *SupplierHandler) GetSupplier(c *gin.Context) {
+	supplier, err := h.supplierUseCase.GetSupplier(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Supplier not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get supplier",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, supplier)
+}
+
+// GetSuppliers handles GET /api/v1/suppliers
+// @Summary List suppliers
+// @Description Retrieves suppliers by name with pagination
+// @Tags Suppliers
+// @Produce json
+// @Param limit query int false "Limit number of results" default(50)
+// @Param offset query int false "Offset for pagination" default(0)
+// @Success 200 {object} SupplierListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/suppliers [get]
+func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	suppliers, err := h.supplierUseCase.GetSuppliers(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get suppliers",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SupplierListResponse{
+		Suppliers: suppliers,
+		Count:     len(suppliers),
+	})
+}
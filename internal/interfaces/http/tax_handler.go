@@ -0,0 +1,98 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaxHandler handles HTTP requests for tax rates
+type TaxHandler struct {
+	taxUseCase *usecases.TaxUseCase
+}
+
+// NewTaxHandler creates a new tax handler with dependency injection
+func NewTaxHandler(taxUseCase *usecases.TaxUseCase) *TaxHandler {
+	return &TaxHandler{
+		taxUseCase: taxUseCase,
+	}
+}
+
+// TaxRateListResponse represents the HTTP response for listing tax rates
+type TaxRateListResponse struct {
+	TaxRates []*entities.TaxRate `json:"tax_rates"`
+	Count    int                 `json:"count"`
+}
+
+// CreateRate handles POST /api/v1/tax-rate
+// @Summary Publish tax rate
+// @Description Adds the rate for a tax class in a jurisdiction, made of one or more components such as CGST and SGST, that applies from its effective date (default now) until the next rate for the class and jurisdiction
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param rate body usecases.CreateTaxRateRequest true "Jurisdiction, tax class, components and effective date"
+// @Success 201 {object} entities.TaxRate
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/tax-rate [post]
+func (h *TaxHandler) CreateRate(c *gin.Context) {
+	var req usecases.CreateTaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rate, err := h.taxUseCase.CreateRate(c.Request.Context(), &req)
+	if err != nil {
+		writeTaxError(c, err, "Failed to create tax rate")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// GetRates handles GET /api/v1/tax-rates
+// @Summary List tax rates
+// @Description Lists rate history per jurisdiction and tax class, newest first
+// @Tags Tax
+// @Produce json
+// @Param jurisdiction query string false "Only rates for this jurisdiction"
+// @Param tax_class query string false "Only rates for this tax class"
+// @Success 200 {object} TaxRateListResponse
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/tax-rates [get]
+func (h *TaxHandler) GetRates(c *gin.Context) {
+	rates, err := h.taxUseCase.GetRates(c.Request.Context(), c.Query("jurisdiction"), c.Query("tax_class"))
+	if err != nil {
+		writeTaxError(c, err, "Failed to get tax rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, TaxRateListResponse{
+		TaxRates: rates,
+		Count:    len(rates),
+	})
+}
+
+// writeTaxError maps a failed tax rate operation to an HTTP response
+func writeTaxError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidTaxRate):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid tax rate",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
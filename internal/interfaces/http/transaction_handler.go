@@ -1,12 +1,14 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"day5/internal/application/usecases"
 	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,26 +27,48 @@ func NewTransactionHandler(transactionUseCase *usecases.TransactionUseCase) *Tra
 
 // TransactionResponse represents the HTTP response for transaction operations
 type TransactionResponse struct {
-	ID            string  `json:"id"`
-	OrderID       string  `json:"order_id"`
-	CustomerID    string  `json:"customer_id"`
-	CustomerName  string  `json:"customer_name,omitempty"`
-	ProductID     string  `json:"product_id"`
-	ProductName   string  `json:"product_name,omitempty"`
-	Type          string  `json:"type"`
-	Amount        float64 `json:"amount"`
-	Quantity      int     `json:"quantity"`
-	UnitPrice     float64 `json:"unit_price"`
-	Description   string  `json:"description"`
-	TransactionAt string  `json:"transaction_at"`
-	CreatedAt     string  `json:"created_at"`
+	ID            string         `json:"id"`
+	OrderID       string         `json:"order_id"`
+	CustomerID    string         `json:"customer_id"`
+	CustomerName  string         `json:"customer_name,omitempty"`
+	ProductID     string         `json:"product_id"`
+	ProductName   string         `json:"product_name,omitempty"`
+	Type          string         `json:"type"`
+	Amount        entities.Money `json:"amount"`
+	Quantity      int            `json:"quantity"`
+	UnitPrice     entities.Money `json:"unit_price"`
+	Discount      entities.Money `json:"discount"`
+	Description   string         `json:"description"`
+	TransactionAt string         `json:"transaction_at"`
+	CreatedAt     string         `json:"created_at"`
+
+	// Tax is the part of Amount that is tax
+	Tax             entities.Money   `json:"tax"`
+	TaxRate         entities.Percent `json:"tax_rate"`
+	TaxClass        string           `json:"tax_class,omitempty"`
+	TaxJurisdiction string           `json:"tax_jurisdiction,omitempty"`
+}
+
+// TaxLiabilityResponse represents the response for the tax liability report
+type TaxLiabilityResponse struct {
+	StartDate   string                   `json:"start_date"`
+	EndDate     string                   `json:"end_date"`
+	Liabilities []*entities.TaxLiability `json:"liabilities"`
+	Count       int                      `json:"count"`
+	TotalTaxDue entities.Money           `json:"total_tax_due"`
+}
+
+// CategorySalesResponse represents the response for category sales statistics
+type CategorySalesResponse struct {
+	Categories []*entities.CategorySales `json:"categories"`
+	Count      int                       `json:"count"`
 }
 
 // TransactionHistoryResponse represents the response for transaction history
 type TransactionHistoryResponse struct {
 	Transactions []*TransactionResponse `json:"transactions"`
 	Count        int                    `json:"count"`
-	TotalAmount  float64                `json:"total_amount"`
+	TotalAmount  entities.Money         `json:"total_amount"`
 	Message      string                 `json:"message,omitempty"`
 }
 
@@ -116,10 +140,16 @@ func (h *TransactionHandler) GetTransactionHistory(c *gin.Context) {
 
 	// Convert to response format and calculate total
 	transactionResponses := make([]*TransactionResponse, len(transactions))
-	totalAmount := 0.0
+	var totalAmount entities.Money
 	for i, transaction := range transactions {
 		transactionResponses[i] = h.entityToResponse(transaction)
-		totalAmount += transaction.GetRevenueAmount()
+		if totalAmount, err = totalAmount.Add(transaction.GetRevenueAmount()); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to total transaction history",
+				"details": err.Error(),
+			})
+			return
+		}
 	}
 
 	response := &TransactionHistoryResponse{
@@ -138,59 +168,88 @@ func (h *TransactionHandler) GetTransactionHistory(c *gin.Context) {
 // @Tags Transactions
 // @Produce json
 // @Param period query string false "Statistics period (today, this_week, this_month, all_time)" default("all_time")
+// @Param currency query string false "Currency to report amounts in, converted at each transaction's historical rate (default: base currency)"
 // @Success 200 {object} map[string]any
 // @Failure 400 {object} map[string]any
+// @Failure 422 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/transactions/stats [get]
 func (h *TransactionHandler) GetTransactionStats(c *gin.Context) {
-	periodParam := c.DefaultQuery("period", "all_time")
-
-	// Validate period parameter
-	var period usecases.StatsPeriod
-	switch periodParam {
-	case "today":
-		period = usecases.StatsPeriodToday
-	case "this_week":
-		period = usecases.StatsPeriodThisWeek
-	case "this_month":
-		period = usecases.StatsPeriodThisMonth
-	case "all_time":
-		period = usecases.StatsPeriodAllTime
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":         "Invalid period parameter",
-			"valid_periods": []string{"today", "this_week", "this_month", "all_time"},
-		})
+	period, ok := statsPeriod(c)
+	if !ok {
 		return
 	}
 
-	stats, err := h.transactionUseCase.GetBusinessStats(c.Request.Context(), period)
+	stats, err := h.transactionUseCase.GetBusinessStats(c.Request.Context(), period, c.Query("currency"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve business statistics",
-			"details": err.Error(),
-		})
+		writeAnalyticsError(c, err, "Failed to retrieve business statistics")
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetCategoryStats handles GET /api/v1/transactions/stats/categories
+// @Summary Get top selling categories
+// @Description Rolls product sales up the category tree; each category includes the sales of its subcategories
+// @Tags Transactions
+// @Produce json
+// @Param period query string false "Statistics period (today, this_week, this_month, all_time)" default("all_time")
+// @Param currency query string false "Currency to report amounts in (default: base currency)"
+// @Param limit query int false "Number of categories" default(10)
+// @Success 200 {object} CategorySalesResponse
+// @Failure 400 {object} map[string]any
+// @Failure 422 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/transactions/stats/categories [get]
+func (h *TransactionHandler) GetCategoryStats(c *gin.Context) {
+	period, ok := statsPeriod(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	categories, err := h.transactionUseCase.GetTopSellingCategories(c.Request.Context(), period, c.Query("currency"), limit)
+	if err != nil {
+		writeAnalyticsError(c, err, "Failed to retrieve category statistics")
+		return
+	}
+
+	c.JSON(http.StatusOK, CategorySalesResponse{
+		Categories: categories,
+		Count:      len(categories),
+	})
+}
+
+// statsPeriod reads the period query parameter, answering 400 when it is not a known period
+func statsPeriod(c *gin.Context) (usecases.StatsPeriod, bool) {
+	switch period := usecases.StatsPeriod(c.DefaultQuery("period", "all_time")); period {
+	case usecases.StatsPeriodToday, usecases.StatsPeriodThisWeek, usecases.StatsPeriodThisMonth, usecases.StatsPeriodAllTime:
+		return period, true
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":         "Invalid period parameter",
+		"valid_periods": []string{"today", "this_week", "this_month", "all_time"},
+	})
+	return "", false
+}
+
 // GetComprehensiveStats handles GET /api/v1/transactions/stats/comprehensive
 // @Summary Get comprehensive statistics for all periods
 // @Description Retrieves business statistics for all time periods (today, week, month, all-time)
 // @Tags Transactions
 // @Produce json
+// @Param currency query string false "Currency to report amounts in (default: base currency)"
 // @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 422 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/transactions/stats/comprehensive [get]
 func (h *TransactionHandler) GetComprehensiveStats(c *gin.Context) {
-	stats, err := h.transactionUseCase.GetComprehensiveStats(c.Request.Context())
+	stats, err := h.transactionUseCase.GetComprehensiveStats(c.Request.Context(), c.Query("currency"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve comprehensive statistics",
-			"details": err.Error(),
-		})
+		writeAnalyticsError(c, err, "Failed to retrieve comprehensive statistics")
 		return
 	}
 
@@ -218,7 +277,7 @@ func (h *TransactionHandler) GetCustomerTransactionSummary(c *gin.Context) {
 
 	summary, err := h.transactionUseCase.GetCustomerTransactionSummary(c.Request.Context(), customerID)
 	if err != nil {
-		if err.Error() == "customer not found" {
+		if errors.Is(err, repositories.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Customer not found",
 			})
@@ -240,22 +299,108 @@ func (h *TransactionHandler) GetCustomerTransactionSummary(c *gin.Context) {
 // @Tags Transactions
 // @Produce json
 // @Param days query int false "Number of days for daily revenue analysis" default(30)
+// @Param currency query string false "Currency to report revenue in (default: base currency)"
 // @Success 200 {object} map[string]any
+// @Failure 400 {object} map[string]any
+// @Failure 422 {object} map[string]any
 // @Failure 500 {object} map[string]any
 // @Router /api/v1/transactions/revenue/analytics [get]
 func (h *TransactionHandler) GetRevenueAnalytics(c *gin.Context) {
 	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
 
-	analytics, err := h.transactionUseCase.GetRevenueAnalytics(c.Request.Context(), days)
+	analytics, err := h.transactionUseCase.GetRevenueAnalytics(c.Request.Context(), days, c.Query("currency"))
+	if err != nil {
+		writeAnalyticsError(c, err, "Failed to retrieve revenue analytics")
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetTaxLiability handles GET /api/v1/transactions/tax-liability
+// @Summary Get tax liability report
+// @Description Reports taxable sales, tax collected and tax refunded per jurisdiction, tax class and rate for the dates given, both included
+// @Tags Transactions
+// @Produce json
+// @Param start_date query string true "First day of the period (YYYY-MM-DD, UTC)"
+// @Param end_date query string true "Last day of the period (YYYY-MM-DD, UTC)"
+// @Success 200 {object} TaxLiabilityResponse
+// @Failure 400 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/transactions/tax-liability [get]
+func (h *TransactionHandler) GetTaxLiability(c *gin.Context) {
+	start, err := time.Parse(time.DateOnly, c.Query("start_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid start_date format",
+			"details": "Use YYYY-MM-DD, e.g. 2024-04-01",
+		})
+		return
+	}
+	end, err := time.Parse(time.DateOnly, c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid end_date format",
+			"details": "Use YYYY-MM-DD, e.g. 2024-06-30",
+		})
+		return
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "end_date is before start_date",
+		})
+		return
+	}
+
+	// The end date counts in full
+	liabilities, err := h.transactionUseCase.GetTaxLiability(c.Request.Context(), start, end.Add(24*time.Hour-time.Nanosecond))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve revenue analytics",
+			"error":   "Failed to retrieve tax liability",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, analytics)
+	total := entities.NewMoney(0, "")
+	for _, liability := range liabilities {
+		if total, err = total.Add(liability.TaxDue); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to total tax liability",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, TaxLiabilityResponse{
+		StartDate:   start.Format(time.DateOnly),
+		EndDate:     end.Format(time.DateOnly),
+		Liabilities: liabilities,
+		Count:       len(liabilities),
+		TotalTaxDue: total,
+	})
+}
+
+// writeAnalyticsError maps a failed report to an HTTP response
+func writeAnalyticsError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidExchangeRate):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid reporting currency",
+			"details": err.Error(),
+		})
+	case errors.Is(err, entities.ErrNoExchangeRate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Missing exchange rate for the reporting currency",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
 }
 
 // Helper method to convert domain entity to HTTP response
@@ -269,9 +414,15 @@ func (h *TransactionHandler) entityToResponse(transaction *entities.Transaction)
 		Amount:        transaction.Amount,
 		Quantity:      transaction.Quantity,
 		UnitPrice:     transaction.UnitPrice,
+		Discount:      transaction.Discount,
 		Description:   transaction.Description,
 		TransactionAt: transaction.TransactionAt.Format("2006-01-02T15:04:05Z"),
 		CreatedAt:     transaction.CreatedAt.Format("2006-01-02T15:04:05Z"),
+
+		Tax:             transaction.Tax,
+		TaxRate:         transaction.TaxRate,
+		TaxClass:        transaction.TaxClass,
+		TaxJurisdiction: transaction.TaxJurisdiction,
 	}
 
 	// Add related entity information if available
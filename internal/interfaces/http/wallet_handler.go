@@ -0,0 +1,100 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WalletHandler handles HTTP requests for customer store credit
+type WalletHandler struct {
+	walletUseCase *usecases.WalletUseCase
+}
+
+// NewWalletHandler creates a new wallet handler with dependency injection
+func NewWalletHandler(walletUseCase *usecases.WalletUseCase) *WalletHandler {
+	return &WalletHandler{
+		walletUseCase: walletUseCase,
+	}
+}
+
+// GetWallet handles GET /api/v1/customer/:id/wallet
+// @Summary Get store credit wallet
+// @Description Retrieves the customer's store credit balance and statement, oldest entry first
+// @Tags Customers
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Success 200 {object} entities.WalletStatement
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/customer/{id}/wallet [get]
+func (h *WalletHandler) GetWallet(c *gin.Context) {
+	statement, err := h.walletUseCase.GetWallet(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeWalletError(c, err, "Failed to get wallet")
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// IssueCredit handles POST /api/v1/customer/:id/wallet/credit
+// @Summary Issue store credit
+// @Description Adds store credit to the customer's wallet, e.g. as a goodwill gesture
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param credit body usecases.IssueCreditRequest true "Amount and reason"
+// @Success 201 {object} entities.Transaction
+// @Failure 400 {object} map[string]any
+// @Failure 404 {object} map[string]any
+// @Failure 500 {object} map[string]any
+// @Router /api/v1/customer/{id}/wallet/credit [post]
+func (h *WalletHandler) IssueCredit(c *gin.Context) {
+	var req usecases.IssueCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	credit, err := h.walletUseCase.IssueCredit(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		writeWalletError(c, err, "Failed to issue store credit")
+		return
+	}
+
+	c.JSON(http.StatusCreated, credit)
+}
+
+// writeWalletError maps a failed wallet operation to an HTTP response
+func writeWalletError(c *gin.Context, err error, message string) {
+	if errors.Is(err, entities.ErrInvalidAmount) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request payload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, repositories.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Customer not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   message,
+		"details": err.Error(),
+	})
+}
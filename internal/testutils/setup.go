@@ -31,13 +31,7 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Run migrations
-	err = db.AutoMigrate(
-		&persistence.Product{},
-		&persistence.Customer{},
-		&persistence.Order{},
-		&persistence.Transaction{},
-		&persistence.CustomerCooldown{},
-	)
+	err = db.AutoMigrate(persistence.GetModelsToMigrate()...)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
@@ -56,11 +50,12 @@ func CleanupTestDB(db *gorm.DB) {
 }
 
 // CreateTestProduct creates a test product in the database
-func CreateTestProduct(db *gorm.DB, name string, price float64, quantity int) *entities.Product {
+func CreateTestProduct(db *gorm.DB, name string, price entities.Money, quantity int) *entities.Product {
 	product := &persistence.Product{
 		ID:          generateTestID("PROD"),
 		ProductName: name,
-		Price:       price,
+		PriceMinor:  price.Minor(),
+		Currency:    price.Currency(),
 		Quantity:    quantity,
 	}
 
@@ -94,14 +89,19 @@ func CreateTestCustomer(db *gorm.DB, name, email, phone string) *entities.Custom
 }
 
 // CreateTestOrder creates a test order in the database
-func CreateTestOrder(db *gorm.DB, customerID, productID string, quantity int, unitPrice float64) *entities.Order {
+func CreateTestOrder(db *gorm.DB, customerID, productID string, quantity int, unitPrice entities.Money) *entities.Order {
+	total, err := unitPrice.Mul(quantity)
+	if err != nil {
+		panic("Failed to price test order: " + err.Error())
+	}
 	order := &persistence.Order{
-		ID:          generateTestID("ORD"),
-		CustomerID:  customerID,
-		ProductID:   productID,
-		Quantity:    quantity,
-		UnitPrice:   unitPrice,
-		TotalAmount: float64(quantity) * unitPrice,
+		ID:               generateTestID("ORD"),
+		CustomerID:       customerID,
+		ProductID:        &productID,
+		Quantity:         quantity,
+		UnitPriceMinor:   unitPrice.Minor(),
+		TotalAmountMinor: total.Minor(),
+		Currency:         unitPrice.Currency(),
 	}
 
 	if err := db.Create(order).Error; err != nil {
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topSellers fetches the business stats' top selling products keyed by product ID
+func topSellers(t *testing.T, appRouter http.Handler) map[string]entities.ProductSales {
+	w := doJSON(appRouter, "GET", "/api/v1/transactions/stats", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stats entities.BusinessStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	sales := make(map[string]entities.ProductSales, len(stats.TopSellingProducts))
+	for _, product := range stats.TopSellingProducts {
+		sales[product.ProductID] = product
+	}
+	return sales
+}
+
+func TestProductBundles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	newCustomer := func(id, email string) string {
+		customer := &entities.Customer{ID: id, Name: "Kit buyer", Email: email, Phone: "+1000000028"}
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+		return customer.ID
+	}
+	stock := func(productID string) int {
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		return product.Quantity
+	}
+
+	pensID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Pen set", "price": "10.00", "quantity": 10, "cost_price": "4.00",
+	})
+	notebookID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Notebook", "price": "20.00", "quantity": 4, "cost_price": "8.00",
+	})
+	eraserID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Eraser", "price": "5.00", "quantity": 30, "cost_price": "1.00",
+	})
+	kitID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Starter kit", "price": "36.00", "type": "bundle",
+		"components": []map[string]any{
+			{"product_id": pensID, "quantity": 1},
+			{"product_id": notebookID, "quantity": 1},
+			{"product_id": eraserID, "quantity": 2},
+		},
+	})
+
+	t.Run("Availability Follows Component Stock", func(t *testing.T) {
+		kit := getProduct(t, appRouter, kitID)
+		assert.Equal(t, entities.ProductTypeBundle, kit.Type)
+		require.Len(t, kit.Components, 3)
+		assert.Equal(t, money("5.00"), kit.Components[2].ListPrice)
+		assert.Equal(t, 4, kit.Available, "the four notebooks make up four kits")
+		assert.Equal(t, 0, stock(kitID), "a bundle holds no stock of its own")
+
+		for _, body := range []map[string]any{
+			{"product_name": "Empty kit", "price": "5.00", "type": "bundle"},
+			{"product_name": "Stocked kit", "price": "5.00", "type": "bundle", "quantity": 3,
+				"components": []map[string]any{{"product_id": pensID, "quantity": 1}}},
+			{"product_name": "Kit of kits", "price": "5.00", "type": "bundle",
+				"components": []map[string]any{{"product_id": kitID, "quantity": 1}}},
+			{"product_name": "Twice", "price": "5.00", "type": "bundle", "components": []map[string]any{
+				{"product_id": pensID, "quantity": 1}, {"product_id": pensID, "quantity": 2}}},
+			{"product_name": "Loose pens", "price": "5.00", "quantity": 3,
+				"components": []map[string]any{{"product_id": pensID, "quantity": 1}}},
+		} {
+			w := doJSON(appRouter, "POST", "/api/v1/product", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%v: %s", body["product_name"], w.Body.String())
+		}
+
+		w := doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Ghost kit", "price": "5.00", "type": "bundle",
+			"components": []map[string]any{{"product_id": "PROD_MISSING", "quantity": 1}},
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+kitID+"/reservations", map[string]any{"quantity": 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	var orderID string
+	t.Run("Ordering A Bundle Takes Its Components", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": newCustomer("CUST31403", "kit1@example.com"), "product_id": kitID, "quantity": 2,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		orderID = order.ID
+		assert.Equal(t, kitID, order.ProductID)
+		assert.Equal(t, money("72.00"), order.TotalAmount)
+
+		assert.Equal(t, 8, stock(pensID))
+		assert.Equal(t, 2, stock(notebookID))
+		assert.Equal(t, 26, stock(eraserID))
+		assert.Equal(t, 2, getProduct(t, appRouter, kitID).Available)
+
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": newCustomer("CUST31404", "kit2@example.com"), "product_id": kitID, "quantity": 3,
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, 8, stock(pensID), "a failed order takes nothing")
+	})
+
+	t.Run("Revenue Is Allocated Across Components", func(t *testing.T) {
+		// 72.00 split by list value 10 : 20 : 2 × 5
+		sales := topSellers(t, appRouter)
+		assert.NotContains(t, sales, kitID)
+		assert.Equal(t, money("18.00"), sales[pensID].TotalRevenue)
+		assert.Equal(t, money("36.00"), sales[notebookID].TotalRevenue)
+		assert.Equal(t, money("18.00"), sales[eraserID].TotalRevenue)
+		assert.Equal(t, 4, sales[eraserID].QuantitySold)
+		assert.Equal(t, money("4.00"), sales[eraserID].CostOfGoods)
+		assert.Equal(t, money("14.00"), sales[eraserID].GrossMargin)
+	})
+
+	t.Run("Returning A Bundle Restocks Its Components", func(t *testing.T) {
+		confirmOrder(t, appRouter, orderID)
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/returns", usecases.CreateReturnRequest{Quantity: 1})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var orderReturn entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orderReturn))
+
+		w = doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", usecases.ResolveReturnRequest{
+			Disposition: entities.ReturnDispositionRestock,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, 9, stock(pensID))
+		assert.Equal(t, 28, stock(eraserID))
+		sales := topSellers(t, appRouter)
+		assert.Equal(t, money("18.00"), sales[notebookID].TotalRevenue)
+		assert.Equal(t, 2, sales[eraserID].QuantitySold)
+		assert.Equal(t, money("2.00"), sales[eraserID].CostOfGoods)
+	})
+
+	t.Run("Cancelling Puts The Components Back", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": newCustomer("CUST31405", "kit3@example.com"),
+			"items":       []map[string]any{{"product_id": kitID, "quantity": 1}, {"product_id": pensID, "quantity": 2}},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, 6, stock(pensID))
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 9, stock(pensID))
+		assert.Equal(t, 3, stock(notebookID))
+		assert.Equal(t, 28, stock(eraserID))
+		assert.Equal(t, money("18.00"), topSellers(t, appRouter)[notebookID].TotalRevenue)
+	})
+
+	t.Run("Archived Components Stop The Bundle Selling", func(t *testing.T) {
+		w := updateProduct(t, appRouter, notebookID, map[string]any{"status": "archived"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		code, _ := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": newCustomer("CUST31406", "kit4@example.com"), "product_id": kitID, "quantity": 1,
+		})
+		assert.Equal(t, http.StatusConflict, code)
+	})
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCartAndMultiLineOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	productRepo := diContainer.GetProductRepository()
+
+	mugID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Mug",
+		Price:       money("10"),
+		Quantity:    5,
+	})
+	teaID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Tea",
+		Price:       money("20"),
+		Quantity:    3,
+	})
+	customer := &entities.Customer{ID: "CUST30401", Name: "Jonas", Email: "jonas@example.com", Phone: "+1000000006"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	cartPath := "/api/v1/customer/" + customer.ID + "/cart"
+
+	getCart := func() *entities.Cart {
+		w := doJSON(appRouter, "GET", cartPath, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var cart entities.Cart
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cart))
+		return &cart
+	}
+
+	stock := func(productID string) int {
+		product, err := productRepo.GetByID(ctx, productID)
+		require.NoError(t, err)
+		return product.Quantity
+	}
+
+	t.Run("Build A Cart", func(t *testing.T) {
+		for _, item := range []usecases.AddCartItemRequest{
+			{ProductID: mugID, Quantity: 2},
+			{ProductID: teaID, Quantity: 2},
+			{ProductID: mugID, Quantity: 1},
+		} {
+			w := doJSON(appRouter, "POST", cartPath+"/items", item)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		}
+
+		cart := getCart()
+		require.Len(t, cart.Items, 2)
+		assert.Equal(t, 3, cart.Items[0].Quantity)
+		assert.Equal(t, 5, cart.ItemCount)
+		assert.Equal(t, money("70.00"), cart.TotalAmount)
+
+		w := doJSON(appRouter, "POST", cartPath+"/items", usecases.AddCartItemRequest{ProductID: "PROD00000", Quantity: 1})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w = doJSON(appRouter, "DELETE", cartPath+"/items/PROD00000", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Checkout Fails As A Whole", func(t *testing.T) {
+		w := doJSON(appRouter, "PUT", cartPath+"/items/"+teaID, usecases.UpdateCartItemRequest{Quantity: 4})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.False(t, getCart().Items[1].Available)
+
+		w = doJSON(appRouter, "POST", cartPath+"/checkout", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), teaID)
+
+		// Nothing was reserved and the cart is kept
+		assert.Equal(t, 5, stock(mugID))
+		assert.Equal(t, 3, stock(teaID))
+		assert.Len(t, getCart().Items, 2)
+	})
+
+	var order httpHandlers.OrderResponse
+	t.Run("Checkout", func(t *testing.T) {
+		w := doJSON(appRouter, "PUT", cartPath+"/items/"+teaID, usecases.UpdateCartItemRequest{Quantity: 1})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", cartPath+"/checkout", nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+
+		require.Len(t, order.Lines, 2)
+		assert.Equal(t, mugID, order.Lines[0].ProductID)
+		assert.Equal(t, money("30.00"), order.Lines[0].LineTotal)
+		assert.Equal(t, money("50.00"), order.TotalAmount)
+		assert.Equal(t, 4, order.Quantity)
+		assert.Empty(t, order.ProductID)
+
+		assert.Equal(t, 2, stock(mugID))
+		assert.Equal(t, 2, stock(teaID))
+		assert.Empty(t, getCart().Items)
+
+		w = doJSON(appRouter, "POST", cartPath+"/checkout", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Cooldown Applies Once Per Checkout", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID: customer.ID,
+			Items:      []usecases.OrderItemRequest{{ProductID: mugID, Quantity: 1}},
+		})
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Order Is Stored With Its Lines", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+order.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var stored httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+		require.Len(t, stored.Lines, 2)
+		assert.Equal(t, "Tea", stored.Lines[1].ProductName)
+
+		orders, err := diContainer.GetOrderRepository().GetByProductID(ctx, teaID, 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, orders, 1)
+
+		stats, err := diContainer.GetTransactionRepository().GetBusinessStats(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.OrderCount)
+		assert.Equal(t, money("50.00"), stats.TotalRevenue)
+	})
+
+	t.Run("Returns Name The Line", func(t *testing.T) {
+		confirmOrder(t, appRouter, order.ID)
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/returns", usecases.CreateReturnRequest{Quantity: 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/returns", usecases.CreateReturnRequest{
+			ProductID: teaID,
+			Quantity:  2,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/returns", usecases.CreateReturnRequest{
+			ProductID: mugID,
+			Quantity:  2,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var orderReturn entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orderReturn))
+		assert.Equal(t, money("20.00"), orderReturn.RefundAmount)
+
+		w = doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/reject", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("Cancel Restocks Every Line", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, 5, stock(mugID))
+		assert.Equal(t, 3, stock(teaID))
+
+		stats, err := diContainer.GetTransactionRepository().GetBusinessStats(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, money("0.00"), stats.TotalRevenue)
+		assert.Equal(t, 0, stats.TotalQuantitySold)
+	})
+
+	t.Run("Empty Order Is Rejected", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{CustomerID: customer.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createCategory adds a category and returns it
+func createCategory(t *testing.T, appRouter http.Handler, name, parentID string) entities.Category {
+	w := doJSON(appRouter, "POST", "/api/v1/category", map[string]any{"name": name, "parent_id": parentID})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var category entities.Category
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &category))
+	return category
+}
+
+// updateProduct sends a product update against the product's current ETag
+func updateProduct(t *testing.T, appRouter http.Handler, productID string, body map[string]any) *httptest.ResponseRecorder {
+	w := doJSON(appRouter, "GET", "/api/v1/product/"+productID, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	jsonData, _ := json.Marshal(body)
+	req, _ := http.NewRequest("PUT", "/api/v1/product/"+productID, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	return w
+}
+
+// listProducts fetches the catalogue and returns the IDs of the products listed
+func listProducts(t *testing.T, appRouter http.Handler, query string) []string {
+	w := doJSON(appRouter, "GET", "/api/v1/products"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.ProductListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	ids := make([]string, len(response.Products))
+	for i, product := range response.Products {
+		ids[i] = product.ID
+	}
+	return ids
+}
+
+func TestProductCatalogue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	clothing := createCategory(t, appRouter, "Clothing", "")
+	shirts := createCategory(t, appRouter, "Shirts", clothing.ID)
+	kitchen := createCategory(t, appRouter, "Kitchen", "")
+	assert.Equal(t, "/"+clothing.ID+"/"+shirts.ID+"/", shirts.Path)
+
+	redShirtID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "T-shirt", "price": "20.00", "quantity": 10,
+		"sku": " ts-red-m ", "barcode": "4006381333931", "description": "Cotton crew neck",
+		"category_id": shirts.ID, "attributes": map[string]string{"Colour": "Red", "size": "M"},
+	})
+	blueShirtID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "T-shirt", "price": "20.00", "quantity": 10,
+		"sku": "TS-BLUE-L", "category_id": shirts.ID, "attributes": map[string]string{"colour": "Blue", "size": "L"},
+	})
+	mugID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Mug", "price": "8.00", "quantity": 10, "category_id": kitchen.ID,
+	})
+
+	t.Run("Products Carry Catalogue Details", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+redShirtID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var product httpHandlers.ProductResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+		assert.Equal(t, "TS-RED-M", product.SKU)
+		assert.Equal(t, "4006381333931", product.Barcode)
+		assert.Equal(t, "Cotton crew neck", product.Description)
+		assert.Equal(t, shirts.ID, product.CategoryID)
+		assert.Equal(t, map[string]string{"colour": "Red", "size": "M"}, product.Attributes)
+		assert.Equal(t, entities.ProductStatusActive, product.Status)
+	})
+
+	t.Run("SKU And Barcode Are Unique And Checked", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Copy", "price": "1.00", "quantity": 1, "sku": "TS-RED-M",
+		})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		w = updateProduct(t, appRouter, mugID, map[string]any{"barcode": "4006381333931"})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Bad code", "price": "1.00", "quantity": 1, "barcode": "4006381333932",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Lost", "price": "1.00", "quantity": 1, "category_id": "CAT_MISSING",
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+		count, err := diContainer.GetProductRepository().Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("Listing Filters By Category And Attributes", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{redShirtID, blueShirtID}, listProducts(t, appRouter, "?category_id="+clothing.ID))
+		assert.ElementsMatch(t, []string{mugID}, listProducts(t, appRouter, "?category_id="+kitchen.ID))
+		assert.ElementsMatch(t, []string{redShirtID}, listProducts(t, appRouter, "?attribute=colour:red"))
+		assert.ElementsMatch(t, []string{blueShirtID},
+			listProducts(t, appRouter, "?category_id="+shirts.ID+"&attribute=Colour:BLUE&attribute=size:L"))
+		assert.Empty(t, listProducts(t, appRouter, "?attribute=colour:red&attribute=size:L"))
+		assert.ElementsMatch(t, []string{redShirtID}, listProducts(t, appRouter, "?sku=ts-red-m"))
+
+		assert.Equal(t, http.StatusBadRequest, doJSON(appRouter, "GET", "/api/v1/products?attribute=colour", nil).Code)
+		assert.Equal(t, http.StatusBadRequest, doJSON(appRouter, "GET", "/api/v1/products?status=gone", nil).Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(appRouter, "GET", "/api/v1/products?category_id=CAT_MISSING", nil).Code)
+	})
+
+	t.Run("Attributes Are Replaced As A Whole", func(t *testing.T) {
+		w := updateProduct(t, appRouter, blueShirtID, map[string]any{"attributes": map[string]string{"colour": "Navy"}})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		product, err := diContainer.GetProductRepository().GetByID(ctx, blueShirtID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"colour": "Navy"}, product.Attributes)
+		assert.Equal(t, "TS-BLUE-L", product.SKU, "fields left out of the update are kept")
+	})
+
+	t.Run("Archived Products Cannot Be Sold", func(t *testing.T) {
+		w := updateProduct(t, appRouter, blueShirtID, map[string]any{"status": "archived"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.ElementsMatch(t, []string{redShirtID}, listProducts(t, appRouter, "?category_id="+shirts.ID))
+		assert.ElementsMatch(t, []string{blueShirtID}, listProducts(t, appRouter, "?status=archived"))
+		assert.Len(t, listProducts(t, appRouter, "?status=all"), 3)
+
+		customer := &entities.Customer{ID: "CUST31301", Name: "Lena", Email: "lena@example.com", Phone: "+1000000024"}
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+		code, _ := placeOrderAt(t, appRouter, map[string]any{"customer_id": customer.ID, "product_id": blueShirtID, "quantity": 1})
+		assert.Equal(t, http.StatusConflict, code)
+
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+blueShirtID+"/reservations", map[string]any{"quantity": 1})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/customer/"+customer.ID+"/cart/items", map[string]any{"product_id": blueShirtID, "quantity": 1})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+		w = updateProduct(t, appRouter, blueShirtID, map[string]any{"status": "discontinued"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Sales Roll Up The Category Tree", func(t *testing.T) {
+		for i, order := range []struct {
+			productID string
+			quantity  int
+		}{{redShirtID, 3}, {mugID, 2}} {
+			customer := &entities.Customer{
+				ID: []string{"CUST31302", "CUST31303"}[i], Name: "Buyer", Phone: "+1000000025",
+				Email: []string{"buyer1@example.com", "buyer2@example.com"}[i],
+			}
+			require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+			code, _ := placeOrderAt(t, appRouter, map[string]any{
+				"customer_id": customer.ID, "product_id": order.productID, "quantity": order.quantity,
+			})
+			require.Equal(t, http.StatusCreated, code)
+		}
+
+		sales := categorySales(t, appRouter)
+		require.Len(t, sales, 3)
+		assert.Equal(t, 3, sales[clothing.ID].QuantitySold)
+		assert.Equal(t, money("60.00"), sales[clothing.ID].TotalRevenue)
+		assert.Equal(t, 1, sales[clothing.ID].Depth)
+		assert.Equal(t, 3, sales[shirts.ID].QuantitySold)
+		assert.Equal(t, clothing.ID, sales[shirts.ID].ParentID)
+		assert.Equal(t, 2, sales[kitchen.ID].QuantitySold)
+		assert.Equal(t, money("16.00"), sales[kitchen.ID].TotalRevenue)
+
+		assert.Equal(t, http.StatusBadRequest, doJSON(appRouter, "GET", "/api/v1/transactions/stats/categories?period=forever", nil).Code)
+	})
+
+	t.Run("Moving A Category Carries Its Products", func(t *testing.T) {
+		w := doJSON(appRouter, "PUT", "/api/v1/category/"+clothing.ID, map[string]any{"parent_id": shirts.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "a category cannot move under its own subcategory")
+
+		// Shirts moves under Kitchen, taking the red shirt with it
+		w = doJSON(appRouter, "PUT", "/api/v1/category/"+shirts.ID, map[string]any{"parent_id": kitchen.ID, "name": "Aprons"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var moved entities.Category
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &moved))
+		assert.Equal(t, "/"+kitchen.ID+"/"+shirts.ID+"/", moved.Path)
+		assert.Equal(t, "Aprons", moved.Name)
+
+		assert.Empty(t, listProducts(t, appRouter, "?category_id="+clothing.ID))
+		assert.ElementsMatch(t, []string{redShirtID, mugID}, listProducts(t, appRouter, "?category_id="+kitchen.ID))
+
+		sales := categorySales(t, appRouter)
+		assert.NotContains(t, sales, clothing.ID)
+		assert.Equal(t, 5, sales[kitchen.ID].QuantitySold)
+		assert.Equal(t, 2, sales[shirts.ID].Depth)
+	})
+}
+
+// categorySales fetches the top selling categories keyed by category ID
+func categorySales(t *testing.T, appRouter http.Handler) map[string]*entities.CategorySales {
+	w := doJSON(appRouter, "GET", "/api/v1/transactions/stats/categories", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.CategorySalesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	sales := make(map[string]*entities.CategorySales, len(response.Categories))
+	for _, category := range response.Categories {
+		sales[category.CategoryID] = category
+	}
+	return sales
+}
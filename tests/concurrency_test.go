@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestConcurrentOrdersDoNotOversell(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	db := diContainer.GetDatabase().GetDB()
+	customerRepo := diContainer.GetCustomerRepository()
+
+	const stock = 50
+	const buyers = 300
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Limited Edition Sneaker",
+		Price:       money("199.99"),
+		Quantity:    stock,
+	})
+
+	// Each buyer is a separate customer so the cooldown does not interfere
+	customerIDs := make([]string, buyers)
+	for i := range customerIDs {
+		customer := &entities.Customer{
+			ID:    fmt.Sprintf("CUST%05d", 20000+i),
+			Name:  fmt.Sprintf("Buyer %d", i),
+			Email: fmt.Sprintf("buyer%d@example.com", i),
+			Phone: "+1234567890",
+		}
+		require.NoError(t, customerRepo.Create(context.Background(), customer))
+		customerIDs[i] = customer.ID
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, buyers)
+	start := make(chan struct{})
+
+	for i, customerID := range customerIDs {
+		wg.Add(1)
+		go func(i int, customerID string) {
+			defer wg.Done()
+			<-start
+
+			jsonData, _ := json.Marshal(usecases.PlaceOrderRequest{
+				CustomerID: customerID,
+				ProductID:  productID,
+				Quantity:   1,
+			})
+			req, _ := http.NewRequest("POST", "/api/v1/order", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			appRouter.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}(i, customerID)
+	}
+
+	close(start)
+	wg.Wait()
+
+	created, rejected := 0, 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusBadRequest:
+			rejected++
+		default:
+			t.Errorf("unexpected status code %d", code)
+		}
+	}
+
+	assert.Equal(t, stock, created)
+	assert.Equal(t, buyers-stock, rejected)
+
+	var quantity int
+	require.NoError(t, db.Raw("SELECT quantity FROM products WHERE id = ?", productID).Scan(&quantity).Error)
+	assert.Equal(t, 0, quantity)
+
+	var orders, transactions int64
+	require.NoError(t, db.Table("orders").Where("product_id = ?", productID).Count(&orders).Error)
+	require.NoError(t, db.Table("transactions").Where("product_id = ?", productID).Count(&transactions).Error)
+	assert.Equal(t, int64(stock), orders)
+	assert.Equal(t, int64(stock), transactions)
+}
+
+func TestConcurrentOrdersKeepCouponPerCustomerLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+	})
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	db := diContainer.GetDatabase().GetDB()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Travel Mug",
+		Price:       money("12.00"),
+		Quantity:    10,
+	})
+	customerID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Ines", "email": "ines@example.com", "phone": "+1000000042",
+	})
+	promotionID := postJSON(t, appRouter, "/api/v1/promotion", map[string]any{
+		"name": "First order", "type": "amount_off", "amount": "2.00", "coupon_only": true,
+	})
+	postJSON(t, appRouter, "/api/v1/promotion/"+promotionID+"/coupons", map[string]any{
+		"code": "FIRST2", "max_per_customer": 1,
+	})
+
+	// Hold the first two counts of the customer's coupon uses until both have
+	// been made, so both checkouts price the order before either is placed
+	const checkouts = 2
+	var counted sync.WaitGroup
+	counted.Add(checkouts)
+	var counts int32
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:hold_redemption_counts", func(tx *gorm.DB) {
+		if tx.Statement.Table == "promotion_redemptions" && atomic.AddInt32(&counts, 1) <= checkouts {
+			counted.Done()
+			counted.Wait()
+		}
+	}))
+
+	var wg sync.WaitGroup
+	codes := make([]int, checkouts)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			jsonData, _ := json.Marshal(usecases.PlaceOrderRequest{
+				CustomerID: customerID,
+				ProductID:  productID,
+				Quantity:   1,
+				CouponCode: "FIRST2",
+			})
+			req, _ := http.NewRequest("POST", "/api/v1/order", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			appRouter.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []int{http.StatusCreated, http.StatusBadRequest}, codes, "once per customer")
+
+	var redemptions, couponUses int64
+	require.NoError(t, db.Table("promotion_redemptions").Where("coupon_code = ?", "FIRST2").Count(&redemptions).Error)
+	require.NoError(t, db.Raw("SELECT redemptions FROM coupons WHERE code = ?", "FIRST2").Scan(&couponUses).Error)
+	assert.Equal(t, int64(1), redemptions)
+	assert.Equal(t, int64(1), couponUses)
+}
+
+// postJSON posts a create request and returns the ID of the created resource
+func postJSON(t *testing.T, appRouter http.Handler, path string, body any) string {
+	jsonData, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var response httpHandlers.ProductResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.ID
+}
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inventoryValue fetches the value of stock on hand and decodes a successful response
+func inventoryValue(t *testing.T, appRouter http.Handler, query string) httpHandlers.InventoryValueResponse {
+	w := doJSON(appRouter, "GET", "/api/v1/products/inventory-value"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.InventoryValueResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+// receiveStock raises a product's quantity with a receipt at unitCost
+func receiveStock(t *testing.T, appRouter http.Handler, productID string, quantity int, unitCost string) {
+	w := doJSON(appRouter, "GET", "/api/v1/product/"+productID, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	jsonData, _ := json.Marshal(map[string]any{"quantity": quantity, "reason": "receipt", "unit_cost": unitCost})
+	req, _ := http.NewRequest("PUT", "/api/v1/product/"+productID, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var product httpHandlers.ProductResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+	require.Equal(t, quantity, product.Quantity)
+}
+
+func TestFIFOCostingAndMargin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	mugID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Mug", "price": "10.00", "quantity": 10, "cost_price": "4.00",
+	})
+	customer := &entities.Customer{ID: "CUST31201", Name: "Omar", Email: "omar@example.com", Phone: "+1000000021"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	t.Run("Receipts Open Batches And Move The Average", func(t *testing.T) {
+		receiveStock(t, appRouter, mugID, 20, "6.00")
+
+		product, err := diContainer.GetProductRepository().GetByID(ctx, mugID)
+		require.NoError(t, err)
+		assert.Equal(t, money("5.00"), product.AverageCost)
+
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+mugID+"/batches", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var batches httpHandlers.BatchListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &batches))
+		require.Equal(t, 2, batches.Count)
+		assert.Equal(t, money("6.00"), batches.Batches[0].UnitCost)
+		assert.Equal(t, money("4.00"), batches.Batches[1].UnitCost)
+
+		assert.Equal(t, money("200.00"), inventoryValue(t, appRouter, "").Value)
+		assert.Equal(t, money("100.00"), inventoryValue(t, appRouter, "?method=fifo").Value)
+		assert.Equal(t, money("100.00"), inventoryValue(t, appRouter, "?method=weighted_average").Value)
+		assert.Equal(t, http.StatusBadRequest, doJSON(appRouter, "GET", "/api/v1/products/inventory-value?method=lifo", nil).Code)
+	})
+
+	var orderID string
+	t.Run("Sales Take The Oldest Batch First", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": mugID, "quantity": 12,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		orderID = order.ID
+
+		transactions, err := diContainer.GetTransactionRepository().GetByProductID(ctx, mugID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		// 10 units at 4.00 and 2 at 6.00
+		assert.Equal(t, money("52.00"), transactions[0].CostOfGoods)
+
+		ledger, err := diContainer.GetProductUseCase().GetProductMovements(ctx, mugID, entities.MovementReasonSale, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, ledger, 1)
+		assert.Equal(t, money("-52.00"), ledger[0].Cost)
+
+		fifo := inventoryValue(t, appRouter, "?method=fifo")
+		assert.Equal(t, money("48.00"), fifo.Value)
+		require.Len(t, fifo.Products, 1)
+		assert.Equal(t, 8, fifo.Products[0].Quantity)
+		assert.Equal(t, money("6.00"), fifo.Products[0].UnitValue)
+		assert.Equal(t, money("40.00"), inventoryValue(t, appRouter, "?method=weighted_average").Value)
+	})
+
+	t.Run("Restocked Returns Come Back At Their Cost", func(t *testing.T) {
+		confirmOrder(t, appRouter, orderID)
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/returns", map[string]any{"quantity": 3, "reason": "chipped box"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var orderReturn entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orderReturn))
+
+		w = doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", map[string]any{"disposition": "restock"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var approved entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &approved))
+		refund, err := diContainer.GetTransactionRepository().GetByID(ctx, approved.RefundTransactionID)
+		require.NoError(t, err)
+		// A quarter of the 52.00 the order's units cost
+		assert.Equal(t, money("13.00"), refund.CostOfGoods)
+
+		// 8 units at 6.00 plus 3 back at 4.33
+		assert.Equal(t, money("60.99"), inventoryValue(t, appRouter, "?method=fifo").Value)
+	})
+
+	t.Run("Stats Report Gross Margin", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/transactions/stats", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var stats entities.BusinessStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, money("90.00"), stats.TotalRevenue)
+		assert.Equal(t, money("39.00"), stats.CostOfGoods)
+		assert.Equal(t, money("51.00"), stats.GrossMargin)
+		assert.InDelta(t, 56.67, stats.MarginPercent, 0.001)
+
+		require.Len(t, stats.TopSellingProducts, 1)
+		top := stats.TopSellingProducts[0]
+		assert.Equal(t, mugID, top.ProductID)
+		assert.Equal(t, money("39.00"), top.CostOfGoods)
+		assert.Equal(t, money("51.00"), top.GrossMargin)
+		assert.InDelta(t, 56.67, top.MarginPercent, 0.001)
+
+		w = doJSON(appRouter, "GET", "/api/v1/transactions/stats/comprehensive", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var periods map[string]entities.BusinessStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &periods))
+		assert.Equal(t, money("51.00"), periods["today"].GrossMargin)
+	})
+
+	t.Run("Cancellation Reverses The Cost", func(t *testing.T) {
+		other := &entities.Customer{ID: "CUST31203", Name: "Ines", Email: "ines@example.com", Phone: "+1000000023"}
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, other))
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": other.ID, "product_id": mugID, "quantity": 1,
+		})
+		require.Equal(t, http.StatusCreated, code)
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/cancel", map[string]any{"reason": "changed mind"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		stats, err := diContainer.GetTransactionRepository().GetBusinessStats(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, money("39.00"), stats.CostOfGoods)
+		assert.Equal(t, money("60.99"), inventoryValue(t, appRouter, "?method=fifo").Value)
+	})
+}
+
+func TestWeightedAverageCostOfGoods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.ValuationMethod = config.ValuationWeightedAverage
+	})
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	teaID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Tea", "price": "8.00", "quantity": 10, "cost_price": "2.00",
+	})
+	receiveStock(t, appRouter, teaID, 20, "4.00")
+	customer := &entities.Customer{ID: "CUST31202", Name: "Priya", Email: "priya@example.com", Phone: "+1000000022"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	code, _ := placeOrderAt(t, appRouter, map[string]any{
+		"customer_id": customer.ID, "product_id": teaID, "quantity": 5,
+	})
+	require.Equal(t, http.StatusCreated, code)
+
+	transactions, err := diContainer.GetTransactionRepository().GetByProductID(ctx, teaID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, money("15.00"), transactions[0].CostOfGoods, "5 units at the 3.00 average")
+
+	// The batches still say which units are left, whichever method costs sales
+	assert.Equal(t, money("50.00"), inventoryValue(t, appRouter, "?method=fifo").Value)
+	assert.Equal(t, money("45.00"), inventoryValue(t, appRouter, "?method=weighted_average").Value)
+
+	stats, err := diContainer.GetTransactionRepository().GetBusinessStats(ctx, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, money("25.00"), stats.GrossMargin)
+	assert.InDelta(t, 62.5, stats.MarginPercent, 0.001)
+}
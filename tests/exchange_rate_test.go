@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeRateConversion(t *testing.T) {
+	rate := func(value string) entities.Rate {
+		r, err := entities.ParseRate(value)
+		require.NoError(t, err)
+		return r
+	}
+
+	assert.Equal(t, "0.9", rate("0.90").String())
+	assert.Equal(t, "150", rate("150").String())
+	_, err := entities.ParseRate("0.123456789")
+	assert.ErrorIs(t, err, entities.ErrInvalidExchangeRate)
+
+	usd := entities.MustParseMoney("100", "USD")
+	assert.Equal(t, entities.MustParseMoney("92.35", "EUR"), usd.Exchange("EUR", rate("0.9235")))
+	assert.Equal(t, entities.MustParseMoney("15050", "JPY"), usd.Exchange("JPY", rate("150.5")))
+	assert.Equal(t, entities.MustParseMoney("0.307", "KWD"), usd.Exchange("KWD", rate("0.00307")))
+
+	// Half a cent rounds to even
+	cents := entities.NewMoney(1, "USD")
+	assert.Equal(t, entities.NewMoney(0, "EUR"), cents.Exchange("EUR", rate("0.5")))
+	assert.Equal(t, entities.NewMoney(2, "EUR"), cents.Exchange("EUR", rate("1.5")))
+}
+
+func TestMultiCurrencyOrdersAndReporting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Travel Adapter",
+		Price:       money("100"),
+		Quantity:    10,
+	})
+	customer := &entities.Customer{ID: "CUST30601", Name: "Lena", Email: "lena@example.com", Phone: "+1000000008"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	publish := func(currency, rate string, effectiveFrom time.Time) int {
+		w := doJSON(appRouter, "POST", "/api/v1/exchange-rate", map[string]any{
+			"currency":       currency,
+			"rate":           rate,
+			"effective_from": effectiveFrom,
+		})
+		return w.Code
+	}
+
+	placeOrder := func(currency string) httpHandlers.OrderResponse {
+		w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID: customer.ID,
+			ProductID:  productID,
+			Quantity:   1,
+			Currency:   currency,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+
+		var order httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		return order
+	}
+
+	stats := func(currency string) *httptest.ResponseRecorder {
+		return doJSON(appRouter, "GET", "/api/v1/transactions/stats?currency="+currency, nil)
+	}
+
+	t.Run("Publish Rates", func(t *testing.T) {
+		twoDaysAgo := time.Now().UTC().Add(-48 * time.Hour)
+		assert.Equal(t, http.StatusCreated, publish("eur", "0.90", twoDaysAgo))
+		assert.Equal(t, http.StatusCreated, publish("JPY", "150", twoDaysAgo))
+
+		assert.Equal(t, http.StatusBadRequest, publish("EUR", "0.95", twoDaysAgo), "same effective date")
+		assert.Equal(t, http.StatusBadRequest, publish("USD", "1", twoDaysAgo), "base currency")
+		assert.Equal(t, http.StatusBadRequest, publish("GBP", "0", twoDaysAgo), "zero rate")
+		assert.Equal(t, http.StatusBadRequest, publish("EURO", "1", twoDaysAgo), "bad code")
+
+		w := doJSON(appRouter, "GET", "/api/v1/exchange-rates", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list httpHandlers.ExchangeRateListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, "USD", list.BaseCurrency)
+		assert.Equal(t, 2, list.Count)
+	})
+
+	var first httpHandlers.OrderResponse
+	t.Run("Order Captures Charged Currency And Rate", func(t *testing.T) {
+		first = placeOrder("EUR")
+		assert.Equal(t, money("100"), first.TotalAmount)
+		assert.Equal(t, "EUR", first.ChargeCurrency)
+		assert.Equal(t, "0.9", first.ExchangeRate.String())
+		assert.Equal(t, entities.MustParseMoney("90", "EUR"), first.ChargedAmount)
+
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+first.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var stored httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+		assert.Equal(t, first.ChargedAmount, stored.ChargedAmount)
+		assert.Equal(t, first.ExchangeRate, stored.ExchangeRate)
+
+		base := placeOrder("")
+		assert.Equal(t, "USD", base.ChargeCurrency)
+		assert.Equal(t, money("100"), base.ChargedAmount)
+
+		yen := placeOrder("JPY")
+		assert.Equal(t, entities.MustParseMoney("15000", "JPY"), yen.ChargedAmount)
+
+		w = doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID: customer.ID,
+			ProductID:  productID,
+			Quantity:   1,
+			Currency:   "GBP",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("New Rate Applies From Its Effective Date", func(t *testing.T) {
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, http.StatusCreated, publish("EUR", "0.80", time.Now().UTC()))
+
+		second := placeOrder("EUR")
+		assert.Equal(t, entities.MustParseMoney("80", "EUR"), second.ChargedAmount)
+
+		w := doJSON(appRouter, "GET", "/api/v1/exchange-rate/EUR?at="+url.QueryEscape(time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)), nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var effective entities.ExchangeRate
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &effective))
+		assert.Equal(t, "0.9", effective.Rate.String())
+
+		w = doJSON(appRouter, "GET", "/api/v1/exchange-rate/EUR?at="+url.QueryEscape(time.Now().UTC().Add(-72*time.Hour).Format(time.RFC3339)), nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Stats In Any Currency Use Historical Rates", func(t *testing.T) {
+		response := stats("")
+		require.Equal(t, http.StatusOK, response.Code)
+		var base entities.BusinessStats
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &base))
+		assert.Equal(t, money("400"), base.TotalRevenue)
+
+		// Three orders booked at 0.90 and one at 0.80
+		response = stats("eur")
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		var eur entities.BusinessStats
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &eur))
+		assert.Equal(t, entities.MustParseMoney("350", "EUR"), eur.TotalRevenue)
+		assert.Equal(t, entities.MustParseMoney("87.50", "EUR"), eur.AverageOrderValue)
+		assert.Equal(t, 4, eur.OrderCount)
+		require.Len(t, eur.TopSellingProducts, 1)
+		assert.Equal(t, entities.MustParseMoney("350", "EUR"), eur.TopSellingProducts[0].TotalRevenue)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, stats("GBP").Code)
+		assert.Equal(t, http.StatusBadRequest, stats("E1").Code)
+	})
+
+	t.Run("Refunds Convert At Their Own Rate", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+first.ID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// The sale was booked at 0.90, the refund at 0.80
+		response := stats("EUR")
+		require.Equal(t, http.StatusOK, response.Code)
+		var eur entities.BusinessStats
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &eur))
+		assert.Equal(t, entities.MustParseMoney("350", "EUR"), eur.GrossRevenue)
+		assert.Equal(t, entities.MustParseMoney("80", "EUR"), eur.RefundedAmount)
+		assert.Equal(t, entities.MustParseMoney("270", "EUR"), eur.TotalRevenue)
+	})
+
+	t.Run("Revenue Analytics In Another Currency", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/transactions/revenue/analytics?currency=EUR", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var analytics struct {
+			Currency     string `json:"currency"`
+			DailyRevenue []struct {
+				Date    string         `json:"date"`
+				Revenue entities.Money `json:"revenue"`
+			} `json:"daily_revenue"`
+			Growth struct {
+				CurrentMonthRevenue entities.Money `json:"current_month_revenue"`
+			} `json:"growth"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &analytics))
+		assert.Equal(t, "EUR", analytics.Currency)
+		require.Len(t, analytics.DailyRevenue, 1)
+		assert.Equal(t, time.Now().UTC().Format("2006-01-02"), analytics.DailyRevenue[0].Date)
+		assert.Equal(t, entities.MustParseMoney("270", "EUR"), analytics.DailyRevenue[0].Revenue)
+		assert.Equal(t, entities.MustParseMoney("270", "EUR"), analytics.Growth.CurrentMonthRevenue)
+	})
+}
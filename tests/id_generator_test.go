@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	"day5/internal/domain/repositories"
+	"day5/internal/infrastructure/container"
+	"day5/internal/infrastructure/idgen"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDGenerators(t *testing.T) {
+	ctx := context.Background()
+
+	snowflake, err := idgen.NewSnowflakeGenerator(7)
+	require.NoError(t, err)
+
+	generators := map[string]repositories.IDGenerator{
+		"ULID":      idgen.NewULIDGenerator(),
+		"Snowflake": snowflake,
+	}
+
+	for name, generator := range generators {
+		t.Run(name+" IDs Are Unique And Ordered", func(t *testing.T) {
+			const workers, perWorker = 8, 500
+
+			var mu sync.Mutex
+			seen := make(map[string]bool, workers*perWorker)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < perWorker; i++ {
+						id, err := generator.NewID(ctx, entities.OrderIDPrefix)
+						assert.NoError(t, err)
+
+						mu.Lock()
+						seen[id] = true
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			assert.Len(t, seen, workers*perWorker)
+
+			var previous string
+			for i := 0; i < 100; i++ {
+				id, err := generator.NewID(ctx, entities.OrderIDPrefix)
+				require.NoError(t, err)
+				assert.True(t, strings.HasPrefix(id, entities.OrderIDPrefix))
+				assert.LessOrEqual(t, len(id), entities.MaxIDLength)
+				if previous != "" && len(previous) == len(id) {
+					assert.Less(t, previous, id)
+				}
+				previous = id
+			}
+		})
+	}
+
+	t.Run("Snowflake Rejects Out Of Range Node", func(t *testing.T) {
+		_, err := idgen.NewSnowflakeGenerator(idgen.MaxNodeID + 1)
+		assert.Error(t, err)
+	})
+
+	t.Run("Sequence IDs Count Per Prefix", func(t *testing.T) {
+		sequence := container.NewContainer()
+		require.NoError(t, sequence.Initialize(&config.AppConfig{
+			Database: config.DatabaseSettings{Dialect: "sqlite", Name: ":memory:"},
+			Server:   config.ServerSettings{Port: 8080},
+			Business: config.BusinessSettings{CooldownPeriodMinutes: 5, CancellationWindowMinutes: 30},
+			IDs:      config.IDSettings{Strategy: config.IDStrategySequence},
+		}))
+		defer sequence.Cleanup()
+
+		gin.SetMode(gin.TestMode)
+		appRouter := httpHandlers.NewRouter(sequence).SetupRoutes()
+
+		productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+			ProductName: "Notebook",
+			Price:       money("4"),
+			Quantity:    10,
+		})
+		assert.Equal(t, "PROD000000001", productID)
+
+		customerID := postJSON(t, appRouter, "/api/v1/customer", usecases.CreateCustomerRequest{
+			Name:  "Mara",
+			Email: "mara@example.com",
+			Phone: "+1000000007",
+		})
+		assert.Equal(t, "CUST000000001", customerID)
+
+		// IDs drawn inside the order's unit of work come from the same transaction
+		w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID: customerID,
+			ProductID:  productID,
+			Quantity:   2,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"ORD000000001"`)
+
+		ids := make([]string, 0, 20)
+		for i := 0; i < 20; i++ {
+			id, err := sequence.GetIDGenerator().NewID(ctx, entities.ProductIDPrefix)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		assert.True(t, sort.StringsAreSorted(ids))
+		assert.Equal(t, "PROD000000002", ids[0])
+		assert.Equal(t, "PROD000000021", ids[19])
+
+		transactionID, err := sequence.GetIDGenerator().NewID(ctx, entities.TransactionIDPrefix)
+		require.NoError(t, err)
+		assert.Equal(t, "TXN000000002", transactionID)
+	})
+}
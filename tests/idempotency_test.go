@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// postWithKey sends a raw JSON body with an Idempotency-Key header
+func postWithKey(appRouter http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(httpHandlers.IdempotencyKeyHeader, key)
+	}
+
+	w := httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Power Bank",
+		"price":        "25.00",
+		"quantity":     10,
+	})
+	customer := &entities.Customer{ID: "CUST30701", Name: "Mateo", Email: "mateo@example.com", Phone: "+1000000009"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	orderBody := `{"customer_id":"CUST30701","product_id":"` + productID + `","quantity":2}`
+
+	t.Run("Retried Order Is Placed Once", func(t *testing.T) {
+		first := postWithKey(appRouter, "/api/v1/order", "order-retry-1", orderBody)
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		assert.Empty(t, first.Header().Get(httpHandlers.IdempotentReplayedHeader))
+
+		// Without the key the retry would hit the cooldown
+		retry := postWithKey(appRouter, "/api/v1/order", "order-retry-1", orderBody)
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		assert.Equal(t, first.Body.Bytes(), retry.Body.Bytes())
+		assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+		assert.Equal(t, "true", retry.Header().Get(httpHandlers.IdempotentReplayedHeader))
+
+		assert.Equal(t, 1, countOrders(t, appRouter, "/api/v1/orders/customer/"+customer.ID))
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, 8, product.Quantity)
+	})
+
+	t.Run("Key Reused With A Different Body", func(t *testing.T) {
+		w := postWithKey(appRouter, "/api/v1/order", "order-retry-1",
+			`{"customer_id":"CUST30701","product_id":"`+productID+`","quantity":3}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		// The same body on another endpoint is a different request too
+		w = postWithKey(appRouter, "/api/v1/product", "order-retry-1", orderBody)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Error Responses Are Replayed", func(t *testing.T) {
+		first := postWithKey(appRouter, "/api/v1/order", "order-cooldown", orderBody)
+		require.Equal(t, http.StatusTooManyRequests, first.Code)
+
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+		retry := postWithKey(appRouter, "/api/v1/order", "order-cooldown", orderBody)
+		assert.Equal(t, http.StatusTooManyRequests, retry.Code)
+		assert.Equal(t, first.Body.Bytes(), retry.Body.Bytes())
+	})
+
+	t.Run("Customer And Product Creation", func(t *testing.T) {
+		customerBody := `{"name":"Ines","email":"ines@example.com","phone":"+1000000010"}`
+		first := postWithKey(appRouter, "/api/v1/customer", "customer-1", customerBody)
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		retry := postWithKey(appRouter, "/api/v1/customer", "customer-1", customerBody)
+		assert.Equal(t, first.Body.Bytes(), retry.Body.Bytes())
+
+		productBody := `{"product_name":"Cable","price":"5.00","quantity":3}`
+		first = postWithKey(appRouter, "/api/v1/product", "product-1", productBody)
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		retry = postWithKey(appRouter, "/api/v1/product", "product-1", productBody)
+		assert.Equal(t, first.Body.Bytes(), retry.Body.Bytes())
+
+		products, err := diContainer.GetProductRepository().GetAll(ctx, 100, 0)
+		require.NoError(t, err)
+		assert.Len(t, products, 2)
+	})
+
+	t.Run("Requests Without A Key Are Not Deduplicated", func(t *testing.T) {
+		productBody := `{"product_name":"Cable","price":"5.00","quantity":3}`
+		assert.Equal(t, http.StatusCreated, postWithKey(appRouter, "/api/v1/product", "", productBody).Code)
+		assert.Equal(t, http.StatusCreated, postWithKey(appRouter, "/api/v1/product", "", productBody).Code)
+
+		w := postWithKey(appRouter, "/api/v1/product", strings.Repeat("k", entities.MaxIdempotencyKeyLength+1), productBody)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Concurrent Retries Place One Order", func(t *testing.T) {
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+
+		const attempts = 5
+		codes := make([]int, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = postWithKey(appRouter, "/api/v1/order", "order-concurrent", orderBody).Code
+			}(i)
+		}
+		wg.Wait()
+
+		for _, code := range codes {
+			assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+		}
+		assert.Equal(t, 2, countOrders(t, appRouter, "/api/v1/orders/customer/"+customer.ID))
+	})
+
+	t.Run("Expired Keys Can Be Reused", func(t *testing.T) {
+		record, err := diContainer.GetIdempotencyRepository().GetByKey(ctx, "customer-1")
+		require.NoError(t, err)
+		assert.True(t, record.IsCompleted())
+		assert.WithinDuration(t, time.Now().Add(entities.DefaultIdempotencyKeyTTL), record.ExpiresAt, time.Minute)
+
+		removed, err := diContainer.GetIdempotencyRepository().DeleteExpired(ctx, time.Now().Add(25*time.Hour))
+		require.NoError(t, err)
+		assert.Positive(t, removed)
+
+		_, err = diContainer.GetIdempotencyRepository().GetByKey(ctx, "customer-1")
+		assert.Error(t, err)
+
+		w := postWithKey(appRouter, "/api/v1/order", "order-retry-1",
+			`{"customer_id":"CUST30701","product_id":"`+productID+`","quantity":3}`)
+		assert.NotEqual(t, http.StatusUnprocessableEntity, w.Code)
+	})
+}
+
+func TestExpiredKeyIsTakenOverOnce(t *testing.T) {
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	db := diContainer.GetDatabase().GetDB()
+	idempotency := diContainer.GetIdempotencyUseCase()
+	fingerprint := idempotency.Fingerprint("POST", "/api/v1/order", []byte(`{}`))
+
+	expired, err := entities.NewIdempotencyRecord("order-expired", fingerprint, time.Hour)
+	require.NoError(t, err)
+	expired.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	require.NoError(t, diContainer.GetIdempotencyRepository().Create(ctx, expired))
+
+	// Both requests read the expired key before either takes it over, and the
+	// second only deletes it once the first has claimed it afresh
+	const requests = 2
+	var read sync.WaitGroup
+	read.Add(requests)
+	var reads, deletes int32
+	claimed := make(chan struct{})
+	var claimOnce sync.Once
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:hold_key_reads", func(tx *gorm.DB) {
+		if tx.Statement.Table == "idempotency_keys" && atomic.AddInt32(&reads, 1) <= requests {
+			read.Done()
+			read.Wait()
+		}
+	}))
+	require.NoError(t, db.Callback().Delete().Before("gorm:delete").Register("test:hold_second_takeover", func(tx *gorm.DB) {
+		if tx.Statement.Table == "idempotency_keys" && atomic.AddInt32(&deletes, 1) == requests {
+			<-claimed
+		}
+	}))
+	require.NoError(t, db.Callback().Create().After("gorm:create").Register("test:signal_claim", func(tx *gorm.DB) {
+		if tx.Statement.Table == "idempotency_keys" && tx.RowsAffected > 0 {
+			claimOnce.Do(func() { close(claimed) })
+		}
+	}))
+
+	var wg sync.WaitGroup
+	errs := make([]error, requests)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = idempotency.Begin(ctx, "order-expired", fingerprint)
+		}(i)
+	}
+	wg.Wait()
+
+	won := 0
+	for _, err := range errs {
+		if err == nil {
+			won++
+		} else {
+			assert.ErrorIs(t, err, entities.ErrIdempotencyKeyInProgress)
+		}
+	}
+	assert.Equal(t, 1, won, "only one request may handle the key")
+}
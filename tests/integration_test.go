@@ -10,6 +10,7 @@ import (
 
 	"day5/internal/application/usecases"
 	"day5/internal/config"
+	"day5/internal/domain/entities"
 	"day5/internal/infrastructure/container"
 	httpHandlers "day5/internal/interfaces/http"
 
@@ -21,6 +22,18 @@ import (
 func setupIntegrationTest(t *testing.T) (*gin.Engine, *gorm.DB) {
 	gin.SetMode(gin.TestMode)
 
+	diContainer := setupTestContainer(t)
+
+	// Setup router
+	router := httpHandlers.NewRouter(diContainer)
+	appRouter := router.SetupRoutes()
+
+	return appRouter, diContainer.GetDatabase().GetDB()
+}
+
+// setupTestContainer builds a container on an in-memory database; options can
+// adjust the test configuration before the container is built
+func setupTestContainer(t *testing.T, options ...func(*config.AppConfig)) *container.Container {
 	// Create test configuration
 	cfg := &config.AppConfig{
 		Database: config.DatabaseSettings{
@@ -34,14 +47,19 @@ func setupIntegrationTest(t *testing.T) (*gin.Engine, *gorm.DB) {
 			Port: 8080,
 		},
 		Business: config.BusinessSettings{
-			CooldownPeriodMinutes: 5,
-			DefaultCurrency:       "USD",
+			CooldownPeriodMinutes:     5,
+			CancellationWindowMinutes: 30,
+			DefaultCurrency:           "USD",
 		},
 		Security: config.SecuritySettings{
 			JWTSecret: "test-secret",
 		},
 	}
 
+	for _, option := range options {
+		option(cfg)
+	}
+
 	// Create DI container and initialize with config
 	diContainer := container.NewContainer()
 	err := diContainer.Initialize(cfg)
@@ -49,11 +67,7 @@ func setupIntegrationTest(t *testing.T) (*gin.Engine, *gorm.DB) {
 		t.Fatalf("Failed to initialize container: %v", err)
 	}
 
-	// Setup router
-	router := httpHandlers.NewRouter(diContainer)
-	appRouter := router.SetupRoutes()
-
-	return appRouter, diContainer.GetDatabase().GetDB()
+	return diContainer
 }
 
 func TestCompleteRetailerWorkflow(t *testing.T) {
@@ -68,9 +82,9 @@ func TestCompleteRetailerWorkflow(t *testing.T) {
 	// 1. Add products (Retailer)
 	t.Run("Add Products", func(t *testing.T) {
 		products := []usecases.CreateProductRequest{
-			{ProductName: "iPhone 15 Pro", Price: 999.99, Quantity: 25},
-			{ProductName: "MacBook Air M3", Price: 1199.99, Quantity: 10},
-			{ProductName: "AirPods Pro", Price: 249.99, Quantity: 50},
+			{ProductName: "iPhone 15 Pro", Price: money("999.99"), Quantity: 25},
+			{ProductName: "MacBook Air M3", Price: money("1199.99"), Quantity: 10},
+			{ProductName: "AirPods Pro", Price: money("249.99"), Quantity: 50},
 		}
 
 		for _, product := range products {
@@ -156,7 +170,7 @@ func TestCompleteRetailerWorkflow(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotEmpty(t, response.ID)
 			orderIDs = append(orderIDs, response.ID)
-			assert.Greater(t, response.TotalAmount, 0.0)
+			assert.True(t, response.TotalAmount.IsPositive())
 		}
 	})
 
@@ -226,7 +240,7 @@ func TestCompleteRetailerWorkflow(t *testing.T) {
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
 		assert.Equal(t, 2, response.Count) // Should have 2 transactions
-		assert.Greater(t, response.TotalAmount, 0.0)
+		assert.True(t, response.TotalAmount.IsPositive())
 	})
 
 	// 9. View business analytics
@@ -247,26 +261,35 @@ func TestCompleteRetailerWorkflow(t *testing.T) {
 
 	// 10. Update product (Retailer)
 	t.Run("Update Product", func(t *testing.T) {
-		price := 899.99
+		getReq, _ := http.NewRequest("GET", "/api/v1/product/"+productIDs[0], nil)
+		getW := httptest.NewRecorder()
+		appRouter.ServeHTTP(getW, getReq)
+		etag := getW.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		price := money("899.99")
 		quantity := 30
 		updateReq := usecases.UpdateProductRequest{
 			Price:    &price,
 			Quantity: &quantity,
+			Reason:   entities.MovementReasonStocktake,
 		}
 
 		jsonData, _ := json.Marshal(updateReq)
 		req, _ := http.NewRequest("PUT", "/api/v1/product/"+productIDs[0], bytes.NewBuffer(jsonData))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", etag)
 
 		w := httptest.NewRecorder()
 		appRouter.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
 
 		var response httpHandlers.ProductResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(t, err)
-		assert.Equal(t, 899.99, response.Price)
+		assert.Equal(t, money("899.99"), response.Price)
 		assert.Equal(t, 30, response.Quantity)
 	})
 }
@@ -282,7 +305,7 @@ func TestErrorScenarios(t *testing.T) {
 		// Create a product first
 		productReq := usecases.CreateProductRequest{
 			ProductName: "Test Product",
-			Price:       99.99,
+			Price:       money("99.99"),
 			Quantity:    10,
 		}
 		jsonData, _ := json.Marshal(productReq)
@@ -361,7 +384,7 @@ func TestErrorScenarios(t *testing.T) {
 		// Create product via HTTP request
 		productReq := usecases.CreateProductRequest{
 			ProductName: "Limited Product",
-			Price:       99.99,
+			Price:       money("99.99"),
 			Quantity:    2,
 		}
 		jsonData, _ = json.Marshal(productReq)
@@ -420,7 +443,7 @@ func TestCooldownExpired(t *testing.T) {
 
 	productReq := usecases.CreateProductRequest{
 		ProductName: "Test Product",
-		Price:       99.99,
+		Price:       money("99.99"),
 		Quantity:    10,
 	}
 	jsonData, _ = json.Marshal(productReq)
@@ -458,3 +481,67 @@ func TestCooldownExpired(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, response.ID)
 }
+
+func TestOptimisticConcurrency(t *testing.T) {
+	appRouter, db := setupIntegrationTest(t)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	productReq := usecases.CreateProductRequest{
+		ProductName: "Standing Desk",
+		Price:       money("499.99"),
+		Quantity:    8,
+	}
+	jsonData, _ := json.Marshal(productReq)
+	req, _ := http.NewRequest("POST", "/api/v1/product", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+
+	var productResponse httpHandlers.ProductResponse
+	json.Unmarshal(w.Body.Bytes(), &productResponse)
+
+	// Both admins read the same version
+	req, _ = http.NewRequest("GET", "/api/v1/product/"+productResponse.ID, nil)
+	w = httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	update := func(ifMatch string, amount string) *httptest.ResponseRecorder {
+		price := money(amount)
+		jsonData, _ := json.Marshal(usecases.UpdateProductRequest{Price: &price})
+		req, _ := http.NewRequest("PUT", "/api/v1/product/"+productResponse.ID, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		appRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Missing If-Match", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionRequired, update("", "449.99").Code)
+	})
+
+	t.Run("First Writer Wins", func(t *testing.T) {
+		w := update(etag, "449.99")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("Stale Writer Rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusPreconditionFailed, update(etag, "399.99").Code)
+
+		req, _ := http.NewRequest("GET", "/api/v1/product/"+productResponse.ID, nil)
+		w := httptest.NewRecorder()
+		appRouter.ServeHTTP(w, req)
+
+		var response httpHandlers.ProductResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, money("449.99"), response.Price)
+	})
+}
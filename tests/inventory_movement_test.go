@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryMovements(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Desk Lamp",
+		"price":        "30.00",
+		"quantity":     10,
+	})
+	customer := &entities.Customer{ID: "CUST30901", Name: "Lena", Email: "lena@example.com", Phone: "+1000000013"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	movements := func(t *testing.T, query string) []*entities.InventoryMovement {
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/movements"+query, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response httpHandlers.MovementListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Equal(t, len(response.Movements), response.Count)
+		return response.Movements
+	}
+	update := func(body map[string]any) int {
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+productID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("PUT", "/api/v1/product/"+productID, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", w.Header().Get("ETag"))
+
+		w = httptest.NewRecorder()
+		appRouter.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("Opening Stock Is A Receipt", func(t *testing.T) {
+		ledger := movements(t, "")
+		require.Len(t, ledger, 1)
+		assert.Equal(t, entities.MovementReasonReceipt, ledger[0].Reason)
+		assert.Equal(t, 0, ledger[0].QuantityBefore)
+		assert.Equal(t, 10, ledger[0].QuantityAfter)
+		assert.Equal(t, 10, ledger[0].Change)
+	})
+
+	var orderID string
+	t.Run("Orders And Cancellations Are Recorded", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order", map[string]any{
+			"customer_id": customer.ID,
+			"product_id":  productID,
+			"quantity":    3,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var order httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		orderID = order.ID
+
+		sales := movements(t, "?reason=sale")
+		require.Len(t, sales, 1)
+		assert.Equal(t, -3, sales[0].Change)
+		assert.Equal(t, 10, sales[0].QuantityBefore)
+		assert.Equal(t, 7, sales[0].QuantityAfter)
+		assert.Equal(t, customer.ID, sales[0].Actor)
+		assert.Equal(t, orderID, sales[0].ReferenceID)
+
+		w = doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", map[string]any{
+			"actor":  "support",
+			"reason": "changed mind",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		ledger := movements(t, "")
+		require.Len(t, ledger, 3)
+		cancellation := ledger[0]
+		assert.Equal(t, entities.MovementReasonCancellation, cancellation.Reason)
+		assert.Equal(t, 3, cancellation.Change)
+		assert.Equal(t, 7, cancellation.QuantityBefore)
+		assert.Equal(t, 10, cancellation.QuantityAfter)
+		assert.Equal(t, "support", cancellation.Actor)
+		assert.Equal(t, orderID, cancellation.ReferenceID)
+		assert.Equal(t, "changed mind", cancellation.Note)
+	})
+
+	t.Run("Manual Edits Require A Reason", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, update(map[string]any{"quantity": 8}))
+		assert.Equal(t, http.StatusBadRequest, update(map[string]any{"quantity": 8, "reason": "sale"}))
+
+		// Damage can only take stock away
+		assert.Equal(t, http.StatusBadRequest, update(map[string]any{"quantity": 12, "reason": "damage"}))
+
+		// Price-only edits and unchanged quantities leave no movement
+		assert.Equal(t, http.StatusOK, update(map[string]any{"price": "32.00", "quantity": 10}))
+		assert.Len(t, movements(t, ""), 3)
+
+		assert.Equal(t, http.StatusOK, update(map[string]any{
+			"quantity":     8,
+			"reason":       "damage",
+			"actor":        "warehouse",
+			"reference_id": "DMG-7",
+			"note":         "dropped in transit",
+		}))
+
+		ledger := movements(t, "")
+		require.Len(t, ledger, 4)
+		damage := ledger[0]
+		assert.Equal(t, entities.MovementReasonDamage, damage.Reason)
+		assert.Equal(t, -2, damage.Change)
+		assert.Equal(t, 10, damage.QuantityBefore)
+		assert.Equal(t, 8, damage.QuantityAfter)
+		assert.Equal(t, "warehouse", damage.Actor)
+		assert.Equal(t, "DMG-7", damage.ReferenceID)
+		assert.Equal(t, "dropped in transit", damage.Note)
+	})
+
+	t.Run("Ledger Explains The Stock Level", func(t *testing.T) {
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+
+		total := 0
+		for _, movement := range movements(t, "") {
+			total += movement.Change
+		}
+		assert.Equal(t, product.Quantity, total)
+
+		assert.Len(t, movements(t, "?limit=2"), 2)
+		assert.Len(t, movements(t, "?limit=2&offset=3"), 1)
+	})
+
+	t.Run("Invalid Queries", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/movements?reason=theft", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(appRouter, "GET", "/api/v1/product/PROD00000/movements", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
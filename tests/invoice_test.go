@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getInvoice requests an order's invoice with the given Accept header
+func getInvoice(appRouter http.Handler, orderID, accept string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/order/"+orderID+"/invoice", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	w := httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	return w
+}
+
+// invoiceJSON fetches an order's invoice as JSON
+func invoiceJSON(t *testing.T, appRouter http.Handler, orderID string) entities.Invoice {
+	w := getInvoice(appRouter, orderID, "application/json")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var invoice entities.Invoice
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invoice))
+	return invoice
+}
+
+func TestInvoices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+		cfg.Business.TaxJurisdiction = "IN-KA"
+		cfg.Business.PricesIncludeTax = true
+		cfg.Invoice = config.InvoiceSettings{
+			NumberPrefix:  "KA/",
+			SellerName:    "Day5 Retail",
+			SellerAddress: "12 MG Road\nBengaluru",
+			SellerTaxID:   "29ABCDE1234F1Z5",
+		}
+	})
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	createTaxRate(t, appRouter, "IN-KA", "gst_18", "CGST", "9", "SGST", "9")
+	createTaxRate(t, appRouter, "IN-KA", "gst_5", "CGST", "2.5", "SGST", "2.5")
+	kettleID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Kettle (1.5 L)", "sku": "KET-15", "price": "118.00", "quantity": 20, "tax_class": "gst_18",
+	})
+	teaID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Tea", "price": "105.00", "quantity": 20, "tax_class": "gst_5",
+	})
+	riceID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Rice", "price": "50.00", "quantity": 20,
+	})
+	customerID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Lakshmi", "email": "lakshmi@example.com", "phone": "+1000000039",
+	})
+
+	var first, second httpHandlers.OrderResponse
+
+	t.Run("Orders Are Invoiced In Sequence", func(t *testing.T) {
+		var code int
+		code, first = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID,
+			"items": []map[string]any{
+				{"product_id": kettleID, "quantity": 2},
+				{"product_id": teaID, "quantity": 1},
+				{"product_id": riceID, "quantity": 1},
+			},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "KA/000001", first.InvoiceNumber)
+
+		// An order that fails does not use up a number
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": riceID, "quantity": 1000,
+		})
+		require.Equal(t, http.StatusBadRequest, code)
+
+		// Nor does an invoice whose unit of work rolls back
+		errRollback := errors.New("rollback")
+		err := diContainer.GetUnitOfWork().Do(context.Background(), func(ctx context.Context) error {
+			sequence, err := diContainer.GetInvoiceRepository().NextSequence(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), sequence)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		_, err = diContainer.GetInvoiceRepository().NextSequence(context.Background())
+		assert.Error(t, err, "numbers are only handed out inside a unit of work")
+
+		code, second = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": riceID, "quantity": 2,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "KA/000002", second.InvoiceNumber)
+	})
+
+	t.Run("Invoices Snapshot The Order", func(t *testing.T) {
+		w := updateProduct(t, appRouter, kettleID, map[string]any{"product_name": "Electric Kettle", "price": "130.00"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "GET", "/api/v1/customer/"+customerID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		body, _ := json.Marshal(map[string]any{"name": "Lakshmi Rao"})
+		req, _ := http.NewRequest("PUT", "/api/v1/customer/"+customerID, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", w.Header().Get("ETag"))
+		w = httptest.NewRecorder()
+		appRouter.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		invoice := invoiceJSON(t, appRouter, first.ID)
+		assert.Equal(t, "KA/000001", invoice.Number)
+		assert.Equal(t, int64(1), invoice.Sequence)
+		assert.Equal(t, first.ID, invoice.OrderID)
+		assert.Equal(t, "Day5 Retail", invoice.Seller.Name)
+		assert.Equal(t, "29ABCDE1234F1Z5", invoice.Seller.TaxID)
+		assert.Equal(t, "Lakshmi", invoice.Customer.Name, "the customer as they were when invoiced")
+		assert.Equal(t, customerID, invoice.Customer.ID)
+		assert.Equal(t, "IN-KA", invoice.TaxJurisdiction)
+
+		require.Len(t, invoice.Lines, 3)
+		kettle := invoice.Lines[0]
+		assert.Equal(t, "Kettle (1.5 L)", kettle.ProductName)
+		assert.Equal(t, "KET-15", kettle.SKU)
+		assert.Equal(t, money("118.00"), kettle.UnitPrice)
+		assert.Equal(t, money("200.00"), kettle.TaxableAmount)
+		assert.Equal(t, "18", kettle.TaxRate.String())
+		assert.Equal(t, money("36.00"), kettle.Tax)
+		assert.Len(t, kettle.Taxes, 2)
+		assert.Equal(t, money("236.00"), kettle.LineTotal)
+		assert.Empty(t, invoice.Lines[2].TaxClass)
+
+		// 200.00 + 100.00 + 50.00 taxable, 36.00 + 5.00 tax
+		assert.Equal(t, money("350.00"), invoice.Subtotal)
+		assert.Equal(t, money("41.00"), invoice.Tax)
+		assert.Equal(t, money("391.00"), invoice.Total)
+		assert.Equal(t, first.TotalAmount, invoice.Total)
+
+		require.Len(t, invoice.TaxSummary, 4)
+		assert.Equal(t, "CGST", invoice.TaxSummary[0].Name)
+		assert.Equal(t, "9", invoice.TaxSummary[0].Rate.String())
+		assert.Equal(t, money("200.00"), invoice.TaxSummary[0].TaxableAmount)
+		assert.Equal(t, money("18.00"), invoice.TaxSummary[0].Amount)
+		assert.Equal(t, "2.5", invoice.TaxSummary[2].Rate.String())
+		assert.Equal(t, money("100.00"), invoice.TaxSummary[2].TaxableAmount)
+		assert.Equal(t, money("2.50"), invoice.TaxSummary[2].Amount)
+	})
+
+	t.Run("Invoices Are Rendered As PDF On Request", func(t *testing.T) {
+		w := getInvoice(appRouter, first.ID, "application/pdf")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, `inline; filename="KA-000001.pdf"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+		document := w.Body.Bytes()
+		assert.True(t, bytes.HasPrefix(document, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(document, []byte("%%EOF\n")))
+		assert.Contains(t, string(document), "(TAX INVOICE) Tj")
+		assert.Contains(t, string(document), "(Invoice KA/000001) Tj")
+		assert.Contains(t, string(document), "(Kettle \\(1.5 L\\)) Tj")
+		assert.Contains(t, string(document), "(391.00) Tj")
+
+		// The cross-reference table points at every object
+		match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(document)
+		require.NotNil(t, match)
+		xref, _ := strconv.Atoi(string(match[1]))
+		require.True(t, bytes.HasPrefix(document[xref:], []byte("xref\n0 ")))
+		offsets := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(document[xref:], -1)
+		require.NotEmpty(t, offsets)
+		for i, offset := range offsets {
+			at, _ := strconv.Atoi(string(offset[1]))
+			assert.True(t, bytes.HasPrefix(document[at:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+		}
+
+		// Orders without tax get a plain invoice
+		w = getInvoice(appRouter, second.ID, "application/pdf")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "(INVOICE) Tj")
+	})
+
+	t.Run("Formats Are Negotiated", func(t *testing.T) {
+		for _, accept := range []string{"", "*/*", "application/json", "application/*"} {
+			w := getInvoice(appRouter, first.ID, accept)
+			require.Equal(t, http.StatusOK, w.Code, "%q: %s", accept, w.Body.String())
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json", accept)
+		}
+
+		w := getInvoice(appRouter, first.ID, "application/pdf, application/json")
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+
+		w = getInvoice(appRouter, first.ID, "text/html")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code, w.Body.String())
+
+		w = getInvoice(appRouter, "ORD-UNKNOWN", "application/pdf")
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	})
+
+	t.Run("Cancelled Orders Keep Their Invoice", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+second.ID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		invoice := invoiceJSON(t, appRouter, second.ID)
+		assert.Equal(t, "KA/000002", invoice.Number)
+		assert.Equal(t, money("100.00"), invoice.Total)
+	})
+}
@@ -0,0 +1,451 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createLocation adds a location through the API and returns its ID
+func createLocation(t *testing.T, appRouter http.Handler, body map[string]any) string {
+	w := doJSON(appRouter, "POST", "/api/v1/location", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var location entities.Location
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &location))
+	return location.ID
+}
+
+// stockByLocation reads how much of a product each location holds
+func stockByLocation(t *testing.T, appRouter http.Handler, productID string) map[string]int {
+	w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/stock", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.StockLevelListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	stock := make(map[string]int, len(response.Levels))
+	for _, level := range response.Levels {
+		stock[level.LocationID] = level.Quantity
+	}
+	return stock
+}
+
+// placeOrderAt places an order and returns the response with the status code
+func placeOrderAt(t *testing.T, appRouter http.Handler, body map[string]any) (int, httpHandlers.OrderResponse) {
+	w := doJSON(appRouter, "POST", "/api/v1/order", body)
+
+	var order httpHandlers.OrderResponse
+	if w.Code == http.StatusCreated {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	}
+	return w.Code, order
+}
+
+func TestStockLocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Notebook",
+		"price":        "5.00",
+		"quantity":     10,
+	})
+	for _, customer := range []*entities.Customer{
+		{ID: "CUST31001", Name: "Ada", Email: "ada@example.com", Phone: "+1000000014"},
+		{ID: "CUST31002", Name: "Bo", Email: "bo@example.com", Phone: "+1000000015"},
+		{ID: "CUST31003", Name: "Cy", Email: "cy@example.com", Phone: "+1000000016"},
+	} {
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+	}
+
+	var mainID, northID, southID string
+	t.Run("Stock Starts At The Default Location", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/locations", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response httpHandlers.LocationListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Locations, 1)
+		assert.Equal(t, entities.DefaultLocationCode, response.Locations[0].Code)
+		assert.True(t, response.Locations[0].IsDefault)
+		mainID = response.Locations[0].ID
+
+		assert.Equal(t, map[string]int{mainID: 10}, stockByLocation(t, appRouter, productID))
+
+		ledger, err := diContainer.GetProductUseCase().GetProductMovements(ctx, productID, "", 0, 0)
+		require.NoError(t, err)
+		require.Len(t, ledger, 1)
+		assert.Equal(t, mainID, ledger[0].LocationID)
+	})
+
+	t.Run("Create Locations", func(t *testing.T) {
+		northID = createLocation(t, appRouter, map[string]any{
+			"code": "north", "name": "North store", "type": "store",
+			"position": map[string]any{"latitude": 51.5074, "longitude": -0.1278},
+		})
+		southID = createLocation(t, appRouter, map[string]any{
+			"code": "SOUTH", "name": "South store", "type": "store",
+			"position": map[string]any{"latitude": 48.8566, "longitude": 2.3522},
+		})
+
+		w := doJSON(appRouter, "GET", "/api/v1/location/"+northID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var location entities.Location
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &location))
+		assert.Equal(t, "NORTH", location.Code)
+		assert.False(t, location.IsDefault)
+
+		w = doJSON(appRouter, "POST", "/api/v1/location", map[string]any{"code": "NORTH", "name": "Again", "type": "store"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w = doJSON(appRouter, "POST", "/api/v1/location", map[string]any{"code": "DEPOT", "name": "Depot", "type": "garage"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(appRouter, "POST", "/api/v1/location", map[string]any{
+			"code": "POLE", "name": "Pole", "type": "store",
+			"position": map[string]any{"latitude": 91, "longitude": 0},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(appRouter, "GET", "/api/v1/location/LOC_MISSING", nil).Code)
+	})
+
+	t.Run("Transfers Move Stock Between Locations", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": mainID, "to_location_id": northID, "quantity": 4, "actor": "ops",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var response httpHandlers.MovementListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Movements, 2)
+
+		out, in := response.Movements[0], response.Movements[1]
+		assert.Equal(t, entities.MovementReasonTransfer, out.Reason)
+		assert.Equal(t, mainID, out.LocationID)
+		assert.Equal(t, -4, out.Change)
+		assert.Equal(t, 6, out.QuantityAfter)
+		assert.Equal(t, northID, in.LocationID)
+		assert.Equal(t, 4, in.QuantityAfter)
+		assert.Equal(t, out.ReferenceID, in.ReferenceID)
+
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": mainID, "to_location_id": southID, "quantity": 3,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		// The total does not change
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, 10, product.Quantity)
+		assert.Equal(t, map[string]int{mainID: 3, northID: 4, southID: 3}, stockByLocation(t, appRouter, productID))
+	})
+
+	t.Run("Transfers Cannot Overdraw A Location", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": northID, "to_location_id": southID, "quantity": 5,
+		})
+		require.Equal(t, http.StatusConflict, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.EqualValues(t, 4, response["available_quantity"])
+
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": northID, "to_location_id": northID, "quantity": 1,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": northID, "to_location_id": "LOC_MISSING", "quantity": 1,
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, map[string]int{mainID: 3, northID: 4, southID: 3}, stockByLocation(t, appRouter, productID))
+	})
+
+	var southOrderID string
+	t.Run("Order From A Chosen Location", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31001", "product_id": productID, "quantity": 2, "location_id": southID,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, southID, order.LocationID)
+		southOrderID = order.ID
+
+		sales, err := diContainer.GetProductUseCase().GetProductMovements(ctx, productID, entities.MovementReasonSale, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, sales, 1)
+		assert.Equal(t, southID, sales[0].LocationID)
+		assert.Equal(t, 1, sales[0].QuantityAfter)
+
+		// Plenty is left in total, but not at the chosen location
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31002", "product_id": productID, "quantity": 2, "location_id": southID,
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Most Stock Strategy Picks The Fullest Location", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31003", "product_id": productID, "quantity": 4,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, northID, order.LocationID)
+
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+order.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var stored httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+		assert.Equal(t, northID, stored.LocationID)
+
+		// No single location holds five any more
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31002", "product_id": productID, "quantity": 5,
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, map[string]int{mainID: 3, northID: 0, southID: 1}, stockByLocation(t, appRouter, productID))
+	})
+
+	t.Run("Cancelled Stock Goes Back Where It Shipped From", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+southOrderID+"/cancel", map[string]any{"reason": "Changed mind"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, map[string]int{mainID: 3, northID: 0, southID: 3}, stockByLocation(t, appRouter, productID))
+	})
+
+	t.Run("Low Stock Per Location And Overall", func(t *testing.T) {
+		levels := func(query string) []*entities.StockLevel {
+			w := doJSON(appRouter, "GET", "/api/v1/products/low-stock"+query, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var response httpHandlers.StockLevelListResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response.Levels
+		}
+
+		north := levels("?threshold=1&location_id=" + northID)
+		require.Len(t, north, 1)
+		assert.Equal(t, "Notebook", north[0].ProductName)
+		assert.Equal(t, 0, north[0].Quantity)
+		assert.Empty(t, levels("?threshold=1&location_id="+mainID))
+
+		assert.Empty(t, levels("?threshold=6"))
+		overall := levels("?threshold=7")
+		require.Len(t, overall, 1)
+		assert.Equal(t, 6, overall[0].Quantity)
+		assert.Empty(t, overall[0].LocationID)
+
+		assert.Equal(t, http.StatusNotFound, doJSON(appRouter, "GET", "/api/v1/products/low-stock?location_id=LOC_MISSING", nil).Code)
+	})
+
+	t.Run("Inventory Value Per Location And Overall", func(t *testing.T) {
+		value := func(query string) entities.Money {
+			w := doJSON(appRouter, "GET", "/api/v1/products/inventory-value"+query, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var response httpHandlers.InventoryValueResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response.Value
+		}
+
+		assert.Equal(t, money("15.00"), value("?location_id="+mainID))
+		assert.Equal(t, money("0.00"), value("?location_id="+northID))
+		assert.Equal(t, money("30.00"), value(""))
+	})
+
+	t.Run("Location Stock Listing", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/location/"+southID+"/stock", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response httpHandlers.StockLevelListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Levels, 1)
+		assert.Equal(t, productID, response.Levels[0].ProductID)
+		assert.Equal(t, 3, response.Levels[0].Quantity)
+	})
+}
+
+func TestNearestFulfilment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.FulfilmentStrategy = config.FulfilmentNearest
+	})
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Umbrella",
+		"price":        "12.00",
+		"quantity":     9,
+	})
+	for _, customer := range []*entities.Customer{
+		{ID: "CUST31004", Name: "Dee", Email: "dee@example.com", Phone: "+1000000017"},
+		{ID: "CUST31005", Name: "Eli", Email: "eli@example.com", Phone: "+1000000018"},
+		{ID: "CUST31006", Name: "Fay", Email: "fay@example.com", Phone: "+1000000019"},
+	} {
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+	}
+
+	main, err := diContainer.GetLocationRepository().GetDefault(ctx)
+	require.NoError(t, err)
+	londonID := createLocation(t, appRouter, map[string]any{
+		"code": "LDN", "name": "London", "type": "store",
+		"position": map[string]any{"latitude": 51.5074, "longitude": -0.1278},
+	})
+	parisID := createLocation(t, appRouter, map[string]any{
+		"code": "PAR", "name": "Paris", "type": "store",
+		"position": map[string]any{"latitude": 48.8566, "longitude": 2.3522},
+	})
+	for _, to := range []string{londonID, parisID} {
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": main.ID, "to_location_id": to, "quantity": 2,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	t.Run("Ships From The Closest Location", func(t *testing.T) {
+		// Versailles is closest to Paris
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31004", "product_id": productID, "quantity": 2,
+			"ship_to": map[string]any{"latitude": 48.8049, "longitude": 2.1204},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, parisID, order.LocationID)
+	})
+
+	t.Run("Skips Locations That Cannot Ship The Whole Order", func(t *testing.T) {
+		// Paris is empty now, so London is the closest that can ship
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31005", "product_id": productID, "quantity": 1,
+			"ship_to": map[string]any{"latitude": 48.8049, "longitude": 2.1204},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, londonID, order.LocationID)
+	})
+
+	t.Run("Without An Address The Fullest Location Ships", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31006", "product_id": productID, "quantity": 1,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, main.ID, order.LocationID)
+	})
+
+	t.Run("Invalid Address Is Rejected", func(t *testing.T) {
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, "CUST31006"))
+		code, _ := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST31006", "product_id": productID, "quantity": 1,
+			"ship_to": map[string]any{"latitude": 0, "longitude": 200},
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestHoldsAtSplitLocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+	})
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Lantern",
+		"price":        "18.00",
+		"quantity":     5,
+	})
+	customer := &entities.Customer{ID: "CUST31007", Name: "Gus", Email: "gus@example.com", Phone: "+1000000043"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	main, err := diContainer.GetLocationRepository().GetDefault(ctx)
+	require.NoError(t, err)
+	northID := createLocation(t, appRouter, map[string]any{"code": "NTH", "name": "North", "type": "store"})
+	w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+		"from_location_id": main.ID, "to_location_id": northID, "quantity": 2,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	reserve := func(t *testing.T, body map[string]any) *entities.StockReservation {
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/reservations", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var reservation entities.StockReservation
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reservation))
+		return &reservation
+	}
+
+	t.Run("A Hold Must Fit In One Location", func(t *testing.T) {
+		// Five are in stock, but split 3 and 2
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/reservations", map[string]any{"quantity": 5})
+		require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.EqualValues(t, 3, response["available_quantity"])
+
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/reservations", map[string]any{"quantity": 3, "location_id": northID})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	var mainHold, northHold *entities.StockReservation
+	t.Run("Holds Go Where Units Are Unheld", func(t *testing.T) {
+		mainHold = reserve(t, map[string]any{"customer_id": customer.ID, "quantity": 3})
+		assert.Equal(t, main.ID, mainHold.LocationID)
+		northHold = reserve(t, map[string]any{"customer_id": customer.ID, "quantity": 2})
+		assert.Equal(t, northID, northHold.LocationID)
+
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/stock", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response httpHandlers.StockLevelListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		for _, level := range response.Levels {
+			assert.Equal(t, level.Quantity, level.Reserved, level.LocationID)
+		}
+
+		// Held units cannot be moved away from their hold
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/transfers", map[string]any{
+			"from_location_id": main.ID, "to_location_id": northID, "quantity": 1,
+		})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	t.Run("Held Units Ship From Their Location", func(t *testing.T) {
+		code, _ := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": productID, "quantity": 5,
+			"reservation_ids": []string{mainHold.ID, northHold.ID},
+		})
+		assert.Equal(t, http.StatusBadRequest, code, "holds at two locations")
+
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": productID, "quantity": 3,
+			"reservation_ids": []string{mainHold.ID}, "location_id": northID,
+		})
+		assert.Equal(t, http.StatusBadRequest, code, "held somewhere else")
+
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": productID, "quantity": 3,
+			"reservation_ids": []string{mainHold.ID},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, main.ID, order.LocationID)
+
+		code, order = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": productID, "quantity": 2,
+			"reservation_ids": []string{northHold.ID},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, northID, order.LocationID)
+
+		assert.Equal(t, map[string]int{main.ID: 0, northID: 0}, stockByLocation(t, appRouter, productID))
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		assert.Zero(t, product.Quantity)
+		assert.Zero(t, product.Reserved)
+	})
+}
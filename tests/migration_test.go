@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"day5/internal/config"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The schema as first released, when amounts were decimal columns
+
+type baselineProduct struct {
+	ID          string    `gorm:"type:varchar(20);primaryKey;not null"`
+	ProductName string    `gorm:"type:varchar(255);not null;index"`
+	Price       float64   `gorm:"type:decimal(10,2);not null;check:price > 0"`
+	Quantity    int       `gorm:"not null;check:quantity >= 0;index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+}
+
+type baselineCustomer struct {
+	ID        string    `gorm:"type:varchar(20);primaryKey;not null"`
+	Name      string    `gorm:"type:varchar(255);not null;index"`
+	Email     string    `gorm:"type:varchar(255);unique;not null;index"`
+	Phone     string    `gorm:"type:varchar(20);not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+type baselineOrder struct {
+	ID          string    `gorm:"type:varchar(20);primaryKey;not null"`
+	CustomerID  string    `gorm:"type:varchar(20);not null;index"`
+	ProductID   string    `gorm:"type:varchar(20);not null;index"`
+	Quantity    int       `gorm:"not null;check:quantity > 0"`
+	UnitPrice   float64   `gorm:"type:decimal(10,2);not null;check:unit_price > 0"`
+	TotalAmount float64   `gorm:"type:decimal(10,2);not null;check:total_amount > 0"`
+	OrderDate   time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+
+	Customer baselineCustomer `gorm:"foreignKey:CustomerID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Product  baselineProduct  `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+type baselineTransaction struct {
+	ID            string    `gorm:"type:varchar(20);primaryKey;not null"`
+	OrderID       string    `gorm:"type:varchar(20);not null;index"`
+	CustomerID    string    `gorm:"type:varchar(20);not null;index"`
+	ProductID     string    `gorm:"type:varchar(20);not null;index"`
+	Type          string    `gorm:"type:varchar(20);not null;index;check:type IN ('order','refund','credit')"`
+	Amount        float64   `gorm:"type:decimal(10,2);not null;check:amount > 0"`
+	Quantity      int       `gorm:"not null;check:quantity > 0"`
+	UnitPrice     float64   `gorm:"type:decimal(10,2);not null;check:unit_price > 0"`
+	Description   string    `gorm:"type:text"`
+	TransactionAt time.Time `gorm:"not null;index"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+
+	Order    baselineOrder    `gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Customer baselineCustomer `gorm:"foreignKey:CustomerID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Product  baselineProduct  `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+type baselineCooldown struct {
+	CustomerID    string    `gorm:"type:varchar(20);primaryKey;not null"`
+	LastOrderTime time.Time `gorm:"not null;index"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselineProduct) TableName() string     { return "products" }
+func (baselineCustomer) TableName() string    { return "customers" }
+func (baselineOrder) TableName() string       { return "orders" }
+func (baselineTransaction) TableName() string { return "transactions" }
+func (baselineCooldown) TableName() string    { return "customer_cooldowns" }
+
+// seedBaselineDatabase creates a database file with the first released schema and a sale in it
+func seedBaselineDatabase(t *testing.T, path string) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	require.NoError(t, db.AutoMigrate(&baselineProduct{}, &baselineCustomer{}, &baselineOrder{}, &baselineTransaction{}, &baselineCooldown{}))
+
+	orderedAt := time.Now().UTC().Add(-48 * time.Hour)
+	require.NoError(t, db.Create(&baselineProduct{ID: "PROD10001", ProductName: "Desk Lamp", Price: 29.99, Quantity: 8}).Error)
+	require.NoError(t, db.Create(&baselineCustomer{ID: "CUST10001", Name: "Maya", Email: "maya@example.com", Phone: "+1000000041"}).Error)
+	require.NoError(t, db.Create(&baselineOrder{
+		ID: "ORD10001", CustomerID: "CUST10001", ProductID: "PROD10001",
+		Quantity: 2, UnitPrice: 29.99, TotalAmount: 59.98, OrderDate: orderedAt,
+	}).Error)
+	require.NoError(t, db.Create(&baselineTransaction{
+		ID: "TXN10001", OrderID: "ORD10001", CustomerID: "CUST10001", ProductID: "PROD10001",
+		Type: "order", Amount: 59.98, Quantity: 2, UnitPrice: 29.99, TransactionAt: orderedAt,
+	}).Error)
+}
+
+func TestUpgradeFromDecimalAmounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	path := filepath.Join(t.TempDir(), "baseline.db")
+	seedBaselineDatabase(t, path)
+
+	upgraded := func(t *testing.T) (http.Handler, *gorm.DB, func() error) {
+		diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+			cfg.Database.Name = path
+			cfg.Business.CooldownPeriodMinutes = 0
+		})
+		return httpHandlers.NewRouter(diContainer).SetupRoutes(), diContainer.GetDatabase().GetDB(), diContainer.Cleanup
+	}
+
+	appRouter, db, cleanup := upgraded(t)
+
+	t.Run("Amounts Move To Minor Units", func(t *testing.T) {
+		product := getProduct(t, appRouter, "PROD10001")
+		assert.Equal(t, money("29.99"), product.Price)
+
+		w := doJSON(appRouter, "GET", "/api/v1/order/ORD10001", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var order httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.Equal(t, money("29.99"), order.UnitPrice)
+		assert.Equal(t, money("59.98"), order.TotalAmount)
+
+		var ledger int64
+		require.NoError(t, db.Raw("SELECT SUM(amount_minor) FROM transactions WHERE currency = ?", "USD").Scan(&ledger).Error)
+		assert.Equal(t, int64(5998), ledger)
+
+		for table, columns := range map[string][]string{
+			"products":     {"price"},
+			"orders":       {"unit_price", "total_amount"},
+			"transactions": {"amount", "unit_price"},
+		} {
+			for _, column := range columns {
+				assert.False(t, db.Migrator().HasColumn(table, column), "%s.%s is dropped", table, column)
+			}
+		}
+	})
+
+	t.Run("New Columns Are Enforced", func(t *testing.T) {
+		err := db.Exec("INSERT INTO products (id, product_name, quantity, currency) VALUES ('PROD10002', 'No Price', 1, 'USD')").Error
+		assert.Error(t, err, "price_minor is NOT NULL")
+
+		err = db.Exec("INSERT INTO products (id, product_name, price_minor, quantity, currency) VALUES ('PROD10002', 'Free', 0, 1, 'USD')").Error
+		assert.Error(t, err, "price_minor must be positive")
+	})
+
+	t.Run("Upgraded Tables Take New Rows", func(t *testing.T) {
+		productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+			"product_name": "Desk Chair", "price": "120.00", "quantity": 3,
+		})
+
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": "CUST10001", "product_id": productID, "quantity": 1,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("120.00"), order.TotalAmount)
+	})
+
+	cleanup()
+
+	t.Run("Migrating Again Changes Nothing", func(t *testing.T) {
+		appRouter, _, cleanup := upgraded(t)
+		defer cleanup()
+
+		assert.Equal(t, money("29.99"), getProduct(t, appRouter, "PROD10001").Price)
+	})
+}
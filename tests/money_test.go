@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// money parses an amount in the default currency
+func money(amount string) entities.Money {
+	return entities.MustParseMoney(amount, "")
+}
+
+func TestMoney(t *testing.T) {
+	t.Run("Parse Is Exact", func(t *testing.T) {
+		m, err := entities.ParseMoney("29.99", "usd")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2999), m.Minor())
+		assert.Equal(t, "USD", m.Currency())
+		assert.Equal(t, "29.99 USD", m.String())
+
+		m, err = entities.ParseMoney("1500", "JPY")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1500), m.Minor())
+		assert.Equal(t, "1500", m.Amount())
+
+		m, err = entities.ParseMoney("1.250", "KWD")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1250), m.Minor())
+
+		m, err = entities.ParseMoney("-0.5", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, "-0.50", m.Amount())
+
+		for _, bad := range []string{"9.999", "1.5", "abc", "", "1e3", "--1"} {
+			currency := "USD"
+			if bad == "1.5" {
+				currency = "JPY"
+			}
+			_, err := entities.ParseMoney(bad, currency)
+			assert.ErrorIs(t, err, entities.ErrInvalidAmount, bad)
+		}
+	})
+
+	t.Run("Sums Do Not Drift", func(t *testing.T) {
+		var total entities.Money
+		for i := 0; i < 1000; i++ {
+			var err error
+			total, err = total.Add(entities.MustParseMoney("0.10", "USD"))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, entities.MustParseMoney("100", "USD"), total)
+
+		sum, err := entities.SumMoney(entities.MustParseMoney("0.10", "USD"), entities.MustParseMoney("0.20", "USD"))
+		require.NoError(t, err)
+		assert.Equal(t, "0.30", sum.Amount())
+	})
+
+	t.Run("Currencies Do Not Mix", func(t *testing.T) {
+		_, err := entities.MustParseMoney("1", "USD").Add(entities.MustParseMoney("1", "EUR"))
+		assert.True(t, errors.Is(err, entities.ErrCurrencyMismatch))
+
+		_, err = entities.MustParseMoney("1", "USD").Cmp(entities.MustParseMoney("1", "EUR"))
+		assert.True(t, errors.Is(err, entities.ErrCurrencyMismatch))
+	})
+
+	t.Run("Rounding Is Half To Even", func(t *testing.T) {
+		usd := func(minor int64) entities.Money { return entities.NewMoney(minor, "USD") }
+
+		assert.Equal(t, usd(2), usd(5).Div(2))   // 2.5 -> 2
+		assert.Equal(t, usd(4), usd(7).Div(2))   // 3.5 -> 4
+		assert.Equal(t, usd(-2), usd(-5).Div(2)) // -2.5 -> -2
+		assert.Equal(t, usd(33), usd(100).Div(3))
+		assert.Equal(t, usd(67), usd(200).Div(3))
+		assert.Equal(t, usd(1000), usd(1000).MulRat(3, 3))
+	})
+
+	t.Run("Products Do Not Wrap Around", func(t *testing.T) {
+		m, err := entities.MustParseMoney("19.99", "USD").Mul(3)
+		require.NoError(t, err)
+		assert.Equal(t, "59.97", m.Amount())
+
+		huge := entities.NewMoney(math.MaxInt64/2+1, "USD")
+		_, err = huge.Mul(2)
+		assert.ErrorIs(t, err, entities.ErrInvalidAmount)
+		_, err = huge.Neg().Mul(3)
+		assert.ErrorIs(t, err, entities.ErrInvalidAmount)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		data, err := json.Marshal(entities.MustParseMoney("1234.50", "USD"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"amount":"1234.50","currency":"USD"}`, string(data))
+
+		var m entities.Money
+		require.NoError(t, json.Unmarshal(data, &m))
+		assert.Equal(t, entities.MustParseMoney("1234.5", "USD"), m)
+
+		require.NoError(t, json.Unmarshal([]byte(`{"amount":19.99,"currency":"eur"}`), &m))
+		assert.Equal(t, entities.MustParseMoney("19.99", "EUR"), m)
+
+		// Bare numbers are in the default currency
+		require.NoError(t, json.Unmarshal([]byte(`0.1`), &m))
+		assert.Equal(t, money("0.10"), m)
+
+		assert.Error(t, json.Unmarshal([]byte(`0.001`), &m))
+		assert.Error(t, json.Unmarshal([]byte(`{"currency":"USD"}`), &m))
+	})
+}
+
+func TestMoneyEndToEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	assert.Equal(t, "USD", entities.DefaultCurrency())
+
+	t.Run("Invalid Prices Are Rejected", func(t *testing.T) {
+		for _, body := range []string{
+			`{"product_name":"Pen","price":9.999,"quantity":1}`,
+			`{"product_name":"Pen","price":0,"quantity":1}`,
+			`{"product_name":"Pen","price":-1,"quantity":1}`,
+			`{"product_name":"Pen","price":{"amount":"1.00","currency":"EUR"},"quantity":1}`,
+		} {
+			w := doJSON(appRouter, "POST", "/api/v1/product", json.RawMessage(body))
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+
+	dimeID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Dime Sweet",
+		"price":        0.10,
+		"quantity":     100,
+	})
+	twentyID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Twenty Sweet",
+		"price":        map[string]string{"amount": "0.20", "currency": "USD"},
+		"quantity":     100,
+	})
+	customer := &entities.Customer{ID: "CUST30501", Name: "Priya", Email: "priya@example.com", Phone: "+1000000007"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	t.Run("Analytics Sum Exactly", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			productID := dimeID
+			if i%2 == 1 {
+				productID = twentyID
+			}
+			w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+				CustomerID: customer.ID,
+				ProductID:  productID,
+				Quantity:   1,
+			})
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+			require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+		}
+
+		w := doJSON(appRouter, "GET", "/api/v1/transactions/stats", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"total_revenue":{"amount":"1.50","currency":"USD"}`)
+
+		var stats entities.BusinessStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, money("1.50"), stats.TotalRevenue)
+		assert.Equal(t, money("0.15"), stats.AverageOrderValue)
+		assert.Equal(t, 10, stats.OrderCount)
+
+		total, err := diContainer.GetOrderRepository().GetTotalRevenue(ctx, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, money("1.50"), total)
+
+		value, err := diContainer.GetProductRepository().GetTotalValue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, money("28.50"), value) // 95 x 0.10 + 95 x 0.20
+	})
+
+	t.Run("Totals Too Large Are Rejected", func(t *testing.T) {
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+		jetID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+			"product_name": "Private Jet",
+			"price":        "50000000000000000.00",
+			"quantity":     5,
+		})
+
+		w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID: customer.ID,
+			ProductID:  jetID,
+			Quantity:   2,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Equal(t, 5, getProduct(t, appRouter, jetID).Quantity)
+	})
+}
@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Desk Lamp",
+		Price:       money("40"),
+		Quantity:    10,
+	})
+	customer := &entities.Customer{ID: "CUST30001", Name: "Erin", Email: "erin@example.com", Phone: "+1000000001"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(context.Background(), customer))
+
+	var placed httpHandlers.OrderResponse
+	w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+		CustomerID: customer.ID,
+		ProductID:  productID,
+		Quantity:   1,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &placed))
+	assert.Equal(t, string(entities.OrderStatusPending), placed.Status)
+
+	t.Run("Complete Before Confirm Is Rejected", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+placed.ID+"/complete", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Filter By Status", func(t *testing.T) {
+		assert.Equal(t, 1, countOrders(t, appRouter, "/api/v1/orders?status=pending"))
+		assert.Equal(t, 0, countOrders(t, appRouter, "/api/v1/orders?status=confirmed"))
+		assert.Equal(t, 1, countOrders(t, appRouter, "/api/v1/orders/customer/"+customer.ID+"?status=pending"))
+
+		w := doJSON(appRouter, "GET", "/api/v1/orders?status=shipped", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Confirm Then Complete", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+placed.ID+"/confirm", usecases.OrderStatusRequest{
+			Actor:  "warehouse-1",
+			Reason: "picked",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/order/"+placed.ID+"/complete", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, string(entities.OrderStatusCompleted), response.Status)
+
+		assert.Equal(t, 1, countOrders(t, appRouter, "/api/v1/orders?status=completed"))
+		assert.Equal(t, 0, countOrders(t, appRouter, "/api/v1/orders?status=pending"))
+	})
+
+	t.Run("History Is Recorded", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+placed.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.StatusHistory, 3)
+		assert.Equal(t, entities.OrderStatusPending, response.StatusHistory[0].ToStatus)
+		assert.Equal(t, customer.ID, response.StatusHistory[0].Actor)
+		assert.Equal(t, entities.OrderStatusConfirmed, response.StatusHistory[1].ToStatus)
+		assert.Equal(t, "warehouse-1", response.StatusHistory[1].Actor)
+		assert.Equal(t, entities.OrderStatusCompleted, response.StatusHistory[2].ToStatus)
+	})
+
+	t.Run("Final Status Cannot Change", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+placed.ID+"/confirm", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Unknown Order", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/ORD99999/confirm", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// doJSON sends a request with an optional JSON body and returns the recorder
+func doJSON(appRouter http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != nil {
+		jsonData, _ := json.Marshal(body)
+		req, _ = http.NewRequest(method, path, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req, _ = http.NewRequest(method, path, nil)
+	}
+
+	w := httptest.NewRecorder()
+	appRouter.ServeHTTP(w, req)
+	return w
+}
+
+// countOrders returns the count reported by an order list endpoint
+func countOrders(t *testing.T, appRouter http.Handler, path string) int {
+	w := doJSON(appRouter, "GET", path, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.OrderHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response.Count
+}
+
+func TestOrderCancellation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	db := diContainer.GetDatabase().GetDB()
+	productRepo := diContainer.GetProductRepository()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Kettle",
+		Price:       money("25"),
+		Quantity:    10,
+	})
+	customers := []*entities.Customer{
+		{ID: "CUST30101", Name: "Finn", Email: "finn@example.com", Phone: "+1000000002"},
+		{ID: "CUST30102", Name: "Gail", Email: "gail@example.com", Phone: "+1000000003"},
+	}
+	for _, customer := range customers {
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+	}
+
+	placeOrder := func(customerID string, quantity int) string {
+		w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID: customerID,
+			ProductID:  productID,
+			Quantity:   quantity,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var response httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.ID
+	}
+
+	t.Run("Cancel Restocks And Refunds", func(t *testing.T) {
+		orderID := placeOrder(customers[0].ID, 3)
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", usecases.CancelOrderRequest{
+			OrderStatusRequest: usecases.OrderStatusRequest{Actor: customers[0].ID, Reason: "changed my mind"},
+			ClearCooldown:      true,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, string(entities.OrderStatusCancelled), response.Status)
+
+		product, err := productRepo.GetByID(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, 10, product.Quantity)
+
+		var refunds int64
+		require.NoError(t, db.Table("transactions").
+			Where("order_id = ? AND type = ?", orderID, "refund").Count(&refunds).Error)
+		assert.Equal(t, int64(1), refunds)
+
+		// The cleared cooldown lets the customer order again straight away
+		placeOrder(customers[0].ID, 1)
+	})
+
+	t.Run("Cancelled Order Cannot Be Cancelled Again", func(t *testing.T) {
+		orders, err := diContainer.GetOrderRepository().GetByCustomerID(ctx, customers[0].ID, 10, 0)
+		require.NoError(t, err)
+
+		for _, order := range orders {
+			if order.Status == entities.OrderStatusCancelled {
+				w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/cancel", nil)
+				assert.Equal(t, http.StatusConflict, w.Code)
+			}
+		}
+	})
+
+	t.Run("Window Has Closed", func(t *testing.T) {
+		orderID := placeOrder(customers[1].ID, 2)
+		require.NoError(t, db.Table("orders").Where("id = ?", orderID).
+			Update("order_date", time.Now().UTC().Add(-31*time.Minute)).Error)
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		// Nothing was restocked or refunded
+		product, err := productRepo.GetByID(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, 7, product.Quantity)
+
+		var refunds int64
+		require.NoError(t, db.Table("transactions").
+			Where("order_id = ? AND type = ?", orderID, "refund").Count(&refunds).Error)
+		assert.Zero(t, refunds)
+	})
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// priceHistory fetches a product's price history
+func priceHistory(t *testing.T, appRouter http.Handler, productID string) []*entities.PricePeriod {
+	w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/price-history", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.PriceHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, productID, response.ProductID)
+	require.Len(t, response.Prices, response.Count)
+	return response.Prices
+}
+
+func TestPriceHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	pricingUseCase := diContainer.GetPricingUseCase()
+
+	// dueNow stands in for a price scheduled earlier whose time has come
+	dueNow := func(productID, price string, from time.Time) {
+		require.NoError(t, diContainer.GetProductPriceRepository().Record(ctx, &entities.ProductPrice{
+			ProductID: productID, Price: money(price), EffectiveFrom: from, CreatedAt: from,
+		}))
+	}
+
+	lampID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Desk lamp", "price": "40.00", "quantity": 50,
+	})
+
+	t.Run("Price Changes Are Recorded", func(t *testing.T) {
+		history := priceHistory(t, appRouter, lampID)
+		require.Len(t, history, 1)
+		assert.Equal(t, money("40.00"), history[0].Price)
+		assert.NotNil(t, history[0].ActivatedAt)
+		assert.Nil(t, history[0].EffectiveTo)
+
+		customer := &entities.Customer{ID: "CUST31407", Name: "Lamp buyer", Email: "lamp1@example.com", Phone: "+1000000032"}
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+		code, _ := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": lampID, "quantity": 2,
+		})
+		require.Equal(t, http.StatusCreated, code)
+
+		w := updateProduct(t, appRouter, lampID, map[string]any{"price": "45.00", "actor": "pricing-team"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		history = priceHistory(t, appRouter, lampID)
+		require.Len(t, history, 2)
+		require.NotNil(t, history[0].EffectiveTo)
+		assert.True(t, history[0].EffectiveTo.Equal(history[1].EffectiveFrom), "each price ends where the next starts")
+		assert.Equal(t, 2, history[0].UnitsSold)
+		assert.Equal(t, money("80.00"), history[0].Revenue)
+		assert.Equal(t, money("45.00"), history[1].Price)
+		assert.Equal(t, "pricing-team", history[1].Actor)
+		assert.Zero(t, history[1].UnitsSold)
+	})
+
+	t.Run("Future Prices Wait For Their Time", func(t *testing.T) {
+		monday := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+lampID+"/scheduled-prices", map[string]any{
+			"price": "50.00", "effective_from": monday,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var scheduled entities.ProductPrice
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scheduled))
+		assert.True(t, scheduled.IsScheduled())
+
+		assert.Equal(t, money("45.00"), getProduct(t, appRouter, lampID).Price)
+		history := priceHistory(t, appRouter, lampID)
+		require.Len(t, history, 3)
+		require.NotNil(t, history[1].EffectiveTo)
+		assert.True(t, history[1].EffectiveTo.Equal(monday), "the current price is planned to end on Monday")
+
+		activated, err := pricingUseCase.ActivateScheduledPrices(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, activated, "Monday has not come yet")
+
+		for _, body := range []map[string]any{
+			{"price": "50.00", "effective_from": time.Now().UTC().Add(-time.Hour)},
+			{"price": "0.00", "effective_from": monday.Add(time.Hour)},
+			{"price": "50.00"},
+		} {
+			w = doJSON(appRouter, "POST", "/api/v1/product/"+lampID+"/scheduled-prices", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%v: %s", body, w.Body.String())
+		}
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+lampID+"/scheduled-prices", map[string]any{
+			"price": "55.00", "effective_from": monday,
+		})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		w = doJSON(appRouter, "POST", "/api/v1/product/PROD_MISSING/scheduled-prices", map[string]any{
+			"price": "55.00", "effective_from": monday,
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+		// Cancelling puts the current price back to until further notice
+		path := "/api/v1/product/" + lampID + "/scheduled-prices/" + fmt.Sprint(scheduled.ID)
+		w = doJSON(appRouter, "DELETE", path, nil)
+		require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+		history = priceHistory(t, appRouter, lampID)
+		require.Len(t, history, 2)
+		assert.Nil(t, history[1].EffectiveTo)
+
+		w = doJSON(appRouter, "DELETE", path, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+		w = doJSON(appRouter, "DELETE", "/api/v1/product/"+lampID+"/scheduled-prices/"+fmt.Sprint(history[0].ID), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "an activated price is history: %s", w.Body.String())
+	})
+
+	t.Run("The Scheduler Activates Due Prices", func(t *testing.T) {
+		dueNow(lampID, "48.00", time.Now().UTC())
+
+		activated, err := pricingUseCase.ActivateScheduledPrices(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, activated)
+		assert.Equal(t, money("48.00"), getProduct(t, appRouter, lampID).Price)
+
+		history := priceHistory(t, appRouter, lampID)
+		require.Len(t, history, 3)
+		assert.NotNil(t, history[2].ActivatedAt)
+		assert.True(t, history[1].EffectiveTo.Equal(history[2].EffectiveFrom))
+
+		activated, err = pricingUseCase.ActivateScheduledPrices(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, activated)
+	})
+
+	t.Run("A Price Overtaken By A Later Change Is Not Applied", func(t *testing.T) {
+		history := priceHistory(t, appRouter, lampID)
+		dueNow(lampID, "30.00", history[2].EffectiveFrom.Add(-time.Millisecond))
+
+		activated, err := pricingUseCase.ActivateScheduledPrices(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, activated)
+		assert.Equal(t, money("48.00"), getProduct(t, appRouter, lampID).Price)
+	})
+
+	t.Run("Variants Following A Parent Share Its Changes", func(t *testing.T) {
+		shadeID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+			"product_name": "Lamp shade", "price": "20.00", "type": "parent",
+		})
+		linenID := postJSON(t, appRouter, "/api/v1/product/"+shadeID+"/variants", map[string]any{
+			"attributes": map[string]string{"material": "linen"}, "quantity": 5,
+		})
+		silkID := postJSON(t, appRouter, "/api/v1/product/"+shadeID+"/variants", map[string]any{
+			"attributes": map[string]string{"material": "silk"}, "price": "35.00", "quantity": 5,
+		})
+
+		dueNow(shadeID, "22.00", time.Now().UTC())
+		activated, err := pricingUseCase.ActivateScheduledPrices(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, activated)
+
+		assert.Equal(t, money("22.00"), getProduct(t, appRouter, linenID).Price)
+		history := priceHistory(t, appRouter, linenID)
+		require.Len(t, history, 2)
+		assert.Equal(t, money("22.00"), history[1].Price)
+		assert.Len(t, priceHistory(t, appRouter, silkID), 1, "a variant with its own price keeps it")
+	})
+
+	w := doJSON(appRouter, "GET", "/api/v1/product/PROD_MISSING/price-history", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
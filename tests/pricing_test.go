@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quotePrice fetches a price quote for a product
+func quotePrice(t *testing.T, appRouter http.Handler, productID, query string) entities.PriceQuote {
+	w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/price"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var quote entities.PriceQuote
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quote))
+	return quote
+}
+
+func TestPriceLists(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	now := time.Now().UTC()
+	paperID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Paper ream", "price": "10.00", "quantity": 100,
+	})
+	retailID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Asha", "email": "asha@example.com", "phone": "+1000000029",
+	})
+	wholesaleID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Stationers Ltd", "email": "buying@stationers.example.com", "phone": "+1000000030",
+		"price_list": "wholesale",
+	})
+
+	bulkID := postJSON(t, appRouter, "/api/v1/price-rule", map[string]any{
+		"name": "10+ units", "min_quantity": 10, "discount_percent": "8",
+	})
+	wholesaleRuleID := postJSON(t, appRouter, "/api/v1/price-rule", map[string]any{
+		"name": "Trade price", "price_list": "wholesale", "product_id": paperID, "price": "8.50",
+	})
+	saleID := postJSON(t, appRouter, "/api/v1/price-rule", map[string]any{
+		"name": "Back to school", "product_id": paperID, "discount_percent": 5,
+		"starts_at": now.Add(-time.Hour), "ends_at": now.Add(24 * time.Hour),
+	})
+	postJSON(t, appRouter, "/api/v1/price-rule", map[string]any{
+		"name": "Clearance", "product_id": paperID, "discount_percent": "50",
+		"starts_at": now.Add(7 * 24 * time.Hour),
+	})
+
+	t.Run("Rules Are Validated", func(t *testing.T) {
+		for _, body := range []map[string]any{
+			{"name": "Both", "product_id": paperID, "price": "9.00", "discount_percent": "5"},
+			{"name": "Neither", "product_id": paperID},
+			{"name": "Fixed for everything", "price": "9.00"},
+			{"name": "Free", "discount_percent": "100"},
+			{"name": "Too precise", "discount_percent": "5.125"},
+			{"name": "Backwards", "discount_percent": "5", "starts_at": now, "ends_at": now.Add(-time.Hour)},
+			{"name": "Members", "discount_percent": "5", "price_list": "members"},
+		} {
+			w := doJSON(appRouter, "POST", "/api/v1/price-rule", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%v: %s", body["name"], w.Body.String())
+		}
+
+		w := doJSON(appRouter, "POST", "/api/v1/price-rule", map[string]any{
+			"name": "Ghost", "product_id": "PROD_MISSING", "discount_percent": "5",
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/customer", map[string]any{
+			"name": "Odd", "email": "odd@example.com", "phone": "+1000000031", "price_list": "members",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "GET", "/api/v1/price-rules?product_id="+paperID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response httpHandlers.PriceRuleListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Count, "the catalogue-wide rule belongs to no product")
+	})
+
+	t.Run("Quotes Take The Lowest Price That Applies", func(t *testing.T) {
+		quote := quotePrice(t, appRouter, paperID, "?customer_id="+retailID)
+		assert.Equal(t, entities.PriceListRetail, quote.PriceList)
+		assert.Equal(t, money("10.00"), quote.ListPrice)
+		assert.Equal(t, money("9.50"), quote.UnitPrice, "the sale is on, the clearance not yet")
+		assert.Equal(t, saleID, quote.PriceRuleID)
+		assert.Equal(t, "Back to school", quote.PriceRuleName)
+
+		quote = quotePrice(t, appRouter, paperID, "?customer_id="+retailID+"&quantity=10")
+		assert.Equal(t, money("9.20"), quote.UnitPrice)
+		assert.Equal(t, money("92.00"), quote.Total)
+		assert.Equal(t, bulkID, quote.PriceRuleID)
+
+		quote = quotePrice(t, appRouter, paperID, "?customer_id="+wholesaleID+"&quantity=10")
+		assert.Equal(t, entities.PriceListWholesale, quote.PriceList)
+		assert.Equal(t, money("8.50"), quote.UnitPrice)
+		assert.Equal(t, wholesaleRuleID, quote.PriceRuleID)
+
+		quote = quotePrice(t, appRouter, paperID, "")
+		assert.Equal(t, 1, quote.Quantity)
+		assert.Equal(t, money("9.50"), quote.UnitPrice, "without a customer the retail price list applies")
+
+		// Carts show the same prices
+		w := doJSON(appRouter, "POST", "/api/v1/customer/"+wholesaleID+"/cart/items", map[string]any{
+			"product_id": paperID, "quantity": 3,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var cart entities.Cart
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cart))
+		require.Len(t, cart.Items, 1)
+		assert.Equal(t, money("8.50"), cart.Items[0].UnitPrice)
+		assert.Equal(t, money("25.50"), cart.TotalAmount)
+		assert.Equal(t, wholesaleRuleID, cart.Items[0].PriceRuleID)
+		doJSON(appRouter, "DELETE", "/api/v1/customer/"+wholesaleID+"/cart", nil)
+
+		w = doJSON(appRouter, "GET", "/api/v1/product/"+paperID+"/price?quantity=0", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		w = doJSON(appRouter, "GET", "/api/v1/product/"+paperID+"/price?customer_id=CUST_MISSING", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	})
+
+	t.Run("Orders Record The Rule That Priced Them", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": retailID, "product_id": paperID, "quantity": 12,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("9.20"), order.UnitPrice)
+		assert.Equal(t, money("110.40"), order.TotalAmount)
+
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+order.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		require.Len(t, order.Lines, 1)
+		assert.Equal(t, money("10.00"), order.Lines[0].ListPrice)
+		assert.Equal(t, money("9.20"), order.Lines[0].UnitPrice)
+		assert.Equal(t, bulkID, order.Lines[0].PriceRuleID)
+
+		assert.Equal(t, money("110.40"), topSellers(t, appRouter)[paperID].TotalRevenue)
+
+		code, order = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": wholesaleID, "product_id": paperID, "quantity": 2,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("17.00"), order.TotalAmount)
+		assert.Equal(t, wholesaleRuleID, order.Lines[0].PriceRuleID)
+	})
+
+	t.Run("Ended Rules Stop Applying", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/price-rule/"+saleID+"/end", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		quote := quotePrice(t, appRouter, paperID, "?customer_id="+retailID)
+		assert.Equal(t, money("10.00"), quote.UnitPrice)
+		assert.Empty(t, quote.PriceRuleID)
+
+		w = doJSON(appRouter, "POST", "/api/v1/price-rule/"+saleID+"/end", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
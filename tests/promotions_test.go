@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// promotionStats fetches the all-time promotion statistics keyed by promotion ID
+func promotionStats(t *testing.T, appRouter http.Handler) map[string]*entities.PromotionStats {
+	w := doJSON(appRouter, "GET", "/api/v1/transactions/stats/promotions", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.PromotionStatsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	stats := make(map[string]*entities.PromotionStats, len(response.Promotions))
+	for _, promotion := range response.Promotions {
+		stats[promotion.PromotionID] = promotion
+	}
+	return stats
+}
+
+func TestPromotions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+	})
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	now := time.Now().UTC()
+	stationery := createCategory(t, appRouter, "Stationery", "")
+	pens := createCategory(t, appRouter, "Pens", stationery.ID)
+	penID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Gel pen", "price": "2.00", "quantity": 100, "category_id": pens.ID,
+	})
+	notebookID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Notebook", "price": "5.00", "quantity": 100, "category_id": stationery.ID,
+	})
+	mugID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Mug", "price": "10.00", "quantity": 100,
+	})
+	customerID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Priya", "email": "priya@example.com", "phone": "+1000000033",
+	})
+	otherID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Tomas", "email": "tomas@example.com", "phone": "+1000000034",
+	})
+
+	saleID := postJSON(t, appRouter, "/api/v1/promotion", map[string]any{
+		"name": "Stationery week", "type": "percent_off", "discount_percent": "10", "category_id": stationery.ID,
+	})
+	mugDealID := postJSON(t, appRouter, "/api/v1/promotion", map[string]any{
+		"name": "Mugs 3 for 2", "type": "buy_x_get_y", "buy_quantity": 2, "get_quantity": 1, "product_id": mugID,
+	})
+	welcomeID := postJSON(t, appRouter, "/api/v1/promotion", map[string]any{
+		"name": "Welcome", "type": "amount_off", "amount": "5.00", "coupon_only": true,
+	})
+	postJSON(t, appRouter, "/api/v1/promotion", map[string]any{
+		"name": "Next month", "type": "percent_off", "discount_percent": "50",
+		"starts_at": now.Add(30 * 24 * time.Hour),
+	})
+
+	postJSON(t, appRouter, "/api/v1/promotion/"+welcomeID+"/coupons", map[string]any{
+		"code": "welcome5", "max_per_customer": 1,
+	})
+	postJSON(t, appRouter, "/api/v1/promotion/"+welcomeID+"/coupons", map[string]any{
+		"code": "ONE-OFF", "max_redemptions": 1,
+	})
+	postJSON(t, appRouter, "/api/v1/promotion/"+welcomeID+"/coupons", map[string]any{
+		"code": "LASTYEAR", "expires_at": now.Add(-time.Hour),
+	})
+
+	t.Run("Promotions And Coupons Are Validated", func(t *testing.T) {
+		for _, body := range []map[string]any{
+			{"name": "No type", "discount_percent": "10"},
+			{"name": "Everything free", "type": "percent_off", "discount_percent": "100"},
+			{"name": "No amount", "type": "amount_off"},
+			{"name": "Negative", "type": "amount_off", "amount": "-1.00"},
+			{"name": "Buy nothing", "type": "buy_x_get_y", "get_quantity": 1},
+			{"name": "Mixed", "type": "percent_off", "discount_percent": "10", "buy_quantity": 2},
+			{"name": "Both", "type": "percent_off", "discount_percent": "10", "product_id": mugID, "category_id": pens.ID},
+			{"name": "Backwards", "type": "percent_off", "discount_percent": "10", "starts_at": now, "ends_at": now.Add(-time.Hour)},
+		} {
+			w := doJSON(appRouter, "POST", "/api/v1/promotion", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%v: %s", body["name"], w.Body.String())
+		}
+
+		w := doJSON(appRouter, "POST", "/api/v1/promotion", map[string]any{
+			"name": "Ghost", "type": "percent_off", "discount_percent": "10", "product_id": "PROD_MISSING",
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/promotion/"+saleID+"/coupons", map[string]any{"code": "SALE10"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "automatic promotions take no coupons: %s", w.Body.String())
+		w = doJSON(appRouter, "POST", "/api/v1/promotion/"+welcomeID+"/coupons", map[string]any{"code": "no spaces"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		w = doJSON(appRouter, "POST", "/api/v1/promotion/"+welcomeID+"/coupons", map[string]any{"code": "Welcome5"})
+		assert.Equal(t, http.StatusConflict, w.Code, "codes are not case-sensitive: %s", w.Body.String())
+
+		w = doJSON(appRouter, "GET", "/api/v1/promotion/"+welcomeID+"/coupons", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var coupons httpHandlers.CouponListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &coupons))
+		require.Equal(t, 3, coupons.Count)
+		assert.Equal(t, "WELCOME5", coupons.Coupons[0].Code)
+	})
+
+	t.Run("Automatic Promotions Discount The Lines They Cover", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": otherID,
+			"items": []map[string]any{
+				{"product_id": penID, "quantity": 5},
+				{"product_id": mugID, "quantity": 3},
+			},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("11.00"), order.Discount, "10% off the pens in a subcategory and one mug free")
+		assert.Equal(t, money("29.00"), order.TotalAmount)
+		assert.Empty(t, order.CouponCode)
+
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+order.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		require.Len(t, order.Lines, 2)
+		assert.Equal(t, money("1.00"), order.Lines[0].Discount)
+		assert.Equal(t, money("9.00"), order.Lines[0].LineTotal)
+		assert.Equal(t, money("10.00"), order.Lines[1].Discount)
+		assert.Equal(t, money("20.00"), order.Lines[1].LineTotal)
+		assert.Equal(t, money("11.00"), order.Discount)
+
+		w = doJSON(appRouter, "GET", "/api/v1/transactions?customer_id="+otherID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var history httpHandlers.TransactionHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Equal(t, 2, history.Count)
+		discounts := map[string]entities.Money{}
+		for _, transaction := range history.Transactions {
+			discounts[transaction.ProductID] = transaction.Discount
+		}
+		assert.Equal(t, money("1.00"), discounts[penID])
+		assert.Equal(t, money("10.00"), discounts[mugID])
+		assert.Equal(t, money("29.00"), history.TotalAmount)
+	})
+
+	var welcomeOrderID string
+	t.Run("Coupons Apply On Top Within Their Limits", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": notebookID, "quantity": 2, "coupon_code": " welcome5 ",
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "WELCOME5", order.CouponCode)
+		assert.Equal(t, money("6.00"), order.Discount, "10% off, then 5.00 off what is left")
+		assert.Equal(t, money("4.00"), order.TotalAmount)
+		welcomeOrderID = order.ID
+
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": notebookID, "quantity": 2, "coupon_code": "WELCOME5",
+		})
+		assert.Equal(t, http.StatusBadRequest, code, "once per customer")
+
+		for _, coupon := range []string{"LASTYEAR", "NOSUCHCODE"} {
+			code, _ = placeOrderAt(t, appRouter, map[string]any{
+				"customer_id": otherID, "product_id": notebookID, "quantity": 2, "coupon_code": coupon,
+			})
+			assert.Equal(t, http.StatusBadRequest, code, coupon)
+		}
+
+		code, order = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": otherID, "product_id": penID, "quantity": 1, "coupon_code": "ONE-OFF",
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("0.01"), order.TotalAmount, "a fixed amount never takes a line below one cent")
+		assert.Equal(t, money("1.99"), order.Discount)
+
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": penID, "quantity": 1, "coupon_code": "ONE-OFF",
+		})
+		assert.Equal(t, http.StatusBadRequest, code, "single-use coupons are used up")
+	})
+
+	t.Run("Analytics Report Discount Cost Per Promotion", func(t *testing.T) {
+		stats := promotionStats(t, appRouter)
+		require.Len(t, stats, 3)
+		assert.Equal(t, 3, stats[saleID].Redemptions)
+		assert.Equal(t, 2, stats[saleID].Customers)
+		assert.Equal(t, money("2.20"), stats[saleID].DiscountCost)
+		assert.Equal(t, 1, stats[mugDealID].Redemptions)
+		assert.Equal(t, money("10.00"), stats[mugDealID].DiscountCost)
+		assert.Equal(t, 2, stats[welcomeID].Redemptions)
+		assert.Equal(t, money("6.79"), stats[welcomeID].DiscountCost)
+
+		w := doJSON(appRouter, "GET", "/api/v1/transactions/stats/promotions?period=fortnight", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Cancelling Gives The Coupon Back", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+welcomeOrderID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		stats := promotionStats(t, appRouter)
+		assert.Equal(t, 1, stats[welcomeID].Redemptions)
+		assert.Equal(t, money("1.79"), stats[welcomeID].DiscountCost)
+
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": mugID, "quantity": 1, "coupon_code": "WELCOME5",
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("5.00"), order.TotalAmount)
+	})
+
+	t.Run("Ended Promotions Stop Applying", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/promotion/"+welcomeID+"/end", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		code, _ := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": otherID, "product_id": mugID, "quantity": 1, "coupon_code": "WELCOME5",
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		w = doJSON(appRouter, "POST", "/api/v1/promotion/"+welcomeID+"/end", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
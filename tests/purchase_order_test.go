@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSupplier adds a supplier through the API and returns its ID
+func createSupplier(t *testing.T, appRouter http.Handler, body map[string]any) string {
+	w := doJSON(appRouter, "POST", "/api/v1/supplier", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var supplier entities.Supplier
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &supplier))
+	return supplier.ID
+}
+
+// purchaseOrderCall sends a purchase order request and decodes a successful response
+func purchaseOrderCall(t *testing.T, appRouter http.Handler, method, path string, body any) (int, entities.PurchaseOrder) {
+	w := doJSON(appRouter, method, path, body)
+
+	var purchaseOrder entities.PurchaseOrder
+	if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &purchaseOrder))
+	}
+	return w.Code, purchaseOrder
+}
+
+func TestPurchaseOrders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	supplierID := createSupplier(t, appRouter, map[string]any{
+		"name": "Paper Mill Ltd", "email": "orders@papermill.example", "lead_time_days": 7,
+	})
+	paperID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Paper", "price": "5.00", "quantity": 1, "supplier_id": supplierID,
+	})
+	inkID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Ink", "price": "9.00", "quantity": 1, "lead_time_days": 14,
+	})
+
+	t.Run("Suppliers", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/supplier/"+supplierID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var supplier entities.Supplier
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &supplier))
+		assert.Equal(t, "Paper Mill Ltd", supplier.Name)
+		assert.Equal(t, 7, supplier.LeadTimeDays)
+
+		w = doJSON(appRouter, "GET", "/api/v1/suppliers", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list httpHandlers.SupplierListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, 1, list.Count)
+
+		w = doJSON(appRouter, "POST", "/api/v1/supplier", map[string]any{"name": "Nameless", "email": "nope"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusNotFound, doJSON(appRouter, "GET", "/api/v1/supplier/SUP_MISSING", nil).Code)
+
+		w = doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Orphan", "price": "1.00", "quantity": 1, "supplier_id": "SUP_MISSING",
+		})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	var purchaseOrderID string
+	t.Run("Drafts Are Edited Until Sent", func(t *testing.T) {
+		code, purchaseOrder := purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order", map[string]any{
+			"supplier_id": supplierID,
+			"lines": []map[string]any{
+				{"product_id": paperID, "quantity": 10, "unit_cost": "2.00"},
+				{"product_id": inkID, "quantity": 5},
+			},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		purchaseOrderID = purchaseOrder.ID
+		assert.Equal(t, entities.PurchaseOrderStatusDraft, purchaseOrder.Status)
+		assert.Equal(t, money("20.00"), purchaseOrder.Total)
+		require.Len(t, purchaseOrder.Lines, 2)
+		assert.Equal(t, "Paper", purchaseOrder.Lines[0].ProductName)
+
+		// Ink has no unit cost yet
+		code, _ = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/send", nil)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/receive", nil)
+		assert.Equal(t, http.StatusConflict, code, "a draft cannot be received")
+
+		code, purchaseOrder = purchaseOrderCall(t, appRouter, "PUT", "/api/v1/purchase-order/"+purchaseOrderID, map[string]any{
+			"lines": []map[string]any{
+				{"product_id": paperID, "quantity": 10, "unit_cost": "2.00"},
+				{"product_id": inkID, "quantity": 5, "unit_cost": "4.00"},
+			},
+			"note": "Rush please",
+		})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, money("40.00"), purchaseOrder.Total)
+		assert.Equal(t, "Rush please", purchaseOrder.Note)
+
+		code, purchaseOrder = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/send", nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, entities.PurchaseOrderStatusSent, purchaseOrder.Status)
+		require.NotNil(t, purchaseOrder.SentAt)
+		require.NotNil(t, purchaseOrder.ExpectedAt)
+		// Ink's own lead time is longer than the supplier's
+		assert.WithinDuration(t, purchaseOrder.SentAt.Add(14*24*time.Hour), *purchaseOrder.ExpectedAt, time.Second)
+
+		code, _ = purchaseOrderCall(t, appRouter, "PUT", "/api/v1/purchase-order/"+purchaseOrderID, map[string]any{"note": "Too late"})
+		assert.Equal(t, http.StatusConflict, code)
+		code, _ = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order", map[string]any{
+			"supplier_id": supplierID,
+			"lines":       []map[string]any{{"product_id": paperID, "quantity": 1, "unit_cost": "-1.00"}},
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Partial Receipt Adds Stock At Landed Cost", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/receive", map[string]any{
+			"lines": []map[string]any{
+				{"product_id": paperID, "quantity": 10},
+				{"product_id": inkID, "quantity": 2},
+			},
+			"additional_cost": "1.40",
+			"actor":           "dock",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var receipt entities.GoodsReceipt
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &receipt))
+		require.Len(t, receipt.Lines, 2)
+
+		// The 1.40 is split 20.00 : 8.00 by line value
+		assert.Equal(t, money("2.00"), receipt.Lines[0].UnitCost)
+		assert.Equal(t, money("2.10"), receipt.Lines[0].LandedUnitCost)
+		assert.Equal(t, money("4.20"), receipt.Lines[1].LandedUnitCost)
+
+		purchaseOrder, err := diContainer.GetPurchaseOrderUseCase().GetPurchaseOrder(ctx, purchaseOrderID)
+		require.NoError(t, err)
+		assert.Equal(t, entities.PurchaseOrderStatusPartiallyReceived, purchaseOrder.Status)
+		assert.Equal(t, 3, purchaseOrder.FindLine(inkID).Outstanding())
+
+		paper, err := diContainer.GetProductRepository().GetByID(ctx, paperID)
+		require.NoError(t, err)
+		assert.Equal(t, 11, paper.Quantity)
+
+		ledger, err := diContainer.GetProductUseCase().GetProductMovements(ctx, inkID, entities.MovementReasonReceipt, 0, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, ledger)
+		assert.Equal(t, receipt.ID, ledger[0].ReferenceID)
+		assert.Equal(t, "dock", ledger[0].Actor)
+		assert.Equal(t, 2, ledger[0].Change)
+	})
+
+	t.Run("Cannot Receive More Than Outstanding", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/receive", map[string]any{
+			"lines": []map[string]any{{"product_id": inkID, "quantity": 4}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = doJSON(appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/receive", map[string]any{
+			"lines": []map[string]any{{"product_id": "PRD_MISSING", "quantity": 1}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		ink, err := diContainer.GetProductRepository().GetByID(ctx, inkID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, ink.Quantity, "rejected receipts must not add stock")
+	})
+
+	t.Run("Empty Receipt Takes Everything Outstanding", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/receive", nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		code, purchaseOrder := purchaseOrderCall(t, appRouter, "GET", "/api/v1/purchase-order/"+purchaseOrderID, nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, entities.PurchaseOrderStatusReceived, purchaseOrder.Status)
+		assert.NotNil(t, purchaseOrder.ReceivedAt)
+
+		ink, err := diContainer.GetProductRepository().GetByID(ctx, inkID)
+		require.NoError(t, err)
+		assert.Equal(t, 6, ink.Quantity)
+
+		code, _ = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order/"+purchaseOrderID+"/receive", nil)
+		assert.Equal(t, http.StatusConflict, code)
+
+		w = doJSON(appRouter, "GET", "/api/v1/purchase-order/"+purchaseOrderID+"/receipts", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var receipts httpHandlers.ReceiptListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &receipts))
+		assert.Equal(t, 2, receipts.Count)
+
+		w = doJSON(appRouter, "GET", "/api/v1/purchase-orders?status=received&supplier_id="+supplierID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var list httpHandlers.PurchaseOrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Equal(t, 1, list.Count)
+		assert.Equal(t, http.StatusBadRequest, doJSON(appRouter, "GET", "/api/v1/purchase-orders?status=lost", nil).Code)
+	})
+}
+
+func TestReorderSuggestions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	supplierID := createSupplier(t, appRouter, map[string]any{"name": "Tea Traders", "lead_time_days": 10})
+	teaID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Tea", "price": "3.00", "quantity": 5,
+		"reorder_point": 10, "reorder_quantity": 5, "supplier_id": supplierID,
+	})
+	mugID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Mug", "price": "6.00", "quantity": 2, "reorder_point": 4,
+	})
+	postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Spoon", "price": "1.00", "quantity": 50, "reorder_point": 10, "supplier_id": supplierID,
+	})
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, &entities.Customer{
+		ID: "CUST31101", Name: "Gus", Email: "gus@example.com", Phone: "+1000000020",
+	}))
+
+	// Earlier tea went for 2.50 a box
+	code, earlier := purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order", map[string]any{
+		"supplier_id": supplierID,
+		"lines":       []map[string]any{{"product_id": teaID, "quantity": 3, "unit_cost": "2.50"}},
+	})
+	require.Equal(t, http.StatusCreated, code)
+	code, _ = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order/"+earlier.ID+"/send", nil)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = purchaseOrderCall(t, appRouter, "POST", "/api/v1/purchase-order/"+earlier.ID+"/receive", nil)
+	require.Equal(t, http.StatusCreated, code)
+
+	code, _ = placeOrderAt(t, appRouter, map[string]any{"customer_id": "CUST31101", "product_id": teaID, "quantity": 6})
+	require.Equal(t, http.StatusCreated, code)
+
+	t.Run("Drafts One Order Per Supplier", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/purchase-orders/reorder", nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var suggestion usecases.ReorderSuggestion
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &suggestion))
+
+		assert.Equal(t, []string{mugID}, suggestion.WithoutSupplier)
+		require.Len(t, suggestion.PurchaseOrders, 1)
+		purchaseOrder := suggestion.PurchaseOrders[0]
+		assert.Equal(t, supplierID, purchaseOrder.SupplierID)
+		assert.Equal(t, entities.PurchaseOrderStatusDraft, purchaseOrder.Status)
+		require.Len(t, purchaseOrder.Lines, 1, "spoons are well stocked")
+
+		// 2 on hand against a reorder point of 10, plus 6 sold in 30 days
+		// over a 10 day lead time, rounded up to 2, plus one to clear the point
+		line := purchaseOrder.Lines[0]
+		assert.Equal(t, teaID, line.ProductID)
+		assert.Equal(t, 11, line.QuantityOrdered)
+		assert.Equal(t, money("2.50"), line.UnitCost)
+	})
+
+	t.Run("Drafts Count As On Order", func(t *testing.T) {
+		suggestion, err := diContainer.GetPurchaseOrderUseCase().SuggestReorders(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, suggestion.PurchaseOrders)
+		assert.Equal(t, []string{mugID}, suggestion.WithoutSupplier)
+	})
+}
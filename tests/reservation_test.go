@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockReservations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Headphones",
+		"price":        "80.00",
+		"quantity":     10,
+	})
+	customer := &entities.Customer{ID: "CUST30801", Name: "Yara", Email: "yara@example.com", Phone: "+1000000011"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+	other := &entities.Customer{ID: "CUST30802", Name: "Omar", Email: "omar@example.com", Phone: "+1000000012"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, other))
+
+	reserve := func(t *testing.T, body map[string]any) *entities.StockReservation {
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/reservations", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var reservation entities.StockReservation
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reservation))
+		return &reservation
+	}
+	stock := func(t *testing.T) *entities.Product {
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		return product
+	}
+
+	t.Run("Hold Reduces Available Stock", func(t *testing.T) {
+		reservation := reserve(t, map[string]any{"customer_id": customer.ID, "quantity": 4})
+		assert.Equal(t, entities.ReservationStatusActive, reservation.Status)
+		assert.WithinDuration(t, time.Now().Add(entities.DefaultReservationTTL), reservation.ExpiresAt, time.Minute)
+
+		product := stock(t)
+		assert.Equal(t, 10, product.Quantity)
+		assert.Equal(t, 4, product.Reserved)
+		assert.Equal(t, 6, product.Available())
+
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+productID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response httpHandlers.ProductResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 4, response.Reserved)
+		assert.Equal(t, 6, response.Available)
+
+		// Held stock cannot be held twice
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+productID+"/reservations", map[string]any{"quantity": 7})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Orders Without A Hold Cannot Take Held Stock", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order", map[string]any{
+			"customer_id": other.ID,
+			"product_id":  productID,
+			"quantity":    7,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"available_quantity":6`)
+		assert.Equal(t, 10, stock(t).Quantity)
+	})
+
+	t.Run("Release Makes Stock Available Again", func(t *testing.T) {
+		reservation := reserve(t, map[string]any{"quantity": 3})
+		assert.Equal(t, 3, stock(t).Available())
+
+		w := doJSON(appRouter, "POST", "/api/v1/reservation/"+reservation.ID+"/release", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 6, stock(t).Available())
+
+		w = doJSON(appRouter, "POST", "/api/v1/reservation/"+reservation.ID+"/release", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Order Confirms Its Holds", func(t *testing.T) {
+		reservations, err := diContainer.GetReservationUseCase().GetProductReservations(ctx, productID, entities.ReservationStatusActive)
+		require.NoError(t, err)
+		require.Len(t, reservations, 1)
+		held := reservations[0]
+
+		// Someone else's hold cannot be used
+		w := doJSON(appRouter, "POST", "/api/v1/order", map[string]any{
+			"customer_id":     other.ID,
+			"reservation_ids": []string{held.ID},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/order", map[string]any{
+			"customer_id":     customer.ID,
+			"reservation_ids": []string{held.ID},
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var order httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+
+		product := stock(t)
+		assert.Equal(t, 6, product.Quantity)
+		assert.Equal(t, 0, product.Reserved)
+
+		confirmed, err := diContainer.GetReservationUseCase().GetReservation(ctx, held.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entities.ReservationStatusConfirmed, confirmed.Status)
+		assert.Equal(t, order.ID, confirmed.OrderID)
+
+		// A confirmed hold cannot be used again
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+		w = doJSON(appRouter, "POST", "/api/v1/order", map[string]any{
+			"customer_id":     customer.ID,
+			"reservation_ids": []string{held.ID},
+		})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	})
+
+	t.Run("Expired Holds Are Swept", func(t *testing.T) {
+		reservation, err := entities.NewStockReservation("RSV-STALE", productID, "", 5, time.Minute)
+		require.NoError(t, err)
+		reservation.ExpiresAt = time.Now().UTC().Add(-time.Second)
+		require.NoError(t, diContainer.GetProductRepository().HoldQuantity(ctx, productID, 5))
+		require.NoError(t, diContainer.GetReservationRepository().Create(ctx, reservation))
+		assert.Equal(t, 1, stock(t).Available())
+
+		// Holding more than is available sweeps stale holds before giving up
+		reserve(t, map[string]any{"quantity": 2})
+
+		expired, err := diContainer.GetReservationUseCase().GetReservation(ctx, reservation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entities.ReservationStatusExpired, expired.Status)
+		assert.Equal(t, 4, stock(t).Available())
+
+		count, err := diContainer.GetReservationUseCase().ExpireStale(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		w := doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/reservations?status=expired", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response httpHandlers.ReservationListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Count)
+
+		w = doJSON(appRouter, "GET", "/api/v1/product/"+productID+"/reservations?status=unknown", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Stock Cannot Drop Below What Is Held", func(t *testing.T) {
+		held := stock(t)
+		require.Positive(t, held.Reserved)
+
+		w := updateProduct(t, appRouter, productID, map[string]any{"quantity": held.Reserved - 1, "reason": "adjustment"})
+		assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.EqualValues(t, held.Available(), body["available_quantity"])
+		assert.EqualValues(t, held.Available()+1, body["requested_quantity"])
+		assert.Equal(t, held.Quantity, stock(t).Quantity)
+
+		// The database refuses it too
+		err := diContainer.GetDatabase().GetDB().Exec("UPDATE products SET quantity = ? WHERE id = ?", held.Reserved-1, productID).Error
+		assert.Error(t, err)
+
+		w = updateProduct(t, appRouter, productID, map[string]any{"quantity": held.Reserved, "reason": "stocktake"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Zero(t, stock(t).Available())
+	})
+}
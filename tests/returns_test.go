@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// confirmOrder confirms a placed order; only confirmed and completed orders can be returned
+func confirmOrder(t *testing.T, appRouter http.Handler, orderID string) {
+	w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/confirm", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestReturnsAndNetRevenue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+	productRepo := diContainer.GetProductRepository()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Running Shoe",
+		Price:       money("50"),
+		Quantity:    10,
+	})
+	customer := &entities.Customer{ID: "CUST30201", Name: "Hana", Email: "hana@example.com", Phone: "+1000000004"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	w := doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+		CustomerID: customer.ID,
+		ProductID:  productID,
+		Quantity:   4,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var order httpHandlers.OrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	confirmOrder(t, appRouter, order.ID)
+
+	requestReturn := func(quantity int) (*entities.OrderReturn, int) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/returns", usecases.CreateReturnRequest{
+			Quantity: quantity,
+			Reason:   "does not fit",
+		})
+		var orderReturn entities.OrderReturn
+		_ = json.Unmarshal(w.Body.Bytes(), &orderReturn)
+		return &orderReturn, w.Code
+	}
+
+	stock := func() int {
+		product, err := productRepo.GetByID(ctx, productID)
+		require.NoError(t, err)
+		return product.Quantity
+	}
+
+	t.Run("Approve With Restock", func(t *testing.T) {
+		orderReturn, code := requestReturn(1)
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, entities.ReturnStatusRequested, orderReturn.Status)
+		assert.Equal(t, money("50.00"), orderReturn.RefundAmount)
+
+		w := doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", usecases.ResolveReturnRequest{
+			Disposition: entities.ReturnDispositionRestock,
+			Actor:       "store-manager",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var approved entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &approved))
+		assert.Equal(t, entities.ReturnStatusApproved, approved.Status)
+		assert.NotEmpty(t, approved.RefundTransactionID)
+		assert.Equal(t, 7, stock())
+
+		// Approving twice is rejected
+		w = doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", usecases.ResolveReturnRequest{
+			Disposition: entities.ReturnDispositionRestock,
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Quantity Is Capped By The Order", func(t *testing.T) {
+		_, code := requestReturn(4)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Approve With Write Off", func(t *testing.T) {
+		orderReturn, code := requestReturn(2)
+		require.Equal(t, http.StatusCreated, code)
+
+		w := doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", usecases.ResolveReturnRequest{
+			Disposition: entities.ReturnDispositionWriteOff,
+			Note:        "sole damaged",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 7, stock())
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		orderReturn, code := requestReturn(1)
+		require.Equal(t, http.StatusCreated, code)
+
+		w := doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/reject", usecases.ResolveReturnRequest{
+			Note: "worn outdoors",
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 7, stock())
+
+		// A rejected return frees its quantity for a new request
+		_, code = requestReturn(1)
+		assert.Equal(t, http.StatusCreated, code)
+	})
+
+	t.Run("List Returns", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+order.ID+"/returns", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response httpHandlers.ReturnListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 4, response.Count)
+
+		w = doJSON(appRouter, "GET", "/api/v1/returns?status=approved", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Count)
+	})
+
+	t.Run("Stats Report Net Revenue", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/transactions/stats", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var stats entities.BusinessStats
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		assert.Equal(t, money("200"), stats.GrossRevenue)
+		assert.Equal(t, money("150"), stats.RefundedAmount)
+		assert.Equal(t, money("50"), stats.TotalRevenue)
+		assert.Equal(t, 1, stats.OrderCount)
+		assert.Equal(t, 2, stats.RefundCount)
+		assert.Equal(t, 1, stats.TotalQuantitySold)
+
+		growth, err := diContainer.GetTransactionRepository().GetRevenueGrowth(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, money("50.00"), growth["current_month_revenue"])
+
+		daily, err := diContainer.GetTransactionRepository().GetDailyRevenue(ctx, 7)
+		require.NoError(t, err)
+		require.Len(t, daily, 1)
+		assert.Equal(t, money("50.00"), daily[0]["revenue"])
+	})
+}
+
+func TestReturnsAndCancellation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+	})
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Trail Shoe",
+		Price:       money("50"),
+		Quantity:    10,
+	})
+	customer := &entities.Customer{ID: "CUST30202", Name: "Ines", Email: "ines@example.com", Phone: "+1000000040"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	placeOrder := func() string {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customer.ID, "product_id": productID, "quantity": 4,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		return order.ID
+	}
+
+	requestReturn := func(orderID string, quantity int) (string, int) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/returns", usecases.CreateReturnRequest{Quantity: quantity})
+		var orderReturn entities.OrderReturn
+		_ = json.Unmarshal(w.Body.Bytes(), &orderReturn)
+		return orderReturn.ID, w.Code
+	}
+
+	resolveReturn := func(returnID, action string) {
+		w := doJSON(appRouter, "POST", "/api/v1/return/"+returnID+"/"+action, usecases.ResolveReturnRequest{
+			Disposition: entities.ReturnDispositionRestock,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	stock := func() int {
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		return product.Quantity
+	}
+
+	t.Run("Pending Orders Are Cancelled, Not Returned", func(t *testing.T) {
+		orderID := placeOrder()
+		_, code := requestReturn(orderID, 1)
+		assert.Equal(t, http.StatusConflict, code)
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		_, code = requestReturn(orderID, 1)
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, 10, stock())
+	})
+
+	t.Run("Returned Orders Cannot Be Cancelled", func(t *testing.T) {
+		orderID := placeOrder()
+		confirmOrder(t, appRouter, orderID)
+
+		returnID, code := requestReturn(orderID, 2)
+		require.Equal(t, http.StatusCreated, code)
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", nil)
+		assert.Equal(t, http.StatusConflict, w.Code, "a requested return blocks cancellation")
+
+		resolveReturn(returnID, "approve")
+		assert.Equal(t, 8, stock())
+
+		w = doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", nil)
+		assert.Equal(t, http.StatusConflict, w.Code, "an approved return blocks cancellation")
+		assert.Equal(t, 8, stock(), "the returned units are restocked once")
+
+		stats, err := diContainer.GetTransactionRepository().GetBusinessStats(ctx, nil, nil)
+		require.NoError(t, err)
+		// The cancelled order above nets to zero; this one keeps 2 of its 4 units
+		assert.Equal(t, money("400"), stats.GrossRevenue)
+		assert.Equal(t, money("300"), stats.RefundedAmount)
+		assert.Equal(t, money("100"), stats.TotalRevenue)
+	})
+
+	t.Run("Rejected Returns Do Not Block Cancellation", func(t *testing.T) {
+		orderID := placeOrder()
+		confirmOrder(t, appRouter, orderID)
+
+		returnID, code := requestReturn(orderID, 1)
+		require.Equal(t, http.StatusCreated, code)
+		resolveReturn(returnID, "reject")
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, 8, stock())
+	})
+}
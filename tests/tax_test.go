@@ -0,0 +1,326 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"day5/internal/config"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTaxRate publishes a tax rate made of the given components, name then rate
+func createTaxRate(t *testing.T, appRouter http.Handler, jurisdiction, taxClass string, components ...string) entities.TaxRate {
+	body := map[string]any{"jurisdiction": jurisdiction, "tax_class": taxClass}
+	var parts []map[string]string
+	for i := 0; i+1 < len(components); i += 2 {
+		parts = append(parts, map[string]string{"name": components[i], "rate": components[i+1]})
+	}
+	body["components"] = parts
+
+	w := doJSON(appRouter, "POST", "/api/v1/tax-rate", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var rate entities.TaxRate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rate))
+	return rate
+}
+
+// taxLiability fetches the tax liability report for the given dates
+func taxLiability(t *testing.T, appRouter http.Handler, start, end string) httpHandlers.TaxLiabilityResponse {
+	w := doJSON(appRouter, "GET", "/api/v1/transactions/tax-liability?start_date="+start+"&end_date="+end, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response httpHandlers.TaxLiabilityResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestTaxInclusivePrices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+		cfg.Business.TaxJurisdiction = "IN-KA"
+		cfg.Business.PricesIncludeTax = true
+	})
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	// GST is split into central and state halves within a state, and charged
+	// as integrated GST to customers in another state
+	createTaxRate(t, appRouter, "IN-KA", "gst_18", "CGST", "9", "SGST", "9")
+	createTaxRate(t, appRouter, "in-tn", "GST_18", "IGST", "18")
+
+	kettleID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Kettle", "price": "118.00", "quantity": 20, "tax_class": "GST_18",
+	})
+	toasterID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Toaster", "price": "99.99", "quantity": 20, "tax_class": "gst_18",
+	})
+	riceID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Rice", "price": "50.00", "quantity": 20,
+	})
+	phoneID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Phone", "price": "300.00", "quantity": 20, "tax_class": "gst_12",
+	})
+	localID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Anil", "email": "anil@example.com", "phone": "+1000000035",
+	})
+	remoteID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Meena", "email": "meena@example.com", "phone": "+1000000036", "tax_jurisdiction": "in-tn",
+	})
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	var localOrder httpHandlers.OrderResponse
+
+	t.Run("Rates Classes And Jurisdictions Are Validated", func(t *testing.T) {
+		for _, body := range []map[string]any{
+			{"jurisdiction": "IN-KA", "tax_class": "gst_5"},
+			{"jurisdiction": "IN-KA", "tax_class": "gst_5", "components": []map[string]string{}},
+			{"jurisdiction": "India", "tax_class": "gst_5", "components": []map[string]string{{"name": "GST", "rate": "5"}}},
+			{"jurisdiction": "IN-KA", "tax_class": "gst 5", "components": []map[string]string{{"name": "GST", "rate": "5"}}},
+			{"jurisdiction": "IN-KA", "tax_class": "gst_5", "components": []map[string]string{{"name": "GST", "rate": "100"}}},
+			{"jurisdiction": "IN-KA", "tax_class": "gst_5", "components": []map[string]string{{"name": "GST", "rate": "-5"}}},
+			{"jurisdiction": "IN-KA", "tax_class": "gst_5", "components": []map[string]string{{"name": "", "rate": "5"}}},
+			{"jurisdiction": "IN-KA", "tax_class": "gst_5", "components": []map[string]string{
+				{"name": "CGST", "rate": "2.5"}, {"name": "CGST", "rate": "2.5"},
+			}},
+		} {
+			w := doJSON(appRouter, "POST", "/api/v1/tax-rate", body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%v: %s", body, w.Body.String())
+		}
+
+		// A second rate taking effect at the same instant would be ambiguous
+		from := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+		body := map[string]any{
+			"jurisdiction": "IN-KA", "tax_class": "gst_28", "effective_from": from,
+			"components": []map[string]string{{"name": "CGST", "rate": "14"}, {"name": "SGST", "rate": "14"}},
+		}
+		w := doJSON(appRouter, "POST", "/api/v1/tax-rate", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = doJSON(appRouter, "POST", "/api/v1/tax-rate", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Mystery", "price": "1.00", "quantity": 1, "tax_class": "no spaces please",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		w = doJSON(appRouter, "POST", "/api/v1/customer", map[string]any{
+			"name": "Nowhere", "email": "nowhere@example.com", "phone": "+1000000037", "tax_jurisdiction": "Karnataka",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Rates Are Listed Per Jurisdiction And Class", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/tax-rates?jurisdiction=in-ka", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response httpHandlers.TaxRateListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Equal(t, 2, response.Count)
+		assert.Equal(t, "gst_18", response.TaxRates[0].TaxClass)
+		assert.Equal(t, "18", response.TaxRates[0].Rate.String(), "the rate is the sum of its components")
+		require.Len(t, response.TaxRates[0].Components, 2)
+		assert.Equal(t, "CGST", response.TaxRates[0].Components[0].Name)
+		assert.Equal(t, "gst_28", response.TaxRates[1].TaxClass)
+
+		w = doJSON(appRouter, "GET", "/api/v1/tax-rates?tax_class=gst_18", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Equal(t, 2, response.Count)
+		assert.Equal(t, "IN-TN", response.TaxRates[1].Jurisdiction)
+	})
+
+	t.Run("Inclusive Prices Are Split Into Tax Per Line", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": localID,
+			"items": []map[string]any{
+				{"product_id": kettleID, "quantity": 2},
+				{"product_id": riceID, "quantity": 1},
+			},
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, money("286.00"), order.TotalAmount, "tax is already in the price")
+		assert.Equal(t, money("36.00"), order.Tax)
+		assert.Equal(t, "IN-KA", order.TaxJurisdiction, "the business's jurisdiction by default")
+
+		w := doJSON(appRouter, "GET", "/api/v1/order/"+order.ID, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		require.Len(t, order.Lines, 2)
+		kettle := order.Lines[0]
+		assert.Equal(t, "gst_18", kettle.TaxClass)
+		assert.True(t, kettle.TaxInclusive)
+		assert.Equal(t, money("236.00"), kettle.LineTotal)
+		assert.Equal(t, money("200.00"), kettle.TaxableAmount)
+		assert.Equal(t, money("36.00"), kettle.Tax)
+		require.Len(t, kettle.Taxes, 2)
+		assert.Equal(t, "CGST", kettle.Taxes[0].Name)
+		assert.Equal(t, money("18.00"), kettle.Taxes[0].Amount)
+		assert.Equal(t, "SGST", kettle.Taxes[1].Name)
+		assert.Equal(t, money("18.00"), kettle.Taxes[1].Amount)
+
+		rice := order.Lines[1]
+		assert.Empty(t, rice.TaxClass, "products without a tax class are not taxed")
+		assert.True(t, rice.Tax.IsZero())
+		assert.Empty(t, rice.Taxes)
+		assert.Equal(t, money("50.00"), rice.TaxableAmount)
+		localOrder = order
+	})
+
+	t.Run("Tax Is Rounded Once And Split Without Losing A Paisa", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": localID, "product_id": toasterID, "quantity": 1,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		require.Len(t, order.Lines, 1)
+		line := order.Lines[0]
+		// 99.99 × 18/118 = 15.2527...
+		assert.Equal(t, money("15.25"), line.Tax)
+		assert.Equal(t, money("84.74"), line.TaxableAmount)
+		require.Len(t, line.Taxes, 2)
+		assert.Equal(t, money("7.62"), line.Taxes[0].Amount)
+		assert.Equal(t, money("7.63"), line.Taxes[1].Amount)
+	})
+
+	t.Run("Customers Elsewhere Are Taxed Under Their Own Rates", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": remoteID, "product_id": kettleID, "quantity": 1,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "IN-TN", order.TaxJurisdiction)
+		assert.Equal(t, money("118.00"), order.TotalAmount)
+		assert.Equal(t, money("18.00"), order.Tax)
+		require.Len(t, order.Lines[0].Taxes, 1)
+		assert.Equal(t, "IGST", order.Lines[0].Taxes[0].Name)
+		assert.Equal(t, money("18.00"), order.Lines[0].Taxes[0].Amount)
+	})
+
+	t.Run("Taxed Products Without A Rate Cannot Be Sold", func(t *testing.T) {
+		code, _ := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": localID, "product_id": phoneID, "quantity": 1,
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		// gst_28 is scheduled for tomorrow
+		w := updateProduct(t, appRouter, phoneID, map[string]any{"tax_class": "gst_28"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "gst_28", getProduct(t, appRouter, phoneID).TaxClass)
+		code, _ = placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": localID, "product_id": phoneID, "quantity": 1,
+		})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Refunds Give The Tax Back", func(t *testing.T) {
+		confirmOrder(t, appRouter, localOrder.ID)
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+localOrder.ID+"/returns", map[string]any{
+			"product_id": kettleID, "quantity": 1,
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var orderReturn entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orderReturn))
+		assert.Equal(t, money("118.00"), orderReturn.RefundAmount)
+
+		w = doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", map[string]any{"disposition": "restock"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "GET", "/api/v1/transactions?type=refund", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var history httpHandlers.TransactionHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Equal(t, 1, history.Count)
+		refund := history.Transactions[0]
+		assert.Equal(t, money("118.00"), refund.Amount)
+		assert.Equal(t, money("18.00"), refund.Tax)
+		assert.Equal(t, "gst_18", refund.TaxClass)
+		assert.Equal(t, "IN-KA", refund.TaxJurisdiction)
+	})
+
+	t.Run("Tax Liability Is Reported Per Jurisdiction And Rate", func(t *testing.T) {
+		report := taxLiability(t, appRouter, today, today)
+		require.Equal(t, 2, report.Count, "untaxed sales are left out")
+
+		local := report.Liabilities[0]
+		assert.Equal(t, "IN-KA", local.Jurisdiction)
+		assert.Equal(t, "gst_18", local.TaxClass)
+		assert.Equal(t, "18", local.Rate.String())
+		assert.Equal(t, money("284.74"), local.TaxableSales)
+		assert.Equal(t, money("100.00"), local.TaxableRefunds)
+		assert.Equal(t, money("51.25"), local.TaxCollected)
+		assert.Equal(t, money("18.00"), local.TaxRefunded)
+		assert.Equal(t, money("33.25"), local.TaxDue)
+		assert.Equal(t, 3, local.TransactionCount)
+
+		remote := report.Liabilities[1]
+		assert.Equal(t, "IN-TN", remote.Jurisdiction)
+		assert.Equal(t, money("100.00"), remote.TaxableSales)
+		assert.Equal(t, money("18.00"), remote.TaxDue)
+
+		assert.Equal(t, money("51.25"), report.TotalTaxDue)
+
+		yesterday := time.Now().UTC().Add(-24 * time.Hour).Format(time.DateOnly)
+		assert.Zero(t, taxLiability(t, appRouter, yesterday, yesterday).Count)
+
+		for _, query := range []string{
+			"", "?start_date=" + today, "?start_date=01/04/2024&end_date=" + today,
+			"?start_date=" + today + "&end_date=" + yesterday,
+		} {
+			w := doJSON(appRouter, "GET", "/api/v1/transactions/tax-liability"+query, nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%q: %s", query, w.Body.String())
+		}
+	})
+}
+
+func TestTaxAddedOnTop(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t, func(cfg *config.AppConfig) {
+		cfg.Business.CooldownPeriodMinutes = 0
+		cfg.Business.TaxJurisdiction = "IN-KA"
+	})
+	defer diContainer.Cleanup()
+
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	createTaxRate(t, appRouter, "IN-KA", "gst_18", "CGST", "9", "SGST", "9")
+	lampID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Lamp", "price": "100.00", "quantity": 20, "tax_class": "gst_18",
+	})
+	customerID := postJSON(t, appRouter, "/api/v1/customer", map[string]any{
+		"name": "Ravi", "email": "ravi@example.com", "phone": "+1000000038",
+	})
+	postJSON(t, appRouter, "/api/v1/promotion", map[string]any{
+		"name": "Lamp sale", "type": "percent_off", "discount_percent": "10", "product_id": lampID,
+	})
+
+	t.Run("Tax Is Added After Discounts", func(t *testing.T) {
+		code, order := placeOrderAt(t, appRouter, map[string]any{
+			"customer_id": customerID, "product_id": lampID, "quantity": 2,
+		})
+		require.Equal(t, http.StatusCreated, code)
+		require.Len(t, order.Lines, 1)
+		line := order.Lines[0]
+		assert.False(t, line.TaxInclusive)
+		assert.Equal(t, money("20.00"), line.Discount)
+		assert.Equal(t, money("180.00"), line.TaxableAmount)
+		assert.Equal(t, money("32.40"), line.Tax)
+		assert.Equal(t, money("212.40"), line.LineTotal)
+		assert.Equal(t, money("212.40"), order.TotalAmount)
+		assert.Equal(t, money("32.40"), order.Tax)
+
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		today := time.Now().UTC().Format(time.DateOnly)
+		report := taxLiability(t, appRouter, today, today)
+		require.Equal(t, 1, report.Count)
+		assert.Equal(t, money("32.40"), report.Liabilities[0].TaxCollected)
+		assert.Equal(t, money("32.40"), report.Liabilities[0].TaxRefunded, "cancelling gives all the tax back")
+		assert.True(t, report.TotalTaxDue.IsZero())
+	})
+}
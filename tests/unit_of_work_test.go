@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"day5/internal/domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitOfWorkRollback(t *testing.T) {
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	productRepo := diContainer.GetProductRepository()
+	customerRepo := diContainer.GetCustomerRepository()
+	orderRepo := diContainer.GetOrderRepository()
+
+	product := &entities.Product{ID: "PROD10001", ProductName: "Widget", Price: money("10"), Quantity: 5}
+	customer := &entities.Customer{ID: "CUST10001", Name: "Dana", Email: "dana@example.com", Phone: "+1000000000"}
+	require.NoError(t, productRepo.Create(ctx, product))
+	require.NoError(t, customerRepo.Create(ctx, customer))
+
+	total, err := product.Price.Mul(2)
+	require.NoError(t, err)
+
+	errBoom := errors.New("boom")
+	err = diContainer.GetUnitOfWork().Do(ctx, func(ctx context.Context) error {
+		order := &entities.Order{
+			ID:          "ORD10001",
+			CustomerID:  customer.ID,
+			ProductID:   product.ID,
+			Quantity:    2,
+			UnitPrice:   product.Price,
+			TotalAmount: total,
+			OrderDate:   time.Now().UTC(),
+		}
+		if err := orderRepo.Create(ctx, order); err != nil {
+			return err
+		}
+		if err := productRepo.ReduceQuantity(ctx, product.ID, 2); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	assert.ErrorIs(t, err, errBoom)
+
+	// Neither the order nor the stock change survives the rollback
+	_, err = orderRepo.GetByID(ctx, "ORD10001")
+	assert.Error(t, err)
+
+	stored, err := productRepo.GetByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, stored.Quantity)
+}
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getProduct fetches a product as the API returns it
+func getProduct(t *testing.T, appRouter http.Handler, productID string) httpHandlers.ProductResponse {
+	w := doJSON(appRouter, "GET", "/api/v1/product/"+productID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var product httpHandlers.ProductResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+	return product
+}
+
+func TestProductVariants(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	clothing := createCategory(t, appRouter, "Clothing", "")
+	hoodieID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Hoodie", "price": "40.00", "type": "parent",
+		"category_id": clothing.ID, "attributes": map[string]string{"material": "Cotton"},
+	})
+	redID := postJSON(t, appRouter, "/api/v1/product/"+hoodieID+"/variants", map[string]any{
+		"sku": "HD-RED-M", "quantity": 5, "attributes": map[string]string{"colour": "Red", "size": "M"},
+	})
+	blueID := postJSON(t, appRouter, "/api/v1/product/"+hoodieID+"/variants", map[string]any{
+		"sku": "HD-BLUE-L", "quantity": 3, "price": "45.00",
+		"attributes": map[string]string{"colour": "Blue", "size": "L"},
+	})
+	mugID := postJSON(t, appRouter, "/api/v1/product", map[string]any{
+		"product_name": "Mug", "price": "8.00", "quantity": 4,
+	})
+
+	t.Run("Variants Carry Their Own SKU, Price And Stock", func(t *testing.T) {
+		red := getProduct(t, appRouter, redID)
+		assert.Equal(t, entities.ProductTypeVariant, red.Type)
+		assert.Equal(t, hoodieID, red.ParentID)
+		assert.Equal(t, "Hoodie (Red, M)", red.ProductName)
+		assert.Equal(t, "HD-RED-M", red.SKU)
+		assert.Equal(t, money("40.00"), red.Price, "a variant follows its parent's price")
+		assert.False(t, red.PriceOverride)
+		assert.Equal(t, clothing.ID, red.CategoryID)
+		assert.Equal(t, 5, red.Quantity)
+
+		blue := getProduct(t, appRouter, blueID)
+		assert.Equal(t, money("45.00"), blue.Price)
+		assert.True(t, blue.PriceOverride)
+
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+hoodieID+"/variants", map[string]any{
+			"quantity": 1, "attributes": map[string]string{"Colour": "red", "size": "m"},
+		})
+		assert.Equal(t, http.StatusConflict, w.Code, "two variants cannot share attributes: %s", w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/product/"+mugID+"/variants", map[string]any{
+			"quantity": 1, "attributes": map[string]string{"colour": "White"},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", "/api/v1/product", map[string]any{
+			"product_name": "Scarf", "price": "15.00", "quantity": 2, "type": "parent",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code, "a parent holds no stock: %s", w.Body.String())
+	})
+
+	t.Run("Catalogue Lists Parents With Aggregated Availability", func(t *testing.T) {
+		w := doJSON(appRouter, "GET", "/api/v1/products", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response httpHandlers.ProductListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Products, 2, "variants are listed under their parent")
+
+		var hoodie *httpHandlers.ProductResponse
+		for _, product := range response.Products {
+			if product.ID == hoodieID {
+				hoodie = product
+			}
+		}
+		require.NotNil(t, hoodie)
+		assert.Equal(t, 8, hoodie.Quantity)
+		assert.Equal(t, 8, hoodie.Available)
+		require.Len(t, hoodie.Variants, 2)
+		assert.Equal(t, redID, hoodie.Variants[0].ID)
+		assert.Equal(t, blueID, hoodie.Variants[1].ID)
+
+		// Attributes match on the variant, or on the parent it shares them with
+		assert.Equal(t, []string{hoodieID}, listProducts(t, appRouter, "?attribute=colour:blue&attribute=size:L"))
+		assert.Equal(t, []string{hoodieID}, listProducts(t, appRouter, "?attribute=material:cotton&attribute=size:M"))
+		assert.Empty(t, listProducts(t, appRouter, "?attribute=colour:blue&attribute=size:M"))
+		assert.Equal(t, []string{hoodieID}, listProducts(t, appRouter, "?category_id="+clothing.ID))
+
+		// A SKU finds the variant itself
+		assert.Equal(t, []string{blueID}, listProducts(t, appRouter, "?sku=hd-blue-l"))
+
+		w = doJSON(appRouter, "GET", "/api/v1/product/"+hoodieID+"/variants", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Count)
+	})
+
+	t.Run("Orders Reference Variants", func(t *testing.T) {
+		customer := &entities.Customer{ID: "CUST31401", Name: "Ravi", Email: "ravi@example.com", Phone: "+1000000026"}
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+		code, _ := placeOrderAt(t, appRouter, map[string]any{"customer_id": customer.ID, "product_id": hoodieID, "quantity": 1})
+		assert.Equal(t, http.StatusBadRequest, code, "the parent itself cannot be ordered")
+
+		code, order := placeOrderAt(t, appRouter, map[string]any{"customer_id": customer.ID, "product_id": blueID, "quantity": 2})
+		require.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, blueID, order.ProductID)
+		assert.Equal(t, money("90.00"), order.TotalAmount)
+
+		hoodie := getProduct(t, appRouter, hoodieID)
+		assert.Equal(t, 6, hoodie.Available)
+
+		w := doJSON(appRouter, "POST", "/api/v1/product/"+hoodieID+"/reservations", map[string]any{"quantity": 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Parent Price And Category Carry Over", func(t *testing.T) {
+		outerwear := createCategory(t, appRouter, "Outerwear", clothing.ID)
+		w := updateProduct(t, appRouter, hoodieID, map[string]any{"price": "42.00", "category_id": outerwear.ID})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		red := getProduct(t, appRouter, redID)
+		assert.Equal(t, money("42.00"), red.Price)
+		assert.Equal(t, outerwear.ID, red.CategoryID)
+
+		blue := getProduct(t, appRouter, blueID)
+		assert.Equal(t, money("45.00"), blue.Price, "an overridden price is kept")
+		assert.Equal(t, outerwear.ID, blue.CategoryID)
+
+		w = updateProduct(t, appRouter, redID, map[string]any{"category_id": clothing.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		w = updateProduct(t, appRouter, hoodieID, map[string]any{"quantity": 3, "reason": "adjustment"})
+		assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+
+	t.Run("Archiving The Parent Stops Its Variants Selling", func(t *testing.T) {
+		w := updateProduct(t, appRouter, hoodieID, map[string]any{"status": "archived"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		customer := &entities.Customer{ID: "CUST31402", Name: "Mei", Email: "mei@example.com", Phone: "+1000000027"}
+		require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+		code, _ := placeOrderAt(t, appRouter, map[string]any{"customer_id": customer.ID, "product_id": redID, "quantity": 1})
+		assert.Equal(t, http.StatusConflict, code)
+	})
+}
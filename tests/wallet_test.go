@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"day5/internal/application/usecases"
+	"day5/internal/domain/entities"
+	httpHandlers "day5/internal/interfaces/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreCreditWallet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	diContainer := setupTestContainer(t)
+	defer diContainer.Cleanup()
+
+	ctx := context.Background()
+	appRouter := httpHandlers.NewRouter(diContainer).SetupRoutes()
+
+	productID := postJSON(t, appRouter, "/api/v1/product", usecases.CreateProductRequest{
+		ProductName: "Desk Lamp",
+		Price:       money("30"),
+		Quantity:    20,
+	})
+	customer := &entities.Customer{ID: "CUST30301", Name: "Ines", Email: "ines@example.com", Phone: "+1000000005"}
+	require.NoError(t, diContainer.GetCustomerRepository().Create(ctx, customer))
+
+	walletPath := "/api/v1/customer/" + customer.ID + "/wallet"
+
+	getWallet := func() *entities.WalletStatement {
+		w := doJSON(appRouter, "GET", walletPath, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var statement entities.WalletStatement
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &statement))
+		return &statement
+	}
+
+	placeOrder := func(quantity int, credit string) *httptest.ResponseRecorder {
+		return doJSON(appRouter, "POST", "/api/v1/order", usecases.PlaceOrderRequest{
+			CustomerID:  customer.ID,
+			ProductID:   productID,
+			Quantity:    quantity,
+			StoreCredit: money(credit),
+		})
+	}
+
+	clearCooldown := func() {
+		require.NoError(t, diContainer.GetCustomerUseCase().ClearCustomerCooldown(ctx, customer.ID))
+	}
+
+	t.Run("Empty Wallet", func(t *testing.T) {
+		statement := getWallet()
+		assert.True(t, statement.Balance.IsZero())
+		assert.Empty(t, statement.Entries)
+
+		w := doJSON(appRouter, "GET", "/api/v1/customer/CUST99999/wallet", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Issue Goodwill Credit", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", walletPath+"/credit", usecases.IssueCreditRequest{
+			Amount: money("50"),
+			Reason: "late delivery",
+		})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		w = doJSON(appRouter, "POST", walletPath+"/credit", usecases.IssueCreditRequest{Amount: money("-5"), Reason: "bad"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		assert.Equal(t, money("50.00"), getWallet().Balance)
+	})
+
+	var orderID string
+	t.Run("Spend Credit On An Order", func(t *testing.T) {
+		w := placeOrder(1, "20")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var order httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		orderID = order.ID
+		assert.Equal(t, money("20.00"), order.StoreCreditApplied)
+		assert.Equal(t, money("10.00"), order.AmountDue)
+
+		statement := getWallet()
+		assert.Equal(t, money("30.00"), statement.Balance)
+		assert.Equal(t, money("50.00"), statement.TotalIssued)
+		assert.Equal(t, money("20.00"), statement.TotalSpent)
+		require.Len(t, statement.Entries, 2)
+		assert.Equal(t, money("-20.00"), statement.Entries[1].Amount)
+		assert.Equal(t, money("30.00"), statement.Entries[1].RunningBalance)
+		assert.Equal(t, orderID, statement.Entries[1].OrderID)
+	})
+
+	t.Run("Overspending Is Rejected", func(t *testing.T) {
+		clearCooldown()
+		// The request is capped at the 60.00 total, which is still more than the wallet holds
+		w := placeOrder(2, "1000")
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "available_store_credit")
+
+		// Nothing was written: stock and balance are unchanged
+		product, err := diContainer.GetProductRepository().GetByID(ctx, productID)
+		require.NoError(t, err)
+		assert.Equal(t, 19, product.Quantity)
+		assert.Equal(t, money("30.00"), getWallet().Balance)
+	})
+
+	t.Run("Cancel Returns The Credit", func(t *testing.T) {
+		w := doJSON(appRouter, "POST", "/api/v1/order/"+orderID+"/cancel", nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		statement := getWallet()
+		assert.Equal(t, money("50.00"), statement.Balance)
+		assert.Len(t, statement.Entries, 3)
+	})
+
+	t.Run("Return Refunded To Store Credit", func(t *testing.T) {
+		clearCooldown()
+		w := placeOrder(2, "0")
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var order httpHandlers.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+		assert.True(t, order.StoreCreditApplied.IsZero())
+		assert.Equal(t, money("60.00"), order.AmountDue)
+		confirmOrder(t, appRouter, order.ID)
+
+		w = doJSON(appRouter, "POST", "/api/v1/order/"+order.ID+"/returns", usecases.CreateReturnRequest{Quantity: 1})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var orderReturn entities.OrderReturn
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &orderReturn))
+
+		w = doJSON(appRouter, "POST", "/api/v1/return/"+orderReturn.ID+"/approve", usecases.ResolveReturnRequest{
+			Disposition:         entities.ReturnDispositionRestock,
+			RefundToStoreCredit: true,
+		})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, money("80.00"), getWallet().Balance)
+	})
+
+	t.Run("Credit Entries Do Not Count As Revenue", func(t *testing.T) {
+		stats, err := diContainer.GetTransactionRepository().GetBusinessStats(ctx, nil, nil)
+		require.NoError(t, err)
+		// Cancelled order 30 - 30, kept order 60 - 30 refunded for the return
+		assert.Equal(t, money("30.00"), stats.TotalRevenue)
+	})
+}